@@ -8,28 +8,51 @@ import (
 
 	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/ui"
+	"myproxy.com/p/internal/urlscheme"
 )
 
 func main() {
-	if err := initDatabase(); err != nil {
+	workDir, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("获取工作目录失败: %v", err)
+	}
+	dataDir := filepath.Join(workDir, "data")
+
+	// 通过 myproxy:// 等链接启动时，系统把链接作为参数传入；
+	// 已有实例在运行时把链接交给它后直接退出，不打开数据库
+	deepLink := urlscheme.FindInArgs(os.Args[1:])
+	if deepLink != "" && urlscheme.Forward(dataDir, deepLink) == nil {
+		return
+	}
+
+	if err := initDatabase(dataDir); err != nil {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 	defer database.CloseDB()
 
 	appState := ui.NewAppState()
+	appState.SafeMode = hasArg(os.Args[1:], "--safe-mode")
 	if err := appState.Startup(); err != nil {
 		log.Fatalf("应用启动失败: %v", err)
 	}
+	if deepLink != "" {
+		appState.HandleDeepLink(deepLink)
+	}
 	appState.Run()
 }
 
-func initDatabase() error {
-	workDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("获取工作目录失败: %w", err)
+// hasArg 判断命令行参数中是否包含指定开关（链接参数与开关混用，不使用 flag 包解析）。
+func hasArg(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == name {
+			return true
+		}
 	}
+	return false
+}
 
-	dbPath := filepath.Join(workDir, "data", "myproxy.db")
+func initDatabase(dataDir string) error {
+	dbPath := filepath.Join(dataDir, "myproxy.db")
 	if err := database.InitDB(dbPath); err != nil {
 		return fmt.Errorf("初始化数据库失败: %w", err)
 	}
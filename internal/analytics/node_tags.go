@@ -0,0 +1,168 @@
+// Package analytics 根据已保存的测速历史推断节点特征，不访问数据库与网络。
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// TagWindow 推断标签使用的测速历史时长
+const TagWindow = 7 * 24 * time.Hour
+
+const (
+	// tagMinSamples 总测速次数少于该值时不推断标签，避免偶发失败或个别慢结果误标
+	tagMinSamples = 8
+	// tagMinPeriodSamples 比较晚间与其他时段时，每个时段至少需要的次数
+	tagMinPeriodSamples = 3
+
+	// highLossRate 失败率达到该值标记「高丢包」
+	highLossRate = 0.2
+	// jitterRatio / jitterMinStdDev 延迟标准差与均值之比、标准差绝对值同时达到时标记「延迟波动大」
+	jitterRatio     = 0.5
+	jitterMinStdDev = 50.0
+	// eveningSlowRatio / eveningSlowMinDiff 晚间延迟中位数为其他时段的倍数、差值同时达到时标记「晚间慢」
+	eveningSlowRatio   = 1.5
+	eveningSlowMinDiff = 50
+	// eveningLossRate 晚间失败率达到该值且明显高于其他时段时标记「晚间不稳」
+	eveningLossRate = 0.3
+
+	// eveningStartHour / eveningEndHour 晚间高峰时段（本地时间，左闭右开）
+	eveningStartHour = 19
+	eveningEndHour   = 24
+)
+
+// 标签名称
+const (
+	TagHighLoss        = "高丢包"
+	TagJitter          = "延迟波动大"
+	TagEveningSlow     = "晚间慢"
+	TagEveningUnstable = "晚间不稳"
+)
+
+// NodeTag 由测速历史推断出的节点特征。
+type NodeTag struct {
+	Label  string // 简短名称，显示为节点名后的标记
+	Detail string // 推断依据，如「近 7 天失败 7/20 次」
+}
+
+// periodStats 一组测速结果的统计。
+type periodStats struct {
+	total  int
+	failed int
+	delays []int // 成功的延迟，升序
+}
+
+func newPeriodStats(samples []model.DelaySample) periodStats {
+	var s periodStats
+	for _, sample := range samples {
+		s.total++
+		if sample.Delay <= 0 {
+			s.failed++
+			continue
+		}
+		s.delays = append(s.delays, sample.Delay)
+	}
+	sort.Ints(s.delays)
+	return s
+}
+
+func (s periodStats) lossRate() float64 {
+	if s.total == 0 {
+		return 0
+	}
+	return float64(s.failed) / float64(s.total)
+}
+
+func (s periodStats) median() int {
+	n := len(s.delays)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return s.delays[n/2]
+	}
+	return (s.delays[n/2-1] + s.delays[n/2]) / 2
+}
+
+// meanStdDev 返回成功延迟的均值与标准差。
+func (s periodStats) meanStdDev() (float64, float64) {
+	n := float64(len(s.delays))
+	if n == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, d := range s.delays {
+		sum += float64(d)
+	}
+	mean := sum / n
+	var sq float64
+	for _, d := range s.delays {
+		sq += (float64(d) - mean) * (float64(d) - mean)
+	}
+	return mean, math.Sqrt(sq / n)
+}
+
+// isEvening 测速时间是否在本地时间的晚间高峰时段。
+func isEvening(t time.Time) bool {
+	h := t.Local().Hour()
+	return h >= eveningStartHour && h < eveningEndHour
+}
+
+// NodeTags 根据一个节点的测速历史（调用方按 TagWindow 截取）推断特征标签；次数不足时返回 nil。
+func NodeTags(samples []model.DelaySample) []NodeTag {
+	if len(samples) < tagMinSamples {
+		return nil
+	}
+	var evening, other []model.DelaySample
+	for _, s := range samples {
+		if isEvening(s.TestedAt) {
+			evening = append(evening, s)
+		} else {
+			other = append(other, s)
+		}
+	}
+	all, ev, ot := newPeriodStats(samples), newPeriodStats(evening), newPeriodStats(other)
+
+	var tags []NodeTag
+	highLoss := all.lossRate() >= highLossRate
+	if highLoss {
+		tags = append(tags, NodeTag{
+			Label:  TagHighLoss,
+			Detail: fmt.Sprintf("近 7 天测速失败 %d/%d 次", all.failed, all.total),
+		})
+	}
+	eveningSlow := false
+	if len(ev.delays) >= tagMinPeriodSamples && len(ot.delays) >= tagMinPeriodSamples {
+		em, om := ev.median(), ot.median()
+		if float64(em) >= float64(om)*eveningSlowRatio && em-om >= eveningSlowMinDiff {
+			eveningSlow = true
+			tags = append(tags, NodeTag{
+				Label:  TagEveningSlow,
+				Detail: fmt.Sprintf("晚间延迟中位数 %d ms，其他时段 %d ms", em, om),
+			})
+		}
+	}
+	// 晚间变慢本身会拉大整体波动，已标记时不再重复标记
+	if !eveningSlow && len(all.delays) >= tagMinSamples {
+		if mean, sd := all.meanStdDev(); sd >= jitterMinStdDev && sd >= mean*jitterRatio {
+			tags = append(tags, NodeTag{
+				Label:  TagJitter,
+				Detail: fmt.Sprintf("平均 %.0f ms，标准差 %.0f ms", mean, sd),
+			})
+		}
+	}
+	// 整体已是高丢包时不再单独标记晚间
+	if !highLoss && ev.total >= tagMinPeriodSamples && ot.total >= tagMinPeriodSamples {
+		if el := ev.lossRate(); el >= eveningLossRate && el >= 2*ot.lossRate() {
+			tags = append(tags, NodeTag{
+				Label:  TagEveningUnstable,
+				Detail: fmt.Sprintf("晚间测速失败 %d/%d 次，其他时段 %d/%d 次", ev.failed, ev.total, ot.failed, ot.total),
+			})
+		}
+	}
+	return tags
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// TrendDays 服务商趋势统计的天数（测速历史最多保留 14 天）
+const TrendDays = 14
+
+// DayHealth 一天内一组节点的测速汇总。
+type DayHealth struct {
+	Day      time.Time // 当天零点（本地时间）
+	Samples  int       // 测速次数
+	Failed   int       // 失败次数
+	AvgDelay int       // 成功测速的平均延迟（毫秒），当天没有成功时为 0
+}
+
+// DailyHealth 按本地日期汇总测速历史（可为多个节点的合并），返回截至 now 的最近 days 天，按日期升序；
+// 没有测速的日期 Samples 为 0。
+func DailyHealth(samples []model.DelaySample, days int, now time.Time) []DayHealth {
+	if days <= 0 {
+		return nil
+	}
+	now = now.Local()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	first := today.AddDate(0, 0, -(days - 1))
+	// 按日历日（而非 24 小时）分组，夏令时切换当天也不会错位
+	index := make(map[string]int, days)
+	for i := 0; i < days; i++ {
+		index[first.AddDate(0, 0, i).Format(time.DateOnly)] = i
+	}
+	buckets := make([][]model.DelaySample, days)
+	for _, s := range samples {
+		if i, ok := index[s.TestedAt.Local().Format(time.DateOnly)]; ok {
+			buckets[i] = append(buckets[i], s)
+		}
+	}
+
+	result := make([]DayHealth, days)
+	for i, bucket := range buckets {
+		stats := newPeriodStats(bucket)
+		mean, _ := stats.meanStdDev()
+		result[i] = DayHealth{
+			Day:      first.AddDate(0, 0, i),
+			Samples:  stats.total,
+			Failed:   stats.failed,
+			AvgDelay: int(mean + 0.5),
+		}
+	}
+	return result
+}
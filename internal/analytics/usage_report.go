@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// UsageReport 一年的本机使用汇总。
+type UsageReport struct {
+	Year          int
+	Connects      int
+	Duration      time.Duration
+	ActiveDays    int               // 有连接的天数
+	LongestStreak int               // 最长连续使用天数
+	BusiestDay    string            // 连接时长最长的一天（YYYY-MM-DD），没有记录时为空
+	BusiestTime   time.Duration     // BusiestDay 当天的连接时长
+	Months        [12]time.Duration // 各月连接时长
+	Nodes         []UsageNode       // 按连接时长降序
+}
+
+// UsageNode 一年内某个节点的使用汇总。
+type UsageNode struct {
+	Name     string
+	Connects int
+	Duration time.Duration
+}
+
+// BuildUsageReport 汇总 year 年的按天统计；days 中不属于该年的记录被忽略。
+func BuildUsageReport(year int, days []model.UsageDay) *UsageReport {
+	report := &UsageReport{Year: year}
+	perDay := make(map[string]time.Duration)
+	perNode := make(map[string]*UsageNode)
+	for _, d := range days {
+		t, err := time.ParseInLocation(time.DateOnly, d.Day, time.Local)
+		if err != nil || t.Year() != year {
+			continue
+		}
+		duration := time.Duration(d.Seconds) * time.Second
+		report.Connects += d.Connects
+		report.Duration += duration
+		report.Months[t.Month()-1] += duration
+		perDay[d.Day] += duration
+
+		n := perNode[d.Node]
+		if n == nil {
+			n = &UsageNode{Name: d.Node}
+			perNode[d.Node] = n
+		}
+		n.Connects += d.Connects
+		n.Duration += duration
+	}
+
+	report.ActiveDays = len(perDay)
+	active := make([]string, 0, len(perDay))
+	for day, duration := range perDay {
+		active = append(active, day)
+		if duration > report.BusiestTime || (duration == report.BusiestTime && day < report.BusiestDay) {
+			report.BusiestDay, report.BusiestTime = day, duration
+		}
+	}
+	report.LongestStreak = longestStreak(active)
+
+	for _, n := range perNode {
+		report.Nodes = append(report.Nodes, *n)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool {
+		a, b := report.Nodes[i], report.Nodes[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		if a.Connects != b.Connects {
+			return a.Connects > b.Connects
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// longestStreak 返回日期（YYYY-MM-DD）中最长的连续天数。
+func longestStreak(days []string) int {
+	sort.Strings(days)
+	longest, current := 0, 0
+	var prev time.Time
+	for _, day := range days {
+		t, err := time.ParseInLocation(time.DateOnly, day, time.Local)
+		if err != nil {
+			continue
+		}
+		// 按日历日比较，夏令时切换当天也算连续
+		if current > 0 && prev.AddDate(0, 0, 1).Equal(t) {
+			current++
+		} else {
+			current = 1
+		}
+		prev = t
+		longest = max(longest, current)
+	}
+	return longest
+}
@@ -0,0 +1,299 @@
+// Package app 应用核心：持有数据存储与各业务服务，并管理代理（xray 实例）的生命周期。
+// 不创建窗口或界面组件，图形界面（ui.AppState）嵌入 Controller 使用。但 Store 的节点、订阅、配置与代理状态以及 TrafficService 的速率
+// 以 Fyne 数据绑定（data/binding）提供，数据变化时的通知需要已创建的 Fyne 应用，
+// 因此命令行、测试等无界面场景复用时也须先创建 Fyne 应用（测试中可用 fyne test.NewApp）。
+package app
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/subscription"
+	"myproxy.com/p/internal/utils"
+	"myproxy.com/p/internal/xray"
+)
+
+// Controller 应用核心控制器。服务字段在 New 中创建；ConnectionMonitor、XraySupervisor 需要界面回调，
+// 由使用方在启动时创建后赋值。xray 实例只经 StartProxy / StopProxy 等方法变化。
+type Controller struct {
+	Ping                *utils.Ping
+	Logger              *logging.Logger
+	SafeLogger          *logging.SafeLogger
+	Store               *store.Store
+	ServerService       *service.ServerService
+	ConfigService       *service.ConfigService
+	ProxyService        *service.ProxyService
+	SubscriptionService *service.SubscriptionService
+	XrayControlService  *service.XrayControlService
+	AccessRecordService *service.AccessRecordService
+	ProcessStatsService *service.ProcessStatsService
+	ConnErrorStats      *service.ConnErrorStatsService // 本次运行的连接错误分类计数
+	DiagnosticsService  *service.DiagnosticsService
+	ImportAPIService    *service.ImportAPIService
+	TrashService        *service.TrashService
+	TrafficService      *service.TrafficService
+	DevToolsService     *service.DevToolsService
+	PowerService        *service.PowerService
+	NetworkStatus       *service.NetworkStatusService // 检测本机是否完全没有网络连接
+	ConnectionMonitor   *service.ConnectionMonitor    // 连接、中断与负载均衡组切换通知
+	XraySupervisor      *service.XraySupervisor       // xray 意外停止时更新状态并自动重启
+	SessionReport       *service.SessionReportService // 断开连接时生成连接报告并记入连接记录
+	ProfileShareService *service.ProfileShareService
+	ClientImportService *service.ClientImportService    // 从 Clash、v2rayN、Shadowrocket 迁移节点与规则
+	WebDAVSyncService   *service.WebDAVSyncService      // 设置与订阅列表的 WebDAV 同步
+	ScheduledPing       *service.ScheduledPingService   // 定时测速与结果 Webhook
+	StatusFileService   *service.StatusFileService      // 供状态栏脚本读取的 status.json
+	Prewarm             *service.PrewarmService         // 为常用目标保持预连接
+	ExternalRefresh     *service.ExternalRefreshService // 其他进程修改数据库后刷新节点与订阅列表
+	UsageStats          *service.UsageStatsService      // 本机使用统计（连接次数、时长与常用节点）
+
+	mu   sync.Mutex
+	xray *xray.XrayInstance // 当前 xray 实例，生命周期 = 代理运行生命周期，停止后为 nil
+}
+
+// New 创建数据存储与各业务服务（不加载数据、不启动后台任务）。
+func New() *Controller {
+	safeLogger := logging.NewSafeLogger(nil)
+	subscriptionManager := subscription.NewSubscriptionManager()
+	subscriptionManager.SetLogger(safeLogger)
+	dataStore := store.NewStore(subscriptionManager)
+	configService := service.NewConfigService(dataStore)
+	subscriptionManager.SetKeepUnsupportedSSR(configService.GetKeepUnsupportedSSR)
+	serverService := service.NewServerService(dataStore, configService)
+	subscriptionService := service.NewSubscriptionService(dataStore, subscriptionManager)
+	pingUtil := utils.NewPing()
+	pingUtil.SetMethodSource(configService.GetLatencyMethod)
+
+	c := &Controller{
+		Ping:                pingUtil,
+		SafeLogger:          safeLogger,
+		Store:               dataStore,
+		ServerService:       serverService,
+		ConfigService:       configService,
+		SubscriptionService: subscriptionService,
+		ProxyService:        service.NewProxyService(nil, configService),
+		XrayControlService:  service.NewXrayControlService(dataStore, configService, nil, nil),
+		AccessRecordService: service.NewAccessRecordService(dataStore, configService),
+		ProcessStatsService: service.NewProcessStatsService(dataStore, configService),
+		ConnErrorStats:      service.NewConnErrorStatsService(),
+		DiagnosticsService:  service.NewDiagnosticsService(configService, dataStore),
+		ImportAPIService:    service.NewImportAPIService(configService, subscriptionService),
+		TrashService:        service.NewTrashService(dataStore),
+		TrafficService:      service.NewTrafficService(dataStore),
+		DevToolsService:     service.NewDevToolsService(configService),
+		PowerService:        service.NewPowerService(configService),
+		ProfileShareService: service.NewProfileShareService(dataStore, configService, subscriptionService),
+		ClientImportService: service.NewClientImportService(dataStore, configService, subscriptionService),
+	}
+	c.WebDAVSyncService = service.NewWebDAVSyncService(dataStore, configService, subscriptionService, c.PowerService)
+	c.NetworkStatus = service.NewNetworkStatusService(c.PowerService)
+	c.ScheduledPing = service.NewScheduledPingService(dataStore, configService, serverService, pingUtil, c.PowerService, c.NetworkStatus)
+	c.StatusFileService = service.NewStatusFileService(dataStore, configService, c.TrafficService, c.PowerService)
+	c.SessionReport = service.NewSessionReportService(configService, c.TrafficService)
+	c.AccessRecordService.SetSessionReport(c.SessionReport)
+	c.Prewarm = service.NewPrewarmService(dataStore, configService, c.AccessRecordService, c.PowerService, c.NetworkStatus, c.runningPort)
+	c.ExternalRefresh = service.NewExternalRefreshService(dataStore, configService, c.PowerService)
+	c.UsageStats = service.NewUsageStatsService(configService)
+	return c
+}
+
+// AppendLog 追加一条日志。由 Logger 写入文件并调用 panelCallback，统一由 OnLogLine 分发到展示和访问记录。
+func (c *Controller) AppendLog(level, logType, message string) {
+	level = strings.ToUpper(level)
+	if strings.ToLower(logType) != "xray" {
+		logType = "app"
+	}
+	if c.Logger != nil {
+		c.Logger.Log(level, logType, message)
+	}
+}
+
+// XrayInstance 返回当前 xray 实例，代理未启动时为 nil。
+func (c *Controller) XrayInstance() *xray.XrayInstance {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.xray
+}
+
+// runningPort 返回运行中代理的本地入站端口，未运行时为 0。
+func (c *Controller) runningPort() int {
+	if inst := c.XrayInstance(); inst != nil && inst.IsRunning() {
+		return inst.GetPort()
+	}
+	return 0
+}
+
+// IsProxyRunning 代理是否在运行。
+func (c *Controller) IsProxyRunning() bool {
+	inst := c.XrayInstance()
+	return inst != nil && inst.IsRunning()
+}
+
+// setXrayInstance 更新当前实例，并同步给依赖实例端口的 ProxyService。
+func (c *Controller) setXrayInstance(inst *xray.XrayInstance) {
+	c.mu.Lock()
+	c.xray = inst
+	c.mu.Unlock()
+	if c.ProxyService != nil {
+		c.ProxyService.UpdateXrayInstance(inst)
+	}
+}
+
+// logFilePath 返回统一日志文件路径（xray 日志与应用日志写入同一个文件）。
+func (c *Controller) logFilePath() string {
+	if c.Logger == nil {
+		return ""
+	}
+	return c.Logger.GetLogFilePath()
+}
+
+// StartProxy 按当前选中的节点启动代理：已有实例时先停止，成功后持有新实例。
+// 失败时保留原实例引用（已停止），返回结果中的 Error 说明原因。
+func (c *Controller) StartProxy() *service.StartProxyResult {
+	if c.XrayControlService == nil {
+		return &service.StartProxyResult{Error: fmt.Errorf("应用核心: XrayControlService 未初始化")}
+	}
+	result := c.XrayControlService.StartProxy(c.XrayInstance(), c.logFilePath())
+	if result.Error != nil {
+		return result
+	}
+	c.setXrayInstance(result.XrayInstance)
+	if c.Logger != nil && result.XrayInstance != nil {
+		if n := c.Store.Nodes.GetSelected(); n != nil {
+			c.Logger.InfoWithType(logging.LogTypeProxy, "xray-core代理已启动: %s (端口: %d)", n.Name, result.XrayInstance.GetPort())
+		}
+	}
+	return result
+}
+
+// StopProxy 停止代理并释放实例；代理未运行时 LogMessage 为「代理未运行」。
+func (c *Controller) StopProxy() *service.StopProxyResult {
+	if c.XrayControlService == nil {
+		return &service.StopProxyResult{Error: fmt.Errorf("应用核心: XrayControlService 未初始化")}
+	}
+	result := c.XrayControlService.StopProxy(c.XrayInstance())
+	if result.Error != nil {
+		return result
+	}
+	c.setXrayInstance(nil)
+	if c.Logger != nil {
+		c.Logger.InfoWithType(logging.LogTypeProxy, "xray-core代理已停止")
+	}
+	return result
+}
+
+// ReleaseStoppedInstance 实例已意外停止（由守护发现）时释放引用，返回是否释放。
+func (c *Controller) ReleaseStoppedInstance() bool {
+	inst := c.XrayInstance()
+	if inst == nil || inst.IsRunning() {
+		return false
+	}
+	c.setXrayInstance(nil)
+	return true
+}
+
+// AutoStartProxy 开启「启动时自动连接」时选中上次的节点并启动代理；未开启时返回 false 且无错误。
+func (c *Controller) AutoStartProxy() (bool, error) {
+	if c.Store == nil || c.Store.AppConfig == nil {
+		return false, fmt.Errorf("应用核心: Store 未初始化")
+	}
+	if c.ConfigService == nil || !c.ConfigService.GetBool("autoStartProxy") {
+		return false, nil
+	}
+
+	selectedServerID, err := c.Store.AppConfig.GetWithDefault("selectedServerID", database.AppConfigBuiltinDefault("selectedServerID"))
+	if err != nil || selectedServerID == "" {
+		return false, fmt.Errorf("应用核心: 未找到保存的选中服务器")
+	}
+	if err := c.Store.Nodes.Select(selectedServerID); err != nil {
+		return false, fmt.Errorf("应用核心: 选中服务器失败: %w", err)
+	}
+
+	c.AppendLog("INFO", "app", "正在自动启动代理服务...")
+	if result := c.StartProxy(); result.Error != nil {
+		return false, fmt.Errorf("应用核心: 启动代理失败: %w", result.Error)
+	}
+	c.AppendLog("INFO", "app", "代理服务自动启动成功")
+	return true, nil
+}
+
+// StopBackground 停止连接监控、守护与各定时任务（退出时调用，先于 Shutdown）。
+func (c *Controller) StopBackground() {
+	if c.PowerService != nil {
+		c.PowerService.Stop()
+	}
+	if c.NetworkStatus != nil {
+		c.NetworkStatus.Stop()
+	}
+	if c.ConnectionMonitor != nil {
+		c.ConnectionMonitor.Unwatch()
+	}
+	if c.XraySupervisor != nil {
+		c.XraySupervisor.Stop()
+	}
+	if c.StatusFileService != nil {
+		c.StatusFileService.Stop()
+	}
+	if c.WebDAVSyncService != nil {
+		c.WebDAVSyncService.Stop()
+	}
+	if c.ScheduledPing != nil {
+		c.ScheduledPing.Stop()
+	}
+	if c.Prewarm != nil {
+		c.Prewarm.Stop()
+	}
+	if c.ExternalRefresh != nil {
+		c.ExternalRefresh.Stop()
+	}
+	if c.UsageStats != nil {
+		c.UsageStats.Stop()
+	}
+}
+
+// Shutdown 停止代理并释放各服务：先停止流量采样保存累计流量，再停止实例、刷盘访问记录，最后关闭日志。
+func (c *Controller) Shutdown() {
+	if c.TrafficService != nil {
+		c.TrafficService.Stop()
+	}
+
+	if inst := c.XrayInstance(); inst != nil && inst.IsRunning() {
+		_ = inst.Stop()
+	}
+	c.setXrayInstance(nil)
+
+	if c.AccessRecordService != nil {
+		if err := c.AccessRecordService.Flush(); err != nil && c.Logger != nil {
+			c.Logger.Error("刷盘访问记录失败: %v", err)
+		}
+	}
+	if c.ProcessStatsService != nil {
+		c.ProcessStatsService.Stop()
+	}
+
+	if c.Logger != nil {
+		c.Logger.Close()
+		c.Logger = nil
+	}
+	if c.SafeLogger != nil {
+		c.SafeLogger.SetLogger(nil)
+	}
+
+	if c.Store != nil {
+		c.Store.Reset()
+	}
+	if c.DiagnosticsService != nil {
+		c.DiagnosticsService.Stop()
+	}
+	if c.ImportAPIService != nil {
+		c.ImportAPIService.Stop()
+	}
+	if c.TrashService != nil {
+		c.TrashService.Stop()
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ChangeWatcher 在独占的连接上读取 PRAGMA data_version，用于发现其他连接（包括 CLI、API 服务等其他进程）提交的修改。
+// data_version 只在其他连接提交后变化；本进程经连接池的写入同样会被报告，调用方需要自行比对内容。
+type ChangeWatcher struct {
+	conn *sql.Conn
+	last int64
+}
+
+// NewChangeWatcher 从连接池取出一条连接专用于检测修改，并记录当前版本。
+func NewChangeWatcher() (*ChangeWatcher, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	conn, err := DB.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	w := &ChangeWatcher{conn: conn}
+	if w.last, err = w.version(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Changed 返回自上次调用以来数据库是否被其他连接修改过。
+func (w *ChangeWatcher) Changed() (bool, error) {
+	v, err := w.version()
+	if err != nil {
+		return false, err
+	}
+	changed := v != w.last
+	w.last = v
+	return changed, nil
+}
+
+// Close 将连接归还连接池。
+func (w *ChangeWatcher) Close() error {
+	return w.conn.Close()
+}
+
+func (w *ChangeWatcher) version() (int64, error) {
+	var v int64
+	if err := w.conn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&v); err != nil {
+		return 0, fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+	return v, nil
+}
@@ -2,9 +2,11 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -24,12 +26,69 @@ const DefaultMixedInboundPort = 10808
 // xray 的 listen 与写入系统/终端/Git 代理的主机名须与此一致（勿用 0.0.0.0 作为客户端连接目标）。
 const LocalMixedInboundListenHost = "127.0.0.1"
 
+// delayHistoryRetention 测速历史保留时长，写入时删除更早的记录
+const delayHistoryRetention = 14 * 24 * time.Hour
+
+// dataDir 应用数据目录（数据库所在目录），由 InitDB 设置
+var dataDir string
+
+// DataDir 返回应用数据目录（数据库所在目录）；xray 资源等运行时文件均放在其下。未初始化时返回空字符串。
+func DataDir() string {
+	return dataDir
+}
+
 // defaultAppConfigEntries 应用配置内置默认值；InitDefaultConfig 仅在键不存在时写入，不覆盖用户已有数据。
 // autoProxyPort 在 init 中写入，与 DefaultMixedInboundPort 一致。
 var defaultAppConfigEntries = map[string]string{
 	"logLevel":                   "info",
 	"logFile":                    "myproxy.log",
 	"theme":                      "dark",
+	// 节点与订阅列表密度：comfortable（52px 行高）、standard（40px）、compact（32px）
+	"listDensity":                "comfortable",
+	// 速度单位：bytes（MB/s，1024 进制）或 bits（Mbps，1000 进制）；数字格式：system 跟随系统语言，
+	// 或固定为 comma-dot（1,234.5）、dot-comma（1.234,5）、space-comma（1 234,5）、plain（1234.5）
+	"speedUnit":                  "bytes",
+	"numberFormat":               "system",
+	// 辅助功能：大号控件（放大间距、图标与文字，图标按钮旁显示文字名称）
+	"largeControls":              "false",
+	// 主窗口置顶、悬浮状态小窗（启动时恢复）
+	"windowAlwaysOnTop":          "false",
+	"miniWindowEnabled":          "false",
+	// macOS 菜单栏图标旁显示实时上传/下载速度（关闭可省电）
+	"trayShowSpeed":              "true",
+	// 测速方式：tcp 计 TCP 连接耗时，tls 对 TLS 节点再计入 TLS 握手
+	"latencyMethod":              "tcp",
+	// 一键测速跳过该时间（分钟）内已测过的节点，0 表示每次全部重测
+	"pingFreshMinutes":           "5",
+	// 定时测速：间隔为 0 时关闭；每轮结束后若设置了 Webhook 地址则 POST JSON 摘要
+	"scheduledPingInterval":      "0s",
+	"pingWebhookURL":             "",
+	// 定时测速的静默时段（如 "23:00-07:00"，空表示不限制）与仅在接通电源时运行
+	"scheduledPingQuietHours":    "",
+	"scheduledPingACOnly":        "false",
+	// 连接预热：代理运行时为访问最多的 N 个经代理目标保持预连接
+	"prewarmEnabled":             "false",
+	"prewarmTopN":                "5",
+	// 使用统计：在本机记录连接次数、连接时长与常用节点，用于使用报告，不同步、不上传
+	"usageStatsEnabled":          "true",
+	// 应用锁：密码的 PBKDF2 哈希（为空表示未设置）、启动时与进入设置页时是否需要密码、无操作多少分钟后自动锁定（0 为不自动锁定）
+	"appLockHash":                "",
+	"appLockOnStartup":           "true",
+	"appLockSettings":            "true",
+	"appLockIdleMinutes":         "0",
+	// 其他进程（CLI、API 服务等）修改数据库后自动刷新节点与订阅列表的检查间隔（秒），0 表示关闭
+	"externalRefreshSeconds":     "5",
+	// 低功耗模式：auto（使用电池时开启）、on、off
+	"lowPowerMode":               "auto",
+	// 系统通知：连接成功、连接意外中断、负载均衡组自动切换节点
+	"notifyOnConnect":            "true",
+	"notifyOnDisconnect":         "true",
+	"notifyOnFailover":           "true",
+	// 断开连接时生成连接报告（时长、流量、访问最多的站点、平均延迟、重连次数）并记入连接记录
+	"sessionReportEnabled":       "false",
+	// xray 意外停止（入站不再接受连接）后按指数退避自动重启，最多尝试的次数
+	"xrayAutoRestart":            "true",
+	"xrayAutoRestartMaxRetries":  "5",
 	"autoProxyEnabled":           "false",
 	"selectedServerID":           "",
 	"selectedSubscriptionID":     "0",
@@ -47,9 +106,86 @@ var defaultAppConfigEntries = map[string]string{
 	"proxyType":                  "socks5",
 	// mixedInboundListenAll=true 时 xray 混合入站监听 0.0.0.0，便于 WSL2 等通过 Windows 主机 IP 访问；本机系统代理仍写 127.0.0.1。
 	"mixedInboundListenAll":      "false",
+	// 局域网入站：在本地混合入站之外再开一个入站（默认 0.0.0.0:7890 HTTP）供局域网设备使用，与本地入站各自开关
+	"lanInboundEnabled":          "false",
+	"lanInboundListen":           "0.0.0.0",
+	"lanInboundPort":             "7890",
+	"lanInboundProtocol":         "http",
+	// 入站认证：开启后混合入站（SOCKS5 与 HTTP Basic）要求用户名/密码，避免向局域网共享时成为开放代理
+	"inboundAuthEnabled":         "false",
+	"inboundAuthUser":            "myproxy",
+	"inboundAuthPassword":        "",
+	// 入站限速（KB/s，0 为不限）：上限作用于整个混合入站；lanOnly 时本机连接不受限
+	"inboundUploadLimitKBps":     "0",
+	"inboundDownloadLimitKBps":   "0",
+	"inboundRateLimitLanOnly":    "false",
 	"directRoutes":             "",
 	"directRoutesUseProxy":       "false",
+	// 绕过局域网与中国大陆：独立于直连列表，生成 geoip:private、geoip:cn、geosite:cn 直连规则
+	"bypassLanAndCN":             "false",
+	// 最终流量走向（未命中其他规则的流量）：proxy 代理、direct 直连、block 阻止
+	"finalOutbound":              "proxy",
 	"logsCollapsed":              "true",
+	"autoProbeSelectedNode":      "false",
+	// 节点连续测试失败达到该次数后自动禁用（0 为不自动禁用）
+	"autoDisableFailThreshold":   "3",
+	// 浏览器导入接口：仅监听本机，需携带 importApiToken 访问
+	"importApiEnabled":           "false",
+	"importApiAddr":              "127.0.0.1:10810",
+	"importApiToken":             "",
+	// 本地接口 /metrics（Prometheus 格式），需同时开启导入接口
+	"metricsEnabled":             "false",
+	// 数据目录下的 status.json：当前节点、端口、模式与流量，供状态栏脚本读取
+	"statusFileEnabled":          "true",
+	// WebDAV 配置同步：地址可为文件或目录（目录时使用 myproxy-sync.json）；默认不同步完整订阅地址
+	"webdavSyncEnabled":          "false",
+	"webdavURL":                  "",
+	"webdavUser":                 "",
+	"webdavPassword":             "",
+	"webdavSyncCredentials":      "false",
+	// 上次同步的远端 ETag 与本地内容摘要（JSON），用于判断哪一方有修改
+	"webdavSyncState":            "",
+	// 剪贴板监听：检测到节点分享链接或订阅地址时提示导入
+	"clipboardMonitorEnabled":    "false",
+	// 不兼容的 SSR 节点（非 origin 协议或非 plain 混淆）是否以禁用状态保留，默认跳过
+	"keepUnsupportedSSR":         "false",
+	// 访问记录方式：full 记录完整地址，hashed 仅记录次数（主机名以本机密钥做哈希），off 不记录
+	"accessRecordMode":           "full",
+	"accessRecordHashKey":        "",
+	// 访问记录是否包含纯 IP 目标（直接按 IP 连接的应用），默认只记录域名
+	"accessRecordIPs":            "false",
+	// 应用维度统计：按连接源端口查找本机进程，记录哪些应用在使用代理
+	"processStatsEnabled":        "false",
+	// 负载均衡组：当前订阅（未选订阅时为全部）的已启用节点由 xray 隧道内探测后自动选择
+	"balancerEnabled":            "false",
+	"balancerStrategy":           "leastPing",
+	"observatoryProbeURL":        "https://www.gstatic.com/generate_204",
+	"observatoryProbeInterval":   "1m0s",
+	// 网络诊断中 DNS 泄漏测试使用的服务（兼容 bash.ws 接口）
+	"dnsLeakTestService":         "https://bash.ws",
+	// 连接超时策略（xray policy level 0），默认与 xray 内置值一致
+	"policyHandshake":            "4s",
+	"policyConnIdle":             "5m0s",
+	"policyUplinkOnly":           "2s",
+	"policyDownlinkOnly":         "5s",
+	// TLS 分片：代理出站经 freedom fragment 出站拨号，拆分 ClientHello 以绕过基于 SNI 的重置
+	"fragmentEnabled":            "false",
+	"fragmentPackets":            "tlshello",
+	"fragmentLength":             "100-200",
+	"fragmentInterval":           "10-20",
+	// 一键写入 git/npm/Docker 代理前的原始设置（JSON），移除时据此恢复
+	"devToolProxyBackup":         "",
+	// 累计流量（字节），由流量服务每分钟保存
+	"trafficTotalUpload":         "0",
+	"trafficTotalDownload":       "0",
+	// xray 资源目录（geoip.dat、geosite.dat），空为数据目录下的 xray；相对路径相对数据目录
+	"xrayAssetDir":               "",
+	// xray 内核：embedded（内置）或 external（运行 xrayBinaryPath 指定的可执行文件，用于固定 xray 版本）
+	"xrayCoreMode":               "embedded",
+	"xrayBinaryPath":             "",
+	// xray gRPC API（HandlerService、StatsService 等）：是否在生成的配置中开启及本机监听端口
+	"xrayAPIEnabled":             "false",
+	"xrayAPIPort":                "10085",
 }
 
 func init() {
@@ -124,6 +260,11 @@ func InitDB(dbPath string) error {
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return fmt.Errorf("创建数据库目录失败: %w", err)
 	}
+	if abs, err := filepath.Abs(filepath.Dir(dbPath)); err == nil {
+		dataDir = abs
+	} else {
+		dataDir = filepath.Dir(dbPath)
+	}
 
 	// 打开数据库连接
 	var err error
@@ -153,6 +294,18 @@ func createTables() error {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		url TEXT NOT NULL UNIQUE,
 		label TEXT NOT NULL DEFAULT '',
+		include_filter TEXT NOT NULL DEFAULT '',
+		exclude_filter TEXT NOT NULL DEFAULT '',
+		rename_rules TEXT NOT NULL DEFAULT '',
+		last_diff TEXT NOT NULL DEFAULT '',
+		locked INTEGER NOT NULL DEFAULT 0,
+		test_url TEXT NOT NULL DEFAULT '',
+		etag TEXT NOT NULL DEFAULT '',
+		last_modified TEXT NOT NULL DEFAULT '',
+		userinfo TEXT NOT NULL DEFAULT '',
+		last_error TEXT NOT NULL DEFAULT '',
+		last_error_at DATETIME,
+		deleted_at DATETIME,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`
@@ -188,6 +341,18 @@ func createTables() error {
 		ssr_protocol TEXT DEFAULT '',
 		ssr_protocol_param TEXT DEFAULT '',
 		raw_config TEXT DEFAULT '',
+		original_name TEXT DEFAULT '',
+		fail_count INTEGER NOT NULL DEFAULT 0,
+		disabled_reason TEXT NOT NULL DEFAULT '',
+		disabled_at DATETIME,
+		tested_at DATETIME,
+		tcp_fast_open INTEGER NOT NULL DEFAULT 0,
+		tcp_keep_alive_interval INTEGER NOT NULL DEFAULT 0,
+		tcp_user_timeout INTEGER NOT NULL DEFAULT 0,
+		domain_strategy TEXT NOT NULL DEFAULT '',
+		happy_eyeballs_delay INTEGER NOT NULL DEFAULT 0,
+		builtin_forward INTEGER NOT NULL DEFAULT 0,
+		deleted_at DATETIME,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE SET NULL
@@ -215,6 +380,7 @@ func createTables() error {
 
 	// 创建访问记录表（用于流量分析：记录访问的网站及累计访问次数）
 	// address 存储 host:port，如 api2.cursor.sh:443，避免不同端口丢失信息
+	// outbound 为最近一次匹配的出站 tag，direct_count / proxied_count 为经直连、代理出站的次数
 	createAccessRecordsTable := `
 	CREATE TABLE IF NOT EXISTS access_records (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -223,12 +389,47 @@ func createTables() error {
 		access_count INTEGER NOT NULL DEFAULT 0,
 		upload_bytes INTEGER NOT NULL DEFAULT 0,
 		download_bytes INTEGER NOT NULL DEFAULT 0,
+		outbound TEXT NOT NULL DEFAULT '',
+		direct_count INTEGER NOT NULL DEFAULT 0,
+		proxied_count INTEGER NOT NULL DEFAULT 0,
 		first_seen DATETIME NOT NULL,
 		last_seen DATETIME NOT NULL,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	// 创建应用维度统计表：记录通过本地入站使用代理的本机进程及累计连接次数
+	createProcessRecordsTable := `
+	CREATE TABLE IF NOT EXISTS process_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		path TEXT NOT NULL DEFAULT '',
+		connection_count INTEGER NOT NULL DEFAULT 0,
+		first_seen DATETIME NOT NULL,
+		last_seen DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 创建测速历史表：每次测速写入一条（delay <= 0 为失败），用于推断节点特征标签，只保留最近 delayHistoryRetention
+	createDelayHistoryTable := `
+	CREATE TABLE IF NOT EXISTS delay_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		delay INTEGER NOT NULL,
+		tested_at DATETIME NOT NULL,
+		FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+	);`
+
+	// 创建使用统计表：按天、节点汇总连接次数与连接时长，仅供本机的使用报告
+	createUsageStatsTable := `
+	CREATE TABLE IF NOT EXISTS usage_stats (
+		day TEXT NOT NULL,
+		node TEXT NOT NULL,
+		connects INTEGER NOT NULL DEFAULT 0,
+		seconds INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, node)
+	);`
+
 	// 创建索引
 	createIndexes := `
 	CREATE INDEX IF NOT EXISTS idx_servers_subscription_id ON servers(subscription_id);
@@ -238,6 +439,7 @@ func createTables() error {
 	CREATE INDEX IF NOT EXISTS idx_app_config_key ON app_config(key);
 	CREATE INDEX IF NOT EXISTS idx_access_records_address ON access_records(address);
 	CREATE INDEX IF NOT EXISTS idx_access_records_last_seen ON access_records(last_seen);
+	CREATE INDEX IF NOT EXISTS idx_delay_history_tested_at ON delay_history(tested_at);
 	`
 
 	if _, err := DB.Exec(createSubscriptionsTable); err != nil {
@@ -260,6 +462,18 @@ func createTables() error {
 		return fmt.Errorf("创建访问记录表失败: %w", err)
 	}
 
+	if _, err := DB.Exec(createProcessRecordsTable); err != nil {
+		return fmt.Errorf("创建应用统计表失败: %w", err)
+	}
+
+	if _, err := DB.Exec(createDelayHistoryTable); err != nil {
+		return fmt.Errorf("创建测速历史表失败: %w", err)
+	}
+
+	if _, err := DB.Exec(createUsageStatsTable); err != nil {
+		return fmt.Errorf("创建使用统计表失败: %w", err)
+	}
+
 	// 先迁移 access_records（旧表无 address 列），再创建依赖 address 的索引
 	if err := migrateAccessRecordsTable(); err != nil {
 		return fmt.Errorf("迁移 access_records 表失败: %w", err)
@@ -274,6 +488,14 @@ func createTables() error {
 		return fmt.Errorf("迁移数据库表失败: %w", err)
 	}
 
+	if err := migrateSubscriptionsTable(); err != nil {
+		return fmt.Errorf("迁移订阅表失败: %w", err)
+	}
+
+	if err := migrateServerIDs(); err != nil {
+		return fmt.Errorf("迁移服务器 ID 失败: %w", err)
+	}
+
 	return nil
 }
 
@@ -332,6 +554,18 @@ func migrateTables() error {
 		{"ssr_protocol", "TEXT DEFAULT ''"},
 		{"ssr_protocol_param", "TEXT DEFAULT ''"},
 		{"raw_config", "TEXT DEFAULT ''"},
+		{"original_name", "TEXT DEFAULT ''"},
+		{"fail_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"disabled_reason", "TEXT NOT NULL DEFAULT ''"},
+		{"disabled_at", "DATETIME"},
+		{"tested_at", "DATETIME"},
+		{"tcp_fast_open", "INTEGER NOT NULL DEFAULT 0"},
+		{"tcp_keep_alive_interval", "INTEGER NOT NULL DEFAULT 0"},
+		{"tcp_user_timeout", "INTEGER NOT NULL DEFAULT 0"},
+		{"domain_strategy", "TEXT NOT NULL DEFAULT ''"},
+		{"happy_eyeballs_delay", "INTEGER NOT NULL DEFAULT 0"},
+		{"builtin_forward", "INTEGER NOT NULL DEFAULT 0"},
+		{"deleted_at", "DATETIME"},
 	}
 
 	// 获取表结构信息
@@ -379,16 +613,171 @@ func migrateTables() error {
 	return nil
 }
 
-// migrateAccessRecordsTable 迁移 access_records 表，添加 address 字段。
-// 旧表只有 domain，新表以 address (host:port) 为唯一键。
+// migrateSubscriptionsTable 为 subscriptions 表补充后续版本新增的字段。
+func migrateSubscriptionsTable() error {
+	migrations := []struct {
+		column  string
+		colType string
+	}{
+		{"include_filter", "TEXT NOT NULL DEFAULT ''"},
+		{"exclude_filter", "TEXT NOT NULL DEFAULT ''"},
+		{"rename_rules", "TEXT NOT NULL DEFAULT ''"},
+		{"last_diff", "TEXT NOT NULL DEFAULT ''"},
+		{"deleted_at", "DATETIME"},
+		{"locked", "INTEGER NOT NULL DEFAULT 0"},
+		{"test_url", "TEXT NOT NULL DEFAULT ''"},
+		{"etag", "TEXT NOT NULL DEFAULT ''"},
+		{"last_modified", "TEXT NOT NULL DEFAULT ''"},
+		{"userinfo", "TEXT NOT NULL DEFAULT ''"},
+		{"last_error", "TEXT NOT NULL DEFAULT ''"},
+		{"last_error_at", "DATETIME"},
+	}
+
+	rows, err := DB.Query("PRAGMA table_info(subscriptions)")
+	if err != nil {
+		return nil
+	}
+	existingColumns := make(map[string]bool)
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notnull int
+		var dfltValue sql.NullString
+		var pk int
+		if err := rows.Scan(&cid, &name, &colType, &notnull, &dfltValue, &pk); err != nil {
+			continue
+		}
+		existingColumns[name] = true
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if existingColumns[m.column] {
+			continue
+		}
+		if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE subscriptions ADD COLUMN %s %s", m.column, m.colType)); err != nil {
+			return fmt.Errorf("添加字段 %s 失败: %w", m.column, err)
+		}
+	}
+	return nil
+}
+
+// serverIDSchemeKey 记录服务器 ID 生成方式的内部配置键，值为 serverIDSchemeStable 时表示已迁移为稳定 ID
+const (
+	serverIDSchemeKey    = "serverIDScheme"
+	serverIDSchemeStable = "stable"
+)
+
+// migrateServerIDs 将旧版本混入时间戳生成的服务器 ID 迁移为稳定 ID（见 model.GenerateServerID），
+// 并同步更新已保存的选中节点。冲突处理与订阅导入一致：同一订阅内重复的节点按出现顺序派生，
+// 已被其他订阅占用时按订阅地址派生，手动节点与订阅节点冲突时按 "manual" 派生。仅执行一次。
+func migrateServerIDs() error {
+	var scheme string
+	err := DB.QueryRow("SELECT value FROM app_config WHERE key = ?", serverIDSchemeKey).Scan(&scheme)
+	if err == nil && scheme == serverIDSchemeStable {
+		return nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("读取服务器 ID 迁移标记失败: %w", err)
+	}
+
+	type serverRow struct {
+		id             string
+		subscriptionID sql.NullInt64
+		url            string
+		addr           string
+		port           int
+		username       string
+	}
+	rows, err := DB.Query(
+		`SELECT n.id, n.subscription_id, COALESCE(s.url, ''), n.addr, n.port, n.username
+		 FROM servers n LEFT JOIN subscriptions s ON n.subscription_id = s.id
+		 ORDER BY n.subscription_id IS NULL, n.subscription_id, n.created_at, n.rowid`,
+	)
+	if err != nil {
+		return fmt.Errorf("查询服务器失败: %w", err)
+	}
+	var servers []serverRow
+	for rows.Next() {
+		var r serverRow
+		if err := rows.Scan(&r.id, &r.subscriptionID, &r.url, &r.addr, &r.port, &r.username); err != nil {
+			rows.Close()
+			return fmt.Errorf("读取服务器失败: %w", err)
+		}
+		servers = append(servers, r)
+	}
+	rows.Close()
+
+	taken := make(map[string]bool, len(servers))
+	occurrences := make(map[string]int)
+	mapping := make(map[string]string)
+	for _, r := range servers {
+		id := model.GenerateServerID(r.addr, r.port, r.username)
+		discriminator := "manual"
+		if r.subscriptionID.Valid {
+			discriminator = r.url
+			key := fmt.Sprintf("%d/%s", r.subscriptionID.Int64, id)
+			occurrences[key]++
+			if n := occurrences[key]; n > 1 {
+				id = model.DeriveServerID(id, strconv.Itoa(n))
+			}
+		}
+		for taken[id] {
+			id = model.DeriveServerID(id, discriminator)
+		}
+		taken[id] = true
+		if id != r.id {
+			mapping[r.id] = id
+		}
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 先改为临时 ID 再改为新 ID，避免新旧 ID 互相占用主键
+	for oldID := range mapping {
+		if _, err := tx.Exec("UPDATE servers SET id = ? WHERE id = ?", "migrating:"+oldID, oldID); err != nil {
+			return fmt.Errorf("更新服务器 ID 失败: %w", err)
+		}
+	}
+	for oldID, newID := range mapping {
+		if _, err := tx.Exec("UPDATE servers SET id = ? WHERE id = ?", newID, "migrating:"+oldID); err != nil {
+			return fmt.Errorf("更新服务器 ID 失败: %w", err)
+		}
+	}
+	var selectedID string
+	if err := tx.QueryRow("SELECT value FROM app_config WHERE key = 'selectedServerID'").Scan(&selectedID); err == nil {
+		if newID, ok := mapping[selectedID]; ok {
+			if _, err := tx.Exec("UPDATE app_config SET value = ? WHERE key = 'selectedServerID'", newID); err != nil {
+				return fmt.Errorf("更新选中服务器失败: %w", err)
+			}
+		}
+	}
+	now := time.Now()
+	if _, err := tx.Exec(
+		`INSERT INTO app_config (key, value, created_at, updated_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		serverIDSchemeKey, serverIDSchemeStable, now, now,
+	); err != nil {
+		return fmt.Errorf("写入服务器 ID 迁移标记失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// migrateAccessRecordsTable 迁移 access_records 表：旧表只有 domain，重建为以 address (host:port) 为唯一键；
+// 并补充后续版本新增的出站字段。
 func migrateAccessRecordsTable() error {
 	rows, err := DB.Query("PRAGMA table_info(access_records)")
 	if err != nil {
 		return nil // 表可能不存在
 	}
-	defer rows.Close()
-
-	hasAddress := false
+	existingColumns := make(map[string]bool)
 	for rows.Next() {
 		var cid int
 		var name string
@@ -399,17 +788,39 @@ func migrateAccessRecordsTable() error {
 		if err := rows.Scan(&cid, &name, &colType, &notnull, &dfltValue, &pk); err != nil {
 			continue
 		}
-		if name == "address" {
-			hasAddress = true
-			break
+		existingColumns[name] = true
+	}
+	rows.Close()
+	if !existingColumns["address"] {
+		if err := rebuildAccessRecordsTable(); err != nil {
+			return err
 		}
+		existingColumns = map[string]bool{}
 	}
-	if hasAddress {
-		return nil
+
+	migrations := []struct {
+		column  string
+		colType string
+	}{
+		{"outbound", "TEXT NOT NULL DEFAULT ''"},
+		{"direct_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"proxied_count", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, m := range migrations {
+		if existingColumns[m.column] {
+			continue
+		}
+		if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE access_records ADD COLUMN %s %s", m.column, m.colType)); err != nil {
+			return fmt.Errorf("添加字段 %s 失败: %w", m.column, err)
+		}
 	}
+	return nil
+}
+
+// rebuildAccessRecordsTable 将只有 domain 的旧表重建为以 address 为唯一键的表，地址按 domain:443 补全。
+func rebuildAccessRecordsTable() error {
 
-	// 旧表无 address，需重建表
-	_, err = DB.Exec(`
+	_, err := DB.Exec(`
 		CREATE TABLE access_records_new (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			domain TEXT NOT NULL,
@@ -448,6 +859,40 @@ func CloseDB() error {
 	return nil
 }
 
+// subscriptionColumns 查询订阅时的列顺序，与 scanSubscription 一致
+const subscriptionColumns = "id, url, label, include_filter, exclude_filter, rename_rules, last_diff, locked, test_url, etag, last_modified, userinfo, last_error, last_error_at, created_at, updated_at"
+
+// rowScanner 抽象 *sql.Row 与 *sql.Rows 的 Scan
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSubscription 按 subscriptionColumns 的顺序读取一行订阅；rename_rules、last_diff 以 JSON 存储，无法解析时视为未配置
+func scanSubscription(row rowScanner, sub *Subscription) error {
+	var renameRules, lastDiff string
+	var lastErrorAt sql.NullTime
+	if err := row.Scan(&sub.ID, &sub.URL, &sub.Label, &sub.IncludeFilter, &sub.ExcludeFilter, &renameRules, &lastDiff, &sub.Locked, &sub.TestURL,
+		&sub.ETag, &sub.LastModified, &sub.Userinfo, &sub.LastError, &lastErrorAt, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		return err
+	}
+	sub.LastErrorAt = time.Time{}
+	if lastErrorAt.Valid {
+		sub.LastErrorAt = lastErrorAt.Time
+	}
+	sub.RenameRules = model.RenameRules{}
+	if renameRules != "" {
+		_ = json.Unmarshal([]byte(renameRules), &sub.RenameRules)
+	}
+	sub.LastDiff = nil
+	if lastDiff != "" {
+		var diff model.SubscriptionDiff
+		if err := json.Unmarshal([]byte(lastDiff), &diff); err == nil {
+			sub.LastDiff = &diff
+		}
+	}
+	return nil
+}
+
 // AddOrUpdateSubscription 添加新订阅或更新现有订阅。
 // 如果订阅 URL 已存在，则更新其标签；否则创建新订阅。
 // 参数：
@@ -458,10 +903,14 @@ func CloseDB() error {
 func AddOrUpdateSubscription(url, label string) (*Subscription, error) {
 	now := time.Now()
 
+	// 回收站中同 URL 的订阅会占用唯一约束，重新添加时将其永久删除
+	if err := purgeTrashedSubscriptionByURL(url); err != nil {
+		return nil, err
+	}
+
 	// 先尝试查询是否存在
 	var sub Subscription
-	err := DB.QueryRow("SELECT id, url, label, created_at, updated_at FROM subscriptions WHERE url = ?", url).
-		Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt)
+	err := scanSubscription(DB.QueryRow("SELECT "+subscriptionColumns+" FROM subscriptions WHERE url = ? AND deleted_at IS NULL", url), &sub)
 
 	if err == sql.ErrNoRows {
 		// 不存在，插入新记录
@@ -508,10 +957,7 @@ func AddOrUpdateSubscription(url, label string) (*Subscription, error) {
 // 返回：订阅实例和错误（如果未找到或发生错误）
 func GetSubscriptionByURL(url string) (*Subscription, error) {
 	var sub Subscription
-	err := DB.QueryRow(
-		"SELECT id, url, label, created_at, updated_at FROM subscriptions WHERE url = ?",
-		url,
-	).Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt)
+	err := scanSubscription(DB.QueryRow("SELECT "+subscriptionColumns+" FROM subscriptions WHERE url = ? AND deleted_at IS NULL", url), &sub)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -526,7 +972,7 @@ func GetSubscriptionByURL(url string) (*Subscription, error) {
 // GetAllSubscriptions 获取所有订阅列表。
 // 返回：订阅列表和错误（如果有）
 func GetAllSubscriptions() ([]*Subscription, error) {
-	rows, err := DB.Query("SELECT id, url, label, created_at, updated_at FROM subscriptions ORDER BY created_at DESC")
+	rows, err := DB.Query("SELECT " + subscriptionColumns + " FROM subscriptions WHERE deleted_at IS NULL ORDER BY created_at DESC")
 	if err != nil {
 		return nil, fmt.Errorf("查询订阅列表失败: %w", err)
 	}
@@ -535,7 +981,7 @@ func GetAllSubscriptions() ([]*Subscription, error) {
 	var subscriptions []*Subscription
 	for rows.Next() {
 		var sub Subscription
-		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		if err := scanSubscription(rows, &sub); err != nil {
 			return nil, fmt.Errorf("扫描订阅数据失败: %w", err)
 		}
 		subscriptions = append(subscriptions, &sub)
@@ -548,7 +994,7 @@ func GetAllSubscriptions() ([]*Subscription, error) {
 	return subscriptions, nil
 }
 
-// DeleteSubscription 删除订阅及其关联的所有服务器。
+// DeleteSubscription 永久删除订阅及其关联的所有服务器（含回收站中的）。
 // 参数：
 //   - subscriptionID: 订阅 ID
 //
@@ -574,10 +1020,7 @@ func DeleteSubscription(subscriptionID int64) error {
 // 返回：订阅实例和错误（如果未找到或发生错误）
 func GetSubscriptionByID(id int64) (*Subscription, error) {
 	var sub Subscription
-	err := DB.QueryRow(
-		"SELECT id, url, label, created_at, updated_at FROM subscriptions WHERE id = ?",
-		id,
-	).Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt)
+	err := scanSubscription(DB.QueryRow("SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ? AND deleted_at IS NULL", id), &sub)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -607,11 +1050,17 @@ func UpdateSubscriptionByID(id int64, url, label string) error {
 	if existingSub == nil {
 		return fmt.Errorf("订阅不存在")
 	}
+	if url != existingSub.URL {
+		if err := purgeTrashedSubscriptionByURL(url); err != nil {
+			return err
+		}
+	}
 
-	// 更新订阅信息
+	// 更新订阅信息；地址变化后上次的 ETag、Last-Modified 不再适用
 	_, err = DB.Exec(
-		"UPDATE subscriptions SET url = ?, label = ?, updated_at = ? WHERE id = ?",
-		url, label, now, id,
+		"UPDATE subscriptions SET url = ?, label = ?, updated_at = ?, etag = CASE WHEN url = ? THEN etag ELSE '' END, "+
+			"last_modified = CASE WHEN url = ? THEN last_modified ELSE '' END WHERE id = ?",
+		url, label, now, url, url, id,
 	)
 	if err != nil {
 		return fmt.Errorf("更新订阅失败: %w", err)
@@ -620,6 +1069,138 @@ func UpdateSubscriptionByID(id int64, url, label string) error {
 	return nil
 }
 
+// UpdateSubscriptionFilters 更新订阅的节点包含/排除过滤规则（正则，空字符串表示不过滤）。
+// 同时清除 ETag、Last-Modified，使下次更新重新下载并按新规则处理。
+// 参数：
+//   - id: 订阅 ID
+//   - include: 包含规则
+//   - exclude: 排除规则
+//
+// 返回：错误（如果有）
+func UpdateSubscriptionFilters(id int64, include, exclude string) error {
+	result, err := DB.Exec(
+		"UPDATE subscriptions SET include_filter = ?, exclude_filter = ?, etag = '', last_modified = '' WHERE id = ?",
+		include, exclude, id,
+	)
+	if err != nil {
+		return fmt.Errorf("更新订阅过滤规则失败: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("订阅不存在")
+	}
+	return nil
+}
+
+// UpdateSubscriptionRenameRules 更新订阅的节点改名规则，并清除 ETag、Last-Modified（同过滤规则）。
+// 参数：
+//   - id: 订阅 ID
+//   - rules: 改名规则，零值表示不改名
+//
+// 返回：错误（如果有）
+func UpdateSubscriptionRenameRules(id int64, rules model.RenameRules) error {
+	encoded := ""
+	if !rules.IsZero() {
+		data, err := json.Marshal(rules)
+		if err != nil {
+			return fmt.Errorf("序列化改名规则失败: %w", err)
+		}
+		encoded = string(data)
+	}
+	result, err := DB.Exec("UPDATE subscriptions SET rename_rules = ?, etag = '', last_modified = '' WHERE id = ?", encoded, id)
+	if err != nil {
+		return fmt.Errorf("更新订阅改名规则失败: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("订阅不存在")
+	}
+	return nil
+}
+
+// SetSubscriptionLocked 设置订阅是否锁定（锁定后界面与同步不能编辑或删除该订阅）。
+// 参数：
+//   - id: 订阅 ID
+//   - locked: 是否锁定
+//
+// 返回：错误（如果有）
+func SetSubscriptionLocked(id int64, locked bool) error {
+	result, err := DB.Exec("UPDATE subscriptions SET locked = ? WHERE id = ?", locked, id)
+	if err != nil {
+		return fmt.Errorf("更新订阅锁定状态失败: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("订阅不存在")
+	}
+	return nil
+}
+
+// UpdateSubscriptionTestURL 更新订阅的测速地址。
+// 参数：
+//   - id: 订阅 ID
+//   - testURL: 测速地址，空字符串表示使用全局探测地址
+//
+// 返回：错误（如果有）
+func UpdateSubscriptionTestURL(id int64, testURL string) error {
+	result, err := DB.Exec("UPDATE subscriptions SET test_url = ? WHERE id = ?", testURL, id)
+	if err != nil {
+		return fmt.Errorf("更新订阅测速地址失败: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("订阅不存在")
+	}
+	return nil
+}
+
+// UpdateSubscriptionLastDiff 保存订阅最近一次更新的节点变化。
+// 参数：
+//   - id: 订阅 ID
+//   - diff: 节点变化
+//
+// 返回：错误（如果有）
+func UpdateSubscriptionLastDiff(id int64, diff model.SubscriptionDiff) error {
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("序列化订阅变化失败: %w", err)
+	}
+	if _, err := DB.Exec("UPDATE subscriptions SET last_diff = ? WHERE id = ?", string(data), id); err != nil {
+		return fmt.Errorf("更新订阅变化失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubscriptionValidators 保存订阅最近一次完整拉取响应的 ETag 与 Last-Modified（服务器未提供时为空）。
+// 参数：
+//   - id: 订阅 ID
+//   - etag: ETag 响应头
+//   - lastModified: Last-Modified 响应头
+//
+// 返回：错误（如果有）
+func UpdateSubscriptionValidators(id int64, etag, lastModified string) error {
+	if _, err := DB.Exec("UPDATE subscriptions SET etag = ?, last_modified = ? WHERE id = ?", etag, lastModified, id); err != nil {
+		return fmt.Errorf("更新订阅缓存标识失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubscriptionUserinfo 保存订阅响应的 subscription-userinfo（流量与到期信息）。
+func UpdateSubscriptionUserinfo(id int64, userinfo string) error {
+	if _, err := DB.Exec("UPDATE subscriptions SET userinfo = ? WHERE id = ?", userinfo, id); err != nil {
+		return fmt.Errorf("更新订阅流量信息失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubscriptionLastError 记录订阅最近一次更新失败的原因与时间；message 为空表示更新成功，清除失败记录。
+func UpdateSubscriptionLastError(id int64, message string) error {
+	var at any
+	if message != "" {
+		at = time.Now()
+	}
+	if _, err := DB.Exec("UPDATE subscriptions SET last_error = ?, last_error_at = ? WHERE id = ?", message, at, id); err != nil {
+		return fmt.Errorf("更新订阅更新状态失败: %w", err)
+	}
+	return nil
+}
+
 // GetServerCountBySubscriptionID 获取指定订阅的服务器数量。
 // 参数：
 //   - subscriptionID: 订阅 ID
@@ -627,7 +1208,7 @@ func UpdateSubscriptionByID(id int64, url, label string) error {
 // 返回：服务器数量和错误（如果有）
 func GetServerCountBySubscriptionID(subscriptionID int64) (int, error) {
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM servers WHERE subscription_id = ?", subscriptionID).Scan(&count)
+	err := DB.QueryRow("SELECT COUNT(*) FROM servers WHERE subscription_id = ? AND deleted_at IS NULL", subscriptionID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("查询服务器数量失败: %w", err)
 	}
@@ -637,6 +1218,7 @@ func GetServerCountBySubscriptionID(subscriptionID int64) (int, error) {
 // AddOrUpdateServer 添加新服务器或更新现有服务器。
 // 如果服务器 ID 已存在，则更新其信息；否则创建新服务器。
 // 如果 subscriptionID 为 nil 且服务器已存在，则保持原有的 subscription_id。
+// 更新已有服务器时不覆盖拨号选项（由 UpdateServerDialOptions 单独维护），订阅刷新不会丢失用户设置。
 // 参数：
 //   - server: 服务器配置信息
 //   - subscriptionID: 关联的订阅 ID（可选，可为 nil）
@@ -653,29 +1235,15 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 
 	if err == sql.ErrNoRows {
 		// 不存在，插入新记录
-		_, err = DB.Exec(
-			`INSERT INTO servers (id, subscription_id, name, addr, port, username, password, delay, selected, enabled,
-				node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
-				vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-				ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config, created_at, updated_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			server.ID, subscriptionID, server.Name, server.Addr, server.Port,
-			server.Username, server.Password, server.Delay,
-			boolToInt(server.Selected), boolToInt(server.Enabled),
-			server.ProtocolType, server.VMessVersion, server.VMessUUID, server.VMessAlterID,
-			server.VMessSecurity, server.VMessNetwork, server.VMessType, server.VMessHost,
-			server.VMessPath, server.VMessTLS, server.SSMethod, server.SSPlugin, server.SSPluginOpts,
-			server.SSRObfs, server.SSRObfsParam, server.SSRProtocol, server.SSRProtocolParam,
-			server.RawConfig, now, now,
-		)
-		if err != nil {
-			return fmt.Errorf("插入服务器失败: %w", err)
+		if err := insertServer(DB, server, subscriptionID, now); err != nil {
+			return err
 		}
 	} else if err != nil {
 		return fmt.Errorf("查询服务器失败: %w", err)
 	} else {
 		// 存在，更新记录
-		// 如果 subscriptionID 为 nil，保持原有的 subscription_id
+		// 如果 subscriptionID 为 nil，保持原有的 subscription_id；
+		// 因连续失败被自动禁用的节点保持禁用（disabled_reason 非空），仅由手动测速成功后恢复
 		updateSubscriptionID := subscriptionID
 		if updateSubscriptionID == nil && existingSubscriptionID.Valid {
 			updateSubscriptionID = &existingSubscriptionID.Int64
@@ -684,12 +1252,12 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 		_, err = DB.Exec(
 			`UPDATE servers SET 
 				subscription_id = ?, name = ?, addr = ?, port = ?, username = ?, password = ?,
-				delay = ?, selected = ?, enabled = ?,
+				delay = ?, selected = ?, enabled = CASE WHEN disabled_reason != '' THEN 0 ELSE ? END,
 				node_protocol_type = ?, vmess_version = ?, vmess_uuid = ?, vmess_alter_id = ?, vmess_security = ?,
 				vmess_network = ?, vmess_type = ?, vmess_host = ?, vmess_path = ?, vmess_tls = ?,
 				ss_method = ?, ss_plugin = ?, ss_plugin_opts = ?,
 				ssr_obfs = ?, ssr_obfs_param = ?, ssr_protocol = ?, ssr_protocol_param = ?,
-				raw_config = ?, updated_at = ?
+				raw_config = ?, original_name = ?, updated_at = ?
 			 WHERE id = ?`,
 			updateSubscriptionID, server.Name, server.Addr, server.Port,
 			server.Username, server.Password, server.Delay,
@@ -698,7 +1266,7 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 			server.VMessSecurity, server.VMessNetwork, server.VMessType, server.VMessHost,
 			server.VMessPath, server.VMessTLS, server.SSMethod, server.SSPlugin, server.SSPluginOpts,
 			server.SSRObfs, server.SSRObfsParam, server.SSRProtocol, server.SSRProtocolParam,
-			server.RawConfig, now, server.ID,
+			server.RawConfig, server.OriginalName, now, server.ID,
 		)
 		if err != nil {
 			return fmt.Errorf("更新服务器失败: %w", err)
@@ -708,6 +1276,153 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 	return nil
 }
 
+// sqlExecer 可执行 SQL 的对象（*sql.DB 或 *sql.Tx）
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertServer 插入一条新的服务器记录。
+func insertServer(exec sqlExecer, server Node, subscriptionID *int64, now time.Time) error {
+	var disabledAt interface{}
+	if !server.DisabledAt.IsZero() {
+		disabledAt = server.DisabledAt
+	}
+	_, err := exec.Exec(
+		`INSERT INTO servers (id, subscription_id, name, addr, port, username, password, delay, selected, enabled,
+			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
+			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
+			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config, original_name,
+			tcp_fast_open, tcp_keep_alive_interval, tcp_user_timeout, domain_strategy, happy_eyeballs_delay, builtin_forward,
+			disabled_reason, disabled_at, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		server.ID, subscriptionID, server.Name, server.Addr, server.Port,
+		server.Username, server.Password, server.Delay,
+		boolToInt(server.Selected), boolToInt(server.Enabled),
+		server.ProtocolType, server.VMessVersion, server.VMessUUID, server.VMessAlterID,
+		server.VMessSecurity, server.VMessNetwork, server.VMessType, server.VMessHost,
+		server.VMessPath, server.VMessTLS, server.SSMethod, server.SSPlugin, server.SSPluginOpts,
+		server.SSRObfs, server.SSRObfsParam, server.SSRProtocol, server.SSRProtocolParam,
+		server.RawConfig, server.OriginalName,
+		boolToInt(server.TCPFastOpen), server.TCPKeepAliveInterval, server.TCPUserTimeout, server.DomainStrategy, server.HappyEyeballsDelay, boolToInt(server.BuiltinForward),
+		server.DisabledReason, disabledAt, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("插入服务器失败: %w", err)
+	}
+	return nil
+}
+
+// SubscriptionMergeResult 订阅节点合并的统计。
+type SubscriptionMergeResult struct {
+	Added   int // 新插入的节点数
+	Updated int // 已存在并刷新的节点数
+	Removed int // 本次结果中已不存在而移入回收站的节点数
+}
+
+// UpsertSubscriptionServers 在一个事务内将订阅的节点与本次拉取结果按 ID 合并：
+// 已存在的节点只刷新订阅提供的字段（名称、地址、端口、账号、协议参数、原始配置），
+// 保留用户侧状态（延迟、选中、启用与自动禁用状态、拨号选项、回收站状态）；
+// 新节点插入；该订阅下本次结果中已不存在的节点移入回收站（与 TrashServer 一致），
+// 其测速历史等关联数据保留到回收站清理时随节点删除。
+// 参数：
+//   - subscriptionID: 订阅 ID
+//   - servers: 本次拉取的节点（ID 需已去重）
+//
+// 返回：合并统计和错误（如果有）
+func UpsertSubscriptionServers(subscriptionID int64, servers []Node) (SubscriptionMergeResult, error) {
+	var result SubscriptionMergeResult
+	tx, err := DB.Begin()
+	if err != nil {
+		return result, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	keep := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		keep[server.ID] = true
+		res, err := tx.Exec(
+			`UPDATE servers SET
+				subscription_id = ?, name = ?, addr = ?, port = ?, username = ?, password = ?,
+				node_protocol_type = ?, vmess_version = ?, vmess_uuid = ?, vmess_alter_id = ?, vmess_security = ?,
+				vmess_network = ?, vmess_type = ?, vmess_host = ?, vmess_path = ?, vmess_tls = ?,
+				ss_method = ?, ss_plugin = ?, ss_plugin_opts = ?,
+				ssr_obfs = ?, ssr_obfs_param = ?, ssr_protocol = ?, ssr_protocol_param = ?,
+				raw_config = ?, original_name = ?, updated_at = ?
+			 WHERE id = ?`,
+			subscriptionID, server.Name, server.Addr, server.Port, server.Username, server.Password,
+			server.ProtocolType, server.VMessVersion, server.VMessUUID, server.VMessAlterID, server.VMessSecurity,
+			server.VMessNetwork, server.VMessType, server.VMessHost, server.VMessPath, server.VMessTLS,
+			server.SSMethod, server.SSPlugin, server.SSPluginOpts,
+			server.SSRObfs, server.SSRObfsParam, server.SSRProtocol, server.SSRProtocolParam,
+			server.RawConfig, server.OriginalName, now, server.ID,
+		)
+		if err != nil {
+			return result, fmt.Errorf("更新服务器失败: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			result.Updated++
+			continue
+		}
+		if err := insertServer(tx, server, &subscriptionID, now); err != nil {
+			return result, err
+		}
+		result.Added++
+	}
+
+	rows, err := tx.Query("SELECT id FROM servers WHERE subscription_id = ? AND deleted_at IS NULL", subscriptionID)
+	if err != nil {
+		return result, fmt.Errorf("查询订阅服务器失败: %w", err)
+	}
+	var stale []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("读取订阅服务器失败: %w", err)
+		}
+		if !keep[id] {
+			stale = append(stale, id)
+		}
+	}
+	rows.Close()
+	trashedAt := now.UTC()
+	for _, id := range stale {
+		if _, err := tx.Exec(
+			"UPDATE servers SET deleted_at = ?, selected = 0 WHERE id = ? AND deleted_at IS NULL",
+			trashedAt, id,
+		); err != nil {
+			return result, fmt.Errorf("服务器移入回收站失败: %w", err)
+		}
+	}
+	result.Removed = len(stale)
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return result, nil
+}
+
+// GetServerSubscriptionID 查询服务器（含回收站中的）所属订阅。
+// 参数：
+//   - id: 服务器 ID
+//
+// 返回：所属订阅 ID（手动添加的为 nil）、服务器是否存在和错误（如果有）
+func GetServerSubscriptionID(id string) (*int64, bool, error) {
+	var subscriptionID sql.NullInt64
+	err := DB.QueryRow("SELECT subscription_id FROM servers WHERE id = ?", id).Scan(&subscriptionID)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("查询服务器失败: %w", err)
+	}
+	if !subscriptionID.Valid {
+		return nil, true, nil
+	}
+	return &subscriptionID.Int64, true, nil
+}
+
 // GetServer 根据 ID 获取服务器信息。
 // 参数：
 //   - id: 服务器 ID
@@ -716,13 +1431,17 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 func GetServer(id string) (*Node, error) {
 	var server Node
 	var selected, enabled int
+	var disabledAt, testedAt sql.NullTime
+	var tcpFastOpen, builtinForward int
 
 	err := DB.QueryRow(
 		`SELECT id, name, addr, port, username, password, delay, selected, enabled,
 			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
 			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config
-		 FROM servers WHERE id = ?`,
+			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config, original_name,
+			fail_count, disabled_reason, disabled_at, tested_at,
+			tcp_fast_open, tcp_keep_alive_interval, tcp_user_timeout, domain_strategy, happy_eyeballs_delay, builtin_forward
+		 FROM servers WHERE id = ? AND deleted_at IS NULL`,
 		id,
 	).Scan(&server.ID, &server.Name, &server.Addr, &server.Port,
 		&server.Username, &server.Password, &server.Delay,
@@ -731,7 +1450,9 @@ func GetServer(id string) (*Node, error) {
 		&server.VMessSecurity, &server.VMessNetwork, &server.VMessType, &server.VMessHost,
 		&server.VMessPath, &server.VMessTLS, &server.SSMethod, &server.SSPlugin, &server.SSPluginOpts,
 		&server.SSRObfs, &server.SSRObfsParam, &server.SSRProtocol, &server.SSRProtocolParam,
-		&server.RawConfig)
+		&server.RawConfig, &server.OriginalName,
+		&server.FailCount, &server.DisabledReason, &disabledAt, &testedAt,
+		&tcpFastOpen, &server.TCPKeepAliveInterval, &server.TCPUserTimeout, &server.DomainStrategy, &server.HappyEyeballsDelay, &builtinForward)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("服务器不存在: %s", id)
@@ -742,6 +1463,10 @@ func GetServer(id string) (*Node, error) {
 
 	server.Selected = intToBool(selected)
 	server.Enabled = intToBool(enabled)
+	server.DisabledAt = disabledAt.Time
+	server.TestedAt = testedAt.Time
+	server.TCPFastOpen = intToBool(tcpFastOpen)
+	server.BuiltinForward = intToBool(builtinForward)
 
 	// 如果 ProtocolType 为空，设置默认值
 	if server.ProtocolType == "" {
@@ -758,8 +1483,10 @@ func GetAllServers() ([]Node, error) {
 		`SELECT id, name, addr, port, username, password, delay, selected, enabled,
 			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
 			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config
-		 FROM servers ORDER BY created_at DESC`,
+			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config, original_name,
+			fail_count, disabled_reason, disabled_at, tested_at,
+			tcp_fast_open, tcp_keep_alive_interval, tcp_user_timeout, domain_strategy, happy_eyeballs_delay, builtin_forward
+		 FROM servers WHERE deleted_at IS NULL ORDER BY created_at DESC`,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("查询服务器列表失败: %w", err)
@@ -770,6 +1497,8 @@ func GetAllServers() ([]Node, error) {
 	for rows.Next() {
 		var server Node
 		var selected, enabled int
+		var disabledAt, testedAt sql.NullTime
+		var tcpFastOpen, builtinForward int
 
 		if err := rows.Scan(&server.ID, &server.Name, &server.Addr, &server.Port,
 			&server.Username, &server.Password, &server.Delay,
@@ -778,12 +1507,18 @@ func GetAllServers() ([]Node, error) {
 			&server.VMessSecurity, &server.VMessNetwork, &server.VMessType, &server.VMessHost,
 			&server.VMessPath, &server.VMessTLS, &server.SSMethod, &server.SSPlugin, &server.SSPluginOpts,
 			&server.SSRObfs, &server.SSRObfsParam, &server.SSRProtocol, &server.SSRProtocolParam,
-			&server.RawConfig); err != nil {
+			&server.RawConfig, &server.OriginalName,
+			&server.FailCount, &server.DisabledReason, &disabledAt, &testedAt,
+			&tcpFastOpen, &server.TCPKeepAliveInterval, &server.TCPUserTimeout, &server.DomainStrategy, &server.HappyEyeballsDelay, &builtinForward); err != nil {
 			return nil, fmt.Errorf("扫描服务器数据失败: %w", err)
 		}
 
 		server.Selected = intToBool(selected)
 		server.Enabled = intToBool(enabled)
+		server.DisabledAt = disabledAt.Time
+		server.TestedAt = testedAt.Time
+		server.TCPFastOpen = intToBool(tcpFastOpen)
+		server.BuiltinForward = intToBool(builtinForward)
 
 		// 如果 ProtocolType 为空，设置默认值
 		if server.ProtocolType == "" {
@@ -810,8 +1545,10 @@ func GetServersBySubscriptionID(subscriptionID int64) ([]Node, error) {
 		`SELECT id, name, addr, port, username, password, delay, selected, enabled,
 			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
 			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config
-		 FROM servers WHERE subscription_id = ? ORDER BY created_at DESC`,
+			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config, original_name,
+			fail_count, disabled_reason, disabled_at, tested_at,
+			tcp_fast_open, tcp_keep_alive_interval, tcp_user_timeout, domain_strategy, happy_eyeballs_delay, builtin_forward
+		 FROM servers WHERE subscription_id = ? AND deleted_at IS NULL ORDER BY created_at DESC`,
 		subscriptionID,
 	)
 	if err != nil {
@@ -823,6 +1560,8 @@ func GetServersBySubscriptionID(subscriptionID int64) ([]Node, error) {
 	for rows.Next() {
 		var server Node
 		var selected, enabled int
+		var disabledAt, testedAt sql.NullTime
+		var tcpFastOpen, builtinForward int
 
 		if err := rows.Scan(&server.ID, &server.Name, &server.Addr, &server.Port,
 			&server.Username, &server.Password, &server.Delay,
@@ -831,12 +1570,18 @@ func GetServersBySubscriptionID(subscriptionID int64) ([]Node, error) {
 			&server.VMessSecurity, &server.VMessNetwork, &server.VMessType, &server.VMessHost,
 			&server.VMessPath, &server.VMessTLS, &server.SSMethod, &server.SSPlugin, &server.SSPluginOpts,
 			&server.SSRObfs, &server.SSRObfsParam, &server.SSRProtocol, &server.SSRProtocolParam,
-			&server.RawConfig); err != nil {
+			&server.RawConfig, &server.OriginalName,
+			&server.FailCount, &server.DisabledReason, &disabledAt, &testedAt,
+			&tcpFastOpen, &server.TCPKeepAliveInterval, &server.TCPUserTimeout, &server.DomainStrategy, &server.HappyEyeballsDelay, &builtinForward); err != nil {
 			return nil, fmt.Errorf("扫描服务器数据失败: %w", err)
 		}
 
 		server.Selected = intToBool(selected)
 		server.Enabled = intToBool(enabled)
+		server.DisabledAt = disabledAt.Time
+		server.TestedAt = testedAt.Time
+		server.TCPFastOpen = intToBool(tcpFastOpen)
+		server.BuiltinForward = intToBool(builtinForward)
 
 		// 如果 ProtocolType 为空，设置默认值
 		if server.ProtocolType == "" {
@@ -853,20 +1598,162 @@ func GetServersBySubscriptionID(subscriptionID int64) ([]Node, error) {
 	return servers, nil
 }
 
-// UpdateServerDelay 更新服务器的延迟值。
+// UpdateServerDelay 更新服务器的延迟值，记录测速时间并写入测速历史。
 // 参数：
 //   - id: 服务器 ID
 //   - delay: 新的延迟值（毫秒）
 //
 // 返回：错误（如果有）
 func UpdateServerDelay(id string, delay int) error {
-	_, err := DB.Exec(
-		"UPDATE servers SET delay = ?, updated_at = ? WHERE id = ?",
-		delay, time.Now(), id,
+	now := time.Now()
+	result, err := DB.Exec(
+		"UPDATE servers SET delay = ?, tested_at = ?, updated_at = ? WHERE id = ?",
+		delay, now, now, id,
 	)
 	if err != nil {
 		return fmt.Errorf("更新服务器延迟失败: %w", err)
 	}
+	// 未测速（0）不是一次测试结果，节点不存在时也不记录
+	if n, _ := result.RowsAffected(); n == 0 || delay == 0 {
+		return nil
+	}
+	if _, err := DB.Exec(
+		"INSERT INTO delay_history (server_id, delay, tested_at) VALUES (?, ?, ?)",
+		id, delay, now,
+	); err != nil {
+		return fmt.Errorf("写入测速历史失败: %w", err)
+	}
+	if _, err := DB.Exec("DELETE FROM delay_history WHERE tested_at < ?", now.Add(-delayHistoryRetention)); err != nil {
+		return fmt.Errorf("清理测速历史失败: %w", err)
+	}
+	return nil
+}
+
+// GetDelayHistory 获取 since 之后的测速历史，按服务器 ID 分组，每组按时间升序。
+func GetDelayHistory(since time.Time) (map[string][]model.DelaySample, error) {
+	rows, err := DB.Query(
+		"SELECT server_id, delay, tested_at FROM delay_history WHERE tested_at >= ? ORDER BY tested_at",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询测速历史失败: %w", err)
+	}
+	defer rows.Close()
+	history := make(map[string][]model.DelaySample)
+	for rows.Next() {
+		var (
+			id     string
+			sample model.DelaySample
+		)
+		if err := rows.Scan(&id, &sample.Delay, &sample.TestedAt); err != nil {
+			return nil, fmt.Errorf("读取测速历史失败: %w", err)
+		}
+		history[id] = append(history[id], sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取测速历史失败: %w", err)
+	}
+	return history, nil
+}
+
+// AddUsage 累加某天某节点的连接次数与连接秒数。
+func AddUsage(day, node string, connects int, seconds int64) error {
+	_, err := DB.Exec(
+		`INSERT INTO usage_stats (day, node, connects, seconds) VALUES (?, ?, ?, ?)
+		ON CONFLICT(day, node) DO UPDATE SET connects = connects + excluded.connects, seconds = seconds + excluded.seconds`,
+		day, node, connects, seconds,
+	)
+	if err != nil {
+		return fmt.Errorf("写入使用统计失败: %w", err)
+	}
+	return nil
+}
+
+// GetUsage 返回 [fromDay, toDay] 日期范围内的使用统计（日期格式 YYYY-MM-DD），按日期升序。
+func GetUsage(fromDay, toDay string) ([]model.UsageDay, error) {
+	rows, err := DB.Query(
+		"SELECT day, node, connects, seconds FROM usage_stats WHERE day >= ? AND day <= ? ORDER BY day",
+		fromDay, toDay,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询使用统计失败: %w", err)
+	}
+	defer rows.Close()
+	var result []model.UsageDay
+	for rows.Next() {
+		var u model.UsageDay
+		if err := rows.Scan(&u.Day, &u.Node, &u.Connects, &u.Seconds); err != nil {
+			return nil, fmt.Errorf("读取使用统计失败: %w", err)
+		}
+		result = append(result, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取使用统计失败: %w", err)
+	}
+	return result, nil
+}
+
+// GetUsageYears 返回有使用统计的年份，按升序。
+func GetUsageYears() ([]int, error) {
+	rows, err := DB.Query("SELECT DISTINCT CAST(substr(day, 1, 4) AS INTEGER) FROM usage_stats ORDER BY 1")
+	if err != nil {
+		return nil, fmt.Errorf("查询使用统计失败: %w", err)
+	}
+	defer rows.Close()
+	var years []int
+	for rows.Next() {
+		var y int
+		if err := rows.Scan(&y); err != nil {
+			return nil, fmt.Errorf("读取使用统计失败: %w", err)
+		}
+		years = append(years, y)
+	}
+	return years, rows.Err()
+}
+
+// ClearUsage 删除全部使用统计。
+func ClearUsage() error {
+	if _, err := DB.Exec("DELETE FROM usage_stats"); err != nil {
+		return fmt.Errorf("清空使用统计失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateServerDialOptions 更新服务器的拨号选项（sockopt 与内置转发）。
+func UpdateServerDialOptions(id string, opts model.DialOptions) error {
+	_, err := DB.Exec(
+		`UPDATE servers SET tcp_fast_open = ?, tcp_keep_alive_interval = ?, tcp_user_timeout = ?,
+			domain_strategy = ?, happy_eyeballs_delay = ?, builtin_forward = ?, updated_at = ? WHERE id = ?`,
+		boolToInt(opts.TCPFastOpen), opts.TCPKeepAliveInterval, opts.TCPUserTimeout,
+		opts.DomainStrategy, opts.HappyEyeballsDelay, boolToInt(opts.BuiltinForward), time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("更新服务器拨号选项失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateServerHealth 更新服务器的连续失败次数与启用状态。
+// 参数：
+//   - id: 服务器 ID
+//   - failCount: 连续失败次数
+//   - enabled: 是否启用
+//   - disabledReason: 自动禁用原因，空表示未被自动禁用
+//   - disabledAt: 自动禁用时间，零值写入 NULL
+//
+// 返回：错误（如果有）
+func UpdateServerHealth(id string, failCount int, enabled bool, disabledReason string, disabledAt time.Time) error {
+	var at interface{}
+	if !disabledAt.IsZero() {
+		at = disabledAt
+	}
+	_, err := DB.Exec(
+		"UPDATE servers SET fail_count = ?, enabled = ?, disabled_reason = ?, disabled_at = ?, updated_at = ? WHERE id = ?",
+		failCount, boolToInt(enabled), disabledReason, at, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("更新服务器健康状态失败: %w", err)
+	}
 	return nil
 }
 
@@ -891,7 +1778,7 @@ func SelectServer(id string) error {
 	return nil
 }
 
-// DeleteServer 删除指定的服务器。
+// DeleteServer 永久删除指定的服务器。
 // 参数：
 //   - id: 要删除的服务器 ID
 //
@@ -904,7 +1791,7 @@ func DeleteServer(id string) error {
 	return nil
 }
 
-// DeleteServersBySubscriptionID 删除指定订阅关联的所有服务器。
+// DeleteServersBySubscriptionID 永久删除指定订阅关联的所有服务器（含回收站中的）。
 // 参数：
 //   - subscriptionID: 订阅 ID
 //
@@ -917,6 +1804,201 @@ func DeleteServersBySubscriptionID(subscriptionID int64) error {
 	return nil
 }
 
+// TrashServer 将服务器移入回收站（设置 deleted_at 并取消选中）。
+// 参数：
+//   - id: 服务器 ID
+//
+// 返回：错误（如果有）
+func TrashServer(id string) error {
+	_, err := DB.Exec(
+		"UPDATE servers SET deleted_at = ?, selected = 0 WHERE id = ? AND deleted_at IS NULL",
+		time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("服务器移入回收站失败: %w", err)
+	}
+	return nil
+}
+
+// TrashSubscription 将订阅及其当前的服务器以同一时间戳移入回收站，恢复时据此一并恢复。
+// 参数：
+//   - subscriptionID: 订阅 ID
+//
+// 返回：错误（如果有）
+func TrashSubscription(subscriptionID int64) error {
+	now := time.Now().UTC()
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(
+		"UPDATE servers SET deleted_at = ?, selected = 0 WHERE subscription_id = ? AND deleted_at IS NULL",
+		now, subscriptionID,
+	); err != nil {
+		return fmt.Errorf("订阅服务器移入回收站失败: %w", err)
+	}
+	if _, err := tx.Exec(
+		"UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
+		now, subscriptionID,
+	); err != nil {
+		return fmt.Errorf("订阅移入回收站失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// RestoreServer 从回收站恢复服务器；所属订阅仍在回收站中时返回错误。
+// 参数：
+//   - id: 服务器 ID
+//
+// 返回：错误（如果有）
+func RestoreServer(id string) error {
+	var subscriptionTrashed int
+	err := DB.QueryRow(
+		`SELECT COUNT(*) FROM servers n JOIN subscriptions s ON n.subscription_id = s.id
+		 WHERE n.id = ? AND s.deleted_at IS NOT NULL`,
+		id,
+	).Scan(&subscriptionTrashed)
+	if err != nil {
+		return fmt.Errorf("查询服务器所属订阅失败: %w", err)
+	}
+	if subscriptionTrashed > 0 {
+		return fmt.Errorf("所属订阅在回收站中，请先恢复订阅")
+	}
+	if _, err := DB.Exec("UPDATE servers SET deleted_at = NULL WHERE id = ?", id); err != nil {
+		return fmt.Errorf("恢复服务器失败: %w", err)
+	}
+	return nil
+}
+
+// RestoreSubscription 从回收站恢复订阅，以及与订阅同时移入回收站的服务器。
+// 参数：
+//   - subscriptionID: 订阅 ID
+//
+// 返回：错误（如果有）
+func RestoreSubscription(subscriptionID int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(
+		`UPDATE servers SET deleted_at = NULL
+		 WHERE subscription_id = ? AND deleted_at = (SELECT deleted_at FROM subscriptions WHERE id = ?)`,
+		subscriptionID, subscriptionID,
+	); err != nil {
+		return fmt.Errorf("恢复订阅服务器失败: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE subscriptions SET deleted_at = NULL WHERE id = ?", subscriptionID); err != nil {
+		return fmt.Errorf("恢复订阅失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// GetTrashItems 获取回收站中的订阅与单独删除的服务器，按删除时间倒序。
+// 返回：回收站条目列表和错误（如果有）
+func GetTrashItems() ([]model.TrashItem, error) {
+	var items []model.TrashItem
+
+	subRows, err := DB.Query(
+		`SELECT s.id, s.label, s.url, s.deleted_at,
+			(SELECT COUNT(*) FROM servers n WHERE n.subscription_id = s.id AND n.deleted_at = s.deleted_at)
+		 FROM subscriptions s WHERE s.deleted_at IS NOT NULL`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询回收站订阅失败: %w", err)
+	}
+	for subRows.Next() {
+		var item model.TrashItem
+		var label, url string
+		if err := subRows.Scan(&item.SubscriptionID, &label, &url, &item.DeletedAt, &item.NodeCount); err != nil {
+			subRows.Close()
+			return nil, fmt.Errorf("扫描回收站订阅失败: %w", err)
+		}
+		item.Kind = model.TrashKindSubscription
+		item.Name = label
+		if item.Name == "" {
+			item.Name = url
+		}
+		items = append(items, item)
+	}
+	subRows.Close()
+
+	nodeRows, err := DB.Query(
+		`SELECT n.id, n.name, n.deleted_at FROM servers n
+		 WHERE n.deleted_at IS NOT NULL AND NOT EXISTS (
+			SELECT 1 FROM subscriptions s WHERE s.id = n.subscription_id AND s.deleted_at IS NOT NULL)`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询回收站服务器失败: %w", err)
+	}
+	defer nodeRows.Close()
+	for nodeRows.Next() {
+		var item model.TrashItem
+		if err := nodeRows.Scan(&item.NodeID, &item.Name, &item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("扫描回收站服务器失败: %w", err)
+		}
+		item.Kind = model.TrashKindNode
+		items = append(items, item)
+	}
+	if err := nodeRows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历回收站服务器失败: %w", err)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// PurgeTrashBefore 永久删除在 cutoff 之前移入回收站的订阅与服务器。
+// 参数：
+//   - cutoff: 截止时间
+//
+// 返回：删除的订阅与服务器总数和错误（如果有）
+func PurgeTrashBefore(cutoff time.Time) (int64, error) {
+	cutoff = cutoff.UTC()
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	serversResult, err := tx.Exec("DELETE FROM servers WHERE deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("清理回收站服务器失败: %w", err)
+	}
+	subsResult, err := tx.Exec("DELETE FROM subscriptions WHERE deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("清理回收站订阅失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
+	}
+	servers, _ := serversResult.RowsAffected()
+	subs, _ := subsResult.RowsAffected()
+	return servers + subs, nil
+}
+
+// purgeTrashedSubscriptionByURL 永久删除回收站中指定 URL 的订阅及其服务器。
+func purgeTrashedSubscriptionByURL(url string) error {
+	var id int64
+	err := DB.QueryRow("SELECT id FROM subscriptions WHERE url = ? AND deleted_at IS NOT NULL", url).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询回收站订阅失败: %w", err)
+	}
+	return DeleteSubscription(id)
+}
+
 // SetLayoutConfig 保存布局配置到数据库。
 // 参数：
 //   - key: 配置键名
@@ -1050,8 +2132,8 @@ func InsertOrUpdateAccessRecord(address string, count int64, uploadBytes, downlo
 }
 
 // BatchInsertOrUpdateAccessRecords 批量插入或更新访问记录（用于初始加载历史日志时优化性能）。
-// records 的 key 为 address (host:port)。
-func BatchInsertOrUpdateAccessRecords(records map[string]int64) error {
+// records 的 key 为 address (host:port)；出站 tag 为空时保留记录原有的出站。
+func BatchInsertOrUpdateAccessRecords(records map[string]model.AccessTally) error {
 	if len(records) == 0 {
 		return nil
 	}
@@ -1063,10 +2145,13 @@ func BatchInsertOrUpdateAccessRecords(records map[string]int64) error {
 
 	now := time.Now()
 	stmt, err := tx.Prepare(
-		`INSERT INTO access_records (domain, address, access_count, upload_bytes, download_bytes, first_seen, last_seen, updated_at)
-		 VALUES (?, ?, ?, 0, 0, ?, ?, ?)
+		`INSERT INTO access_records (domain, address, access_count, outbound, direct_count, proxied_count, upload_bytes, download_bytes, first_seen, last_seen, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, 0, 0, ?, ?, ?)
 		 ON CONFLICT(address) DO UPDATE SET
 			access_count = access_count + excluded.access_count,
+			outbound = CASE WHEN excluded.outbound = '' THEN outbound ELSE excluded.outbound END,
+			direct_count = direct_count + excluded.direct_count,
+			proxied_count = proxied_count + excluded.proxied_count,
 			last_seen = excluded.last_seen,
 			updated_at = excluded.updated_at`,
 	)
@@ -1075,12 +2160,12 @@ func BatchInsertOrUpdateAccessRecords(records map[string]int64) error {
 	}
 	defer stmt.Close()
 
-	for address, count := range records {
-		if address == "" || count <= 0 {
+	for address, tally := range records {
+		if address == "" || tally.Count <= 0 {
 			continue
 		}
 		domain := extractHostFromAddress(address)
-		if _, err := stmt.Exec(domain, address, count, now, now, now); err != nil {
+		if _, err := stmt.Exec(domain, address, tally.Count, tally.Outbound, tally.Direct, tally.Proxied, now, now, now); err != nil {
 			return fmt.Errorf("插入访问记录失败: %w", err)
 		}
 	}
@@ -1101,7 +2186,7 @@ func extractHostFromAddress(address string) string {
 // GetAllAccessRecords 获取所有访问记录，按 last_seen 倒序。
 func GetAllAccessRecords() ([]model.AccessRecord, error) {
 	rows, err := DB.Query(
-		`SELECT id, domain, address, access_count, upload_bytes, download_bytes, first_seen, last_seen
+		`SELECT id, domain, address, access_count, upload_bytes, download_bytes, outbound, direct_count, proxied_count, first_seen, last_seen
 		 FROM access_records ORDER BY last_seen DESC`,
 	)
 	if err != nil {
@@ -1112,7 +2197,7 @@ func GetAllAccessRecords() ([]model.AccessRecord, error) {
 	var records []model.AccessRecord
 	for rows.Next() {
 		var r model.AccessRecord
-		if err := rows.Scan(&r.ID, &r.Domain, &r.Address, &r.AccessCount, &r.UploadBytes, &r.DownloadBytes, &r.FirstSeen, &r.LastSeen); err != nil {
+		if err := rows.Scan(&r.ID, &r.Domain, &r.Address, &r.AccessCount, &r.UploadBytes, &r.DownloadBytes, &r.Outbound, &r.DirectCount, &r.ProxiedCount, &r.FirstSeen, &r.LastSeen); err != nil {
 			return nil, fmt.Errorf("扫描访问记录失败: %w", err)
 		}
 		records = append(records, r)
@@ -1141,6 +2226,81 @@ func ClearAllAccessRecords() error {
 	return nil
 }
 
+// BatchInsertOrUpdateProcessRecords 批量累加应用维度统计，records 的 ConnectionCount 为本次新增次数。
+// 路径为空时保留已有路径。
+func BatchInsertOrUpdateProcessRecords(records []model.ProcessRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	stmt, err := tx.Prepare(
+		`INSERT INTO process_records (name, path, connection_count, first_seen, last_seen, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(name) DO UPDATE SET
+			path = CASE WHEN excluded.path != '' THEN excluded.path ELSE path END,
+			connection_count = connection_count + excluded.connection_count,
+			last_seen = excluded.last_seen,
+			updated_at = excluded.updated_at`,
+	)
+	if err != nil {
+		return fmt.Errorf("准备语句失败: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if r.Name == "" || r.ConnectionCount <= 0 {
+			continue
+		}
+		if _, err := stmt.Exec(r.Name, r.Path, r.ConnectionCount, now, now, now); err != nil {
+			return fmt.Errorf("插入应用统计失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// GetAllProcessRecords 获取应用维度统计，按累计连接次数倒序。
+func GetAllProcessRecords() ([]model.ProcessRecord, error) {
+	rows, err := DB.Query(
+		`SELECT id, name, path, connection_count, first_seen, last_seen
+		 FROM process_records ORDER BY connection_count DESC, last_seen DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询应用统计失败: %w", err)
+	}
+	defer rows.Close()
+
+	var records []model.ProcessRecord
+	for rows.Next() {
+		var r model.ProcessRecord
+		if err := rows.Scan(&r.ID, &r.Name, &r.Path, &r.ConnectionCount, &r.FirstSeen, &r.LastSeen); err != nil {
+			return nil, fmt.Errorf("扫描应用统计失败: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历应用统计失败: %w", err)
+	}
+	return records, nil
+}
+
+// ClearAllProcessRecords 清空应用维度统计。
+func ClearAllProcessRecords() error {
+	if _, err := DB.Exec("DELETE FROM process_records"); err != nil {
+		return fmt.Errorf("清空应用统计失败: %w", err)
+	}
+	return nil
+}
+
 // boolToInt 将布尔值转换为整数
 func boolToInt(b bool) int {
 	if b {
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"myproxy.com/p/internal/model"
+)
+
+func TestMigrateServerIDsCollisions(t *testing.T) {
+	if err := InitDB(filepath.Join(t.TempDir(), "myproxy.db")); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = CloseDB() })
+
+	subA, err := AddOrUpdateSubscription("https://a.example.com/sub", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subB, err := AddOrUpdateSubscription("https://b.example.com/sub", "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本混入时间戳的 ID：同一节点在订阅 A 中出现两次、订阅 B 中一次、手动添加一次
+	rows := []struct {
+		oldID string
+		sub   any
+	}{
+		{"old-a1", subA.ID},
+		{"old-a2", subA.ID},
+		{"old-b1", subB.ID},
+		{"old-manual", nil},
+	}
+	for i, r := range rows {
+		if _, err := DB.Exec(
+			`INSERT INTO servers (id, subscription_id, name, addr, port, username, created_at)
+			 VALUES (?, ?, ?, 'node.example.com', 443, 'pw', datetime('now', ?))`,
+			r.oldID, r.sub, r.oldID, fmt.Sprintf("+%d seconds", i),
+		); err != nil {
+			t.Fatalf("插入服务器失败: %v", err)
+		}
+	}
+	if _, err := DB.Exec(`INSERT INTO app_config (key, value) VALUES ('selectedServerID', 'old-b1')
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec("DELETE FROM app_config WHERE key = ?", serverIDSchemeKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateServerIDs(); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+
+	base := model.GenerateServerID("node.example.com", 443, "pw")
+	want := map[string]string{
+		"old-a1":     base,
+		"old-a2":     model.DeriveServerID(base, "2"),
+		"old-b1":     model.DeriveServerID(base, "https://b.example.com/sub"),
+		"old-manual": model.DeriveServerID(base, "manual"),
+	}
+	seen := make(map[string]bool)
+	for oldID, newID := range want {
+		var id string
+		if err := DB.QueryRow("SELECT id FROM servers WHERE name = ?", oldID).Scan(&id); err != nil {
+			t.Fatalf("读取 %s 失败: %v", oldID, err)
+		}
+		if id != newID {
+			t.Errorf("%s 迁移后 ID = %s，期望 %s", oldID, id, newID)
+		}
+		if seen[id] {
+			t.Errorf("迁移后 ID 重复: %s", id)
+		}
+		seen[id] = true
+	}
+
+	var selected string
+	if err := DB.QueryRow("SELECT value FROM app_config WHERE key = 'selectedServerID'").Scan(&selected); err != nil {
+		t.Fatal(err)
+	}
+	if selected != want["old-b1"] {
+		t.Errorf("选中节点未随迁移更新: %s", selected)
+	}
+
+	// 已迁移过的数据库不再执行
+	if _, err := DB.Exec("UPDATE servers SET id = 'manual-edit' WHERE name = 'old-a1'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateServerIDs(); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	if err := DB.QueryRow("SELECT id FROM servers WHERE name = 'old-a1'").Scan(&id); err != nil || id != "manual-edit" {
+		t.Errorf("迁移标记存在时不应再次迁移: %s %v", id, err)
+	}
+}
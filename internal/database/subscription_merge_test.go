@@ -0,0 +1,73 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+func TestUpsertSubscriptionServersTrashesDroppedNodes(t *testing.T) {
+	if err := InitDB(filepath.Join(t.TempDir(), "myproxy.db")); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = CloseDB() })
+
+	sub, err := AddOrUpdateSubscription("https://a.example.com/sub", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep := Node{ID: "keep", Name: "keep", Addr: "a.example.com", Port: 443}
+	drop := Node{ID: "drop", Name: "drop", Addr: "b.example.com", Port: 443}
+	if _, err := UpsertSubscriptionServers(sub.ID, []Node{keep, drop}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateServerDelay("drop", 120); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := UpsertSubscriptionServers(sub.ID, []Node{keep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("Removed = %d，期望 1", result.Removed)
+	}
+
+	// 节点进入回收站，测速历史仍在
+	items, err := GetTrashItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Kind != model.TrashKindNode || items[0].NodeID != "drop" {
+		t.Fatalf("回收站条目 = %+v，期望只有节点 drop", items)
+	}
+	var history int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM delay_history WHERE server_id = 'drop'").Scan(&history); err != nil {
+		t.Fatal(err)
+	}
+	if history != 1 {
+		t.Errorf("移入回收站后测速历史 %d 条，期望 1", history)
+	}
+
+	// 已在回收站中的节点不再计入移除
+	result, err = UpsertSubscriptionServers(sub.ID, []Node{keep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 0 {
+		t.Errorf("再次合并 Removed = %d，期望 0", result.Removed)
+	}
+
+	// 清理回收站时关联的测速历史随节点删除
+	if _, err := PurgeTrashBefore(time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.QueryRow("SELECT COUNT(*) FROM delay_history WHERE server_id = 'drop'").Scan(&history); err != nil {
+		t.Fatal(err)
+	}
+	if history != 0 {
+		t.Errorf("清理回收站后残留测速历史 %d 条", history)
+	}
+}
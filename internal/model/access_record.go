@@ -5,12 +5,43 @@ import "time"
 // AccessRecord 访问记录，用于流量分析。
 // 记录访问的网站及累计访问次数，便于后续分析。
 type AccessRecord struct {
-	ID           int64     `json:"id"`
-	Domain       string    `json:"domain"`        // 访问的域名（兼容旧数据，新数据同 Address 的 host 部分）
-	Address      string    `json:"address"`       // 完整地址 host:port，如 api2.cursor.sh:443
-	AccessCount  int64     `json:"accessCount"`  // 累计访问次数
-	UploadBytes  int64     `json:"uploadBytes"`  // 累计上传字节（暂不支持，保留字段）
-	DownloadBytes int64    `json:"downloadBytes"` // 累计下载字节（暂不支持，保留字段）
-	FirstSeen    time.Time `json:"firstSeen"`   // 首次访问时间
-	LastSeen     time.Time `json:"lastSeen"`    // 最近访问时间
+	ID            int64     `json:"id"`
+	Domain        string    `json:"domain"`        // 访问的域名（兼容旧数据，新数据同 Address 的 host 部分）
+	Address       string    `json:"address"`       // 完整地址 host:port，如 api2.cursor.sh:443
+	AccessCount   int64     `json:"accessCount"`   // 累计访问次数
+	UploadBytes   int64     `json:"uploadBytes"`   // 累计上传字节（暂不支持，保留字段）
+	DownloadBytes int64     `json:"downloadBytes"` // 累计下载字节（暂不支持，保留字段）
+	Outbound      string    `json:"outbound"`      // 最近一次匹配的出站 tag，如 proxy、direct；旧数据为空
+	DirectCount   int64     `json:"directCount"`   // 经直连出站的次数
+	ProxiedCount  int64     `json:"proxiedCount"`  // 经代理出站（含负载均衡组节点）的次数
+	FirstSeen     time.Time `json:"firstSeen"`     // 首次访问时间
+	LastSeen      time.Time `json:"lastSeen"`      // 最近访问时间
+}
+
+// AccessTally 一段时间内对同一地址的访问次数，按匹配的出站分类，用于合并后批量写入访问记录。
+type AccessTally struct {
+	Count    int64  // 访问次数
+	Direct   int64  // 其中经直连出站的次数
+	Proxied  int64  // 其中经代理出站的次数
+	Outbound string // 最近一次匹配的出站 tag，日志无路由信息时为空
+}
+
+// AccessRecordGroup 同一可注册域名（如 foo.com）下的访问记录汇总，api.foo.com、cdn.foo.com 归入同一组。
+type AccessRecordGroup struct {
+	Domain       string         `json:"domain"`       // 可注册域名，IP 地址按原样分组
+	AccessCount  int64          `json:"accessCount"`  // 组内访问次数之和
+	DirectCount  int64          `json:"directCount"`  // 组内经直连出站的次数之和
+	ProxiedCount int64          `json:"proxiedCount"` // 组内经代理出站的次数之和
+	LastSeen     time.Time      `json:"lastSeen"`     // 组内最近访问时间
+	Records      []AccessRecord `json:"records"`      // 组内记录，保持原顺序
+}
+
+// ProcessRecord 应用维度的访问统计：本机哪些进程通过本地入站使用了代理。
+type ProcessRecord struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`            // 进程名（局域网客户端为「局域网 <IP>」）
+	Path            string    `json:"path"`            // 可执行文件路径，无法获取时为空
+	ConnectionCount int64     `json:"connectionCount"` // 累计连接次数
+	FirstSeen       time.Time `json:"firstSeen"`       // 首次出现时间
+	LastSeen        time.Time `json:"lastSeen"`        // 最近出现时间
 }
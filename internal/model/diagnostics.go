@@ -29,3 +29,51 @@ type DiagnosticSummary struct {
 	LastDiagnosticExport     string             `json:"lastDiagnosticExport"`
 	Current                  DiagnosticSnapshot `json:"current"`
 }
+
+// NetworkCheckStatus 网络诊断单项结果状态。
+type NetworkCheckStatus string
+
+const (
+	NetworkCheckOK      NetworkCheckStatus = "ok"
+	NetworkCheckFailed  NetworkCheckStatus = "fail"
+	NetworkCheckSkipped NetworkCheckStatus = "skip"
+)
+
+// NetworkCheck 网络诊断中的一项检查（网关、DNS、节点连通性等）。
+type NetworkCheck struct {
+	Name    string             `json:"name"`
+	Status  NetworkCheckStatus `json:"status"`
+	Detail  string             `json:"detail"`
+	Latency time.Duration      `json:"latency"`
+}
+
+// NetworkReport 一次网络诊断的完整结果，可导出分享。
+type NetworkReport struct {
+	Timestamp time.Time      `json:"timestamp"`
+	NodeName  string         `json:"nodeName"`
+	NodeAddr  string         `json:"nodeAddr"`
+	Checks    []NetworkCheck `json:"checks"`
+	Hops      []string       `json:"hops"` // traceroute / tracert 原始输出的逐跳行
+}
+
+// DNSResolver DNS 泄漏测试服务观察到的解析器（或出口 IP）。
+type DNSResolver struct {
+	IP      string `json:"ip"`
+	Country string `json:"country"`
+	ASN     string `json:"asn"`
+}
+
+// DNSLeakReport 一次 DNS 泄漏测试的结果：分别在隧道内与本机直连触发解析，比较测试服务看到的解析器。
+type DNSLeakReport struct {
+	Timestamp       time.Time     `json:"timestamp"`
+	Service         string        `json:"service"`
+	ExitIP          string        `json:"exitIP"`          // 测试服务看到的代理出口 IP
+	TunnelResolvers []DNSResolver `json:"tunnelResolvers"` // 经代理访问的域名由这些解析器解析
+	DirectResolvers []DNSResolver `json:"directResolvers"` // 本机解析域名时使用的解析器（运营商或本地 DNS）
+	LeakedResolvers []DNSResolver `json:"leakedResolvers"` // 同时出现在隧道内的直连解析器，非空即为泄漏
+}
+
+// Leaked 是否存在 DNS 泄漏。
+func (r DNSLeakReport) Leaked() bool {
+	return len(r.LeakedResolvers) > 0
+}
@@ -1,9 +1,12 @@
 package model
 
+import "time"
+
 // Node 表示一个代理服务器的配置信息。
 type Node struct {
 	ID           string `json:"id"`            // 服务器唯一标识
 	Name         string `json:"name"`          // 服务器名称
+	OriginalName string `json:"original_name"` // 订阅中的原始名称（应用改名规则前），手动添加的节点为空
 	Addr         string `json:"addr"`          // 服务器地址
 	Port         int    `json:"port"`          // 服务器端口
 	Username     string `json:"username"`      // 认证用户名
@@ -13,6 +16,12 @@ type Node struct {
 	Enabled      bool   `json:"enabled"`       // 是否启用
 	ProtocolType string `json:"protocol_type"` // 协议类型: vmess, ss, ssr, socks5, etc.
 
+	// 健康状态：连续测试失败达到阈值后自动禁用，手动测速成功后恢复
+	FailCount      int       `json:"fail_count,omitempty"`      // 连续失败次数
+	DisabledReason string    `json:"disabled_reason,omitempty"` // 自动禁用原因，空表示未被自动禁用
+	DisabledAt     time.Time `json:"disabled_at,omitempty"`     // 自动禁用时间
+	TestedAt       time.Time `json:"tested_at,omitempty"`       // 最近一次测速时间，未测速为零值
+
 	// VMess 协议字段
 	VMessVersion  string `json:"vmess_version,omitempty"`  // VMess 版本 (v)
 	VMessUUID     string `json:"vmess_uuid,omitempty"`     // VMess UUID (id)
@@ -43,4 +52,34 @@ type Node struct {
 
 	// 原始配置 JSON（用于存储完整的协议配置，便于未来扩展）
 	RawConfig string `json:"raw_config,omitempty"` // 原始配置 JSON 字符串
+
+	// 拨号选项：用户在节点高级设置中填写，订阅更新不覆盖
+	DialOptions
+}
+
+// DialOptions 节点的拨号选项，除 BuiltinForward 外对应 xray 出站 streamSettings.sockopt；零值表示使用 xray 默认行为。
+type DialOptions struct {
+	TCPFastOpen          bool   `json:"tcp_fast_open,omitempty"`           // 启用 TCP Fast Open
+	TCPKeepAliveInterval int    `json:"tcp_keep_alive_interval,omitempty"` // TCP 保活探测间隔（秒）
+	TCPUserTimeout       int    `json:"tcp_user_timeout,omitempty"`        // 未确认数据的最长等待时间（毫秒），超时断开连接
+	DomainStrategy       string `json:"domain_strategy,omitempty"`         // 服务器域名解析策略：AsIs、UseIP、UseIPv4、ForceIPv6 等
+	HappyEyeballsDelay   int    `json:"happy_eyeballs_delay,omitempty"`    // Happy Eyeballs 尝试下一个地址前的等待（毫秒），需配合 UseIP 类策略
+	BuiltinForward       bool   `json:"builtin_forward,omitempty"`         // SOCKS5 节点使用内置转发而不启动 xray（不写入 sockopt）
+}
+
+// IsZero 是否未设置任何拨号选项。
+func (o DialOptions) IsZero() bool {
+	return o == DialOptions{}
+}
+
+// SockoptZero 是否未设置任何需写入 xray sockopt 的选项。
+func (o DialOptions) SockoptZero() bool {
+	o.BuiltinForward = false
+	return o.IsZero()
+}
+
+// DelaySample 一次测速结果，用于按历史推断节点特征。
+type DelaySample struct {
+	Delay    int       `json:"delay"` // 延迟（毫秒），<= 0 表示失败
+	TestedAt time.Time `json:"tested_at"`
 }
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// NodeValidationError 节点字段不满足协议要求时返回，Problems 逐条列出缺失或不兼容的字段。
+type NodeValidationError struct {
+	NodeName string
+	Protocol string
+	Problems []string
+}
+
+func (e *NodeValidationError) Error() string {
+	return fmt.Sprintf("节点 %s (%s) 配置无效: %s", e.NodeName, e.Protocol, strings.Join(e.Problems, "；"))
+}
+
+// vmessIDGroups 标准 UUID 各段的十六进制字符数
+var vmessIDGroups = []int{8, 4, 4, 4, 12}
+
+// ValidVMessID 判断 VMess ID 能否被 xray 接受：标准 UUID（可省略连字符），
+// 或 1-30 字节的任意字符串（xray 将其映射为 UUID）。规则与 xray 解析 ID（uuid.ParseString）时一致。
+func ValidVMessID(id string) bool {
+	if n := len(id); n < 32 || n > 36 {
+		return n >= 1 && n <= 30
+	}
+	text := id
+	for _, group := range vmessIDGroups {
+		text = strings.TrimPrefix(text, "-")
+		if len(text) < group {
+			return false
+		}
+		if _, err := hex.DecodeString(text[:group]); err != nil {
+			return false
+		}
+		text = text[group:]
+	}
+	return true
+}
+
+// NodeFieldProblems 检查节点的基本字段：地址、端口与各协议的必需字段（UUID、密码、加密方式），
+// 不判断 xray 是否支持该协议、传输或加密方式。保存节点与启动代理前共用这部分规则；ssr 按 Shadowsocks 的字段检查。
+// SOCKS5 的密码可以为空（RFC 1929），不做要求。
+func NodeFieldProblems(server *Node) []string {
+	var problems []string
+	if strings.TrimSpace(server.Addr) == "" {
+		problems = append(problems, "缺少服务器地址")
+	}
+	if server.Port <= 0 || server.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("端口 %d 超出范围，应为 1-65535", server.Port))
+	}
+
+	switch server.ProtocolType {
+	case "vmess":
+		if server.VMessUUID == "" {
+			problems = append(problems, "缺少 UUID")
+		} else if !ValidVMessID(server.VMessUUID) {
+			problems = append(problems, fmt.Sprintf("UUID 格式无效: %s（应为标准 UUID 或不超过 30 字节的字符串）", server.VMessUUID))
+		}
+	case "ss", "ssr":
+		if server.SSMethod == "" {
+			problems = append(problems, "缺少加密方式")
+		}
+		if server.Password == "" {
+			problems = append(problems, "缺少密码")
+		}
+	case "trojan":
+		if server.Password == "" {
+			problems = append(problems, "缺少密码")
+		}
+	}
+	return problems
+}
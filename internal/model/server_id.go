@@ -0,0 +1,33 @@
+package model
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GenerateServerID 生成服务器 ID。
+// 同一节点（地址、端口、身份标识相同）每次解析都得到相同的 ID，订阅刷新后延迟、选中等状态可按 ID 保留。
+// 参数：
+//   - addr: 服务器地址（不区分大小写）
+//   - port: 服务器端口
+//   - identity: 身份标识（VMess 为 UUID，SS/Trojan 为密码，SOCKS5 为用户名）
+//
+// 返回：服务器 ID（MD5 哈希）
+func GenerateServerID(addr string, port int, identity string) string {
+	data := fmt.Sprintf("%s:%d:%s", strings.ToLower(strings.TrimSpace(addr)), port, identity)
+	hash := md5.Sum([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// DeriveServerID 在 ID 冲突时由原 ID 与区分标识（同批次序号、订阅地址等）派生新 ID，结果同样稳定。
+// 参数：
+//   - baseID: 冲突的原 ID
+//   - discriminator: 区分标识
+//
+// 返回：派生的服务器 ID（MD5 哈希）
+func DeriveServerID(baseID, discriminator string) string {
+	hash := md5.Sum([]byte(baseID + "#" + discriminator))
+	return hex.EncodeToString(hash[:])
+}
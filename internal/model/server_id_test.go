@@ -0,0 +1,52 @@
+package model
+
+import "testing"
+
+func TestGenerateServerIDStable(t *testing.T) {
+	id := GenerateServerID("example.com", 443, "uuid-1")
+	if again := GenerateServerID("example.com", 443, "uuid-1"); again != id {
+		t.Fatalf("同一节点两次生成的 ID 不同: %s != %s", id, again)
+	}
+	// 地址不区分大小写，忽略首尾空白
+	if other := GenerateServerID(" Example.COM ", 443, "uuid-1"); other != id {
+		t.Fatalf("地址大小写或空白不同时 ID 应相同: %s != %s", id, other)
+	}
+}
+
+func TestGenerateServerIDDistinct(t *testing.T) {
+	base := GenerateServerID("example.com", 443, "uuid-1")
+	cases := []struct {
+		name     string
+		addr     string
+		port     int
+		identity string
+	}{
+		{"不同地址", "example.org", 443, "uuid-1"},
+		{"不同端口", "example.com", 8443, "uuid-1"},
+		{"不同身份标识", "example.com", 443, "uuid-2"},
+		// 拼接时端口与身份标识之间有分隔符，不会与相邻字段混淆
+		{"端口与标识拼接相同", "example.com", 44, "3:uuid-1"},
+	}
+	for _, c := range cases {
+		if id := GenerateServerID(c.addr, c.port, c.identity); id == base {
+			t.Errorf("%s: ID 不应与原节点相同", c.name)
+		}
+	}
+}
+
+func TestDeriveServerID(t *testing.T) {
+	base := GenerateServerID("example.com", 443, "uuid-1")
+	derived := DeriveServerID(base, "2")
+	if derived == base {
+		t.Fatal("派生 ID 不应与原 ID 相同")
+	}
+	if again := DeriveServerID(base, "2"); again != derived {
+		t.Fatalf("相同区分标识派生的 ID 应稳定: %s != %s", derived, again)
+	}
+	if other := DeriveServerID(base, "3"); other == derived {
+		t.Fatal("不同区分标识派生的 ID 应不同")
+	}
+	if manual := DeriveServerID(base, "manual"); manual == DeriveServerID(base, "https://sub.example.com") {
+		t.Fatal("手动节点与订阅节点派生的 ID 应不同")
+	}
+}
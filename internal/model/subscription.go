@@ -1,12 +1,84 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Subscription 表示一个订阅配置，包含 URL 和标签信息。
 type Subscription struct {
-	ID        int64     `json:"id"`
-	URL       string    `json:"url"`
-	Label     string    `json:"label"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            int64             `json:"id"`
+	URL           string            `json:"url"`
+	Label         string            `json:"label"`
+	IncludeFilter string            `json:"include_filter"`      // 节点名称包含规则（正则），非空时仅保留匹配的节点
+	ExcludeFilter string            `json:"exclude_filter"`      // 节点名称排除规则（正则），匹配的节点会被丢弃
+	RenameRules   RenameRules       `json:"rename_rules"`        // 节点改名规则，保存节点时应用
+	LastDiff      *SubscriptionDiff `json:"last_diff,omitempty"` // 最近一次更新相对上次的节点变化，从未更新时为 nil
+	Locked        bool              `json:"locked"`              // 锁定后不能编辑或删除（仍可更新节点），需先解锁
+	TestURL       string            `json:"test_url"`            // 测速地址，非空时该订阅的节点以此代替全局探测地址（如国内中转线路使用国内地址）
+	ETag          string            `json:"-"`                   // 上次拉取响应的 ETag，更新时以 If-None-Match 发送；仅本机缓存，不导出
+	LastModified  string            `json:"-"`                   // 上次拉取响应的 Last-Modified，更新时以 If-Modified-Since 发送
+	Userinfo      string            `json:"-"`                   // 上次拉取响应的 subscription-userinfo（流量与到期时间），服务器未提供时为空
+	LastError     string            `json:"-"`                   // 最近一次更新失败的原因，更新成功后清空
+	LastErrorAt   time.Time         `json:"-"`                   // 最近一次更新失败的时间，没有失败记录时为零值
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// SubscriptionDiff 订阅更新前后的节点变化，按订阅中的原始名称比对。
+type SubscriptionDiff struct {
+	At      time.Time `json:"at"`
+	Added   []string  `json:"added,omitempty"`   // 新增节点名称
+	Removed []string  `json:"removed,omitempty"` // 移除节点名称
+	Changed []string  `json:"changed,omitempty"` // 名称不变但协议、地址或端口变化的节点
+}
+
+// IsEmpty 判断本次更新是否没有节点变化。
+func (d *SubscriptionDiff) IsEmpty() bool {
+	return d == nil || (len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0)
+}
+
+// Summary 返回「本次更新 +5 -2 个节点」形式的摘要，有变更节点时追加「，3 个变更」。
+func (d *SubscriptionDiff) Summary() string {
+	if d.IsEmpty() {
+		return "本次更新节点无变化"
+	}
+	s := fmt.Sprintf("本次更新 +%d -%d 个节点", len(d.Added), len(d.Removed))
+	if len(d.Changed) > 0 {
+		s += fmt.Sprintf("，%d 个变更", len(d.Changed))
+	}
+	return s
+}
+
+// SubscriptionQuota 订阅流量与到期信息（由 subscription-userinfo 响应头解析），流量单位为字节。
+type SubscriptionQuota struct {
+	Upload   int64
+	Download int64
+	Total    int64     // 总流量，0 表示未提供
+	Expire   time.Time // 到期时间，零值表示未提供
+}
+
+// Used 返回已用流量（上传 + 下载）。
+func (q SubscriptionQuota) Used() int64 {
+	return q.Upload + q.Download
+}
+
+// Remaining 返回剩余流量，未提供总流量时返回 -1，已用完时为 0。
+func (q SubscriptionQuota) Remaining() int64 {
+	if q.Total <= 0 {
+		return -1
+	}
+	return max(q.Total-q.Used(), 0)
+}
+
+// RenameRules 订阅节点改名规则，按 StripEmoji → RemovePattern → Template 的顺序应用。
+type RenameRules struct {
+	StripEmoji    bool   `json:"strip_emoji,omitempty"`    // 去除名称中的 emoji（含国旗）
+	RemovePattern string `json:"remove_pattern,omitempty"` // 要移除的片段（正则），如机场前缀 "^【.*?】"
+	Template      string `json:"template,omitempty"`       // 名称模板，支持 {name}、{label}、{index}；为空时保持名称
+}
+
+// IsZero 判断是否未配置任何改名规则。
+func (r RenameRules) IsZero() bool {
+	return !r.StripEmoji && r.RemovePattern == "" && r.Template == ""
 }
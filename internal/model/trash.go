@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// TrashRetention 回收站条目的保留时长，超期后由清理任务永久删除。
+const TrashRetention = 30 * 24 * time.Hour
+
+// TrashKind 回收站条目类型。
+type TrashKind string
+
+const (
+	TrashKindSubscription TrashKind = "subscription" // 订阅（连同删除时下属的节点）
+	TrashKindNode         TrashKind = "node"         // 单独删除的节点
+)
+
+// TrashItem 回收站中的一个订阅或节点。
+type TrashItem struct {
+	Kind           TrashKind
+	SubscriptionID int64  // 订阅条目的 ID
+	NodeID         string // 节点条目的 ID
+	Name           string
+	NodeCount      int // 订阅条目随之移入回收站的节点数
+	DeletedAt      time.Time
+}
+
+// ExpiresAt 返回条目被永久删除的时间。
+func (t TrashItem) ExpiresAt() time.Time {
+	return t.DeletedAt.Add(TrashRetention)
+}
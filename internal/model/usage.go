@@ -0,0 +1,9 @@
+package model
+
+// UsageDay 某天使用某个节点的汇总，仅保存在本机数据库。
+type UsageDay struct {
+	Day      string `json:"day"`      // 本地日期，YYYY-MM-DD
+	Node     string `json:"node"`     // 节点名称（记录时的名称）
+	Connects int    `json:"connects"` // 连接次数，重启与自动重连不计
+	Seconds  int64  `json:"seconds"`  // 保持连接的秒数
+}
@@ -0,0 +1,42 @@
+package procinfo
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// lookupDarwin 通过 lsof 查找本地端口为 localPort 的 TCP 连接所属进程。
+// 输出格式（-F pcn）：p<pid> / c<command> / n<local>-><remote>，同一端口会同时列出客户端与服务端两侧，
+// 这里只取本地地址以 :localPort 结尾的一侧。
+func lookupDarwin(localPort int) (Process, error) {
+	port := strconv.Itoa(localPort)
+	out, err := exec.Command("lsof", "-nP", "-iTCP:"+port, "-sTCP:ESTABLISHED", "-Fpcn").Output()
+	if err != nil {
+		// 无匹配连接时 lsof 以非 0 退出
+		return Process{}, ErrNotFound
+	}
+
+	var cur Process
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			pid, _ := strconv.Atoi(line[1:])
+			cur = Process{PID: pid}
+		case 'c':
+			cur.Name = line[1:]
+		case 'n':
+			local := line[1:]
+			if idx := strings.Index(local, "->"); idx >= 0 {
+				local = local[:idx]
+			}
+			if strings.HasSuffix(local, ":"+port) {
+				return cur, nil
+			}
+		}
+	}
+	return Process{}, ErrNotFound
+}
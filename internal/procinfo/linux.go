@@ -0,0 +1,81 @@
+package procinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lookupLinux 在 /proc/net/tcp{,6} 中找到本地端口为 localPort 的连接 inode，再遍历 /proc/*/fd 定位进程。
+func lookupLinux(localPort int) (Process, error) {
+	inode := ""
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if inode = findSocketInode(path, localPort); inode != "" {
+			break
+		}
+	}
+	if inode == "" {
+		return Process{}, ErrNotFound
+	}
+
+	target := "socket:[" + inode + "]"
+	pids, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return Process{}, err
+	}
+	for _, dir := range pids {
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue // 其他用户的进程无权限读取
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || link != target {
+				continue
+			}
+			pid, _ := strconv.Atoi(filepath.Base(dir))
+			p := Process{PID: pid}
+			if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+				p.Name = strings.TrimSpace(string(comm))
+			}
+			if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+				p.Path = exe
+			}
+			return p, nil
+		}
+	}
+	return Process{}, ErrNotFound
+}
+
+// findSocketInode 解析 /proc/net/tcp 格式的文件，返回本地端口匹配的已建立连接的 inode。
+// 行格式：sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+func findSocketInode(path string, localPort int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	portHex := strings.ToUpper(strconv.FormatInt(int64(localPort), 16))
+	for len(portHex) < 4 {
+		portHex = "0" + portHex
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		// st=01 为 ESTABLISHED
+		if fields[3] != "01" || !strings.HasSuffix(fields[1], ":"+portHex) {
+			continue
+		}
+		if fields[9] != "0" {
+			return fields[9]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+// Package procinfo 根据本机 TCP 连接的源端口查找发起连接的进程，用于统计哪些应用在使用本地代理入站。
+package procinfo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrNotFound 未找到持有该端口的进程（连接已关闭或无权限读取）。
+var ErrNotFound = errors.New("未找到对应进程")
+
+// Process 进程信息。
+type Process struct {
+	PID  int    // 进程 ID
+	Name string // 进程名（如 chrome、Code.exe）
+	Path string // 可执行文件路径，无法获取时为空
+}
+
+// DisplayName 返回用于展示与聚合的名称：优先取可执行文件名，去掉 Windows 的 .exe 后缀。
+func (p Process) DisplayName() string {
+	name := p.Name
+	if p.Path != "" {
+		name = filepath.Base(p.Path)
+	}
+	name = strings.TrimSuffix(name, ".exe")
+	if name == "" {
+		name = fmt.Sprintf("pid %d", p.PID)
+	}
+	return name
+}
+
+// LookupTCPClient 查找本机源端口为 localPort 的 TCP 连接所属进程；当前进程自身的连接不计入。
+func LookupTCPClient(localPort int) (Process, error) {
+	if localPort <= 0 || localPort > 65535 {
+		return Process{}, fmt.Errorf("procinfo: 端口无效: %d", localPort)
+	}
+	var (
+		p   Process
+		err error
+	)
+	switch runtime.GOOS {
+	case "linux":
+		p, err = lookupLinux(localPort)
+	case "darwin":
+		p, err = lookupDarwin(localPort)
+	case "windows":
+		p, err = lookupWindows(localPort)
+	default:
+		return Process{}, fmt.Errorf("procinfo: 不支持的操作系统: %s", runtime.GOOS)
+	}
+	if err != nil {
+		return Process{}, err
+	}
+	if p.PID == os.Getpid() {
+		return Process{}, ErrNotFound
+	}
+	return p, nil
+}
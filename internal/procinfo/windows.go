@@ -0,0 +1,42 @@
+package procinfo
+
+import (
+	"encoding/csv"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// lookupWindows 通过 netstat -ano 查找本地端口为 localPort 的 TCP 连接的 PID，再用 tasklist 取进程名。
+// 行格式：  TCP    127.0.0.1:52101    127.0.0.1:10808    ESTABLISHED    1234
+func lookupWindows(localPort int) (Process, error) {
+	out, err := exec.Command("netstat", "-ano", "-p", "TCP").Output()
+	if err != nil {
+		return Process{}, err
+	}
+	suffix := ":" + strconv.Itoa(localPort)
+	pid := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.EqualFold(fields[0], "TCP") {
+			continue
+		}
+		if strings.HasSuffix(fields[1], suffix) {
+			pid, _ = strconv.Atoi(fields[4])
+			break
+		}
+	}
+	if pid == 0 {
+		return Process{}, ErrNotFound
+	}
+
+	p := Process{PID: pid}
+	// tasklist CSV 输出："chrome.exe","1234","Console","1","123,456 K"
+	out, err = exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/FO", "CSV", "/NH").Output()
+	if err == nil {
+		if rec, err := csv.NewReader(strings.NewReader(string(out))).Read(); err == nil && len(rec) > 0 {
+			p.Name = rec[0]
+		}
+	}
+	return p, nil
+}
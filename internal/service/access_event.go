@@ -0,0 +1,109 @@
+package service
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// 访问事件的出站选择方式，对应 xray 访问日志中入站与出站 tag 之间的分隔符
+const (
+	AccessRouteRule     = "rule"     // 命中路由规则（->）
+	AccessRouteBalancer = "balancer" // 由负载均衡组选择（==>）
+	AccessRouteDefault  = "default"  // 未命中规则，使用默认出站（>>）
+)
+
+// AccessEvent 实时解析到的一次连接，供访问记录的实时视图显示；包含纯 IP 目标。
+type AccessEvent struct {
+	Time     time.Time
+	Network  string // tcp 或 udp，日志未注明时为空
+	Host     string
+	Port     string
+	Inbound  string // 入站 tag
+	Outbound string // 出站 tag，如 proxy、direct、block
+	Route    string // AccessRouteRule / AccessRouteBalancer / AccessRouteDefault，日志无路由信息时为空
+}
+
+// Subscribe 注册实时访问事件回调（在日志 goroutine 中调用），返回取消函数。
+// 仅在按完整地址记录时推送，关闭或匿名记录时不推送。
+func (ars *AccessRecordService) Subscribe(fn func(AccessEvent)) (unsubscribe func()) {
+	ars.listenerMu.Lock()
+	defer ars.listenerMu.Unlock()
+	if ars.listeners == nil {
+		ars.listeners = make(map[int]func(AccessEvent))
+	}
+	id := ars.nextListener
+	ars.nextListener++
+	ars.listeners[id] = fn
+	return func() {
+		ars.listenerMu.Lock()
+		defer ars.listenerMu.Unlock()
+		delete(ars.listeners, id)
+	}
+}
+
+// publishAccessEvent 有订阅时解析访问日志行并推送。
+func (ars *AccessRecordService) publishAccessEvent(line string) {
+	ars.listenerMu.Lock()
+	if len(ars.listeners) == 0 {
+		ars.listenerMu.Unlock()
+		return
+	}
+	listeners := make([]func(AccessEvent), 0, len(ars.listeners))
+	for _, fn := range ars.listeners {
+		listeners = append(listeners, fn)
+	}
+	ars.listenerMu.Unlock()
+
+	if ars.mode() != AccessRecordModeFull {
+		return
+	}
+	event, ok := parseAccessEvent(line)
+	if !ok {
+		return
+	}
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// parseAccessEvent 解析 xray 访问日志行，如
+// 2026/02/12 10:20:40.159520 from tcp:127.0.0.1:52101 accepted tcp:api2.cursor.sh:443 [mixed-in -> proxy]
+func parseAccessEvent(line string) (AccessEvent, bool) {
+	idx := strings.Index(line, " accepted ")
+	if idx == -1 {
+		return AccessEvent{}, false
+	}
+	rest := strings.TrimSpace(line[idx+len(" accepted "):])
+	target, detour, _ := strings.Cut(rest, " ")
+	event := AccessEvent{Time: time.Now()}
+	if network, addr, ok := strings.Cut(target, ":"); ok && (network == "tcp" || network == "udp") {
+		event.Network, target = network, addr
+	}
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(target, "//"))
+	if err != nil || host == "" {
+		return AccessEvent{}, false
+	}
+	event.Host, event.Port = host, port
+
+	if start := strings.Index(detour, "["); start != -1 {
+		if end := strings.Index(detour[start:], "]"); end != -1 {
+			event.Inbound, event.Outbound, event.Route = parseAccessDetour(detour[start+1 : start+end])
+		}
+	}
+	return event, true
+}
+
+// parseAccessDetour 解析访问日志方括号内的「入站 分隔符 出站」；没有入站 tag 时整段为出站。
+func parseAccessDetour(detour string) (inbound, outbound, route string) {
+	for _, sep := range []struct{ text, route string }{
+		{" ==> ", AccessRouteBalancer},
+		{" -> ", AccessRouteRule},
+		{" >> ", AccessRouteDefault},
+	} {
+		if in, out, ok := strings.Cut(detour, sep.text); ok {
+			return in, out, sep.route
+		}
+	}
+	return "", detour, ""
+}
@@ -1,163 +1,324 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/utils"
+	"myproxy.com/p/internal/xray"
 )
 
 const (
-	accessRecordFlushDebounce    = 2 * time.Second // 合并写库前的最长等待
-	accessRecordFlushMaxPending  = 400             // 待刷盘地址数上限，防止高流量时 map 过大
+	accessRecordFlushInterval   = 5 * time.Second // 写缓冲刷盘间隔：首条待写访问之后最多等待这么久
+	accessRecordFlushMaxPending = 400             // 待刷盘地址数上限，达到后立即刷盘，防止高流量时 map 过大
 )
 
 // AccessRecordService 访问记录服务，提供从日志解析并记录访问记录的能力。
 type AccessRecordService struct {
-	store *store.Store
+	store  *store.Store
+	config *ConfigService
 
-	// 批量模式：用于 loadInitialLogs 等场景，避免逐行写入 DB
-	mu          sync.Mutex
-	batchMode   bool
-	batchCounts map[string]int64
-
-	// 非批量模式：内存合并后定时/定量刷盘，降低长期运行下 SQLite 写入频率
-	pending    map[string]int64
+	// 写缓冲：解析到的访问先在内存中按地址合并，每隔 accessRecordFlushInterval 在一个事务中批量写库，
+	// 避免每条访问日志都写一次 SQLite；退出时由 Flush 落盘
+	mu         sync.Mutex
+	pending    map[string]model.AccessTally
 	flushTimer *time.Timer
-	flushGen   uint64 // 每次重排定时器递增，避免旧 AfterFunc 回调误清空新 timer
+
+	connections atomic.Int64 // 本次运行解析到的连接数
+
+	session *SessionReportService // 连接报告，为 nil 时不统计
+
+	// 实时视图订阅（见 access_event.go），无订阅时不解析路由信息
+	listenerMu   sync.Mutex
+	listeners    map[int]func(AccessEvent)
+	nextListener int
+
+	ipInfo ipInfoCache // 纯 IP 目标的反向解析与 GeoIP 结果（见 ip_info.go）
+
+	// 应用自身经本地入站建立的连接（如连接预热），其访问日志不计入访问记录；键为连接的本地地址
+	ignoredMu      sync.Mutex
+	ignoredSources map[string]struct{}
+	ignoredCount   atomic.Int32
 }
 
-// xray 访问日志格式（空格分割）：第 6 个字段为 host:port
+// xray 访问日志格式（空格分割）：第 6 个字段为 host:port，方括号内为「入站 -> 出站」
 // 示例: 2026/02/12 10:20:40.159520 from tcp:127.0.0.1:52101 accepted tcp:api2.cursor.sh:443 [mixed-in -> proxy]
 // 示例: 2026/02/12 10:20:42.465015 from 127.0.0.1:52117 accepted //www.google.com:443 [mixed-in -> proxy]
 // 字段索引: 0          1               2    3                   4        5
 
-// NewAccessRecordService 创建访问记录服务实例。
-func NewAccessRecordService(store *store.Store) *AccessRecordService {
-	return &AccessRecordService{store: store}
+// NewAccessRecordService 创建访问记录服务实例；config 为 nil 时按完整地址记录。
+func NewAccessRecordService(store *store.Store, config *ConfigService) *AccessRecordService {
+	return &AccessRecordService{store: store, config: config}
 }
 
-// StartBatch 开启批量模式，后续 RecordAccessFromLogLine 将累积到内存，由 EndBatch 统一写入。
-func (ars *AccessRecordService) StartBatch() {
-	_ = ars.Flush()
-	ars.mu.Lock()
-	ars.batchMode = true
-	ars.batchCounts = make(map[string]int64)
-	ars.mu.Unlock()
-}
-
-// EndBatch 结束批量模式，将累积的访问记录写入 DB。
-func (ars *AccessRecordService) EndBatch() error {
-	ars.mu.Lock()
-	batchMode := ars.batchMode
-	counts := ars.batchCounts
-	ars.batchMode = false
-	ars.batchCounts = nil
-	ars.mu.Unlock()
-
-	if !batchMode || len(counts) == 0 || ars.store == nil || ars.store.AccessRecords == nil {
-		return nil
-	}
-	return ars.store.AccessRecords.RecordAccessBatch(counts)
+// SetSessionReport 设置连接报告服务，实时解析到的连接计入当前连接的报告。
+func (ars *AccessRecordService) SetSessionReport(session *SessionReportService) {
+	ars.session = session
 }
 
-// RecordAccessFromLogLine 解析日志行，若为 xray 访问日志则提取 address (host:port) 并记录。
-// 批量模式下累积到 batchCounts；否则写入 pending，经防抖或达到上限后批量落库。
+// RecordAccessFromLogLine 解析日志行，若为 xray 访问日志则提取 address (host:port) 与匹配的出站并记录。
+// 按访问记录方式：off 不记录，hashed 以哈希替代主机名；记录先进入写缓冲，定时或达到上限后批量落库。
 // 返回：是否成功记录（true 表示解析到并记录了地址）。
 func (ars *AccessRecordService) RecordAccessFromLogLine(line string) bool {
-	address := extractAddressFromXrayAccessLine(line)
+	if ars.ignoredCount.Load() > 0 && ars.isIgnoredSource(accessLineSource(line)) {
+		return false
+	}
+	ars.publishAccessEvent(line)
+	address, outbound := extractAddressFromXrayAccessLine(line, ars.recordIPs())
 	if address == "" {
 		return false
 	}
 	if ars.store == nil || ars.store.AccessRecords == nil {
 		return false
 	}
+	ars.connections.Add(1)
 
-	ars.mu.Lock()
-	if ars.batchMode {
-		ars.batchCounts[address]++
-		ars.mu.Unlock()
-		return true
+	mode := ars.mode()
+	if ars.session != nil {
+		// 关闭或匿名记录时报告只计连接数，不列出站点
+		if mode == AccessRecordModeFull {
+			ars.session.RecordDestination(address)
+		} else {
+			ars.session.RecordDestination("")
+		}
 	}
-	if ars.pending == nil {
-		ars.pending = make(map[string]int64)
+
+	switch mode {
+	case AccessRecordModeOff:
+		return false
+	case AccessRecordModeHashed:
+		address = hashAccessAddress(address, ars.config.GetAccessRecordHashKey())
 	}
-	ars.pending[address]++
-	if ars.flushTimer != nil {
-		ars.flushTimer.Stop()
-		ars.flushTimer = nil
+
+	ars.mu.Lock()
+	if ars.pending == nil {
+		ars.pending = make(map[string]model.AccessTally)
 	}
+	tallyAccess(ars.pending, address, outbound)
 	if len(ars.pending) >= accessRecordFlushMaxPending {
-		ars.flushGen++
-		counts := ars.pending
-		ars.pending = make(map[string]int64)
+		counts := ars.takePendingLocked()
 		ars.mu.Unlock()
-		_ = ars.store.AccessRecords.RecordAccessBatch(counts)
+		_ = ars.writeTallies(counts)
 		return true
 	}
-	ars.flushGen++
-	myGen := ars.flushGen
-	ars.flushTimer = time.AfterFunc(accessRecordFlushDebounce, func() {
-		ars.flushPendingAsync(myGen)
-	})
+	ars.scheduleFlushLocked()
 	ars.mu.Unlock()
 	return true
 }
 
-// flushPendingAsync 由定时器在独立 goroutine 调用，将 pending 合并写入数据库。
-func (ars *AccessRecordService) flushPendingAsync(myGen uint64) {
-	if ars == nil {
-		return
+// IgnoreSource 忽略来自本地地址 source（如 127.0.0.1:52101）的访问日志，直到 ReleaseSource。
+func (ars *AccessRecordService) IgnoreSource(source string) {
+	ars.ignoredMu.Lock()
+	defer ars.ignoredMu.Unlock()
+	if ars.ignoredSources == nil {
+		ars.ignoredSources = make(map[string]struct{})
 	}
-	ars.mu.Lock()
-	if ars.flushGen != myGen {
-		ars.mu.Unlock()
-		return
+	if _, ok := ars.ignoredSources[source]; !ok {
+		ars.ignoredSources[source] = struct{}{}
+		ars.ignoredCount.Add(1)
+	}
+}
+
+// ReleaseSource 取消对 source 的忽略（连接关闭后本地端口可能被其他程序复用）。
+func (ars *AccessRecordService) ReleaseSource(source string) {
+	ars.ignoredMu.Lock()
+	defer ars.ignoredMu.Unlock()
+	if _, ok := ars.ignoredSources[source]; ok {
+		delete(ars.ignoredSources, source)
+		ars.ignoredCount.Add(-1)
+	}
+}
+
+func (ars *AccessRecordService) isIgnoredSource(source string) bool {
+	if source == "" {
+		return false
+	}
+	// 开启入站限速时日志中的来源是转发连接，按客户端的实际地址判断
+	if client, ok := xray.RelayClientAddr(source); ok {
+		source = client
 	}
+	ars.ignoredMu.Lock()
+	defer ars.ignoredMu.Unlock()
+	_, ok := ars.ignoredSources[source]
+	return ok
+}
+
+// accessLineSource 取访问日志中 from 之后的来源地址（去掉 tcp:/udp: 前缀），非访问日志返回空。
+func accessLineSource(line string) string {
+	idx := strings.Index(line, "from ")
+	if idx == -1 {
+		return ""
+	}
+	fields := strings.Fields(line[idx+len("from "):])
+	if len(fields) < 2 || fields[1] != "accepted" {
+		return ""
+	}
+	source := fields[0]
+	if strings.HasPrefix(source, "tcp:") || strings.HasPrefix(source, "udp:") {
+		source = source[4:]
+	}
+	return source
+}
+
+// scheduleFlushLocked 写缓冲中有数据且尚未安排刷盘时，在 accessRecordFlushInterval 后刷盘。
+// 后续写入不推迟已安排的刷盘，持续有流量时也按固定间隔落库。调用方需持有 mu。
+func (ars *AccessRecordService) scheduleFlushLocked() {
+	if ars.flushTimer == nil && len(ars.pending) > 0 {
+		ars.flushTimer = time.AfterFunc(accessRecordFlushInterval, func() { _ = ars.Flush() })
+	}
+}
+
+// takePendingLocked 取出写缓冲中的全部数据并取消已安排的刷盘。调用方需持有 mu。
+func (ars *AccessRecordService) takePendingLocked() map[string]model.AccessTally {
 	if ars.flushTimer != nil {
+		ars.flushTimer.Stop()
 		ars.flushTimer = nil
 	}
-	if len(ars.pending) == 0 {
-		ars.mu.Unlock()
+	counts := ars.pending
+	ars.pending = make(map[string]model.AccessTally)
+	return counts
+}
+
+// writeTallies 在一个事务中写入合并后的访问次数；失败时放回写缓冲，下次刷盘重试。
+func (ars *AccessRecordService) writeTallies(counts map[string]model.AccessTally) error {
+	if len(counts) == 0 || ars.store == nil || ars.store.AccessRecords == nil {
+		return nil
+	}
+	err := ars.store.AccessRecords.RecordAccessBatch(counts)
+	if err == nil {
+		return nil
+	}
+	ars.mu.Lock()
+	defer ars.mu.Unlock()
+	for address, t := range counts {
+		merged := ars.pending[address]
+		merged.Count += t.Count
+		merged.Direct += t.Direct
+		merged.Proxied += t.Proxied
+		if merged.Outbound == "" {
+			merged.Outbound = t.Outbound
+		}
+		ars.pending[address] = merged
+	}
+	ars.scheduleFlushLocked()
+	return err
+}
+
+// recordIPs 是否记录纯 IP 目标。
+func (ars *AccessRecordService) recordIPs() bool {
+	return ars.config != nil && ars.config.GetAccessRecordIPs()
+}
+
+// tallyAccess 将一次访问计入 counts：按出站 tag 区分直连与代理，并记下最近的出站。
+func tallyAccess(counts map[string]model.AccessTally, address, outbound string) {
+	t := counts[address]
+	t.Count++
+	switch {
+	case outbound == "direct":
+		t.Direct++
+	case outbound == "proxy" || strings.HasPrefix(outbound, "proxy-"): // 含负载均衡组节点 proxy-0、proxy-1…
+		t.Proxied++
+	}
+	if outbound != "" {
+		t.Outbound = outbound
+	}
+	counts[address] = t
+}
+
+// mode 返回当前访问记录方式。
+func (ars *AccessRecordService) mode() string {
+	if ars.config == nil {
+		return AccessRecordModeFull
+	}
+	return ars.config.GetAccessRecordMode()
+}
+
+// hashAccessAddress 以本机密钥对主机名做 HMAC，返回「#哈希前 12 位:端口」，只保留访问次数而不暴露站点。
+func hashAccessAddress(address, key string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, ""
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strings.ToLower(host)))
+	hashed := "#" + hex.EncodeToString(mac.Sum(nil))[:12]
+	if port == "" {
+		return hashed
+	}
+	return net.JoinHostPort(hashed, port)
+}
+
+// Discard 丢弃内存中尚未落盘的访问记录（清除记录时调用，避免清空后又被写回）。
+func (ars *AccessRecordService) Discard() {
+	if ars == nil {
 		return
 	}
-	counts := ars.pending
-	ars.pending = make(map[string]int64)
-	storeRef := ars.store
-	ars.mu.Unlock()
-	if storeRef != nil && storeRef.AccessRecords != nil {
-		_ = storeRef.AccessRecords.RecordAccessBatch(counts)
+	ars.mu.Lock()
+	defer ars.mu.Unlock()
+	ars.takePendingLocked()
+}
+
+// ConnectionCount 返回本次运行以来经代理建立的连接数。
+func (ars *AccessRecordService) ConnectionCount() int64 {
+	return ars.connections.Load()
+}
+
+// GroupAccessRecords 按可注册域名（公共后缀 + 一级）归并访问记录，组按最近访问时间倒序，组内保持原顺序。
+func GroupAccessRecords(records []model.AccessRecord) []model.AccessRecordGroup {
+	index := make(map[string]int)
+	var groups []model.AccessRecordGroup
+	for _, r := range records {
+		host := r.Domain
+		if h, _, err := net.SplitHostPort(r.Address); err == nil {
+			host = h
+		}
+		domain := utils.RegistrableDomain(host)
+		if domain == "" {
+			continue // 无法识别主机的记录不参与展示
+		}
+		i, ok := index[domain]
+		if !ok {
+			i = len(groups)
+			index[domain] = i
+			groups = append(groups, model.AccessRecordGroup{Domain: domain})
+		}
+		g := &groups[i]
+		g.Records = append(g.Records, r)
+		g.AccessCount += r.AccessCount
+		g.DirectCount += r.DirectCount
+		g.ProxiedCount += r.ProxiedCount
+		if r.LastSeen.After(g.LastSeen) {
+			g.LastSeen = r.LastSeen
+		}
 	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].LastSeen.After(groups[j].LastSeen) })
+	return groups
 }
 
-// Flush 将内存中待写入的访问记录立即落盘（应用退出或进入批量模式前调用）。
+// Flush 将写缓冲中的访问记录立即落盘（定时刷盘与应用退出时调用）。
 func (ars *AccessRecordService) Flush() error {
 	if ars == nil {
 		return nil
 	}
 	ars.mu.Lock()
-	if ars.flushTimer != nil {
-		ars.flushTimer.Stop()
-		ars.flushTimer = nil
-	}
-	ars.flushGen++
-	var counts map[string]int64
-	if len(ars.pending) > 0 {
-		counts = ars.pending
-		ars.pending = make(map[string]int64)
-	}
-	storeRef := ars.store
+	counts := ars.takePendingLocked()
 	ars.mu.Unlock()
-	if len(counts) == 0 || storeRef == nil || storeRef.AccessRecords == nil {
-		return nil
-	}
-	return storeRef.AccessRecords.RecordAccessBatch(counts)
+	return ars.writeTallies(counts)
 }
 
 // ExtractAddressFromLogLine 解析日志行提取 address (host:port)，供批量处理使用。
 func (ars *AccessRecordService) ExtractAddressFromLogLine(line string) string {
-	return extractAddressFromXrayAccessLine(line)
+	address, _ := extractAddressFromXrayAccessLine(line, ars.recordIPs())
+	return address
 }
 
 // RecordAccessBatchFromLines 批量解析日志行并记录访问。
@@ -165,38 +326,43 @@ func (ars *AccessRecordService) RecordAccessBatchFromLines(lines []string) error
 	if ars.store == nil || ars.store.AccessRecords == nil {
 		return nil
 	}
-	addressCounts := make(map[string]int64)
+	tallies := make(map[string]model.AccessTally)
+	includeIPs := ars.recordIPs()
 	for _, line := range lines {
-		addr := extractAddressFromXrayAccessLine(line)
-		if addr != "" {
-			addressCounts[addr]++
+		if addr, outbound := extractAddressFromXrayAccessLine(line, includeIPs); addr != "" {
+			tallyAccess(tallies, addr, outbound)
 		}
 	}
-	return ars.store.AccessRecords.RecordAccessBatch(addressCounts)
+	return ars.store.AccessRecords.RecordAccessBatch(tallies)
 }
 
-// RecordAccessBatchFromAddressCounts 根据已统计的地址计数批量记录。
+// RecordAccessBatchFromAddressCounts 根据已统计的地址计数批量记录（不区分出站）。
 func (ars *AccessRecordService) RecordAccessBatchFromAddressCounts(addressCounts map[string]int64) error {
 	if ars.store == nil || ars.store.AccessRecords == nil {
 		return nil
 	}
-	return ars.store.AccessRecords.RecordAccessBatch(addressCounts)
+	tallies := make(map[string]model.AccessTally, len(addressCounts))
+	for addr, count := range addressCounts {
+		tallies[addr] = model.AccessTally{Count: count}
+	}
+	return ars.store.AccessRecords.RecordAccessBatch(tallies)
 }
 
-// extractAddressFromXrayAccessLine 从 xray 访问日志行提取 address (host:port)，保留端口信息。
+// extractAddressFromXrayAccessLine 从 xray 访问日志行提取 address (host:port)，保留端口信息，
+// 并从行尾「[入站 -> 出站]」取出匹配的出站 tag（日志无路由信息时为空）。includeIPs 为 false 时跳过纯 IP 目标。
 // 仅解析包含 "accepted" 的 xray 代理访问日志，排除 app 日志和 xray 启动等日志。
 // 规则：定位 "accepted" 后取其后的第一个 token 为 host:port，兼容有无时间戳两种格式：
-//   - 有 timestamp: 2026/02/12 10:43:05.230386 from tcp:127.0.0.1:59593 accepted tcp:api2.cursor.sh:443
+//   - 有 timestamp: 2026/02/12 10:43:05.230386 from tcp:127.0.0.1:59593 accepted tcp:api2.cursor.sh:443 [mixed-in -> proxy]
 //   - 无 timestamp: from tcp:127.0.0.1:49379 accepted tcp:api2.cursor.sh:443
-func extractAddressFromXrayAccessLine(line string) string {
+func extractAddressFromXrayAccessLine(line string, includeIPs bool) (address, outbound string) {
 	idx := strings.Index(line, "accepted")
 	if idx == -1 {
-		return ""
+		return "", ""
 	}
 	rest := strings.TrimSpace(line[idx+len("accepted"):])
 	fields := strings.Fields(rest)
 	if len(fields) < 1 {
-		return ""
+		return "", ""
 	}
 	hostPort := fields[0]
 	// 去掉 tcp:/udp: 前缀
@@ -206,28 +372,27 @@ func extractAddressFromXrayAccessLine(line string) string {
 	// 去掉 // 前缀
 	hostPort = strings.TrimPrefix(hostPort, "//")
 	// 保留 :port，不剥离
-	address := strings.TrimSpace(hostPort)
+	address = strings.TrimSpace(hostPort)
 	if address == "" || len(address) > 268 || strings.Contains(address, " ") {
-		return ""
+		return "", ""
 	}
-	// 跳过纯 IP（不含端口则无法判断，含端口时 host 部分可能是 IP）
+	// 未开启时跳过纯 IP（不含端口则无法判断，含端口时 host 部分可能是 IP，IPv6 带方括号）
 	host := address
 	if idx := strings.LastIndex(address, ":"); idx > 0 {
 		host = address[:idx]
 	}
-	if isIPLike(host) {
-		return ""
+	if !includeIPs && IsIPHost(host) {
+		return "", ""
 	}
-	return address
-}
-
-func isIPLike(s string) bool {
-	// 简单判断：仅含数字和点
-	for _, r := range s {
-		if (r >= '0' && r <= '9') || r == '.' {
-			continue
+	if start := strings.Index(rest, "["); start != -1 {
+		if end := strings.Index(rest[start:], "]"); end != -1 {
+			_, outbound, _ = parseAccessDetour(rest[start+1 : start+end])
 		}
-		return false
 	}
-	return strings.Contains(s, ".")
+	return address, strings.TrimSpace(outbound)
+}
+
+// IsIPHost 判断访问记录的主机部分是否为 IP 地址（IPv6 可带方括号）。
+func IsIPHost(host string) bool {
+	return net.ParseIP(strings.Trim(host, "[]")) != nil
 }
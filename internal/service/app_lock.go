@@ -0,0 +1,63 @@
+package service
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// appLockHashScheme 应用锁密码哈希的格式标识，保存为「pbkdf2-sha256$迭代次数$盐$哈希」
+	appLockHashScheme = "pbkdf2-sha256"
+	// appLockIterations PBKDF2 迭代次数，解锁一次约需数十毫秒
+	appLockIterations = 200000
+	// appLockSaltSize 随机盐的字节数
+	appLockSaltSize = 16
+	// appLockKeySize 派生哈希的字节数
+	appLockKeySize = 32
+	// AppLockMinPasswordLen 应用锁密码（或 PIN）的最小长度
+	AppLockMinPasswordLen = 4
+)
+
+// hashAppLockPassword 以随机盐计算密码的 PBKDF2-SHA256 哈希，返回可保存的编码字符串。
+func hashAppLockPassword(password string) (string, error) {
+	salt := make([]byte, appLockSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("生成随机盐失败: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, appLockIterations, appLockKeySize)
+	if err != nil {
+		return "", fmt.Errorf("计算密码哈希失败: %w", err)
+	}
+	return strings.Join([]string{appLockHashScheme, strconv.Itoa(appLockIterations), hex.EncodeToString(salt), hex.EncodeToString(key)}, "$"), nil
+}
+
+// verifyAppLockPassword 校验密码是否与编码后的哈希一致；格式无法识别时视为不一致。
+func verifyAppLockPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != appLockHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/subscription"
+	"myproxy.com/p/internal/xray"
+)
+
+// clientImportMaxSize 导入文件的大小上限
+const clientImportMaxSize = 16 << 20
+
+// ClientImportResult 从其他客户端导入的结果。
+type ClientImportResult struct {
+	NodesAdded    int      // 新增的节点数
+	NodesExisting int      // 已存在而跳过的节点数
+	NodeErrors    []string // 保存失败的节点及原因（如字段无效）
+	RoutesAdded   int      // 新增的直连路由条数
+	RulesSkipped  int      // 无法转换的规则数
+	Added         []string // 新增并已拉取的订阅名称
+	Existing      []string // 已存在（按地址）而跳过的订阅名称
+	Errors        []string // 添加或拉取失败的订阅及原因
+	FinalOutbound string   // 原配置的最终规则走向与当前设置不同时为其走向（不自动修改），否则为空
+}
+
+// finalOutboundLabels 最终规则走向的显示名称
+var finalOutboundLabels = map[string]string{xray.FinalOutboundProxy: "代理", xray.FinalOutboundDirect: "直连", xray.FinalOutboundBlock: "阻止"}
+
+// Summary 返回多行摘要。
+func (r *ClientImportResult) Summary() string {
+	nodes := fmt.Sprintf("新增节点 %d 个", r.NodesAdded)
+	if r.NodesExisting > 0 {
+		nodes += fmt.Sprintf("（%d 个已存在）", r.NodesExisting)
+	}
+	lines := []string{nodes}
+	routes := fmt.Sprintf("新增直连路由 %d 条", r.RoutesAdded)
+	if r.RulesSkipped > 0 {
+		routes += fmt.Sprintf("（%d 条 GEOIP、GEOSITE、关键字等规则无法转换，可改用「绕过局域网与中国大陆」）", r.RulesSkipped)
+	}
+	lines = append(lines, routes)
+	if r.FinalOutbound != "" {
+		// 只导入了直连规则，照搬 MATCH,DIRECT 等会让原本走代理的站点改为直连，因此只提示
+		lines = append(lines, fmt.Sprintf("原配置的最终规则为「%s」，未自动修改；如需一致，请在路由设置中修改「最终流量走」", finalOutboundLabels[r.FinalOutbound]))
+	}
+	if len(r.Added) > 0 {
+		lines = append(lines, "新增订阅: "+strings.Join(r.Added, "、"))
+	}
+	if len(r.Existing) > 0 {
+		lines = append(lines, "已存在的订阅: "+strings.Join(r.Existing, "、"))
+	}
+	if len(r.NodeErrors) > 0 {
+		lines = append(lines, fmt.Sprintf("%d 个节点未导入: %s", len(r.NodeErrors), strings.Join(r.NodeErrors, "；")))
+	}
+	if len(r.Errors) > 0 {
+		lines = append(lines, "订阅失败: "+strings.Join(r.Errors, "；"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ClientImportService 从其他客户端（Clash、v2rayN、Shadowrocket）的配置迁移节点、订阅与直连规则。
+type ClientImportService struct {
+	store         *store.Store
+	config        *ConfigService
+	subscriptions *SubscriptionService
+}
+
+// NewClientImportService 创建客户端配置导入服务。
+func NewClientImportService(store *store.Store, config *ConfigService, subscriptions *SubscriptionService) *ClientImportService {
+	return &ClientImportService{store: store, config: config, subscriptions: subscriptions}
+}
+
+// Parse 识别并解析配置文件内容，供导入前预览，不写数据库。
+func (cs *ClientImportService) Parse(data []byte) (*subscription.ClientConfig, error) {
+	if len(data) > clientImportMaxSize {
+		return nil, fmt.Errorf("导入配置: 文件过大")
+	}
+	if cs.subscriptions == nil || cs.subscriptions.subscriptionManager == nil {
+		return nil, fmt.Errorf("导入配置: 订阅管理器未初始化")
+	}
+	return cs.subscriptions.subscriptionManager.ParseClientConfig(data)
+}
+
+// Import 写入解析结果：节点作为手动添加的节点保存（已存在的跳过），直连规则与现有列表合并，
+// 新订阅逐个添加并拉取，地址已存在的订阅跳过。会访问网络，须在后台 goroutine 中调用。
+func (cs *ClientImportService) Import(cfg *subscription.ClientConfig) (*ClientImportResult, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("导入配置: 配置为空")
+	}
+	if cs.config == nil || cs.store == nil || cs.store.Nodes == nil || cs.store.Subscriptions == nil {
+		return nil, fmt.Errorf("导入配置: Store 未初始化")
+	}
+	result := &ClientImportResult{RulesSkipped: cfg.SkippedRules}
+	if cfg.FinalOutbound != "" && cfg.FinalOutbound != cs.config.GetFinalOutbound() {
+		result.FinalOutbound = cfg.FinalOutbound
+	}
+
+	for i := range cfg.Nodes {
+		node := cfg.Nodes[i]
+		// 同一节点已在某个订阅中时改用派生 ID，作为独立节点保存而不改动订阅中的记录
+		if cs.store.Nodes.BelongsToSubscription(node.ID) {
+			node.ID = model.DeriveServerID(node.ID, "manual")
+		}
+		if _, err := cs.store.Nodes.Get(node.ID); err == nil {
+			result.NodesExisting++
+			continue
+		}
+		if err := cs.store.Nodes.Add(&node); err != nil {
+			result.NodeErrors = append(result.NodeErrors, err.Error())
+			continue
+		}
+		result.NodesAdded++
+	}
+
+	routes := cs.config.GetDirectRoutes()
+	existing := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		existing[r] = true
+	}
+	for _, r := range parseDirectRoutes(formatDirectRoutes(cfg.DirectRoutes)) {
+		if !existing[r] {
+			existing[r] = true
+			routes = append(routes, r)
+			result.RoutesAdded++
+		}
+	}
+	if result.RoutesAdded > 0 {
+		if err := cs.config.SetDirectRoutes(routes); err != nil {
+			return result, fmt.Errorf("导入配置: 保存直连路由失败: %w", err)
+		}
+	}
+
+	for _, sub := range cfg.Subscriptions {
+		name := sub.Label
+		if name == "" {
+			name = redactSubscriptionURL(sub.URL)
+		}
+		if _, err := cs.store.Subscriptions.GetByURL(sub.URL); err == nil {
+			result.Existing = append(result.Existing, name)
+			continue
+		}
+		added, err := cs.store.Subscriptions.Add(sub.URL, sub.Label)
+		if err == nil && cs.subscriptions != nil {
+			_, err = cs.subscriptions.UpdateByID(added.ID)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		result.Added = append(result.Added, name)
+	}
+	return result, nil
+}
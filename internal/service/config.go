@@ -1,13 +1,21 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"strconv"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/systemproxy"
+	"myproxy.com/p/internal/xray"
 )
 
 // 默认的国内域名直连路由列表
@@ -89,7 +97,7 @@ func (cs *ConfigService) SetTheme(theme string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
 		return fmt.Errorf("Store 未初始化")
 	}
-	return cs.store.AppConfig.Set("theme", theme)
+	return cs.Set("theme", theme)
 }
 
 // GetWindowSize 获取窗口大小。
@@ -119,14 +127,7 @@ func (cs *ConfigService) SaveWindowSize(size fyne.Size) error {
 // GetLogsCollapsed 获取日志面板折叠状态。
 // 返回：是否折叠
 func (cs *ConfigService) GetLogsCollapsed() bool {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return true // 默认折叠
-	}
-	collapsed, err := cs.store.AppConfig.GetWithDefault("logsCollapsed", database.AppConfigBuiltinDefault("logsCollapsed"))
-	if err != nil {
-		return true
-	}
-	return collapsed == "true"
+	return cs.GetBool("logsCollapsed")
 }
 
 // SetLogsCollapsed 设置日志面板折叠状态。
@@ -135,66 +136,342 @@ func (cs *ConfigService) GetLogsCollapsed() bool {
 //
 // 返回：错误（如果有）
 func (cs *ConfigService) SetLogsCollapsed(collapsed bool) error {
+	return cs.SetBool("logsCollapsed", collapsed)
+}
+
+// GetLocalInboundPort 返回本地混合入站端口（xray 监听、系统代理与终端环境变量须与此一致）。
+// 读取 app_config 键 autoProxyPort；无效、越界或缺失时使用内置默认值 database.DefaultMixedInboundPort。
+func (cs *ConfigService) GetLocalInboundPort() int {
+	return cs.GetInt("autoProxyPort")
+}
+
+// GetMixedInboundListenAll 是否在所有接口上监听混合入站（0.0.0.0），便于 WSL2 等通过 Windows 主机 IP 连接。
+// 读取 app_config 键 mixedInboundListenAll；无法解析时使用内置默认值。
+func (cs *ConfigService) GetMixedInboundListenAll() bool {
+	return cs.GetBool("mixedInboundListenAll")
+}
+
+// SetMixedInboundListenAll 设置是否在所有接口上监听混合入站。
+func (cs *ConfigService) SetMixedInboundListenAll(listenAll bool) error {
+	return cs.SetBool("mixedInboundListenAll", listenAll)
+}
+
+// GetMixedInboundXrayListenAddress 返回 xray 混合入站应绑定的地址（127.0.0.1 或 0.0.0.0）。
+func (cs *ConfigService) GetMixedInboundXrayListenAddress() string {
+	if cs.GetMixedInboundListenAll() {
+		return "0.0.0.0"
+	}
+	return database.LocalMixedInboundListenHost
+}
+
+// lanInboundTag 局域网入站的 tag，访问记录中据此区分来自局域网设备的连接
+const lanInboundTag = "lan-in"
+
+// GetLANInbound 获取局域网入站设置（不论开关状态）及是否开启。
+func (cs *ConfigService) GetLANInbound() (inbound xray.ExtraInbound, enabled bool) {
+	inbound = xray.ExtraInbound{
+		Tag:      lanInboundTag,
+		Listen:   database.AppConfigBuiltinDefault("lanInboundListen"),
+		Port:     cs.GetInt("lanInboundPort"),
+		Protocol: cs.typedValue("lanInboundProtocol", ConfigKindString),
+	}
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return inbound, false
+	}
+	if v, _ := cs.store.AppConfig.GetWithDefault("lanInboundListen", inbound.Listen); net.ParseIP(strings.TrimSpace(v)) != nil {
+		inbound.Listen = strings.TrimSpace(v)
+	}
+	return inbound, cs.GetBool("lanInboundEnabled")
+}
+
+// SetLANInbound 设置局域网入站；listen 须为 IP（0.0.0.0 或本机局域网地址），端口不能与本地入站或 gRPC API 相同。
+func (cs *ConfigService) SetLANInbound(enabled bool, listen string, port int, protocol string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
 		return fmt.Errorf("Store 未初始化")
 	}
-	state := "false"
-	if collapsed {
-		state = "true"
+	listen = strings.TrimSpace(listen)
+	if net.ParseIP(listen) == nil {
+		return fmt.Errorf("监听地址须为 IP 地址: %s", listen)
+	}
+	if port == cs.GetLocalInboundPort() {
+		return fmt.Errorf("局域网入站端口 %d 与本地入站端口相同", port)
+	}
+	if cs.GetXrayAPIEnabled() && port == cs.GetXrayAPIPort() {
+		return fmt.Errorf("局域网入站端口 %d 与 xray gRPC API 端口相同", port)
+	}
+	if err := cs.SetInt("lanInboundPort", port); err != nil {
+		return err
+	}
+	if err := cs.Set("lanInboundProtocol", protocol); err != nil {
+		return err
 	}
-	return cs.store.AppConfig.Set("logsCollapsed", state)
+	if err := cs.store.AppConfig.Set("lanInboundListen", listen); err != nil {
+		return err
+	}
+	return cs.SetBool("lanInboundEnabled", enabled)
 }
 
-// GetLocalInboundPort 返回本地混合入站端口（xray 监听、系统代理与终端环境变量须与此一致）。
-// 读取 app_config 键 autoProxyPort；无效或缺失时使用 database.DefaultMixedInboundPort。
-func (cs *ConfigService) GetLocalInboundPort() int {
+// GetInboundAuthEnabled 获取入站认证开关。
+func (cs *ConfigService) GetInboundAuthEnabled() bool {
+	return cs.GetBool("inboundAuthEnabled")
+}
+
+// SetInboundAuthEnabled 设置入站认证开关；开启时若尚无密码则自动生成。
+func (cs *ConfigService) SetInboundAuthEnabled(enabled bool) error {
+	if enabled {
+		if _, pass := cs.GetInboundAuthCredentials(); pass == "" {
+			if _, err := cs.ResetInboundAuthPassword(); err != nil {
+				return err
+			}
+		}
+	}
+	return cs.SetBool("inboundAuthEnabled", enabled)
+}
+
+// GetInboundAuthCredentials 获取入站认证的用户名与密码（不论开关状态）。
+func (cs *ConfigService) GetInboundAuthCredentials() (user, pass string) {
+	user = database.AppConfigBuiltinDefault("inboundAuthUser")
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return database.DefaultMixedInboundPort
+		return user, ""
 	}
-	def := database.AppConfigBuiltinDefault("autoProxyPort")
-	s, err := cs.store.AppConfig.GetWithDefault("autoProxyPort", def)
-	if err != nil {
-		return database.DefaultMixedInboundPort
+	if v, _ := cs.store.AppConfig.GetWithDefault("inboundAuthUser", user); strings.TrimSpace(v) != "" {
+		user = strings.TrimSpace(v)
 	}
-	p, err := strconv.Atoi(strings.TrimSpace(s))
-	if err != nil || p < 1 || p > 65535 {
-		return database.DefaultMixedInboundPort
+	pass, _ = cs.store.AppConfig.GetWithDefault("inboundAuthPassword", "")
+	return user, pass
+}
+
+// SetInboundAuthCredentials 保存入站认证的用户名与密码；用户名不能包含冒号，二者均不能为空。
+func (cs *ConfigService) SetInboundAuthCredentials(user, pass string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return fmt.Errorf("Store 未初始化")
 	}
-	return p
+	user = strings.TrimSpace(user)
+	if user == "" || pass == "" {
+		return fmt.Errorf("用户名和密码不能为空")
+	}
+	if strings.ContainsAny(user, ":@/ ") {
+		return fmt.Errorf("用户名不能包含 : @ / 或空格")
+	}
+	if err := cs.store.AppConfig.Set("inboundAuthUser", user); err != nil {
+		return err
+	}
+	return cs.store.AppConfig.Set("inboundAuthPassword", pass)
 }
 
-// GetMixedInboundListenAll 是否在所有接口上监听混合入站（0.0.0.0），便于 WSL2 等通过 Windows 主机 IP 连接。
-// 读取 app_config 键 mixedInboundListenAll；非 "true" 时视为 false。
-func (cs *ConfigService) GetMixedInboundListenAll() bool {
+// AppLockEnabled 是否设置了应用锁密码。
+func (cs *ConfigService) AppLockEnabled() bool {
+	hash, _ := cs.GetWithDefault("appLockHash", "")
+	return hash != ""
+}
+
+// SetAppLockPassword 设置应用锁密码，只保存哈希；password 为空时移除密码并关闭应用锁。
+func (cs *ConfigService) SetAppLockPassword(password string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return false
+		return fmt.Errorf("Store 未初始化")
+	}
+	if password == "" {
+		return cs.store.AppConfig.Set("appLockHash", "")
+	}
+	if len([]rune(password)) < AppLockMinPasswordLen {
+		return fmt.Errorf("密码至少 %d 位", AppLockMinPasswordLen)
 	}
-	def := database.AppConfigBuiltinDefault("mixedInboundListenAll")
-	v, err := cs.store.AppConfig.GetWithDefault("mixedInboundListenAll", def)
+	hash, err := hashAppLockPassword(password)
 	if err != nil {
-		return false
+		return err
 	}
-	return strings.TrimSpace(strings.ToLower(v)) == "true"
+	return cs.store.AppConfig.Set("appLockHash", hash)
 }
 
-// SetMixedInboundListenAll 设置是否在所有接口上监听混合入站。
-func (cs *ConfigService) SetMixedInboundListenAll(listenAll bool) error {
+// CheckAppLockPassword 校验应用锁密码；未设置密码时返回 true。
+func (cs *ConfigService) CheckAppLockPassword(password string) bool {
+	hash, _ := cs.GetWithDefault("appLockHash", "")
+	return hash == "" || verifyAppLockPassword(hash, password)
+}
+
+// GetAppLockOnStartup 获取启动时是否需要输入应用锁密码。
+func (cs *ConfigService) GetAppLockOnStartup() bool {
+	return cs.GetBool("appLockOnStartup")
+}
+
+// SetAppLockOnStartup 设置启动时是否需要输入应用锁密码。
+func (cs *ConfigService) SetAppLockOnStartup(enabled bool) error {
+	return cs.SetBool("appLockOnStartup", enabled)
+}
+
+// GetAppLockSettings 获取进入设置页时是否需要输入应用锁密码。
+func (cs *ConfigService) GetAppLockSettings() bool {
+	return cs.GetBool("appLockSettings")
+}
+
+// SetAppLockSettings 设置进入设置页时是否需要输入应用锁密码。
+func (cs *ConfigService) SetAppLockSettings(enabled bool) error {
+	return cs.SetBool("appLockSettings", enabled)
+}
+
+// GetAppLockIdleTimeout 获取无操作后自动锁定的时间，0 表示不自动锁定。
+func (cs *ConfigService) GetAppLockIdleTimeout() time.Duration {
+	return time.Duration(cs.GetInt("appLockIdleMinutes")) * time.Minute
+}
+
+// SetAppLockIdleMinutes 设置无操作多少分钟后自动锁定（0-1440，0 表示不自动锁定）。
+func (cs *ConfigService) SetAppLockIdleMinutes(minutes int) error {
+	return cs.SetInt("appLockIdleMinutes", minutes)
+}
+
+// ResetInboundAuthPassword 随机生成新的入站认证密码并保存。
+func (cs *ConfigService) ResetInboundAuthPassword() (string, error) {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return "", fmt.Errorf("Store 未初始化")
 	}
-	val := "false"
-	if listenAll {
-		val = "true"
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成密码失败: %w", err)
 	}
-	return cs.store.AppConfig.Set("mixedInboundListenAll", val)
+	pass := hex.EncodeToString(buf)
+	if err := cs.store.AppConfig.Set("inboundAuthPassword", pass); err != nil {
+		return "", err
+	}
+	return pass, nil
 }
 
-// GetMixedInboundXrayListenAddress 返回 xray 混合入站应绑定的地址（127.0.0.1 或 0.0.0.0）。
-func (cs *ConfigService) GetMixedInboundXrayListenAddress() string {
-	if cs.GetMixedInboundListenAll() {
-		return "0.0.0.0"
+// InboundAuth 返回生效中的入站认证凭据；未开启或密码为空时返回空字符串（即不认证）。
+func (cs *ConfigService) InboundAuth() (user, pass string) {
+	if !cs.GetInboundAuthEnabled() {
+		return "", ""
 	}
-	return database.LocalMixedInboundListenHost
+	user, pass = cs.GetInboundAuthCredentials()
+	if pass == "" {
+		return "", ""
+	}
+	return user, pass
+}
+
+// GetInboundRateLimit 获取混合入站的带宽上限。
+func (cs *ConfigService) GetInboundRateLimit() xray.RateLimitOptions {
+	return xray.RateLimitOptions{
+		UploadKBps:   cs.GetInt("inboundUploadLimitKBps"),
+		DownloadKBps: cs.GetInt("inboundDownloadLimitKBps"),
+		LANOnly:      cs.GetBool("inboundRateLimitLanOnly"),
+	}
+}
+
+// SetInboundRateLimit 保存混合入站的带宽上限，重启代理后生效。
+func (cs *ConfigService) SetInboundRateLimit(opts xray.RateLimitOptions) error {
+	if err := cs.SetInt("inboundUploadLimitKBps", opts.UploadKBps); err != nil {
+		return err
+	}
+	if err := cs.SetInt("inboundDownloadLimitKBps", opts.DownloadKBps); err != nil {
+		return err
+	}
+	return cs.SetBool("inboundRateLimitLanOnly", opts.LANOnly)
+}
+
+// GetPolicyOptions 获取连接超时策略。
+func (cs *ConfigService) GetPolicyOptions() xray.PolicyOptions {
+	return xray.PolicyOptions{
+		Handshake:    cs.GetDuration("policyHandshake"),
+		ConnIdle:     cs.GetDuration("policyConnIdle"),
+		UplinkOnly:   cs.GetDuration("policyUplinkOnly"),
+		DownlinkOnly: cs.GetDuration("policyDownlinkOnly"),
+	}
+}
+
+// SetPolicyOptions 保存连接超时策略，重启代理后生效。
+func (cs *ConfigService) SetPolicyOptions(opts xray.PolicyOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	for key, d := range map[string]time.Duration{
+		"policyHandshake":    opts.Handshake,
+		"policyConnIdle":     opts.ConnIdle,
+		"policyUplinkOnly":   opts.UplinkOnly,
+		"policyDownlinkOnly": opts.DownlinkOnly,
+	} {
+		if err := cs.SetDuration(key, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetXrayAutoRestart 获取 xray 意外停止后是否自动重启。
+func (cs *ConfigService) GetXrayAutoRestart() bool {
+	return cs.GetBool("xrayAutoRestart")
+}
+
+// SetXrayAutoRestart 设置 xray 意外停止后是否自动重启。
+func (cs *ConfigService) SetXrayAutoRestart(enabled bool) error {
+	return cs.SetBool("xrayAutoRestart", enabled)
+}
+
+// GetXrayAutoRestartMaxRetries 获取自动重启的最大尝试次数。
+func (cs *ConfigService) GetXrayAutoRestartMaxRetries() int {
+	return cs.GetInt("xrayAutoRestartMaxRetries")
+}
+
+// SetXrayAutoRestartMaxRetries 设置自动重启的最大尝试次数（1-20）。
+func (cs *ConfigService) SetXrayAutoRestartMaxRetries(n int) error {
+	return cs.SetInt("xrayAutoRestartMaxRetries", n)
+}
+
+// GetPrewarmEnabled 获取是否开启连接预热。
+func (cs *ConfigService) GetPrewarmEnabled() bool {
+	return cs.GetBool("prewarmEnabled")
+}
+
+// SetPrewarmEnabled 设置是否开启连接预热。
+func (cs *ConfigService) SetPrewarmEnabled(enabled bool) error {
+	return cs.SetBool("prewarmEnabled", enabled)
+}
+
+// GetPrewarmTopN 获取连接预热的目标数。
+func (cs *ConfigService) GetPrewarmTopN() int {
+	return cs.GetInt("prewarmTopN")
+}
+
+// SetPrewarmTopN 设置连接预热的目标数（1-20）。
+func (cs *ConfigService) SetPrewarmTopN(n int) error {
+	return cs.SetInt("prewarmTopN", n)
+}
+
+// GetUsageStatsEnabled 获取是否在本机记录使用统计。
+func (cs *ConfigService) GetUsageStatsEnabled() bool {
+	return cs.GetBool("usageStatsEnabled")
+}
+
+// SetUsageStatsEnabled 设置是否在本机记录使用统计。
+func (cs *ConfigService) SetUsageStatsEnabled(enabled bool) error {
+	return cs.SetBool("usageStatsEnabled", enabled)
+}
+
+// GetExternalRefreshInterval 获取检查数据库外部修改的间隔，0 表示关闭自动刷新。
+func (cs *ConfigService) GetExternalRefreshInterval() time.Duration {
+	return time.Duration(cs.GetInt("externalRefreshSeconds")) * time.Second
+}
+
+// SetExternalRefreshSeconds 设置检查数据库外部修改的间隔（0-300 秒，0 表示关闭）。
+func (cs *ConfigService) SetExternalRefreshSeconds(seconds int) error {
+	return cs.SetInt("externalRefreshSeconds", seconds)
+}
+
+// ProxySnippets 生成指向本地混合入站的 PAC、环境变量与 Docker/git/apt/npm 配置片段；
+// host 为空时使用本机回环地址，直连路由中的域名写入 PAC。
+func (cs *ConfigService) ProxySnippets(host string) []systemproxy.Snippet {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		host = database.LocalMixedInboundListenHost
+	}
+	opts := systemproxy.SnippetOptions{Host: host, Port: cs.GetLocalInboundPort()}
+	opts.User, opts.Pass = cs.InboundAuth()
+	for _, route := range cs.GetDirectRoutes() {
+		if domain, ok := strings.CutPrefix(route, "domain:"); ok {
+			opts.DirectDomains = append(opts.DirectDomains, domain)
+		} else if domain, ok := strings.CutPrefix(route, "full:"); ok {
+			opts.DirectDomains = append(opts.DirectDomains, domain)
+		}
+	}
+	return systemproxy.Snippets(opts)
 }
 
 // GetSystemProxyMode 获取系统代理模式。
@@ -257,28 +534,21 @@ func (cs *ConfigService) Set(key, value string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
 		return fmt.Errorf("Store 未初始化")
 	}
-	return cs.store.AppConfig.Set(key, value)
+	canonical, err := ValidateConfigValue(key, value)
+	if err != nil {
+		return err
+	}
+	return cs.store.AppConfig.Set(key, canonical)
 }
 
 // GetDebugPprofEnabled 获取 pprof 开关。
 func (cs *ConfigService) GetDebugPprofEnabled() bool {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return false
-	}
-	v, _ := cs.store.AppConfig.GetWithDefault("debugPprofEnabled", database.AppConfigBuiltinDefault("debugPprofEnabled"))
-	return v == "true"
+	return cs.GetBool("debugPprofEnabled")
 }
 
 // SetDebugPprofEnabled 设置 pprof 开关。
 func (cs *ConfigService) SetDebugPprofEnabled(enabled bool) error {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
-	}
-	value := "false"
-	if enabled {
-		value = "true"
-	}
-	return cs.store.AppConfig.Set("debugPprofEnabled", value)
+	return cs.SetBool("debugPprofEnabled", enabled)
 }
 
 // GetDebugPprofAddr 获取 pprof 地址。
@@ -305,20 +575,625 @@ func (cs *ConfigService) SetDebugPprofAddr(addr string) error {
 	return cs.store.AppConfig.Set("debugPprofAddr", addr)
 }
 
-// GetDiagnosticsSamplingSeconds 获取诊断采样周期（秒）。
-func (cs *ConfigService) GetDiagnosticsSamplingSeconds() int {
+// GetAutoProbeSelectedNode 获取「选中节点时自动测速」开关。
+func (cs *ConfigService) GetAutoProbeSelectedNode() bool {
+	return cs.GetBool("autoProbeSelectedNode")
+}
+
+// SetAutoProbeSelectedNode 设置「选中节点时自动测速」开关。
+func (cs *ConfigService) SetAutoProbeSelectedNode(enabled bool) error {
+	return cs.SetBool("autoProbeSelectedNode", enabled)
+}
+
+// GetImportAPIEnabled 获取浏览器导入接口开关。
+func (cs *ConfigService) GetImportAPIEnabled() bool {
+	return cs.GetBool("importApiEnabled")
+}
+
+// SetImportAPIEnabled 设置浏览器导入接口开关。
+func (cs *ConfigService) SetImportAPIEnabled(enabled bool) error {
+	return cs.SetBool("importApiEnabled", enabled)
+}
+
+// GetMetricsEnabled 获取本地接口 /metrics 开关。
+func (cs *ConfigService) GetMetricsEnabled() bool {
+	return cs.GetBool("metricsEnabled")
+}
+
+// SetMetricsEnabled 设置本地接口 /metrics 开关。
+func (cs *ConfigService) SetMetricsEnabled(enabled bool) error {
+	return cs.SetBool("metricsEnabled", enabled)
+}
+
+// GetStatusFileEnabled 获取是否写入状态文件（数据目录下的 status.json）。
+func (cs *ConfigService) GetStatusFileEnabled() bool {
+	return cs.GetBool("statusFileEnabled")
+}
+
+// SetStatusFileEnabled 设置是否写入状态文件。
+func (cs *ConfigService) SetStatusFileEnabled(enabled bool) error {
+	return cs.SetBool("statusFileEnabled", enabled)
+}
+
+// GetClipboardMonitorEnabled 获取剪贴板链接检测开关。
+func (cs *ConfigService) GetClipboardMonitorEnabled() bool {
+	return cs.GetBool("clipboardMonitorEnabled")
+}
+
+// SetClipboardMonitorEnabled 设置剪贴板链接检测开关。
+func (cs *ConfigService) SetClipboardMonitorEnabled(enabled bool) error {
+	return cs.SetBool("clipboardMonitorEnabled", enabled)
+}
+
+// GetKeepUnsupportedSSR 获取是否以禁用状态保留不兼容的 SSR 节点。
+func (cs *ConfigService) GetKeepUnsupportedSSR() bool {
+	return cs.GetBool("keepUnsupportedSSR")
+}
+
+// SetKeepUnsupportedSSR 设置是否以禁用状态保留不兼容的 SSR 节点（下次导入或更新订阅时生效）。
+func (cs *ConfigService) SetKeepUnsupportedSSR(keep bool) error {
+	return cs.SetBool("keepUnsupportedSSR", keep)
+}
+
+// GetProcessStatsEnabled 获取应用维度统计开关。
+func (cs *ConfigService) GetProcessStatsEnabled() bool {
+	return cs.GetBool("processStatsEnabled")
+}
+
+// SetProcessStatsEnabled 设置应用维度统计开关。
+func (cs *ConfigService) SetProcessStatsEnabled(enabled bool) error {
+	return cs.SetBool("processStatsEnabled", enabled)
+}
+
+// GetAutoDisableFailThreshold 获取节点自动禁用的连续失败次数阈值，0 表示不自动禁用。
+func (cs *ConfigService) GetAutoDisableFailThreshold() int {
+	return cs.GetInt("autoDisableFailThreshold")
+}
+
+// SetAutoDisableFailThreshold 设置节点自动禁用的连续失败次数阈值。
+func (cs *ConfigService) SetAutoDisableFailThreshold(threshold int) error {
+	return cs.SetInt("autoDisableFailThreshold", threshold)
+}
+
+// GetLatencyMethod 获取测速方式（utils.DelayMethodTCP 或 utils.DelayMethodTLS）。
+func (cs *ConfigService) GetLatencyMethod() string {
+	return cs.typedValue("latencyMethod", ConfigKindString)
+}
+
+// SetLatencyMethod 设置测速方式。
+func (cs *ConfigService) SetLatencyMethod(method string) error {
+	return cs.Set("latencyMethod", method)
+}
+
+// GetPingFreshWindow 获取一键测速的结果有效期：该时间内测过的节点不再重测，0 表示每次全部重测。
+func (cs *ConfigService) GetPingFreshWindow() time.Duration {
+	return time.Duration(cs.GetInt("pingFreshMinutes")) * time.Minute
+}
+
+// SetPingFreshMinutes 设置一键测速的结果有效期（分钟）。
+func (cs *ConfigService) SetPingFreshMinutes(minutes int) error {
+	return cs.SetInt("pingFreshMinutes", minutes)
+}
+
+// minScheduledPingInterval 定时测速的最短间隔，避免频繁连接服务商
+const minScheduledPingInterval = 5 * time.Minute
+
+// GetScheduledPingInterval 获取定时测速间隔，0 表示关闭；不足最短间隔时按最短间隔。
+func (cs *ConfigService) GetScheduledPingInterval() time.Duration {
+	d := cs.GetDuration("scheduledPingInterval")
+	if d > 0 && d < minScheduledPingInterval {
+		return minScheduledPingInterval
+	}
+	return d
+}
+
+// SetScheduledPingInterval 设置定时测速间隔（0 关闭，最长 24 小时）。
+func (cs *ConfigService) SetScheduledPingInterval(interval time.Duration) error {
+	return cs.SetDuration("scheduledPingInterval", interval)
+}
+
+// GetScheduledPingQuietHours 获取定时测速的静默时段，未设置或无效时返回零值（不限制）。
+func (cs *ConfigService) GetScheduledPingQuietHours() QuietHours {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return QuietHours{}
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("scheduledPingQuietHours", "")
+	qh, _ := ParseQuietHours(v)
+	return qh
+}
+
+// SetScheduledPingQuietHours 设置定时测速的静默时段（如 "23:00-07:00"），空字符串表示不限制。
+func (cs *ConfigService) SetScheduledPingQuietHours(value string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return fmt.Errorf("Store 未初始化")
+	}
+	qh, err := ParseQuietHours(value)
+	if err != nil {
+		return err
+	}
+	return cs.store.AppConfig.Set("scheduledPingQuietHours", qh.String())
+}
+
+// GetScheduledPingACOnly 获取定时测速是否仅在接通电源时运行。
+func (cs *ConfigService) GetScheduledPingACOnly() bool {
+	return cs.GetBool("scheduledPingACOnly")
+}
+
+// SetScheduledPingACOnly 设置定时测速是否仅在接通电源时运行。
+func (cs *ConfigService) SetScheduledPingACOnly(acOnly bool) error {
+	return cs.SetBool("scheduledPingACOnly", acOnly)
+}
+
+// GetPingWebhookURL 获取测速结果 Webhook 地址，空表示不发送。
+func (cs *ConfigService) GetPingWebhookURL() string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return ""
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("pingWebhookURL", "")
+	return strings.TrimSpace(v)
+}
+
+// SetPingWebhookURL 设置测速结果 Webhook 地址，须为 http/https；空字符串表示不发送。
+func (cs *ConfigService) SetPingWebhookURL(webhookURL string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return fmt.Errorf("Store 未初始化")
+	}
+	webhookURL = strings.TrimSpace(webhookURL)
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("Webhook 地址无效: %s", webhookURL)
+		}
+	}
+	return cs.store.AppConfig.Set("pingWebhookURL", webhookURL)
+}
+
+// GetBalancerEnabled 获取负载均衡组开关。
+func (cs *ConfigService) GetBalancerEnabled() bool {
+	return cs.GetBool("balancerEnabled")
+}
+
+// SetBalancerEnabled 设置负载均衡组开关，下次启动代理时生效。
+func (cs *ConfigService) SetBalancerEnabled(enabled bool) error {
+	return cs.SetBool("balancerEnabled", enabled)
+}
+
+// GetBalancerStrategy 获取负载均衡策略（leastPing / leastLoad）。
+func (cs *ConfigService) GetBalancerStrategy() string {
+	return cs.typedValue("balancerStrategy", ConfigKindString)
+}
+
+// SetBalancerStrategy 设置负载均衡策略。
+func (cs *ConfigService) SetBalancerStrategy(strategy string) error {
+	return cs.Set("balancerStrategy", strategy)
+}
+
+// GetObservatoryProbeURL 获取隧道内延迟探测地址。
+func (cs *ConfigService) GetObservatoryProbeURL() string {
+	defaultURL := database.AppConfigBuiltinDefault("observatoryProbeURL")
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return defaultURL
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("observatoryProbeURL", defaultURL)
+	if strings.TrimSpace(v) == "" {
+		return defaultURL
+	}
+	return strings.TrimSpace(v)
+}
+
+// SetObservatoryProbeURL 设置隧道内延迟探测地址，须为 http(s) 地址；空字符串恢复默认。
+func (cs *ConfigService) SetObservatoryProbeURL(probeURL string) error {
+	probeURL = strings.TrimSpace(probeURL)
+	if probeURL == "" {
+		probeURL = database.AppConfigBuiltinDefault("observatoryProbeURL")
+	}
+	u, err := url.Parse(probeURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("探测地址无效: %s", probeURL)
+	}
+	return cs.Set("observatoryProbeURL", probeURL)
+}
+
+// GetDNSLeakTestService 获取 DNS 泄漏测试服务地址。
+func (cs *ConfigService) GetDNSLeakTestService() string {
+	defaultURL := database.AppConfigBuiltinDefault("dnsLeakTestService")
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return defaultDiagnosticsSampleSecs
+		return defaultURL
 	}
-	raw, _ := cs.store.AppConfig.GetWithDefault("diagnosticsSamplingSeconds", database.AppConfigBuiltinDefault("diagnosticsSamplingSeconds"))
-	switch strings.TrimSpace(raw) {
-	case "1":
-		return 1
-	case "10":
-		return 10
-	default:
-		return 5
+	v, _ := cs.store.AppConfig.GetWithDefault("dnsLeakTestService", defaultURL)
+	if strings.TrimSpace(v) == "" {
+		return defaultURL
+	}
+	return strings.TrimSpace(v)
+}
+
+// SetDNSLeakTestService 设置 DNS 泄漏测试服务地址；空字符串恢复默认。
+func (cs *ConfigService) SetDNSLeakTestService(service string) error {
+	service = strings.TrimRight(strings.TrimSpace(service), "/")
+	if service == "" {
+		service = database.AppConfigBuiltinDefault("dnsLeakTestService")
+	}
+	if err := ValidateDNSLeakTestService(service); err != nil {
+		return err
+	}
+	return cs.Set("dnsLeakTestService", service)
+}
+
+// ProbeURLPreset 常用探测地址，部分地区访问 gstatic 不稳定时可改用国内地址。
+type ProbeURLPreset struct {
+	Label string
+	URL   string
+}
+
+// ProbeURLPresets 设置与订阅编辑中可选的探测地址。
+var ProbeURLPresets = []ProbeURLPreset{
+	{Label: "Google", URL: "https://www.gstatic.com/generate_204"},
+	{Label: "Cloudflare", URL: "https://cp.cloudflare.com/generate_204"},
+	{Label: "Apple", URL: "http://captive.apple.com/hotspot-detect.html"},
+	{Label: "国内（小米）", URL: "http://connect.rom.miui.com/generate_204"},
+	{Label: "国内（华为）", URL: "http://connectivitycheck.platform.hicloud.com/generate_204"},
+}
+
+// ProbeURLForNode 返回测试节点时使用的探测地址：节点所属订阅设置了测速地址时使用该地址，否则使用全局探测地址。
+func (cs *ConfigService) ProbeURLForNode(nodeID string) string {
+	if cs.store != nil && cs.store.Subscriptions != nil && nodeID != "" {
+		if subID, ok, err := database.GetServerSubscriptionID(nodeID); err == nil && ok && subID != nil {
+			if sub, err := cs.store.Subscriptions.Get(*subID); err == nil && sub.TestURL != "" {
+				return sub.TestURL
+			}
+		}
+	}
+	return cs.GetObservatoryProbeURL()
+}
+
+// GetObservatoryProbeInterval 获取隧道内延迟探测间隔。
+func (cs *ConfigService) GetObservatoryProbeInterval() time.Duration {
+	return cs.GetDuration("observatoryProbeInterval")
+}
+
+// SetObservatoryProbeInterval 设置隧道内延迟探测间隔（10 秒 ~ 1 小时）。
+func (cs *ConfigService) SetObservatoryProbeInterval(interval time.Duration) error {
+	return cs.SetDuration("observatoryProbeInterval", interval)
+}
+
+// GetFragmentEnabled 获取 TLS 分片开关。
+func (cs *ConfigService) GetFragmentEnabled() bool {
+	return cs.GetBool("fragmentEnabled")
+}
+
+// SetFragmentEnabled 设置 TLS 分片开关，下次启动代理时生效。
+func (cs *ConfigService) SetFragmentEnabled(enabled bool) error {
+	return cs.SetBool("fragmentEnabled", enabled)
+}
+
+// GetFragmentOptions 获取已保存的 TLS 分片参数（不论开关状态）。
+func (cs *ConfigService) GetFragmentOptions() xray.FragmentOptions {
+	get := func(key string) string {
+		def := database.AppConfigBuiltinDefault(key)
+		if cs.store == nil || cs.store.AppConfig == nil {
+			return def
+		}
+		v, _ := cs.store.AppConfig.GetWithDefault(key, def)
+		if strings.TrimSpace(v) == "" {
+			return def
+		}
+		return strings.TrimSpace(v)
+	}
+	return xray.FragmentOptions{
+		Packets:  get("fragmentPackets"),
+		Length:   get("fragmentLength"),
+		Interval: get("fragmentInterval"),
+	}
+}
+
+// SetFragmentOptions 校验并保存 TLS 分片参数。
+func (cs *ConfigService) SetFragmentOptions(opts xray.FragmentOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := cs.Set("fragmentPackets", opts.Packets); err != nil {
+		return err
+	}
+	if err := cs.Set("fragmentLength", opts.Length); err != nil {
+		return err
+	}
+	return cs.Set("fragmentInterval", opts.Interval)
+}
+
+// GetImportAPIAddr 获取浏览器导入接口监听地址。
+func (cs *ConfigService) GetImportAPIAddr() string {
+	defaultAddr := database.AppConfigBuiltinDefault("importApiAddr")
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return defaultAddr
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("importApiAddr", defaultAddr)
+	if strings.TrimSpace(v) == "" {
+		return defaultAddr
+	}
+	return strings.TrimSpace(v)
+}
+
+// 列表密度（listDensity 取值）
+const (
+	ListDensityComfortable = "comfortable" // 宽松，行高 52px
+	ListDensityStandard    = "standard"    // 标准，行高 40px
+	ListDensityCompact     = "compact"     // 紧凑，行高 32px
+)
+
+// GetListDensity 获取节点与订阅列表的密度。
+func (cs *ConfigService) GetListDensity() string {
+	return cs.typedValue("listDensity", ConfigKindString)
+}
+
+// SetListDensity 设置节点与订阅列表的密度。
+func (cs *ConfigService) SetListDensity(density string) error {
+	return cs.Set("listDensity", density)
+}
+
+// 速度单位（speedUnit 取值）
+const (
+	SpeedUnitBytes = "bytes" // 字节每秒（KB/s、MB/s，1024 进制）
+	SpeedUnitBits  = "bits"  // 比特每秒（Kbps、Mbps，1000 进制，与运营商带宽一致）
+)
+
+// 数字格式（numberFormat 取值）：千位分隔符与小数点
+const (
+	NumberFormatSystem     = "system"      // 跟随系统语言
+	NumberFormatCommaDot   = "comma-dot"   // 1,234.5
+	NumberFormatDotComma   = "dot-comma"   // 1.234,5
+	NumberFormatSpaceComma = "space-comma" // 1 234,5
+	NumberFormatPlain      = "plain"       // 1234.5
+)
+
+// GetSpeedUnit 获取速度显示单位（bytes 或 bits）。
+func (cs *ConfigService) GetSpeedUnit() string {
+	return cs.typedValue("speedUnit", ConfigKindString)
+}
+
+// SetSpeedUnit 设置速度显示单位。
+func (cs *ConfigService) SetSpeedUnit(unit string) error {
+	return cs.Set("speedUnit", unit)
+}
+
+// GetNumberFormat 获取数字格式。
+func (cs *ConfigService) GetNumberFormat() string {
+	return cs.typedValue("numberFormat", ConfigKindString)
+}
+
+// SetNumberFormat 设置数字格式。
+func (cs *ConfigService) SetNumberFormat(format string) error {
+	return cs.Set("numberFormat", format)
+}
+
+// GetLargeControls 获取「大号控件」辅助功能开关。
+func (cs *ConfigService) GetLargeControls() bool {
+	return cs.GetBool("largeControls")
+}
+
+// SetLargeControls 设置「大号控件」辅助功能开关。
+func (cs *ConfigService) SetLargeControls(enabled bool) error {
+	return cs.SetBool("largeControls", enabled)
+}
+
+// GetWindowAlwaysOnTop 获取主窗口置顶开关。
+func (cs *ConfigService) GetWindowAlwaysOnTop() bool {
+	return cs.GetBool("windowAlwaysOnTop")
+}
+
+// SetWindowAlwaysOnTop 设置主窗口置顶开关。
+func (cs *ConfigService) SetWindowAlwaysOnTop(enabled bool) error {
+	return cs.SetBool("windowAlwaysOnTop", enabled)
+}
+
+// GetMiniWindowEnabled 获取悬浮状态小窗开关。
+func (cs *ConfigService) GetMiniWindowEnabled() bool {
+	return cs.GetBool("miniWindowEnabled")
+}
+
+// SetMiniWindowEnabled 设置悬浮状态小窗开关。
+func (cs *ConfigService) SetMiniWindowEnabled(enabled bool) error {
+	return cs.SetBool("miniWindowEnabled", enabled)
+}
+
+// GetTrayShowSpeed 获取菜单栏显示实时速度开关（仅 macOS 生效）。
+func (cs *ConfigService) GetTrayShowSpeed() bool {
+	return cs.GetBool("trayShowSpeed")
+}
+
+// SetTrayShowSpeed 设置菜单栏显示实时速度开关。
+func (cs *ConfigService) SetTrayShowSpeed(enabled bool) error {
+	return cs.SetBool("trayShowSpeed", enabled)
+}
+
+// GetLowPowerMode 获取低功耗模式（auto、on、off）。
+func (cs *ConfigService) GetLowPowerMode() string {
+	return cs.typedValue("lowPowerMode", ConfigKindString)
+}
+
+// SetLowPowerMode 设置低功耗模式。
+func (cs *ConfigService) SetLowPowerMode(mode string) error {
+	return cs.Set("lowPowerMode", mode)
+}
+
+// GetNotifyOnConnect 获取「连接成功时发送系统通知」开关。
+func (cs *ConfigService) GetNotifyOnConnect() bool {
+	return cs.GetBool("notifyOnConnect")
+}
+
+// SetNotifyOnConnect 设置「连接成功时发送系统通知」开关。
+func (cs *ConfigService) SetNotifyOnConnect(enabled bool) error {
+	return cs.SetBool("notifyOnConnect", enabled)
+}
+
+// GetNotifyOnDisconnect 获取「连接意外中断时发送系统通知」开关；关闭后不再经隧道定期检测连通性。
+func (cs *ConfigService) GetNotifyOnDisconnect() bool {
+	return cs.GetBool("notifyOnDisconnect")
+}
+
+// SetNotifyOnDisconnect 设置「连接意外中断时发送系统通知」开关。
+func (cs *ConfigService) SetNotifyOnDisconnect(enabled bool) error {
+	return cs.SetBool("notifyOnDisconnect", enabled)
+}
+
+// GetNotifyOnFailover 获取「负载均衡组自动切换节点时发送系统通知」开关。
+func (cs *ConfigService) GetNotifyOnFailover() bool {
+	return cs.GetBool("notifyOnFailover")
+}
+
+// SetNotifyOnFailover 设置「负载均衡组自动切换节点时发送系统通知」开关。
+func (cs *ConfigService) SetNotifyOnFailover(enabled bool) error {
+	return cs.SetBool("notifyOnFailover", enabled)
+}
+
+// GetSessionReportEnabled 获取「断开连接时生成连接报告」开关。
+func (cs *ConfigService) GetSessionReportEnabled() bool {
+	return cs.GetBool("sessionReportEnabled")
+}
+
+// SetSessionReportEnabled 设置「断开连接时生成连接报告」开关。
+func (cs *ConfigService) SetSessionReportEnabled(enabled bool) error {
+	return cs.SetBool("sessionReportEnabled", enabled)
+}
+
+// GetWebDAVSyncEnabled 获取 WebDAV 配置同步开关。
+func (cs *ConfigService) GetWebDAVSyncEnabled() bool {
+	return cs.GetBool("webdavSyncEnabled")
+}
+
+// SetWebDAVSyncEnabled 设置 WebDAV 配置同步开关。
+func (cs *ConfigService) SetWebDAVSyncEnabled(enabled bool) error {
+	return cs.SetBool("webdavSyncEnabled", enabled)
+}
+
+// GetWebDAVSyncCredentials 获取「同步完整订阅地址」开关，关闭时订阅只同步服务商主机。
+func (cs *ConfigService) GetWebDAVSyncCredentials() bool {
+	return cs.GetBool("webdavSyncCredentials")
+}
+
+// SetWebDAVSyncCredentials 设置「同步完整订阅地址」开关。
+func (cs *ConfigService) SetWebDAVSyncCredentials(enabled bool) error {
+	return cs.SetBool("webdavSyncCredentials", enabled)
+}
+
+// GetWebDAVEndpoint 获取 WebDAV 地址、用户名与密码。
+func (cs *ConfigService) GetWebDAVEndpoint() (endpoint, user, pass string) {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return "", "", ""
 	}
+	endpoint, _ = cs.store.AppConfig.GetWithDefault("webdavURL", "")
+	user, _ = cs.store.AppConfig.GetWithDefault("webdavUser", "")
+	pass, _ = cs.store.AppConfig.GetWithDefault("webdavPassword", "")
+	return strings.TrimSpace(endpoint), strings.TrimSpace(user), pass
+}
+
+// SetWebDAVEndpoint 保存 WebDAV 地址（须为 http/https）、用户名与密码；地址变化时清除同步状态。
+func (cs *ConfigService) SetWebDAVEndpoint(endpoint, user, pass string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return fmt.Errorf("Store 未初始化")
+	}
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("WebDAV 地址须为 http:// 或 https:// 开头的完整地址")
+		}
+	}
+	if old, _, _ := cs.GetWebDAVEndpoint(); old != endpoint {
+		if err := cs.store.AppConfig.Set("webdavSyncState", ""); err != nil {
+			return err
+		}
+	}
+	for key, value := range map[string]string{"webdavURL": endpoint, "webdavUser": strings.TrimSpace(user), "webdavPassword": pass} {
+		if err := cs.store.AppConfig.Set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 访问记录方式（accessRecordMode 取值）
+const (
+	AccessRecordModeFull   = "full"   // 记录完整地址
+	AccessRecordModeHashed = "hashed" // 仅记录次数，主机名哈希后保存
+	AccessRecordModeOff    = "off"    // 不记录
+)
+
+// GetAccessRecordMode 获取访问记录方式。
+func (cs *ConfigService) GetAccessRecordMode() string {
+	return cs.typedValue("accessRecordMode", ConfigKindString)
+}
+
+// SetAccessRecordMode 设置访问记录方式。
+func (cs *ConfigService) SetAccessRecordMode(mode string) error {
+	return cs.Set("accessRecordMode", mode)
+}
+
+// GetAccessRecordIPs 访问记录是否包含纯 IP 目标。
+func (cs *ConfigService) GetAccessRecordIPs() bool {
+	return cs.GetBool("accessRecordIPs")
+}
+
+// SetAccessRecordIPs 设置访问记录是否包含纯 IP 目标。
+func (cs *ConfigService) SetAccessRecordIPs(enabled bool) error {
+	return cs.SetBool("accessRecordIPs", enabled)
+}
+
+// GetAccessRecordHashKey 获取访问记录主机名哈希的本机密钥；尚未生成时自动生成并保存。
+// 使用随机密钥而非直接哈希，避免通过常见域名字典反查记录。
+func (cs *ConfigService) GetAccessRecordHashKey() string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return ""
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("accessRecordHashKey", "")
+	if strings.TrimSpace(v) != "" {
+		return v
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	key := hex.EncodeToString(buf)
+	if err := cs.store.AppConfig.Set("accessRecordHashKey", key); err != nil {
+		return ""
+	}
+	return key
+}
+
+// GetImportAPIToken 获取浏览器导入接口令牌；尚未生成时自动生成并保存。
+func (cs *ConfigService) GetImportAPIToken() string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return ""
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("importApiToken", database.AppConfigBuiltinDefault("importApiToken"))
+	if strings.TrimSpace(v) != "" {
+		return v
+	}
+	token, err := cs.ResetImportAPIToken()
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// ResetImportAPIToken 重新生成浏览器导入接口令牌，旧令牌立即失效。
+func (cs *ConfigService) ResetImportAPIToken() (string, error) {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return "", fmt.Errorf("Store 未初始化")
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := cs.store.AppConfig.Set("importApiToken", token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetDiagnosticsSamplingSeconds 获取诊断采样周期（秒）。
+func (cs *ConfigService) GetDiagnosticsSamplingSeconds() int {
+	return cs.GetInt("diagnosticsSamplingSeconds")
 }
 
 // SetDiagnosticsSamplingSeconds 设置诊断采样周期（秒）。
@@ -329,7 +1204,7 @@ func (cs *ConfigService) SetDiagnosticsSamplingSeconds(seconds int) error {
 	if seconds != 1 && seconds != 5 && seconds != 10 {
 		seconds = defaultDiagnosticsSampleSecs
 	}
-	return cs.store.AppConfig.Set("diagnosticsSamplingSeconds", fmt.Sprintf("%d", seconds))
+	return cs.SetInt("diagnosticsSamplingSeconds", seconds)
 }
 
 // GetDiagnosticsDir 获取诊断目录。
@@ -349,6 +1224,123 @@ func (cs *ConfigService) SetDiagnosticsDir(dir string) error {
 	return cs.store.AppConfig.Set("diagnosticsDir", strings.TrimSpace(dir))
 }
 
+// xrayRuntimeDir xray 证书、配置等运行时文件所在目录：数据目录下的 xray。
+func xrayRuntimeDir() string {
+	dir := database.DataDir()
+	if dir == "" {
+		dir = "data"
+	}
+	return filepath.Join(dir, "xray")
+}
+
+// GetXrayAssetDirSetting 获取用户配置的 xray 资源目录原值（空表示使用默认）。
+func (cs *ConfigService) GetXrayAssetDirSetting() string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return ""
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("xrayAssetDir", database.AppConfigBuiltinDefault("xrayAssetDir"))
+	return strings.TrimSpace(v)
+}
+
+// GetXrayAssetDir 返回生效的 xray 资源目录（geoip.dat、geosite.dat 所在）：
+// 配置项 xrayAssetDir（相对路径相对数据目录）> 启动时的环境变量 XRAY_LOCATION_ASSET > 数据目录下的 xray；
+// 兼容旧版本，数据目录下缺少资源而程序所在目录齐全时沿用程序所在目录。
+func (cs *ConfigService) GetXrayAssetDir() string {
+	if dir := cs.GetXrayAssetDirSetting(); dir != "" {
+		if !filepath.IsAbs(dir) && database.DataDir() != "" {
+			dir = filepath.Join(database.DataDir(), dir)
+		}
+		return dir
+	}
+	if dir := xray.LaunchAssetDir(); dir != "" {
+		return dir
+	}
+	dir := xrayRuntimeDir()
+	if !xray.HasGeoAssets(dir) {
+		if exe, err := os.Executable(); err == nil && xray.HasGeoAssets(filepath.Dir(exe)) {
+			return filepath.Dir(exe)
+		}
+	}
+	return dir
+}
+
+// SetXrayAssetDir 保存 xray 资源目录并立即应用到 xray 环境；运行中的代理需重启后生效。
+func (cs *ConfigService) SetXrayAssetDir(dir string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return fmt.Errorf("Store 未初始化")
+	}
+	if err := cs.store.AppConfig.Set("xrayAssetDir", strings.TrimSpace(dir)); err != nil {
+		return err
+	}
+	return cs.ApplyXrayEnvironment()
+}
+
+// xray 内核（xrayCoreMode 取值）
+const (
+	XrayCoreEmbedded = "embedded" // 内置 xray-core
+	XrayCoreExternal = "external" // 外部 xray 可执行文件
+)
+
+// GetXrayCoreMode 获取 xray 内核模式。
+func (cs *ConfigService) GetXrayCoreMode() string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return XrayCoreEmbedded
+	}
+	return cs.typedValue("xrayCoreMode", ConfigKindString)
+}
+
+// GetXrayBinaryPath 获取外部 xray 可执行文件路径。
+func (cs *ConfigService) GetXrayBinaryPath() string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return ""
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("xrayBinaryPath", "")
+	return strings.TrimSpace(v)
+}
+
+// SetXrayCore 设置 xray 内核；external 时 binaryPath 须指向存在的可执行文件，下次启动代理时生效。
+func (cs *ConfigService) SetXrayCore(mode, binaryPath string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return fmt.Errorf("Store 未初始化")
+	}
+	binaryPath = strings.TrimSpace(binaryPath)
+	if mode == XrayCoreExternal {
+		if err := xray.CheckExternalBinary(binaryPath); err != nil {
+			return err
+		}
+	}
+	if err := cs.store.AppConfig.Set("xrayBinaryPath", binaryPath); err != nil {
+		return err
+	}
+	return cs.Set("xrayCoreMode", mode)
+}
+
+// GetXrayAPIEnabled 获取是否在生成的 xray 配置中开启 gRPC API。
+func (cs *ConfigService) GetXrayAPIEnabled() bool {
+	return cs.GetBool("xrayAPIEnabled")
+}
+
+// GetXrayAPIPort 获取 xray gRPC API 的本机监听端口。
+func (cs *ConfigService) GetXrayAPIPort() int {
+	return cs.GetInt("xrayAPIPort")
+}
+
+// SetXrayAPI 设置 xray gRPC API 开关与端口（1024-65535，不能与本地入站端口相同），下次启动代理时生效。
+func (cs *ConfigService) SetXrayAPI(enabled bool, port int) error {
+	if port == cs.GetLocalInboundPort() {
+		return fmt.Errorf("API 端口 %d 与本地入站端口相同", port)
+	}
+	if err := cs.SetInt("xrayAPIPort", port); err != nil {
+		return err
+	}
+	return cs.SetBool("xrayAPIEnabled", enabled)
+}
+
+// ApplyXrayEnvironment 将 xray-core 的资源目录设为 GetXrayAssetDir，证书与配置目录限定在数据目录下。
+func (cs *ConfigService) ApplyXrayEnvironment() error {
+	return xray.ConfigureEnvironment(cs.GetXrayAssetDir(), xrayRuntimeDir())
+}
+
 // GetDirectRoutes 获取直连路由列表（域名或 IP/CIDR，每行一条，对应 xray 规则）。
 // 返回：直连地址列表，空切片表示未配置
 func (cs *ConfigService) GetDirectRoutes() []string {
@@ -390,33 +1382,38 @@ func (cs *ConfigService) SetDirectRoutes(routes []string) error {
 // GetDirectRoutesUseProxy 获取「直连列表中的地址是否走代理」。
 // true：直连列表中的地址走代理；false：走直连。
 func (cs *ConfigService) GetDirectRoutesUseProxy() bool {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return false
-	}
-	v, _ := cs.store.AppConfig.GetWithDefault("directRoutesUseProxy", database.AppConfigBuiltinDefault("directRoutesUseProxy"))
-	return v == "true"
+	return cs.GetBool("directRoutesUseProxy")
 }
 
 // SetDirectRoutesUseProxy 设置「直连列表中的地址是否走代理」。
 func (cs *ConfigService) SetDirectRoutesUseProxy(useProxy bool) error {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
-	}
-	val := "false"
-	if useProxy {
-		val = "true"
-	}
-	return cs.store.AppConfig.Set("directRoutesUseProxy", val)
+	return cs.SetBool("directRoutesUseProxy", useProxy)
+}
+
+// GetBypassLanAndCN 获取「绕过局域网与中国大陆」开关。
+func (cs *ConfigService) GetBypassLanAndCN() bool {
+	return cs.GetBool("bypassLanAndCN")
+}
+
+// SetBypassLanAndCN 设置「绕过局域网与中国大陆」开关。
+func (cs *ConfigService) SetBypassLanAndCN(enabled bool) error {
+	return cs.SetBool("bypassLanAndCN", enabled)
+}
+
+// GetFinalOutbound 获取最终规则的流量走向（xray.FinalOutboundProxy、FinalOutboundDirect 或 FinalOutboundBlock）。
+func (cs *ConfigService) GetFinalOutbound() string {
+	return cs.typedValue("finalOutbound", ConfigKindString)
+}
+
+// SetFinalOutbound 设置最终规则的流量走向。
+func (cs *ConfigService) SetFinalOutbound(outbound string) error {
+	return cs.Set("finalOutbound", outbound)
 }
 
 // GetTerminalProxyEnabled 获取是否启用终端代理配置。
 // 返回：是否启用终端代理配置
 func (cs *ConfigService) GetTerminalProxyEnabled() bool {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return false // 默认不启用
-	}
-	v, _ := cs.store.AppConfig.GetWithDefault("terminalProxyEnabled", database.AppConfigBuiltinDefault("terminalProxyEnabled"))
-	return v == "true"
+	return cs.GetBool("terminalProxyEnabled")
 }
 
 // SetTerminalProxyEnabled 设置是否启用终端代理配置。
@@ -425,35 +1422,17 @@ func (cs *ConfigService) GetTerminalProxyEnabled() bool {
 //
 // 返回：错误（如果有）
 func (cs *ConfigService) SetTerminalProxyEnabled(enabled bool) error {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
-	}
-	val := "false"
-	if enabled {
-		val = "true"
-	}
-	return cs.store.AppConfig.Set("terminalProxyEnabled", val)
+	return cs.SetBool("terminalProxyEnabled", enabled)
 }
 
 // GetGitProxyEnabled 获取是否由本应用写入 Git 全局 http(s).proxy。
 func (cs *ConfigService) GetGitProxyEnabled() bool {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return false
-	}
-	v, _ := cs.store.AppConfig.GetWithDefault("gitProxyEnabled", database.AppConfigBuiltinDefault("gitProxyEnabled"))
-	return v == "true"
+	return cs.GetBool("gitProxyEnabled")
 }
 
 // SetGitProxyEnabled 设置是否写入 Git 全局代理。
 func (cs *ConfigService) SetGitProxyEnabled(enabled bool) error {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
-	}
-	val := "false"
-	if enabled {
-		val = "true"
-	}
-	return cs.store.AppConfig.Set("gitProxyEnabled", val)
+	return cs.SetBool("gitProxyEnabled", enabled)
 }
 
 // GetProxyType 获取代理类型配置。
@@ -479,7 +1458,7 @@ func (cs *ConfigService) SetProxyType(proxyType string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
 		return fmt.Errorf("Store 未初始化")
 	}
-	return cs.store.AppConfig.Set("proxyType", proxyType)
+	return cs.Set("proxyType", proxyType)
 }
 
 // parseDirectRoutes 从换行分隔的字符串解析直连路由列表。
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/utils"
+	"myproxy.com/p/internal/xray"
+)
+
+// ConfigValueKind 配置值类型。
+type ConfigValueKind int
+
+const (
+	// ConfigKindString 任意字符串（可用 Allowed 限定取值）
+	ConfigKindString ConfigValueKind = iota
+	// ConfigKindBool 布尔值，规范存储为 "true"/"false"
+	ConfigKindBool
+	// ConfigKindInt 整数，可用 Min/Max 或 Allowed 限定范围
+	ConfigKindInt
+	// ConfigKindDuration 时长，规范存储为 Go duration 字符串（如 "30s"），兼容纯数字秒数
+	ConfigKindDuration
+)
+
+// String 返回类型名称，用于错误信息。
+func (k ConfigValueKind) String() string {
+	switch k {
+	case ConfigKindBool:
+		return "bool"
+	case ConfigKindInt:
+		return "int"
+	case ConfigKindDuration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// ConfigKeySpec 描述一个 app_config 键的类型与取值约束；默认值统一取自 database 内置默认值。
+type ConfigKeySpec struct {
+	Key  string
+	Kind ConfigValueKind
+	// Min/Max 仅对 int（数值）与 duration（秒）生效；均为 0 表示不限制
+	Min, Max int64
+	// Allowed 非空时值必须为其中之一（对 string 与 int 生效）
+	Allowed []string
+}
+
+// configSchema 已知配置键的类型定义；未登记的键按原样读写，不做校验。
+var configSchema = map[string]ConfigKeySpec{}
+
+func init() {
+	for _, spec := range []ConfigKeySpec{
+		{Key: "logLevel", Kind: ConfigKindString, Allowed: []string{"debug", "info", "warn", "error", "fatal"}},
+		{Key: "theme", Kind: ConfigKindString, Allowed: []string{"dark", "light", "system"}},
+		{Key: "listDensity", Kind: ConfigKindString, Allowed: []string{ListDensityComfortable, ListDensityStandard, ListDensityCompact}},
+		{Key: "speedUnit", Kind: ConfigKindString, Allowed: []string{SpeedUnitBytes, SpeedUnitBits}},
+		{Key: "numberFormat", Kind: ConfigKindString, Allowed: []string{NumberFormatSystem, NumberFormatCommaDot, NumberFormatDotComma, NumberFormatSpaceComma, NumberFormatPlain}},
+		{Key: "windowAlwaysOnTop", Kind: ConfigKindBool},
+		{Key: "miniWindowEnabled", Kind: ConfigKindBool},
+		{Key: "trayShowSpeed", Kind: ConfigKindBool},
+		{Key: "lowPowerMode", Kind: ConfigKindString, Allowed: []string{LowPowerModeAuto, LowPowerModeOn, LowPowerModeOff}},
+		{Key: "notifyOnConnect", Kind: ConfigKindBool},
+		{Key: "notifyOnDisconnect", Kind: ConfigKindBool},
+		{Key: "notifyOnFailover", Kind: ConfigKindBool},
+		{Key: "sessionReportEnabled", Kind: ConfigKindBool},
+		{Key: "proxyType", Kind: ConfigKindString, Allowed: []string{"socks5", "http", "https_tls"}},
+		{Key: "autoProxyEnabled", Kind: ConfigKindBool},
+		{Key: "autoStartProxy", Kind: ConfigKindBool},
+		{Key: "debugPprofEnabled", Kind: ConfigKindBool},
+		{Key: "terminalProxyEnabled", Kind: ConfigKindBool},
+		{Key: "gitProxyEnabled", Kind: ConfigKindBool},
+		{Key: "mixedInboundListenAll", Kind: ConfigKindBool},
+		{Key: "lanInboundEnabled", Kind: ConfigKindBool},
+		{Key: "lanInboundPort", Kind: ConfigKindInt, Min: 1, Max: 65535},
+		{Key: "lanInboundProtocol", Kind: ConfigKindString, Allowed: []string{"http", "mixed"}},
+		{Key: "inboundAuthEnabled", Kind: ConfigKindBool},
+		{Key: "inboundRateLimitLanOnly", Kind: ConfigKindBool},
+		{Key: "directRoutesUseProxy", Kind: ConfigKindBool},
+		{Key: "bypassLanAndCN", Kind: ConfigKindBool},
+		{Key: "logsCollapsed", Kind: ConfigKindBool},
+		{Key: "autoProbeSelectedNode", Kind: ConfigKindBool},
+		{Key: "importApiEnabled", Kind: ConfigKindBool},
+		{Key: "metricsEnabled", Kind: ConfigKindBool},
+		{Key: "statusFileEnabled", Kind: ConfigKindBool},
+		{Key: "clipboardMonitorEnabled", Kind: ConfigKindBool},
+		{Key: "webdavSyncEnabled", Kind: ConfigKindBool},
+		{Key: "webdavSyncCredentials", Kind: ConfigKindBool},
+		{Key: "keepUnsupportedSSR", Kind: ConfigKindBool},
+		{Key: "accessRecordMode", Kind: ConfigKindString, Allowed: []string{AccessRecordModeFull, AccessRecordModeHashed, AccessRecordModeOff}},
+		{Key: "accessRecordIPs", Kind: ConfigKindBool},
+		{Key: "processStatsEnabled", Kind: ConfigKindBool},
+		{Key: "finalOutbound", Kind: ConfigKindString, Allowed: []string{xray.FinalOutboundProxy, xray.FinalOutboundDirect, xray.FinalOutboundBlock}},
+		{Key: "balancerEnabled", Kind: ConfigKindBool},
+		{Key: "balancerStrategy", Kind: ConfigKindString, Allowed: []string{"leastPing", "leastLoad"}},
+		{Key: "observatoryProbeInterval", Kind: ConfigKindDuration, Min: 10, Max: 3600},
+		{Key: "fragmentEnabled", Kind: ConfigKindBool},
+		{Key: "xrayCoreMode", Kind: ConfigKindString, Allowed: []string{XrayCoreEmbedded, XrayCoreExternal}},
+		{Key: "xrayAPIEnabled", Kind: ConfigKindBool},
+		{Key: "xrayAPIPort", Kind: ConfigKindInt, Min: 1024, Max: 65535},
+		{Key: "policyHandshake", Kind: ConfigKindDuration, Min: 1, Max: 60},
+		{Key: "policyConnIdle", Kind: ConfigKindDuration, Min: 10, Max: 3600},
+		{Key: "policyUplinkOnly", Kind: ConfigKindDuration, Min: 0, Max: 60},
+		{Key: "policyDownlinkOnly", Kind: ConfigKindDuration, Min: 0, Max: 60},
+		{Key: "autoProxyPort", Kind: ConfigKindInt, Min: 1, Max: 65535},
+		{Key: "autoDisableFailThreshold", Kind: ConfigKindInt, Min: 0, Max: 100},
+		{Key: "xrayAutoRestart", Kind: ConfigKindBool},
+		{Key: "xrayAutoRestartMaxRetries", Kind: ConfigKindInt, Min: 1, Max: 20},
+		{Key: "pingFreshMinutes", Kind: ConfigKindInt, Min: 0, Max: 1440},
+		{Key: "latencyMethod", Kind: ConfigKindString, Allowed: []string{utils.DelayMethodTCP, utils.DelayMethodTLS}},
+		{Key: "scheduledPingInterval", Kind: ConfigKindDuration, Min: 0, Max: 86400},
+		{Key: "scheduledPingACOnly", Kind: ConfigKindBool},
+		{Key: "prewarmEnabled", Kind: ConfigKindBool},
+		{Key: "prewarmTopN", Kind: ConfigKindInt, Min: 1, Max: 20},
+		{Key: "externalRefreshSeconds", Kind: ConfigKindInt, Min: 0, Max: 300},
+		{Key: "usageStatsEnabled", Kind: ConfigKindBool},
+		{Key: "appLockOnStartup", Kind: ConfigKindBool},
+		{Key: "appLockSettings", Kind: ConfigKindBool},
+		{Key: "appLockIdleMinutes", Kind: ConfigKindInt, Min: 0, Max: 1440},
+		{Key: "selectedSubscriptionID", Kind: ConfigKindInt, Min: 0},
+		{Key: "inboundUploadLimitKBps", Kind: ConfigKindInt, Min: 0, Max: 10485760},
+		{Key: "inboundDownloadLimitKBps", Kind: ConfigKindInt, Min: 0, Max: 10485760},
+		{Key: "diagnosticsSamplingSeconds", Kind: ConfigKindInt, Allowed: []string{"1", "5", "10"}},
+	} {
+		configSchema[spec.Key] = spec
+	}
+}
+
+// LookupConfigSpec 返回配置键的类型定义，未登记时 ok 为 false。
+func LookupConfigSpec(key string) (spec ConfigKeySpec, ok bool) {
+	spec, ok = configSchema[key]
+	return spec, ok
+}
+
+// normalizeConfigValue 按类型定义解析原始值并返回规范形式；无法解析或越界时返回错误。
+func normalizeConfigValue(spec ConfigKeySpec, raw string) (string, error) {
+	v := strings.TrimSpace(raw)
+	switch spec.Kind {
+	case ConfigKindBool:
+		b, err := parseConfigBool(v)
+		if err != nil {
+			return "", fmt.Errorf("配置 %s 需要布尔值，实际为 %q", spec.Key, raw)
+		}
+		return strconv.FormatBool(b), nil
+	case ConfigKindInt:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("配置 %s 需要整数，实际为 %q", spec.Key, raw)
+		}
+		if err := checkConfigRange(spec, n, strconv.FormatInt(n, 10)); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	case ConfigKindDuration:
+		d, err := parseConfigDuration(v)
+		if err != nil {
+			return "", fmt.Errorf("配置 %s 需要时长（如 30s、5m），实际为 %q", spec.Key, raw)
+		}
+		if err := checkConfigRange(spec, int64(d/time.Second), d.String()); err != nil {
+			return "", err
+		}
+		return d.String(), nil
+	default:
+		if len(spec.Allowed) > 0 && !containsString(spec.Allowed, v) {
+			return "", fmt.Errorf("配置 %s 取值无效 %q，可选：%s", spec.Key, raw, strings.Join(spec.Allowed, "/"))
+		}
+		return v, nil
+	}
+}
+
+// checkConfigRange 校验数值范围与枚举取值。
+func checkConfigRange(spec ConfigKeySpec, n int64, canonical string) error {
+	if len(spec.Allowed) > 0 && !containsString(spec.Allowed, canonical) {
+		return fmt.Errorf("配置 %s 取值无效 %s，可选：%s", spec.Key, canonical, strings.Join(spec.Allowed, "/"))
+	}
+	if spec.Min != 0 || spec.Max != 0 {
+		if n < spec.Min || (spec.Max != 0 && n > spec.Max) {
+			if spec.Max == 0 {
+				return fmt.Errorf("配置 %s 取值 %s 超出范围（需 ≥ %d）", spec.Key, canonical, spec.Min)
+			}
+			return fmt.Errorf("配置 %s 取值 %s 超出范围 %d-%d", spec.Key, canonical, spec.Min, spec.Max)
+		}
+	}
+	return nil
+}
+
+// parseConfigBool 解析布尔值，除 strconv.ParseBool 支持的写法外也接受 yes/no/on/off。
+func parseConfigBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// parseConfigDuration 解析时长；纯数字按秒处理，兼容历史以秒存储的配置。
+func parseConfigDuration(v string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(v)
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// typedValue 读取配置并按类型定义规范化；Store 未初始化、键缺失或值无效时返回内置默认值的规范形式。
+func (cs *ConfigService) typedValue(key string, kind ConfigValueKind) string {
+	spec, ok := configSchema[key]
+	if !ok {
+		spec = ConfigKeySpec{Key: key, Kind: kind}
+	}
+	def := database.AppConfigBuiltinDefault(key)
+	if canonical, err := normalizeConfigValue(spec, def); err == nil {
+		def = canonical
+	}
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return def
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault(key, database.AppConfigBuiltinDefault(key))
+	if err != nil {
+		return def
+	}
+	v, err := normalizeConfigValue(spec, raw)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetBool 按布尔类型读取配置；值无法解析时返回内置默认值，而不是静默当作 false。
+func (cs *ConfigService) GetBool(key string) bool {
+	b, _ := parseConfigBool(cs.typedValue(key, ConfigKindBool))
+	return b
+}
+
+// GetInt 按整数类型读取配置；值无法解析或越界时返回内置默认值。
+func (cs *ConfigService) GetInt(key string) int {
+	n, _ := strconv.Atoi(cs.typedValue(key, ConfigKindInt))
+	return n
+}
+
+// GetDuration 按时长类型读取配置；值无法解析或越界时返回内置默认值。
+func (cs *ConfigService) GetDuration(key string) time.Duration {
+	d, _ := parseConfigDuration(cs.typedValue(key, ConfigKindDuration))
+	return d
+}
+
+// SetBool 保存布尔配置。
+func (cs *ConfigService) SetBool(key string, value bool) error {
+	return cs.Set(key, strconv.FormatBool(value))
+}
+
+// SetInt 保存整数配置，超出类型定义范围时返回错误。
+func (cs *ConfigService) SetInt(key string, value int) error {
+	return cs.Set(key, strconv.Itoa(value))
+}
+
+// SetDuration 保存时长配置，超出类型定义范围时返回错误。
+func (cs *ConfigService) SetDuration(key string, value time.Duration) error {
+	return cs.Set(key, value.String())
+}
+
+// ValidateConfigValue 按类型定义校验配置值并返回规范形式；未登记的键原样返回。
+func ValidateConfigValue(key, value string) (string, error) {
+	spec, ok := configSchema[key]
+	if !ok {
+		return value, nil
+	}
+	return normalizeConfigValue(spec, value)
+}
+
+// MigrateTypedConfig 将已登记键的存量值迁移为规范形式（如 "1"/"TRUE" → "true"），
+// 无法解析的值重置为内置默认值。返回每项修正的说明，供启动时写入日志。
+func (cs *ConfigService) MigrateTypedConfig() []string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(configSchema))
+	for key := range configSchema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var changes []string
+	for _, key := range keys {
+		raw, err := cs.store.AppConfig.Get(key)
+		if err != nil {
+			continue
+		}
+		if key == "proxyType" && raw == "https" {
+			// 历史值由 GetProxyType 迁移
+			continue
+		}
+		canonical, err := normalizeConfigValue(configSchema[key], raw)
+		if err != nil {
+			def := database.AppConfigBuiltinDefault(key)
+			if setErr := cs.store.AppConfig.Set(key, def); setErr != nil {
+				changes = append(changes, fmt.Sprintf("%v；重置失败: %v", err, setErr))
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("%v，已重置为默认值 %q", err, def))
+			continue
+		}
+		if canonical != raw {
+			if err := cs.store.AppConfig.Set(key, canonical); err == nil {
+				changes = append(changes, fmt.Sprintf("配置 %s: %q 已规范为 %q", key, raw, canonical))
+			}
+		}
+	}
+	return changes
+}
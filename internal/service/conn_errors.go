@@ -0,0 +1,158 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/xray"
+)
+
+// 连接错误发生在哪一侧
+const (
+	ConnErrorSideNode    = "node"    // 连接节点失败：节点不可用
+	ConnErrorSideTarget  = "target"  // 直连或解析目标失败：目标网站不可达
+	ConnErrorSideInbound = "inbound" // 本地入站拒绝：客户端认证失败或请求无效
+)
+
+// 连接错误类型
+const (
+	ConnErrorRefused = "refused" // 连接被拒绝
+	ConnErrorTimeout = "timeout" // 超时
+	ConnErrorDNS     = "dns"     // 域名解析失败
+	ConnErrorAuth    = "auth"    // 认证失败
+	ConnErrorReset   = "reset"   // 连接被重置
+	ConnErrorOther   = "other"   // 其他
+)
+
+// ConnErrorSides 与 ConnErrorKinds 为展示顺序
+var (
+	ConnErrorSides = []string{ConnErrorSideNode, ConnErrorSideTarget, ConnErrorSideInbound}
+	ConnErrorKinds = []string{ConnErrorRefused, ConnErrorTimeout, ConnErrorDNS, ConnErrorAuth, ConnErrorReset, ConnErrorOther}
+)
+
+// connErrorKindPatterns 按顺序匹配错误原因（小写子串），先匹配到的生效
+var connErrorKindPatterns = []struct {
+	kind     string
+	patterns []string
+}{
+	{ConnErrorAuth, []string{"invalid user", "invalid username or password", "invalid password", "authentication failed", "unauthorized"}},
+	{ConnErrorDNS, []string{"no such host", "server misbehaving", "app/dns:", "lookup ", "failed to resolve"}},
+	{ConnErrorRefused, []string{"connection refused", "actively refused"}},
+	{ConnErrorTimeout, []string{"i/o timeout", "deadline exceeded", "timed out", "timeout"}},
+	{ConnErrorReset, []string{"connection reset", "forcibly closed", "broken pipe"}},
+}
+
+// connNodeOutboundMarkers 代理协议出站的日志前缀，出现时表示连接节点失败
+var connNodeOutboundMarkers = []string{
+	"failed to find an available destination",
+	"proxy/vmess/outbound:", "proxy/vless/outbound:", "proxy/trojan:", "proxy/shadowsocks:", "proxy/hysteria:",
+	"proxy/wireguard:",
+}
+
+// ClassifyConnError 将 xray 日志行归类为连接错误：返回出错的一侧与错误类型；非连接错误的日志返回 ok=false。
+// 只统计 xray 的告警/错误日志（含模块路径，如 proxy/freedom:）与入站拒绝的访问日志，应用自身的日志不计入。
+func ClassifyConnError(line string) (side, kind string, ok bool) {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.Contains(lower, "from ") && strings.Contains(lower, " rejected "):
+		side = ConnErrorSideInbound
+	case !strings.Contains(lower, "[warning]") && !strings.Contains(lower, "[error]") &&
+		!strings.Contains(lower, "[warn]"):
+		return "", "", false
+	case strings.Contains(lower, "proxy/freedom:") || strings.Contains(lower, "app/dns:"):
+		side = ConnErrorSideTarget
+	default:
+		for _, m := range connNodeOutboundMarkers {
+			if strings.Contains(lower, m) {
+				side = ConnErrorSideNode
+				break
+			}
+		}
+		if side == "" {
+			return "", "", false
+		}
+	}
+	kind = ConnErrorOther
+	for _, p := range connErrorKindPatterns {
+		for _, s := range p.patterns {
+			if strings.Contains(lower, s) {
+				return side, p.kind, true
+			}
+		}
+	}
+	return side, kind, true
+}
+
+// ConnErrorSnapshot 连接错误计数快照：Counts[一侧][类型] = 次数。
+type ConnErrorSnapshot struct {
+	Since  time.Time
+	Counts map[string]map[string]int64
+}
+
+// Total 返回某一侧的错误总数。
+func (s ConnErrorSnapshot) Total(side string) int64 {
+	var n int64
+	for _, c := range s.Counts[side] {
+		n += c
+	}
+	return n
+}
+
+// ConnErrorStatsService 从 xray 日志统计本次运行的连接错误，按出错的一侧（节点 / 目标网站 / 本地入站）
+// 与类型（拒绝、超时、DNS、认证、重置）计数，便于区分「节点坏了」与「目标网站挂了」。仅保存在内存中。
+type ConnErrorStatsService struct {
+	mu     sync.Mutex
+	since  time.Time
+	counts map[string]map[string]int64
+}
+
+// NewConnErrorStatsService 创建连接错误统计服务。
+func NewConnErrorStatsService() *ConnErrorStatsService {
+	return &ConnErrorStatsService{since: time.Now(), counts: make(map[string]map[string]int64)}
+}
+
+// RecordFromLogLine 解析日志行，若为连接错误则计数。
+// 重复日志的汇总行（「又出现 N 次，已省略」）按被省略的 N 次计入，节点频繁断连时计数不会偏少。
+func (s *ConnErrorStatsService) RecordFromLogLine(line string) {
+	if s == nil {
+		return
+	}
+	side, kind, ok := ClassifyConnError(line)
+	if !ok {
+		return
+	}
+	n := int64(1)
+	if suppressed := xray.SuppressedLogCount(line); suppressed > 0 {
+		n = int64(suppressed)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts[side] == nil {
+		s.counts[side] = make(map[string]int64)
+	}
+	s.counts[side][kind] += n
+}
+
+// Snapshot 返回当前计数的副本。
+func (s *ConnErrorStatsService) Snapshot() ConnErrorSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := ConnErrorSnapshot{Since: s.since, Counts: make(map[string]map[string]int64, len(s.counts))}
+	for side, kinds := range s.counts {
+		m := make(map[string]int64, len(kinds))
+		for k, v := range kinds {
+			m[k] = v
+		}
+		snap.Counts[side] = m
+	}
+	return snap
+}
+
+// Reset 清零计数并从现在开始重新统计。
+func (s *ConnErrorStatsService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = time.Now()
+	s.counts = make(map[string]map[string]int64)
+}
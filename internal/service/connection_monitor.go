@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// connectionCheckInterval 检查连通性与负载均衡组选中节点的间隔
+	connectionCheckInterval = 30 * time.Second
+	// lowPowerConnectionCheckInterval 低功耗模式下的检查间隔
+	lowPowerConnectionCheckInterval = 2 * time.Minute
+	// connectionProbeTimeout 经隧道探测的超时
+	connectionProbeTimeout = 10 * time.Second
+	// connectionLostThreshold 连续探测失败达到该次数视为连接中断，避免偶发超时误报
+	connectionLostThreshold = 2
+)
+
+// ConnectionEventKind 连接事件类型
+type ConnectionEventKind int
+
+const (
+	ConnectionEventConnected    ConnectionEventKind = iota // 代理启动成功，或中断后恢复
+	ConnectionEventDisconnected                            // 代理仍在运行但经隧道无法访问外网
+	ConnectionEventFailover                                // 负载均衡组自动切换到另一个节点
+)
+
+// ConnectionEvent 连接状态事件。
+type ConnectionEvent struct {
+	Kind     ConnectionEventKind
+	Node     string // 相关节点名称（切换时为新节点）
+	Detail   string // 补充说明，如中断原因、切换前的节点
+	Restored bool   // Connected 事件：是否为中断后恢复
+}
+
+// ConnectionMonitor 监控运行中的代理：定期经本地入站探测隧道连通性（需开启中断通知或连接报告），
+// 并跟踪负载均衡组当前选中的节点，通过回调报告连接、中断与自动切换事件。
+type ConnectionMonitor struct {
+	xcs     *XrayControlService
+	config  *ConfigService
+	power   *PowerService
+	onEvent func(ConnectionEvent)
+	session *SessionReportService
+
+	mu       sync.Mutex
+	instance *xray.XrayInstance
+	stopCh   chan struct{}
+}
+
+// NewConnectionMonitor 创建连接监控；onEvent 在监控 goroutine 中调用（Watch 产生的连接事件在调用方 goroutine 中调用）。
+func NewConnectionMonitor(xcs *XrayControlService, config *ConfigService, power *PowerService, onEvent func(ConnectionEvent)) *ConnectionMonitor {
+	return &ConnectionMonitor{xcs: xcs, config: config, power: power, onEvent: onEvent}
+}
+
+// SetSessionReport 设置连接报告服务，探测延迟与中断后恢复计入当前连接的报告。
+func (cm *ConnectionMonitor) SetSessionReport(session *SessionReportService) {
+	cm.session = session
+}
+
+// Watch 开始监控新启动的代理实例并报告连接事件；同一实例重复调用时忽略。
+func (cm *ConnectionMonitor) Watch(instance *xray.XrayInstance, nodeName string) {
+	if instance == nil {
+		cm.Unwatch()
+		return
+	}
+	cm.mu.Lock()
+	if cm.instance == instance {
+		cm.mu.Unlock()
+		return
+	}
+	if cm.stopCh != nil {
+		close(cm.stopCh)
+	}
+	stopCh := make(chan struct{})
+	cm.instance, cm.stopCh = instance, stopCh
+	cm.mu.Unlock()
+
+	var balancerNodes []*model.Node
+	if cm.xcs != nil {
+		balancerNodes = cm.xcs.BalancerNodes()
+	}
+	cm.emit(ConnectionEvent{Kind: ConnectionEventConnected, Node: nodeName})
+	go cm.run(instance, stopCh, balancerNodes)
+}
+
+// Unwatch 停止监控（用户主动停止代理或退出时调用），不报告事件。
+func (cm *ConnectionMonitor) Unwatch() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.stopCh != nil {
+		close(cm.stopCh)
+		cm.stopCh = nil
+	}
+	cm.instance = nil
+}
+
+func (cm *ConnectionMonitor) emit(event ConnectionEvent) {
+	if cm.onEvent != nil {
+		cm.onEvent(event)
+	}
+}
+
+func (cm *ConnectionMonitor) interval() time.Duration {
+	if cm.power.LowPower() {
+		return lowPowerConnectionCheckInterval
+	}
+	return connectionCheckInterval
+}
+
+func (cm *ConnectionMonitor) run(instance *xray.XrayInstance, stopCh chan struct{}, balancerNodes []*model.Node) {
+	var (
+		lastTarget string
+		failures   int
+		lost       bool
+	)
+	timer := time.NewTimer(cm.interval())
+	defer timer.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-timer.C:
+		}
+		if !instance.IsRunning() {
+			return
+		}
+
+		if len(balancerNodes) > 0 {
+			target := instance.BalancerTarget()
+			if target != "" && lastTarget != "" && target != lastTarget {
+				cm.emit(ConnectionEvent{
+					Kind:   ConnectionEventFailover,
+					Node:   balancerNodeName(balancerNodes, target),
+					Detail: balancerNodeName(balancerNodes, lastTarget),
+				})
+			}
+			if target != "" {
+				lastTarget = target
+			}
+		}
+
+		notify := cm.config != nil && cm.config.GetNotifyOnDisconnect()
+		if notify || cm.session.Tracking() {
+			latency, err := cm.probe(instance.GetPort())
+			if err != nil {
+				failures++
+				if failures >= connectionLostThreshold && !lost {
+					lost = true
+					if notify {
+						cm.emit(ConnectionEvent{Kind: ConnectionEventDisconnected, Detail: err.Error()})
+					}
+				}
+			} else {
+				failures = 0
+				if cm.session != nil {
+					cm.session.RecordLatency(latency)
+				}
+				if lost {
+					lost = false
+					if cm.session != nil {
+						cm.session.RecordReconnect()
+					}
+					if notify {
+						cm.emit(ConnectionEvent{Kind: ConnectionEventConnected, Restored: true})
+					}
+				}
+			}
+		}
+
+		// 停止后不再报告：Unwatch 与本轮检查并发时以 Unwatch 为准
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		timer.Reset(cm.interval())
+	}
+}
+
+// selectedNodeID 返回当前选中节点的 ID，用于选择该节点所属订阅的测速地址。
+func (cm *ConnectionMonitor) selectedNodeID() string {
+	if cm.xcs == nil || cm.xcs.store == nil || cm.xcs.store.Nodes == nil {
+		return ""
+	}
+	return cm.xcs.store.Nodes.GetSelectedID()
+}
+
+// probe 经本地入站请求隧道内探测地址（与负载均衡组相同），返回收到响应的耗时，
+// 或 2xx/3xx 以外的结果与网络错误。
+func (cm *ConnectionMonitor) probe(proxyPort int) (time.Duration, error) {
+	probeURL := xray.DefaultObservatoryProbeURL
+	if cm.config != nil {
+		probeURL = cm.config.ProbeURLForNode(cm.selectedNodeID())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectionProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("连接监控: 创建探测请求失败: %w", err)
+	}
+	start := time.Now()
+	resp, err := localInboundClient(cm.config, proxyPort, connectionProbeTimeout).Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("连接监控: 探测失败: %w", err)
+	}
+	latency := time.Since(start)
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return 0, fmt.Errorf("连接监控: 探测失败: HTTP %d", resp.StatusCode)
+	}
+	return latency, nil
+}
+
+// balancerNodeName 由均衡组出站 tag 得到节点名称，无法识别时返回 tag。
+func balancerNodeName(nodes []*model.Node, tag string) string {
+	if i := xray.BalancerNodeIndex(tag); i >= 0 && i < len(nodes) {
+		return nodes[i].Name
+	}
+	return tag
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/systemproxy"
+)
+
+// devToolBackupKey 写入开发工具代理前的原始设置（JSON：工具 -> 键 -> 原值），移除时据此恢复
+const devToolBackupKey = "devToolProxyBackup"
+
+// DevToolsService 一键为 git、npm、Docker 客户端写入或移除指向本地入站的代理设置。
+type DevToolsService struct {
+	config *ConfigService
+}
+
+// NewDevToolsService 创建开发工具代理服务。
+func NewDevToolsService(config *ConfigService) *DevToolsService {
+	return &DevToolsService{config: config}
+}
+
+// PlanApply 预览将工具指向当前本地入站（HTTP，含入站认证账号）所需的变化。
+func (ds *DevToolsService) PlanApply(tool systemproxy.DevTool) ([]systemproxy.DevToolSetting, error) {
+	if ds.config == nil {
+		return nil, fmt.Errorf("开发工具代理: 配置服务未初始化")
+	}
+	user, pass := ds.config.InboundAuth()
+	opts := systemproxy.SnippetOptions{
+		Host: database.LocalMixedInboundListenHost,
+		Port: ds.config.GetLocalInboundPort(),
+		User: user,
+		Pass: pass,
+	}
+	values := systemproxy.DevToolProxyValues(tool, systemproxy.HTTPProxyURL(opts), systemproxy.NoProxyList())
+	changes, err := systemproxy.PlanDevToolSettings(tool, values)
+	if err != nil {
+		return nil, fmt.Errorf("开发工具代理: %w", err)
+	}
+	return changes, nil
+}
+
+// Apply 写入 PlanApply 给出的变化；首次写入的键会记录原值，供 Remove 恢复。
+func (ds *DevToolsService) Apply(changes []systemproxy.DevToolSetting) error {
+	backup := ds.loadBackup()
+	for _, c := range changes {
+		if backup[string(c.Tool)] == nil {
+			backup[string(c.Tool)] = map[string]string{}
+		}
+		if _, saved := backup[string(c.Tool)][c.Key]; !saved {
+			backup[string(c.Tool)][c.Key] = c.Old
+		}
+	}
+	if err := ds.saveBackup(backup); err != nil {
+		return err
+	}
+	if err := systemproxy.ApplyDevToolSettings(changes); err != nil {
+		return fmt.Errorf("开发工具代理: %w", err)
+	}
+	return nil
+}
+
+// PlanRemove 预览移除代理设置所需的变化：恢复写入前记录的原值，没有记录的键直接移除。
+func (ds *DevToolsService) PlanRemove(tool systemproxy.DevTool) ([]systemproxy.DevToolSetting, error) {
+	values := ds.loadBackup()[string(tool)]
+	changes, err := systemproxy.PlanDevToolSettings(tool, values)
+	if err != nil {
+		return nil, fmt.Errorf("开发工具代理: %w", err)
+	}
+	return changes, nil
+}
+
+// Remove 写入 PlanRemove 给出的变化并清除该工具的原值记录。
+func (ds *DevToolsService) Remove(tool systemproxy.DevTool, changes []systemproxy.DevToolSetting) error {
+	if err := systemproxy.ApplyDevToolSettings(changes); err != nil {
+		return fmt.Errorf("开发工具代理: %w", err)
+	}
+	backup := ds.loadBackup()
+	delete(backup, string(tool))
+	return ds.saveBackup(backup)
+}
+
+// IsApplied 判断工具是否有由本应用写入、尚未移除的代理设置。
+func (ds *DevToolsService) IsApplied(tool systemproxy.DevTool) bool {
+	_, ok := ds.loadBackup()[string(tool)]
+	return ok
+}
+
+func (ds *DevToolsService) loadBackup() map[string]map[string]string {
+	backup := map[string]map[string]string{}
+	if ds.config == nil || ds.config.store == nil || ds.config.store.AppConfig == nil {
+		return backup
+	}
+	raw, _ := ds.config.store.AppConfig.GetWithDefault(devToolBackupKey, "")
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &backup)
+	}
+	return backup
+}
+
+func (ds *DevToolsService) saveBackup(backup map[string]map[string]string) error {
+	if ds.config == nil || ds.config.store == nil || ds.config.store.AppConfig == nil {
+		return fmt.Errorf("开发工具代理: Store 未初始化")
+	}
+	raw := ""
+	if len(backup) > 0 {
+		data, err := json.Marshal(backup)
+		if err != nil {
+			return fmt.Errorf("开发工具代理: 序列化原值失败: %w", err)
+		}
+		raw = string(data)
+	}
+	if err := ds.config.store.AppConfig.Set(devToolBackupKey, raw); err != nil {
+		return fmt.Errorf("开发工具代理: 保存原值失败: %w", err)
+	}
+	return nil
+}
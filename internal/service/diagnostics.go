@@ -39,6 +39,8 @@ type DiagnosticsService struct {
 	stoppedCh chan struct{}
 	running   bool
 
+	power *PowerService // 低功耗模式下加长采样间隔
+
 	pprofMu     sync.Mutex
 	pprofServer *http.Server
 	pprofAddr   string
@@ -373,16 +375,28 @@ func (ds *DiagnosticsService) GetPprofAddr() string {
 }
 
 func (ds *DiagnosticsService) getSampleIntervalSeconds() int {
-	if ds.config == nil {
-		return defaultDiagnosticsSampleSecs
+	secs := defaultDiagnosticsSampleSecs
+	if ds.config != nil {
+		if v := ds.config.GetDiagnosticsSamplingSeconds(); v > 0 {
+			secs = v
+		}
 	}
-	secs := ds.config.GetDiagnosticsSamplingSeconds()
-	if secs <= 0 {
-		return defaultDiagnosticsSampleSecs
+	ds.mu.RLock()
+	lowPower := ds.power.LowPower()
+	ds.mu.RUnlock()
+	if lowPower && secs < lowPowerDiagnosticsSampleSecs {
+		secs = lowPowerDiagnosticsSampleSecs
 	}
 	return secs
 }
 
+// SetPowerService 设置低功耗模式来源：开启时运行时采样间隔不少于 60 秒。
+func (ds *DiagnosticsService) SetPowerService(power *PowerService) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.power = power
+}
+
 func (ds *DiagnosticsService) getDiagnosticsDir() string {
 	if ds.config != nil {
 		if dir := strings.TrimSpace(ds.config.GetDiagnosticsDir()); dir != "" {
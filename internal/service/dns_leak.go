@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+const (
+	// DefaultDNSLeakTestService 默认的 DNS 泄漏测试服务（bash.ws 接口）
+	DefaultDNSLeakTestService = "https://bash.ws"
+	// dnsLeakProbeCount 每组触发解析的随机子域名数，多次解析便于看到全部上游解析器
+	dnsLeakProbeCount = 6
+	// dnsLeakProbeTimeout 单次触发解析的超时；子域名不提供服务，只需解析发生
+	dnsLeakProbeTimeout = 3 * time.Second
+	// dnsLeakAPITimeout 获取测试 ID 与结果的超时
+	dnsLeakAPITimeout = 10 * time.Second
+)
+
+// ValidateDNSLeakTestService 校验 DNS 泄漏测试服务地址（http/https，兼容 bash.ws 接口）。
+func ValidateDNSLeakTestService(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("DNS 泄漏测试: 服务地址须为 http:// 或 https:// 开头的网址")
+	}
+	return nil
+}
+
+// RunDNSLeakTest 测试 DNS 是否泄漏：分别经本地入站（由代理远端解析）与本机系统解析器解析测试服务的随机子域名，
+// 再经代理查询服务记录到的解析器。隧道内出现本机直连使用的解析器即视为泄漏。
+// 需要代理运行；测试服务接口兼容 bash.ws（/id、/dnsleak/test/{id}?json，子域名 {n}.{id}.{host}）。
+func (ds *DiagnosticsService) RunDNSLeakTest(ctx context.Context, proxyPort int) (*model.DNSLeakReport, error) {
+	base := DefaultDNSLeakTestService
+	if ds.config != nil {
+		base = ds.config.GetDNSLeakTestService()
+	}
+	if err := ValidateDNSLeakTestService(base); err != nil {
+		return nil, err
+	}
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	u, _ := url.Parse(base)
+	host := u.Hostname()
+	client := localInboundClient(ds.config, proxyPort, dnsLeakAPITimeout)
+	report := &model.DNSLeakReport{Timestamp: time.Now(), Service: base}
+
+	// 隧道内：经 HTTP 代理请求子域名，由代理远端按节点出口解析
+	tunnelID, err := dnsLeakTestID(ctx, client, base)
+	if err != nil {
+		return nil, err
+	}
+	probeClient := localInboundClient(ds.config, proxyPort, dnsLeakProbeTimeout)
+	dnsLeakProbe(func(name string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+name+"/", nil)
+		if err != nil {
+			return
+		}
+		if resp, err := probeClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}, tunnelID, host)
+
+	// 直连：本机系统解析器解析，结果即只走 SOCKS、由本机解析域名的应用会暴露的解析器
+	directID, err := dnsLeakTestID(ctx, client, base)
+	if err != nil {
+		return nil, err
+	}
+	dnsLeakProbe(func(name string) {
+		lookupCtx, cancel := context.WithTimeout(ctx, dnsLeakProbeTimeout)
+		defer cancel()
+		_, _ = net.DefaultResolver.LookupHost(lookupCtx, name)
+	}, directID, host)
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	tunnel, err := dnsLeakResults(ctx, client, base, tunnelID)
+	if err != nil {
+		return nil, err
+	}
+	direct, err := dnsLeakResults(ctx, client, base, directID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range tunnel {
+		switch r.kind {
+		case "ip":
+			report.ExitIP = r.IP
+		case "dns":
+			report.TunnelResolvers = append(report.TunnelResolvers, r.DNSResolver)
+		}
+	}
+	for _, r := range direct {
+		if r.kind == "dns" {
+			report.DirectResolvers = append(report.DirectResolvers, r.DNSResolver)
+		}
+	}
+	if len(report.TunnelResolvers) == 0 {
+		return nil, fmt.Errorf("DNS 泄漏测试: 测试服务未记录到经代理的解析，请检查节点是否可用")
+	}
+	directIPs := make(map[string]bool, len(report.DirectResolvers))
+	for _, r := range report.DirectResolvers {
+		directIPs[r.IP] = true
+	}
+	for _, r := range report.TunnelResolvers {
+		if directIPs[r.IP] {
+			report.LeakedResolvers = append(report.LeakedResolvers, r)
+		}
+	}
+	return report, nil
+}
+
+// dnsLeakProbe 并发触发 dnsLeakProbeCount 次子域名解析。
+func dnsLeakProbe(resolve func(name string), id, host string) {
+	var wg sync.WaitGroup
+	for i := 1; i <= dnsLeakProbeCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resolve(fmt.Sprintf("%d.%s.%s", i, id, host))
+		}(i)
+	}
+	wg.Wait()
+}
+
+// dnsLeakTestID 向测试服务申请一个测试 ID。
+func dnsLeakTestID(ctx context.Context, client *http.Client, base string) (string, error) {
+	body, err := dnsLeakGet(ctx, client, base+"/id")
+	if err != nil {
+		return "", err
+	}
+	id := strings.TrimSpace(string(body))
+	if id == "" || strings.ContainsAny(id, "./ \n") {
+		return "", fmt.Errorf("DNS 泄漏测试: 测试服务返回的 ID 无效")
+	}
+	return id, nil
+}
+
+// dnsLeakResult 测试服务返回的一条记录；type 为 ip（访问者出口）、dns（解析器）或 conclusion。
+type dnsLeakResult struct {
+	model.DNSResolver
+	kind string
+}
+
+// dnsLeakResults 查询测试 ID 记录到的出口 IP 与解析器。
+func dnsLeakResults(ctx context.Context, client *http.Client, base, id string) ([]dnsLeakResult, error) {
+	body, err := dnsLeakGet(ctx, client, base+"/dnsleak/test/"+url.PathEscape(id)+"?json")
+	if err != nil {
+		return nil, err
+	}
+	var raw []struct {
+		IP          string `json:"ip"`
+		CountryName string `json:"country_name"`
+		ASN         string `json:"asn"`
+		Type        string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("DNS 泄漏测试: 解析测试结果失败: %w", err)
+	}
+	results := make([]dnsLeakResult, 0, len(raw))
+	for _, r := range raw {
+		results = append(results, dnsLeakResult{
+			DNSResolver: model.DNSResolver{IP: r.IP, Country: r.CountryName, ASN: r.ASN},
+			kind:        r.Type,
+		})
+	}
+	return results, nil
+}
+
+// dnsLeakGet 经代理请求测试服务接口。
+func dnsLeakGet(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("DNS 泄漏测试: 创建请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("DNS 泄漏测试: 访问测试服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS 泄漏测试: 测试服务返回 HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("DNS 泄漏测试: 读取响应失败: %w", err)
+	}
+	return body, nil
+}
+
+// FormatDNSLeakReport 将 DNS 泄漏测试结果格式化为便于复制分享的纯文本。
+func FormatDNSLeakReport(report model.DNSLeakReport) string {
+	var b strings.Builder
+	b.WriteString("myproxy DNS 泄漏测试\n")
+	fmt.Fprintf(&b, "时间: %s\n", report.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "系统: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "测试服务: %s\n", report.Service)
+	if report.ExitIP != "" {
+		fmt.Fprintf(&b, "代理出口 IP: %s\n", report.ExitIP)
+	}
+	if report.Leaked() {
+		b.WriteString("结果: 未通过 — 经代理访问的域名仍由本机直连使用的解析器解析\n")
+	} else {
+		b.WriteString("结果: 通过 — 经代理访问的域名未使用本机直连的解析器\n")
+	}
+	writeResolvers := func(title string, resolvers []model.DNSResolver) {
+		fmt.Fprintf(&b, "\n%s（%d 个）:\n", title, len(resolvers))
+		for _, r := range resolvers {
+			b.WriteString("  " + r.IP)
+			if detail := strings.TrimSpace(strings.Join([]string{r.Country, r.ASN}, " ")); detail != "" {
+				b.WriteString("  " + detail)
+			}
+			b.WriteString("\n")
+		}
+	}
+	writeResolvers("经代理的解析器", report.TunnelResolvers)
+	writeResolvers("本机直连的解析器", report.DirectResolvers)
+	if len(report.LeakedResolvers) > 0 {
+		writeResolvers("泄漏的解析器", report.LeakedResolvers)
+	}
+	b.WriteString("\n提示: 只设置 SOCKS 代理时，应用若在本机解析域名（socks5 而非 socks5h），其 DNS 查询会经上方「本机直连的解析器」发出。\n")
+	return b.String()
+}
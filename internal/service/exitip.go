@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/database"
+)
+
+// exitIPLookupURL 返回纯文本客户端 IP 的查询地址
+const exitIPLookupURL = "https://api.ipify.org"
+
+// exitIPLookupTimeout 经代理查询出口 IP 的超时
+const exitIPLookupTimeout = 10 * time.Second
+
+// LookupExitIP 经本地混合入站（proxyPort）访问 IP 查询服务，返回代理出口 IP。
+func (ps *ProxyService) LookupExitIP(ctx context.Context, proxyPort int) (string, error) {
+	if proxyPort <= 0 {
+		return "", fmt.Errorf("代理服务: 代理未运行")
+	}
+	client := localInboundClient(ps.configService, proxyPort, exitIPLookupTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, exitIPLookupURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("代理服务: 创建出口 IP 查询请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("代理服务: 查询出口 IP 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("代理服务: 查询出口 IP 失败: HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("代理服务: 读取出口 IP 失败: %w", err)
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("代理服务: 出口 IP 查询返回无效内容")
+	}
+	return ip, nil
+}
+
+// localInboundClient 返回经本地混合入站（proxyPort）访问外网的 HTTP 客户端；入站开启认证时带上账号。
+func localInboundClient(config *ConfigService, proxyPort int, timeout time.Duration) *http.Client {
+	proxyURL := &url.URL{Scheme: "http", Host: net.JoinHostPort(database.LocalMixedInboundListenHost, strconv.Itoa(proxyPort))}
+	if config != nil {
+		if user, pass := config.InboundAuth(); user != "" && pass != "" {
+			proxyURL.User = url.UserPassword(user, pass)
+		}
+	}
+	return &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL), DisableKeepAlives: true},
+		Timeout:   timeout,
+	}
+}
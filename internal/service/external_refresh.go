@@ -0,0 +1,119 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/store"
+)
+
+// externalRefreshIdleCheck 自动刷新关闭时检查设置是否重新开启的间隔
+const externalRefreshIdleCheck = 30 * time.Second
+
+// ExternalRefreshService 检测其他进程（CLI、API 服务等）对数据库的修改并刷新 Store 中的节点与订阅，
+// 使打开中的界面列表保持最新。通过 SQLite 的 data_version 判断数据库是否有新的提交，
+// 有提交时才读取节点与订阅比对内容，本进程自身的写入不会引起列表重绘。
+type ExternalRefreshService struct {
+	store  *store.Store
+	config *ConfigService
+	power  *PowerService
+
+	mu        sync.Mutex
+	stopCh    chan struct{}
+	onRefresh func(nodes, subscriptions bool)
+}
+
+// NewExternalRefreshService 创建外部修改检测服务。
+func NewExternalRefreshService(store *store.Store, config *ConfigService, power *PowerService) *ExternalRefreshService {
+	return &ExternalRefreshService{store: store, config: config, power: power}
+}
+
+// SetOnRefresh 设置检测到外部修改并刷新后的回调，参数表示节点、订阅列表是否有变化。
+func (es *ExternalRefreshService) SetOnRefresh(fn func(nodes, subscriptions bool)) {
+	es.mu.Lock()
+	es.onRefresh = fn
+	es.mu.Unlock()
+}
+
+// Start 开始检测。重复调用时忽略。
+func (es *ExternalRefreshService) Start() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.stopCh != nil {
+		return
+	}
+	es.stopCh = make(chan struct{})
+	go es.run(es.stopCh)
+}
+
+// Stop 停止检测。
+func (es *ExternalRefreshService) Stop() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.stopCh != nil {
+		close(es.stopCh)
+		es.stopCh = nil
+	}
+}
+
+func (es *ExternalRefreshService) run(stopCh chan struct{}) {
+	var watcher *database.ChangeWatcher
+	defer func() {
+		if watcher != nil {
+			_ = watcher.Close()
+		}
+	}()
+	for {
+		interval := es.interval()
+		if interval > 0 {
+			if watcher == nil {
+				watcher, _ = database.NewChangeWatcher() // 失败时下一轮重试
+			} else {
+				es.check(watcher)
+			}
+		} else {
+			// 关闭期间释放专用连接，重新开启时以当时的版本为基准
+			if watcher != nil {
+				_ = watcher.Close()
+				watcher = nil
+			}
+			interval = externalRefreshIdleCheck
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// interval 当前的检查间隔，低功耗模式下加倍；关闭时返回 0。
+func (es *ExternalRefreshService) interval() time.Duration {
+	if es.config == nil {
+		return 0
+	}
+	interval := es.config.GetExternalRefreshInterval()
+	if interval > 0 && es.power.LowPower() {
+		interval *= 2
+	}
+	return interval
+}
+
+// check 数据库有新的提交时比对并刷新节点与订阅列表。
+func (es *ExternalRefreshService) check(watcher *database.ChangeWatcher) {
+	changed, err := watcher.Changed()
+	if err != nil || !changed || es.store == nil {
+		return
+	}
+	nodes, subs, err := es.store.ReloadIfChanged()
+	if err != nil || (!nodes && !subs) {
+		return
+	}
+	es.mu.Lock()
+	fn := es.onRefresh
+	es.mu.Unlock()
+	if fn != nil {
+		fn(nodes, subs)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+// fragmentTrialTimeout 单个分片组合经临时实例访问探测地址的超时
+const fragmentTrialTimeout = 8 * time.Second
+
+// FragmentTrial 分片助手的一次试验结果；Options 为 nil 表示不分片的基线。
+type FragmentTrial struct {
+	Options *xray.FragmentOptions
+	Latency time.Duration
+	Err     error
+}
+
+// TuneFragment 先不分片、再依次以 xray.FragmentCandidates 的组合，经选中节点访问探测地址，
+// 返回延迟最低的可用组合（全部失败时为 nil）。每完成一次试验回调 onTrial。
+// 每次试验使用独立端口的临时 xray 实例；xray-core 的日志处理器为进程级全局，
+// 试验期间正在运行的代理日志会被临时实例接管，结束后调用方应重启代理以恢复日志。
+func (xcs *XrayControlService) TuneFragment(ctx context.Context, onTrial func(FragmentTrial)) (*FragmentTrial, error) {
+	if xcs.store == nil || xcs.store.Nodes == nil {
+		return nil, fmt.Errorf("Xray控制服务: Store 未初始化")
+	}
+	node := xcs.store.Nodes.GetSelected()
+	if node == nil {
+		return nil, fmt.Errorf("Xray控制服务: 未选中节点")
+	}
+	probeURL := xray.DefaultObservatoryProbeURL
+	if xcs.config != nil {
+		probeURL = xcs.config.ProbeURLForNode(node.ID)
+	}
+
+	candidates := []*xray.FragmentOptions{nil}
+	for _, opts := range xray.FragmentCandidates() {
+		opts := opts
+		candidates = append(candidates, &opts)
+	}
+
+	var best *FragmentTrial
+	for _, opts := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		trial := FragmentTrial{Options: opts}
+		trial.Latency, trial.Err = xcs.runFragmentTrial(ctx, node, opts, probeURL)
+		if onTrial != nil {
+			onTrial(trial)
+		}
+		if trial.Err == nil && (best == nil || trial.Latency < best.Latency) {
+			t := trial
+			best = &t
+		}
+	}
+	if xcs.logCallback != nil {
+		if best != nil {
+			xcs.logCallback("INFO", fmt.Sprintf("分片助手: 推荐 %s（%dms）", best.Options.String(), best.Latency.Milliseconds()))
+		} else {
+			xcs.logCallback("WARN", "分片助手: 所有组合均无法经选中节点访问 "+probeURL)
+		}
+	}
+	return best, nil
+}
+
+// runFragmentTrial 以给定分片参数启动临时实例，经其 HTTP 入站访问探测地址并返回耗时。
+func (xcs *XrayControlService) runFragmentTrial(ctx context.Context, node *model.Node, opts *xray.FragmentOptions, probeURL string) (time.Duration, error) {
+	port, err := findFreeLocalPort()
+	if err != nil {
+		return 0, fmt.Errorf("分配临时端口失败: %w", err)
+	}
+	var routing *xray.RoutingOptions
+	if opts != nil {
+		routing = &xray.RoutingOptions{Fragment: opts}
+	}
+	instance, client, err := startTempInstance(node, routing, port, fragmentTrialTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer instance.Stop()
+
+	return timeProbeRequest(ctx, client, probeURL)
+}
+
+// startTempInstance 以节点启动监听 127.0.0.1:port 的临时实例，返回实例与经其 HTTP 入站访问的客户端。
+func startTempInstance(node *model.Node, routing *xray.RoutingOptions, port int, timeout time.Duration) (*xray.XrayInstance, *http.Client, error) {
+	configJSON, err := xray.CreateXrayConfig(port, "127.0.0.1", node, "", routing, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	instance, err := xray.NewXrayInstanceFromJSON(configJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := instance.Start(); err != nil {
+		return nil, nil, err
+	}
+	if err := xray.WaitInboundReady(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), xrayReadyTimeout); err != nil {
+		_ = instance.Stop()
+		return nil, nil, err
+	}
+	proxyURL := &url.URL{Scheme: "http", Host: net.JoinHostPort("127.0.0.1", strconv.Itoa(port))}
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL), DisableKeepAlives: true},
+		Timeout:   timeout,
+	}
+	return instance, client, nil
+}
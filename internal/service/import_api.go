@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ImportAPIService 提供本机 HTTP 导入接口，供浏览器扩展或「一键导入」链接添加订阅/节点。
+// 接口仅允许监听本机地址，且每个请求都必须携带令牌（请求头 X-MyProxy-Token 或查询参数 token）。
+//
+//	GET/POST /import?url=<订阅地址或分享链接>&label=<可选名称>&token=<令牌>
+//	GET /logs?token=<令牌>&level=<可选最低级别>（WebSocket，实时推送结构化日志，见 log_stream.go）
+//	GET /metrics?token=<令牌>（Prometheus 指标，需另行开启，见 metrics.go）
+type ImportAPIService struct {
+	config        *ConfigService
+	subscriptions *SubscriptionService
+
+	mu         sync.Mutex
+	server     *http.Server
+	addr       string
+	onImported func(message string)
+	logSource  LogSubscribeFunc
+	metrics    *MetricsService
+	logStreams map[*websocket.Conn]struct{} // 已建立的 /logs 推送，停止接口或重置令牌时主动断开
+}
+
+// importAPIResponse 导入接口的 JSON 响应。
+type importAPIResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+// NewImportAPIService 创建导入接口服务。
+func NewImportAPIService(config *ConfigService, subscriptions *SubscriptionService) *ImportAPIService {
+	return &ImportAPIService{
+		config:        config,
+		subscriptions: subscriptions,
+	}
+}
+
+// SetOnImported 设置导入成功回调（在 HTTP 协程中调用，UI 更新需自行切回主线程）。
+func (s *ImportAPIService) SetOnImported(callback func(message string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onImported = callback
+}
+
+// ApplyConfig 根据当前配置启停导入接口。
+func (s *ImportAPIService) ApplyConfig() error {
+	if s.config == nil || !s.config.GetImportAPIEnabled() {
+		s.Stop()
+		return nil
+	}
+
+	addr := s.config.GetImportAPIAddr()
+	if !isLoopbackListenAddr(addr) {
+		return fmt.Errorf("导入接口: 地址仅允许监听 localhost 或 127.0.0.1")
+	}
+
+	s.mu.Lock()
+	if s.server != nil && s.addr == addr {
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	s.Stop()
+
+	// 先同步监听，端口被占用时能直接返回错误
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("导入接口: 监听 %s 失败: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/import", s.handleImport)
+	mux.HandleFunc("/logs", s.handleLogs)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	// 被劫持的 WebSocket 连接不受 Shutdown 管理，停止时通过取消请求上下文结束日志推送
+	baseCtx, cancel := context.WithCancel(context.Background())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+	server.RegisterOnShutdown(cancel)
+
+	s.mu.Lock()
+	s.server = server
+	s.addr = addr
+	s.mu.Unlock()
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			s.mu.Lock()
+			if s.server == server {
+				s.server = nil
+				s.addr = ""
+			}
+			s.mu.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+// Stop 停止导入接口。
+func (s *ImportAPIService) Stop() {
+	s.mu.Lock()
+	server := s.server
+	s.server = nil
+	s.addr = ""
+	s.mu.Unlock()
+
+	// Shutdown 不管理已升级的 WebSocket 连接，单独断开
+	s.closeLogStreams()
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+}
+
+// ResetToken 重新生成令牌并断开已建立的 /logs 推送：这些连接由旧令牌授权，令牌失效后不应继续接收日志。
+func (s *ImportAPIService) ResetToken() (string, error) {
+	if s.config == nil {
+		return "", fmt.Errorf("导入接口: 配置服务未初始化")
+	}
+	token, err := s.config.ResetImportAPIToken()
+	if err != nil {
+		return "", err
+	}
+	s.closeLogStreams()
+	return token, nil
+}
+
+// IsRunning 返回导入接口是否正在监听。
+func (s *ImportAPIService) IsRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.server != nil
+}
+
+// ImportURL 返回带令牌的导入地址模板（url 参数留空），便于复制给浏览器扩展。
+func (s *ImportAPIService) ImportURL() string {
+	if s.config == nil {
+		return ""
+	}
+	return fmt.Sprintf("http://%s/import?token=%s&url=", s.config.GetImportAPIAddr(), s.config.GetImportAPIToken())
+}
+
+// authorize 处理 CORS 预检、校验请求方法与令牌；返回 false 时已写入响应。
+func (s *ImportAPIService) authorize(w http.ResponseWriter, r *http.Request) bool {
+	// 浏览器扩展跨域调用：令牌校验保证安全，这里放开 CORS
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "X-MyProxy-Token, Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeImportAPIResponse(w, http.StatusMethodNotAllowed, false, "仅支持 GET/POST")
+		return false
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeImportAPIResponse(w, http.StatusBadRequest, false, "请求参数无效")
+		return false
+	}
+
+	token := r.Header.Get("X-MyProxy-Token")
+	if token == "" {
+		token = r.Form.Get("token")
+	}
+	expected := ""
+	if s.config != nil {
+		expected = s.config.GetImportAPIToken()
+	}
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		writeImportAPIResponse(w, http.StatusUnauthorized, false, "令牌无效")
+		return false
+	}
+	return true
+}
+
+func (s *ImportAPIService) handleImport(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r) {
+		return
+	}
+
+	target := strings.TrimSpace(r.Form.Get("url"))
+	label := strings.TrimSpace(r.Form.Get("label"))
+	if target == "" {
+		writeImportAPIResponse(w, http.StatusBadRequest, false, "缺少 url 参数")
+		return
+	}
+
+	message, err := s.importTarget(target, label)
+	if err != nil {
+		writeImportAPIResponse(w, http.StatusUnprocessableEntity, false, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	callback := s.onImported
+	s.mu.Unlock()
+	if callback != nil {
+		callback(message)
+	}
+	writeImportAPIResponse(w, http.StatusOK, true, message)
+}
+
+// importTarget 按 url 类型导入：http(s) 视为订阅地址，其余视为单节点分享链接。
+func (s *ImportAPIService) importTarget(target, label string) (string, error) {
+	if s.subscriptions == nil {
+		return "", fmt.Errorf("导入接口: 订阅服务未初始化")
+	}
+
+	lower := strings.ToLower(target)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		report, err := s.subscriptions.Fetch(target, label)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("已导入订阅: %s", report.Summary()), nil
+	}
+
+	node, err := s.subscriptions.ImportShareLink(target)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("已导入节点: %s", node.Name), nil
+}
+
+func writeImportAPIResponse(w http.ResponseWriter, status int, ok bool, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(importAPIResponse{OK: ok, Message: message})
+}
+
+// isLoopbackListenAddr 判断监听地址是否为本机回环地址。
+func isLoopbackListenAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// ipInfoLookupTimeout 单个 IP 反向解析的超时
+	ipInfoLookupTimeout = 3 * time.Second
+	// ipInfoLookupWorkers 并发反向解析的数量
+	ipInfoLookupWorkers = 8
+	// ipInfoCacheMax 缓存的 IP 数上限，超过后整体清空
+	ipInfoCacheMax = 2048
+)
+
+// IPInfo 访问记录中纯 IP 目标的补充信息。
+type IPInfo struct {
+	Hostname string // 反向解析（PTR）得到的主机名，没有记录时为空
+	Country  string // geoip.dat 中的国家或地区代码，如 US；局域网地址为 PRIVATE；缺少数据文件时为空
+}
+
+// ipInfoCache 已查询过的 IP 信息，跨页面刷新复用，避免每次打开访问记录都重新解析
+type ipInfoCache struct {
+	mu      sync.Mutex
+	entries map[string]IPInfo
+}
+
+// LookupIPInfo 查询 IP 的反向解析主机名与 GeoIP 归属地，返回 IP -> 信息；已查询过的 IP 直接取缓存。
+// 反向解析经系统 DNS 进行（不经过代理）；GeoIP 使用 xray 资源目录中的 geoip.dat，文件缺失时只返回主机名。
+func (ars *AccessRecordService) LookupIPInfo(ctx context.Context, ips []string) map[string]IPInfo {
+	result := make(map[string]IPInfo, len(ips))
+	ars.ipInfo.mu.Lock()
+	var pending []string
+	for _, ip := range ips {
+		if info, ok := ars.ipInfo.entries[ip]; ok {
+			result[ip] = info
+		} else if _, dup := result[ip]; !dup {
+			result[ip] = IPInfo{}
+			pending = append(pending, ip)
+		}
+	}
+	ars.ipInfo.mu.Unlock()
+	if len(pending) == 0 {
+		return result
+	}
+
+	countries, _ := xray.LookupGeoIP(pending)
+	hostnames := lookupHostnames(ctx, pending)
+	if ctx.Err() != nil {
+		return result // 已取消：不缓存不完整的结果
+	}
+
+	ars.ipInfo.mu.Lock()
+	defer ars.ipInfo.mu.Unlock()
+	if ars.ipInfo.entries == nil || len(ars.ipInfo.entries)+len(pending) > ipInfoCacheMax {
+		ars.ipInfo.entries = make(map[string]IPInfo)
+	}
+	for _, ip := range pending {
+		info := IPInfo{Hostname: hostnames[ip], Country: countries[ip]}
+		ars.ipInfo.entries[ip] = info
+		result[ip] = info
+	}
+	return result
+}
+
+// lookupHostnames 并发反向解析 IP，返回 IP -> 主机名（去掉末尾的点）；解析失败的 IP 不在结果中。
+func lookupHostnames(ctx context.Context, ips []string) map[string]string {
+	var mu sync.Mutex
+	names := make(map[string]string, len(ips))
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < ipInfoLookupWorkers && i < len(ips); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				lookupCtx, cancel := context.WithTimeout(ctx, ipInfoLookupTimeout)
+				hosts, err := net.DefaultResolver.LookupAddr(lookupCtx, strings.Trim(ip, "[]"))
+				cancel()
+				if err != nil || len(hosts) == 0 {
+					continue
+				}
+				mu.Lock()
+				names[ip] = strings.TrimSuffix(hosts[0], ".")
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, ip := range ips {
+		select {
+		case jobs <- ip:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return names
+}
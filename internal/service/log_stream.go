@@ -0,0 +1,155 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"myproxy.com/p/internal/logging"
+)
+
+const (
+	// logStreamBuffer 每个连接待发送的日志事件上限，客户端过慢时丢弃新事件
+	logStreamBuffer = 256
+	// logStreamWriteTimeout 单条消息的写超时
+	logStreamWriteTimeout = 5 * time.Second
+	// logStreamPingInterval 心跳间隔，用于发现已断开的客户端
+	logStreamPingInterval = 30 * time.Second
+)
+
+// LogSubscribeFunc 订阅结构化日志事件，返回取消函数（与 logging.Logger.Subscribe 一致）。
+type LogSubscribeFunc func(fn func(logging.LogEvent)) (unsubscribe func())
+
+// logStreamLevels 日志级别排序，用于 level 参数过滤
+var logStreamLevels = map[string]int{"DEBUG": 0, "INFO": 1, "WARN": 2, "WARNING": 2, "ERROR": 3, "FATAL": 4}
+
+var logStreamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// 外部面板通常运行在其他源下；连接必须携带令牌，这里不再校验 Origin。
+	// 令牌重置或接口停止时已建立的连接会被断开（见 closeLogStreams）
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// SetLogSource 设置 /logs 接口的日志来源。
+func (s *ImportAPIService) SetLogSource(subscribe LogSubscribeFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logSource = subscribe
+}
+
+// trackLogStream 登记已建立的推送连接。
+func (s *ImportAPIService) trackLogStream(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.logStreams == nil {
+		s.logStreams = make(map[*websocket.Conn]struct{})
+	}
+	s.logStreams[conn] = struct{}{}
+}
+
+func (s *ImportAPIService) untrackLogStream(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.logStreams, conn)
+}
+
+// closeLogStreams 通知并断开所有推送连接；推送协程随读协程出错而退出。
+func (s *ImportAPIService) closeLogStreams() {
+	s.mu.Lock()
+	conns := make([]*websocket.Conn, 0, len(s.logStreams))
+	for conn := range s.logStreams {
+		conns = append(conns, conn)
+	}
+	s.logStreams = nil
+	s.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+		_ = conn.Close()
+	}
+}
+
+// handleLogs 升级为 WebSocket，逐条推送 JSON 日志事件：{"level","source","message","time"}。
+// 可选参数 level 仅推送不低于该级别的事件。浏览器无法为 WebSocket 设置请求头，令牌可放在查询参数中。
+func (s *ImportAPIService) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodOptions {
+		writeImportAPIResponse(w, http.StatusMethodNotAllowed, false, "仅支持 GET")
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+	minLevel := 0
+	if level := strings.ToUpper(strings.TrimSpace(r.Form.Get("level"))); level != "" {
+		v, ok := logStreamLevels[level]
+		if !ok {
+			writeImportAPIResponse(w, http.StatusBadRequest, false, "level 参数无效")
+			return
+		}
+		minLevel = v
+	}
+
+	s.mu.Lock()
+	subscribe := s.logSource
+	s.mu.Unlock()
+	if subscribe == nil {
+		writeImportAPIResponse(w, http.StatusServiceUnavailable, false, "日志未就绪")
+		return
+	}
+
+	conn, err := logStreamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade 已写入错误响应
+	}
+	defer conn.Close()
+	s.trackLogStream(conn)
+	defer s.untrackLogStream(conn)
+
+	events := make(chan logging.LogEvent, logStreamBuffer)
+	unsubscribe := subscribe(func(event logging.LogEvent) {
+		if logStreamLevels[strings.ToUpper(event.Level)] < minLevel {
+			return
+		}
+		select {
+		case events <- event:
+		default: // 客户端跟不上时丢弃，不能阻塞写日志
+		}
+	})
+	defer unsubscribe()
+
+	// 读协程：处理 close/pong 控制帧，客户端断开时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(logStreamPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case event := <-events:
+			_ = conn.SetWriteDeadline(time.Now().Add(logStreamWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(logStreamWriteTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			// 接口停止：通知客户端后关闭
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+			return
+		}
+	}
+}
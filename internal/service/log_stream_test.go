@@ -0,0 +1,86 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/subscription"
+)
+
+// startLogStream 启动导入接口并建立一条 /logs 推送连接。
+func startLogStream(t *testing.T) (*ImportAPIService, *websocket.Conn) {
+	t.Helper()
+	if err := database.InitDB(filepath.Join(t.TempDir(), "myproxy.db")); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	config := NewConfigService(store.NewStore(subscription.NewSubscriptionManager()))
+	if err := config.Set("importApiAddr", addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetImportAPIEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+
+	api := NewImportAPIService(config, nil)
+	api.SetLogSource(func(fn func(logging.LogEvent)) func() { return func() {} })
+	if err := api.ApplyConfig(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(api.Stop)
+
+	header := http.Header{}
+	header.Set("X-MyProxy-Token", config.GetImportAPIToken())
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/logs", header)
+	if err != nil {
+		t.Fatalf("连接 /logs 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return api, conn
+}
+
+// expectStreamClosed 断言推送连接在超时内被服务端断开。
+func expectStreamClosed(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("推送连接未被断开")
+			}
+			return
+		}
+	}
+}
+
+func TestLogStreamEndsOnStop(t *testing.T) {
+	api, conn := startLogStream(t)
+	api.Stop()
+	expectStreamClosed(t, conn)
+}
+
+func TestLogStreamEndsOnTokenReset(t *testing.T) {
+	api, conn := startLogStream(t)
+	if _, err := api.ResetToken(); err != nil {
+		t.Fatal(err)
+	}
+	expectStreamClosed(t, conn)
+	if !api.IsRunning() {
+		t.Fatal("重置令牌不应停止导入接口")
+	}
+}
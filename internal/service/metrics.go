@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/store"
+)
+
+// MetricsService 以 Prometheus 文本格式导出运行指标：代理状态、连接数、流量、节点延迟与订阅更新时间，
+// 由本地接口的 /metrics 提供，便于 Grafana 等工具绘图。
+type MetricsService struct {
+	store         *store.Store
+	traffic       *TrafficService
+	accessRecords *AccessRecordService
+}
+
+// NewMetricsService 创建指标服务；traffic、accessRecords 为 nil 时跳过对应指标。
+func NewMetricsService(store *store.Store, traffic *TrafficService, accessRecords *AccessRecordService) *MetricsService {
+	return &MetricsService{store: store, traffic: traffic, accessRecords: accessRecords}
+}
+
+// metricsWriter 按 Prometheus 文本格式输出，每个指标名只写一次 HELP/TYPE。
+type metricsWriter struct {
+	w       *bufio.Writer
+	current string
+}
+
+func (mw *metricsWriter) metric(name, kind, help string, value float64, labels ...string) {
+	if name != mw.current {
+		fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		mw.current = name
+	}
+	mw.w.WriteString(name)
+	if len(labels) > 0 {
+		mw.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				mw.w.WriteByte(',')
+			}
+			fmt.Fprintf(mw.w, "%s=\"%s\"", labels[i], escapeMetricLabel(labels[i+1]))
+		}
+		mw.w.WriteByte('}')
+	}
+	mw.w.WriteByte(' ')
+	mw.w.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	mw.w.WriteByte('\n')
+}
+
+// escapeMetricLabel 转义标签值中的反斜杠、双引号与换行。
+func escapeMetricLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// WriteMetrics 输出当前全部指标。
+func (ms *MetricsService) WriteMetrics(w io.Writer) error {
+	mw := &metricsWriter{w: bufio.NewWriter(w)}
+	now := time.Now()
+
+	if ms.store != nil && ms.store.ProxyStatus != nil {
+		startedAt := ms.store.ProxyStatus.SessionStartedAt()
+		mw.metric("myproxy_proxy_up", "gauge", "Whether the proxy is running (1) or stopped (0).", boolMetric(!startedAt.IsZero()))
+		uptime := 0.0
+		if !startedAt.IsZero() {
+			uptime = now.Sub(startedAt).Seconds()
+		}
+		mw.metric("myproxy_proxy_uptime_seconds", "gauge", "Seconds since the current proxy session started.", uptime)
+	}
+
+	if ms.accessRecords != nil {
+		mw.metric("myproxy_connections_total", "counter", "Connections accepted by the proxy since the app started.", float64(ms.accessRecords.ConnectionCount()))
+	}
+
+	if ms.traffic != nil {
+		up, down := ms.traffic.Totals()
+		mw.metric("myproxy_traffic_bytes_total", "counter", "Bytes transferred through the proxy, including previous runs.", float64(up), "direction", "upload")
+		mw.metric("myproxy_traffic_bytes_total", "counter", "", float64(down), "direction", "download")
+		var upSpeed, downSpeed int64
+		if history := ms.traffic.History(); len(history) > 0 {
+			last := history[len(history)-1]
+			upSpeed, downSpeed = last.Upload, last.Download
+		}
+		mw.metric("myproxy_traffic_bytes_per_second", "gauge", "Current transfer rate.", float64(upSpeed), "direction", "upload")
+		mw.metric("myproxy_traffic_bytes_per_second", "gauge", "", float64(downSpeed), "direction", "download")
+	}
+
+	if ms.store != nil && ms.store.Nodes != nil {
+		nodes := ms.store.Nodes.GetAll()
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+		enabled := 0
+		for _, node := range nodes {
+			if node.Enabled {
+				enabled++
+			}
+		}
+		mw.metric("myproxy_nodes", "gauge", "Number of nodes by state.", float64(enabled), "state", "enabled")
+		mw.metric("myproxy_nodes", "gauge", "", float64(len(nodes)-enabled), "state", "disabled")
+		for _, node := range nodes {
+			// 未测速或测速失败（<=0）的节点不导出，避免图表出现 0 延迟
+			if node.Delay <= 0 {
+				continue
+			}
+			mw.metric("myproxy_node_latency_milliseconds", "gauge", "Last measured latency of each node.", float64(node.Delay),
+				"id", node.ID, "name", node.Name, "protocol", node.ProtocolType, "selected", strconv.FormatBool(node.Selected))
+		}
+	}
+
+	if ms.store != nil && ms.store.Subscriptions != nil {
+		var subs []*database.Subscription
+		for _, sub := range ms.store.Subscriptions.GetAll() {
+			if !sub.UpdatedAt.IsZero() {
+				subs = append(subs, sub)
+			}
+		}
+		sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+		for _, sub := range subs {
+			mw.metric("myproxy_subscription_last_update_timestamp_seconds", "gauge", "Unix time of the last successful subscription update.",
+				float64(sub.UpdatedAt.Unix()), "id", strconv.FormatInt(sub.ID, 10), "label", sub.Label)
+		}
+		for _, sub := range subs {
+			mw.metric("myproxy_subscription_age_seconds", "gauge", "Seconds since the last successful subscription update.",
+				now.Sub(sub.UpdatedAt).Seconds(), "id", strconv.FormatInt(sub.ID, 10), "label", sub.Label)
+		}
+	}
+
+	return mw.w.Flush()
+}
+
+// handleMetrics 输出 Prometheus 指标；需在设置中开启，否则返回 404。
+// Prometheus 抓取配置可通过 params 传递 token，或在请求头 X-MyProxy-Token 中携带。
+func (s *ImportAPIService) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	metrics := s.metrics
+	s.mu.Unlock()
+	if metrics == nil || s.config == nil || !s.config.GetMetricsEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = metrics.WriteMetrics(w)
+}
+
+// MetricsURL 返回带令牌的 /metrics 地址。
+func (s *ImportAPIService) MetricsURL() string {
+	if s.config == nil {
+		return ""
+	}
+	return fmt.Sprintf("http://%s/metrics?token=%s", s.config.GetImportAPIAddr(), s.config.GetImportAPIToken())
+}
+
+// SetMetrics 设置 /metrics 接口的数据来源。
+func (s *ImportAPIService) SetMetrics(metrics *MetricsService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = metrics
+}
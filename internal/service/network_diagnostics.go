@@ -0,0 +1,371 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+const (
+	// networkCheckTimeout 单项检查（DNS、TCP 连接、ping）的超时
+	networkCheckTimeout = 3 * time.Second
+	// networkTraceTimeout 逐跳探测的总超时
+	networkTraceTimeout = 60 * time.Second
+	// networkTraceMaxHops 逐跳探测的最大跳数
+	networkTraceMaxHops = 20
+)
+
+// networkDNSProbeDomains 直连 DNS 解析探测的域名：国内与国外各一个，便于区分 DNS 污染与网络不通
+var networkDNSProbeDomains = []string{"www.baidu.com", "www.google.com"}
+
+// networkProxyProbeTarget 经代理解析与连接的探测目标
+const networkProxyProbeTarget = "www.google.com:443"
+
+// pingTimePattern 从 ping 输出中提取往返时间，兼容英文与中文 Windows（时间=1ms / 时间<1ms）
+var pingTimePattern = regexp.MustCompile(`(?:time|时间)[=<]\s*([\d.]+)\s*ms`)
+
+// RunNetworkDiagnostics 依次检查默认网关、直连 DNS、经代理解析、节点 TCP 连通与逐跳延迟。
+// 参数：
+//   - ctx: 取消后中止剩余检查
+//   - proxyPort: 本地混合入站端口，proxyRunning 为 false 时跳过经代理的检查
+//   - onCheck: 每完成一项回调一次（在调用方协程中执行），可为 nil
+func (ds *DiagnosticsService) RunNetworkDiagnostics(ctx context.Context, proxyRunning bool, proxyPort int, onCheck func(model.NetworkCheck)) model.NetworkReport {
+	report := model.NetworkReport{Timestamp: time.Now()}
+	add := func(c model.NetworkCheck) {
+		report.Checks = append(report.Checks, c)
+		if onCheck != nil {
+			onCheck(c)
+		}
+	}
+
+	// 1. 默认网关
+	gateway, err := detectDefaultGateway()
+	if err != nil {
+		add(model.NetworkCheck{Name: "默认网关", Status: model.NetworkCheckFailed, Detail: err.Error()})
+	} else {
+		latency, err := pingHost(ctx, gateway)
+		if err != nil {
+			add(model.NetworkCheck{Name: "默认网关", Status: model.NetworkCheckFailed, Detail: fmt.Sprintf("%s 不可达: %v", gateway, err)})
+		} else {
+			add(model.NetworkCheck{Name: "默认网关", Status: model.NetworkCheckOK, Detail: gateway, Latency: latency})
+		}
+	}
+
+	// 2. 直连 DNS
+	for _, domain := range networkDNSProbeDomains {
+		if ctx.Err() != nil {
+			return report
+		}
+		name := "直连 DNS " + domain
+		latency, addrs, err := resolveHost(ctx, domain)
+		if err != nil {
+			add(model.NetworkCheck{Name: name, Status: model.NetworkCheckFailed, Detail: err.Error()})
+			continue
+		}
+		add(model.NetworkCheck{Name: name, Status: model.NetworkCheckOK, Detail: strings.Join(addrs, ", "), Latency: latency})
+	}
+
+	// 3. 经代理解析并连接（由远端解析域名，可判断本地 DNS 是否被污染）
+	if ctx.Err() != nil {
+		return report
+	}
+	if !proxyRunning {
+		add(model.NetworkCheck{Name: "经代理访问 " + networkProxyProbeTarget, Status: model.NetworkCheckSkipped, Detail: "代理未运行"})
+	} else {
+		var user, pass string
+		if ds.config != nil {
+			user, pass = ds.config.InboundAuth()
+		}
+		latency, err := connectViaLocalProxy(ctx, proxyPort, networkProxyProbeTarget, user, pass)
+		if err != nil {
+			add(model.NetworkCheck{Name: "经代理访问 " + networkProxyProbeTarget, Status: model.NetworkCheckFailed, Detail: err.Error()})
+		} else {
+			add(model.NetworkCheck{Name: "经代理访问 " + networkProxyProbeTarget, Status: model.NetworkCheckOK, Detail: "远端解析并建立连接", Latency: latency})
+		}
+	}
+
+	// 4. 选中节点：解析、TCP 连接与逐跳延迟
+	var node *model.Node
+	if ds.store != nil && ds.store.Nodes != nil {
+		node = ds.store.Nodes.GetSelected()
+	}
+	if node == nil {
+		add(model.NetworkCheck{Name: "节点连通性", Status: model.NetworkCheckSkipped, Detail: "未选中节点"})
+		return report
+	}
+	report.NodeName = node.Name
+	report.NodeAddr = net.JoinHostPort(node.Addr, strconv.Itoa(node.Port))
+
+	if net.ParseIP(node.Addr) == nil {
+		latency, addrs, err := resolveHost(ctx, node.Addr)
+		if err != nil {
+			add(model.NetworkCheck{Name: "节点域名解析", Status: model.NetworkCheckFailed, Detail: err.Error()})
+		} else {
+			add(model.NetworkCheck{Name: "节点域名解析", Status: model.NetworkCheckOK, Detail: strings.Join(addrs, ", "), Latency: latency})
+		}
+	}
+
+	if ctx.Err() != nil {
+		return report
+	}
+	start := time.Now()
+	dialer := net.Dialer{Timeout: networkCheckTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", report.NodeAddr)
+	if err != nil {
+		add(model.NetworkCheck{Name: "节点 TCP 连接", Status: model.NetworkCheckFailed, Detail: err.Error()})
+	} else {
+		_ = conn.Close()
+		add(model.NetworkCheck{Name: "节点 TCP 连接", Status: model.NetworkCheckOK, Detail: report.NodeAddr, Latency: time.Since(start)})
+	}
+
+	if ctx.Err() != nil {
+		return report
+	}
+	hops, err := traceRoute(ctx, node.Addr)
+	report.Hops = hops
+	switch {
+	case err != nil:
+		add(model.NetworkCheck{Name: "逐跳延迟", Status: model.NetworkCheckSkipped, Detail: err.Error()})
+	default:
+		add(model.NetworkCheck{Name: "逐跳延迟", Status: model.NetworkCheckOK, Detail: fmt.Sprintf("共 %d 跳", len(hops))})
+	}
+	return report
+}
+
+// FormatNetworkReport 将网络诊断结果格式化为便于复制分享的纯文本。
+func FormatNetworkReport(report model.NetworkReport) string {
+	var b strings.Builder
+	b.WriteString("myproxy 网络诊断报告\n")
+	fmt.Fprintf(&b, "时间: %s\n", report.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "系统: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if report.NodeName != "" {
+		fmt.Fprintf(&b, "节点: %s (%s)\n", report.NodeName, report.NodeAddr)
+	}
+	b.WriteString("\n")
+	for _, c := range report.Checks {
+		fmt.Fprintf(&b, "[%s] %s", NetworkCheckStatusText(c.Status), c.Name)
+		if c.Latency > 0 {
+			fmt.Fprintf(&b, " %dms", c.Latency.Milliseconds())
+		}
+		if c.Detail != "" {
+			fmt.Fprintf(&b, " — %s", c.Detail)
+		}
+		b.WriteString("\n")
+	}
+	if len(report.Hops) > 0 {
+		b.WriteString("\n逐跳延迟:\n")
+		for _, hop := range report.Hops {
+			b.WriteString(hop)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// NetworkCheckStatusText 返回检查状态的中文标签。
+func NetworkCheckStatusText(status model.NetworkCheckStatus) string {
+	switch status {
+	case model.NetworkCheckOK:
+		return "正常"
+	case model.NetworkCheckFailed:
+		return "失败"
+	default:
+		return "跳过"
+	}
+}
+
+// ExportNetworkReport 将网络诊断报告写入诊断目录，返回文件路径。
+func (ds *DiagnosticsService) ExportNetworkReport(report model.NetworkReport) (string, error) {
+	if err := os.MkdirAll(ds.getDiagnosticsDir(), 0755); err != nil {
+		return "", fmt.Errorf("创建诊断目录失败: %w", err)
+	}
+	filePath := filepath.Join(ds.getDiagnosticsDir(), "network_"+report.Timestamp.Format("20060102_150405")+".txt")
+	if err := os.WriteFile(filePath, []byte(FormatNetworkReport(report)), 0644); err != nil {
+		return "", fmt.Errorf("写入网络诊断报告失败: %w", err)
+	}
+	ds.recordLastExport(filePath)
+	return filePath, nil
+}
+
+// resolveHost 使用系统解析器解析域名，返回耗时与地址列表。
+func resolveHost(ctx context.Context, host string) (time.Duration, []string, error) {
+	ctx, cancel := context.WithTimeout(ctx, networkCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return 0, nil, fmt.Errorf("解析失败: %w", err)
+	}
+	if len(addrs) > 3 {
+		addrs = addrs[:3]
+	}
+	return time.Since(start), addrs, nil
+}
+
+// connectViaLocalProxy 通过本地混合入站发送 HTTP CONNECT，由远端解析并连接目标，返回建立隧道的耗时。
+func connectViaLocalProxy(ctx context.Context, proxyPort int, target, user, pass string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*networkCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(proxyPort)))
+	if err != nil {
+		return 0, fmt.Errorf("连接本地入站失败: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", target, target)
+	if user != "" && pass != "" {
+		req += "Proxy-Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass)) + "\r\n"
+	}
+	req += "\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return 0, fmt.Errorf("发送请求失败: %w", err)
+	}
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return 0, fmt.Errorf("读取响应失败: %w", err)
+	}
+	fields := strings.Fields(status)
+	if len(fields) < 2 || fields[1] != "200" {
+		return 0, fmt.Errorf("代理返回 %s", strings.TrimSpace(status))
+	}
+	return time.Since(start), nil
+}
+
+// detectDefaultGateway 获取默认网关地址。
+func detectDefaultGateway() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return linuxDefaultGateway()
+	case "darwin":
+		out, err := exec.Command("route", "-n", "get", "default").Output()
+		if err != nil {
+			return "", fmt.Errorf("获取默认网关失败: %w", err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "gateway:") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "gateway:")), nil
+			}
+		}
+	case "windows":
+		// 活动路由行：0.0.0.0  0.0.0.0  <网关>  <接口>  <跃点数>
+		out, err := exec.Command("route", "print", "0.0.0.0").Output()
+		if err != nil {
+			return "", fmt.Errorf("获取默认网关失败: %w", err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 5 && fields[0] == "0.0.0.0" && fields[1] == "0.0.0.0" && net.ParseIP(fields[2]) != nil {
+				return fields[2], nil
+			}
+		}
+	default:
+		return "", fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+	}
+	return "", fmt.Errorf("未找到默认网关")
+}
+
+// linuxDefaultGateway 解析 /proc/net/route 中目标为 00000000 的路由；网关字段为小端序十六进制。
+func linuxDefaultGateway() (string, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return "", fmt.Errorf("读取路由表失败: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		return ip.String(), nil
+	}
+	return "", fmt.Errorf("未找到默认网关")
+}
+
+// pingHost 调用系统 ping 发送一次 ICMP 回显（普通用户无法直接发送 ICMP），返回往返时间。
+func pingHost(ctx context.Context, host string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, networkCheckTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.CommandContext(ctx, "ping", "-n", "1", "-w", "2000", host)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "ping", "-c", "1", "-t", "2", host)
+	default:
+		cmd = exec.CommandContext(ctx, "ping", "-c", "1", "-W", "2", host)
+	}
+	start := time.Now()
+	out, err := cmd.Output()
+	elapsed := time.Since(start)
+	if err != nil {
+		return 0, fmt.Errorf("ping 失败: %w", err)
+	}
+	if m := pingTimePattern.FindStringSubmatch(string(out)); m != nil {
+		if ms, err := strconv.ParseFloat(m[1], 64); err == nil {
+			return time.Duration(ms * float64(time.Millisecond)), nil
+		}
+	}
+	return elapsed, nil
+}
+
+// traceRoute 调用系统 traceroute / tracert 获取到目标的逐跳延迟，返回去掉表头后的输出行。
+func traceRoute(ctx context.Context, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, networkTraceTimeout)
+	defer cancel()
+	maxHops := strconv.Itoa(networkTraceMaxHops)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "tracert", "-d", "-h", maxHops, "-w", "1000", host)
+	} else {
+		if _, err := exec.LookPath("traceroute"); err != nil {
+			return nil, fmt.Errorf("未安装 traceroute")
+		}
+		cmd = exec.CommandContext(ctx, "traceroute", "-n", "-q", "1", "-w", "1", "-m", maxHops, host)
+	}
+	out, err := cmd.Output()
+	var hops []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimRight(line, "\r ")
+		trimmed := strings.TrimSpace(line)
+		// 逐跳行以跳数开头，其余为表头或说明
+		if trimmed == "" || trimmed[0] < '0' || trimmed[0] > '9' {
+			continue
+		}
+		hops = append(hops, line)
+	}
+	if err != nil && len(hops) == 0 {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("逐跳探测超时")
+		}
+		return nil, fmt.Errorf("逐跳探测失败: %w", err)
+	}
+	return hops, nil
+}
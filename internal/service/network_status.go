@@ -0,0 +1,117 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// networkCheckInterval 检测网络连接的间隔
+	networkCheckInterval = 5 * time.Second
+	// lowPowerNetworkCheckInterval 低功耗模式下检测网络连接的间隔
+	lowPowerNetworkCheckInterval = 30 * time.Second
+)
+
+// NetworkStatusService 检测本机是否完全没有网络连接（网线拔出、Wi‑Fi 断开、飞行模式）。
+// 离线时界面显示提示并停用连接与测速，定时测速暂停，避免产生大量失败结果；网络恢复后自动解除。
+// 只检查路由表，不访问网络；未启动或检测不到状态时视为在线。
+type NetworkStatusService struct {
+	power *PowerService
+	probe func() bool // 返回是否在线，默认 utils.HasDefaultRoute
+
+	mu           sync.Mutex
+	offline      bool
+	listeners    map[int]func(online bool)
+	nextListener int
+	stopCh       chan struct{}
+}
+
+// NewNetworkStatusService 创建网络连接检测服务；power 可为 nil。
+func NewNetworkStatusService(power *PowerService) *NetworkStatusService {
+	return &NetworkStatusService{power: power, probe: utils.HasDefaultRoute, listeners: make(map[int]func(bool))}
+}
+
+// Start 立即检测一次并开始定时检测；已在运行时忽略。
+func (ns *NetworkStatusService) Start() {
+	ns.mu.Lock()
+	if ns.stopCh != nil {
+		ns.mu.Unlock()
+		return
+	}
+	stopCh := make(chan struct{})
+	ns.stopCh = stopCh
+	ns.mu.Unlock()
+
+	ns.Refresh()
+	go func() {
+		for {
+			interval := networkCheckInterval
+			if ns.power.LowPower() {
+				interval = lowPowerNetworkCheckInterval
+			}
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(interval):
+				ns.Refresh()
+			}
+		}
+	}()
+}
+
+// Stop 停止检测。
+func (ns *NetworkStatusService) Stop() {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.stopCh != nil {
+		close(ns.stopCh)
+		ns.stopCh = nil
+	}
+}
+
+// Refresh 重新检测网络连接，状态变化时通知监听者。
+func (ns *NetworkStatusService) Refresh() {
+	offline := !ns.probe()
+
+	ns.mu.Lock()
+	if ns.offline == offline {
+		ns.mu.Unlock()
+		return
+	}
+	ns.offline = offline
+	listeners := make([]func(bool), 0, len(ns.listeners))
+	for _, fn := range ns.listeners {
+		listeners = append(listeners, fn)
+	}
+	ns.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(!offline)
+	}
+}
+
+// Online 当前是否有网络连接；服务为 nil 时视为在线。
+func (ns *NetworkStatusService) Online() bool {
+	if ns == nil {
+		return true
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	return !ns.offline
+}
+
+// Subscribe 注册状态变化回调（在检测 goroutine 中调用），返回取消函数。
+func (ns *NetworkStatusService) Subscribe(fn func(online bool)) (unsubscribe func()) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	id := ns.nextListener
+	ns.nextListener++
+	ns.listeners[id] = fn
+	return func() {
+		ns.mu.Lock()
+		defer ns.mu.Unlock()
+		delete(ns.listeners, id)
+	}
+}
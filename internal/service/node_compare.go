@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// compareLatencyRounds 对比模式中每个节点访问探测地址的次数
+	compareLatencyRounds = 5
+	// compareRequestTimeout 单次访问探测地址的超时
+	compareRequestTimeout = 8 * time.Second
+	// compareDownloadDuration 测量吞吐时下载的最长时间，到时按已下载字节计算
+	compareDownloadDuration = 8 * time.Second
+	// compareDownloadURL 测量吞吐使用的下载地址（约 25MB，通常在时限内无法下完）
+	compareDownloadURL = "https://speed.cloudflare.com/__down?bytes=25000000"
+)
+
+// NodeCompareResult 对比模式中单个节点的测量结果。
+type NodeCompareResult struct {
+	Node       *model.Node
+	Latencies  []time.Duration // 成功访问探测地址的耗时
+	Failures   int             // 访问探测地址失败的次数
+	Throughput float64         // 下载速度（字节/秒），测量失败时为 0
+	Err        error           // 临时实例启动失败或下载失败的原因
+}
+
+// MedianLatency 返回成功访问耗时的中位数，没有成功样本时为 0。
+func (r *NodeCompareResult) MedianLatency() time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), r.Latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// CompareNodes 为两个节点各启动一个临时实例，同时经两者访问探测地址并测量下载速度，
+// 让网络状况对两边尽量一致。与 TuneFragment 相同，临时实例会接管进程级日志处理器，
+// 结束后调用方应重启正在运行的代理以恢复日志。
+func (xcs *XrayControlService) CompareNodes(ctx context.Context, a, b *model.Node) ([2]NodeCompareResult, error) {
+	results := [2]NodeCompareResult{{Node: a}, {Node: b}}
+	if a == nil || b == nil {
+		return results, fmt.Errorf("Xray控制服务: 请选择两个节点")
+	}
+	if a.ID == b.ID {
+		return results, fmt.Errorf("Xray控制服务: 请选择两个不同的节点")
+	}
+	// 两个节点所属订阅的测速地址不同时各自使用自己的地址
+	probeURLs := [2]string{xray.DefaultObservatoryProbeURL, xray.DefaultObservatoryProbeURL}
+	if xcs.config != nil {
+		probeURLs = [2]string{xcs.config.ProbeURLForNode(a.ID), xcs.config.ProbeURLForNode(b.ID)}
+	}
+
+	// 先分配两个不同的端口，避免两个实例抢占同一端口
+	var ports [2]int
+	for i := range ports {
+		for attempt := 0; attempt < 5; attempt++ {
+			port, err := findFreeLocalPort()
+			if err != nil {
+				return results, fmt.Errorf("Xray控制服务: 分配临时端口失败: %w", err)
+			}
+			if i == 0 || port != ports[0] {
+				ports[i] = port
+				break
+			}
+		}
+		if ports[i] == 0 {
+			return results, fmt.Errorf("Xray控制服务: 分配临时端口失败")
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			runNodeComparison(ctx, &results[i], ports[i], probeURLs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if xcs.logCallback != nil {
+		for _, r := range results {
+			if r.Err != nil && len(r.Latencies) == 0 {
+				xcs.logCallback("WARN", fmt.Sprintf("节点对比: %s 失败: %v", r.Node.Name, r.Err))
+				continue
+			}
+			xcs.logCallback("INFO", fmt.Sprintf("节点对比: %s 延迟中位数 %dms（失败 %d/%d），下载 %.2f MB/s",
+				r.Node.Name, r.MedianLatency().Milliseconds(), r.Failures, compareLatencyRounds, r.Throughput/1024/1024))
+		}
+	}
+	return results, ctx.Err()
+}
+
+// runNodeComparison 启动单个节点的临时实例，依次测量探测地址延迟与下载速度，结果写入 r。
+func runNodeComparison(ctx context.Context, r *NodeCompareResult, port int, probeURL string) {
+	instance, client, err := startTempInstance(r.Node, nil, port, compareRequestTimeout)
+	if err != nil {
+		r.Err = err
+		return
+	}
+	defer instance.Stop()
+
+	for i := 0; i < compareLatencyRounds && ctx.Err() == nil; i++ {
+		latency, err := timeProbeRequest(ctx, client, probeURL)
+		if err != nil {
+			r.Failures++
+			r.Err = err
+			continue
+		}
+		r.Latencies = append(r.Latencies, latency)
+	}
+	if len(r.Latencies) == 0 || ctx.Err() != nil {
+		return
+	}
+	r.Err = nil
+	r.Throughput, r.Err = measureThroughput(ctx, client, compareDownloadURL)
+}
+
+// timeProbeRequest 访问探测地址并返回完整读取响应的耗时。
+func timeProbeRequest(ctx context.Context, client *http.Client, probeURL string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return 0, fmt.Errorf("探测地址返回 %s", resp.Status)
+	}
+	return time.Since(start), nil
+}
+
+// measureThroughput 在 compareDownloadDuration 内下载 downloadURL，返回平均速度（字节/秒）。
+func measureThroughput(ctx context.Context, client *http.Client, downloadURL string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, compareDownloadDuration)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	// 下载时长由 ctx 控制，不使用客户端的单次请求超时
+	downloader := *client
+	downloader.Timeout = 0
+	start := time.Now()
+	resp, err := downloader.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("测速下载失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("测速下载返回 %s", resp.Status)
+	}
+	n, err := io.Copy(io.Discard, resp.Body)
+	elapsed := time.Since(start)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 0, fmt.Errorf("测速下载中断: %w", err)
+	}
+	if n == 0 || elapsed <= 0 {
+		return 0, fmt.Errorf("测速下载没有收到数据")
+	}
+	return float64(n) / elapsed.Seconds(), nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/subscription"
+	"myproxy.com/p/internal/xray"
+)
+
+// 节点导出格式
+const (
+	NodeExportClash = "clash" // Clash 配置的 proxies 段
+	NodeExportV2ray = "v2ray" // v2ray / xray 配置的 outbounds 段
+)
+
+// NodeExportFormats 导出格式的展示顺序
+var NodeExportFormats = []string{NodeExportClash, NodeExportV2ray}
+
+// NodeExportFormatName 返回导出格式的展示名称。
+func NodeExportFormatName(format string) string {
+	switch format {
+	case NodeExportClash:
+		return "Clash（proxies YAML）"
+	case NodeExportV2ray:
+		return "v2ray / xray（outbounds JSON）"
+	}
+	return format
+}
+
+// NodeExportFileName 返回导出文件的默认文件名。
+func NodeExportFileName(format, base string) string {
+	if base == "" {
+		base = "myproxy-nodes"
+	}
+	if format == NodeExportV2ray {
+		return base + ".json"
+	}
+	return base + ".yaml"
+}
+
+// ExportNodes 将节点导出为其他客户端或路由器可用的配置片段，供在路由器等设备上同时使用。
+// 导出内容包含节点凭据；无法在目标格式中表示的节点跳过并计入 skipped。
+func ExportNodes(nodes []*model.Node, format string) (data []byte, skipped int, err error) {
+	if len(nodes) == 0 {
+		return nil, 0, fmt.Errorf("导出配置: 没有可导出的节点")
+	}
+	switch format {
+	case NodeExportClash:
+		return subscription.ExportClashProxies(nodes)
+	case NodeExportV2ray:
+		return exportV2rayOutbounds(nodes)
+	}
+	return nil, 0, fmt.Errorf("导出配置: 不支持的格式 %s", format)
+}
+
+// exportV2rayOutbounds 按启动代理时相同的规则生成出站，tag 使用节点名称（重名时追加序号）。
+func exportV2rayOutbounds(nodes []*model.Node) ([]byte, int, error) {
+	skipped := 0
+	outbounds := make([]interface{}, 0, len(nodes))
+	tags := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		outbound, err := xray.CreateOutboundFromServer(n)
+		if err != nil {
+			skipped++
+			continue
+		}
+		tag := n.Name
+		if tag == "" {
+			tag = fmt.Sprintf("%s:%d", n.Addr, n.Port)
+		}
+		tags[tag]++
+		if c := tags[tag]; c > 1 {
+			tag = fmt.Sprintf("%s %d", tag, c)
+		}
+		outbound["tag"] = tag
+		outbounds = append(outbounds, outbound)
+	}
+	if len(outbounds) == 0 {
+		return nil, skipped, fmt.Errorf("导出配置: 所选节点均无法导出为 v2ray 出站")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]interface{}{"outbounds": outbounds}); err != nil {
+		return nil, skipped, fmt.Errorf("导出配置: 生成 v2ray 配置失败: %w", err)
+	}
+	return buf.Bytes(), skipped, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// powerCheckInterval 自动模式下检测供电状态的间隔
+	powerCheckInterval = time.Minute
+
+	// 低功耗模式下各后台任务的最短间隔
+	lowPowerTrafficPollInterval   = 5 * time.Second
+	lowPowerDiagnosticsSampleSecs = 60
+	lowPowerObservatoryInterval   = 10 * time.Minute
+)
+
+// 低功耗模式（lowPowerMode 取值）
+const (
+	LowPowerModeAuto = "auto" // 使用电池时开启
+	LowPowerModeOn   = "on"   // 始终开启
+	LowPowerModeOff  = "off"  // 关闭
+)
+
+// PowerService 低功耗模式：按配置与供电状态决定是否降低后台任务频率。
+// 开启时流量采样、运行时采样等轮询间隔加长，选中节点时的自动测速暂停，负载均衡组的隧道内探测间隔加长。
+// 各组件在调度下一次任务时读取 LowPower，为 nil 时视为关闭。
+type PowerService struct {
+	config *ConfigService
+
+	mu           sync.Mutex
+	lowPower     bool
+	listeners    map[int]func(lowPower bool)
+	nextListener int
+	stopCh       chan struct{}
+}
+
+// NewPowerService 创建低功耗模式服务。
+func NewPowerService(config *ConfigService) *PowerService {
+	return &PowerService{config: config, listeners: make(map[int]func(bool))}
+}
+
+// Start 立即计算一次状态并开始定时检测供电状态；已在运行时忽略。
+func (ps *PowerService) Start() {
+	ps.mu.Lock()
+	if ps.stopCh != nil {
+		ps.mu.Unlock()
+		return
+	}
+	stopCh := make(chan struct{})
+	ps.stopCh = stopCh
+	ps.mu.Unlock()
+
+	ps.Refresh()
+	go func() {
+		ticker := time.NewTicker(powerCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				ps.Refresh()
+			}
+		}
+	}()
+}
+
+// Stop 停止检测。
+func (ps *PowerService) Stop() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.stopCh != nil {
+		close(ps.stopCh)
+		ps.stopCh = nil
+	}
+}
+
+// Refresh 按配置与供电状态重新计算是否处于低功耗模式，状态变化时通知监听者（设置变化后也应调用）。
+func (ps *PowerService) Refresh() {
+	lowPower := false
+	mode := LowPowerModeAuto
+	if ps.config != nil {
+		mode = ps.config.GetLowPowerMode()
+	}
+	switch mode {
+	case LowPowerModeOn:
+		lowPower = true
+	case LowPowerModeAuto:
+		// 检测失败时按接通电源处理
+		lowPower, _ = utils.OnBatteryPower()
+	}
+
+	ps.mu.Lock()
+	if ps.lowPower == lowPower {
+		ps.mu.Unlock()
+		return
+	}
+	ps.lowPower = lowPower
+	listeners := make([]func(bool), 0, len(ps.listeners))
+	for _, fn := range ps.listeners {
+		listeners = append(listeners, fn)
+	}
+	ps.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(lowPower)
+	}
+}
+
+// LowPower 当前是否处于低功耗模式。
+func (ps *PowerService) LowPower() bool {
+	if ps == nil {
+		return false
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.lowPower
+}
+
+// Subscribe 注册状态变化回调（在检测 goroutine 或调用 Refresh 的 goroutine 中调用），返回取消函数。
+func (ps *PowerService) Subscribe(fn func(lowPower bool)) (unsubscribe func()) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	id := ps.nextListener
+	ps.nextListener++
+	ps.listeners[id] = fn
+	return func() {
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		delete(ps.listeners, id)
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/store"
+)
+
+const (
+	// prewarmCheckInterval 检查预连接池的间隔：补齐断开的连接、按最新访问记录调整目标
+	prewarmCheckInterval = 30 * time.Second
+	// prewarmMaxAge 预连接保持的最长时间，到期后重建，避免被 xray 或服务器按空闲超时断开后才发现
+	prewarmMaxAge = 2 * time.Minute
+	// prewarmDialTimeout 经本地入站建立一条预连接的超时
+	prewarmDialTimeout = 10 * time.Second
+	// prewarmRecentDays 只预热最近这些天内访问过的目标
+	prewarmRecentDays = 7
+)
+
+// prewarmConn 池中的一条预连接。
+type prewarmConn struct {
+	conn   net.Conn
+	opened time.Time
+	closed chan struct{} // 对端关闭或出错后关闭
+}
+
+// PrewarmService 连接预热：代理运行时经本地入站为访问最多的 N 个经代理目标各保持一条隧道连接，
+// 使节点连接路径、服务器端 DNS 与目标站点的路由保持热状态，减少首次访问常用站点时的等待。
+// 预连接不发送数据，到期或断开后重建；低功耗模式或网络未连接时释放全部连接。
+// 预连接产生的访问日志不计入访问记录，避免自我强化排名。
+type PrewarmService struct {
+	store   *store.Store
+	config  *ConfigService
+	records *AccessRecordService
+	power   *PowerService
+	network *NetworkStatusService
+	port    func() int // 代理运行中的本地入站端口，未运行时为 0
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+	pool   map[string]*prewarmConn // 目标地址 -> 预连接
+	poolAt int                     // 池中连接所用的入站端口，代理重启或换端口后整体重建
+}
+
+// NewPrewarmService 创建连接预热服务；port 返回代理运行中的本地入站端口，未运行时返回 0。
+func NewPrewarmService(store *store.Store, config *ConfigService, records *AccessRecordService, power *PowerService, network *NetworkStatusService, port func() int) *PrewarmService {
+	return &PrewarmService{
+		store:   store,
+		config:  config,
+		records: records,
+		power:   power,
+		network: network,
+		port:    port,
+		pool:    make(map[string]*prewarmConn),
+	}
+}
+
+// Start 开始维护预连接池。重复调用时忽略。
+func (ps *PrewarmService) Start() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.stopCh != nil {
+		return
+	}
+	ps.stopCh = make(chan struct{})
+	go ps.run(ps.stopCh)
+}
+
+// Stop 停止维护并关闭全部预连接。
+func (ps *PrewarmService) Stop() {
+	ps.mu.Lock()
+	if ps.stopCh != nil {
+		close(ps.stopCh)
+		ps.stopCh = nil
+	}
+	ps.mu.Unlock()
+	ps.closeAll()
+}
+
+func (ps *PrewarmService) run(stopCh chan struct{}) {
+	ticker := time.NewTicker(prewarmCheckInterval)
+	defer ticker.Stop()
+	for {
+		ps.refresh()
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh 按当前设置与访问记录调整预连接池：移除不再需要、已断开或到期的连接，再为缺少连接的目标补齐。
+func (ps *PrewarmService) refresh() {
+	port := 0
+	if ps.port != nil {
+		port = ps.port()
+	}
+	if port <= 0 || ps.config == nil || !ps.config.GetPrewarmEnabled() || ps.power.LowPower() || !ps.network.Online() {
+		ps.closeAll()
+		return
+	}
+
+	targets := ps.topDestinations(ps.config.GetPrewarmTopN(), time.Now())
+	wanted := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		wanted[t] = true
+	}
+
+	ps.mu.Lock()
+	if ps.poolAt != port {
+		ps.closeAllLocked()
+		ps.poolAt = port
+	}
+	var missing []string
+	for target, pc := range ps.pool {
+		expired := time.Since(pc.opened) >= prewarmMaxAge
+		select {
+		case <-pc.closed:
+			expired = true
+		default:
+		}
+		if !wanted[target] || expired {
+			ps.closeLocked(target)
+		}
+	}
+	for _, t := range targets {
+		if _, ok := ps.pool[t]; !ok {
+			missing = append(missing, t)
+		}
+	}
+	ps.mu.Unlock()
+
+	for _, target := range missing {
+		conn, err := ps.dial(port, target)
+		if err != nil {
+			continue // 下一轮重试
+		}
+		pc := &prewarmConn{conn: conn, opened: time.Now(), closed: make(chan struct{})}
+		go func() {
+			// 预连接不发送数据，读到数据、EOF 或错误都说明连接已不可用
+			_, _ = conn.Read(make([]byte, 1))
+			close(pc.closed)
+		}()
+		ps.mu.Lock()
+		if ps.poolAt != port {
+			ps.mu.Unlock()
+			ps.release(conn)
+			continue
+		}
+		ps.pool[target] = pc
+		ps.mu.Unlock()
+	}
+}
+
+// dial 经本地入站（SOCKS5，开启入站认证时带账号）建立到 target 的连接，并让访问记录忽略该连接。
+func (ps *PrewarmService) dial(port int, target string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if user, pass := ps.config.InboundAuth(); user != "" && pass != "" {
+		auth = &proxy.Auth{User: user, Password: pass}
+	}
+	inbound := net.JoinHostPort(database.LocalMixedInboundListenHost, strconv.Itoa(port))
+	ctx, cancel := context.WithTimeout(context.Background(), prewarmDialTimeout)
+	defer cancel()
+
+	// 先连上入站并登记本地地址，再做 SOCKS5 握手：xray 的访问日志可能早于握手完成
+	var d net.Dialer
+	raw, err := d.DialContext(ctx, "tcp", inbound)
+	if err != nil {
+		return nil, err
+	}
+	if ps.records != nil {
+		ps.records.IgnoreSource(raw.LocalAddr().String())
+	}
+	dialer, err := proxy.SOCKS5("tcp", inbound, auth, connDialer{raw})
+	if err == nil {
+		var conn net.Conn
+		if conn, err = dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", target); err == nil {
+			return conn, nil
+		}
+	}
+	ps.release(raw)
+	return nil, err
+}
+
+// connDialer 返回已建立的连接，用于在指定连接上完成 SOCKS5 握手。
+type connDialer struct {
+	conn net.Conn
+}
+
+func (d connDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.conn, nil
+}
+
+// topDestinations 返回最近 prewarmRecentDays 天内经代理访问次数最多的 n 个目标地址（host:port）。
+// 匿名记录（哈希后的地址）无法连接，跳过。
+func (ps *PrewarmService) topDestinations(n int, now time.Time) []string {
+	if n <= 0 || ps.store == nil || ps.store.AccessRecords == nil {
+		return nil
+	}
+	since := now.AddDate(0, 0, -prewarmRecentDays)
+	records := ps.store.AccessRecords.GetAll()
+	candidates := records[:0:0]
+	for _, r := range records {
+		if r.ProxiedCount <= 0 || r.LastSeen.Before(since) || strings.HasPrefix(r.Address, "#") {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(r.Address); err != nil {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].ProxiedCount > candidates[j].ProxiedCount })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	targets := make([]string, len(candidates))
+	for i, r := range candidates {
+		targets[i] = r.Address
+	}
+	return targets
+}
+
+func (ps *PrewarmService) closeAll() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.closeAllLocked()
+}
+
+func (ps *PrewarmService) closeAllLocked() {
+	for target := range ps.pool {
+		ps.closeLocked(target)
+	}
+}
+
+// closeLocked 关闭并移除一条预连接。调用方需持有 mu。
+func (ps *PrewarmService) closeLocked(target string) {
+	if pc, ok := ps.pool[target]; ok {
+		ps.release(pc.conn)
+		delete(ps.pool, target)
+	}
+}
+
+// release 关闭连接并取消访问记录对它的忽略。
+func (ps *PrewarmService) release(conn net.Conn) {
+	local := conn.LocalAddr().String()
+	_ = conn.Close()
+	if ps.records != nil {
+		ps.records.ReleaseSource(local)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/procinfo"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// processLookupQueueSize 待查找连接的队列长度，队列满时丢弃（统计允许少量遗漏）
+	processLookupQueueSize = 256
+	// processStatsFlushInterval 应用统计刷盘间隔
+	processStatsFlushInterval = 10 * time.Second
+	// processPortCacheTTL 同一源端口在该时间内复用上次的查找结果，避免短时间内重复扫描
+	processPortCacheTTL = 5 * time.Second
+	// processLineMaxAge 早于该时间的访问日志（如启动时回放的历史日志）不再统计：连接早已关闭，且回放会重复计数
+	processLineMaxAge = 30 * time.Second
+	// xrayAccessTimeLayout xray 访问日志的时间戳格式
+	xrayAccessTimeLayout = "2006/01/02 15:04:05.000000"
+)
+
+// ProcessStatsService 应用维度统计：从 xray 访问日志取出连接源地址，
+// 本机连接按源端口查找发起进程，局域网连接按来源 IP 归类，累计后定时写入数据库。
+type ProcessStatsService struct {
+	store  *store.Store
+	config *ConfigService
+
+	mu        sync.Mutex
+	queue     chan int
+	stopCh    chan struct{}
+	pending   map[string]*model.ProcessRecord
+	portCache map[int]cachedProcess
+}
+
+type cachedProcess struct {
+	proc procinfo.Process
+	at   time.Time
+}
+
+// NewProcessStatsService 创建应用维度统计服务。
+func NewProcessStatsService(store *store.Store, config *ConfigService) *ProcessStatsService {
+	return &ProcessStatsService{
+		store:     store,
+		config:    config,
+		pending:   make(map[string]*model.ProcessRecord),
+		portCache: make(map[int]cachedProcess),
+	}
+}
+
+// ApplyConfig 按配置启动或停止统计。
+func (pss *ProcessStatsService) ApplyConfig() {
+	if pss.config != nil && pss.config.GetProcessStatsEnabled() {
+		pss.Start()
+	} else {
+		pss.Stop()
+	}
+}
+
+// Start 启动后台查找协程；已在运行时忽略。
+func (pss *ProcessStatsService) Start() {
+	pss.mu.Lock()
+	defer pss.mu.Unlock()
+	if pss.stopCh != nil {
+		return
+	}
+	pss.queue = make(chan int, processLookupQueueSize)
+	pss.stopCh = make(chan struct{})
+	go pss.run(pss.queue, pss.stopCh)
+}
+
+// Stop 停止统计并将未写入的数据落盘。
+func (pss *ProcessStatsService) Stop() {
+	pss.mu.Lock()
+	if pss.stopCh != nil {
+		close(pss.stopCh)
+		pss.stopCh = nil
+		pss.queue = nil
+	}
+	pss.mu.Unlock()
+	_ = pss.Flush()
+}
+
+// RecordFromLogLine 解析 xray 访问日志的来源地址并登记；未启用、非访问日志或历史日志时忽略。
+func (pss *ProcessStatsService) RecordFromLogLine(line string) {
+	if pss == nil {
+		return
+	}
+	pss.mu.Lock()
+	queue := pss.queue
+	pss.mu.Unlock()
+	if queue == nil {
+		return
+	}
+
+	host, port, ok := parseAccessSource(line)
+	if !ok {
+		return
+	}
+	// 开启入站限速时连接经本机转发，日志中的来源是转发连接，还原为客户端的实际地址
+	if client, found := xray.RelayClientAddr(net.JoinHostPort(host, strconv.Itoa(port))); found {
+		if h, p, err := net.SplitHostPort(client); err == nil {
+			if n, err := strconv.Atoi(p); err == nil {
+				host, port = h, n
+			}
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return
+	}
+	// 启动时回放的历史日志不计入，局域网来源同样适用，避免每次启动重复累计
+	if ts, ok := parseAccessTime(line); ok && time.Since(ts) > processLineMaxAge {
+		return
+	}
+	if !ip.IsLoopback() {
+		// 局域网客户端无法查找进程，按来源 IP 归类
+		pss.add(procinfo.Process{Name: "局域网 " + host})
+		return
+	}
+	select {
+	case queue <- port:
+	default:
+	}
+}
+
+// run 后台协程：逐个查找进程并定时刷盘。
+func (pss *ProcessStatsService) run(queue chan int, stopCh chan struct{}) {
+	ticker := time.NewTicker(processStatsFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			_ = pss.Flush()
+		case port := <-queue:
+			if proc, ok := pss.lookup(port); ok {
+				pss.add(proc)
+			}
+		}
+	}
+}
+
+// lookup 查找源端口对应的进程，短时间内同一端口复用缓存结果。
+func (pss *ProcessStatsService) lookup(port int) (procinfo.Process, bool) {
+	pss.mu.Lock()
+	if c, ok := pss.portCache[port]; ok && time.Since(c.at) < processPortCacheTTL {
+		pss.mu.Unlock()
+		return c.proc, true
+	}
+	pss.mu.Unlock()
+
+	proc, err := procinfo.LookupTCPClient(port)
+	if err != nil {
+		return procinfo.Process{}, false
+	}
+
+	pss.mu.Lock()
+	now := time.Now()
+	for p, c := range pss.portCache {
+		if now.Sub(c.at) >= processPortCacheTTL {
+			delete(pss.portCache, p)
+		}
+	}
+	pss.portCache[port] = cachedProcess{proc: proc, at: now}
+	pss.mu.Unlock()
+	return proc, true
+}
+
+func (pss *ProcessStatsService) add(proc procinfo.Process) {
+	name := proc.DisplayName()
+	pss.mu.Lock()
+	defer pss.mu.Unlock()
+	r, ok := pss.pending[name]
+	if !ok {
+		r = &model.ProcessRecord{Name: name}
+		pss.pending[name] = r
+	}
+	if proc.Path != "" {
+		r.Path = proc.Path
+	}
+	r.ConnectionCount++
+}
+
+// Flush 将累计的应用统计写入数据库。
+func (pss *ProcessStatsService) Flush() error {
+	if pss == nil {
+		return nil
+	}
+	pss.mu.Lock()
+	if len(pss.pending) == 0 {
+		pss.mu.Unlock()
+		return nil
+	}
+	records := make([]model.ProcessRecord, 0, len(pss.pending))
+	for _, r := range pss.pending {
+		records = append(records, *r)
+	}
+	pss.pending = make(map[string]*model.ProcessRecord)
+	pss.mu.Unlock()
+
+	if pss.store == nil || pss.store.ProcessRecords == nil {
+		return nil
+	}
+	return pss.store.ProcessRecords.RecordBatch(records)
+}
+
+// parseAccessSource 从访问日志中提取 "from" 之后的来源地址，仅处理 TCP 连接。
+// 示例: ... from tcp:127.0.0.1:52101 accepted tcp:api2.cursor.sh:443 [mixed-in -> proxy]
+func parseAccessSource(line string) (host string, port int, ok bool) {
+	if !strings.Contains(line, " accepted ") {
+		return "", 0, false
+	}
+	idx := strings.Index(line, "from ")
+	if idx == -1 {
+		return "", 0, false
+	}
+	fields := strings.Fields(line[idx+len("from "):])
+	if len(fields) == 0 {
+		return "", 0, false
+	}
+	src := fields[0]
+	if strings.HasPrefix(src, "udp:") {
+		return "", 0, false
+	}
+	src = strings.TrimPrefix(src, "tcp:")
+	h, p, err := net.SplitHostPort(src)
+	if err != nil {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(p)
+	if err != nil {
+		return "", 0, false
+	}
+	return h, n, true
+}
+
+// parseAccessTime 解析 "from" 之前的 xray 时间戳（本地时间）；不存在时 ok 为 false。
+func parseAccessTime(line string) (time.Time, bool) {
+	idx := strings.Index(line, " from ")
+	if idx == -1 {
+		return time.Time{}, false
+	}
+	fields := strings.Fields(line[:idx])
+	if len(fields) < 2 {
+		return time.Time{}, false
+	}
+	ts, err := time.ParseInLocation(xrayAccessTimeLayout, fields[len(fields)-2]+" "+fields[len(fields)-1], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/urlscheme"
+)
+
+const (
+	// shareProfileVersion 分享配置的格式版本，不兼容修改时递增
+	shareProfileVersion = 1
+	// shareProfileMaxSize 解码后配置的大小上限，防止异常数据占用内存
+	shareProfileMaxSize = 1 << 20
+)
+
+// ShareProfile 可在团队内分享的配置：直连路由规则与订阅（含节点过滤、改名规则）。
+type ShareProfile struct {
+	Version              int                 `json:"v"`
+	DirectRoutes         []string            `json:"direct_routes,omitempty"`
+	DirectRoutesUseProxy bool                `json:"direct_routes_use_proxy,omitempty"`
+	BypassLanAndCN       bool                `json:"bypass_lan_cn,omitempty"`
+	Subscriptions        []ShareSubscription `json:"subscriptions,omitempty"`
+}
+
+// ShareSubscription 分享配置中的订阅。不含凭据导出时 URL 只保留协议与主机，Redacted 为 true，导入时跳过。
+type ShareSubscription struct {
+	Label         string            `json:"label,omitempty"`
+	URL           string            `json:"url"`
+	Redacted      bool              `json:"redacted,omitempty"`
+	IncludeFilter string            `json:"include_filter,omitempty"`
+	ExcludeFilter string            `json:"exclude_filter,omitempty"`
+	RenameRules   model.RenameRules `json:"rename_rules,omitempty"`
+	TestURL       string            `json:"test_url,omitempty"`
+}
+
+// ProfileImportResult 导入分享配置的结果。
+type ProfileImportResult struct {
+	RoutesAdded   int      // 新增的直连路由条数
+	Added         []string // 新增并已拉取的订阅名称
+	Existing      []string // 已存在（按地址）而跳过的订阅名称
+	Redacted      []string // 不含凭据、需自行获取订阅地址的订阅（名称与主机）
+	Errors        []string // 添加或拉取失败的订阅及原因
+	RoutesChanged bool     // 路由规则是否有变化（运行中的代理需重启生效）
+}
+
+// Summary 返回多行摘要。
+func (r *ProfileImportResult) Summary() string {
+	lines := []string{fmt.Sprintf("新增直连路由 %d 条", r.RoutesAdded)}
+	if len(r.Added) > 0 {
+		lines = append(lines, "新增订阅: "+strings.Join(r.Added, "、"))
+	}
+	if len(r.Existing) > 0 {
+		lines = append(lines, "已存在的订阅: "+strings.Join(r.Existing, "、"))
+	}
+	if len(r.Redacted) > 0 {
+		lines = append(lines, "以下订阅未包含凭据，请向服务商获取自己的订阅链接: "+strings.Join(r.Redacted, "、"))
+	}
+	if len(r.Errors) > 0 {
+		lines = append(lines, "失败: "+strings.Join(r.Errors, "；"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ProfileShareService 导出、导入分享配置（myproxy://profile 链接）。
+type ProfileShareService struct {
+	store         *store.Store
+	config        *ConfigService
+	subscriptions *SubscriptionService
+}
+
+// NewProfileShareService 创建分享配置服务。
+func NewProfileShareService(store *store.Store, config *ConfigService, subscriptions *SubscriptionService) *ProfileShareService {
+	return &ProfileShareService{store: store, config: config, subscriptions: subscriptions}
+}
+
+// Export 导出当前路由规则与订阅为分享链接；includeCredentials 为 false 时订阅地址只保留协议与主机。
+func (ps *ProfileShareService) Export(includeCredentials bool) (string, error) {
+	if ps.config == nil || ps.store == nil || ps.store.Subscriptions == nil {
+		return "", fmt.Errorf("分享配置: Store 未初始化")
+	}
+	profile := ShareProfile{
+		Version:              shareProfileVersion,
+		DirectRoutes:         ps.config.GetDirectRoutes(),
+		DirectRoutesUseProxy: ps.config.GetDirectRoutesUseProxy(),
+		BypassLanAndCN:       ps.config.GetBypassLanAndCN(),
+	}
+	for _, sub := range ps.store.Subscriptions.GetAll() {
+		shared := ShareSubscription{
+			Label:         sub.Label,
+			URL:           sub.URL,
+			IncludeFilter: sub.IncludeFilter,
+			ExcludeFilter: sub.ExcludeFilter,
+			RenameRules:   sub.RenameRules,
+			TestURL:       sub.TestURL,
+		}
+		if !includeCredentials {
+			shared.URL = redactSubscriptionURL(sub.URL)
+			shared.Redacted = true
+		}
+		profile.Subscriptions = append(profile.Subscriptions, shared)
+	}
+	data, err := EncodeShareProfile(&profile)
+	if err != nil {
+		return "", err
+	}
+	return urlscheme.ProfileURL(data), nil
+}
+
+// EncodeShareProfile 将配置编码为 JSON 经 deflate 压缩后的 URL 安全 Base64。
+func EncodeShareProfile(profile *ShareProfile) (string, error) {
+	raw, err := json.Marshal(profile)
+	if err != nil {
+		return "", fmt.Errorf("分享配置: 编码失败: %w", err)
+	}
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	if _, err := w.Write(raw); err != nil {
+		return "", fmt.Errorf("分享配置: 压缩失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("分享配置: 压缩失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeShareProfile 解码分享链接中的 data 参数。
+func DecodeShareProfile(data string) (*ShareProfile, error) {
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(data), "="))
+	if err != nil {
+		return nil, fmt.Errorf("分享配置: 数据无效: %w", err)
+	}
+	raw, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), shareProfileMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("分享配置: 解压失败: %w", err)
+	}
+	if len(raw) > shareProfileMaxSize {
+		return nil, fmt.Errorf("分享配置: 数据过大")
+	}
+	var profile ShareProfile
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		return nil, fmt.Errorf("分享配置: 解析失败: %w", err)
+	}
+	if profile.Version > shareProfileVersion {
+		return nil, fmt.Errorf("分享配置: 由更新版本的应用导出（格式版本 %d），请先升级", profile.Version)
+	}
+	return &profile, nil
+}
+
+// Import 应用分享配置：直连路由与现有列表合并（保留本地条目），两个路由开关采用分享者的设置；
+// 新订阅逐个添加、写入过滤与改名规则后拉取，地址已存在或不含凭据的订阅跳过。
+// 会访问网络，须在后台 goroutine 中调用。
+func (ps *ProfileShareService) Import(profile *ShareProfile) (*ProfileImportResult, error) {
+	if profile == nil {
+		return nil, fmt.Errorf("分享配置: 配置为空")
+	}
+	if ps.config == nil || ps.store == nil || ps.store.Subscriptions == nil {
+		return nil, fmt.Errorf("分享配置: Store 未初始化")
+	}
+	result := &ProfileImportResult{}
+
+	routes := ps.config.GetDirectRoutes()
+	existing := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		existing[r] = true
+	}
+	for _, r := range parseDirectRoutes(formatDirectRoutes(profile.DirectRoutes)) {
+		if !existing[r] {
+			existing[r] = true
+			routes = append(routes, r)
+			result.RoutesAdded++
+		}
+	}
+	if result.RoutesAdded > 0 {
+		if err := ps.config.SetDirectRoutes(routes); err != nil {
+			return nil, fmt.Errorf("分享配置: 保存直连路由失败: %w", err)
+		}
+	}
+	if ps.config.GetDirectRoutesUseProxy() != profile.DirectRoutesUseProxy {
+		if err := ps.config.SetDirectRoutesUseProxy(profile.DirectRoutesUseProxy); err != nil {
+			return nil, fmt.Errorf("分享配置: %w", err)
+		}
+		result.RoutesChanged = true
+	}
+	if ps.config.GetBypassLanAndCN() != profile.BypassLanAndCN {
+		if err := ps.config.SetBypassLanAndCN(profile.BypassLanAndCN); err != nil {
+			return nil, fmt.Errorf("分享配置: %w", err)
+		}
+		result.RoutesChanged = true
+	}
+	result.RoutesChanged = result.RoutesChanged || result.RoutesAdded > 0
+
+	for _, shared := range profile.Subscriptions {
+		name := shared.Label
+		if name == "" {
+			name = shared.URL
+		}
+		if shared.Redacted {
+			result.Redacted = append(result.Redacted, fmt.Sprintf("%s（%s）", name, shared.URL))
+			continue
+		}
+		if _, err := ps.store.Subscriptions.GetByURL(shared.URL); err == nil {
+			result.Existing = append(result.Existing, name)
+			continue
+		}
+		if err := ps.addSubscription(shared); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		result.Added = append(result.Added, name)
+	}
+	return result, nil
+}
+
+// addSubscription 添加订阅并先写入过滤、改名规则，再拉取节点，使规则在首次拉取时即生效。
+func (ps *ProfileShareService) addSubscription(shared ShareSubscription) error {
+	sub, err := ps.store.Subscriptions.Add(shared.URL, shared.Label)
+	if err != nil {
+		return err
+	}
+	if shared.IncludeFilter != "" || shared.ExcludeFilter != "" {
+		if err := ps.store.Subscriptions.UpdateFilters(sub.ID, shared.IncludeFilter, shared.ExcludeFilter); err != nil {
+			return err
+		}
+	}
+	if !shared.RenameRules.IsZero() {
+		if err := ps.store.Subscriptions.UpdateRenameRules(sub.ID, shared.RenameRules); err != nil {
+			return err
+		}
+	}
+	if shared.TestURL != "" {
+		if err := ps.store.Subscriptions.UpdateTestURL(sub.ID, shared.TestURL); err != nil {
+			return err
+		}
+	}
+	if ps.subscriptions == nil {
+		return nil
+	}
+	_, err = ps.subscriptions.UpdateByID(sub.ID)
+	return err
+}
+
+// redactSubscriptionURL 去掉订阅地址中的账号、路径与参数（令牌通常位于其中），只保留协议与主机。
+func redactSubscriptionURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
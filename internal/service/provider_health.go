@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"myproxy.com/p/internal/analytics"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/subscription"
+)
+
+// ProviderHealth 一个订阅（服务商）的健康概况，由已保存的节点测速结果、测速历史与订阅响应汇总。
+type ProviderHealth struct {
+	Subscription *model.Subscription
+	Nodes        int                      // 节点总数
+	Tested       int                      // 有测速结果的节点数
+	Alive        int                      // 最近一次测速成功的节点数
+	AvgDelay     int                      // 可用节点的平均延迟（毫秒），没有可用节点时为 0
+	Quota        *model.SubscriptionQuota // 服务商提供的流量与到期信息，未提供时为 nil
+	Trend        []analytics.DayHealth    // 最近 analytics.TrendDays 天每天的测速汇总，按日期升序
+}
+
+// AliveRate 返回已测速节点中可用的比例，没有测速过的节点时返回 -1。
+func (h ProviderHealth) AliveRate() float64 {
+	if h.Tested == 0 {
+		return -1
+	}
+	return float64(h.Alive) / float64(h.Tested)
+}
+
+// UpdateFailed 最近一次更新是否失败（更新成功时会清除失败记录）。
+func (h ProviderHealth) UpdateFailed() bool {
+	return h.Subscription != nil && h.Subscription.LastError != ""
+}
+
+// ProviderHealth 汇总每个订阅的健康概况，顺序与订阅列表一致，便于比较不同服务商。
+// 只读取已保存的数据，不发起测速或网络请求。
+func (ss *SubscriptionService) ProviderHealth() ([]ProviderHealth, error) {
+	if ss.store == nil || ss.store.Subscriptions == nil || ss.store.Nodes == nil {
+		return nil, fmt.Errorf("Store 未初始化")
+	}
+	now := time.Now()
+	history, err := ss.store.Nodes.DelayHistory(now.AddDate(0, 0, -analytics.TrendDays))
+	if err != nil {
+		return nil, err
+	}
+
+	subs := ss.store.Subscriptions.GetAll()
+	result := make([]ProviderHealth, 0, len(subs))
+	for _, sub := range subs {
+		nodes, err := ss.store.Nodes.GetBySubscriptionID(sub.ID)
+		if err != nil {
+			return nil, err
+		}
+		h := ProviderHealth{Subscription: sub, Nodes: len(nodes)}
+		var delaySum int
+		var samples []model.DelaySample
+		for _, n := range nodes {
+			samples = append(samples, history[n.ID]...)
+			if n.TestedAt.IsZero() || n.Delay == 0 {
+				continue
+			}
+			h.Tested++
+			if n.Delay > 0 {
+				h.Alive++
+				delaySum += n.Delay
+			}
+		}
+		if h.Alive > 0 {
+			h.AvgDelay = delaySum / h.Alive
+		}
+		if quota, ok := subscription.ParseUserinfo(sub.Userinfo); ok {
+			h.Quota = &quota
+		}
+		h.Trend = analytics.DailyHealth(samples, analytics.TrendDays, now)
+		result = append(result, h)
+	}
+	return result, nil
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"fmt"
+	"sync"
 
 	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/systemproxy"
 	"myproxy.com/p/internal/xray"
 )
@@ -11,10 +14,16 @@ import (
 // ProxyService 系统代理服务层，提供系统代理相关的业务逻辑。
 type ProxyService struct {
 	systemProxy  *systemproxy.SystemProxy
-	xrayInstance *xray.XrayInstance
 	configService *ConfigService
+
+	mu           sync.Mutex
+	xrayInstance *xray.XrayInstance
+	session      uint64 // 实例代次，每换一个非 nil 实例递增
 }
 
+// ProxyService 实现 store.ProxyController，供状态绑定读取运行状态快照
+var _ store.ProxyController = (*ProxyService)(nil)
+
 // NewProxyService 创建新的代理服务实例。
 // 参数：
 //   - xrayInstance: Xray 实例，用于获取代理端口
@@ -26,6 +35,9 @@ func NewProxyService(xrayInstance *xray.XrayInstance, configService *ConfigServi
 		xrayInstance: xrayInstance,
 		configService: configService,
 	}
+	if xrayInstance != nil {
+		ps.session = 1
+	}
 	ps.updateSystemProxyPort()
 	return ps
 }
@@ -36,16 +48,35 @@ func (ps *ProxyService) effectiveProxyPort() int {
 	if ps.configService != nil {
 		p = ps.configService.GetLocalInboundPort()
 	}
-	if ps.xrayInstance != nil && ps.xrayInstance.IsRunning() {
-		if port := ps.xrayInstance.GetPort(); port > 0 {
-			p = port
-		}
+	if state := ps.ProxyState(); state.Running {
+		p = state.Port
 	}
 	return p
 }
 
+// ProxyState 返回当前 xray 实例的运行状态快照；ps 或实例为 nil 时为未运行。
+// 运行中但实例未记录端口时按默认入站端口。
+func (ps *ProxyService) ProxyState() store.ProxyState {
+	if ps == nil {
+		return store.ProxyState{}
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.xrayInstance == nil || !ps.xrayInstance.IsRunning() {
+		return store.ProxyState{}
+	}
+	port := ps.xrayInstance.GetPort()
+	if port <= 0 {
+		port = database.DefaultMixedInboundPort
+	}
+	return store.ProxyState{Running: true, Port: port, Session: ps.session}
+}
+
 // updateSystemProxyPort 更新系统代理管理器的端口。
 func (ps *ProxyService) updateSystemProxyPort() {
+	if ps.configService != nil {
+		systemproxy.SetProxyCredentials(ps.configService.InboundAuth())
+	}
 	ps.systemProxy = systemproxy.NewSystemProxy(database.LocalMixedInboundListenHost, ps.effectiveProxyPort())
 }
 
@@ -53,7 +84,12 @@ func (ps *ProxyService) updateSystemProxyPort() {
 // 参数：
 //   - xrayInstance: Xray 实例
 func (ps *ProxyService) UpdateXrayInstance(xrayInstance *xray.XrayInstance) {
+	ps.mu.Lock()
+	if xrayInstance != nil && xrayInstance != ps.xrayInstance {
+		ps.session++
+	}
 	ps.xrayInstance = xrayInstance
+	ps.mu.Unlock()
 	ps.updateSystemProxyPort()
 }
 
@@ -110,7 +146,8 @@ func (ps *ProxyService) ApplySystemProxyMode(mode string) *ApplySystemProxyModeR
 		}
 		err = ps.systemProxy.SetTerminalProxy(proxyType)
 		if err == nil {
-			proxyURL := systemproxy.TerminalProxyURL(database.LocalMixedInboundListenHost, ps.effectiveProxyPort(), proxyType)
+			// 入站认证开启时地址中含账号，写日志前脱敏
+			proxyURL := logging.Redact(systemproxy.TerminalProxyURL(database.LocalMixedInboundListenHost, ps.effectiveProxyPort(), proxyType))
 			if proxyType == "https_tls" {
 				logMessage = fmt.Sprintf("已设置环境变量代理: %s（HTTPS 到代理；本地默认入站为明文时请选 http）", proxyURL)
 			} else {
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/xray"
+)
+
+// routeCheckTimeout 直连探测与经代理访问的超时
+const routeCheckTimeout = 8 * time.Second
+
+// RouteCheckResult 直连路由条目的检查结果。
+type RouteCheckResult struct {
+	Route         string
+	Target        string           // 由条目推断的测试地址（域名或 IP）
+	Match         *xray.RouteMatch // 按当前规则应走的出站
+	DirectLatency time.Duration    // 不经代理直接连接 Target:443 的耗时
+	DirectErr     error            // 直接连接失败的原因（域名拼写错误时通常为解析失败）
+	Actual        string           // 经本地代理访问时实际使用的出站：direct、proxy，未验证时为空
+	ActualNote    string           // 未能验证实际出站的原因
+}
+
+// Summary 返回多行说明。
+func (r *RouteCheckResult) Summary() string {
+	lines := []string{"测试地址: " + r.Target}
+	if r.Match != nil {
+		lines = append(lines, "规则匹配: "+routeOutboundName(r.Match.Outbound))
+	}
+	if r.DirectErr != nil {
+		lines = append(lines, "直接连接失败: "+r.DirectErr.Error())
+	} else {
+		lines = append(lines, fmt.Sprintf("直接连接成功: %d ms", r.DirectLatency.Milliseconds()))
+	}
+	switch {
+	case r.Actual != "":
+		line := "经本地代理访问实际: " + routeOutboundName(r.Actual)
+		if r.Match != nil && r.Match.Outbound != r.Actual {
+			line += "（与规则不一致，可能被更早的规则命中）"
+		}
+		lines = append(lines, line)
+	case r.ActualNote != "":
+		lines = append(lines, "实际出站未验证: "+r.ActualNote)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func routeOutboundName(tag string) string {
+	switch tag {
+	case "direct":
+		return "直连"
+	case "proxy":
+		return "代理"
+	case "block":
+		return "拦截"
+	}
+	return tag
+}
+
+// routeCheckTarget 由直连路由条目推断可连接的地址：域名条目取域名，IP/CIDR 取其中第一个主机地址；
+// geosite、geoip、regexp 等不对应具体地址的条目返回错误。
+func routeCheckTarget(route string) (string, error) {
+	s := strings.TrimSpace(route)
+	if v, ok := strings.CutPrefix(s, "domain:"); ok {
+		return strings.TrimPrefix(v, "."), nil
+	}
+	if v, ok := strings.CutPrefix(s, "full:"); ok {
+		return v, nil
+	}
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		addr := prefix.Masked().Addr()
+		if prefix.Bits() < addr.BitLen() {
+			addr = addr.Next()
+		}
+		return addr.String(), nil
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.String(), nil
+	}
+	return "", fmt.Errorf("路由检查: 「%s」不对应具体地址，请在「这个地址会走哪里？」中输入具体域名测试", s)
+}
+
+// CheckDirectRoute 检查直连路由条目：按当前规则判断应走的出站，直接连接目标的 443 端口验证地址可达
+// （可发现域名拼写错误），代理运行时再经本地入站访问目标，比较 direct 与代理出站的流量计数确认实际出站。
+// 会访问网络，须在后台 goroutine 中调用。
+func (xcs *XrayControlService) CheckDirectRoute(route string, instance *xray.XrayInstance) (*RouteCheckResult, error) {
+	target, err := routeCheckTarget(route)
+	if err != nil {
+		return nil, err
+	}
+	result := &RouteCheckResult{Route: route, Target: target}
+	if result.Match, err = xcs.TestRoute(target); err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(target, "443")
+	start := time.Now()
+	if conn, err := net.DialTimeout("tcp", addr, routeCheckTimeout); err != nil {
+		result.DirectErr = err
+	} else {
+		result.DirectLatency = time.Since(start)
+		conn.Close()
+	}
+
+	if instance == nil || !instance.IsRunning() {
+		result.ActualNote = "代理未运行"
+		return result, nil
+	}
+	directBefore := instance.OutboundTraffic("direct")
+	upBefore, downBefore := instance.TrafficStats()
+
+	client := localInboundClient(xcs.config, xcs.config.GetLocalInboundPort(), routeCheckTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), routeCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://"+addr+"/", nil)
+	if err != nil {
+		return nil, fmt.Errorf("路由检查: 创建请求失败: %w", err)
+	}
+	// 证书或 HTTP 错误不影响判断：只要有数据经过出站，流量计数就会变化
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+
+	directDelta := instance.OutboundTraffic("direct") - directBefore
+	up, down := instance.TrafficStats()
+	proxyDelta := up + down - upBefore - downBefore
+	switch {
+	case directDelta > 0 && proxyDelta == 0:
+		result.Actual = "direct"
+	case proxyDelta > 0 && directDelta == 0:
+		result.Actual = "proxy"
+	case directDelta == 0 && proxyDelta == 0:
+		result.ActualNote = "经本地代理访问时没有数据经过出站"
+	default:
+		result.ActualNote = "同时有其他连接在传输数据，无法区分"
+	}
+	return result, nil
+}
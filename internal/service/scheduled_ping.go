@@ -0,0 +1,359 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// scheduledPingCheckInterval 检查是否到达下一轮定时测速的间隔，设置修改后最迟在该时间内生效
+	scheduledPingCheckInterval = time.Minute
+	// pingWebhookTimeout 发送 Webhook 的超时
+	pingWebhookTimeout = 10 * time.Second
+	// latencyReportEvent Webhook 请求体中的事件名
+	latencyReportEvent = "latency_test"
+)
+
+// LatencyReport 一轮测速的摘要，作为 Webhook 请求体（JSON）。
+type LatencyReport struct {
+	Event         string               `json:"event"`
+	StartedAt     time.Time            `json:"started_at"`
+	FinishedAt    time.Time            `json:"finished_at"`
+	Tested        int                  `json:"tested"`
+	Succeeded     int                  `json:"succeeded"`
+	Failed        int                  `json:"failed"`
+	AutoDisabled  int                  `json:"auto_disabled"`
+	Subscriptions []LatencyReportGroup `json:"subscriptions"`
+	Nodes         []LatencyReportNode  `json:"nodes"`
+}
+
+// LatencyReportGroup 按订阅汇总的测速结果，便于发现某个服务商整体变差。手动添加的节点归入 ID 为 0 的分组。
+type LatencyReportGroup struct {
+	ID         int64  `json:"id"`
+	Label      string `json:"label"`
+	Tested     int    `json:"tested"`
+	Failed     int    `json:"failed"`
+	AvgDelayMs int    `json:"avg_delay_ms"` // 成功节点的平均延迟，全部失败时为 0
+}
+
+// LatencyReportNode 单个节点的测速结果；不包含服务器地址与凭据。
+type LatencyReportNode struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Subscription        string `json:"subscription,omitempty"`
+	Protocol            string `json:"protocol,omitempty"`
+	DelayMs             int    `json:"delay_ms"` // 失败时为 -1
+	OK                  bool   `json:"ok"`
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"`
+	AutoDisabled        bool   `json:"auto_disabled,omitempty"`
+}
+
+// QuietHours 每天的静默时段 [Start, End)，以当天零点起的分钟数表示；Start > End 表示跨零点（如 23:00-07:00）。
+// Start == End 表示不限制。
+type QuietHours struct {
+	Start, End int
+}
+
+// ParseQuietHours 解析 "HH:MM-HH:MM" 形式的静默时段，空字符串返回零值（不限制）。
+func ParseQuietHours(s string) (QuietHours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return QuietHours{}, nil
+	}
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("静默时段格式应为 HH:MM-HH:MM，实际为 %q", s)
+	}
+	var qh QuietHours
+	for i, part := range []string{start, end} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return QuietHours{}, fmt.Errorf("静默时段格式应为 HH:MM-HH:MM，实际为 %q", s)
+		}
+		minutes := t.Hour()*60 + t.Minute()
+		if i == 0 {
+			qh.Start = minutes
+		} else {
+			qh.End = minutes
+		}
+	}
+	return qh, nil
+}
+
+// IsZero 是否未设置静默时段。
+func (qh QuietHours) IsZero() bool {
+	return qh.Start == qh.End
+}
+
+// Contains t 的本地时间是否落在静默时段内。
+func (qh QuietHours) Contains(t time.Time) bool {
+	if qh.IsZero() {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if qh.Start < qh.End {
+		return m >= qh.Start && m < qh.End
+	}
+	return m >= qh.Start || m < qh.End
+}
+
+// String 返回 "HH:MM-HH:MM"，未设置时返回空字符串。
+func (qh QuietHours) String() string {
+	if qh.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", qh.Start/60, qh.Start%60, qh.End/60, qh.End%60)
+}
+
+// ScheduledPingService 按设置的间隔对全部启用节点测速，结果与一键测速一样记录（连续失败达到阈值自动禁用），
+// 每轮结束后向设置的 Webhook 地址 POST JSON 摘要，便于服务商质量下降时由外部系统告警。
+// 低功耗模式下、网络未连接时、静默时段内以及设置了仅接通电源时使用电池期间暂停，条件解除后补测错过的一轮。
+type ScheduledPingService struct {
+	store   *store.Store
+	config  *ConfigService
+	servers *ServerService
+	ping    *utils.Ping
+	power   *PowerService
+	network *NetworkStatusService
+	client  *http.Client
+
+	runMu sync.Mutex // 同一时间只进行一轮测速
+
+	mu       sync.Mutex
+	stopCh   chan struct{}
+	lastRun  time.Time
+	onReport func(*LatencyReport, error)
+}
+
+// NewScheduledPingService 创建定时测速服务。
+func NewScheduledPingService(store *store.Store, config *ConfigService, servers *ServerService, ping *utils.Ping, power *PowerService, network *NetworkStatusService) *ScheduledPingService {
+	return &ScheduledPingService{
+		store:   store,
+		config:  config,
+		servers: servers,
+		ping:    ping,
+		power:   power,
+		network: network,
+		client:  &http.Client{Timeout: pingWebhookTimeout},
+	}
+}
+
+// SetOnReport 设置定时测速完成后的回调（在测速 goroutine 中调用），err 为发送 Webhook 的错误。
+func (sp *ScheduledPingService) SetOnReport(fn func(*LatencyReport, error)) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.onReport = fn
+}
+
+// Start 开始定时测速；首轮在一个间隔之后进行。重复调用时忽略。
+func (sp *ScheduledPingService) Start() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.stopCh != nil {
+		return
+	}
+	sp.stopCh = make(chan struct{})
+	sp.lastRun = time.Now()
+	go sp.run(sp.stopCh)
+}
+
+// Stop 停止定时测速。
+func (sp *ScheduledPingService) Stop() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.stopCh != nil {
+		close(sp.stopCh)
+		sp.stopCh = nil
+	}
+}
+
+func (sp *ScheduledPingService) run(stopCh chan struct{}) {
+	ticker := time.NewTicker(scheduledPingCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		interval := sp.config.GetScheduledPingInterval()
+		sp.mu.Lock()
+		due := interval > 0 && time.Since(sp.lastRun) >= interval
+		sp.mu.Unlock()
+		if !due || sp.paused(time.Now()) {
+			continue
+		}
+		report, err := sp.RunNow()
+		if report == nil {
+			continue
+		}
+		sp.mu.Lock()
+		onReport := sp.onReport
+		sp.mu.Unlock()
+		if onReport != nil {
+			onReport(report, err)
+		}
+	}
+}
+
+// paused 定时测速当前是否应暂停：低功耗模式、网络未连接、静默时段内，或仅接通电源时运行而正在使用电池。
+func (sp *ScheduledPingService) paused(now time.Time) bool {
+	if sp.power.LowPower() || !sp.network.Online() {
+		return true
+	}
+	if sp.config == nil {
+		return false
+	}
+	if sp.config.GetScheduledPingQuietHours().Contains(now) {
+		return true
+	}
+	if sp.config.GetScheduledPingACOnly() {
+		// 检测失败时按接通电源处理
+		onBattery, _ := utils.OnBatteryPower()
+		return onBattery
+	}
+	return false
+}
+
+// RunNow 立即对全部启用节点测速并发送 Webhook；已有一轮在进行时返回 nil。
+// 返回的错误仅表示 Webhook 发送失败，测速结果已记录。会访问网络，须在后台 goroutine 中调用。
+func (sp *ScheduledPingService) RunNow() (*LatencyReport, error) {
+	if !sp.runMu.TryLock() {
+		return nil, nil
+	}
+	defer sp.runMu.Unlock()
+	sp.mu.Lock()
+	sp.lastRun = time.Now()
+	sp.mu.Unlock()
+
+	if sp.store == nil || sp.store.Nodes == nil || sp.ping == nil {
+		return nil, fmt.Errorf("定时测速: Store 未初始化")
+	}
+	var nodes []model.Node
+	for _, n := range sp.store.Nodes.GetAll() {
+		if n != nil && n.Enabled {
+			nodes = append(nodes, *n)
+		}
+	}
+	report := &LatencyReport{Event: latencyReportEvent, StartedAt: time.Now()}
+	results := sp.ping.TestAllServersDelay(nodes)
+	report.FinishedAt = time.Now()
+
+	groups := sp.subscriptionGroups()
+	byGroup := make(map[int64]*LatencyReportGroup)
+	delaySum := make(map[int64]int)
+	for _, n := range nodes {
+		delay, ok := results[n.ID]
+		if !ok {
+			continue
+		}
+		if delay <= 0 {
+			delay = -1
+		}
+		entry := LatencyReportNode{ID: n.ID, Name: n.Name, Protocol: n.ProtocolType, DelayMs: delay, OK: delay > 0}
+		if sp.servers != nil {
+			disabled, _, err := sp.servers.RecordTestResult(n.ID, delay, false)
+			if err == nil && disabled {
+				entry.AutoDisabled = true
+				report.AutoDisabled++
+			}
+			if updated, err := sp.store.Nodes.Get(n.ID); err == nil {
+				entry.ConsecutiveFailures = updated.FailCount
+			}
+		}
+
+		group, ok := groups[n.ID]
+		if !ok {
+			group = LatencyReportGroup{Label: "手动添加"}
+		}
+		g := byGroup[group.ID]
+		if g == nil {
+			g = &LatencyReportGroup{ID: group.ID, Label: group.Label}
+			byGroup[group.ID] = g
+		}
+		entry.Subscription = g.Label
+		g.Tested++
+		report.Tested++
+		if entry.OK {
+			report.Succeeded++
+			delaySum[g.ID] += delay
+		} else {
+			g.Failed++
+			report.Failed++
+		}
+		report.Nodes = append(report.Nodes, entry)
+	}
+	for id, g := range byGroup {
+		if ok := g.Tested - g.Failed; ok > 0 {
+			g.AvgDelayMs = delaySum[id] / ok
+		}
+		report.Subscriptions = append(report.Subscriptions, *g)
+	}
+	sort.Slice(report.Subscriptions, func(i, j int) bool { return report.Subscriptions[i].ID < report.Subscriptions[j].ID })
+	sort.Slice(report.Nodes, func(i, j int) bool { return report.Nodes[i].Name < report.Nodes[j].Name })
+
+	return report, sp.SendWebhook(report)
+}
+
+// subscriptionGroups 返回节点 ID 到所属订阅（仅 ID 与名称）的映射。
+func (sp *ScheduledPingService) subscriptionGroups() map[string]LatencyReportGroup {
+	idx := make(map[string]LatencyReportGroup)
+	if sp.store.Subscriptions == nil {
+		return idx
+	}
+	for _, sub := range sp.store.Subscriptions.GetAll() {
+		nodes, err := sp.store.Nodes.GetBySubscriptionID(sub.ID)
+		if err != nil {
+			continue
+		}
+		label := sub.Label
+		if label == "" {
+			label = redactSubscriptionURL(sub.URL)
+		}
+		for _, n := range nodes {
+			idx[n.ID] = LatencyReportGroup{ID: sub.ID, Label: label}
+		}
+	}
+	return idx
+}
+
+// SendWebhook 将测速摘要 POST 到设置的 Webhook 地址；未设置地址时不发送。
+func (sp *ScheduledPingService) SendWebhook(report *LatencyReport) error {
+	webhookURL := ""
+	if sp.config != nil {
+		webhookURL = sp.config.GetPingWebhookURL()
+	}
+	if webhookURL == "" || report == nil {
+		return nil
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("定时测速: 编码 Webhook 内容失败: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("定时测速: 创建 Webhook 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := sp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("定时测速: 发送 Webhook 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("定时测速: Webhook 返回 HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -2,26 +2,32 @@ package service
 
 import (
 	"fmt"
+	"time"
 
+	"myproxy.com/p/internal/analytics"
 	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/xray"
 )
 
 // ServerService 服务器服务层，提供服务器相关的业务逻辑。
 // 它封装了对 Store 的访问，提供统一的服务器操作接口。
 type ServerService struct {
-	store *store.Store
+	store  *store.Store
+	config *ConfigService
 }
 
 // NewServerService 创建新的服务器服务实例。
 // 参数：
 //   - store: Store 实例，用于数据访问
+//   - config: 配置服务，用于读取自动禁用阈值（可为 nil，此时不自动禁用）
 //
 // 返回：初始化后的 ServerService 实例
-func NewServerService(store *store.Store) *ServerService {
+func NewServerService(store *store.Store, config *ConfigService) *ServerService {
 	return &ServerService{
-		store: store,
+		store:  store,
+		config: config,
 	}
 }
 
@@ -120,6 +126,87 @@ func (ss *ServerService) UpdateServerDelay(id string, delay int) error {
 	return ss.store.Nodes.UpdateDelay(id, delay)
 }
 
+// RecordTestResult 记录一次测速（或连接）结果并维护节点健康状态。
+// 成功时写入延迟并清零连续失败次数；manual 为 true（用户手动测速）时同时恢复被自动禁用的节点。
+// 失败时累加连续失败次数，达到配置阈值后禁用节点并记录原因与时间。
+// 参数：
+//   - id: 服务器ID
+//   - delay: 延迟（毫秒），<= 0 表示失败
+//   - manual: 是否为用户手动发起的单节点测速
+//
+// 返回：本次是否自动禁用了节点、是否恢复了节点，以及错误（如果有）
+func (ss *ServerService) RecordTestResult(id string, delay int, manual bool) (disabled, restored bool, err error) {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return false, false, fmt.Errorf("服务器服务: Store 未初始化")
+	}
+	node, err := ss.store.Nodes.Get(id)
+	if err != nil {
+		return false, false, err
+	}
+	failCount, enabled, reason, disabledAt := node.FailCount, node.Enabled, node.DisabledReason, node.DisabledAt
+
+	if delay > 0 {
+		if err := ss.store.Nodes.UpdateDelay(id, delay); err != nil {
+			return false, false, err
+		}
+		restored = manual && reason != ""
+		if failCount == 0 && !restored {
+			return false, false, nil
+		}
+		failCount = 0
+		if restored {
+			enabled, reason, disabledAt = true, "", time.Time{}
+		}
+		return false, restored, ss.store.Nodes.UpdateHealth(id, failCount, enabled, reason, disabledAt)
+	}
+
+	if err := ss.store.Nodes.UpdateDelay(id, -1); err != nil {
+		return false, false, err
+	}
+	failCount++
+	threshold := 0
+	if ss.config != nil {
+		threshold = ss.config.GetAutoDisableFailThreshold()
+	}
+	if threshold > 0 && failCount >= threshold && enabled {
+		enabled = false
+		reason = fmt.Sprintf("连续 %d 次测试或连接失败", failCount)
+		disabledAt = time.Now()
+		disabled = true
+	}
+	return disabled, false, ss.store.Nodes.UpdateHealth(id, failCount, enabled, reason, disabledAt)
+}
+
+// NodeTags 根据近 analytics.TagWindow 的测速历史推断各节点的特征标签（如「高丢包」「晚间慢」），
+// 只返回有标签的节点。
+func (ss *ServerService) NodeTags() (map[string][]analytics.NodeTag, error) {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return nil, fmt.Errorf("服务器服务: Store 未初始化")
+	}
+	history, err := ss.store.Nodes.DelayHistory(time.Now().Add(-analytics.TagWindow))
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]analytics.NodeTag)
+	for id, samples := range history {
+		if t := analytics.NodeTags(samples); len(t) > 0 {
+			tags[id] = t
+		}
+	}
+	return tags, nil
+}
+
+// UpdateDialOptions 校验并保存节点的拨号选项，重启代理后生效。
+func (ss *ServerService) UpdateDialOptions(id string, opts model.DialOptions) error {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return fmt.Errorf("服务器服务: Store 未初始化")
+	}
+	if err := xray.ValidateDialOptions(opts); err != nil {
+		return fmt.Errorf("服务器服务: %w", err)
+	}
+	return ss.store.Nodes.UpdateDialOptions(id, opts)
+}
+
 // AddOrUpdateServer 添加或更新服务器。
 // 参数：
 //   - node: 服务器节点
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// sessionHistoryFileName 连接记录文件名（每行一条 JSON），位于数据目录下
+	sessionHistoryFileName = "sessions.jsonl"
+	// sessionHistoryLimit 保留的连接记录条数，超出时删除最早的记录
+	sessionHistoryLimit = 200
+	// sessionTopDestinations 报告中列出的访问最多的站点数
+	sessionTopDestinations = 10
+)
+
+// SessionReport 一次连接（从连接到断开）的汇总。
+type SessionReport struct {
+	StartedAt       time.Time            `json:"started_at"`
+	EndedAt         time.Time            `json:"ended_at"`
+	Nodes           []string             `json:"nodes"` // 使用过的节点，按首次使用顺序
+	Upload          int64                `json:"upload"`
+	Download        int64                `json:"download"`
+	Connections     int64                `json:"connections"`
+	TopDestinations []SessionDestination `json:"top_destinations,omitempty"`
+	AvgLatencyMs    int                  `json:"avg_latency_ms"` // 隧道内探测的平均延迟，无探测结果时为 0
+	Reconnects      int                  `json:"reconnects"`     // 重启 xray（含自动重启、修改设置后重启）与中断后恢复的次数
+}
+
+// Duration 返回连接时长。
+func (r *SessionReport) Duration() time.Duration {
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// SessionDestination 连接期间访问的站点（按可注册域名归并）与连接次数。
+type SessionDestination struct {
+	Host  string `json:"host"`
+	Count int64  `json:"count"`
+}
+
+// SessionReportService 跟踪从用户连接到断开的一次连接：流量、访问站点、隧道内延迟与重连次数，
+// 断开时生成报告并追加到数据目录下的连接记录。设置关闭时不跟踪。
+type SessionReportService struct {
+	config  *ConfigService
+	traffic *TrafficService
+
+	mu          sync.Mutex
+	active      bool
+	startedAt   time.Time
+	nodes       []string
+	startUp     int64
+	startDown   int64
+	connections int64
+	dests       map[string]int64
+	latencySum  time.Duration
+	latencyN    int
+	reconnects  int
+}
+
+// NewSessionReportService 创建连接报告服务；traffic 为 nil 时报告不含流量。
+func NewSessionReportService(config *ConfigService, traffic *TrafficService) *SessionReportService {
+	return &SessionReportService{config: config, traffic: traffic}
+}
+
+// SessionHistoryPath 返回连接记录文件路径。
+func SessionHistoryPath() string {
+	dir := database.DataDir()
+	if dir == "" {
+		dir = "data"
+	}
+	return filepath.Join(dir, sessionHistoryFileName)
+}
+
+func (sr *SessionReportService) enabled() bool {
+	return sr.config != nil && sr.config.GetSessionReportEnabled()
+}
+
+// Begin 代理开始新会话（连接、重启或自动重启）时调用；连接尚未结束时计为一次重连。
+func (sr *SessionReportService) Begin(node string) {
+	if !sr.enabled() {
+		return
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.active {
+		sr.reconnects++
+		sr.addNodeLocked(node)
+		return
+	}
+	sr.active, sr.startedAt = true, time.Now()
+	sr.nodes, sr.connections, sr.dests = nil, 0, make(map[string]int64)
+	sr.latencySum, sr.latencyN, sr.reconnects = 0, 0, 0
+	sr.startUp, sr.startDown = 0, 0
+	if sr.traffic != nil {
+		sr.startUp, sr.startDown = sr.traffic.Totals()
+	}
+	sr.addNodeLocked(node)
+}
+
+func (sr *SessionReportService) addNodeLocked(node string) {
+	if node == "" {
+		return
+	}
+	for _, n := range sr.nodes {
+		if n == node {
+			return
+		}
+	}
+	sr.nodes = append(sr.nodes, node)
+}
+
+// RecordDestination 记录一次连接的目标地址（host:port），按可注册域名归并；address 为空时只计连接数。
+func (sr *SessionReportService) RecordDestination(address string) {
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if !sr.active {
+		return
+	}
+	sr.connections++
+	if host == "" {
+		return
+	}
+	if host = utils.RegistrableDomain(host); host != "" {
+		sr.dests[host]++
+	}
+}
+
+// RecordLatency 记录一次隧道内探测的延迟。
+func (sr *SessionReportService) RecordLatency(d time.Duration) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.active {
+		sr.latencySum += d
+		sr.latencyN++
+	}
+}
+
+// RecordReconnect 记录一次中断后恢复。
+func (sr *SessionReportService) RecordReconnect() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.active {
+		sr.reconnects++
+	}
+}
+
+// Tracking 返回是否正在跟踪连接（用于决定是否需要隧道内探测）。
+func (sr *SessionReportService) Tracking() bool {
+	if sr == nil {
+		return false
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.active
+}
+
+// End 结束当前连接，生成报告并追加到连接记录；未在跟踪时返回 nil。
+func (sr *SessionReportService) End() (*SessionReport, error) {
+	sr.mu.Lock()
+	if !sr.active {
+		sr.mu.Unlock()
+		return nil, nil
+	}
+	sr.active = false
+	report := &SessionReport{
+		StartedAt:   sr.startedAt,
+		EndedAt:     time.Now(),
+		Nodes:       sr.nodes,
+		Connections: sr.connections,
+		Reconnects:  sr.reconnects,
+	}
+	if sr.latencyN > 0 {
+		report.AvgLatencyMs = int((sr.latencySum / time.Duration(sr.latencyN)).Milliseconds())
+	}
+	for host, count := range sr.dests {
+		report.TopDestinations = append(report.TopDestinations, SessionDestination{Host: host, Count: count})
+	}
+	startUp, startDown := sr.startUp, sr.startDown
+	sr.dests = nil
+	sr.mu.Unlock()
+
+	sort.Slice(report.TopDestinations, func(i, j int) bool {
+		a, b := report.TopDestinations[i], report.TopDestinations[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Host < b.Host
+	})
+	if len(report.TopDestinations) > sessionTopDestinations {
+		report.TopDestinations = report.TopDestinations[:sessionTopDestinations]
+	}
+	if sr.traffic != nil {
+		up, down := sr.traffic.Totals()
+		// 连接期间清零了累计流量时差值为负，按 0 计
+		report.Upload, report.Download = max(up-startUp, 0), max(down-startDown, 0)
+	}
+	return report, appendSessionHistory(report)
+}
+
+// History 读取连接记录，最近的在前。
+func (sr *SessionReportService) History() ([]SessionReport, error) {
+	data, err := os.ReadFile(SessionHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接记录: 读取失败: %w", err)
+	}
+	var reports []SessionReport
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r SessionReport
+		if err := json.Unmarshal(scanner.Bytes(), &r); err == nil {
+			reports = append(reports, r)
+		}
+	}
+	for i, j := 0, len(reports)-1; i < j; i, j = i+1, j-1 {
+		reports[i], reports[j] = reports[j], reports[i]
+	}
+	return reports, nil
+}
+
+// ClearHistory 删除全部连接记录。
+func (sr *SessionReportService) ClearHistory() error {
+	if err := os.Remove(SessionHistoryPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("连接记录: 删除失败: %w", err)
+	}
+	return nil
+}
+
+// appendSessionHistory 追加一条记录，超过 sessionHistoryLimit 时只保留最近的记录。
+func appendSessionHistory(report *SessionReport) error {
+	line, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("连接记录: 编码失败: %w", err)
+	}
+	path := SessionHistoryPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("连接记录: 创建目录失败: %w", err)
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("连接记录: 读取失败: %w", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(existing), "\n"), "\n")
+	if len(existing) == 0 {
+		lines = nil
+	}
+	lines = append(lines, string(line))
+	if len(lines) > sessionHistoryLimit {
+		lines = lines[len(lines)-sessionHistoryLimit:]
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("连接记录: 写入失败: %w", err)
+	}
+	return nil
+}
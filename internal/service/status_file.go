@@ -0,0 +1,215 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/store"
+)
+
+const (
+	// statusFileName 状态文件名，位于数据目录下
+	statusFileName = "status.json"
+	// statusFileInterval 连接期间刷新状态文件（速度、流量、运行时长）的间隔
+	statusFileInterval = 5 * time.Second
+	// lowPowerStatusFileInterval 低功耗模式下的刷新间隔
+	lowPowerStatusFileInterval = 30 * time.Second
+	// statusSystemProxyMode 系统代理模式中表示已设置系统代理的取值
+	statusSystemProxyMode = "自动配置系统代理"
+)
+
+// StatusFile status.json 的内容，供 polybar、BitBar 等脚本读取；字段名保持稳定。
+type StatusFile struct {
+	Running       bool              `json:"running"`
+	Node          *StatusFileNode   `json:"node,omitempty"`
+	Listen        string            `json:"listen"`
+	Port          int               `json:"port"`
+	ProxyType     string            `json:"proxy_type"`
+	SystemProxy   bool              `json:"system_proxy"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	UptimeSeconds int64             `json:"uptime_seconds"`
+	ExitIP        string            `json:"exit_ip,omitempty"`
+	Traffic       StatusFileTraffic `json:"traffic"`
+	PID           int               `json:"pid"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// StatusFileNode 当前选中节点；不包含服务器地址与凭据。
+type StatusFileNode struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	DelayMs  int    `json:"delay_ms"` // 未测速或失败时为 0
+}
+
+// StatusFileTraffic 实时速度（字节/秒）与累计流量（字节）。
+type StatusFileTraffic struct {
+	UploadSpeed   int64 `json:"upload_speed"`
+	DownloadSpeed int64 `json:"download_speed"`
+	TotalUpload   int64 `json:"total_upload"`
+	TotalDownload int64 `json:"total_download"`
+}
+
+// StatusFileService 把代理状态写入数据目录下的 status.json，状态变化时立即写入，连接期间定期刷新速度与流量，
+// 供状态栏脚本在不开启本地接口的情况下显示。写入先落临时文件再改名，读取方不会读到半个文件。
+type StatusFileService struct {
+	store   *store.Store
+	config  *ConfigService
+	traffic *TrafficService
+	power   *PowerService
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+}
+
+// NewStatusFileService 创建状态文件服务；traffic、power 为 nil 时跳过流量字段、不放缓刷新。
+func NewStatusFileService(store *store.Store, config *ConfigService, traffic *TrafficService, power *PowerService) *StatusFileService {
+	return &StatusFileService{store: store, config: config, traffic: traffic, power: power}
+}
+
+// StatusFilePath 返回状态文件路径。
+func StatusFilePath() string {
+	dir := database.DataDir()
+	if dir == "" {
+		dir = "data"
+	}
+	return filepath.Join(dir, statusFileName)
+}
+
+// ApplyConfig 按开关启动定时刷新并立即写入，或停止并删除状态文件。
+func (sf *StatusFileService) ApplyConfig() error {
+	if sf.config == nil || !sf.config.GetStatusFileEnabled() {
+		sf.stop()
+		if err := os.Remove(StatusFilePath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("状态文件: 删除失败: %w", err)
+		}
+		return nil
+	}
+	sf.mu.Lock()
+	if sf.stopCh == nil {
+		sf.stopCh = make(chan struct{})
+		go sf.run(sf.stopCh)
+	}
+	sf.mu.Unlock()
+	return sf.Write()
+}
+
+// Stop 停止刷新并写入未连接状态（退出时调用），使脚本不再显示已连接。
+func (sf *StatusFileService) Stop() {
+	if !sf.stop() {
+		return
+	}
+	status := sf.Snapshot()
+	status.Running, status.StartedAt, status.UptimeSeconds, status.ExitIP = false, nil, 0, ""
+	status.Traffic.UploadSpeed, status.Traffic.DownloadSpeed = 0, 0
+	_ = writeStatusFile(status)
+}
+
+// stop 停止定时刷新，返回之前是否在运行。
+func (sf *StatusFileService) stop() bool {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.stopCh == nil {
+		return false
+	}
+	close(sf.stopCh)
+	sf.stopCh = nil
+	return true
+}
+
+// Touch 状态变化（连接、断开、切换节点或模式）后调用，开关开启时立即写入。
+func (sf *StatusFileService) Touch() {
+	sf.mu.Lock()
+	running := sf.stopCh != nil
+	sf.mu.Unlock()
+	if running {
+		_ = sf.Write()
+	}
+}
+
+func (sf *StatusFileService) run(stopCh chan struct{}) {
+	for {
+		interval := statusFileInterval
+		if sf.power.LowPower() {
+			interval = lowPowerStatusFileInterval
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(interval):
+		}
+		// 未连接时内容只随 Touch 变化，不重复写入
+		if sf.store != nil && sf.store.ProxyStatus != nil && !sf.store.ProxyStatus.SessionStartedAt().IsZero() {
+			_ = sf.Write()
+		}
+	}
+}
+
+// Write 立即写入当前状态。
+func (sf *StatusFileService) Write() error {
+	return writeStatusFile(sf.Snapshot())
+}
+
+// Snapshot 汇总当前状态。
+func (sf *StatusFileService) Snapshot() *StatusFile {
+	now := time.Now()
+	status := &StatusFile{PID: os.Getpid(), UpdatedAt: now}
+	if sf.config != nil {
+		status.Listen = sf.config.GetMixedInboundXrayListenAddress()
+		status.Port = sf.config.GetLocalInboundPort()
+		status.ProxyType = sf.config.GetProxyType()
+		status.SystemProxy = sf.config.GetSystemProxyMode() == statusSystemProxyMode
+	}
+	if sf.store != nil && sf.store.ProxyStatus != nil {
+		if startedAt := sf.store.ProxyStatus.SessionStartedAt(); !startedAt.IsZero() {
+			status.Running = true
+			status.StartedAt = &startedAt
+			status.UptimeSeconds = int64(now.Sub(startedAt) / time.Second)
+			if ip, err := sf.store.ProxyStatus.ExitIPBinding.Get(); err == nil && ip != "-" {
+				status.ExitIP = ip
+			}
+		}
+	}
+	if sf.store != nil && sf.store.Nodes != nil {
+		if n := sf.store.Nodes.GetSelected(); n != nil {
+			status.Node = &StatusFileNode{ID: n.ID, Name: n.Name, Protocol: n.ProtocolType}
+			if n.Delay > 0 {
+				status.Node.DelayMs = n.Delay
+			}
+		}
+	}
+	if sf.traffic != nil {
+		status.Traffic.TotalUpload, status.Traffic.TotalDownload = sf.traffic.Totals()
+		if status.Running {
+			latest := sf.traffic.Latest()
+			status.Traffic.UploadSpeed, status.Traffic.DownloadSpeed = latest.Upload, latest.Download
+		}
+	}
+	return status
+}
+
+// writeStatusFile 以临时文件加改名的方式写入状态文件。
+func writeStatusFile(status *StatusFile) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("状态文件: 编码失败: %w", err)
+	}
+	path := StatusFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("状态文件: 创建目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("状态文件: 写入失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("状态文件: 写入失败: %w", err)
+	}
+	return nil
+}
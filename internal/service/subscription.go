@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/subscription"
 )
@@ -30,33 +31,34 @@ func NewSubscriptionService(store *store.Store, subscriptionManager *subscriptio
 // 参数：
 //   - id: 订阅 ID
 //
-// 返回：错误（如果有）
-func (ss *SubscriptionService) UpdateByID(id int64) error {
+// 返回：解析报告（逐行错误与不支持协议统计）和错误（如果有）
+func (ss *SubscriptionService) UpdateByID(id int64) (*subscription.ParseReport, error) {
 	if ss.subscriptionManager == nil {
-		return fmt.Errorf("订阅管理器未初始化，无法更新订阅")
+		return nil, fmt.Errorf("订阅管理器未初始化，无法更新订阅")
 	}
 	if ss.store == nil || ss.store.Subscriptions == nil {
-		return fmt.Errorf("Store 未初始化")
+		return nil, fmt.Errorf("Store 未初始化")
 	}
 
 	// 调用 SubscriptionManager 更新订阅（会更新数据库中的订阅和节点）
-	if err := ss.subscriptionManager.UpdateSubscriptionByID(id); err != nil {
-		return fmt.Errorf("更新订阅失败: %w", err)
+	report, err := ss.subscriptionManager.UpdateSubscriptionByID(id)
+	if err != nil {
+		return report, fmt.Errorf("更新订阅失败: %w", err)
 	}
 
 	// 更新后重新加载订阅数据
 	if err := ss.store.Subscriptions.Load(); err != nil {
-		return fmt.Errorf("刷新订阅数据失败: %w", err)
+		return report, fmt.Errorf("刷新订阅数据失败: %w", err)
 	}
 
 	// 同时刷新节点数据（因为订阅更新会添加/更新节点）
 	if ss.store.Nodes != nil {
 		if err := ss.store.Nodes.Load(); err != nil {
-			return fmt.Errorf("刷新节点数据失败: %w", err)
+			return report, fmt.Errorf("刷新节点数据失败: %w", err)
 		}
 	}
 
-	return nil
+	return report, nil
 }
 
 // Fetch 从 URL 获取订阅服务器列表并保存。
@@ -64,32 +66,59 @@ func (ss *SubscriptionService) UpdateByID(id int64) error {
 //   - url: 订阅 URL
 //   - label: 订阅标签（可选）
 //
-// 返回：错误（如果有）
-func (ss *SubscriptionService) Fetch(url string, label ...string) error {
+// 返回：解析报告和错误（如果有）
+func (ss *SubscriptionService) Fetch(url string, label ...string) (*subscription.ParseReport, error) {
 	if ss.subscriptionManager == nil {
-		return fmt.Errorf("订阅管理器未初始化，无法获取订阅")
+		return nil, fmt.Errorf("订阅管理器未初始化，无法获取订阅")
 	}
 	if ss.store == nil || ss.store.Subscriptions == nil {
-		return fmt.Errorf("Store 未初始化")
+		return nil, fmt.Errorf("Store 未初始化")
 	}
 
 	// 调用 SubscriptionManager 获取订阅（会更新数据库中的订阅和节点）
-	_, err := ss.subscriptionManager.FetchSubscription(url, label...)
+	_, report, err := ss.subscriptionManager.FetchSubscription(url, label...)
 	if err != nil {
-		return fmt.Errorf("获取订阅失败: %w", err)
+		return report, fmt.Errorf("获取订阅失败: %w", err)
 	}
 
 	// 获取后重新加载订阅数据
 	if err := ss.store.Subscriptions.Load(); err != nil {
-		return fmt.Errorf("刷新订阅数据失败: %w", err)
+		return report, fmt.Errorf("刷新订阅数据失败: %w", err)
 	}
 
 	// 同时刷新节点数据（因为订阅获取会添加节点）
 	if ss.store.Nodes != nil {
 		if err := ss.store.Nodes.Load(); err != nil {
-			return fmt.Errorf("刷新节点数据失败: %w", err)
+			return report, fmt.Errorf("刷新节点数据失败: %w", err)
 		}
 	}
 
-	return nil
+	return report, nil
+}
+
+// ImportShareLink 解析单条分享链接并作为独立节点（不属于任何订阅）保存。
+// 参数：
+//   - link: 分享链接，如 vmess://...、ss://...
+//
+// 返回：导入的节点和错误（如果有）
+func (ss *SubscriptionService) ImportShareLink(link string) (*model.Node, error) {
+	if ss.subscriptionManager == nil {
+		return nil, fmt.Errorf("订阅管理器未初始化，无法导入节点")
+	}
+	if ss.store == nil || ss.store.Nodes == nil {
+		return nil, fmt.Errorf("Store 未初始化")
+	}
+
+	node, err := ss.subscriptionManager.ParseShareLink(link)
+	if err != nil {
+		return nil, fmt.Errorf("解析分享链接失败: %w", err)
+	}
+	// 同一节点已在某个订阅中时改用派生 ID，作为独立节点保存而不改动订阅中的记录
+	if ss.store.Nodes.BelongsToSubscription(node.ID) {
+		node.ID = model.DeriveServerID(node.ID, "manual")
+	}
+	if err := ss.store.Nodes.Add(node); err != nil {
+		return nil, fmt.Errorf("保存节点失败: %w", err)
+	}
+	return node, nil
 }
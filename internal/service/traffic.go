@@ -0,0 +1,307 @@
+package service
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/data/binding"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// trafficPollInterval 读取 xray 流量计数器的间隔
+	trafficPollInterval = time.Second
+	// trafficPersistInterval 累计流量写入配置的间隔
+	trafficPersistInterval = time.Minute
+	// trafficHistorySize 保留的速度采样点数（约 1 分钟）
+	trafficHistorySize = 60
+
+	trafficTotalUploadKey   = "trafficTotalUpload"
+	trafficTotalDownloadKey = "trafficTotalDownload"
+)
+
+// TrafficSample 一次采样的实时速度（字节/秒）。
+type TrafficSample struct {
+	Upload   int64
+	Download int64
+	Time     time.Time
+}
+
+// TrafficService 集中读取 xray 流量计数器：计算实时速度、累计总流量并定期持久化，
+// 供流量图、状态栏等组件通过绑定或监听使用，避免各组件各自轮询。
+// xray 实例重启后计数器从零开始，服务按实例与计数回退识别重启，累计值不会丢失。
+type TrafficService struct {
+	store *store.Store
+
+	// 绑定：实时速度与累计流量（字节/秒、字节）
+	UploadSpeed   binding.Int
+	DownloadSpeed binding.Int
+	TotalUpload   binding.Int
+	TotalDownload binding.Int
+
+	mu           sync.Mutex
+	instance     *xray.XrayInstance // 上次采样的实例
+	lastUp       int64              // 上次采样时实例计数器的值
+	lastDown     int64
+	lastTime     time.Time
+	totalUp      int64
+	totalDown    int64
+	dirty        bool
+	history      []TrafficSample
+	nodeTotals   map[string][2]int64 // 本次运行中各节点的上传、下载字节数
+	listeners    map[int]func(TrafficSample)
+	nextListener int
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+	power        *PowerService // 低功耗模式下加长采样间隔，nil 表示不启用
+}
+
+// NewTrafficService 创建流量服务。
+func NewTrafficService(store *store.Store) *TrafficService {
+	return &TrafficService{
+		store:         store,
+		UploadSpeed:   binding.NewInt(),
+		DownloadSpeed: binding.NewInt(),
+		TotalUpload:   binding.NewInt(),
+		TotalDownload: binding.NewInt(),
+		listeners:     make(map[int]func(TrafficSample)),
+		nodeTotals:    make(map[string][2]int64),
+	}
+}
+
+// SetPowerService 设置低功耗模式来源：开启时每 5 秒采样一次。
+func (ts *TrafficService) SetPowerService(power *PowerService) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.power = power
+}
+
+// pollInterval 返回当前采样间隔。
+func (ts *TrafficService) pollInterval() time.Duration {
+	ts.mu.Lock()
+	power := ts.power
+	ts.mu.Unlock()
+	if power.LowPower() {
+		return lowPowerTrafficPollInterval
+	}
+	return trafficPollInterval
+}
+
+// Start 读取上次保存的累计流量，开始每秒采样 current 返回的实例（返回 nil 或未运行时速度为 0），
+// 每分钟保存累计流量；已在运行时忽略。
+func (ts *TrafficService) Start(current func() *xray.XrayInstance) {
+	ts.mu.Lock()
+	if ts.stopCh != nil {
+		ts.mu.Unlock()
+		return
+	}
+	stopCh, doneCh := make(chan struct{}), make(chan struct{})
+	ts.stopCh, ts.doneCh = stopCh, doneCh
+	ts.lastTime = time.Now()
+	if !ts.dirty {
+		ts.totalUp = ts.loadTotal(trafficTotalUploadKey)
+		ts.totalDown = ts.loadTotal(trafficTotalDownloadKey)
+	}
+	totalUp, totalDown := ts.totalUp, ts.totalDown
+	ts.mu.Unlock()
+	_ = ts.TotalUpload.Set(int(totalUp))
+	_ = ts.TotalDownload.Set(int(totalDown))
+
+	go func() {
+		defer close(doneCh)
+		interval := ts.pollInterval()
+		poll := time.NewTicker(interval)
+		persist := time.NewTicker(trafficPersistInterval)
+		defer poll.Stop()
+		defer persist.Stop()
+		for {
+			select {
+			case <-poll.C:
+				var instance *xray.XrayInstance
+				if current != nil {
+					instance = current()
+				}
+				ts.sample(instance)
+				if next := ts.pollInterval(); next != interval {
+					interval = next
+					poll.Reset(interval)
+				}
+			case <-persist.C:
+				ts.persist()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止采样并保存累计流量。
+func (ts *TrafficService) Stop() {
+	ts.mu.Lock()
+	stopCh, doneCh := ts.stopCh, ts.doneCh
+	ts.stopCh, ts.doneCh = nil, nil
+	ts.mu.Unlock()
+	if stopCh != nil {
+		close(stopCh)
+		<-doneCh
+	}
+	ts.persist()
+}
+
+// Subscribe 注册采样回调（在采样 goroutine 中调用），返回取消函数。
+func (ts *TrafficService) Subscribe(fn func(TrafficSample)) (unsubscribe func()) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	id := ts.nextListener
+	ts.nextListener++
+	ts.listeners[id] = fn
+	return func() {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		delete(ts.listeners, id)
+	}
+}
+
+// History 返回最近的速度采样（由旧到新）。
+func (ts *TrafficService) History() []TrafficSample {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]TrafficSample(nil), ts.history...)
+}
+
+// Latest 返回最近一次采样，尚未采样时为零值。
+func (ts *TrafficService) Latest() TrafficSample {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(ts.history) == 0 {
+		return TrafficSample{}
+	}
+	return ts.history[len(ts.history)-1]
+}
+
+// NodeTraffic 返回节点在本次运行中经代理传输的上传、下载字节数（按采样时选中的节点归属）。
+func (ts *TrafficService) NodeTraffic(id string) (upload, download int64) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t := ts.nodeTotals[id]
+	return t[0], t[1]
+}
+
+// Totals 返回累计上传、下载字节数。
+func (ts *TrafficService) Totals() (upload, download int64) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.totalUp, ts.totalDown
+}
+
+// ResetTotals 清零累计流量并立即保存。
+func (ts *TrafficService) ResetTotals() {
+	ts.mu.Lock()
+	ts.totalUp, ts.totalDown = 0, 0
+	ts.dirty = true
+	ts.mu.Unlock()
+	_ = ts.TotalUpload.Set(0)
+	_ = ts.TotalDownload.Set(0)
+	ts.persist()
+}
+
+// sample 读取一次计数器并更新速度、累计值与绑定。
+func (ts *TrafficService) sample(instance *xray.XrayInstance) {
+	var up, down int64
+	nodeID := ""
+	if instance != nil && instance.IsRunning() {
+		up, down = instance.TrafficStats()
+		if ts.store != nil && ts.store.Nodes != nil {
+			nodeID = ts.store.Nodes.GetSelectedID()
+		}
+	}
+
+	ts.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(ts.lastTime).Seconds()
+	if elapsed <= 0 {
+		elapsed = trafficPollInterval.Seconds()
+	}
+	// 换了实例或计数器回退（实例重启）时，新计数器从零开始，本次读数全部计为增量
+	baseUp, baseDown := ts.lastUp, ts.lastDown
+	if instance != ts.instance || up < baseUp || down < baseDown {
+		baseUp, baseDown = 0, 0
+	}
+	deltaUp, deltaDown := up-baseUp, down-baseDown
+	ts.instance, ts.lastUp, ts.lastDown, ts.lastTime = instance, up, down, now
+	if deltaUp > 0 || deltaDown > 0 {
+		ts.totalUp += deltaUp
+		ts.totalDown += deltaDown
+		ts.dirty = true
+		if nodeID != "" {
+			t := ts.nodeTotals[nodeID]
+			ts.nodeTotals[nodeID] = [2]int64{t[0] + deltaUp, t[1] + deltaDown}
+		}
+	}
+	s := TrafficSample{
+		Upload:   int64(float64(deltaUp) / elapsed),
+		Download: int64(float64(deltaDown) / elapsed),
+		Time:     now,
+	}
+	ts.history = append(ts.history, s)
+	if len(ts.history) > trafficHistorySize {
+		ts.history = ts.history[len(ts.history)-trafficHistorySize:]
+	}
+	totalUp, totalDown := ts.totalUp, ts.totalDown
+	listeners := make([]func(TrafficSample), 0, len(ts.listeners))
+	for _, fn := range ts.listeners {
+		listeners = append(listeners, fn)
+	}
+	ts.mu.Unlock()
+
+	_ = ts.UploadSpeed.Set(int(s.Upload))
+	_ = ts.DownloadSpeed.Set(int(s.Download))
+	_ = ts.TotalUpload.Set(int(totalUp))
+	_ = ts.TotalDownload.Set(int(totalDown))
+	if ts.store != nil && ts.store.ProxyStatus != nil {
+		ts.store.ProxyStatus.SetSpeeds(s.Upload, s.Download)
+	}
+	for _, fn := range listeners {
+		fn(s)
+	}
+}
+
+// persist 累计值有变化时写入配置。
+func (ts *TrafficService) persist() {
+	ts.mu.Lock()
+	if !ts.dirty || ts.store == nil || ts.store.AppConfig == nil {
+		ts.mu.Unlock()
+		return
+	}
+	up, down := ts.totalUp, ts.totalDown
+	ts.dirty = false
+	ts.mu.Unlock()
+
+	if err := ts.store.AppConfig.Set(trafficTotalUploadKey, strconv.FormatInt(up, 10)); err != nil {
+		ts.markDirty()
+		return
+	}
+	if err := ts.store.AppConfig.Set(trafficTotalDownloadKey, strconv.FormatInt(down, 10)); err != nil {
+		ts.markDirty()
+	}
+}
+
+func (ts *TrafficService) markDirty() {
+	ts.mu.Lock()
+	ts.dirty = true
+	ts.mu.Unlock()
+}
+
+func (ts *TrafficService) loadTotal(key string) int64 {
+	if ts.store == nil || ts.store.AppConfig == nil {
+		return 0
+	}
+	raw, _ := ts.store.AppConfig.GetWithDefault(key, "0")
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
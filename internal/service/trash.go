@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+)
+
+// trashPurgeInterval 回收站过期条目的清理间隔
+const trashPurgeInterval = 6 * time.Hour
+
+// TrashService 回收站：列出、恢复或永久删除已删除的订阅与节点，并定期清理超过 model.TrashRetention 的条目。
+type TrashService struct {
+	store *store.Store
+
+	mu       sync.Mutex
+	stopCh   chan struct{}
+	onPurged func(count int64)
+}
+
+// NewTrashService 创建回收站服务。
+func NewTrashService(store *store.Store) *TrashService {
+	return &TrashService{store: store}
+}
+
+// SetOnPurged 设置定期清理删除了条目时的回调。
+func (ts *TrashService) SetOnPurged(fn func(count int64)) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.onPurged = fn
+}
+
+// List 返回回收站中的条目，按删除时间倒序。
+func (ts *TrashService) List() ([]model.TrashItem, error) {
+	if ts.store == nil {
+		return nil, fmt.Errorf("回收站服务: Store 未初始化")
+	}
+	return ts.store.TrashItems()
+}
+
+// Restore 恢复条目；节点所属订阅仍在回收站时需先恢复订阅。
+func (ts *TrashService) Restore(item model.TrashItem) error {
+	if ts.store == nil {
+		return fmt.Errorf("回收站服务: Store 未初始化")
+	}
+	return ts.store.RestoreTrashItem(item)
+}
+
+// Purge 永久删除条目。
+func (ts *TrashService) Purge(item model.TrashItem) error {
+	if ts.store == nil {
+		return fmt.Errorf("回收站服务: Store 未初始化")
+	}
+	return ts.store.PurgeTrashItem(item)
+}
+
+// Empty 永久删除回收站中的全部条目。
+func (ts *TrashService) Empty() error {
+	if ts.store == nil {
+		return fmt.Errorf("回收站服务: Store 未初始化")
+	}
+	_, err := ts.store.PurgeTrashBefore(time.Now().Add(time.Minute))
+	return err
+}
+
+// PurgeExpired 永久删除超过保留期的条目，返回删除的订阅与节点数。
+func (ts *TrashService) PurgeExpired() (int64, error) {
+	if ts.store == nil {
+		return 0, fmt.Errorf("回收站服务: Store 未初始化")
+	}
+	return ts.store.PurgeTrashBefore(time.Now().Add(-model.TrashRetention))
+}
+
+// Start 立即清理一次过期条目，之后按 trashPurgeInterval 定期清理；已在运行时忽略。
+func (ts *TrashService) Start() {
+	ts.mu.Lock()
+	if ts.stopCh != nil {
+		ts.mu.Unlock()
+		return
+	}
+	ts.stopCh = make(chan struct{})
+	stopCh := ts.stopCh
+	ts.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			ts.purgeAndNotify()
+			select {
+			case <-ticker.C:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定期清理。
+func (ts *TrashService) Stop() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.stopCh != nil {
+		close(ts.stopCh)
+		ts.stopCh = nil
+	}
+}
+
+func (ts *TrashService) purgeAndNotify() {
+	count, err := ts.PurgeExpired()
+	if err != nil || count == 0 {
+		return
+	}
+	ts.mu.Lock()
+	fn := ts.onPurged
+	ts.mu.Unlock()
+	if fn != nil {
+		fn(count)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/analytics"
+	"myproxy.com/p/internal/database"
+)
+
+// usageFlushInterval 把连接中累计的时长写入数据库的间隔，意外退出时最多丢失这段时间
+const usageFlushInterval = time.Minute
+
+// UsageStatsService 在本机记录使用统计：按天、节点累计连接次数与连接时长，用于使用报告。
+// 统计只写入本机数据库，不参与 WebDAV 同步，也不会发送到任何地方；设置关闭时不记录。
+type UsageStatsService struct {
+	config *ConfigService
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+	active bool
+	node   string
+	since  time.Time // 尚未写入的连接时长的起点
+}
+
+// NewUsageStatsService 创建使用统计服务。
+func NewUsageStatsService(config *ConfigService) *UsageStatsService {
+	return &UsageStatsService{config: config}
+}
+
+// Start 开始定时写入连接时长。重复调用时忽略。
+func (us *UsageStatsService) Start() {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if us.stopCh != nil {
+		return
+	}
+	us.stopCh = make(chan struct{})
+	go us.run(us.stopCh)
+}
+
+// Stop 停止定时写入，并写入当前连接尚未记录的时长。
+func (us *UsageStatsService) Stop() {
+	us.mu.Lock()
+	if us.stopCh != nil {
+		close(us.stopCh)
+		us.stopCh = nil
+	}
+	us.mu.Unlock()
+	us.End()
+}
+
+func (us *UsageStatsService) run(stopCh chan struct{}) {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			us.mu.Lock()
+			us.flushLocked(time.Now())
+			us.mu.Unlock()
+		}
+	}
+}
+
+// Begin 代理开始新会话时调用：计一次连接并开始累计时长。
+// 已在记录时（重启 xray、切换节点）只切换节点，不重复计数。
+func (us *UsageStatsService) Begin(node string) {
+	if !us.enabled() {
+		return
+	}
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	now := time.Now()
+	if us.active {
+		us.flushLocked(now)
+		us.node = node
+		return
+	}
+	us.active, us.node, us.since = true, node, now
+	_ = database.AddUsage(now.Format(time.DateOnly), node, 1, 0)
+}
+
+// End 代理停止时调用：写入尚未记录的时长并停止累计。未在记录时忽略。
+func (us *UsageStatsService) End() {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if !us.active {
+		return
+	}
+	us.flushLocked(time.Now())
+	us.active = false
+}
+
+// flushLocked 把 since 到 now 的时长按本地日期拆分后写入数据库；设置已关闭时丢弃并停止累计。调用方需持有 mu。
+func (us *UsageStatsService) flushLocked(now time.Time) {
+	if !us.active {
+		return
+	}
+	if !us.enabled() {
+		us.active = false
+		return
+	}
+	for us.since.Before(now) {
+		y, m, d := us.since.Date()
+		end := time.Date(y, m, d+1, 0, 0, 0, 0, us.since.Location())
+		seconds := int64(end.Sub(us.since) / time.Second)
+		if end.After(now) {
+			// 当天不足一秒的部分留到下次写入
+			seconds = int64(now.Sub(us.since) / time.Second)
+			if seconds == 0 {
+				return
+			}
+			end = us.since.Add(time.Duration(seconds) * time.Second)
+		}
+		if seconds > 0 {
+			if err := database.AddUsage(us.since.Format(time.DateOnly), us.node, 0, seconds); err != nil {
+				return // 下次写入时重试
+			}
+		}
+		us.since = end
+	}
+}
+
+func (us *UsageStatsService) enabled() bool {
+	return us.config != nil && us.config.GetUsageStatsEnabled()
+}
+
+// Report 返回 year 年的使用报告，包含当前连接尚未写入的时长。
+func (us *UsageStatsService) Report(year int) (*analytics.UsageReport, error) {
+	us.mu.Lock()
+	us.flushLocked(time.Now())
+	us.mu.Unlock()
+	days, err := database.GetUsage(fmt.Sprintf("%04d-01-01", year), fmt.Sprintf("%04d-12-31", year))
+	if err != nil {
+		return nil, err
+	}
+	return analytics.BuildUsageReport(year, days), nil
+}
+
+// Years 返回有使用统计的年份，按升序。
+func (us *UsageStatsService) Years() ([]int, error) {
+	return database.GetUsageYears()
+}
+
+// Clear 清空全部使用统计；连接中时从现在起重新累计。
+func (us *UsageStatsService) Clear() error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if err := database.ClearUsage(); err != nil {
+		return err
+	}
+	us.since = time.Now()
+	return nil
+}
@@ -0,0 +1,613 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/store"
+)
+
+const (
+	// syncDocumentVersion 同步文件的格式版本，不兼容修改时递增
+	syncDocumentVersion = 1
+	// syncDocumentMaxSize 同步文件大小上限
+	syncDocumentMaxSize = 4 << 20
+	// webdavSyncFileName 地址为目录（以 / 结尾）时使用的文件名
+	webdavSyncFileName = "myproxy-sync.json"
+	// webdavSyncTimeout 单次 WebDAV 请求超时
+	webdavSyncTimeout = 30 * time.Second
+	// webdavSyncInterval 自动同步间隔
+	webdavSyncInterval = 30 * time.Minute
+	// lowPowerWebDAVSyncInterval 低功耗模式下的自动同步间隔
+	lowPowerWebDAVSyncInterval = 2 * time.Hour
+	// webdavSyncStartDelay 启动后首次自动同步的延迟，避开启动时的订阅更新与测速
+	webdavSyncStartDelay = 20 * time.Second
+)
+
+// syncedConfigKeys 参与同步的设置。端口、监听地址、入站认证、接口令牌、文件路径、窗口状态与当前选中项
+// 属于本机配置，不同步。
+var syncedConfigKeys = []string{
+	"theme", "listDensity", "largeControls", "speedUnit", "numberFormat", "trayShowSpeed", "lowPowerMode", "logLevel",
+	"notifyOnConnect", "notifyOnDisconnect", "notifyOnFailover", "sessionReportEnabled",
+	"xrayAutoRestart", "xrayAutoRestartMaxRetries",
+	"proxyType", "autoStartProxy", "terminalProxyEnabled", "gitProxyEnabled",
+	"directRoutes", "directRoutesUseProxy", "bypassLanAndCN", "finalOutbound",
+	"autoProbeSelectedNode", "autoDisableFailThreshold", "pingFreshMinutes", "latencyMethod", "scheduledPingInterval",
+	"scheduledPingQuietHours", "scheduledPingACOnly", "prewarmEnabled", "prewarmTopN",
+	"externalRefreshSeconds", "clipboardMonitorEnabled", "keepUnsupportedSSR", "accessRecordMode", "accessRecordIPs",
+	"balancerEnabled", "balancerStrategy", "observatoryProbeURL", "observatoryProbeInterval", "dnsLeakTestService",
+	"fragmentEnabled", "fragmentPackets", "fragmentLength", "fragmentInterval",
+	"policyHandshake", "policyConnIdle", "policyUplinkOnly", "policyDownlinkOnly",
+}
+
+// syncedProxyConfigKeys 影响 xray 配置的设置，从远端应用后运行中的代理需重启生效
+var syncedProxyConfigKeys = map[string]bool{
+	"directRoutes": true, "directRoutesUseProxy": true, "bypassLanAndCN": true, "finalOutbound": true,
+	"balancerEnabled": true, "balancerStrategy": true, "observatoryProbeURL": true, "observatoryProbeInterval": true,
+	"fragmentEnabled": true, "fragmentPackets": true, "fragmentLength": true, "fragmentInterval": true,
+	"policyHandshake": true, "policyConnIdle": true, "policyUplinkOnly": true, "policyDownlinkOnly": true,
+}
+
+// SyncDocument WebDAV 上的同步文件：设置与订阅列表。
+// 未开启「同步完整订阅地址」时订阅地址只保留协议与主机（Redacted），其他电脑无法据此添加订阅。
+type SyncDocument struct {
+	Version       int                 `json:"v"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Device        string              `json:"device,omitempty"`
+	Settings      map[string]string   `json:"settings"`
+	Subscriptions []ShareSubscription `json:"subscriptions,omitempty"`
+}
+
+// contentHash 设置与订阅内容的摘要，忽略更新时间与设备名。
+func (d *SyncDocument) contentHash() string {
+	raw, _ := json.Marshal(struct {
+		Settings      map[string]string   `json:"settings"`
+		Subscriptions []ShareSubscription `json:"subscriptions"`
+	}{d.Settings, d.Subscriptions})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// webdavSyncState 上次同步的状态（webdavSyncState 配置）：远端版本与当时双方一致的内容，
+// 作为三方合并的基准，以区分本机修改、远端修改与冲突。Pending 为本机无法添加（未包含凭据或拉取失败）、
+// 上传时原样保留的远端订阅，避免其他电脑将其当作已删除。
+type webdavSyncState struct {
+	ETag       string        `json:"etag"`
+	Base       *SyncDocument `json:"base,omitempty"`
+	Pending    []string      `json:"pending,omitempty"`
+	LastSyncAt time.Time     `json:"last_sync_at"`
+}
+
+// WebDAVSyncResult 一次同步的结果。
+type WebDAVSyncResult struct {
+	Pushed              bool     // 是否上传了本机内容
+	Pulled              []string // 从远端应用的设置
+	SubsAdded           []string // 从远端添加的订阅
+	SubsUpdated         []string // 按远端更新过滤或改名规则的订阅
+	SubsRemoved         []string // 远端已删除、本机移入回收站的订阅
+	Conflicts           []string // 两边都修改过的设置，保留本机的值
+	Skipped             []string // 无法应用的远端内容及原因
+	ProxyConfigChanged  bool     // 是否应用了影响 xray 配置的设置（运行中的代理需重启生效）
+	SubscriptionChanged bool     // 订阅列表是否有变化
+}
+
+// Summary 返回多行摘要。
+func (r *WebDAVSyncResult) Summary() string {
+	var lines []string
+	if len(r.Pulled) > 0 {
+		lines = append(lines, fmt.Sprintf("已应用远端设置 %d 项", len(r.Pulled)))
+	}
+	if len(r.SubsAdded) > 0 {
+		lines = append(lines, "新增订阅: "+strings.Join(r.SubsAdded, "、"))
+	}
+	if len(r.SubsUpdated) > 0 {
+		lines = append(lines, "更新订阅规则: "+strings.Join(r.SubsUpdated, "、"))
+	}
+	if len(r.SubsRemoved) > 0 {
+		lines = append(lines, "已移入回收站的订阅: "+strings.Join(r.SubsRemoved, "、"))
+	}
+	if len(r.Conflicts) > 0 {
+		lines = append(lines, "两边都修改过、已保留本机设置: "+strings.Join(r.Conflicts, "、"))
+	}
+	if len(r.Skipped) > 0 {
+		lines = append(lines, "未应用: "+strings.Join(r.Skipped, "；"))
+	}
+	if r.Pushed {
+		lines = append(lines, "已上传本机配置")
+	}
+	if len(lines) == 0 {
+		return "已是最新"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WebDAVSyncService 将设置、直连路由与订阅列表同步到用户提供的 WebDAV 地址，使多台电脑保持一致。
+// 以上次同步的内容为基准做三方合并：只有一方修改的项采用修改方，两边都修改的设置保留本机并报告冲突；
+// 订阅的增删同样按基准判断，远端删除的订阅在本机移入回收站。
+type WebDAVSyncService struct {
+	store         *store.Store
+	config        *ConfigService
+	subscriptions *SubscriptionService
+	power         *PowerService
+	client        *http.Client
+
+	syncMu sync.Mutex // 串行化同步
+
+	mu       sync.Mutex
+	stopCh   chan struct{}
+	onResult func(*WebDAVSyncResult, error)
+}
+
+// NewWebDAVSyncService 创建 WebDAV 同步服务；subscriptions 为 nil 时新增的订阅不立即拉取节点。
+func NewWebDAVSyncService(store *store.Store, config *ConfigService, subscriptions *SubscriptionService, power *PowerService) *WebDAVSyncService {
+	return &WebDAVSyncService{
+		store:         store,
+		config:        config,
+		subscriptions: subscriptions,
+		power:         power,
+		client:        &http.Client{Timeout: webdavSyncTimeout},
+	}
+}
+
+// SetOnResult 设置自动同步完成后的回调（在同步 goroutine 中调用）。
+func (ws *WebDAVSyncService) SetOnResult(fn func(*WebDAVSyncResult, error)) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.onResult = fn
+}
+
+// Start 开始定期同步；未开启同步时每次到期跳过。重复调用时忽略。
+func (ws *WebDAVSyncService) Start() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.stopCh != nil {
+		return
+	}
+	ws.stopCh = make(chan struct{})
+	go ws.run(ws.stopCh)
+}
+
+// Stop 停止定期同步。
+func (ws *WebDAVSyncService) Stop() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.stopCh != nil {
+		close(ws.stopCh)
+		ws.stopCh = nil
+	}
+}
+
+func (ws *WebDAVSyncService) run(stopCh chan struct{}) {
+	timer := time.NewTimer(webdavSyncStartDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-timer.C:
+		}
+		if ws.config != nil && ws.config.GetWebDAVSyncEnabled() {
+			result, err := ws.Sync()
+			ws.mu.Lock()
+			onResult := ws.onResult
+			ws.mu.Unlock()
+			if onResult != nil {
+				onResult(result, err)
+			}
+		}
+		interval := webdavSyncInterval
+		if ws.power.LowPower() {
+			interval = lowPowerWebDAVSyncInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// LastSyncAt 返回上次成功同步的时间，从未同步时为零值。
+func (ws *WebDAVSyncService) LastSyncAt() time.Time {
+	return ws.loadState().LastSyncAt
+}
+
+// Sync 立即同步一次：下载远端文件，远端有变化时合并到本机，本机内容与远端不同时上传。
+// 会访问网络，须在后台 goroutine 中调用。
+func (ws *WebDAVSyncService) Sync() (*WebDAVSyncResult, error) {
+	ws.syncMu.Lock()
+	defer ws.syncMu.Unlock()
+	if ws.config == nil || ws.store == nil || ws.store.Subscriptions == nil {
+		return nil, fmt.Errorf("WebDAV 同步: Store 未初始化")
+	}
+	endpoint, user, pass := ws.config.GetWebDAVEndpoint()
+	if endpoint == "" {
+		return nil, fmt.Errorf("WebDAV 同步: 未设置 WebDAV 地址")
+	}
+	fileURL := webdavFileURL(endpoint)
+
+	remote, etag, err := ws.download(fileURL, user, pass)
+	if err != nil {
+		return nil, err
+	}
+	state := ws.loadState()
+	pending := make(map[string]bool, len(state.Pending))
+	for _, key := range state.Pending {
+		pending[key] = true
+	}
+	var carried []ShareSubscription
+	if state.Base != nil {
+		for _, s := range state.Base.Subscriptions {
+			if pending[syncSubscriptionKey(s)] {
+				carried = append(carried, s)
+			}
+		}
+	}
+	result := &WebDAVSyncResult{}
+	local := ws.buildDocument()
+	if remote != nil && (state.Base == nil || etag != state.ETag) {
+		carried = ws.merge(state.Base, local, remote, pending, result)
+		local = ws.buildDocument()
+	}
+	local.Subscriptions = append(local.Subscriptions, carried...)
+	sortSyncSubscriptions(local.Subscriptions)
+
+	base := remote
+	if remote == nil || local.contentHash() != remote.contentHash() {
+		newETag, err := ws.upload(fileURL, user, pass, local, etag, remote == nil)
+		if err != nil {
+			return result, err
+		}
+		etag, base = newETag, local
+		result.Pushed = true
+	}
+	state = webdavSyncState{ETag: etag, Base: base, LastSyncAt: time.Now()}
+	for _, s := range carried {
+		state.Pending = append(state.Pending, syncSubscriptionKey(s))
+	}
+	if err := ws.saveState(state); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// buildDocument 读取本机需同步的设置与订阅。
+func (ws *WebDAVSyncService) buildDocument() *SyncDocument {
+	doc := &SyncDocument{
+		Version:   syncDocumentVersion,
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+		Settings:  make(map[string]string, len(syncedConfigKeys)),
+	}
+	doc.Device, _ = os.Hostname()
+	for _, key := range syncedConfigKeys {
+		v, _ := ws.config.GetWithDefault(key, database.AppConfigBuiltinDefault(key))
+		doc.Settings[key] = v
+	}
+	includeCredentials := ws.config.GetWebDAVSyncCredentials()
+	for _, sub := range ws.store.Subscriptions.GetAll() {
+		shared := ShareSubscription{
+			Label:         sub.Label,
+			URL:           sub.URL,
+			IncludeFilter: sub.IncludeFilter,
+			ExcludeFilter: sub.ExcludeFilter,
+			RenameRules:   sub.RenameRules,
+			TestURL:       sub.TestURL,
+		}
+		if !includeCredentials {
+			shared.URL = redactSubscriptionURL(sub.URL)
+			shared.Redacted = true
+		}
+		doc.Subscriptions = append(doc.Subscriptions, shared)
+	}
+	sortSyncSubscriptions(doc.Subscriptions)
+	return doc
+}
+
+// sortSyncSubscriptions 按标识排序，使内容摘要与订阅的添加顺序无关。
+func sortSyncSubscriptions(subs []ShareSubscription) {
+	sort.SliceStable(subs, func(i, j int) bool {
+		return syncSubscriptionKey(subs[i]) < syncSubscriptionKey(subs[j])
+	})
+}
+
+// syncSubscriptionKey 订阅在同步中的标识：名称与服务商主机。是否包含凭据不影响标识，
+// 两台电脑的凭据设置不同时也能对应到同一订阅。
+func syncSubscriptionKey(s ShareSubscription) string {
+	return s.Label + "|" + redactSubscriptionURL(s.URL)
+}
+
+// sameSubscriptionRules 过滤、改名规则与测速地址是否一致。
+func sameSubscriptionRules(a, b ShareSubscription) bool {
+	return a.IncludeFilter == b.IncludeFilter && a.ExcludeFilter == b.ExcludeFilter && a.RenameRules == b.RenameRules && a.TestURL == b.TestURL
+}
+
+func syncSubscriptionMap(doc *SyncDocument) map[string]ShareSubscription {
+	m := make(map[string]ShareSubscription)
+	if doc == nil {
+		return m
+	}
+	for _, s := range doc.Subscriptions {
+		if _, ok := m[syncSubscriptionKey(s)]; !ok {
+			m[syncSubscriptionKey(s)] = s
+		}
+	}
+	return m
+}
+
+// merge 以 base 为基准将远端修改应用到本机，返回本机无法添加、上传时需保留的远端订阅。
+// base 为 nil（首次同步）时远端设置优先，订阅只增不删；pending 为上次已保留的订阅，不视为本机删除。
+func (ws *WebDAVSyncService) merge(base, local, remote *SyncDocument, pending map[string]bool, result *WebDAVSyncResult) []ShareSubscription {
+	firstSync := base == nil
+	if firstSync {
+		base = &SyncDocument{}
+	}
+
+	for _, key := range syncedConfigKeys {
+		r, ok := remote.Settings[key]
+		l := local.Settings[key]
+		if !ok || r == l {
+			continue
+		}
+		b, inBase := base.Settings[key]
+		switch {
+		case firstSync || (inBase && l == b):
+			if err := ws.config.Set(key, r); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("设置 %s: %v", key, err))
+				continue
+			}
+			result.Pulled = append(result.Pulled, key)
+			if syncedProxyConfigKeys[key] {
+				result.ProxyConfigChanged = true
+			}
+		case !inBase || r != b:
+			result.Conflicts = append(result.Conflicts, key)
+		}
+	}
+
+	baseSubs, localSubs, remoteSubs := syncSubscriptionMap(base), syncSubscriptionMap(local), syncSubscriptionMap(remote)
+	var carried []ShareSubscription
+	localIDs := make(map[string]int64)
+	for _, sub := range ws.store.Subscriptions.GetAll() {
+		shared := ShareSubscription{Label: sub.Label, URL: sub.URL}
+		localIDs[syncSubscriptionKey(shared)] = sub.ID
+	}
+	for _, r := range remote.Subscriptions {
+		key := syncSubscriptionKey(r)
+		name := r.Label
+		if name == "" {
+			name = redactSubscriptionURL(r.URL)
+		}
+		l, inLocal := localSubs[key]
+		b, inBase := baseSubs[key]
+		switch {
+		case !inLocal && inBase && !firstSync && !pending[key]:
+			// 本机已删除，上传时一并从远端移除
+		case !inLocal:
+			if r.Redacted {
+				carried = append(carried, r)
+				if !pending[key] {
+					result.Skipped = append(result.Skipped, fmt.Sprintf("订阅 %s 未包含凭据，需在本机手动添加", name))
+				}
+				continue
+			}
+			if err := ws.addSubscription(r); err != nil {
+				carried = append(carried, r)
+				result.Skipped = append(result.Skipped, fmt.Sprintf("订阅 %s: %v", name, err))
+				continue
+			}
+			result.SubsAdded = append(result.SubsAdded, name)
+			result.SubscriptionChanged = true
+		case !sameSubscriptionRules(l, r) && (firstSync || (inBase && sameSubscriptionRules(l, b))):
+			id := localIDs[key]
+			if err := ws.store.Subscriptions.UpdateFilters(id, r.IncludeFilter, r.ExcludeFilter); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("订阅 %s: %v", name, err))
+				continue
+			}
+			if err := ws.store.Subscriptions.UpdateRenameRules(id, r.RenameRules); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("订阅 %s: %v", name, err))
+				continue
+			}
+			if err := ws.store.Subscriptions.UpdateTestURL(id, r.TestURL); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("订阅 %s: %v", name, err))
+				continue
+			}
+			result.SubsUpdated = append(result.SubsUpdated, name)
+			result.SubscriptionChanged = true
+		}
+	}
+	if firstSync {
+		return carried
+	}
+	for key, l := range localSubs {
+		b, inBase := baseSubs[key]
+		if _, inRemote := remoteSubs[key]; inRemote || !inBase || !sameSubscriptionRules(l, b) {
+			continue
+		}
+		if err := ws.store.Subscriptions.Delete(localIDs[key]); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("删除订阅 %s: %v", l.Label, err))
+			continue
+		}
+		result.SubsRemoved = append(result.SubsRemoved, l.Label)
+		result.SubscriptionChanged = true
+	}
+	sort.Strings(result.SubsRemoved)
+	return carried
+}
+
+// addSubscription 添加远端的订阅并写入过滤、改名规则后拉取节点。
+func (ws *WebDAVSyncService) addSubscription(shared ShareSubscription) error {
+	ps := &ProfileShareService{store: ws.store, config: ws.config, subscriptions: ws.subscriptions}
+	return ps.addSubscription(shared)
+}
+
+// webdavFileURL 地址以 / 结尾时视为目录，拼接默认文件名。
+func webdavFileURL(endpoint string) string {
+	if strings.HasSuffix(endpoint, "/") {
+		return endpoint + webdavSyncFileName
+	}
+	return endpoint
+}
+
+func (ws *WebDAVSyncService) newRequest(method, target, user, pass string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV 同步: 创建请求失败: %w", err)
+	}
+	if user != "" || pass != "" {
+		req.SetBasicAuth(user, pass)
+	}
+	return req, nil
+}
+
+// download 下载同步文件；文件不存在时返回 nil。版本号优先取 ETag，服务器不提供时以内容摘要代替。
+func (ws *WebDAVSyncService) download(fileURL, user, pass string) (*SyncDocument, string, error) {
+	req, err := ws.newRequest(http.MethodGet, fileURL, user, pass, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("WebDAV 同步: 下载失败: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, "", nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, "", fmt.Errorf("WebDAV 同步: 认证失败（HTTP %d），请检查用户名与密码", resp.StatusCode)
+	default:
+		return nil, "", fmt.Errorf("WebDAV 同步: 下载失败: HTTP %d", resp.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, syncDocumentMaxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("WebDAV 同步: 下载失败: %w", err)
+	}
+	if len(raw) > syncDocumentMaxSize {
+		return nil, "", fmt.Errorf("WebDAV 同步: 远端文件过大")
+	}
+	var doc SyncDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, "", fmt.Errorf("WebDAV 同步: 远端文件无效: %w", err)
+	}
+	if doc.Version > syncDocumentVersion {
+		return nil, "", fmt.Errorf("WebDAV 同步: 远端文件由更新版本的应用写入（格式版本 %d），请先升级", doc.Version)
+	}
+	return &doc, documentVersion(resp.Header.Get("ETag"), raw), nil
+}
+
+// upload 上传同步文件并返回新版本号。带上下载时的 ETag（If-Match）或 If-None-Match，
+// 远端在此期间被其他电脑修改时返回错误，下次同步重新合并。父目录不存在（409）时尝试创建一次。
+func (ws *WebDAVSyncService) upload(fileURL, user, pass string, doc *SyncDocument, etag string, create bool) (string, error) {
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("WebDAV 同步: 编码失败: %w", err)
+	}
+	put := func() (*http.Response, error) {
+		req, err := ws.newRequest(http.MethodPut, fileURL, user, pass, raw)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		switch {
+		case create:
+			req.Header.Set("If-None-Match", "*")
+		case etag != "" && !strings.HasPrefix(etag, "sha256:"):
+			req.Header.Set("If-Match", etag)
+		}
+		resp, err := ws.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("WebDAV 同步: 上传失败: %w", err)
+		}
+		resp.Body.Close()
+		return resp, nil
+	}
+	resp, err := put()
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusConflict {
+		if err := ws.mkcol(fileURL, user, pass); err != nil {
+			return "", err
+		}
+		if resp, err = put(); err != nil {
+			return "", err
+		}
+	}
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return "", fmt.Errorf("WebDAV 同步: 远端在同步期间被其他设备修改，请稍后重试")
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("WebDAV 同步: 认证失败（HTTP %d），请检查用户名与密码", resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return "", fmt.Errorf("WebDAV 同步: 上传失败: HTTP %d", resp.StatusCode)
+	}
+	return documentVersion(resp.Header.Get("ETag"), raw), nil
+}
+
+// mkcol 创建同步文件所在的目录（只创建最后一级）。
+func (ws *WebDAVSyncService) mkcol(fileURL, user, pass string) error {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return fmt.Errorf("WebDAV 同步: 地址无效: %w", err)
+	}
+	u.Path = path.Dir(u.Path) + "/"
+	req, err := ws.newRequest("MKCOL", u.String(), user, pass, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("WebDAV 同步: 创建目录失败: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("WebDAV 同步: 创建目录失败: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// documentVersion 远端文件的版本号：ETag，或服务器不提供 ETag 时的内容摘要。
+func documentVersion(etag string, raw []byte) string {
+	if etag = strings.TrimSpace(etag); etag != "" {
+		return etag
+	}
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (ws *WebDAVSyncService) loadState() webdavSyncState {
+	var state webdavSyncState
+	if ws.config == nil {
+		return state
+	}
+	raw, _ := ws.config.GetWithDefault("webdavSyncState", "")
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &state)
+	}
+	return state
+}
+
+func (ws *WebDAVSyncService) saveState(state webdavSyncState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("WebDAV 同步: 保存同步状态失败: %w", err)
+	}
+	if err := ws.config.Set("webdavSyncState", string(raw)); err != nil {
+		return fmt.Errorf("WebDAV 同步: 保存同步状态失败: %w", err)
+	}
+	return nil
+}
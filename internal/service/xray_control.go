@@ -1,19 +1,35 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/xray"
 )
 
+// xrayReadyTimeout 启动后等待本地入站可连接的最长时间，超时视为启动失败
+const xrayReadyTimeout = 3 * time.Second
+
 // XrayControlService 代理控制服务层，提供 xray 代理启动和停止的业务逻辑。
 type XrayControlService struct {
 	store          *store.Store
 	config         *ConfigService
 	logCallback    func(level, message string)      // 应用级消息（如启动成功）
 	rawLogCallback func(level, rawLine string)     // xray 劫持的原始日志行：落盘、展示、解析
+	power          *PowerService                   // 低功耗模式下加长负载均衡组的探测间隔
+	servers        *ServerService                  // 节点原因导致的启动失败计入连续失败次数
+	balancerNodes  []*model.Node                   // 最近一次启动的负载均衡组节点，未启用均衡组时为 nil
+	lastConfig     []byte                          // 最近一次启动使用的 xray 配置 JSON
+	lastCustom     bool                            // 最近一次启动是否使用了手动编辑的配置
+	configOverride []byte                          // 手动编辑的配置，下一次启动时替换生成的配置，用后即清除
 }
 
 // NewXrayControlService 创建新的代理控制服务实例。
@@ -28,6 +44,7 @@ func NewXrayControlService(store *store.Store, config *ConfigService, logCallbac
 		config:         config,
 		logCallback:    logCallback,
 		rawLogCallback: rawLogCallback,
+		servers:        NewServerService(store, config),
 	}
 }
 
@@ -63,6 +80,19 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 		}
 	}
 
+	// 按协议能力矩阵校验节点字段，给出精确的错误而不是笼统的「创建xray配置失败」
+	if err := xray.ValidateNode(selectedNode); err != nil {
+		logMsg := fmt.Sprintf("节点配置校验失败: %v", err)
+		if xcs.logCallback != nil {
+			xcs.logCallback("ERROR", logMsg)
+		}
+		xcs.recordConnectFailure(selectedNode)
+		return &StartProxyResult{
+			LogMessage: logMsg,
+			Error:      fmt.Errorf("Xray控制服务: %w", err),
+		}
+	}
+
 	// 如果已有代理在运行，先停止并销毁实例
 	if oldInstance != nil {
 		if oldInstance.IsRunning() {
@@ -82,21 +112,11 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 		xcs.logCallback("INFO", fmt.Sprintf("开始启动xray-core代理: %s", selectedNode.Name))
 	}
 
-	// 读取直连路由配置：如果用户配置为空，则使用默认路由
-	var routing *xray.RoutingOptions
-	if xcs.config != nil {
-		routes := xcs.config.GetDirectRoutes()
-		useProxy := xcs.config.GetDirectRoutesUseProxy()
-		// 如果用户配置为空，使用默认路由
-		if len(routes) == 0 {
-			routes = xcs.config.GetDefaultDirectRoutes()
-		}
-		if len(routes) > 0 {
-			routing = &xray.RoutingOptions{
-				DirectRoutes:         routes,
-				DirectRoutesUseProxy: useProxy,
-			}
-		}
+	// 按设置组装路由选项与入站认证；负载均衡组节点留作出站与节点的对应
+	routing, auth := xcs.proxyOptions(selectedNode, xcs.logCallback)
+	xcs.balancerNodes = nil
+	if routing != nil && routing.Balancer != nil {
+		xcs.balancerNodes = routing.Balancer.Nodes
 	}
 
 	listenHost := database.LocalMixedInboundListenHost
@@ -104,13 +124,53 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 		listenHost = xcs.config.GetMixedInboundXrayListenAddress()
 	}
 
+	// 入站限速：xray 改为仅监听本机随机端口，由限速转发在原地址上接收连接
+	xrayPort, xrayHost := proxyPort, listenHost
+	var extraRelays []struct{ listen, backend string } // 同样经限速转发的局域网入站：原地址 -> xray 实际监听的本机地址
+	var rateLimit xray.RateLimitOptions
+	if xcs.config != nil {
+		rateLimit = xcs.config.GetInboundRateLimit()
+	}
+	if rateLimit.Enabled() {
+		port, err := findFreeLocalPort()
+		if err != nil {
+			return &StartProxyResult{
+				LogMessage: fmt.Sprintf("分配限速转发端口失败: %v", err),
+				Error:      fmt.Errorf("Xray控制服务: 分配限速转发端口失败: %w", err),
+			}
+		}
+		xrayPort, xrayHost = port, database.LocalMixedInboundListenHost
+		// 限速转发只转发 TCP：SOCKS5 UDP ASSOCIATE 的回复会指向客户端无法访问的本机端口，
+		// 且 UDP 不经过限速，因此关闭混合入站的 UDP，需要 UDP 的客户端会收到明确的拒绝
+		if routing == nil {
+			routing = &xray.RoutingOptions{}
+		}
+		routing.DisableMixedUDP = true
+		// 局域网入站与本地入站共用同一限速转发，否则局域网设备可以绕过带宽上限
+		for i, extra := range routing.ExtraInbounds {
+			port, err := findFreeLocalPort()
+			if err != nil {
+				return &StartProxyResult{
+					LogMessage: fmt.Sprintf("分配限速转发端口失败: %v", err),
+					Error:      fmt.Errorf("Xray控制服务: 分配限速转发端口失败: %w", err),
+				}
+			}
+			extraRelays = append(extraRelays, struct{ listen, backend string }{
+				listen:  net.JoinHostPort(extra.Listen, strconv.Itoa(extra.Port)),
+				backend: net.JoinHostPort(database.LocalMixedInboundListenHost, strconv.Itoa(port)),
+			})
+			routing.ExtraInbounds[i].Listen, routing.ExtraInbounds[i].Port = database.LocalMixedInboundListenHost, port
+		}
+	}
+
 	// 创建 xray 配置（不设日志路径，由劫持 handler 落盘）
-	xrayConfigJSON, err := xray.CreateXrayConfig(proxyPort, listenHost, selectedNode, "", routing)
+	xrayConfigJSON, err := xray.CreateXrayConfig(xrayPort, xrayHost, selectedNode, "", routing, auth)
 	if err != nil {
 		logMsg := fmt.Sprintf("创建xray配置失败: %v", err)
 		if xcs.logCallback != nil {
 			xcs.logCallback("ERROR", logMsg)
 		}
+		xcs.recordConnectFailure(selectedNode)
 		return &StartProxyResult{
 			LogMessage: logMsg,
 			Error:      fmt.Errorf("Xray控制服务: 创建xray配置失败: %w", err),
@@ -122,19 +182,49 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 		xcs.logCallback("DEBUG", fmt.Sprintf("xray配置已创建: %s", selectedNode.Name))
 	}
 
+	// 手动编辑的配置（调试用）：替换生成的配置，仅本次启动生效；入站由该配置自行决定，不再套用入站限速
+	custom := xcs.configOverride != nil
+	if custom {
+		xrayConfigJSON = xcs.configOverride
+		xcs.configOverride = nil
+		rateLimit = xray.RateLimitOptions{}
+		if xcs.logCallback != nil {
+			xcs.logCallback("WARN", "正在使用手动编辑的 xray 配置启动，设置中的路由、入站等选项不再生效；再次连接或切换节点后恢复自动生成")
+		}
+	}
+	xcs.lastConfig, xcs.lastCustom = xrayConfigJSON, custom
+
 	// 创建 xray 实例的日志回调：优先用 rawLogCallback（落盘+展示+解析），否则用 logCallback
 	xrayLogCallback := xcs.rawLogCallback
 	if xrayLogCallback == nil {
 		xrayLogCallback = xcs.logCallback
 	}
 
-	// 创建xray实例，并设置日志回调（每次配置变化都需要重新创建实例）
-	xrayInstance, err := xray.NewXrayInstanceFromJSONWithCallback(xrayConfigJSON, xrayLogCallback)
+	// 创建xray实例，并设置日志回调（每次配置变化都需要重新创建实例）；外部内核以子进程运行同一份配置；
+	// 开启了内置转发的 SOCKS5 节点不启动 xray，由内置转发在同一入站地址上直接转发到上游
+	var xrayInstance *xray.XrayInstance
+	if !custom && xray.SupportsBuiltinForward(selectedNode) {
+		if xcs.logCallback != nil {
+			xcs.logCallback("INFO", "使用内置 SOCKS5 转发（未启动 xray），直连规则、分流与局域网入站等 xray 功能不生效")
+		}
+		// 内置转发只连接所选节点，不使用负载均衡组
+		xcs.balancerNodes = nil
+		xrayInstance, err = xray.NewForwarderInstance(selectedNode, net.JoinHostPort(xrayHost, strconv.Itoa(xrayPort)), auth, xrayLogCallback)
+	} else if xcs.config != nil && xcs.config.GetXrayCoreMode() == XrayCoreExternal {
+		binary := xcs.config.GetXrayBinaryPath()
+		if xcs.logCallback != nil {
+			xcs.logCallback("INFO", "使用外部 xray: "+binary)
+		}
+		xrayInstance, err = xray.NewXrayInstanceFromBinary(binary, xrayConfigJSON, xrayLogCallback)
+	} else {
+		xrayInstance, err = xray.NewXrayInstanceFromJSONWithCallback(xrayConfigJSON, xrayLogCallback)
+	}
 	if err != nil {
 		logMsg := fmt.Sprintf("创建xray实例失败: %v", err)
 		if xcs.logCallback != nil {
 			xcs.logCallback("ERROR", logMsg)
 		}
+		xcs.recordConnectFailure(selectedNode)
 		return &StartProxyResult{
 			LogMessage: logMsg,
 			Error:      fmt.Errorf("Xray控制服务: 创建xray实例失败: %w", err),
@@ -148,6 +238,7 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 		if xcs.logCallback != nil {
 			xcs.logCallback("ERROR", logMsg)
 		}
+		xcs.recordConnectFailure(selectedNode)
 		return &StartProxyResult{
 			XrayInstance: xrayInstance, // 即使启动失败，也返回实例（可能需要清理）
 			LogMessage:   logMsg,
@@ -155,6 +246,49 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 		}
 	}
 
+	if rateLimit.Enabled() {
+		listenAddr := net.JoinHostPort(listenHost, strconv.Itoa(proxyPort))
+		backendAddr := net.JoinHostPort(xrayHost, strconv.Itoa(xrayPort))
+		err := xrayInstance.StartRateLimitedInbound(listenAddr, backendAddr, rateLimit)
+		// 内置转发不运行局域网入站
+		for i := 0; err == nil && i < len(extraRelays) && !xrayInstance.IsBuiltinForward(); i++ {
+			err = xrayInstance.AddRateLimitedInbound(extraRelays[i].listen, extraRelays[i].backend)
+		}
+		if err != nil {
+			_ = xrayInstance.Stop()
+			logMsg := fmt.Sprintf("启动入站限速失败: %v", err)
+			if xcs.logCallback != nil {
+				xcs.logCallback("ERROR", logMsg)
+			}
+			return &StartProxyResult{
+				LogMessage: logMsg,
+				Error:      fmt.Errorf("Xray控制服务: 启动入站限速失败: %w", err),
+			}
+		}
+		if xcs.logCallback != nil {
+			xcs.logCallback("INFO", "已启用入站限速: "+rateLimit.String()+"（限速期间本地与局域网入站不支持 SOCKS5 UDP）")
+		}
+	}
+
+	// 就绪检测：入站可以建立连接后才报告已启动，避免界面显示已连接而首批请求失败。
+	// 手动编辑的配置可能不在该端口监听，检测失败时仅提示
+	if err := xray.WaitInboundReady(net.JoinHostPort(listenHost, strconv.Itoa(proxyPort)), xrayReadyTimeout); err != nil {
+		if !custom {
+			_ = xrayInstance.Stop()
+			logMsg := fmt.Sprintf("xray入站未就绪: %v", err)
+			if xcs.logCallback != nil {
+				xcs.logCallback("ERROR", logMsg)
+			}
+			return &StartProxyResult{
+				LogMessage: logMsg,
+				Error:      fmt.Errorf("Xray控制服务: 入站未就绪: %w", err),
+			}
+		}
+		if xcs.logCallback != nil {
+			xcs.logCallback("WARN", fmt.Sprintf("手动编辑的配置未在端口 %d 上就绪: %v", proxyPort, err))
+		}
+	}
+
 	// 启动成功，设置端口信息
 	xrayInstance.SetPort(proxyPort)
 
@@ -172,6 +306,292 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 	}
 }
 
+// recordConnectFailure 把节点配置或实例启动导致的连接失败计入节点的连续失败次数，与测速失败共用自动禁用阈值。
+// 限速端口分配、入站就绪等本机原因的失败不计入。
+func (xcs *XrayControlService) recordConnectFailure(node *model.Node) {
+	disabled, _, err := xcs.servers.RecordTestResult(node.ID, -1, false)
+	if xcs.logCallback == nil {
+		return
+	}
+	if err != nil {
+		xcs.logCallback("ERROR", fmt.Sprintf("更新节点 %s 失败次数失败: %v", node.Name, err))
+		return
+	}
+	if disabled {
+		xcs.logCallback("WARN", fmt.Sprintf("节点 %s 连续失败次数已达阈值，已自动禁用", node.Name))
+	}
+}
+
+// proxyOptions 按设置组装启动选中节点所需的路由选项（分流、负载均衡组、TLS 分片、连接超时、局域网入站、gRPC API）与入站认证。
+// logf 用于记录启用了哪些选项，为 nil 时不记录。
+func (xcs *XrayControlService) proxyOptions(selectedNode *model.Node, logf func(level, message string)) (*xray.RoutingOptions, *xray.InboundAuth) {
+	if logf == nil {
+		logf = func(string, string) {}
+	}
+
+	// 读取直连路由与分流配置
+	routing, missingGeo := xcs.routingOptions()
+	if len(missingGeo) > 0 {
+		logf("WARN", fmt.Sprintf("未找到 %s，已跳过「绕过局域网与中国大陆」规则；请将文件放到 %s，或在设置中指定 xray 资源目录", strings.Join(missingGeo, "、"), xcs.config.GetXrayAssetDir()))
+	}
+
+	// 负载均衡组：由 xray observatory 在隧道内探测，选出延迟最低（或最稳定）的节点
+	if balancer := xcs.buildBalancerOptions(selectedNode); balancer != nil {
+		if routing == nil {
+			routing = &xray.RoutingOptions{}
+		}
+		routing.Balancer = balancer
+		logf("INFO", fmt.Sprintf("已启用负载均衡组: %d 个节点，策略 %s，探测间隔 %s", len(balancer.Nodes), balancer.Strategy, balancer.ProbeInterval))
+	}
+
+	// TLS 分片：对均衡组内所有节点同样生效
+	if xcs.config != nil && xcs.config.GetFragmentEnabled() {
+		fragment := xcs.config.GetFragmentOptions()
+		if routing == nil {
+			routing = &xray.RoutingOptions{}
+		}
+		routing.Fragment = &fragment
+		logf("INFO", "已启用 TLS 分片: "+fragment.String())
+	}
+
+	// 连接超时：与 xray 默认值不同时才写入配置
+	if xcs.config != nil {
+		if policy := xcs.config.GetPolicyOptions(); !policy.IsDefault() {
+			if routing == nil {
+				routing = &xray.RoutingOptions{}
+			}
+			routing.Policy = &policy
+			logf("INFO", "连接超时策略: "+policy.String())
+		}
+	}
+
+	// 局域网入站：与本地混合入站并存，供局域网设备使用
+	if xcs.config != nil {
+		if lan, enabled := xcs.config.GetLANInbound(); enabled {
+			if routing == nil {
+				routing = &xray.RoutingOptions{}
+			}
+			routing.ExtraInbounds = append(routing.ExtraInbounds, lan)
+			logf("INFO", fmt.Sprintf("已开启局域网入站: %s %s", lan.Protocol, net.JoinHostPort(lan.Listen, strconv.Itoa(lan.Port))))
+		}
+	}
+
+	// gRPC API：仅监听本机，供流量统计、出站热切换与连接列表查询
+	if xcs.config != nil && xcs.config.GetXrayAPIEnabled() {
+		if routing == nil {
+			routing = &xray.RoutingOptions{}
+		}
+		routing.API = &xray.APIOptions{Port: xcs.config.GetXrayAPIPort()}
+		logf("INFO", fmt.Sprintf("已开启 xray gRPC API: 127.0.0.1:%d", routing.API.Port))
+	}
+
+	// 入站认证：开启后 SOCKS5 与 HTTP 均需账号，本机终端/Git 代理地址会带上同一账号
+	var auth *xray.InboundAuth
+	if xcs.config != nil {
+		if user, pass := xcs.config.InboundAuth(); pass != "" {
+			auth = &xray.InboundAuth{Username: user, Password: pass}
+		}
+	}
+	if auth == nil && routing != nil && len(routing.ExtraInbounds) > 0 {
+		logf("WARN", "局域网入站未开启入站认证，同一网络中的任何设备都可以使用该代理")
+	}
+	return routing, auth
+}
+
+// routingOptions 按配置组装路由规则选项：直连列表（用户未配置时使用默认路由）与「绕过局域网与中国大陆」。
+// 开启了绕过但缺少 geo 数据文件时跳过该规则，避免 xray 因无法加载规则而启动失败，缺失的文件名由 missingGeo 返回。
+func (xcs *XrayControlService) routingOptions() (routing *xray.RoutingOptions, missingGeo []string) {
+	if xcs.config == nil {
+		return nil, nil
+	}
+	routes := xcs.config.GetDirectRoutes()
+	if len(routes) == 0 {
+		routes = xcs.config.GetDefaultDirectRoutes()
+	}
+	if len(routes) > 0 {
+		routing = &xray.RoutingOptions{
+			DirectRoutes:         routes,
+			DirectRoutesUseProxy: xcs.config.GetDirectRoutesUseProxy(),
+		}
+	}
+	if xcs.config.GetBypassLanAndCN() {
+		if missingGeo = xray.MissingGeoAssets(); len(missingGeo) == 0 {
+			if routing == nil {
+				routing = &xray.RoutingOptions{}
+			}
+			routing.BypassLanAndCN = true
+		}
+	}
+	if final := xcs.config.GetFinalOutbound(); final != "" && final != xray.FinalOutboundProxy {
+		if routing == nil {
+			routing = &xray.RoutingOptions{}
+		}
+		routing.FinalOutbound = final
+	}
+	return routing, missingGeo
+}
+
+// xrayConfigSecretKeys xray 配置中保存凭据的字段，脱敏展示时替换为 ***
+var xrayConfigSecretKeys = map[string]bool{
+	"id": true, "password": true, "pass": true, "user": true, "auth": true,
+	"privateKey": true, "preSharedKey": true, "secretKey": true, "shortId": true,
+}
+
+// CurrentConfig 返回最近一次启动代理时使用的 xray 配置 JSON（已缩进），custom 表示该配置为手动编辑；尚未启动过时返回 nil。
+// redact 为 true 时隐藏 UUID、密码等凭据，便于复制给他人排查。
+func (xcs *XrayControlService) CurrentConfig(redact bool) (configJSON []byte, custom bool) {
+	if len(xcs.lastConfig) == 0 {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(xcs.lastConfig))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return xcs.lastConfig, xcs.lastCustom
+	}
+	if redact {
+		redactXrayConfigValue(v)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return xcs.lastConfig, xcs.lastCustom
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), xcs.lastCustom
+}
+
+// redactXrayConfigValue 递归替换配置中凭据字段的字符串值。
+func redactXrayConfigValue(v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if s, ok := child.(string); ok && s != "" && xrayConfigSecretKeys[k] {
+				val[k] = "***"
+				continue
+			}
+			redactXrayConfigValue(child)
+		}
+	case []interface{}:
+		for _, child := range val {
+			redactXrayConfigValue(child)
+		}
+	}
+}
+
+// SetConfigOverride 校验手动编辑的 xray 配置 JSON，通过后在下一次启动代理时替换自动生成的配置（仅生效一次）。
+// 配置无效时返回错误，不影响正在运行的代理。
+func (xcs *XrayControlService) SetConfigOverride(configJSON []byte) error {
+	if !json.Valid(configJSON) {
+		return fmt.Errorf("Xray控制服务: 配置不是有效的 JSON")
+	}
+	if err := xray.ValidateConfigJSON(configJSON); err != nil {
+		return fmt.Errorf("Xray控制服务: %w", err)
+	}
+	xcs.configOverride = append([]byte(nil), configJSON...)
+	return nil
+}
+
+// ValidateConfig 按当前设置与选中节点生成 xray 配置，并交给 xray 的配置加载器校验，不创建实例、不监听入站，
+// 用于在连接前发现手动填写的无效字段。依次检查节点字段、配置生成与 xray 加载，返回第一处失败：
+// *model.NodeValidationError 或 *xray.ConfigValidationError 可逐条列出问题。
+func (xcs *XrayControlService) ValidateConfig() error {
+	if xcs.store == nil || xcs.store.Nodes == nil {
+		return fmt.Errorf("Xray控制服务: Store 未初始化")
+	}
+	selectedNode := xcs.store.Nodes.GetSelected()
+	if selectedNode == nil {
+		return fmt.Errorf("Xray控制服务: 未选中服务器")
+	}
+	if err := xray.ValidateNode(selectedNode); err != nil {
+		return err
+	}
+	proxyPort := database.DefaultMixedInboundPort
+	listenHost := database.LocalMixedInboundListenHost
+	if xcs.config != nil {
+		proxyPort = xcs.config.GetLocalInboundPort()
+		listenHost = xcs.config.GetMixedInboundXrayListenAddress()
+	}
+	routing, auth := xcs.proxyOptions(selectedNode, nil)
+	configJSON, err := xray.CreateXrayConfig(proxyPort, listenHost, selectedNode, "", routing, auth)
+	if err != nil {
+		return fmt.Errorf("Xray控制服务: 创建xray配置失败: %w", err)
+	}
+	return xray.ValidateConfigJSON(configJSON)
+}
+
+// BalancerNodes 返回最近一次启动代理时负载均衡组内的节点（顺序与出站 proxy-0、proxy-1… 对应），未启用均衡组时为 nil。
+func (xcs *XrayControlService) BalancerNodes() []*model.Node {
+	return xcs.balancerNodes
+}
+
+// TestRoute 判断目标（域名或 IP）在当前路由配置下命中的规则与出站，规则与启动代理时生成的配置一致。
+func (xcs *XrayControlService) TestRoute(target string) (*xray.RouteMatch, error) {
+	routing, _ := xcs.routingOptions()
+	return xray.MatchRoute(routing, target)
+}
+
+// buildBalancerOptions 按配置组装负载均衡组：当前订阅（未选订阅时为全部节点）中已启用且校验通过的节点，
+// 选中节点排在首位作为回退出站。未开启或可用节点不足 2 个时返回 nil，按单节点启动。
+func (xcs *XrayControlService) buildBalancerOptions(selected *model.Node) *xray.BalancerOptions {
+	if xcs.config == nil || !xcs.config.GetBalancerEnabled() {
+		return nil
+	}
+
+	var candidates []*model.Node
+	if subID := int64(xcs.config.GetInt("selectedSubscriptionID")); subID > 0 {
+		nodes, err := xcs.store.Nodes.GetBySubscriptionID(subID)
+		if err != nil {
+			if xcs.logCallback != nil {
+				xcs.logCallback("WARN", fmt.Sprintf("读取订阅节点失败，负载均衡组未启用: %v", err))
+			}
+			return nil
+		}
+		candidates = nodes
+	} else {
+		candidates = xcs.store.Nodes.GetAll()
+	}
+
+	group := []*model.Node{selected}
+	skipped := 0
+	for _, node := range candidates {
+		if node == nil || node.ID == selected.ID || !node.Enabled {
+			continue
+		}
+		if err := xray.ValidateNode(node); err != nil {
+			skipped++
+			continue
+		}
+		group = append(group, node)
+	}
+	if skipped > 0 && xcs.logCallback != nil {
+		xcs.logCallback("WARN", fmt.Sprintf("负载均衡组跳过 %d 个配置不完整的节点", skipped))
+	}
+	if len(group) < 2 {
+		if xcs.logCallback != nil {
+			xcs.logCallback("WARN", "负载均衡组可用节点不足 2 个，按单节点启动")
+		}
+		return nil
+	}
+
+	probeInterval := xcs.config.GetObservatoryProbeInterval()
+	if xcs.power.LowPower() && probeInterval < lowPowerObservatoryInterval {
+		probeInterval = lowPowerObservatoryInterval
+	}
+	return &xray.BalancerOptions{
+		Nodes:         group,
+		Strategy:      xcs.config.GetBalancerStrategy(),
+		ProbeURL:      xcs.config.ProbeURLForNode(selected.ID),
+		ProbeInterval: probeInterval,
+	}
+}
+
+// SetPowerService 设置低功耗模式来源：开启时负载均衡组的隧道内探测间隔不少于 10 分钟（下次启动代理生效）。
+func (xcs *XrayControlService) SetPowerService(power *PowerService) {
+	xcs.power = power
+}
+
 // StopProxyResult 停止代理操作结果。
 type StopProxyResult struct {
 	LogMessage string // 日志消息
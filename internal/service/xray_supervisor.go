@@ -0,0 +1,195 @@
+package service
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// supervisorCheckInterval 检查 xray 入站是否存活的间隔
+	supervisorCheckInterval = 5 * time.Second
+	// supervisorProbeTimeout 单次存活检查等待入站可连接的时间
+	supervisorProbeTimeout = time.Second
+	// supervisorDeadThreshold 连续检查失败达到该次数视为实例已停止，避免瞬时繁忙误判
+	supervisorDeadThreshold = 2
+	// supervisorBackoffInitial / supervisorBackoffMax 自动重启的首次等待时间与上限，每次失败翻倍
+	supervisorBackoffInitial = 2 * time.Second
+	supervisorBackoffMax     = time.Minute
+)
+
+// SupervisorEventKind 守护事件类型
+type SupervisorEventKind int
+
+const (
+	SupervisorEventDied      SupervisorEventKind = iota // 实例意外停止（入站不再接受连接）
+	SupervisorEventRestarted                            // 自动重启成功
+	SupervisorEventGaveUp                               // 自动重启达到最大次数仍失败
+)
+
+// SupervisorEvent 守护事件。
+type SupervisorEvent struct {
+	Kind    SupervisorEventKind
+	Attempt int    // Restarted/GaveUp：已尝试的重启次数
+	Detail  string // 停止原因或最后一次重启失败的原因
+}
+
+// XraySupervisor 守护运行中的 xray 实例：定期检查本地入站能否连接，连续失败视为实例已停止，
+// 停止实例并报告事件，使界面不再显示已连接；开启自动重启时按指数退避重试，达到最大次数后放弃。
+// 重启由调用方提供的 restart 完成（须更新界面状态并对新实例重新调用 Watch）。
+type XraySupervisor struct {
+	config  *ConfigService
+	onEvent func(SupervisorEvent)
+	restart func() error
+
+	mu        sync.Mutex
+	instance  *xray.XrayInstance
+	stopCh    chan struct{} // 监控当前实例，Unwatch 或 Watch 新实例时关闭
+	restartCh chan struct{} // 自动重启进行中，用户手动启动（Watch）或 Stop 时关闭
+}
+
+// NewXraySupervisor 创建 xray 守护；onEvent 与 restart 在守护 goroutine 中调用。
+func NewXraySupervisor(config *ConfigService, onEvent func(SupervisorEvent), restart func() error) *XraySupervisor {
+	return &XraySupervisor{config: config, onEvent: onEvent, restart: restart}
+}
+
+// Watch 开始守护新启动的实例，并取消进行中的自动重启；同一实例重复调用时忽略。
+func (sv *XraySupervisor) Watch(instance *xray.XrayInstance) {
+	if instance == nil {
+		sv.Unwatch()
+		return
+	}
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if sv.instance == instance {
+		return
+	}
+	sv.cancelLocked()
+	stopCh := make(chan struct{})
+	sv.instance, sv.stopCh = instance, stopCh
+	go sv.run(instance, stopCh)
+}
+
+// Unwatch 停止守护当前实例（用户主动停止代理时调用）；不影响已开始的自动重启。
+func (sv *XraySupervisor) Unwatch() {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if sv.stopCh != nil {
+		close(sv.stopCh)
+		sv.stopCh = nil
+	}
+	sv.instance = nil
+}
+
+// Stop 停止守护并取消自动重启（退出时调用）。
+func (sv *XraySupervisor) Stop() {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	sv.cancelLocked()
+}
+
+func (sv *XraySupervisor) cancelLocked() {
+	if sv.stopCh != nil {
+		close(sv.stopCh)
+		sv.stopCh = nil
+	}
+	if sv.restartCh != nil {
+		close(sv.restartCh)
+		sv.restartCh = nil
+	}
+	sv.instance = nil
+}
+
+func (sv *XraySupervisor) emit(event SupervisorEvent) {
+	if sv.onEvent != nil {
+		sv.onEvent(event)
+	}
+}
+
+func (sv *XraySupervisor) run(instance *xray.XrayInstance, stopCh chan struct{}) {
+	ticker := time.NewTicker(supervisorCheckInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		if !instance.IsRunning() {
+			return
+		}
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(instance.GetPort()))
+		err := xray.WaitInboundReady(addr, supervisorProbeTimeout)
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if failures++; failures < supervisorDeadThreshold {
+			continue
+		}
+
+		// 用户停止代理与本轮检查并发时以用户操作为准
+		sv.mu.Lock()
+		if sv.stopCh != stopCh {
+			sv.mu.Unlock()
+			return
+		}
+		sv.stopCh, sv.instance = nil, nil
+		var restartCh chan struct{}
+		if sv.restart != nil && sv.config != nil && sv.config.GetXrayAutoRestart() {
+			restartCh = make(chan struct{})
+			sv.restartCh = restartCh
+		}
+		sv.mu.Unlock()
+
+		_ = instance.Stop()
+		sv.emit(SupervisorEvent{Kind: SupervisorEventDied, Detail: err.Error()})
+		if restartCh != nil {
+			sv.autoRestart(restartCh)
+		}
+		return
+	}
+}
+
+// autoRestart 按指数退避调用 restart，直到成功、达到最大次数或被取消。
+func (sv *XraySupervisor) autoRestart(restartCh chan struct{}) {
+	maxRetries := sv.config.GetXrayAutoRestartMaxRetries()
+	backoff := supervisorBackoffInitial
+	var lastErr error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		select {
+		case <-restartCh:
+			return
+		case <-time.After(backoff):
+		}
+		// restart 成功时会对新实例调用 Watch 并关闭 restartCh，因此先取出状态再判断
+		sv.mu.Lock()
+		cancelled := sv.restartCh != restartCh
+		sv.mu.Unlock()
+		if cancelled {
+			return
+		}
+		if lastErr = sv.restart(); lastErr == nil {
+			sv.emit(SupervisorEvent{Kind: SupervisorEventRestarted, Attempt: attempt})
+			return
+		}
+		if backoff *= 2; backoff > supervisorBackoffMax {
+			backoff = supervisorBackoffMax
+		}
+	}
+
+	sv.mu.Lock()
+	if sv.restartCh == restartCh {
+		sv.restartCh = nil
+	}
+	sv.mu.Unlock()
+	detail := ""
+	if lastErr != nil {
+		detail = lastErr.Error()
+	}
+	sv.emit(SupervisorEvent{Kind: SupervisorEventGaveUp, Attempt: maxRetries, Detail: detail})
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+)
+
+// hostnameLabelPattern 域名中的单个标签：字母、数字、连字符（不在首尾），兼容部分服务商使用的下划线
+var hostnameLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]{0,61}[A-Za-z0-9_])?$`)
+
+// knownNodeProtocols 存储接受的协议类型（ssr 仅以禁用状态保留，能否启动由 xray.ValidateNode 判断）
+var knownNodeProtocols = map[string]bool{"socks5": true, "vmess": true, "ss": true, "ssr": true, "trojan": true}
+
+// SanitizeNode 规范化用户输入或订阅解析得到的节点字段：去除首尾空白、协议名转小写、
+// 去掉 IPv6 地址两侧的方括号。
+func SanitizeNode(node *model.Node) {
+	if node == nil {
+		return
+	}
+	node.Name = strings.TrimSpace(node.Name)
+	node.Addr = strings.Trim(strings.TrimSpace(node.Addr), "[]")
+	node.ProtocolType = strings.ToLower(strings.TrimSpace(node.ProtocolType))
+	node.VMessUUID = strings.TrimSpace(node.VMessUUID)
+	node.SSMethod = strings.TrimSpace(node.SSMethod)
+}
+
+// ValidateNode 校验节点写入存储前的字段：协议类型、地址语法，以及与启动代理前相同的基本字段规则（model.NodeFieldProblems）。
+// 协议能力（传输方式、加密方式是否被 xray 支持）在启动代理时由 xray.ValidateNode 检查。
+// 返回：校验失败时返回 *model.NodeValidationError，通过返回 nil
+func ValidateNode(node *model.Node) error {
+	if node == nil {
+		return fmt.Errorf("节点存储: 节点为空")
+	}
+
+	var problems []string
+	switch {
+	case node.ProtocolType == "":
+		problems = append(problems, "未指定协议类型")
+	case !knownNodeProtocols[node.ProtocolType]:
+		problems = append(problems, fmt.Sprintf("不支持的协议类型 %q，可选 socks5、vmess、ss、trojan", node.ProtocolType))
+	}
+	if node.Addr != "" && !validNodeHost(node.Addr) {
+		problems = append(problems, fmt.Sprintf("地址 %q 不是有效的域名或 IP，请只填写主机名，不要包含协议头、端口或路径", node.Addr))
+	}
+	problems = append(problems, model.NodeFieldProblems(node)...)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &model.NodeValidationError{
+		NodeName: node.Name,
+		Protocol: node.ProtocolType,
+		Problems: problems,
+	}
+}
+
+// validNodeHost 判断地址是否为 IP 或语法合法的域名（总长不超过 253，每段 1-63 个字符）。
+func validNodeHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !hostnameLabelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
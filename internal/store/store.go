@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,23 +18,25 @@ import (
 )
 
 type Store struct {
-	initialized   bool
-	Nodes         *NodesStore
-	Subscriptions *SubscriptionsStore
-	Layout        *LayoutStore
-	AppConfig     *AppConfigStore
-	ProxyStatus   *ProxyStatusStore
-	AccessRecords *AccessRecordsStore
+	initialized    bool
+	Nodes          *NodesStore
+	Subscriptions  *SubscriptionsStore
+	Layout         *LayoutStore
+	AppConfig      *AppConfigStore
+	ProxyStatus    *ProxyStatusStore
+	AccessRecords  *AccessRecordsStore
+	ProcessRecords *ProcessRecordsStore
 }
 
 func NewStore(subscriptionManager *subscription.SubscriptionManager) *Store {
 	s := &Store{
-		Nodes:         NewNodesStore(),
-		Subscriptions: NewSubscriptionsStore(subscriptionManager),
-		Layout:        NewLayoutStore(),
-		AppConfig:     NewAppConfigStore(),
-		ProxyStatus:   NewProxyStatusStore(),
-		AccessRecords: NewAccessRecordsStore(),
+		Nodes:          NewNodesStore(),
+		Subscriptions:  NewSubscriptionsStore(subscriptionManager),
+		Layout:         NewLayoutStore(),
+		AppConfig:      NewAppConfigStore(),
+		ProxyStatus:    NewProxyStatusStore(),
+		AccessRecords:  NewAccessRecordsStore(),
+		ProcessRecords: NewProcessRecordsStore(),
 	}
 	s.Subscriptions.setParentStore(s)
 	return s
@@ -52,6 +55,104 @@ func (s *Store) LoadAll() {
 	s.initialized = true
 }
 
+// TrashItems 返回回收站中的订阅与节点。
+func (s *Store) TrashItems() ([]model.TrashItem, error) {
+	items, err := database.GetTrashItems()
+	if err != nil {
+		return nil, fmt.Errorf("回收站: %w", err)
+	}
+	return items, nil
+}
+
+// RestoreTrashItem 从回收站恢复条目并刷新订阅与节点数据。
+func (s *Store) RestoreTrashItem(item model.TrashItem) error {
+	var err error
+	switch item.Kind {
+	case model.TrashKindSubscription:
+		err = database.RestoreSubscription(item.SubscriptionID)
+	case model.TrashKindNode:
+		err = database.RestoreServer(item.NodeID)
+	default:
+		err = fmt.Errorf("未知条目类型: %s", item.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("回收站: 恢复失败: %w", err)
+	}
+	s.reloadNodesAndSubscriptions()
+	return nil
+}
+
+// PurgeTrashItem 永久删除回收站中的条目。
+func (s *Store) PurgeTrashItem(item model.TrashItem) error {
+	var err error
+	switch item.Kind {
+	case model.TrashKindSubscription:
+		err = database.DeleteSubscription(item.SubscriptionID)
+	case model.TrashKindNode:
+		err = database.DeleteServer(item.NodeID)
+	default:
+		err = fmt.Errorf("未知条目类型: %s", item.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("回收站: 永久删除失败: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrashBefore 永久删除 cutoff 之前移入回收站的条目，返回删除的订阅与节点数。
+func (s *Store) PurgeTrashBefore(cutoff time.Time) (int64, error) {
+	n, err := database.PurgeTrashBefore(cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("回收站: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Store) reloadNodesAndSubscriptions() {
+	if s.Subscriptions != nil {
+		_ = s.Subscriptions.Load()
+	}
+	if s.Nodes != nil {
+		_ = s.Nodes.Load()
+	}
+}
+
+// ReloadIfChanged 从数据库读取节点与订阅，与内存中的数据比对，有差异时重新加载并更新绑定。
+// 用于其他进程修改数据库后同步界面；内容相同时不更新绑定，避免本进程自身的写入引起列表重绘。
+func (s *Store) ReloadIfChanged() (nodesChanged, subscriptionsChanged bool, err error) {
+	if s.Nodes != nil {
+		nodes, err := database.GetAllServers()
+		if err != nil {
+			return false, false, fmt.Errorf("节点存储: 加载节点列表失败: %w", err)
+		}
+		if nodesChanged = !s.Nodes.sameAs(nodes); nodesChanged {
+			if err := s.Nodes.Load(); err != nil {
+				return nodesChanged, false, err
+			}
+		}
+	}
+	if s.Subscriptions != nil {
+		subs, err := database.GetAllSubscriptions()
+		if err != nil {
+			return nodesChanged, false, fmt.Errorf("订阅存储: 加载订阅列表失败: %w", err)
+		}
+		if subscriptionsChanged = !s.Subscriptions.sameAs(subs); subscriptionsChanged {
+			if err := s.Subscriptions.Load(); err != nil {
+				return nodesChanged, subscriptionsChanged, err
+			}
+		}
+	}
+	return nodesChanged, subscriptionsChanged, nil
+}
+
+// truncTime 将时间统一为 UTC 秒精度，比较内存与数据库中的数据时忽略时区、单调时钟与存储精度的差异。
+func truncTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return time.Unix(t.Unix(), 0).UTC()
+}
+
 func (s *Store) IsInitialized() bool {
 	return s.initialized
 }
@@ -65,6 +166,7 @@ type NodesStore struct {
 	nodes            []*model.Node
 	NodesBinding     binding.UntypedList
 	selectedServerID string
+	revision         uint64 // 每次更新绑定时递增，界面据此判断节点数据是否变化
 }
 
 func NewNodesStore() *NodesStore {
@@ -105,15 +207,63 @@ func (ns *NodesStore) Load() error {
 }
 
 func (ns *NodesStore) updateBinding() {
-	ns.mu.RLock()
+	ns.mu.Lock()
+	ns.revision++
 	items := make([]any, len(ns.nodes))
 	for i, node := range ns.nodes {
 		items[i] = node
 	}
-	ns.mu.RUnlock()
+	ns.mu.Unlock()
 	_ = ns.NodesBinding.Set(items)
 }
 
+// Revision 返回节点数据的版本号，节点列表或任一节点变化后递增。
+func (ns *NodesStore) Revision() uint64 {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	return ns.revision
+}
+
+// patchNode 在内存中更新单个节点（替换为修改后的副本，已取出的节点指针不受影响），
+// 用于测速等高频更新，避免每次都从数据库重新加载全部节点。节点不在内存中时回退为重新加载。
+func (ns *NodesStore) patchNode(id string, fn func(*model.Node)) error {
+	ns.mu.Lock()
+	found := false
+	for i, node := range ns.nodes {
+		if node.ID == id {
+			updated := *node
+			fn(&updated)
+			ns.nodes[i] = &updated
+			found = true
+			break
+		}
+	}
+	ns.mu.Unlock()
+	if !found {
+		return ns.Load()
+	}
+	ns.updateBinding()
+	return nil
+}
+
+// sameAs 判断内存中的节点列表与 nodes（数据库中读出的）内容是否一致，顺序不同也视为变化。
+func (ns *NodesStore) sameAs(nodes []model.Node) bool {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if len(nodes) != len(ns.nodes) {
+		return false
+	}
+	for i := range nodes {
+		a, b := *ns.nodes[i], nodes[i]
+		a.TestedAt, b.TestedAt = truncTime(a.TestedAt), truncTime(b.TestedAt)
+		a.DisabledAt, b.DisabledAt = truncTime(a.DisabledAt), truncTime(b.DisabledAt)
+		if !reflect.DeepEqual(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
 func (ns *NodesStore) GetAll() []*model.Node {
 	ns.mu.RLock()
 	defer ns.mu.RUnlock()
@@ -174,24 +324,75 @@ func (ns *NodesStore) UpdateDelay(id string, delay int) error {
 	if err := database.UpdateServerDelay(id, delay); err != nil {
 		return fmt.Errorf("节点存储: 更新节点延迟失败: %w", err)
 	}
+	now := time.Now()
+	return ns.patchNode(id, func(n *model.Node) {
+		n.Delay = delay
+		n.TestedAt = now
+	})
+}
+
+// DelayHistory 返回 since 之后的测速历史，按节点 ID 分组。
+func (ns *NodesStore) DelayHistory(since time.Time) (map[string][]model.DelaySample, error) {
+	history, err := database.GetDelayHistory(since)
+	if err != nil {
+		return nil, fmt.Errorf("节点存储: %w", err)
+	}
+	return history, nil
+}
+
+// UpdateHealth 更新节点的连续失败次数与自动禁用状态。
+func (ns *NodesStore) UpdateHealth(id string, failCount int, enabled bool, disabledReason string, disabledAt time.Time) error {
+	if err := database.UpdateServerHealth(id, failCount, enabled, disabledReason, disabledAt); err != nil {
+		return fmt.Errorf("节点存储: 更新节点健康状态失败: %w", err)
+	}
+	return ns.patchNode(id, func(n *model.Node) {
+		n.FailCount = failCount
+		n.Enabled = enabled
+		n.DisabledReason = disabledReason
+		n.DisabledAt = disabledAt
+	})
+}
+
+// UpdateDialOptions 更新节点的拨号选项。
+func (ns *NodesStore) UpdateDialOptions(id string, opts model.DialOptions) error {
+	if err := database.UpdateServerDialOptions(id, opts); err != nil {
+		return fmt.Errorf("节点存储: 更新节点拨号选项失败: %w", err)
+	}
 	return ns.Load()
 }
 
+// Delete 将节点移入回收站，保留 model.TrashRetention 后永久删除。
 func (ns *NodesStore) Delete(id string) error {
-	if err := database.DeleteServer(id); err != nil {
+	if err := database.TrashServer(id); err != nil {
 		return fmt.Errorf("节点存储: 删除节点失败: %w", err)
 	}
 	return ns.Load()
 }
 
+// Add 规范化并校验节点后保存，字段无效时返回包含逐条说明的错误。
 func (ns *NodesStore) Add(node *model.Node) error {
+	SanitizeNode(node)
+	if err := ValidateNode(node); err != nil {
+		return fmt.Errorf("节点存储: %w", err)
+	}
 	if err := database.AddOrUpdateServer(*node, nil); err != nil {
 		return fmt.Errorf("节点存储: 添加节点失败: %w", err)
 	}
 	return ns.Load()
 }
 
+// BelongsToSubscription 节点 ID 是否已被某个订阅的节点（含回收站中的）占用。
+func (ns *NodesStore) BelongsToSubscription(id string) bool {
+	owner, exists, err := database.GetServerSubscriptionID(id)
+	return err == nil && exists && owner != nil
+}
+
+// Update 规范化并校验节点后保存，字段无效时返回包含逐条说明的错误。
 func (ns *NodesStore) Update(node *model.Node) error {
+	SanitizeNode(node)
+	if err := ValidateNode(node); err != nil {
+		return fmt.Errorf("节点存储: %w", err)
+	}
 	if err := database.AddOrUpdateServer(*node, nil); err != nil {
 		return fmt.Errorf("节点存储: 更新节点失败: %w", err)
 	}
@@ -221,6 +422,7 @@ type SubscriptionsStore struct {
 }
 
 func NewSubscriptionsStore(subscriptionManager *subscription.SubscriptionManager) *SubscriptionsStore {
+	setNodeValidator(subscriptionManager)
 	return &SubscriptionsStore{
 		subscriptions:        make([]*database.Subscription, 0),
 		SubscriptionsBinding: binding.NewUntypedList(),
@@ -234,9 +436,20 @@ func (ss *SubscriptionsStore) setParentStore(parent *Store) {
 }
 
 func (ss *SubscriptionsStore) SetSubscriptionManager(subscriptionManager *subscription.SubscriptionManager) {
+	setNodeValidator(subscriptionManager)
 	ss.subscriptionManager = subscriptionManager
 }
 
+// setNodeValidator 让订阅导入使用与 NodesStore 相同的校验规则，无效节点以禁用状态导入并标注原因。
+func setNodeValidator(subscriptionManager *subscription.SubscriptionManager) {
+	if subscriptionManager != nil {
+		subscriptionManager.SetNodeValidator(func(node *model.Node) error {
+			SanitizeNode(node)
+			return ValidateNode(node)
+		})
+	}
+}
+
 func (ss *SubscriptionsStore) Load() error {
 	subscriptions, err := database.GetAllSubscriptions()
 	if err != nil {
@@ -271,6 +484,26 @@ func (ss *SubscriptionsStore) updateBinding() {
 	_ = ss.LabelsBinding.Set(labels)
 }
 
+// sameAs 判断内存中的订阅列表与 subs（数据库中读出的）内容是否一致。
+func (ss *SubscriptionsStore) sameAs(subs []*database.Subscription) bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	if len(subs) != len(ss.subscriptions) {
+		return false
+	}
+	for i := range subs {
+		a, b := *ss.subscriptions[i], *subs[i]
+		a.CreatedAt, b.CreatedAt = truncTime(a.CreatedAt), truncTime(b.CreatedAt)
+		a.UpdatedAt, b.UpdatedAt = truncTime(a.UpdatedAt), truncTime(b.UpdatedAt)
+		a.LastErrorAt, b.LastErrorAt = truncTime(a.LastErrorAt), truncTime(b.LastErrorAt)
+		a.LastDiff, b.LastDiff = nil, nil // 仅在更新订阅时写入，更新本身会改变 UpdatedAt
+		if !reflect.DeepEqual(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
 func (ss *SubscriptionsStore) GetAll() []*database.Subscription {
 	ss.mu.RLock()
 	defer ss.mu.RUnlock()
@@ -319,72 +552,146 @@ func (ss *SubscriptionsStore) Add(url, label string) (*database.Subscription, er
 }
 
 func (ss *SubscriptionsStore) Update(id int64, url, label string) error {
+	if err := ss.checkUnlocked(id); err != nil {
+		return err
+	}
 	if err := database.UpdateSubscriptionByID(id, url, label); err != nil {
 		return fmt.Errorf("订阅存储: 更新订阅失败: %w", err)
 	}
 	return ss.Load()
 }
 
+// UpdateFilters 更新订阅的节点包含/排除规则（正则），在下次更新订阅时生效。
+func (ss *SubscriptionsStore) UpdateFilters(id int64, include, exclude string) error {
+	if err := ss.checkUnlocked(id); err != nil {
+		return err
+	}
+	if _, err := subscription.NewNodeFilter(include, exclude); err != nil {
+		return fmt.Errorf("订阅存储: %w", err)
+	}
+	if err := database.UpdateSubscriptionFilters(id, strings.TrimSpace(include), strings.TrimSpace(exclude)); err != nil {
+		return fmt.Errorf("订阅存储: 更新过滤规则失败: %w", err)
+	}
+	return ss.Load()
+}
+
+// UpdateRenameRules 更新订阅的节点改名规则，在下次更新订阅时生效。
+func (ss *SubscriptionsStore) UpdateRenameRules(id int64, rules model.RenameRules) error {
+	if err := ss.checkUnlocked(id); err != nil {
+		return err
+	}
+	if err := subscription.ValidateRenameRules(rules); err != nil {
+		return fmt.Errorf("订阅存储: %w", err)
+	}
+	rules.RemovePattern = strings.TrimSpace(rules.RemovePattern)
+	rules.Template = strings.TrimSpace(rules.Template)
+	if err := database.UpdateSubscriptionRenameRules(id, rules); err != nil {
+		return fmt.Errorf("订阅存储: 更新改名规则失败: %w", err)
+	}
+	return ss.Load()
+}
+
+// UpdateTestURL 更新订阅的测速地址，空字符串表示使用全局探测地址。
+func (ss *SubscriptionsStore) UpdateTestURL(id int64, testURL string) error {
+	if err := ss.checkUnlocked(id); err != nil {
+		return err
+	}
+	if err := subscription.ValidateTestURL(testURL); err != nil {
+		return fmt.Errorf("订阅存储: %w", err)
+	}
+	if err := database.UpdateSubscriptionTestURL(id, strings.TrimSpace(testURL)); err != nil {
+		return fmt.Errorf("订阅存储: %w", err)
+	}
+	return ss.Load()
+}
+
+// SetLocked 锁定或解锁订阅；锁定后 Update、UpdateFilters、UpdateRenameRules、Delete 均返回错误，更新节点不受影响。
+func (ss *SubscriptionsStore) SetLocked(id int64, locked bool) error {
+	if err := database.SetSubscriptionLocked(id, locked); err != nil {
+		return fmt.Errorf("订阅存储: %w", err)
+	}
+	return ss.Load()
+}
+
+// checkUnlocked 订阅已锁定时返回错误。
+func (ss *SubscriptionsStore) checkUnlocked(id int64) error {
+	if sub, err := ss.Get(id); err == nil && sub.Locked {
+		return fmt.Errorf("订阅存储: 订阅「%s」已锁定，请先解锁", sub.Label)
+	}
+	return nil
+}
+
+// Delete 将订阅及其节点移入回收站，保留 model.TrashRetention 后永久删除。
 func (ss *SubscriptionsStore) Delete(id int64) error {
-	if err := database.DeleteSubscription(id); err != nil {
+	if err := ss.checkUnlocked(id); err != nil {
+		return err
+	}
+	if err := database.TrashSubscription(id); err != nil {
 		return fmt.Errorf("订阅存储: 删除订阅失败: %w", err)
 	}
-	return ss.Load()
+	if err := ss.Load(); err != nil {
+		return err
+	}
+	if ss.parentStore != nil && ss.parentStore.Nodes != nil {
+		return ss.parentStore.Nodes.Load()
+	}
+	return nil
 }
 
 func (ss *SubscriptionsStore) GetServerCount(id int64) (int, error) {
 	return database.GetServerCountBySubscriptionID(id)
 }
 
-func (ss *SubscriptionsStore) UpdateByID(id int64) error {
+func (ss *SubscriptionsStore) UpdateByID(id int64) (*subscription.ParseReport, error) {
 	if ss.subscriptionManager == nil {
-		return fmt.Errorf("订阅存储: 订阅管理器未初始化，无法更新订阅")
+		return nil, fmt.Errorf("订阅存储: 订阅管理器未初始化，无法更新订阅")
 	}
 
-	if err := ss.subscriptionManager.UpdateSubscriptionByID(id); err != nil {
-		return fmt.Errorf("订阅存储: 更新订阅失败: %w", err)
+	report, err := ss.subscriptionManager.UpdateSubscriptionByID(id)
+	if err != nil {
+		return report, fmt.Errorf("订阅存储: 更新订阅失败: %w", err)
 	}
 
 	if err := ss.Load(); err != nil {
-		return fmt.Errorf("订阅存储: 刷新订阅数据失败: %w", err)
+		return report, fmt.Errorf("订阅存储: 刷新订阅数据失败: %w", err)
 	}
 
 	if ss.parentStore != nil && ss.parentStore.Nodes != nil {
 		if err := ss.parentStore.Nodes.Load(); err != nil {
-			return fmt.Errorf("订阅存储: 刷新节点数据失败: %w", err)
+			return report, fmt.Errorf("订阅存储: 刷新节点数据失败: %w", err)
 		}
 	}
 	if ss.parentStore != nil && ss.parentStore.AppConfig != nil {
 		_ = ss.parentStore.AppConfig.Set("lastSubscriptionUpdateAt", time.Now().Format(time.RFC3339))
 	}
 
-	return nil
+	return report, nil
 }
 
-func (ss *SubscriptionsStore) Fetch(url string, label ...string) error {
+func (ss *SubscriptionsStore) Fetch(url string, label ...string) (*subscription.ParseReport, error) {
 	if ss.subscriptionManager == nil {
-		return fmt.Errorf("订阅存储: 订阅管理器未初始化，无法获取订阅")
+		return nil, fmt.Errorf("订阅存储: 订阅管理器未初始化，无法获取订阅")
 	}
 
-	_, err := ss.subscriptionManager.FetchSubscription(url, label...)
+	_, report, err := ss.subscriptionManager.FetchSubscription(url, label...)
 	if err != nil {
-		return fmt.Errorf("订阅存储: 获取订阅失败: %w", err)
+		return report, fmt.Errorf("订阅存储: 获取订阅失败: %w", err)
 	}
 
 	if err := ss.Load(); err != nil {
-		return fmt.Errorf("订阅存储: 刷新订阅数据失败: %w", err)
+		return report, fmt.Errorf("订阅存储: 刷新订阅数据失败: %w", err)
 	}
 
 	if ss.parentStore != nil && ss.parentStore.Nodes != nil {
 		if err := ss.parentStore.Nodes.Load(); err != nil {
-			return fmt.Errorf("订阅存储: 刷新节点数据失败: %w", err)
+			return report, fmt.Errorf("订阅存储: 刷新节点数据失败: %w", err)
 		}
 	}
 	if ss.parentStore != nil && ss.parentStore.AppConfig != nil {
 		_ = ss.parentStore.AppConfig.Set("lastSubscriptionUpdateAt", time.Now().Format(time.RFC3339))
 	}
 
-	return nil
+	return report, nil
 }
 
 type LayoutStore struct {
@@ -392,10 +699,86 @@ type LayoutStore struct {
 	ConfigBinding binding.Untyped
 }
 
+// LayoutConfig 窗口布局配置，持久化到数据库，应用重启后恢复；界面只通过 LayoutStore 的方法读写。
 type LayoutConfig struct {
 	SubscriptionOffset float64 `json:"subscriptionOffset"`
 	ServerListOffset   float64 `json:"serverListOffset"`
 	StatusOffset       float64 `json:"statusOffset"`
+	// NodeColumns 旧版保存的节点列表列设置，加载时迁移到 Pages[LayoutPageNode].Columns
+	NodeColumns []NodeColumnConfig `json:"nodeColumns,omitempty"`
+	// Pages 各页面的布局状态，键为 LayoutPageHome 等页面名称
+	Pages map[string]PageLayout `json:"pages,omitempty"`
+	// LogWindow 独立日志窗口的位置与大小，从未弹出过时为空
+	LogWindow *WindowGeometry `json:"logWindow,omitempty"`
+	// LastPage 上次退出时所在的页面（home/node/settings/subscription），启动时恢复
+	LastPage string `json:"lastPage,omitempty"`
+	// NodeListOffset 节点列表的滚动位置，首次显示节点列表时恢复
+	NodeListOffset float32 `json:"nodeListOffset,omitempty"`
+}
+
+// 布局配置中的页面名称，与 LastPage 取值一致
+const (
+	LayoutPageHome         = "home"
+	LayoutPageNode         = "node"
+	LayoutPageSettings     = "settings"
+	LayoutPageSubscription = "subscription"
+	LayoutPageProviders    = "providers"
+	LayoutPageUsage        = "usage"
+)
+
+// maxExpandedGroups 每个页面最多记住的展开分组数，超出时丢弃最早展开的
+const maxExpandedGroups = 200
+
+// PageLayout 单个页面的布局状态。
+type PageLayout struct {
+	// Columns 列表各列的显示与宽度，为空时使用页面默认列
+	Columns []NodeColumnConfig `json:"columns,omitempty"`
+	// ExpandedGroups 展开的分组（如访问记录中的域名），按展开先后排列
+	ExpandedGroups []string `json:"expandedGroups,omitempty"`
+	// Search 列表搜索框中的关键字（保留用户输入的原文）
+	Search string `json:"search,omitempty"`
+	// Filters 选中的筛选项（如节点列表的地区代码）
+	Filters []string `json:"filters,omitempty"`
+}
+
+// WindowGeometry 独立窗口的位置与大小；位置仅在能读取原生窗口坐标的平台上记录（HasPosition）。
+type WindowGeometry struct {
+	X           int     `json:"x"`
+	Y           int     `json:"y"`
+	HasPosition bool    `json:"hasPosition"`
+	Width       float32 `json:"width"`
+	Height      float32 `json:"height"`
+}
+
+// 节点列表列 ID
+const (
+	NodeColumnRegion   = "region"
+	NodeColumnName     = "name"
+	NodeColumnProtocol = "protocol"
+	NodeColumnPort     = "port"
+	NodeColumnDelay    = "delay"
+	NodeColumnSpeed    = "speed"
+	NodeColumnTraffic  = "traffic"
+)
+
+// NodeColumnConfig 节点列表一列的配置；名称列始终显示并占满剩余宽度，其 Width 不生效。
+type NodeColumnConfig struct {
+	ID      string  `json:"id"`
+	Visible bool    `json:"visible"`
+	Width   float32 `json:"width"`
+}
+
+// DefaultNodeColumns 返回节点列表的默认列配置（顺序即显示顺序）。
+func DefaultNodeColumns() []NodeColumnConfig {
+	return []NodeColumnConfig{
+		{ID: NodeColumnRegion, Visible: true, Width: 80},
+		{ID: NodeColumnName, Visible: true},
+		{ID: NodeColumnProtocol, Visible: false, Width: 70},
+		{ID: NodeColumnPort, Visible: false, Width: 60},
+		{ID: NodeColumnDelay, Visible: true, Width: 80},
+		{ID: NodeColumnSpeed, Visible: false, Width: 130},
+		{ID: NodeColumnTraffic, Visible: false, Width: 100},
+	}
 }
 
 func DefaultLayoutConfig() *LayoutConfig {
@@ -430,6 +813,16 @@ func (ls *LayoutStore) Load() error {
 	}
 
 	ls.config = &config
+	if len(config.NodeColumns) > 0 {
+		// 旧版把列设置放在顶层，迁移到节点页的页面布局
+		page := ls.page(LayoutPageNode)
+		if len(page.Columns) == 0 {
+			page.Columns = config.NodeColumns
+		}
+		config.NodeColumns = nil
+		ls.setPage(LayoutPageNode, page)
+		ls.save()
+	}
 	ls.updateBinding()
 	return nil
 }
@@ -438,15 +831,141 @@ func (ls *LayoutStore) updateBinding() {
 	_ = ls.ConfigBinding.Set(ls.config)
 }
 
-func (ls *LayoutStore) Get() *LayoutConfig {
-	return ls.config
+// page 返回页面布局状态的副本，未保存时为零值。
+func (ls *LayoutStore) page(name string) PageLayout {
+	if ls.config == nil {
+		return PageLayout{}
+	}
+	return ls.config.Pages[name]
+}
+
+// setPage 更新页面布局状态（不落库）。
+func (ls *LayoutStore) setPage(name string, page PageLayout) {
+	if ls.config == nil {
+		ls.config = DefaultLayoutConfig()
+	}
+	if ls.config.Pages == nil {
+		ls.config.Pages = make(map[string]PageLayout)
+	}
+	ls.config.Pages[name] = page
+}
+
+// NodeColumns 返回节点列表列配置：按默认顺序合并已保存的显示与宽度，忽略未知列，名称列强制显示。
+func (ls *LayoutStore) NodeColumns() []NodeColumnConfig {
+	cols := DefaultNodeColumns()
+	page := ls.page(LayoutPageNode)
+	saved := make(map[string]NodeColumnConfig, len(page.Columns))
+	for _, c := range page.Columns {
+		saved[c.ID] = c
+	}
+	for i := range cols {
+		if c, ok := saved[cols[i].ID]; ok {
+			cols[i].Visible = c.Visible
+			if c.Width > 0 {
+				cols[i].Width = c.Width
+			}
+		}
+		if cols[i].ID == NodeColumnName {
+			cols[i].Visible = true
+		}
+	}
+	return cols
+}
+
+// SaveNodeColumns 保存节点列表列配置，传入 nil 恢复默认。
+func (ls *LayoutStore) SaveNodeColumns(cols []NodeColumnConfig) error {
+	page := ls.page(LayoutPageNode)
+	page.Columns = append([]NodeColumnConfig(nil), cols...)
+	ls.setPage(LayoutPageNode, page)
+	return ls.save()
+}
+
+// ExpandedGroups 返回页面上次展开的分组。
+func (ls *LayoutStore) ExpandedGroups(pageName string) []string {
+	return append([]string(nil), ls.page(pageName).ExpandedGroups...)
+}
+
+// SetGroupExpanded 记录页面分组的展开或收起，状态未变化时不写库。
+func (ls *LayoutStore) SetGroupExpanded(pageName, group string, expanded bool) error {
+	page := ls.page(pageName)
+	idx := -1
+	for i, g := range page.ExpandedGroups {
+		if g == group {
+			idx = i
+			break
+		}
+	}
+	if (idx >= 0) == expanded {
+		return nil
+	}
+	groups := append([]string(nil), page.ExpandedGroups...)
+	if expanded {
+		groups = append(groups, group)
+		if len(groups) > maxExpandedGroups {
+			groups = groups[len(groups)-maxExpandedGroups:]
+		}
+	} else {
+		groups = append(groups[:idx], groups[idx+1:]...)
+	}
+	page.ExpandedGroups = groups
+	ls.setPage(pageName, page)
+	return ls.save()
+}
+
+// PageFilter 返回页面上次的搜索关键字与选中的筛选项，页面重建或重启后据此恢复。
+func (ls *LayoutStore) PageFilter(pageName string) (search string, filters []string) {
+	page := ls.page(pageName)
+	return page.Search, append([]string(nil), page.Filters...)
+}
+
+// SavePageFilter 保存页面的搜索关键字与选中的筛选项，未变化时不写库。
+func (ls *LayoutStore) SavePageFilter(pageName, search string, filters []string) error {
+	page := ls.page(pageName)
+	if page.Search == search && slices.Equal(page.Filters, filters) {
+		return nil
+	}
+	page.Search = search
+	page.Filters = append([]string(nil), filters...)
+	ls.setPage(pageName, page)
+	return ls.save()
+}
+
+// LogWindowGeometry 返回独立日志窗口保存的位置与大小，未保存时返回 nil。
+func (ls *LayoutStore) LogWindowGeometry() *WindowGeometry {
+	if ls.config == nil || ls.config.LogWindow == nil {
+		return nil
+	}
+	g := *ls.config.LogWindow
+	return &g
+}
+
+// SaveLogWindowGeometry 保存独立日志窗口的位置与大小。
+func (ls *LayoutStore) SaveLogWindowGeometry(g WindowGeometry) error {
+	if ls.config == nil {
+		ls.config = DefaultLayoutConfig()
+	}
+	ls.config.LogWindow = &g
+	return ls.save()
+}
+
+// LastView 返回上次退出时所在的页面与节点列表滚动位置。
+func (ls *LayoutStore) LastView() (page string, nodeListOffset float32) {
+	if ls.config == nil {
+		return "", 0
+	}
+	return ls.config.LastPage, ls.config.NodeListOffset
 }
 
-func (ls *LayoutStore) Save(config *LayoutConfig) error {
-	if config == nil {
-		config = DefaultLayoutConfig()
+// SaveLastView 保存当前所在页面与节点列表滚动位置。
+func (ls *LayoutStore) SaveLastView(page string, nodeListOffset float32) error {
+	if ls.config == nil {
+		ls.config = DefaultLayoutConfig()
 	}
-	ls.config = config
+	if ls.config.LastPage == page && ls.config.NodeListOffset == nodeListOffset {
+		return nil
+	}
+	ls.config.LastPage = page
+	ls.config.NodeListOffset = nodeListOffset
 	return ls.save()
 }
 
@@ -533,50 +1052,124 @@ func splitSizeString(s string) []string {
 	return strings.Split(s, ",")
 }
 
+// ProxyStatusStore 代理状态绑定：主页、托盘等组件统一从这里渲染连接状态、模式、速度、运行时长与出口 IP，
+// 不各自拼装状态。
 type ProxyStatusStore struct {
-	ProxyStatusBinding binding.String
-	PortBinding        binding.String
-	ServerNameBinding  binding.String
+	ProxyStatusBinding   binding.String
+	PortBinding          binding.String
+	ServerNameBinding    binding.String
+	ModeBinding          binding.String // 系统代理模式（SystemProxyMode.String()）
+	UploadSpeedBinding   binding.Int    // 上传速度（字节/秒）
+	DownloadSpeedBinding binding.Int    // 下载速度（字节/秒）
+	UptimeBinding        binding.String // 本次连接的运行时长，未连接为 "-"（首次刷新前为空）
+	ExitIPBinding        binding.String // 出口 IP，未连接为 "-"（首次刷新前为空）
+
+	mu        sync.Mutex
+	session   uint64    // 当前会话对应的实例代次（ProxyState.Session），变化即视为新会话
+	startedAt time.Time // 当前会话开始时间，未连接为零值
 }
 
 func NewProxyStatusStore() *ProxyStatusStore {
 	return &ProxyStatusStore{
-		ProxyStatusBinding: binding.NewString(),
-		PortBinding:        binding.NewString(),
-		ServerNameBinding:  binding.NewString(),
-	}
-}
-
-func (ps *ProxyStatusStore) UpdateProxyStatus(xrayInstance interface {
-	IsRunning() bool
-	GetPort() int
-}, nodesStore *NodesStore) {
-	isRunning := false
-	proxyPort := 0
-	if xrayInstance != nil {
-		v := reflect.ValueOf(xrayInstance)
-		if v.Kind() == reflect.Ptr && v.IsNil() {
-			isRunning = false
-			proxyPort = 0
-		} else {
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						isRunning = false
-						proxyPort = 0
-					}
-				}()
-				if xrayInstance.IsRunning() {
-					isRunning = true
-					if xrayInstance.GetPort() > 0 {
-						proxyPort = xrayInstance.GetPort()
-					} else {
-						proxyPort = database.DefaultMixedInboundPort
-					}
-				}
-			}()
-		}
+		ProxyStatusBinding:   binding.NewString(),
+		PortBinding:          binding.NewString(),
+		ServerNameBinding:    binding.NewString(),
+		ModeBinding:          binding.NewString(),
+		UploadSpeedBinding:   binding.NewInt(),
+		DownloadSpeedBinding: binding.NewInt(),
+		UptimeBinding:        binding.NewString(),
+		ExitIPBinding:        binding.NewString(),
+	}
+}
+
+// SetMode 更新系统代理模式绑定。
+func (ps *ProxyStatusStore) SetMode(mode string) {
+	ps.ModeBinding.Set(mode)
+}
+
+// SetSpeeds 更新实时速度绑定，并刷新运行时长。
+func (ps *ProxyStatusStore) SetSpeeds(upload, download int64) {
+	ps.UploadSpeedBinding.Set(int(upload))
+	ps.DownloadSpeedBinding.Set(int(download))
+	ps.RefreshUptime()
+}
+
+// RefreshUptime 按当前会话开始时间刷新运行时长绑定。
+func (ps *ProxyStatusStore) RefreshUptime() {
+	ps.mu.Lock()
+	startedAt := ps.startedAt
+	ps.mu.Unlock()
+	if startedAt.IsZero() {
+		ps.UptimeBinding.Set("-")
+		return
 	}
+	ps.UptimeBinding.Set(formatUptime(time.Since(startedAt)))
+}
+
+// SessionStartedAt 返回当前会话开始时间，未连接时为零值。
+func (ps *ProxyStatusStore) SessionStartedAt() time.Time {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.startedAt
+}
+
+// SetSessionExitIP 更新出口 IP；startedAt 与当前会话不一致（查询期间已断开或重连）时忽略。
+func (ps *ProxyStatusStore) SetSessionExitIP(startedAt time.Time, ip string) {
+	ps.mu.Lock()
+	current := !ps.startedAt.IsZero() && ps.startedAt.Equal(startedAt)
+	ps.mu.Unlock()
+	if current {
+		ps.ExitIPBinding.Set(ip)
+	}
+}
+
+// formatUptime 将时长格式化为 HH:MM:SS，超过一天时带天数。
+func formatUptime(d time.Duration) string {
+	total := int64(d / time.Second)
+	days := total / 86400
+	h, m, s := total%86400/3600, total%3600/60, total%60
+	if days > 0 {
+		return fmt.Sprintf("%d天 %02d:%02d:%02d", days, h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
+
+// ProxyState 代理运行状态快照。
+type ProxyState struct {
+	Running bool
+	Port    int    // 本地入站端口，未运行时为 0
+	Session uint64 // 实例代次：每换一个 xray 实例递增，用于判断是否开始了新的连接会话
+}
+
+// ProxyController 提供代理运行状态快照，由 service.ProxyService 实现；
+// 实现须允许 nil 接收者（返回未运行），调用方无需再判断类型化的 nil。
+type ProxyController interface {
+	ProxyState() ProxyState
+}
+
+// UpdateProxyStatus 按代理状态快照与选中节点刷新状态绑定；返回本次是否开始了新的连接会话
+// （由未连接变为连接，或换了实例），调用方可据此重新查询出口 IP。controller 为 nil 时视为未连接。
+func (ps *ProxyStatusStore) UpdateProxyStatus(controller ProxyController, nodesStore *NodesStore) (newSession bool) {
+	var state ProxyState
+	if controller != nil {
+		state = controller.ProxyState()
+	}
+	isRunning, proxyPort := state.Running, state.Port
+	ps.mu.Lock()
+	if isRunning && (ps.startedAt.IsZero() || ps.session != state.Session) {
+		ps.session, ps.startedAt = state.Session, time.Now()
+		newSession = true
+	} else if !isRunning {
+		ps.session, ps.startedAt = 0, time.Time{}
+	}
+	ps.mu.Unlock()
+	if newSession || !isRunning {
+		ps.ExitIPBinding.Set("-")
+		ps.UploadSpeedBinding.Set(0)
+		ps.DownloadSpeedBinding.Set(0)
+	}
+	ps.RefreshUptime()
+
 	if isRunning {
 		ps.ProxyStatusBinding.Set("当前连接状态: 🟢 已连接")
 		if proxyPort > 0 {
@@ -598,6 +1191,7 @@ func (ps *ProxyStatusStore) UpdateProxyStatus(xrayInstance interface {
 	} else {
 		ps.ServerNameBinding.Set("无")
 	}
+	return newSession
 }
 
 // AccessRecordsStore 访问记录存储，用于流量分析。
@@ -638,10 +1232,10 @@ func (ars *AccessRecordsStore) RecordAccess(address string, count, uploadBytes,
 	return database.InsertOrUpdateAccessRecord(address, count, uploadBytes, downloadBytes)
 }
 
-// RecordAccessBatch 批量记录访问，key 为 address (host:port)。
+// RecordAccessBatch 批量记录访问，key 为 address (host:port)，值含按出站分类的次数。
 // 与 RecordAccess 相同，不在此处全表 Load；由调用方在适当时机 Load。
-func (ars *AccessRecordsStore) RecordAccessBatch(addressCounts map[string]int64) error {
-	return database.BatchInsertOrUpdateAccessRecords(addressCounts)
+func (ars *AccessRecordsStore) RecordAccessBatch(tallies map[string]model.AccessTally) error {
+	return database.BatchInsertOrUpdateAccessRecords(tallies)
 }
 
 func (ars *AccessRecordsStore) Delete(id int64) error {
@@ -660,3 +1254,50 @@ func (ars *AccessRecordsStore) ClearAll() error {
 	ars.mu.Unlock()
 	return nil
 }
+
+// ProcessRecordsStore 应用维度统计存储：哪些本机进程通过本地入站使用了代理。
+type ProcessRecordsStore struct {
+	mu      sync.RWMutex
+	records []model.ProcessRecord
+}
+
+func NewProcessRecordsStore() *ProcessRecordsStore {
+	return &ProcessRecordsStore{}
+}
+
+func (prs *ProcessRecordsStore) Load() error {
+	records, err := database.GetAllProcessRecords()
+	if err != nil {
+		return fmt.Errorf("应用统计存储: 加载失败: %w", err)
+	}
+	prs.mu.Lock()
+	prs.records = records
+	prs.mu.Unlock()
+	return nil
+}
+
+func (prs *ProcessRecordsStore) GetAll() []model.ProcessRecord {
+	prs.mu.RLock()
+	defer prs.mu.RUnlock()
+	result := make([]model.ProcessRecord, len(prs.records))
+	copy(result, prs.records)
+	return result
+}
+
+// RecordBatch 批量累加连接次数；与 AccessRecordsStore 相同，不在此处全表 Load。
+func (prs *ProcessRecordsStore) RecordBatch(records []model.ProcessRecord) error {
+	if err := database.BatchInsertOrUpdateProcessRecords(records); err != nil {
+		return fmt.Errorf("应用统计存储: %w", err)
+	}
+	return nil
+}
+
+func (prs *ProcessRecordsStore) ClearAll() error {
+	if err := database.ClearAllProcessRecords(); err != nil {
+		return fmt.Errorf("应用统计存储: %w", err)
+	}
+	prs.mu.Lock()
+	prs.records = nil
+	prs.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,69 @@
+package subscription
+
+import (
+	"encoding/base64"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// decodeBase64Loose 宽松的 Base64 解码：忽略空白与换行，兼容 URL 安全字母表（-_）与标准字母表（+/）混用，
+// 填充缺失、多余或不完整时均按无填充解码。
+func decodeBase64Loose(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r), r == '=':
+			return -1
+		case r == '-':
+			return '+'
+		case r == '_':
+			return '/'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return nil, nil
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// decodeSubscriptionBody 将订阅正文还原为明文：整体是 Base64（可能按 76 列折行、URL 安全字母表、缺少填充）时整体解码；
+// 否则逐行检查，把单独经过 Base64 编码的行（解码后含 "://"）展开，其余行（明文链接、JSON 等）原样保留。
+func decodeSubscriptionBody(content string) string {
+	content = strings.TrimPrefix(strings.TrimSpace(content), "\ufeff")
+	if decoded, ok := decodeBase64Text(content); ok {
+		return decoded
+	}
+
+	lines := strings.Split(content, "\n")
+	changed := false
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, "://") {
+			continue
+		}
+		if decoded, ok := decodeBase64Text(line); ok && strings.Contains(decoded, "://") {
+			lines[i] = strings.TrimSpace(decoded)
+			changed = true
+		}
+	}
+	if !changed {
+		return content
+	}
+	return strings.Join(lines, "\n")
+}
+
+// decodeBase64Text 解码 Base64 并确认结果是文本（合法 UTF-8、无控制字符），避免把恰好由 Base64 字符组成的明文误解码。
+func decodeBase64Text(s string) (string, bool) {
+	decoded, err := decodeBase64Loose(s)
+	if err != nil || len(decoded) == 0 || !utf8.Valid(decoded) {
+		return "", false
+	}
+	text := string(decoded)
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return "", false
+		}
+	}
+	return text, true
+}
@@ -0,0 +1,108 @@
+package subscription
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBase64Loose(t *testing.T) {
+	// 0xfb 0xef 0xff 编码后含 + / 或 - _，用于区分两种字母表
+	raw := []byte{0xfb, 0xef, 0xff, 0x01}
+	std := base64.StdEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    []byte
+		wantErr bool
+	}{
+		{"标准字母表带填充", std, raw, false},
+		{"标准字母表缺少填充", base64.RawStdEncoding.EncodeToString(raw), raw, false},
+		{"URL 安全字母表带填充", base64.URLEncoding.EncodeToString(raw), raw, false},
+		{"URL 安全字母表缺少填充", base64.RawURLEncoding.EncodeToString(raw), raw, false},
+		{"两种字母表混用", "+-/_AQ", raw, false},
+		{"多余填充", std + "==", raw, false},
+		{"CRLF 折行", std[:3] + "\r\n" + std[3:], raw, false},
+		{"空白与制表符", " " + std[:2] + "\t" + std[2:] + " \n", raw, false},
+		{"空串", "", nil, false},
+		{"只有空白与填充", " \r\n==", nil, false},
+		{"非法字符", "ab$d", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBase64Loose(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBase64Loose(%q) err = %v，期望出错 %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("decodeBase64Loose(%q) = %x，期望 %x", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBase64Text(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"文本", base64.StdEncoding.EncodeToString([]byte("vmess://abc")), "vmess://abc", true},
+		{"含换行与中文", base64.RawURLEncoding.EncodeToString([]byte("节点 A\r\n节点 B\t")), "节点 A\r\n节点 B\t", true},
+		{"非 UTF-8", base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0xfd}), "", false},
+		{"含控制字符", base64.StdEncoding.EncodeToString([]byte("a\x00b")), "", false},
+		{"恰好由 Base64 字符组成的明文", "abcd", "", false},
+		{"普通明文", "hello world!", "", false},
+		{"空串", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeBase64Text(tt.in)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("decodeBase64Text(%q) = %q, %v，期望 %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecodeSubscriptionBody(t *testing.T) {
+	links := "vmess://eyJhZGQiOiJhLmV4YW1wbGUuY29tIn0=\nss://YWVzLTI1Ni1nY206cHc@b.example.com:8388#B\ntrojan://pw@c.example.com:443?sni=c.example.com#%E8%8A%82%E7%82%B9"
+	std := base64.StdEncoding.EncodeToString([]byte(links))
+
+	// 按 76 列折行，模拟 MIME 风格的订阅正文
+	var folded strings.Builder
+	for i := 0; i < len(std); i += 76 {
+		end := min(i+76, len(std))
+		folded.WriteString(std[i:end])
+		folded.WriteString("\r\n")
+	}
+
+	encodedLine := base64.RawURLEncoding.EncodeToString([]byte("ss://YWVzLTI1Ni1nY206cHc@d.example.com:8388#D"))
+	plainJSON := `{"outbounds":[{"protocol":"vmess"}]}`
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"整体标准 Base64", std, links},
+		{"整体 URL 安全且缺少填充", base64.RawURLEncoding.EncodeToString([]byte(links)), links},
+		{"76 列 CRLF 折行", folded.String(), links},
+		{"BOM 与首尾空白", "\ufeff  " + std + "\n\n", links},
+		{"明文链接原样保留", links, links},
+		{"逐行 Base64 展开", "vmess://abc\n" + encodedLine + "\n", "vmess://abc\nss://YWVzLTI1Ni1nY206cHc@d.example.com:8388#D"},
+		{"解码后不是链接的行保留", "vmess://abc\naGVsbG8=", "vmess://abc\naGVsbG8="},
+		{"JSON 明文", plainJSON, plainJSON},
+		{"恰好由 Base64 字符组成的明文", "abcd", "abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeSubscriptionBody(tt.in); got != tt.want {
+				t.Errorf("decodeSubscriptionBody(%q) = %q，期望 %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package subscription
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"myproxy.com/p/internal/model"
+)
+
+// clashProxy Clash proxies 中的一项，字段顺序即输出顺序。
+type clashProxy struct {
+	Name           string            `yaml:"name"`
+	Type           string            `yaml:"type"`
+	Server         string            `yaml:"server"`
+	Port           int               `yaml:"port"`
+	Cipher         string            `yaml:"cipher,omitempty"`
+	Password       string            `yaml:"password,omitempty"`
+	UUID           string            `yaml:"uuid,omitempty"`
+	AlterID        *int              `yaml:"alterId,omitempty"`
+	Username       string            `yaml:"username,omitempty"`
+	Protocol       string            `yaml:"protocol,omitempty"`
+	ProtocolParam  string            `yaml:"protocol-param,omitempty"`
+	Obfs           string            `yaml:"obfs,omitempty"`
+	ObfsParam      string            `yaml:"obfs-param,omitempty"`
+	Plugin         string            `yaml:"plugin,omitempty"`
+	PluginOpts     map[string]string `yaml:"plugin-opts,omitempty"`
+	TLS            bool              `yaml:"tls,omitempty"`
+	ServerName     string            `yaml:"servername,omitempty"`
+	SNI            string            `yaml:"sni,omitempty"`
+	ALPN           []string          `yaml:"alpn,omitempty"`
+	SkipCertVerify bool              `yaml:"skip-cert-verify,omitempty"`
+	Network        string            `yaml:"network,omitempty"`
+	WSOpts         *clashWSOpts      `yaml:"ws-opts,omitempty"`
+	H2Opts         *clashH2Opts      `yaml:"h2-opts,omitempty"`
+	GRPCOpts       map[string]string `yaml:"grpc-opts,omitempty"`
+	UDP            bool              `yaml:"udp"`
+}
+
+type clashWSOpts struct {
+	Path    string            `yaml:"path,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+type clashH2Opts struct {
+	Host []string `yaml:"host,omitempty"`
+	Path string   `yaml:"path,omitempty"`
+}
+
+// ExportClashProxies 将节点导出为 Clash 配置的 proxies 段（YAML），与 ParseClientConfig 的转换互逆。
+// 重名节点追加序号以满足 Clash 名称唯一的要求；无法表示的节点（如 VMess 的 kcp、quic 传输）计入 skipped。
+func ExportClashProxies(nodes []*model.Node) (data []byte, skipped int, err error) {
+	proxies := make([]clashProxy, 0, len(nodes))
+	names := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		p, ok := nodeToClashProxy(n)
+		if !ok {
+			skipped++
+			continue
+		}
+		names[p.Name]++
+		if c := names[p.Name]; c > 1 {
+			p.Name = fmt.Sprintf("%s %d", p.Name, c)
+		}
+		proxies = append(proxies, p)
+	}
+	if len(proxies) == 0 {
+		return nil, skipped, fmt.Errorf("导出配置: 所选节点均无法导出为 Clash 配置")
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(struct {
+		Proxies []clashProxy `yaml:"proxies"`
+	}{proxies})
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return nil, skipped, fmt.Errorf("导出配置: 生成 Clash 配置失败: %w", err)
+	}
+	return buf.Bytes(), skipped, nil
+}
+
+// nodeToClashProxy 将节点转换为 Clash proxies 中的一项。
+func nodeToClashProxy(n *model.Node) (clashProxy, bool) {
+	p := clashProxy{Name: n.Name, Server: n.Addr, Port: n.Port, UDP: true}
+	if p.Name == "" {
+		p.Name = fmt.Sprintf("%s:%d", n.Addr, n.Port)
+	}
+	switch n.ProtocolType {
+	case "ss":
+		p.Type, p.Cipher, p.Password = "ss", n.SSMethod, n.Password
+		if n.SSPlugin != "" {
+			p.Plugin = n.SSPlugin
+			p.PluginOpts = splitPluginOpts(n.SSPluginOpts)
+		}
+	case "ssr":
+		p.Type, p.Cipher, p.Password = "ssr", n.SSMethod, n.Password
+		p.Protocol, p.ProtocolParam = n.SSRProtocol, n.SSRProtocolParam
+		p.Obfs, p.ObfsParam = n.SSRObfs, n.SSRObfsParam
+	case "vmess":
+		alterID := n.VMessAlterID
+		p.Type, p.UUID, p.AlterID = "vmess", n.VMessUUID, &alterID
+		p.Cipher = n.VMessSecurity
+		if p.Cipher == "" {
+			p.Cipher = "auto"
+		}
+		p.TLS = n.VMessTLS == "tls"
+		switch n.VMessNetwork {
+		case "", "tcp":
+			if n.VMessType == "http" {
+				// Clash 无 TCP + HTTP 伪装的独立写法，统一为 http 传输
+				p.Network = "http"
+			}
+		case "ws", "websocket":
+			p.Network = "ws"
+			p.WSOpts = &clashWSOpts{Path: n.VMessPath}
+			if n.VMessHost != "" {
+				p.WSOpts.Headers = map[string]string{"Host": n.VMessHost}
+			}
+		case "h2", "http":
+			p.Network = "h2"
+			p.H2Opts = &clashH2Opts{Path: n.VMessPath}
+			if n.VMessHost != "" {
+				p.H2Opts.Host = strings.Split(n.VMessHost, ",")
+			}
+		case "grpc":
+			p.Network = "grpc"
+			p.GRPCOpts = map[string]string{"grpc-service-name": n.VMessPath}
+		default:
+			return p, false
+		}
+		if p.TLS && n.VMessHost != "" && p.Network != "h2" {
+			p.ServerName = n.VMessHost
+		}
+	case "trojan":
+		p.Type, p.Password = "trojan", n.Password
+		p.SNI = n.TrojanSNI
+		if n.TrojanAlpn != "" {
+			p.ALPN = strings.Split(n.TrojanAlpn, ",")
+		}
+		p.SkipCertVerify = n.TrojanAllowInsecure
+	case "socks5":
+		p.Type, p.Username, p.Password = "socks5", n.Username, n.Password
+	default:
+		return p, false
+	}
+	return p, true
+}
+
+// splitPluginOpts 将 SIP003 插件参数字符串（k=v;k=v）转换为 Clash 的 plugin-opts。
+func splitPluginOpts(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	opts := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			opts[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		} else if part = strings.TrimSpace(part); part != "" {
+			opts[part] = "true"
+		}
+	}
+	return opts
+}
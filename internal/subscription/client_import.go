@@ -0,0 +1,654 @@
+package subscription
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/model"
+)
+
+// 可导入的其他客户端配置格式
+const (
+	ClientFormatClash        = "clash"        // Clash / Clash Meta 的 YAML 配置
+	ClientFormatV2rayN       = "v2rayn"       // v2rayN 的 guiNConfig.json
+	ClientFormatShadowrocket = "shadowrocket" // Shadowrocket 备份（分享链接 + Surge 风格的 [Proxy]、[Rule] 段）
+)
+
+// ClientConfig 从其他客户端配置中读出、已转换为本应用格式的节点、订阅与直连规则。
+type ClientConfig struct {
+	Format        string               // ClientFormatClash 等
+	Nodes         []model.Node         // 手动添加的节点；属于订阅的节点由订阅更新获取，不在此列
+	Subscriptions []ClientSubscription // 订阅地址（Clash proxy-providers、v2rayN 订阅列表）
+	DirectRoutes  []string             // 转换后的直连规则（domain:、full:、IP/CIDR）
+	SkippedRules  int                  // 无法转换的直连规则数（GEOIP、GEOSITE、DOMAIN-KEYWORD 等）
+	FinalOutbound string               // 最终规则（MATCH / FINAL）的走向：proxy、direct 或 block，未设置时为空
+	Report        *ParseReport         // 节点解析报告
+}
+
+// ClientSubscription 其他客户端中的订阅。
+type ClientSubscription struct {
+	Label string
+	URL   string
+}
+
+// FormatName 返回配置格式的展示名称。
+func (c *ClientConfig) FormatName() string {
+	switch c.Format {
+	case ClientFormatClash:
+		return "Clash 配置"
+	case ClientFormatV2rayN:
+		return "v2rayN 配置"
+	case ClientFormatShadowrocket:
+		return "Shadowrocket 备份"
+	}
+	return c.Format
+}
+
+// ParseClientConfig 识别并解析其他客户端的配置文件：JSON 按 v2rayN 的 guiNConfig.json，含 proxies 等字段的 YAML 按 Clash，
+// 其余按 Shadowrocket 备份（分享链接与 Surge 风格配置）。只读取 xray 支持的协议，其余计入报告的「不支持」。
+func (sm *SubscriptionManager) ParseClientConfig(data []byte) (*ClientConfig, error) {
+	content := strings.TrimPrefix(strings.TrimSpace(string(data)), "\ufeff")
+	if content == "" {
+		return nil, fmt.Errorf("导入配置: 文件为空")
+	}
+	var cfg *ClientConfig
+	var err error
+	switch {
+	case strings.HasPrefix(content, "{"):
+		cfg, err = sm.parseV2rayNConfig([]byte(content))
+	case looksLikeClashConfig(content):
+		cfg, err = sm.parseClashConfig([]byte(content))
+	default:
+		cfg = sm.parseShadowrocketBackup(decodeSubscriptionBody(content))
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg.Report.Imported = len(cfg.Nodes)
+	assignUniqueServerIDs(cfg.Nodes)
+	if len(cfg.Nodes) == 0 && len(cfg.Subscriptions) == 0 && len(cfg.DirectRoutes) == 0 {
+		return nil, fmt.Errorf("导入配置: 未在%s中找到可导入的节点、订阅或直连规则（%s）", cfg.FormatName(), cfg.Report.Summary())
+	}
+	return cfg, nil
+}
+
+// looksLikeClashConfig 是否为 Clash 配置：存在顶层的 proxies、proxy-providers 或 rules 键。
+func looksLikeClashConfig(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		for _, key := range []string{"proxies:", "proxy-providers:", "rules:"} {
+			if strings.HasPrefix(line, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptNode 对解析出的节点应用 SSR 策略并记入报告，返回是否保留。
+func (sm *SubscriptionManager) acceptNode(node *model.Node, report *ParseReport) bool {
+	if node.ProtocolType == "ssr" || node.SSRProtocol != "" || node.SSRObfs != "" {
+		if !sm.applySSRPolicy(node) {
+			report.UnsupportedSchemes["ssr"]++
+			return false
+		}
+		if node.ProtocolType == "ssr" {
+			report.SSRKept++
+		} else {
+			report.SSRConverted++
+		}
+	}
+	return true
+}
+
+// --- Clash ---
+
+type clashConfig struct {
+	Proxies        []map[string]interface{} `yaml:"proxies"`
+	ProxyProviders map[string]struct {
+		Type string `yaml:"type"`
+		URL  string `yaml:"url"`
+	} `yaml:"proxy-providers"`
+	Rules []string `yaml:"rules"`
+}
+
+func (sm *SubscriptionManager) parseClashConfig(data []byte) (*ClientConfig, error) {
+	var raw clashConfig
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("导入配置: 解析 Clash 配置失败: %w", err)
+	}
+	cfg := &ClientConfig{Format: ClientFormatClash, Report: newParseReport()}
+	for i, proxy := range raw.Proxies {
+		proxyType := strings.ToLower(mapString(proxy, "type"))
+		node, err := clashProxyToNode(proxy)
+		if err != nil {
+			if err == errUnsupportedClientProtocol {
+				cfg.Report.UnsupportedSchemes[proxyType]++
+				continue
+			}
+			sm.logDebug("导入配置: Clash 第 %d 个节点解析失败: %v", i+1, err)
+			cfg.Report.Errors = append(cfg.Report.Errors, ParseLineError{Line: i + 1, Scheme: proxyType, Err: err})
+			continue
+		}
+		if sm.acceptNode(node, cfg.Report) {
+			cfg.Nodes = append(cfg.Nodes, *node)
+		}
+	}
+
+	names := make([]string, 0, len(raw.ProxyProviders))
+	for name := range raw.ProxyProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := raw.ProxyProviders[name]
+		if strings.EqualFold(p.Type, "http") && p.URL != "" {
+			cfg.Subscriptions = append(cfg.Subscriptions, ClientSubscription{Label: name, URL: p.URL})
+		}
+	}
+
+	for _, rule := range raw.Rules {
+		addClashRule(cfg, rule)
+	}
+	return cfg, nil
+}
+
+// errUnsupportedClientProtocol 节点协议 xray 或本应用不支持
+var errUnsupportedClientProtocol = fmt.Errorf("不支持的协议")
+
+// clashProxyToNode 将 Clash proxies 中的一项转换为节点。
+func clashProxyToNode(p map[string]interface{}) (*model.Node, error) {
+	server := mapString(p, "server")
+	port := mapInt(p, "port")
+	if server == "" || port <= 0 {
+		return nil, fmt.Errorf("缺少 server 或 port")
+	}
+	rawConfig, _ := json.Marshal(p)
+	node := &model.Node{
+		Name:      mapString(p, "name"),
+		Addr:      server,
+		Port:      port,
+		Enabled:   true,
+		RawConfig: string(rawConfig),
+	}
+
+	switch strings.ToLower(mapString(p, "type")) {
+	case "ss":
+		password := mapString(p, "password")
+		node.ProtocolType = "ss"
+		node.Username, node.Password = password, password
+		node.SSMethod = mapString(p, "cipher")
+		node.SSPlugin = mapString(p, "plugin")
+		node.SSPluginOpts = joinPluginOpts(p["plugin-opts"])
+		node.ID = model.GenerateServerID(server, port, password)
+
+	case "ssr":
+		password := mapString(p, "password")
+		node.ProtocolType = "ssr"
+		node.Username, node.Password = password, password
+		node.SSMethod = mapString(p, "cipher")
+		node.SSRProtocol = mapString(p, "protocol")
+		node.SSRProtocolParam = mapString(p, "protocol-param")
+		node.SSRObfs = mapString(p, "obfs")
+		node.SSRObfsParam = mapString(p, "obfs-param")
+		if ssrCompatibleWithSS(node.SSRProtocol, node.SSRObfs) {
+			node.ProtocolType = "ss"
+		}
+		node.ID = model.GenerateServerID(server, port, password)
+
+	case "vmess":
+		uuid := mapString(p, "uuid")
+		node.ProtocolType = "vmess"
+		node.Username = uuid
+		node.VMessUUID = uuid
+		node.VMessAlterID = mapInt(p, "alterId")
+		node.VMessSecurity = mapString(p, "cipher")
+		if node.VMessSecurity == "" {
+			node.VMessSecurity = "auto"
+		}
+		node.VMessNetwork = mapString(p, "network")
+		if mapBool(p, "tls") {
+			node.VMessTLS = "tls"
+		}
+		switch node.VMessNetwork {
+		case "", "tcp":
+			node.VMessNetwork = "tcp"
+		case "ws":
+			opts := mapMap(p, "ws-opts")
+			node.VMessPath = mapString(opts, "path")
+			node.VMessHost = mapString(mapMap(opts, "headers"), "Host")
+		case "h2":
+			opts := mapMap(p, "h2-opts")
+			node.VMessPath = mapString(opts, "path")
+			node.VMessHost = strings.Join(mapStrings(opts, "host"), ",")
+		case "grpc":
+			node.VMessPath = mapString(mapMap(p, "grpc-opts"), "grpc-service-name")
+		case "http":
+			// Clash 的 http 传输即 TCP + HTTP 伪装
+			opts := mapMap(p, "http-opts")
+			node.VMessNetwork, node.VMessType = "tcp", "http"
+			node.VMessPath = strings.Join(mapStrings(opts, "path"), ",")
+			node.VMessHost = strings.Join(mapStrings(mapMap(opts, "headers"), "Host"), ",")
+		}
+		if node.VMessHost == "" {
+			node.VMessHost = mapString(p, "servername")
+		}
+		node.ID = model.GenerateServerID(server, port, uuid)
+
+	case "trojan":
+		password := mapString(p, "password")
+		node.ProtocolType = "trojan"
+		node.Username, node.Password = password, password
+		node.TrojanPassword = password
+		node.TrojanSNI = mapString(p, "sni")
+		node.TrojanAlpn = strings.Join(mapStrings(p, "alpn"), ",")
+		node.TrojanAllowInsecure = mapBool(p, "skip-cert-verify")
+		node.ID = model.GenerateServerID(server, port, password)
+
+	case "socks5":
+		node.ProtocolType = "socks5"
+		node.Username = mapString(p, "username")
+		node.Password = mapString(p, "password")
+		node.ID = model.GenerateServerID(server, port, node.Username)
+
+	default:
+		return nil, errUnsupportedClientProtocol
+	}
+	if node.Name == "" {
+		node.Name = fmt.Sprintf("%s:%d", server, port)
+	}
+	return node, nil
+}
+
+// joinPluginOpts 将 Clash 的 plugin-opts（键值表）转换为 SIP003 插件参数字符串（k=v;k=v）。
+func joinPluginOpts(v interface{}) string {
+	opts, ok := v.(map[string]interface{})
+	if !ok || len(opts) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, opts[k]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// addClashRule 转换一条 Clash / Surge 规则：只处理策略为 DIRECT 的规则，无法转换的计入 SkippedRules；
+// 最终规则（MATCH / FINAL）记下其走向。
+func addClashRule(cfg *ClientConfig, rule string) {
+	parts := strings.Split(rule, ",")
+	if kind := strings.ToUpper(strings.TrimSpace(parts[0])); (kind == "MATCH" || kind == "FINAL") && len(parts) >= 2 {
+		switch policy := strings.ToUpper(strings.TrimSpace(parts[1])); {
+		case policy == "DIRECT":
+			cfg.FinalOutbound = "direct"
+		case strings.HasPrefix(policy, "REJECT"):
+			cfg.FinalOutbound = "block"
+		default:
+			cfg.FinalOutbound = "proxy"
+		}
+		return
+	}
+	if len(parts) < 3 || !strings.EqualFold(strings.TrimSpace(parts[2]), "DIRECT") {
+		return
+	}
+	value := strings.TrimSpace(parts[1])
+	switch strings.ToUpper(strings.TrimSpace(parts[0])) {
+	case "DOMAIN":
+		cfg.DirectRoutes = append(cfg.DirectRoutes, "full:"+value)
+	case "DOMAIN-SUFFIX":
+		cfg.DirectRoutes = append(cfg.DirectRoutes, "domain:"+value)
+	case "IP-CIDR", "IP-CIDR6":
+		if _, _, err := net.ParseCIDR(value); err == nil {
+			cfg.DirectRoutes = append(cfg.DirectRoutes, value)
+		} else {
+			cfg.SkippedRules++
+		}
+	default:
+		cfg.SkippedRules++
+	}
+}
+
+// --- v2rayN ---
+
+// v2rayN guiNConfig.json 中服务器的 configType
+const (
+	v2rayNTypeVMess       = 1
+	v2rayNTypeShadowsocks = 3
+	v2rayNTypeSocks       = 4
+	v2rayNTypeVLESS       = 5
+	v2rayNTypeTrojan      = 6
+)
+
+type v2rayNConfig struct {
+	Vmess []struct {
+		ConfigType     int    `json:"configType"`
+		Address        string `json:"address"`
+		Port           int    `json:"port"`
+		ID             string `json:"id"`
+		AlterID        int    `json:"alterId"`
+		Security       string `json:"security"`
+		Network        string `json:"network"`
+		Remarks        string `json:"remarks"`
+		HeaderType     string `json:"headerType"`
+		RequestHost    string `json:"requestHost"`
+		Path           string `json:"path"`
+		StreamSecurity string `json:"streamSecurity"`
+		AllowInsecure  string `json:"allowInsecure"`
+		Sni            string `json:"sni"`
+		Subid          string `json:"subid"`
+	} `json:"vmess"`
+	SubItem []struct {
+		Remarks string `json:"remarks"`
+		URL     string `json:"url"`
+	} `json:"subItem"`
+	RoutingIndex int `json:"routingIndex"`
+	Routings     []struct {
+		Rules []struct {
+			OutboundTag string   `json:"outboundTag"`
+			Domain      []string `json:"domain"`
+			IP          []string `json:"ip"`
+		} `json:"rules"`
+	} `json:"routings"`
+}
+
+func (sm *SubscriptionManager) parseV2rayNConfig(data []byte) (*ClientConfig, error) {
+	var raw v2rayNConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("导入配置: 解析 v2rayN 配置失败: %w", err)
+	}
+	cfg := &ClientConfig{Format: ClientFormatV2rayN, Report: newParseReport()}
+	for _, sub := range raw.SubItem {
+		if strings.TrimSpace(sub.URL) != "" {
+			cfg.Subscriptions = append(cfg.Subscriptions, ClientSubscription{Label: sub.Remarks, URL: strings.TrimSpace(sub.URL)})
+		}
+	}
+
+	for i, s := range raw.Vmess {
+		// 属于订阅的节点随订阅更新获取
+		if s.Subid != "" {
+			continue
+		}
+		node := &model.Node{Name: s.Remarks, Addr: s.Address, Port: s.Port, Enabled: true}
+		switch s.ConfigType {
+		case v2rayNTypeVMess:
+			node.ProtocolType = "vmess"
+			node.Username, node.VMessUUID = s.ID, s.ID
+			node.VMessAlterID = s.AlterID
+			node.VMessSecurity = s.Security
+			if node.VMessSecurity == "" {
+				node.VMessSecurity = "auto"
+			}
+			node.VMessNetwork = s.Network
+			if node.VMessNetwork == "" {
+				node.VMessNetwork = "tcp"
+			}
+			node.VMessType = s.HeaderType
+			node.VMessHost = s.RequestHost
+			node.VMessPath = s.Path
+			node.VMessTLS = s.StreamSecurity
+		case v2rayNTypeShadowsocks:
+			node.ProtocolType = "ss"
+			node.Username, node.Password = s.ID, s.ID
+			node.SSMethod = s.Security
+		case v2rayNTypeSocks:
+			node.ProtocolType = "socks5"
+			node.Username, node.Password = s.Security, s.ID
+		case v2rayNTypeTrojan:
+			node.ProtocolType = "trojan"
+			node.Username, node.Password, node.TrojanPassword = s.ID, s.ID, s.ID
+			node.TrojanSNI = s.Sni
+			node.TrojanAllowInsecure = s.AllowInsecure == "true"
+		case v2rayNTypeVLESS:
+			cfg.Report.UnsupportedSchemes["vless"]++
+			continue
+		default:
+			cfg.Report.UnsupportedSchemes[fmt.Sprintf("类型 %d", s.ConfigType)]++
+			continue
+		}
+		if node.Addr == "" || node.Port <= 0 {
+			cfg.Report.Errors = append(cfg.Report.Errors, ParseLineError{Line: i + 1, Scheme: node.ProtocolType, Err: fmt.Errorf("缺少地址或端口")})
+			continue
+		}
+		node.ID = model.GenerateServerID(node.Addr, node.Port, s.ID)
+		if node.Name == "" {
+			node.Name = fmt.Sprintf("%s:%d", node.Addr, node.Port)
+		}
+		rawConfig, _ := json.Marshal(s)
+		node.RawConfig = string(rawConfig)
+		cfg.Nodes = append(cfg.Nodes, *node)
+	}
+
+	// 只取当前使用的路由方案中的直连规则
+	if idx := raw.RoutingIndex; idx >= 0 && idx < len(raw.Routings) {
+		for _, rule := range raw.Routings[idx].Rules {
+			if rule.OutboundTag != "direct" {
+				continue
+			}
+			for _, d := range rule.Domain {
+				addXrayRoute(cfg, d, true)
+			}
+			for _, ip := range rule.IP {
+				addXrayRoute(cfg, ip, false)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// addXrayRoute 转换一条 xray 格式的路由条目；geosite:、geoip:、关键字等无法放入直连列表的计入 SkippedRules。
+func addXrayRoute(cfg *ClientConfig, entry string, domain bool) {
+	entry = strings.TrimSpace(entry)
+	switch {
+	case entry == "":
+	case domain && (strings.HasPrefix(entry, "domain:") || strings.HasPrefix(entry, "full:") || strings.HasPrefix(entry, "regexp:")):
+		cfg.DirectRoutes = append(cfg.DirectRoutes, entry)
+	case domain && !strings.Contains(entry, ":") && strings.Contains(entry, "."):
+		cfg.DirectRoutes = append(cfg.DirectRoutes, "domain:"+entry)
+	case !domain && net.ParseIP(entry) != nil:
+		cfg.DirectRoutes = append(cfg.DirectRoutes, entry)
+	case !domain && strings.Contains(entry, "/"):
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			cfg.DirectRoutes = append(cfg.DirectRoutes, entry)
+		} else {
+			cfg.SkippedRules++
+		}
+	default:
+		cfg.SkippedRules++
+	}
+}
+
+// --- Shadowrocket ---
+
+// parseShadowrocketBackup 解析 Shadowrocket 备份：任意位置的分享链接、[Proxy] 段的 Surge 风格节点与 [Rule] 段的规则。
+func (sm *SubscriptionManager) parseShadowrocketBackup(content string) *ClientConfig {
+	cfg := &ClientConfig{Format: ClientFormatShadowrocket, Report: newParseReport()}
+	section := ""
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			continue
+		}
+		if idx := strings.Index(line, "://"); idx > 0 && !strings.Contains(line[:idx], "=") {
+			scheme := strings.ToLower(line[:idx])
+			parser, ok := sm.parsers[strings.ToLower(line[:idx+3])]
+			if !ok {
+				cfg.Report.UnsupportedSchemes[scheme]++
+				continue
+			}
+			node, err := parser.Parse(line)
+			if err != nil {
+				sm.logDebug("导入配置: 第 %d 行解析失败: %v: %s", i+1, err, logging.Redact(line))
+				cfg.Report.Errors = append(cfg.Report.Errors, ParseLineError{Line: i + 1, Scheme: scheme, Err: err})
+				continue
+			}
+			if sm.acceptNode(node, cfg.Report) {
+				cfg.Nodes = append(cfg.Nodes, *node)
+			}
+			continue
+		}
+		switch section {
+		case "proxy":
+			node, scheme, err := parseSurgeProxy(line)
+			if err == errUnsupportedClientProtocol {
+				cfg.Report.UnsupportedSchemes[scheme]++
+				continue
+			}
+			if err != nil {
+				cfg.Report.Errors = append(cfg.Report.Errors, ParseLineError{Line: i + 1, Scheme: scheme, Err: err})
+				continue
+			}
+			cfg.Nodes = append(cfg.Nodes, *node)
+		case "rule":
+			addClashRule(cfg, line)
+		}
+	}
+	return cfg
+}
+
+// parseSurgeProxy 解析 Surge 风格的节点行：名称 = 类型, 地址, 端口, 键=值, ...
+func parseSurgeProxy(line string) (*model.Node, string, error) {
+	name, def, ok := strings.Cut(line, "=")
+	if !ok {
+		return nil, "", fmt.Errorf("无法识别的节点格式")
+	}
+	fields := strings.Split(def, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	scheme := strings.ToLower(fields[0])
+	if scheme == "direct" || scheme == "reject" {
+		return nil, scheme, errUnsupportedClientProtocol
+	}
+	if len(fields) < 3 {
+		return nil, scheme, fmt.Errorf("缺少地址或端口")
+	}
+	port, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, scheme, fmt.Errorf("端口无效: %w", err)
+	}
+	opts := make(map[string]string)
+	var positional []string
+	for _, f := range fields[3:] {
+		if k, v, ok := strings.Cut(f, "="); ok {
+			opts[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+		} else {
+			positional = append(positional, f)
+		}
+	}
+	node := &model.Node{Name: strings.TrimSpace(name), Addr: fields[1], Port: port, Enabled: true, RawConfig: line}
+	switch scheme {
+	case "ss", "shadowsocks":
+		node.ProtocolType = "ss"
+		node.Username, node.Password = opts["password"], opts["password"]
+		node.SSMethod = opts["encrypt-method"]
+		node.ID = model.GenerateServerID(node.Addr, port, node.Password)
+	case "vmess":
+		uuid := opts["username"]
+		node.ProtocolType = "vmess"
+		node.Username, node.VMessUUID = uuid, uuid
+		node.VMessSecurity = "auto"
+		node.VMessNetwork = "tcp"
+		if opts["ws"] == "true" {
+			node.VMessNetwork = "ws"
+			node.VMessPath = opts["ws-path"]
+			if host, ok := strings.CutPrefix(opts["ws-headers"], "Host:"); ok {
+				node.VMessHost = strings.TrimSpace(host)
+			}
+		}
+		if opts["tls"] == "true" {
+			node.VMessTLS = "tls"
+		}
+		node.ID = model.GenerateServerID(node.Addr, port, uuid)
+	case "trojan":
+		node.ProtocolType = "trojan"
+		node.Username, node.Password, node.TrojanPassword = opts["password"], opts["password"], opts["password"]
+		node.TrojanSNI = opts["sni"]
+		node.TrojanAllowInsecure = opts["skip-cert-verify"] == "true"
+		node.ID = model.GenerateServerID(node.Addr, port, node.Password)
+	case "socks5":
+		node.ProtocolType = "socks5"
+		node.Username, node.Password = opts["username"], opts["password"]
+		if len(positional) >= 2 {
+			node.Username, node.Password = positional[0], positional[1]
+		}
+		node.ID = model.GenerateServerID(node.Addr, port, node.Username)
+	default:
+		return nil, scheme, errUnsupportedClientProtocol
+	}
+	if node.Name == "" {
+		node.Name = fmt.Sprintf("%s:%d", node.Addr, port)
+	}
+	return node, scheme, nil
+}
+
+// --- YAML 字段读取 ---
+
+func mapString(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func mapInt(m map[string]interface{}, key string) int {
+	switch v := m[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+func mapBool(m map[string]interface{}, key string) bool {
+	switch v := m[key].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+func mapMap(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+// mapStrings 读取字符串或字符串列表。
+func mapStrings(m map[string]interface{}, key string) []string {
+	switch v := m[key].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
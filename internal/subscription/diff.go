@@ -0,0 +1,47 @@
+package subscription
+
+import (
+	"sort"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// diffKey 比对用的节点名称：优先使用订阅中的原始名称，改名规则变化不会被当作增删。
+func diffKey(n model.Node) string {
+	if n.OriginalName != "" {
+		return n.OriginalName
+	}
+	return n.Name
+}
+
+// DiffNodes 比对订阅更新前后的节点列表，返回新增、移除与变更（同名但协议、地址或端口不同）的节点名称。
+func DiffNodes(before, after []model.Node) model.SubscriptionDiff {
+	old := make(map[string]model.Node, len(before))
+	for _, n := range before {
+		old[diffKey(n)] = n
+	}
+	diff := model.SubscriptionDiff{At: time.Now()}
+	seen := make(map[string]bool, len(after))
+	for _, n := range after {
+		key := diffKey(n)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case prev.ProtocolType != n.ProtocolType || prev.Addr != n.Addr || prev.Port != n.Port:
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range old {
+		if !seen[key] {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Removed)
+	return diff
+}
@@ -0,0 +1,77 @@
+package subscription
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+)
+
+// NodeFilter 按节点名称过滤订阅节点：include 非空时仅保留匹配的节点，exclude 匹配的节点被丢弃。
+// 用于去掉「剩余流量」「到期时间」「官网」等信息类条目，或只保留特定地区的节点。
+type NodeFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// NewNodeFilter 编译包含/排除规则；两者均为空时返回 nil（不过滤）。
+func NewNodeFilter(include, exclude string) (*NodeFilter, error) {
+	include = strings.TrimSpace(include)
+	exclude = strings.TrimSpace(exclude)
+	if include == "" && exclude == "" {
+		return nil, nil
+	}
+	f := &NodeFilter{}
+	var err error
+	if include != "" {
+		if f.include, err = regexp.Compile(include); err != nil {
+			return nil, fmt.Errorf("包含规则无效: %w", err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("排除规则无效: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// ValidateFilterPattern 校验单条过滤规则（正则），空字符串视为有效。
+func ValidateFilterPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(strings.TrimSpace(pattern)); err != nil {
+		return fmt.Errorf("正则表达式无效: %w", err)
+	}
+	return nil
+}
+
+// Match 判断节点名称是否保留。
+func (f *NodeFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	if f.include != nil && !f.include.MatchString(name) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// Apply 过滤节点列表，返回保留的节点与被过滤的数量。
+func (f *NodeFilter) Apply(nodes []model.Node) ([]model.Node, int) {
+	if f == nil {
+		return nodes, 0
+	}
+	kept := make([]model.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if f.Match(n.Name) {
+			kept = append(kept, n)
+		}
+	}
+	return kept, len(nodes) - len(kept)
+}
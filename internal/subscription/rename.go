@@ -0,0 +1,106 @@
+package subscription
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+)
+
+// multiSpacePattern 合并改名后残留的连续空白
+var multiSpacePattern = regexp.MustCompile(`\s+`)
+
+// isEmojiRune 判断字符是否属于 emoji 相关区段（含国旗区域指示符、变体选择符与零宽连接符）。
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 麻将/扑克、国旗区域指示符、各类符号与表情
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号与装饰符号
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // 箭头与几何图形（⭐ 等）
+		return true
+	case r == 0xFE0F || r == 0x200D || r == 0x20E3: // 变体选择符、零宽连接符、组合键帽
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // 旗帜标签序列
+		return true
+	}
+	return false
+}
+
+// stripEmoji 去除名称中的 emoji。
+func stripEmoji(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmojiRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// ValidateRenameRules 校验改名规则（移除片段须为合法正则）。
+func ValidateRenameRules(rules model.RenameRules) error {
+	if _, err := compileRemovePattern(rules.RemovePattern); err != nil {
+		return err
+	}
+	return nil
+}
+
+func compileRemovePattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("移除规则无效: %w", err)
+	}
+	return re, nil
+}
+
+// ApplyRenameRules 按订阅的改名规则生成显示名称，原始名称保存在 OriginalName 中。
+// {index} 为节点在订阅中的序号（从 1 开始），位数随节点总数补零（至少两位）。
+func ApplyRenameRules(rules model.RenameRules, label string, nodes []model.Node) ([]model.Node, error) {
+	for i := range nodes {
+		if nodes[i].OriginalName == "" {
+			nodes[i].OriginalName = nodes[i].Name
+		}
+	}
+	if rules.IsZero() {
+		return nodes, nil
+	}
+	removeRe, err := compileRemovePattern(rules.RemovePattern)
+	if err != nil {
+		return nodes, err
+	}
+
+	width := len(strconv.Itoa(len(nodes)))
+	if width < 2 {
+		width = 2
+	}
+	for i := range nodes {
+		name := nodes[i].OriginalName
+		if rules.StripEmoji {
+			name = stripEmoji(name)
+		}
+		if removeRe != nil {
+			name = removeRe.ReplaceAllString(name, "")
+		}
+		name = strings.TrimSpace(multiSpacePattern.ReplaceAllString(name, " "))
+		if tpl := strings.TrimSpace(rules.Template); tpl != "" {
+			name = strings.NewReplacer(
+				"{name}", name,
+				"{label}", label,
+				"{index}", fmt.Sprintf("%0*d", width, i+1),
+			).Replace(tpl)
+			name = strings.TrimSpace(multiSpacePattern.ReplaceAllString(name, " "))
+		}
+		if name == "" {
+			// 规则把名称清空时保留原名，避免出现无名节点
+			name = nodes[i].OriginalName
+		}
+		nodes[i].Name = name
+	}
+	return nodes, nil
+}
@@ -0,0 +1,51 @@
+package subscription
+
+import (
+	"strconv"
+	"testing"
+
+	"myproxy.com/p/internal/model"
+)
+
+func TestAssignUniqueServerIDs(t *testing.T) {
+	a := model.GenerateServerID("a.example.com", 443, "pw")
+	b := model.GenerateServerID("b.example.com", 443, "pw")
+	servers := []model.Node{{ID: a}, {ID: b}, {ID: a}, {ID: a}}
+	assignUniqueServerIDs(servers)
+
+	want := []string{a, b, model.DeriveServerID(a, "2"), model.DeriveServerID(a, "3")}
+	seen := make(map[string]bool)
+	for i, s := range servers {
+		if s.ID != want[i] {
+			t.Errorf("第 %d 个节点 ID = %s，期望 %s", i, s.ID, want[i])
+		}
+		if seen[s.ID] {
+			t.Errorf("第 %d 个节点 ID 重复: %s", i, s.ID)
+		}
+		seen[s.ID] = true
+	}
+
+	// 顺序不变时再次分配得到相同的 ID
+	again := []model.Node{{ID: a}, {ID: b}, {ID: a}, {ID: a}}
+	assignUniqueServerIDs(again)
+	for i := range again {
+		if again[i].ID != servers[i].ID {
+			t.Errorf("第 %d 个节点两次分配的 ID 不同", i)
+		}
+	}
+}
+
+func TestAssignUniqueServerIDsDerivedCollision(t *testing.T) {
+	a := model.GenerateServerID("a.example.com", 443, "pw")
+	// 订阅中另一节点的 ID 恰好等于 a 第 2 次出现时派生的 ID
+	taken := model.DeriveServerID(a, "2")
+	servers := []model.Node{{ID: a}, {ID: taken}, {ID: a}}
+	assignUniqueServerIDs(servers)
+
+	if servers[1].ID != taken {
+		t.Fatalf("先出现的节点应保留原 ID")
+	}
+	if want := model.DeriveServerID(taken, strconv.Itoa(2)); servers[2].ID != want {
+		t.Fatalf("派生 ID 冲突时应继续派生: 得到 %s，期望 %s", servers[2].ID, want)
+	}
+}
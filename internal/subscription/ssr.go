@@ -0,0 +1,86 @@
+package subscription
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+)
+
+// ssrUnsupportedHint SSR 节点无法转换时给用户的说明
+const ssrUnsupportedHint = "xray 不支持 SSR 的协议插件与混淆，仅 origin 协议 + plain 混淆的 SSR 节点可按 SS 导入；" +
+	"其余节点可在设置中开启「保留不兼容的 SSR 节点」以禁用状态导入，或改用服务商提供的 SS/VMess 订阅。"
+
+// SSRParser SSR（ShadowsocksR）协议解析器。
+// origin 协议 + plain 混淆的节点与 SS 等价，直接转换为 SS 节点；
+// 其余节点返回 ProtocolType 为 "ssr" 的节点（xray 无法使用），由订阅管理器决定跳过或以禁用状态保留。
+type SSRParser struct{}
+
+// Parse 解析 SSR 链接：ssr://base64(host:port:protocol:method:obfs:base64(password)/?obfsparam=..&protoparam=..&remarks=..)
+func (p *SSRParser) Parse(content string) (*model.Node, error) {
+	decoded, err := decodeBase64Loose(strings.TrimPrefix(content, "ssr://"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSR format: %w", err)
+	}
+	body, query, _ := strings.Cut(string(decoded), "/?")
+	body = strings.TrimSuffix(body, "/")
+
+	// host 可能是含冒号的 IPv6 地址，从右侧取固定的 5 个字段
+	fields := strings.Split(body, ":")
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("invalid SSR format: expected host:port:protocol:method:obfs:password")
+	}
+	n := len(fields)
+	host := strings.Trim(strings.Join(fields[:n-5], ":"), "[]")
+	port, err := strconv.Atoi(fields[n-5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSR port: %w", err)
+	}
+	protocol, method, obfs := fields[n-4], fields[n-3], fields[n-2]
+	passwordBytes, err := decodeBase64Loose(fields[n-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSR password: %w", err)
+	}
+	password := string(passwordBytes)
+
+	params, _ := url.ParseQuery(query)
+	param := func(key string) string {
+		v, err := decodeBase64Loose(params.Get(key))
+		if err != nil {
+			return ""
+		}
+		return string(v)
+	}
+	name := param("remarks")
+	if name == "" {
+		name = fmt.Sprintf("%s:%d", host, port)
+	}
+
+	s := &model.Node{
+		ID:               model.GenerateServerID(host, port, password),
+		Name:             name,
+		Addr:             host,
+		Port:             port,
+		Username:         password, // 与 SS 一致，以密码作为标识
+		Password:         password,
+		Enabled:          true,
+		ProtocolType:     "ssr",
+		SSMethod:         method,
+		SSRProtocol:      protocol,
+		SSRProtocolParam: param("protoparam"),
+		SSRObfs:          obfs,
+		SSRObfsParam:     param("obfsparam"),
+		RawConfig:        content,
+	}
+	if ssrCompatibleWithSS(protocol, obfs) {
+		s.ProtocolType = "ss"
+	}
+	return s, nil
+}
+
+// ssrCompatibleWithSS origin 协议 + plain 混淆的 SSR 节点与 SS 协议等价。
+func ssrCompatibleWithSS(protocol, obfs string) bool {
+	return (protocol == "" || protocol == "origin") && (obfs == "" || obfs == "plain")
+}
@@ -1,20 +1,20 @@
 package subscription
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/logging"
 	"myproxy.com/p/internal/model"
-	"myproxy.com/p/internal/utils"
 )
 
 // ServerParser 服务器配置解析器接口
@@ -30,14 +30,10 @@ type VMessParser struct{}
 func (p *VMessParser) Parse(content string) (*model.Node, error) {
 	// 移除前缀
 	vmessData := strings.TrimPrefix(content, "vmess://")
-	// 解码Base64
-	decoded, err := base64.StdEncoding.DecodeString(vmessData)
+	// 解码Base64（兼容 URL 安全字母表与缺少填充）
+	decoded, err := decodeBase64Loose(vmessData)
 	if err != nil {
-		// 尝试URL安全的Base64解码
-		decoded, err = base64.URLEncoding.DecodeString(vmessData)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
 
 	// 解析JSON - 包含所有字段
@@ -76,7 +72,7 @@ func (p *VMessParser) Parse(content string) (*model.Node, error) {
 	}
 
 	// 生成服务器ID（使用 addr:port:uuid）
-	serverID := utils.GenerateServerID(vmessConfig.Add, port, vmessConfig.Id)
+	serverID := model.GenerateServerID(vmessConfig.Add, port, vmessConfig.Id)
 
 	// 创建服务器配置，包含所有字段
 	s := &model.Node{
@@ -142,19 +138,10 @@ func (p *SSParser) Parse(content string) (*model.Node, error) {
 
 	if !found {
 		// 如果没有 @ 符号，说明整个部分都是 Base64 编码的
-		// 解码Base64 - 处理可能的填充问题
-		base64Str := ssDataWithoutRemark
-		// 确保Base64字符串的长度是4的倍数，必要时添加填充字符
-		for len(base64Str)%4 != 0 {
-			base64Str += "="
-		}
-		decoded, err := base64.StdEncoding.DecodeString(base64Str)
+		// 解码Base64（兼容 URL 安全字母表与缺少填充）
+		decoded, err := decodeBase64Loose(ssDataWithoutRemark)
 		if err != nil {
-			// 尝试URL安全的Base64解码
-			decoded, err = base64.URLEncoding.DecodeString(base64Str)
-			if err != nil {
-				return nil, err
-			}
+			return nil, err
 		}
 
 		ssStr := string(decoded)
@@ -168,14 +155,10 @@ func (p *SSParser) Parse(content string) (*model.Node, error) {
 			return nil, fmt.Errorf("invalid SS format: missing cipher:password")
 		}
 	} else {
-		// 解码Base64
-		decoded, err := base64.StdEncoding.DecodeString(base64Part)
+		// 解码Base64（兼容 URL 安全字母表与缺少填充）
+		decoded, err := decodeBase64Loose(base64Part)
 		if err != nil {
-			// 尝试URL安全的Base64解码
-			decoded, err = base64.URLEncoding.DecodeString(base64Part)
-			if err != nil {
-				return nil, err
-			}
+			return nil, err
 		}
 
 		ssStr := string(decoded)
@@ -217,7 +200,7 @@ func (p *SSParser) Parse(content string) (*model.Node, error) {
 	}
 
 	// 生成服务器ID
-	serverID := utils.GenerateServerID(addr, port, password)
+	serverID := model.GenerateServerID(addr, port, password)
 
 	// 创建服务器配置
 	s := &model.Node{
@@ -326,7 +309,7 @@ func (p *TrojanParser) Parse(content string) (*model.Node, error) {
 	}
 
 	// 生成服务器ID
-	serverID := utils.GenerateServerID(addr, port, password)
+	serverID := model.GenerateServerID(addr, port, password)
 
 	// 创建服务器配置
 	s := &model.Node{
@@ -379,7 +362,7 @@ func (p *SOCKS5Parser) Parse(content string) (*model.Node, error) {
 	}
 
 	// 生成服务器ID
-	serverID := utils.GenerateServerID(addr, port, username)
+	serverID := model.GenerateServerID(addr, port, username)
 
 	// 创建服务器配置
 	s := &model.Node{
@@ -421,7 +404,7 @@ func (p *SimpleParser) Parse(content string) (*model.Node, error) {
 	}
 
 	// 生成服务器ID
-	serverID := utils.GenerateServerID(addr, port, username)
+	serverID := model.GenerateServerID(addr, port, username)
 
 	// 创建服务器配置
 	s := &model.Node{
@@ -441,11 +424,138 @@ func (p *SimpleParser) Parse(content string) (*model.Node, error) {
 	return s, nil
 }
 
+// ParseLineError 单行解析失败的记录
+type ParseLineError struct {
+	Line   int    // 行号（从 1 开始，基于解码后的订阅正文）
+	Scheme string // 协议前缀（不含 "://"），无法识别时为空
+	Err    error
+}
+
+// ParseReport 订阅解析报告：记录导入数量、逐行解析错误与不支持协议的统计
+type ParseReport struct {
+	Imported           int
+	Filtered           int // 被订阅包含/排除规则过滤掉的节点数（不计入 Skipped）
+	Errors             []ParseLineError
+	UnsupportedSchemes map[string]int          // key 为协议名（如 ssr），value 为跳过的行数
+	SSRConverted       int                     // 按 SS 导入的 SSR 节点数（origin + plain）
+	SSRKept            int                     // 以禁用状态保留的不兼容 SSR 节点数
+	Invalid            int                     // 字段无效（地址、端口、UUID 等）而以禁用状态导入的节点数，计入 Imported
+	Diff               *model.SubscriptionDiff // 更新已有订阅时相对上次的节点变化；首次获取或批量汇总时为 nil
+	NotModified        bool                    // 服务器返回 304（内容未变化），未重新解析，Imported 为保留的节点数
+}
+
+// newParseReport 创建空的解析报告
+func newParseReport() *ParseReport {
+	return &ParseReport{UnsupportedSchemes: make(map[string]int)}
+}
+
+// Skipped 返回被跳过的行数（解析失败 + 不支持的协议）
+func (r *ParseReport) Skipped() int {
+	if r == nil {
+		return 0
+	}
+	n := len(r.Errors)
+	for _, c := range r.UnsupportedSchemes {
+		n += c
+	}
+	return n
+}
+
+// Merge 合并另一份报告（用于批量更新订阅时汇总）
+func (r *ParseReport) Merge(other *ParseReport) {
+	if r == nil || other == nil {
+		return
+	}
+	if r.UnsupportedSchemes == nil {
+		r.UnsupportedSchemes = make(map[string]int)
+	}
+	r.Imported += other.Imported
+	r.Filtered += other.Filtered
+	r.SSRConverted += other.SSRConverted
+	r.SSRKept += other.SSRKept
+	r.Errors = append(r.Errors, other.Errors...)
+	for scheme, c := range other.UnsupportedSchemes {
+		r.UnsupportedSchemes[scheme] += c
+	}
+}
+
+// Summary 返回简要的中文摘要，例如 "导入 120 个，跳过 8 个：3 个 ssr 不支持，5 个解析失败"
+func (r *ParseReport) Summary() string {
+	if r == nil {
+		return ""
+	}
+	if r.NotModified {
+		return fmt.Sprintf("订阅内容未变化，保留 %d 个节点", r.Imported)
+	}
+	filtered := ""
+	if r.Filtered > 0 {
+		filtered = fmt.Sprintf("（规则过滤 %d 个）", r.Filtered)
+	}
+	if r.Invalid > 0 {
+		filtered += fmt.Sprintf("（%d 个配置无效已禁用）", r.Invalid)
+	}
+	skipped := r.Skipped()
+	if skipped == 0 {
+		return fmt.Sprintf("导入 %d 个%s", r.Imported, filtered)
+	}
+	schemes := make([]string, 0, len(r.UnsupportedSchemes))
+	for scheme := range r.UnsupportedSchemes {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	parts := make([]string, 0, len(schemes)+1)
+	for _, scheme := range schemes {
+		parts = append(parts, fmt.Sprintf("%d 个 %s 不支持", r.UnsupportedSchemes[scheme], scheme))
+	}
+	if len(r.Errors) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个解析失败", len(r.Errors)))
+	}
+	return fmt.Sprintf("导入 %d 个%s，跳过 %d 个：%s", r.Imported, filtered, skipped, strings.Join(parts, "，"))
+}
+
+// Hint 返回针对跳过原因的处理建议（目前为不兼容的 SSR 节点），无建议时返回空字符串
+func (r *ParseReport) Hint() string {
+	if r == nil || r.UnsupportedSchemes["ssr"] == 0 {
+		return ""
+	}
+	return ssrUnsupportedHint
+}
+
+// Details 返回逐行的错误明细，每条一行，用于写入日志
+func (r *ParseReport) Details() []string {
+	if r == nil {
+		return nil
+	}
+	lines := make([]string, 0, len(r.Errors)+2)
+	if r.SSRConverted > 0 {
+		lines = append(lines, fmt.Sprintf("%d 个 SSR 节点（origin + plain）已按 SS 导入", r.SSRConverted))
+	}
+	if r.SSRKept > 0 {
+		lines = append(lines, fmt.Sprintf("%d 个不兼容的 SSR 节点以禁用状态保留", r.SSRKept))
+	}
+	if hint := r.Hint(); hint != "" {
+		lines = append(lines, hint)
+	}
+	for _, e := range r.Errors {
+		if e.Scheme != "" {
+			lines = append(lines, fmt.Sprintf("第 %d 行 (%s): %v", e.Line, e.Scheme, e.Err))
+		} else {
+			lines = append(lines, fmt.Sprintf("第 %d 行: %v", e.Line, e.Err))
+		}
+	}
+	return lines
+}
+
 // SubscriptionManager 订阅管理器
 // 注意：不再维护订阅列表缓存，数据统一由 Store 管理
 type SubscriptionManager struct {
 	client  *http.Client
 	parsers map[string]ServerParser // 服务器配置解析器映射，key为协议前缀
+	logger  *logging.SafeLogger     // 可选，未设置时不输出诊断日志
+	// keepUnsupportedSSR 返回是否以禁用状态保留不兼容的 SSR 节点；未设置时跳过这些节点
+	keepUnsupportedSSR func() bool
+	// validateNode 校验解析得到的节点（由 store 设置）；未设置时不校验
+	validateNode func(node *model.Node) error
 }
 
 // NewSubscriptionManager 创建新的订阅管理器
@@ -456,6 +566,7 @@ func NewSubscriptionManager() *SubscriptionManager {
 	parsers["ss://"] = &SSParser{}
 	parsers["trojan://"] = &TrojanParser{}
 	parsers["socks5://"] = &SOCKS5Parser{}
+	parsers["ssr://"] = &SSRParser{}
 
 	sm := &SubscriptionManager{
 		client: &http.Client{
@@ -467,65 +578,202 @@ func NewSubscriptionManager() *SubscriptionManager {
 	return sm
 }
 
+// SetLogger 设置诊断日志输出。订阅内容可能包含凭据，写日志前统一经 logging.Redact 脱敏。
+func (sm *SubscriptionManager) SetLogger(logger *logging.SafeLogger) {
+	sm.logger = logger
+}
+
+// SetKeepUnsupportedSSR 设置不兼容 SSR 节点的处理方式（每次解析时调用 fn 读取当前配置）。
+func (sm *SubscriptionManager) SetKeepUnsupportedSSR(fn func() bool) {
+	sm.keepUnsupportedSSR = fn
+}
+
+// SetNodeValidator 设置节点校验函数：校验失败的节点仍然导入，但以禁用状态保存并记录原因。
+func (sm *SubscriptionManager) SetNodeValidator(fn func(node *model.Node) error) {
+	sm.validateNode = fn
+}
+
+// flagInvalidNode 校验节点，无效时禁用并写入原因，返回是否无效。
+func (sm *SubscriptionManager) flagInvalidNode(node *model.Node) bool {
+	if sm.validateNode == nil {
+		return false
+	}
+	err := sm.validateNode(node)
+	if err == nil {
+		return false
+	}
+	node.Enabled = false
+	node.DisabledReason = err.Error()
+	node.DisabledAt = time.Now()
+	return true
+}
+
+// applySSRPolicy 处理 xray 无法使用的 SSR 节点：按配置改为禁用状态保留，否则返回 false 表示跳过。
+// 其他节点原样保留。
+func (sm *SubscriptionManager) applySSRPolicy(node *model.Node) bool {
+	if node.ProtocolType != "ssr" {
+		return true
+	}
+	if sm.keepUnsupportedSSR == nil || !sm.keepUnsupportedSSR() {
+		return false
+	}
+	node.Enabled = false
+	return true
+}
+
+// logDebug 输出调试日志（logger 未设置时忽略）
+func (sm *SubscriptionManager) logDebug(format string, args ...interface{}) {
+	if sm.logger != nil {
+		sm.logger.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+// ParseShareLink 解析单条分享链接（如 vmess:// ss:// ssr:// trojan:// socks5://），用于直接导入单个节点。
+func (sm *SubscriptionManager) ParseShareLink(link string) (*model.Node, error) {
+	link = strings.TrimSpace(link)
+	idx := strings.Index(link, "://")
+	if idx == -1 {
+		return nil, fmt.Errorf("无效的分享链接")
+	}
+	parser, ok := sm.parsers[strings.ToLower(link[:idx+3])]
+	if !ok {
+		return nil, fmt.Errorf("不支持的协议: %s", link[:idx])
+	}
+	node, err := parser.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	if !sm.applySSRPolicy(node) {
+		return nil, fmt.Errorf("不支持该 SSR 节点（协议 %s，混淆 %s）：%s", node.SSRProtocol, node.SSRObfs, ssrUnsupportedHint)
+	}
+	return node, nil
+}
+
+// applySubscriptionRules 按订阅配置先过滤（基于原始名称）再改名，并把过滤数量记入报告。
+// sub 为 nil（新订阅）时仅记录原始名称。
+func applySubscriptionRules(sub *database.Subscription, label string, servers []model.Node, report *ParseReport) ([]model.Node, error) {
+	if sub == nil {
+		return ApplyRenameRules(model.RenameRules{}, label, servers)
+	}
+	filter, err := NewNodeFilter(sub.IncludeFilter, sub.ExcludeFilter)
+	if err != nil {
+		return nil, fmt.Errorf("订阅过滤规则无效: %w", err)
+	}
+	kept, filtered := filter.Apply(servers)
+	if report != nil && filtered > 0 {
+		report.Filtered += filtered
+		report.Imported -= filtered
+		if report.Imported < 0 {
+			report.Imported = 0
+		}
+	}
+	return ApplyRenameRules(sub.RenameRules, label, kept)
+}
+
+// subscriptionHeaders 订阅响应中需要保存的头：缓存标识（ETag、Last-Modified）用于下次条件请求，
+// subscription-userinfo 为服务商提供的流量与到期信息
+type subscriptionHeaders struct {
+	etag         string
+	lastModified string
+	userinfo     string
+}
+
 // downloadAndParseSubscription 仅发起 HTTP 请求并解析订阅正文，不写数据库。
-func (sm *SubscriptionManager) downloadAndParseSubscription(url string) ([]model.Node, error) {
-	resp, err := sm.client.Get(url)
+// cached 非 nil 时附带上次的 ETag、Last-Modified 发起条件请求；服务器返回 304 时 notModified 为 true，不返回节点。
+func (sm *SubscriptionManager) downloadAndParseSubscription(url string, cached *subscriptionHeaders) (servers []model.Node, report *ParseReport, headers subscriptionHeaders, notModified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取订阅失败: %w", err)
+		return nil, nil, headers, false, fmt.Errorf("获取订阅失败: %w", err)
+	}
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+	resp, err := sm.client.Do(req)
+	if err != nil {
+		return nil, nil, headers, false, fmt.Errorf("获取订阅失败: %w", err)
 	}
 	defer resp.Body.Close()
 
+	headers.userinfo = resp.Header.Get("Subscription-Userinfo")
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, headers, true, nil
+	}
+	if resp.StatusCode == http.StatusOK {
+		headers.etag, headers.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取订阅内容失败: %w", err)
+		return nil, nil, headers, false, fmt.Errorf("读取订阅内容失败: %w", err)
 	}
 
-	servers, err := sm.parseSubscription(string(body))
+	servers, report, err = sm.parseSubscription(string(body))
 	if err != nil {
-		return nil, fmt.Errorf("解析订阅失败: %w", err)
+		return nil, report, headers, false, fmt.Errorf("解析订阅失败: %w", err)
 	}
 
-	return servers, nil
+	return servers, report, headers, false, nil
 }
 
-// persistSubscriptionServers 将解析得到的节点写入数据库。restoreByID 非 nil 时优先用其中保存的 Selected/Delay（用于订阅更新），否则回退到数据库已有记录。
-func (sm *SubscriptionManager) persistSubscriptionServers(url, subscriptionLabel string, servers []model.Node, restoreByID map[string]struct {
-	Selected bool
-	Delay    int
-}) error {
+// persistSubscriptionServers 将解析得到的节点按 ID 合并写入数据库：已有节点只刷新订阅提供的字段，
+// 延迟、选中、健康状态与拨号选项等用户状态保留；订阅中已不存在的节点被删除。
+// 最后保存本次响应的缓存标识（供下次更新发起条件请求）与流量信息，并清除更新失败记录。
+func (sm *SubscriptionManager) persistSubscriptionServers(url, subscriptionLabel string, servers []model.Node, headers subscriptionHeaders) error {
 	sub, err := database.AddOrUpdateSubscription(url, subscriptionLabel)
 	if err != nil {
 		return fmt.Errorf("保存订阅到数据库失败: %w", err)
 	}
-
-	var subscriptionID *int64
-	if sub != nil {
-		subscriptionID = &sub.ID
+	if sub == nil {
+		return fmt.Errorf("保存订阅到数据库失败: 订阅不存在")
 	}
 
-	for _, s := range servers {
-		if state, ok := restoreByID[s.ID]; ok {
-			s.Selected = state.Selected
-			s.Delay = state.Delay
-		} else if existingServer, err := database.GetServer(s.ID); err == nil && existingServer != nil {
-			s.Selected = existingServer.Selected
-			s.Delay = existingServer.Delay
-		}
-
-		if err := database.AddOrUpdateServer(s, subscriptionID); err != nil {
-			return fmt.Errorf("保存服务器到数据库失败: %w", err)
+	for i := range servers {
+		// 同一节点已属于其他订阅或为手动添加时，以订阅地址派生 ID，避免覆盖对方的记录
+		if owner, exists, err := database.GetServerSubscriptionID(servers[i].ID); err == nil && exists &&
+			(owner == nil || *owner != sub.ID) {
+			servers[i].ID = model.DeriveServerID(servers[i].ID, url)
 		}
 	}
 
+	merged, err := database.UpsertSubscriptionServers(sub.ID, servers)
+	if err != nil {
+		return fmt.Errorf("保存服务器到数据库失败: %w", err)
+	}
+	sm.logDebug("订阅: %s 合并节点：新增 %d，更新 %d，移除 %d", logging.Redact(url), merged.Added, merged.Updated, merged.Removed)
+	if err := database.UpdateSubscriptionValidators(sub.ID, headers.etag, headers.lastModified); err != nil {
+		sm.logDebug("保存订阅缓存标识失败: %v", err)
+	}
+	sm.saveUpdateStatus(sub.ID, headers, nil)
 	return nil
 }
 
-// FetchSubscription 从URL获取订阅服务器列表
+// saveUpdateStatus 记录一次更新的结果：响应带有流量信息时保存，updateErr 非 nil 时记录失败原因，否则清除失败记录。
+func (sm *SubscriptionManager) saveUpdateStatus(id int64, headers subscriptionHeaders, updateErr error) {
+	if headers.userinfo != "" {
+		if err := database.UpdateSubscriptionUserinfo(id, headers.userinfo); err != nil {
+			sm.logDebug("保存订阅流量信息失败: %v", err)
+		}
+	}
+	message := ""
+	if updateErr != nil {
+		message = updateErr.Error()
+	}
+	if err := database.UpdateSubscriptionLastError(id, message); err != nil {
+		sm.logDebug("保存订阅更新状态失败: %v", err)
+	}
+}
+
+// FetchSubscription 从URL获取订阅服务器列表，同时返回解析报告
 // label 参数用于为订阅添加标签，如果为空则使用默认标签
-func (sm *SubscriptionManager) FetchSubscription(url string, label ...string) ([]model.Node, error) {
-	servers, err := sm.downloadAndParseSubscription(url)
+func (sm *SubscriptionManager) FetchSubscription(url string, label ...string) ([]model.Node, *ParseReport, error) {
+	servers, report, headers, _, err := sm.downloadAndParseSubscription(url, nil)
 	if err != nil {
-		return nil, err
+		return nil, report, err
 	}
 
 	subscriptionLabel := ""
@@ -533,16 +781,25 @@ func (sm *SubscriptionManager) FetchSubscription(url string, label ...string) ([
 		subscriptionLabel = label[0]
 	}
 
-	if err := sm.persistSubscriptionServers(url, subscriptionLabel, servers, nil); err != nil {
-		return nil, err
+	// 订阅可能已由 Store 先行创建（含过滤与改名规则），按其规则处理
+	existingSub, err := database.GetSubscriptionByURL(url)
+	if err != nil {
+		existingSub = nil
+	}
+	if servers, err = applySubscriptionRules(existingSub, subscriptionLabel, servers, report); err != nil {
+		return nil, report, err
 	}
 
-	return servers, nil
+	if err := sm.persistSubscriptionServers(url, subscriptionLabel, servers, headers); err != nil {
+		return nil, report, err
+	}
+
+	return servers, report, nil
 }
 
-// UpdateSubscription 更新订阅
+// UpdateSubscription 更新订阅，返回本次解析报告
 // label 参数用于更新订阅标签，如果为空则保持原有标签
-func (sm *SubscriptionManager) UpdateSubscription(url string, label ...string) error {
+func (sm *SubscriptionManager) UpdateSubscription(url string, label ...string) (*ParseReport, error) {
 	// 获取订阅服务器列表（会自动保存到数据库）
 	subscriptionLabel := ""
 	if len(label) > 0 && label[0] != "" {
@@ -555,50 +812,70 @@ func (sm *SubscriptionManager) UpdateSubscription(url string, label ...string) e
 		}
 	}
 
-	// 获取现有订阅（用于清理旧服务器和保存状态）
+	// 获取现有订阅（用于应用订阅规则和计算节点变化）
 	existingSub, err := database.GetSubscriptionByURL(url)
 	if err != nil {
-		return fmt.Errorf("获取订阅信息失败: %w", err)
+		return nil, fmt.Errorf("获取订阅信息失败: %w", err)
 	}
 
-	// 如果存在旧订阅，先保存现有服务器的状态（Selected 和 Delay）
-	// 这样在清理后重新保存时能恢复状态
-	serverStates := make(map[string]struct {
-		Selected bool
-		Delay    int
-	})
+	var existingServers []model.Node
 	if existingSub != nil {
-		// 获取该订阅下的所有服务器
-		existingServers, err := database.GetServersBySubscriptionID(existingSub.ID)
-		if err == nil {
-			for _, s := range existingServers {
-				serverStates[s.ID] = struct {
-					Selected bool
-					Delay    int
-				}{
-					Selected: s.Selected,
-					Delay:    s.Delay,
-				}
-			}
-		}
+		existingServers, _ = database.GetServersBySubscriptionID(existingSub.ID)
 	}
 
-	servers, err := sm.downloadAndParseSubscription(url)
+	// 已有节点时发起条件请求；节点被全部删除后需要完整拉取才能恢复
+	var cached *subscriptionHeaders
+	if existingSub != nil && len(existingServers) > 0 {
+		cached = &subscriptionHeaders{etag: existingSub.ETag, lastModified: existingSub.LastModified}
+	}
+	servers, report, headers, notModified, err := sm.downloadAndParseSubscription(url, cached)
 	if err != nil {
-		return err
+		if existingSub != nil {
+			sm.saveUpdateStatus(existingSub.ID, headers, err)
+		}
+		return report, err
+	}
+	if notModified {
+		// 内容未变化：节点保持不动，只刷新标签与检查时间
+		sub, err := database.AddOrUpdateSubscription(url, subscriptionLabel)
+		if err != nil {
+			return nil, fmt.Errorf("保存订阅到数据库失败: %w", err)
+		}
+		sm.saveUpdateStatus(sub.ID, headers, nil)
+		sm.logDebug("订阅: %s 内容未变化（304），跳过解析", logging.Redact(url))
+		report = newParseReport()
+		report.NotModified = true
+		report.Imported = len(existingServers)
+		return report, nil
 	}
 
-	if existingSub != nil {
-		if err := database.DeleteServersBySubscriptionID(existingSub.ID); err != nil {
-			return fmt.Errorf("清理旧订阅服务器失败: %w", err)
+	if servers, err = applySubscriptionRules(existingSub, subscriptionLabel, servers, report); err != nil {
+		if existingSub != nil {
+			sm.saveUpdateStatus(existingSub.ID, headers, err)
 		}
+		return report, err
 	}
 
-	if err := sm.persistSubscriptionServers(url, subscriptionLabel, servers, serverStates); err != nil {
-		return err
+	// 按 ID 合并而非删除后重建，节点的延迟、选中等用户状态得以保留
+	if err := sm.persistSubscriptionServers(url, subscriptionLabel, servers, headers); err != nil {
+		if existingSub != nil {
+			sm.saveUpdateStatus(existingSub.ID, headers, err)
+		}
+		return report, err
 	}
 
-	return nil
+	// 记录相对上次的节点变化，供订阅卡片展示
+	if existingSub != nil {
+		diff := DiffNodes(existingServers, servers)
+		if err := database.UpdateSubscriptionLastDiff(existingSub.ID, diff); err != nil {
+			sm.logDebug("保存订阅变化失败: %v", err)
+		}
+		if report != nil {
+			report.Diff = &diff
+		}
+	}
+
+	return report, nil
 }
 
 // UpdateSubscriptionByID 根据订阅 ID 更新订阅。
@@ -606,28 +883,27 @@ func (sm *SubscriptionManager) UpdateSubscription(url string, label ...string) e
 // 参数：
 //   - id: 订阅 ID
 //
-// 返回：错误（如果有）
-func (sm *SubscriptionManager) UpdateSubscriptionByID(id int64) error {
+// 返回：解析报告和错误（如果有）
+func (sm *SubscriptionManager) UpdateSubscriptionByID(id int64) (*ParseReport, error) {
 	// 根据 ID 获取订阅信息
 	sub, err := database.GetSubscriptionByID(id)
 	if err != nil {
-		return fmt.Errorf("获取订阅信息失败: %w", err)
+		return nil, fmt.Errorf("获取订阅信息失败: %w", err)
 	}
 	if sub == nil {
-		return fmt.Errorf("订阅不存在")
+		return nil, fmt.Errorf("订阅不存在")
 	}
 
 	// 调用 UpdateSubscription 更新订阅（会拉取最新内容）
 	return sm.UpdateSubscription(sub.URL, sub.Label)
 }
 
-// parseSubscription 解析订阅内容
-func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node, error) {
-	// 尝试解码Base64
-	decoded, err := base64.StdEncoding.DecodeString(content)
-	if err == nil {
-		content = string(decoded)
-	}
+// parseSubscription 解析订阅内容，返回节点列表与逐行解析报告
+func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node, *ParseReport, error) {
+	report := newParseReport()
+
+	// 还原 Base64 编码的正文（整体或逐行）
+	content = decodeSubscriptionBody(content)
 
 	// 1. 尝试JSON格式
 	var jsonServers []struct {
@@ -644,7 +920,7 @@ func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node,
 		for i, js := range jsonServers {
 			rawConfig, _ := json.Marshal(js)
 			servers[i] = model.Node{
-				ID:           utils.GenerateServerID(js.Addr, js.Port, js.Username),
+				ID:           model.GenerateServerID(js.Addr, js.Port, js.Username),
 				Name:         js.Name,
 				Addr:         js.Addr,
 				Port:         js.Port,
@@ -656,15 +932,21 @@ func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node,
 				ProtocolType: "socks5", // JSON格式默认为 SOCKS5
 				RawConfig:    string(rawConfig),
 			}
+			if sm.flagInvalidNode(&servers[i]) {
+				sm.logDebug("订阅: 第 %d 个节点 %s", i+1, servers[i].DisabledReason)
+				report.Invalid++
+			}
 		}
-		return servers, nil
+		assignUniqueServerIDs(servers)
+		report.Imported = len(servers)
+		return servers, report, nil
 	}
 
 	// 2. 尝试Clash格式 (每行一个服务器配置)
 	lines := strings.Split(content, "\n")
 	var servers []model.Node
 
-	for _, line := range lines {
+	for i, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
@@ -673,40 +955,90 @@ func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node,
 		// 尝试解析Clash格式
 		if strings.HasPrefix(line, "- name:") {
 			// 多行Clash格式，暂时不支持
+			report.UnsupportedSchemes["clash"]++
 			continue
 		}
 
 		// 使用注册的解析器解析服务器配置
 		var parsedServer *model.Node
+		var parseErr error
+		scheme := ""
 
 		// 直接根据前缀获取解析器
 		// 查找字符串中第一个 "://" 出现的位置
 		if idx := strings.Index(line, "://"); idx != -1 {
 			// 提取前缀（包括 "://"）
 			prefix := line[:idx+3]
-			fmt.Println("prefix", prefix)
+			scheme = strings.ToLower(line[:idx])
 			// 从 map 中获取对应的解析器
-			if parser, ok := sm.parsers[prefix]; ok {
-				parsedServer, err = parser.Parse(line)
-				fmt.Println("parsedServer", parsedServer)
+			parser, ok := sm.parsers[prefix]
+			if !ok {
+				sm.logDebug("订阅: 第 %d 行协议 %s 不支持: %s", i+1, scheme, logging.Redact(line))
+				report.UnsupportedSchemes[scheme]++
+				continue
 			}
+			parsedServer, parseErr = parser.Parse(line)
+		} else {
+			// 无协议前缀时尝试使用 SimpleParser
+			parsedServer, parseErr = (&SimpleParser{}).Parse(line)
 		}
 
-		// 如果没有找到解析器或解析失败，尝试使用 SimpleParser
-		if parsedServer == nil {
-			simpleParser := &SimpleParser{}
-			parsedServer, err = simpleParser.Parse(line)
+		if parseErr != nil || parsedServer == nil {
+			if parseErr == nil {
+				parseErr = fmt.Errorf("无法识别的节点格式")
+			}
+			sm.logDebug("订阅: 第 %d 行解析失败: %v: %s", i+1, parseErr, logging.Redact(line))
+			report.Errors = append(report.Errors, ParseLineError{Line: i + 1, Scheme: scheme, Err: parseErr})
+			continue
 		}
 
-		// 如果解析成功，添加到服务器列表
-		if parsedServer != nil {
-			servers = append(servers, *parsedServer)
+		if scheme == "ssr" {
+			if !sm.applySSRPolicy(parsedServer) {
+				sm.logDebug("订阅: 第 %d 行 SSR 节点不兼容（协议 %s，混淆 %s），已跳过", i+1, parsedServer.SSRProtocol, parsedServer.SSRObfs)
+				report.UnsupportedSchemes["ssr"]++
+				continue
+			}
+			if parsedServer.ProtocolType == "ssr" {
+				report.SSRKept++
+			} else {
+				report.SSRConverted++
+			}
 		}
+
+		if sm.flagInvalidNode(parsedServer) {
+			sm.logDebug("订阅: 第 %d 行节点%s，已禁用: %s", i+1, parsedServer.DisabledReason, logging.Redact(line))
+			report.Invalid++
+		}
+
+		servers = append(servers, *parsedServer)
 	}
 
+	report.Imported = len(servers)
 	if len(servers) == 0 {
-		return nil, fmt.Errorf("不支持的订阅格式")
+		return nil, report, fmt.Errorf("不支持的订阅格式（%s）", report.Summary())
 	}
 
-	return servers, nil
+	assignUniqueServerIDs(servers)
+	return servers, report, nil
+}
+
+// assignUniqueServerIDs 处理同一订阅内 ID 相同的节点（地址、端口、身份标识相同，仅传输参数或名称不同）：
+// 第 n 次出现（n ≥ 2）的节点改用由序号派生的 ID，只要订阅中的顺序不变，刷新后 ID 保持一致。
+func assignUniqueServerIDs(servers []model.Node) {
+	seen := make(map[string]int, len(servers))
+	used := make(map[string]bool, len(servers))
+	for i := range servers {
+		base := servers[i].ID
+		seen[base]++
+		if seen[base] == 1 && !used[base] {
+			used[base] = true
+			continue
+		}
+		id := model.DeriveServerID(base, strconv.Itoa(seen[base]))
+		for used[id] {
+			id = model.DeriveServerID(id, strconv.Itoa(seen[base]))
+		}
+		servers[i].ID = id
+		used[id] = true
+	}
 }
@@ -0,0 +1,20 @@
+package subscription
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidateTestURL 校验订阅的测速地址（须为 http(s) 地址），空字符串表示使用全局探测地址。
+func ValidateTestURL(testURL string) error {
+	testURL = strings.TrimSpace(testURL)
+	if testURL == "" {
+		return nil
+	}
+	u, err := url.Parse(testURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("测速地址无效: %s", testURL)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package subscription
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// ParseUserinfo 解析 subscription-userinfo 响应头，如 "upload=123; download=456; total=1073741824; expire=1767225600"。
+// 未知字段与无法解析的值忽略；没有任何可用字段时返回 false。
+func ParseUserinfo(header string) (model.SubscriptionQuota, bool) {
+	var quota model.SubscriptionQuota
+	found := false
+	for _, part := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		// 部分服务商以浮点数或科学计数法输出字节数
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || n < 0 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "upload":
+			quota.Upload = int64(n)
+		case "download":
+			quota.Download = int64(n)
+		case "total":
+			quota.Total = int64(n)
+		case "expire":
+			if n > 0 {
+				quota.Expire = time.Unix(int64(n), 0)
+			}
+		default:
+			continue
+		}
+		found = true
+	}
+	return quota, found
+}
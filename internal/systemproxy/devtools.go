@@ -0,0 +1,285 @@
+package systemproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DevTool 可一键写入代理设置的开发工具。
+type DevTool string
+
+const (
+	DevToolGit    DevTool = "git"    // git config --global http.proxy / https.proxy
+	DevToolNpm    DevTool = "npm"    // npm config proxy / https-proxy
+	DevToolDocker DevTool = "docker" // Docker 客户端 ~/.docker/config.json 的 proxies.default（作用于容器内）
+)
+
+// DevTools 支持的开发工具，按界面展示顺序。
+var DevTools = []DevTool{DevToolGit, DevToolNpm, DevToolDocker}
+
+// DevToolSetting 一项代理设置的变化；Old、New 为空表示未设置 / 移除。
+type DevToolSetting struct {
+	Tool DevTool
+	Key  string
+	Old  string
+	New  string
+}
+
+// DisplayName 返回工具的显示名称。
+func (t DevTool) DisplayName() string {
+	switch t {
+	case DevToolGit:
+		return "Git（全局）"
+	case DevToolNpm:
+		return "npm"
+	case DevToolDocker:
+		return "Docker 客户端"
+	default:
+		return string(t)
+	}
+}
+
+// devToolKeys 工具中与代理相关的设置键。
+func devToolKeys(t DevTool) []string {
+	switch t {
+	case DevToolGit:
+		return []string{"http.proxy", "https.proxy"}
+	case DevToolNpm:
+		return []string{"proxy", "https-proxy"}
+	case DevToolDocker:
+		return []string{"httpProxy", "httpsProxy", "noProxy"}
+	default:
+		return nil
+	}
+}
+
+// DevToolProxyValues 返回将工具指向给定代理地址时各设置键的取值。
+func DevToolProxyValues(t DevTool, httpURL, noProxy string) map[string]string {
+	values := make(map[string]string)
+	for _, key := range devToolKeys(t) {
+		if key == "noProxy" {
+			values[key] = noProxy
+		} else {
+			values[key] = httpURL
+		}
+	}
+	return values
+}
+
+// PlanDevToolSettings 读取工具当前设置，返回改为 values 所需的变化（取值相同的键不列出；values 中缺失的键视为移除）。
+func PlanDevToolSettings(t DevTool, values map[string]string) ([]DevToolSetting, error) {
+	keys := devToolKeys(t)
+	if keys == nil {
+		return nil, fmt.Errorf("不支持的工具: %s", t)
+	}
+	var changes []DevToolSetting
+	for _, key := range keys {
+		old, err := readDevToolSetting(t, key)
+		if err != nil {
+			return nil, err
+		}
+		if old != values[key] {
+			changes = append(changes, DevToolSetting{Tool: t, Key: key, Old: old, New: values[key]})
+		}
+	}
+	return changes, nil
+}
+
+// ApplyDevToolSettings 依次写入变化，New 为空的键被移除。
+func ApplyDevToolSettings(changes []DevToolSetting) error {
+	for _, c := range changes {
+		if err := writeDevToolSetting(c.Tool, c.Key, c.New); err != nil {
+			return fmt.Errorf("%s %s: %w", c.Tool.DisplayName(), c.Key, err)
+		}
+	}
+	return nil
+}
+
+// ReadDevToolSettings 读取工具当前的代理设置（未设置的键为空）。
+func ReadDevToolSettings(t DevTool) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range devToolKeys(t) {
+		v, err := readDevToolSetting(t, key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+func readDevToolSetting(t DevTool, key string) (string, error) {
+	switch t {
+	case DevToolGit:
+		git, ok := lookPathGit()
+		if !ok {
+			return "", fmt.Errorf("未找到 git")
+		}
+		// 键不存在时 git 以退出码 1 结束，视为未设置
+		out, _ := exec.Command(git, "config", "--global", "--get", key).Output()
+		return strings.TrimSpace(string(out)), nil
+	case DevToolNpm:
+		npm, err := exec.LookPath("npm")
+		if err != nil {
+			return "", fmt.Errorf("未找到 npm")
+		}
+		out, err := exec.Command(npm, "config", "get", key).Output()
+		if err != nil {
+			return "", fmt.Errorf("npm config get %s: %w", key, err)
+		}
+		v := strings.TrimSpace(string(out))
+		if v == "null" || v == "undefined" {
+			v = ""
+		}
+		return v, nil
+	case DevToolDocker:
+		cfg, err := readDockerConfig()
+		if err != nil {
+			return "", err
+		}
+		v, _ := dockerDefaultProxies(cfg, false)[key].(string)
+		return v, nil
+	default:
+		return "", fmt.Errorf("不支持的工具: %s", t)
+	}
+}
+
+func writeDevToolSetting(t DevTool, key, value string) error {
+	switch t {
+	case DevToolGit:
+		git, ok := lookPathGit()
+		if !ok {
+			return fmt.Errorf("未找到 git")
+		}
+		if value == "" {
+			_ = exec.Command(git, "config", "--global", "--unset", key).Run()
+			return nil
+		}
+		if out, err := exec.Command(git, "config", "--global", key, value).CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	case DevToolNpm:
+		npm, err := exec.LookPath("npm")
+		if err != nil {
+			return fmt.Errorf("未找到 npm")
+		}
+		args := []string{"config", "set", key, value}
+		if value == "" {
+			args = []string{"config", "delete", key}
+		}
+		if out, err := exec.Command(npm, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	case DevToolDocker:
+		cfg, err := readDockerConfig()
+		if err != nil {
+			return err
+		}
+		proxies := dockerDefaultProxies(cfg, value != "")
+		if value == "" {
+			if proxies == nil {
+				return nil
+			}
+			delete(proxies, key)
+			pruneDockerProxies(cfg)
+		} else {
+			proxies[key] = value
+		}
+		return writeDockerConfig(cfg)
+	default:
+		return fmt.Errorf("不支持的工具: %s", t)
+	}
+}
+
+// dockerConfigPath Docker 客户端配置文件路径，遵循 DOCKER_CONFIG 环境变量。
+func dockerConfigPath() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户目录失败: %w", err)
+	}
+	return filepath.Join(home, ".docker", "config.json"), nil
+}
+
+// readDockerConfig 读取 Docker 客户端配置；文件不存在时返回空配置。
+func readDockerConfig() (map[string]interface{}, error) {
+	path, err := dockerConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	cfg := map[string]interface{}{}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+func writeDockerConfig(cfg map[string]interface{}) error {
+	path, err := dockerConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("创建 %s 失败: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// dockerDefaultProxies 返回 cfg["proxies"]["default"]；create 为 true 时按需创建。
+func dockerDefaultProxies(cfg map[string]interface{}, create bool) map[string]interface{} {
+	proxies, ok := cfg["proxies"].(map[string]interface{})
+	if !ok {
+		if !create {
+			return nil
+		}
+		proxies = map[string]interface{}{}
+		cfg["proxies"] = proxies
+	}
+	def, ok := proxies["default"].(map[string]interface{})
+	if !ok {
+		if !create {
+			return nil
+		}
+		def = map[string]interface{}{}
+		proxies["default"] = def
+	}
+	return def
+}
+
+// pruneDockerProxies 移除设置后留下的空 proxies.default 与 proxies。
+func pruneDockerProxies(cfg map[string]interface{}) {
+	proxies, ok := cfg["proxies"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if def, ok := proxies["default"].(map[string]interface{}); ok && len(def) == 0 {
+		delete(proxies, "default")
+	}
+	if len(proxies) == 0 {
+		delete(cfg, "proxies")
+	}
+}
@@ -0,0 +1,141 @@
+package systemproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// snippetNoProxy 各片段中不走代理的地址
+var snippetNoProxy = []string{"localhost", "127.0.0.1", "::1"}
+
+// Snippet 一段指向本地入站、可直接使用的代理配置。
+type Snippet struct {
+	Name     string // 显示名称
+	FileName string // 保存时的建议文件名
+	Hint     string // 放置位置或用法说明
+	Content  string
+}
+
+// SnippetOptions 生成配置片段所需的本地入站信息。
+type SnippetOptions struct {
+	Host          string   // 入站地址，局域网设备使用时填本机局域网 IP
+	Port          int      // 混合入站端口（HTTP 与 SOCKS5 共用）
+	User, Pass    string   // 入站认证账号，未开启时为空
+	DirectDomains []string // PAC 中直连的域名（后缀匹配）
+}
+
+// Snippets 生成 PAC、终端环境变量、Docker 守护进程与 git/apt/npm 的代理配置片段。
+func Snippets(opts SnippetOptions) []Snippet {
+	httpURL := HTTPProxyURL(opts)
+	socksURL := snippetProxyURL("socks5h", opts)
+	noProxy := NoProxyList()
+
+	docker, _ := json.MarshalIndent(map[string]interface{}{
+		"proxies": map[string]string{
+			"http-proxy":  httpURL,
+			"https-proxy": httpURL,
+			"no-proxy":    noProxy,
+		},
+	}, "", "  ")
+
+	return []Snippet{
+		{
+			Name:     "PAC 文件",
+			FileName: "proxy.pac",
+			Hint:     "在系统或浏览器的「自动代理配置」中引用；PAC 无法携带入站认证账号。",
+			Content:  pacScript(opts),
+		},
+		{
+			Name:     "Shell 环境变量",
+			FileName: "proxy.sh",
+			Hint:     "在终端执行 source proxy.sh，或追加到 ~/.bashrc、~/.zshrc。",
+			Content: fmt.Sprintf("export http_proxy=%s\nexport https_proxy=%s\nexport all_proxy=%s\nexport no_proxy=%s\n"+
+				"export HTTP_PROXY=\"$http_proxy\"\nexport HTTPS_PROXY=\"$https_proxy\"\nexport ALL_PROXY=\"$all_proxy\"\nexport NO_PROXY=\"$no_proxy\"\n",
+				shellQuote(httpURL), shellQuote(httpURL), shellQuote(socksURL), shellQuote(noProxy)),
+		},
+		{
+			Name:     "PowerShell 环境变量",
+			FileName: "proxy.ps1",
+			Hint:     "在 PowerShell 中执行 . .\\proxy.ps1，仅对当前会话生效。",
+			Content: fmt.Sprintf("$env:HTTP_PROXY = %s\n$env:HTTPS_PROXY = %s\n$env:ALL_PROXY = %s\n$env:NO_PROXY = %s\n",
+				psQuote(httpURL), psQuote(httpURL), psQuote(socksURL), psQuote(noProxy)),
+		},
+		{
+			Name:     "Docker 守护进程",
+			FileName: "daemon.json",
+			Hint:     "合并到 /etc/docker/daemon.json（Docker Desktop 在设置的 Docker Engine 中）后重启 Docker，需 Docker 23 及以上。",
+			Content:  string(docker) + "\n",
+		},
+		{
+			Name:     "Git",
+			FileName: "gitconfig",
+			Hint:     "追加到 ~/.gitconfig，或对单个仓库追加到 .git/config。",
+			Content:  fmt.Sprintf("[http]\n\tproxy = %s\n[https]\n\tproxy = %s\n", httpURL, httpURL),
+		},
+		{
+			Name:     "APT",
+			FileName: "95proxy",
+			Hint:     "保存为 /etc/apt/apt.conf.d/95proxy。",
+			Content:  fmt.Sprintf("Acquire::http::Proxy \"%s\";\nAcquire::https::Proxy \"%s\";\n", httpURL, httpURL),
+		},
+		{
+			Name:     "npm",
+			FileName: ".npmrc",
+			Hint:     "追加到 ~/.npmrc 或项目根目录的 .npmrc。",
+			Content:  fmt.Sprintf("proxy=%s\nhttps-proxy=%s\nnoproxy=%s\n", httpURL, httpURL, noProxy),
+		},
+	}
+}
+
+// HTTPProxyURL 返回本地入站的 HTTP 代理地址（含可选认证账号）。
+func HTTPProxyURL(opts SnippetOptions) string {
+	return snippetProxyURL("http", opts)
+}
+
+// NoProxyList 返回逗号分隔的不走代理地址。
+func NoProxyList() string {
+	return strings.Join(snippetNoProxy, ",")
+}
+
+// snippetProxyURL 拼接带可选认证账号的代理地址。
+func snippetProxyURL(scheme string, opts SnippetOptions) string {
+	u := url.URL{Scheme: scheme, Host: net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))}
+	if opts.User != "" && opts.Pass != "" {
+		u.User = url.UserPassword(opts.User, opts.Pass)
+	}
+	return u.String()
+}
+
+// shellQuote 以单引号包裹，避免账号中的 $ 等字符被 shell 展开。
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// psQuote PowerShell 单引号字符串，内部单引号写作两个。
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// pacScript 生成 PAC：本机、内网与直连域名直连，其余依次尝试 SOCKS5 与 HTTP 入站。
+func pacScript(opts SnippetOptions) string {
+	hostPort := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
+	domains, _ := json.Marshal(opts.DirectDomains)
+	if opts.DirectDomains == nil {
+		domains = []byte("[]")
+	}
+	var b strings.Builder
+	b.WriteString("var direct = " + string(domains) + ";\n\n")
+	b.WriteString("function FindProxyForURL(url, host) {\n")
+	b.WriteString("  if (isPlainHostName(host) || host === \"localhost\" || shExpMatch(host, \"127.*\") ||\n")
+	b.WriteString("      shExpMatch(host, \"10.*\") || shExpMatch(host, \"192.168.*\")) {\n")
+	b.WriteString("    return \"DIRECT\";\n  }\n")
+	b.WriteString("  for (var i = 0; i < direct.length; i++) {\n")
+	b.WriteString("    if (host === direct[i] || dnsDomainIs(host, \".\" + direct[i])) {\n")
+	b.WriteString("      return \"DIRECT\";\n    }\n  }\n")
+	fmt.Fprintf(&b, "  return \"SOCKS5 %s; SOCKS %s; PROXY %s; DIRECT\";\n}\n", hostPort, hostPort, hostPort)
+	return b.String()
+}
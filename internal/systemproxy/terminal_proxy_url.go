@@ -1,6 +1,25 @@
 package systemproxy
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// 本地入站开启认证时的账号，写入终端/Git 代理地址（user:pass@host:port）。
+// 系统代理（注册表 / networksetup / gsettings）不支持携带账号，由应用自行提示输入。
+var (
+	credentialsMu sync.RWMutex
+	proxyUser     string
+	proxyPass     string
+)
+
+// SetProxyCredentials 设置本地入站认证账号；传入空字符串表示不认证。
+func SetProxyCredentials(user, pass string) {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	proxyUser, proxyPass = user, pass
+}
 
 func TerminalProxyURL(host string, port int, proxyType string) string {
 	if proxyType == "" {
@@ -15,5 +34,11 @@ func TerminalProxyURL(host string, port int, proxyType string) string {
 	case "socks5", "socks":
 		scheme = "socks5"
 	}
+	credentialsMu.RLock()
+	user, pass := proxyUser, proxyPass
+	credentialsMu.RUnlock()
+	if user != "" && pass != "" {
+		return fmt.Sprintf("%s://%s@%s:%d", scheme, url.UserPassword(user, pass).String(), host, port)
+	}
 	return fmt.Sprintf("%s://%s:%d", scheme, host, port)
 }
@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+const (
+	// accessLiveLimit 实时视图保留的最近连接数
+	accessLiveLimit = 500
+	// accessLiveRefreshInterval 实时视图刷新列表的最小间隔，连接密集时合并刷新
+	accessLiveRefreshInterval = 300 * time.Millisecond
+)
+
+// accessRouteLabels 出站选择方式的显示名称
+var accessRouteLabels = map[string]string{
+	service.AccessRouteRule:     "规则",
+	service.AccessRouteBalancer: "均衡",
+	service.AccessRouteDefault:  "默认",
+}
+
+// accessLiveView 访问记录的「实时」视图：逐条显示新建立的连接（最新在上），可暂停。
+// 仅在视图显示期间订阅，离开时取消，不影响访问记录的统计与写库。
+type accessLiveView struct {
+	appState *AppState
+
+	list      *widget.List
+	pauseBtn  *widget.Button
+	hintLabel *widget.Label
+	content   fyne.CanvasObject
+	refresh   *refreshThrottle
+
+	mu          sync.Mutex
+	events      []service.AccessEvent // 最新在前
+	paused      bool
+	unsubscribe func()
+}
+
+func newAccessLiveView(appState *AppState) *accessLiveView {
+	v := &accessLiveView{appState: appState}
+	v.list = widget.NewList(
+		func() int {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			return len(v.events)
+		},
+		func() fyne.CanvasObject {
+			timeLabel := widget.NewLabel("00:00:00")
+			timeLabel.TextStyle = fyne.TextStyle{Monospace: true}
+			addrLabel := widget.NewLabel("")
+			addrLabel.Truncation = fyne.TextTruncateEllipsis
+			routeLabel := widget.NewLabel("")
+			routeLabel.Alignment = fyne.TextAlignTrailing
+			return container.NewBorder(nil, nil, timeLabel, routeLabel, addrLabel)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			v.mu.Lock()
+			if id < 0 || id >= len(v.events) {
+				v.mu.Unlock()
+				return
+			}
+			e := v.events[id]
+			v.mu.Unlock()
+			labels := collectLabelsFromObject(obj)
+			if len(labels) < 3 {
+				return
+			}
+			addr := net.JoinHostPort(e.Host, e.Port)
+			if e.Network == "udp" {
+				addr += " (UDP)"
+			}
+			labels[0].SetText(addr)
+			labels[1].SetText(e.Time.Format("15:04:05"))
+			labels[2].SetText(accessEventRoute(e))
+		},
+	)
+	v.refresh = newRefreshThrottle(accessLiveRefreshInterval, v.list.Refresh)
+
+	v.pauseBtn = widget.NewButtonWithIcon("暂停", theme.MediaPauseIcon(), v.togglePause)
+	v.pauseBtn.Importance = widget.LowImportance
+	v.hintLabel = widget.NewLabel("")
+	v.hintLabel.Importance = widget.LowImportance
+	v.hintLabel.Truncation = fyne.TextTruncateEllipsis
+
+	v.content = container.NewBorder(
+		container.NewBorder(nil, nil, nil, v.pauseBtn, v.hintLabel),
+		nil, nil, nil,
+		v.list,
+	)
+	return v
+}
+
+// accessEventRoute 返回「入站 → 出站（方式）」，日志无路由信息时为空。
+func accessEventRoute(e service.AccessEvent) string {
+	if e.Outbound == "" {
+		return ""
+	}
+	route := e.Outbound
+	if e.Inbound != "" {
+		route = e.Inbound + " → " + e.Outbound
+	}
+	if label := accessRouteLabels[e.Route]; label != "" {
+		route += "（" + label + "）"
+	}
+	return route
+}
+
+// Start 开始订阅新连接。
+func (v *accessLiveView) Start() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.unsubscribe != nil || v.appState == nil || v.appState.AccessRecordService == nil {
+		return
+	}
+	v.unsubscribe = v.appState.AccessRecordService.Subscribe(v.onEvent)
+	v.updateHintLocked()
+}
+
+// Stop 取消订阅（切换视图或离开设置页时调用），已显示的连接保留。
+func (v *accessLiveView) Stop() {
+	v.mu.Lock()
+	unsubscribe := v.unsubscribe
+	v.unsubscribe = nil
+	v.mu.Unlock()
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+}
+
+// Clear 清空已显示的连接。
+func (v *accessLiveView) Clear() {
+	v.mu.Lock()
+	v.events = nil
+	v.updateHintLocked()
+	v.mu.Unlock()
+	v.list.Refresh()
+}
+
+func (v *accessLiveView) onEvent(e service.AccessEvent) {
+	v.mu.Lock()
+	if v.paused {
+		v.mu.Unlock()
+		return
+	}
+	v.events = append([]service.AccessEvent{e}, v.events...)
+	if len(v.events) > accessLiveLimit {
+		v.events = v.events[:accessLiveLimit]
+	}
+	v.mu.Unlock()
+	v.refresh.Trigger()
+}
+
+func (v *accessLiveView) togglePause() {
+	v.mu.Lock()
+	v.paused = !v.paused
+	paused := v.paused
+	v.updateHintLocked()
+	v.mu.Unlock()
+	if paused {
+		v.pauseBtn.SetText("继续")
+		v.pauseBtn.SetIcon(theme.MediaPlayIcon())
+	} else {
+		v.pauseBtn.SetText("暂停")
+		v.pauseBtn.SetIcon(theme.MediaPauseIcon())
+	}
+}
+
+// updateHintLocked 按记录方式与暂停状态更新提示；在 UI 线程调用，调用方须持有 v.mu。
+func (v *accessLiveView) updateHintLocked() {
+	var hint string
+	switch {
+	case v.appState != nil && v.appState.ConfigService != nil && v.appState.ConfigService.GetAccessRecordMode() != service.AccessRecordModeFull:
+		hint = "当前访问记录方式不保留完整地址，实时视图不显示连接"
+	case v.paused:
+		hint = fmt.Sprintf("已暂停，显示暂停前的 %d 条连接", len(v.events))
+	default:
+		hint = fmt.Sprintf("新建立的连接会实时显示在这里，保留最近 %d 条", accessLiveLimit)
+	}
+	v.hintLabel.SetText(hint)
+}
+
+// Content 返回视图内容。
+func (v *accessLiveView) Content() fyne.CanvasObject {
+	return v.content
+}
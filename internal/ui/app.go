@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -11,82 +12,104 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/theme"
+	appcore "myproxy.com/p/internal/app"
 	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/logging"
 	"myproxy.com/p/internal/service"
-	"myproxy.com/p/internal/store"
-	"myproxy.com/p/internal/subscription"
-	"myproxy.com/p/internal/utils"
-	"myproxy.com/p/internal/xray"
+	"myproxy.com/p/internal/urlscheme"
 )
 
+// AppState 图形界面状态：窗口、页面与界面组件；数据存储、业务服务与代理生命周期由嵌入的 appcore.Controller 管理。
 type AppState struct {
-	initialized         bool
-	Ping                *utils.Ping
-	Logger              *logging.Logger
-	SafeLogger          *logging.SafeLogger
-	App                 fyne.App
-	Window              fyne.Window
-	MainWindow          *MainWindow
-	TrayManager         *TrayManager
-	Store               *store.Store
-	ServerService       *service.ServerService
-	ConfigService       *service.ConfigService
-	ProxyService        *service.ProxyService
-	SubscriptionService *service.SubscriptionService
-	XrayControlService  *service.XrayControlService
-	AccessRecordService *service.AccessRecordService
-	DiagnosticsService  *service.DiagnosticsService
-	XrayInstance        *xray.XrayInstance
-	LogsPanel           *LogsPanel // 日志面板，仅设置页使用；OnLogLine 分发到此
-	ProxyStatusBinding  binding.String
-	PortBinding         binding.String
-	ServerNameBinding   binding.String
-	LogCallback         func(level, logType, message string)
+	*appcore.Controller
+
+	initialized        bool
+	App                fyne.App
+	Window             fyne.Window
+	MainWindow         *MainWindow
+	TrayManager        *TrayManager
+	AppMenu            *AppMenu   // 主菜单（文件 / 视图 / 代理 / 帮助）与快捷键
+	LogsPanel          *LogsPanel // 日志面板，嵌入设置页或弹出为独立窗口；OnLogLine 分发到此
+	ClipboardMonitor   *ClipboardMonitor
+	MiniWindow         *MiniWindow // 悬浮状态小窗
+	ProxyStatusBinding binding.String
+	PortBinding        binding.String
+	ServerNameBinding  binding.String
+	LogCallback        func(level, logType, message string)
 	// OnLogLine 统一日志入口：收到完整日志行时调用，用于分发到展示和访问记录。
 	// 由 MainWindow 设置，供 Logger 的 panelCallback 和文件读取使用。
 	OnLogLine func(logLine string)
 
 	windowSizeSaveMu    sync.Mutex
 	windowSizeSaveTimer *time.Timer
+
+	// nodeRegionFilter 节点列表选中的地区筛选（地区代码），首次建节点页时从布局配置恢复
+	nodeRegionFilter map[string]bool
+	// configViewer 已打开的「查看当前配置」窗口，未打开时为 nil
+	configViewer *ConfigViewer
+	// offline 网络未连接时为 true，由 watchNetworkStatus 更新（见 offline.go）
+	offline binding.Bool
+
+	// appLock 应用锁：锁屏状态与自动锁定计时（见 app_lock.go）
+	appLock appLockState
+
+	// linkListener 接收再次通过链接启动时转发来的链接（见 urlscheme.Listen）
+	linkListener *urlscheme.Listener
+
+	// configMigrations 启动时类型化配置迁移的修正说明，待日志初始化后写入
+	configMigrations []string
+
+	// SafeMode 安全模式（--safe-mode）：只加载界面与数据库，不自动连接、不改动系统代理、不启动后台定时任务，
+	// 用于配置损坏导致启动崩溃时进入界面修复。须在 Startup 之前设置。
+	SafeMode bool
 }
 
 func NewAppState() *AppState {
-	subscriptionManager := subscription.NewSubscriptionManager()
-	dataStore := store.NewStore(subscriptionManager)
-	serverService := service.NewServerService(dataStore)
-	configService := service.NewConfigService(dataStore)
-	subscriptionService := service.NewSubscriptionService(dataStore, subscriptionManager)
-	pingUtil := utils.NewPing()
-
-	appState := &AppState{
-		Ping:                pingUtil,
-		Logger:              nil,
-		SafeLogger:          logging.NewSafeLogger(nil),
-		Store:               dataStore,
-		ServerService:       serverService,
-		ConfigService:       configService,
-		SubscriptionService: subscriptionService,
-		ProxyStatusBinding:  dataStore.ProxyStatus.ProxyStatusBinding,
-		PortBinding:         dataStore.ProxyStatus.PortBinding,
-		ServerNameBinding:   dataStore.ProxyStatus.ServerNameBinding,
-		ProxyService:        service.NewProxyService(nil, configService),
-		XrayControlService:  service.NewXrayControlService(dataStore, configService, nil, nil),
-		AccessRecordService: service.NewAccessRecordService(dataStore),
-		DiagnosticsService:  service.NewDiagnosticsService(configService, dataStore),
-	}
-
-	// LogCallback 保留用于兼容，但展示已改为通过 OnLogLine 统一分发
-	appState.LogCallback = nil
-
-	return appState
+	ctrl := appcore.New()
+	return &AppState{
+		Controller:         ctrl,
+		ProxyStatusBinding: ctrl.Store.ProxyStatus.ProxyStatusBinding,
+		PortBinding:        ctrl.Store.ProxyStatus.PortBinding,
+		ServerNameBinding:  ctrl.Store.ProxyStatus.ServerNameBinding,
+	}
 }
 
 func (a *AppState) updateStatusBindings() {
 	if a.Store == nil || a.Store.ProxyStatus == nil {
 		return
 	}
-	a.Store.ProxyStatus.UpdateProxyStatus(a.XrayInstance, a.Store.Nodes)
+	a.Store.ProxyStatus.SetMode(getSystemProxyModeFromAppState(a).String())
+	newSession := a.Store.ProxyStatus.UpdateProxyStatus(a.ProxyService, a.Store.Nodes)
+	if newSession {
+		a.refreshExitIP()
+	}
+	a.syncSessionReport(newSession)
+	a.syncUsageStats(newSession)
+	a.syncConnectionMonitor(newSession)
+	a.syncXraySupervisor()
+	if a.StatusFileService != nil {
+		a.StatusFileService.Touch()
+	}
+}
+
+// refreshExitIP 在后台经当前入站查询出口 IP 并写入状态绑定；查询期间会话变化时结果被丢弃。
+func (a *AppState) refreshExitIP() {
+	if a.ProxyService == nil || a.XrayInstance() == nil || a.Store == nil || a.Store.ProxyStatus == nil {
+		return
+	}
+	ps := a.Store.ProxyStatus
+	startedAt := ps.SessionStartedAt()
+	port := a.XrayInstance().GetPort()
+	proxyService := a.ProxyService
+	ps.SetSessionExitIP(startedAt, "查询中…")
+	go func() {
+		ip, err := proxyService.LookupExitIP(context.Background(), port)
+		if err != nil {
+			a.SafeLogger.Warn(fmt.Sprintf("查询出口 IP 失败: %v", err))
+			ip = "未知"
+		}
+		ps.SetSessionExitIP(startedAt, ip)
+	}()
 }
 
 func (a *AppState) UpdateProxyStatus() {
@@ -103,8 +126,9 @@ func (a *AppState) refreshTrayProxyMenu() {
 
 func (a *AppState) InitApp() error {
 	a.App = app.NewWithID("com.myproxy.socks5")
-	// 应用主题（从配置加载）
+	// 应用主题、速度单位与数字格式（从配置加载）
 	a.ApplyTheme()
+	applyUnitFormat(a)
 
 	appIcon := createAppIcon(a)
 	if appIcon != nil {
@@ -114,7 +138,11 @@ func (a *AppState) InitApp() error {
 		a.SafeLogger.Warn("应用图标创建失败")
 	}
 
-	a.Window = a.App.NewWindow("myproxy")
+	title := "myproxy"
+	if a.SafeMode {
+		title += "（安全模式）"
+	}
+	a.Window = a.App.NewWindow(title)
 
 	// 必须先加载数据库中的 app_config（含 windowSize），再按配置 Resize，否则会误用默认尺寸并在后续 SetContent 时写回库覆盖用户值。
 	if a.Store != nil {
@@ -126,6 +154,10 @@ func (a *AppState) InitApp() error {
 
 	if a.ConfigService != nil {
 		_ = a.ConfigService.SaveDefaultDirectRoutes()
+		a.configMigrations = a.ConfigService.MigrateTypedConfig()
+		if err := a.ConfigService.ApplyXrayEnvironment(); err != nil {
+			a.configMigrations = append(a.configMigrations, err.Error())
+		}
 	}
 
 	a.updateStatusBindings()
@@ -168,6 +200,7 @@ func (a *AppState) InitLogger() error {
 		rawLogCallback := func(level, rawLine string) {
 			if a.Logger != nil {
 				a.Logger.WriteRawLine(rawLine)
+				a.Logger.Publish(logging.LogEvent{Level: strings.ToUpper(level), Source: "xray", Message: rawLine})
 			}
 			if a.OnLogLine != nil {
 				a.OnLogLine(rawLine)
@@ -179,17 +212,6 @@ func (a *AppState) InitLogger() error {
 	return nil
 }
 
-// AppendLog 追加一条日志。由 Logger 写入文件并调用 panelCallback，统一由 OnLogLine 分发到展示和访问记录。
-func (a *AppState) AppendLog(level, logType, message string) {
-	level = strings.ToUpper(level)
-	if strings.ToLower(logType) != "xray" {
-		logType = "app"
-	}
-	if a.Logger != nil {
-		a.Logger.Log(level, logType, message)
-	}
-}
-
 // LoadWindowSize 从配置加载窗口大小，未配置时返回默认尺寸。
 func (a *AppState) LoadWindowSize(defaultSize fyne.Size) fyne.Size {
 	if a.ConfigService != nil {
@@ -243,16 +265,18 @@ func (a *AppState) schedulePersistWindowSize() {
 	})
 }
 
-// wrapWithWindowSizePersistence 包裹根内容，使拖动/缩放窗口后 windowSize 能落库。
-func (a *AppState) wrapWithWindowSizePersistence(inner fyne.CanvasObject) fyne.CanvasObject {
+// wrapWithWindowSizePersistence 包裹根内容，使拖动/缩放窗口后 windowSize 能落库；
+// minSize 为页面的最小窗口尺寸（见 pageMinSizes），窗口不能缩到比它更小。内容中的鼠标输入计入应用锁的无操作计时。
+func (a *AppState) wrapWithWindowSizePersistence(inner fyne.CanvasObject, minSize fyne.Size) fyne.CanvasObject {
 	if a == nil || inner == nil {
 		return inner
 	}
-	return container.New(&windowSizePersistLayout{appState: a}, inner)
+	return container.New(&windowSizePersistLayout{appState: a, minSize: minSize}, newAppLockInputTracker(a, inner))
 }
 
 type windowSizePersistLayout struct {
 	appState *AppState
+	minSize  fyne.Size
 }
 
 func (l *windowSizePersistLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
@@ -267,9 +291,9 @@ func (l *windowSizePersistLayout) Layout(objects []fyne.CanvasObject, size fyne.
 
 func (l *windowSizePersistLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 	if len(objects) == 0 || objects[0] == nil {
-		return fyne.NewSize(0, 0)
+		return l.minSize
 	}
-	return objects[0].MinSize()
+	return objects[0].MinSize().Max(l.minSize)
 }
 
 func (a *AppState) SetupTray() {
@@ -291,6 +315,9 @@ func (a *AppState) SetupWindowCloseHandler() {
 				a.SaveWindowSize(sz)
 			}
 		}
+		if a.MainWindow != nil {
+			a.MainWindow.saveLastView()
+		}
 		a.Window.Hide()
 	})
 }
@@ -304,7 +331,7 @@ func (a *AppState) Startup() error {
 		return fmt.Errorf("应用状态: 初始化应用失败: %w", err)
 	}
 
-	if a.DiagnosticsService != nil {
+	if a.DiagnosticsService != nil && !a.SafeMode {
 		if err := a.DiagnosticsService.Start(); err != nil {
 			return fmt.Errorf("应用状态: 启动诊断服务失败: %w", err)
 		}
@@ -325,124 +352,225 @@ func (a *AppState) Startup() error {
 		return fmt.Errorf("应用状态: 初始化日志失败: %w", err)
 	}
 
+	// 配置迁移发生在日志初始化之前，此处补记
+	for _, change := range a.configMigrations {
+		a.AppendLog("WARN", "app", change)
+	}
+	a.configMigrations = nil
+
 	// xray 日志由劫持 handler 落盘并分发，无需文件监控
 
-	content := mainWindow.Build()
-	if content != nil {
-		a.Window.SetContent(a.wrapWithWindowSizePersistence(content))
-	}
+	a.ClipboardMonitor = NewClipboardMonitor(a)
 
-	a.SetupTray()
-	a.SetupWindowCloseHandler()
+	// 低功耗模式：各后台任务在调度时读取状态，XrayControlService 在 InitLogger 中重建，须在其后设置
+	if a.PowerService != nil {
+		a.TrafficService.SetPowerService(a.PowerService)
+		a.DiagnosticsService.SetPowerService(a.PowerService)
+		a.XrayControlService.SetPowerService(a.PowerService)
+	}
 
-	if err := a.autoLoadProxyConfig(); err != nil {
-		a.AppendLog("INFO", "app", "自动加载代理配置失败: "+err.Error())
+	// 流量采样替代各组件自行轮询，安全模式下手动连接时流量图同样需要
+	if a.TrafficService != nil {
+		a.TrafficService.Start(a.XrayInstance)
 	}
 
-	a.initialized = true
-	return nil
-}
+	// 连接监控：代理状态更新时开始或结束，事件按设置发送系统通知
+	a.ConnectionMonitor = service.NewConnectionMonitor(a.XrayControlService, a.ConfigService, a.PowerService, a.handleConnectionEvent)
+	a.ConnectionMonitor.SetSessionReport(a.SessionReport)
+	a.XraySupervisor = service.NewXraySupervisor(a.ConfigService, a.handleSupervisorEvent, a.restartCrashedProxy)
 
-func (a *AppState) IsInitialized() bool {
-	return a.initialized
-}
+	if listener, err := urlscheme.Listen(database.DataDir(), a.HandleDeepLink); err != nil {
+		a.AppendLog("WARN", "app", "启动导入链接通道失败，再次点击链接将打开新的实例: "+err.Error())
+	} else {
+		a.linkListener = listener
+	}
 
-func (a *AppState) Reset() {
-	a.initialized = false
-}
+	if a.SafeMode {
+		a.AppendLog("WARN", "app", "安全模式启动：已跳过自动连接、系统代理恢复与后台任务，修复配置后请正常重启")
+		return a.finishStartup(mainWindow)
+	}
 
-func (a *AppState) autoLoadProxyConfig() error {
-	if a.Store == nil || a.Store.AppConfig == nil {
-		return fmt.Errorf("应用状态: Store 未初始化")
+	if a.ImportAPIService != nil {
+		a.ImportAPIService.SetOnImported(func(message string) {
+			a.AppendLog("INFO", "app", "浏览器导入: "+message)
+		})
+		if a.Logger != nil {
+			a.ImportAPIService.SetLogSource(a.Logger.Subscribe)
+		}
+		a.ImportAPIService.SetMetrics(service.NewMetricsService(a.Store, a.TrafficService, a.AccessRecordService))
+		if err := a.ImportAPIService.ApplyConfig(); err != nil {
+			a.AppendLog("ERROR", "app", "启动浏览器导入接口失败: "+err.Error())
+		}
 	}
 
-	autoStart, err := a.Store.AppConfig.GetWithDefault("autoStartProxy", database.AppConfigBuiltinDefault("autoStartProxy"))
-	if err != nil || autoStart != "true" {
-		return nil
+	a.ClipboardMonitor.ApplyConfig()
+
+	if a.PowerService != nil {
+		a.PowerService.Subscribe(func(lowPower bool) {
+			if lowPower {
+				a.AppendLog("INFO", "app", "已进入低功耗模式：流量与运行时采样放缓，暂停选中节点时的自动测速")
+			} else {
+				a.AppendLog("INFO", "app", "已退出低功耗模式")
+			}
+		})
+		a.PowerService.Start()
 	}
 
-	selectedServerID, err := a.Store.AppConfig.GetWithDefault("selectedServerID", database.AppConfigBuiltinDefault("selectedServerID"))
-	if err != nil || selectedServerID == "" {
-		return fmt.Errorf("应用状态: 未找到保存的选中服务器")
+	a.watchNetworkStatus()
+
+	if a.TrashService != nil {
+		a.TrashService.SetOnPurged(func(count int64) {
+			a.AppendLog("INFO", "app", fmt.Sprintf("回收站: 已永久删除 %d 个超过保留期的订阅或节点", count))
+		})
+		a.TrashService.Start()
 	}
 
-	if err := a.Store.Nodes.Select(selectedServerID); err != nil {
-		return fmt.Errorf("应用状态: 选中服务器失败: %w", err)
+	if a.WebDAVSyncService != nil {
+		a.WebDAVSyncService.SetOnResult(a.handleWebDAVSyncResult)
+		a.WebDAVSyncService.Start()
 	}
 
-	a.AppendLog("INFO", "app", "正在自动启动代理服务...")
+	if a.ScheduledPing != nil {
+		a.ScheduledPing.SetOnReport(a.handleLatencyReport)
+		a.ScheduledPing.Start()
+	}
 
-	if a.XrayControlService == nil {
-		return fmt.Errorf("应用状态: XrayControlService 未初始化")
+	if a.Prewarm != nil {
+		a.Prewarm.Start()
 	}
 
-	unifiedLogPath := ""
-	if a.Logger != nil {
-		unifiedLogPath = a.Logger.GetLogFilePath()
+	if a.ExternalRefresh != nil {
+		a.ExternalRefresh.SetOnRefresh(a.handleExternalRefresh)
+		a.ExternalRefresh.Start()
 	}
-	result := a.XrayControlService.StartProxy(a.XrayInstance, unifiedLogPath)
-	if result.Error != nil {
-		return fmt.Errorf("应用状态: 启动代理失败: %w", result.Error)
+
+	if a.UsageStats != nil {
+		a.UsageStats.Start()
 	}
 
-	a.XrayInstance = result.XrayInstance
+	if a.StatusFileService != nil {
+		if err := a.StatusFileService.ApplyConfig(); err != nil {
+			a.AppendLog("WARN", "app", "写入状态文件失败: "+err.Error())
+		}
+	}
 
-	if a.ProxyService != nil {
-		a.ProxyService.UpdateXrayInstance(a.XrayInstance)
+	if a.ProcessStatsService != nil {
+		a.ProcessStatsService.ApplyConfig()
 	}
 
-	a.updateStatusBindings()
+	if err := a.finishStartup(mainWindow); err != nil {
+		return err
+	}
 
-	a.AppendLog("INFO", "app", "代理服务自动启动成功")
+	if err := a.autoLoadProxyConfig(); err != nil {
+		a.AppendLog("INFO", "app", "自动加载代理配置失败: "+err.Error())
+	}
 	return nil
 }
 
-func (a *AppState) Cleanup() {
-	a.stopWindowSizeSaveTimer()
-
-	if a.MainWindow != nil {
-		a.MainWindow.Cleanup()
-		a.MainWindow = nil
+// finishStartup 构建主界面、托盘与关闭行为，并标记初始化完成。
+func (a *AppState) finishStartup(mainWindow *MainWindow) error {
+	content := mainWindow.Build()
+	if content != nil {
+		a.Window.SetContent(a.wrapWithWindowSizePersistence(content, pageMinSize(PageTypeHome)))
+		// 先锁定再恢复上次的页面，恢复的页面在解锁后才显示
+		a.lockOnStartup()
+		mainWindow.RestoreLastPage()
 	}
 
-	if a.LogsPanel != nil {
-		a.LogsPanel.Stop()
-		a.LogsPanel = nil
-	}
+	a.SetupTray()
+	a.AppMenu = NewAppMenu(a)
+	a.AppMenu.Setup()
+	a.SetupWindowCloseHandler()
 
-	if a.XrayInstance != nil {
-		if a.XrayInstance.IsRunning() {
-			_ = a.XrayInstance.Stop()
+	a.initialized = true
+	return nil
+}
+
+// HandleDeepLink 处理系统传入的导入链接（myproxy://、sub://、vmess://、ss://）：
+// 显示窗口并跳转到订阅页，弹出预填好的导入对话框，由用户确认后导入。
+func (a *AppState) HandleDeepLink(link string) {
+	parsed, err := urlscheme.Parse(link)
+	if err != nil {
+		a.AppendLog("ERROR", "app", "无法处理导入链接: "+err.Error())
+		return
+	}
+	a.AppendLog("INFO", "app", "收到导入链接: "+logging.Redact(link))
+	fyne.Do(func() {
+		if a.appLock.locked {
+			a.AppendLog("WARN", "app", "应用已锁定，已忽略导入链接")
+			return
 		}
-		a.XrayInstance = nil
+		a.openImportLink(parsed)
+	})
+}
+
+// openImportLink 显示窗口并跳转到订阅页，弹出预填的导入对话框（须在主线程调用）。
+func (a *AppState) openImportLink(link *urlscheme.Link) {
+	if a.Window != nil {
+		a.Window.Show()
+		a.Window.RequestFocus()
+	}
+	if a.MainWindow == nil {
+		return
+	}
+	a.MainWindow.ShowSubscriptionPage()
+	if a.MainWindow.subscriptionPageInstance != nil {
+		a.MainWindow.subscriptionPageInstance.ShowImportDialog(link)
 	}
+}
 
-	if a.AccessRecordService != nil {
-		if err := a.AccessRecordService.Flush(); err != nil && a.Logger != nil {
-			a.Logger.Error("刷盘访问记录失败: %v", err)
-		}
+func (a *AppState) IsInitialized() bool {
+	return a.initialized
+}
+
+func (a *AppState) Reset() {
+	a.initialized = false
+}
+
+// autoLoadProxyConfig 按设置在启动时自动连接，成功后刷新状态绑定。
+func (a *AppState) autoLoadProxyConfig() error {
+	started, err := a.AutoStartProxy()
+	if started {
+		a.updateStatusBindings()
 	}
+	return err
+}
 
-	if a.Logger != nil {
-		a.Logger.Close()
-		a.Logger = nil
+func (a *AppState) Cleanup() {
+	a.stopWindowSizeSaveTimer()
+	a.stopAppLockIdleCheck()
+	if a.linkListener != nil {
+		_ = a.linkListener.Close()
+		a.linkListener = nil
 	}
 
-	if a.SafeLogger != nil {
-		a.SafeLogger.SetLogger(nil)
+	if a.ClipboardMonitor != nil {
+		a.ClipboardMonitor.Stop()
+		a.ClipboardMonitor = nil
 	}
 
-	if a.Store != nil {
-		a.Store.Reset()
+	if a.MiniWindow != nil {
+		a.MiniWindow.Close()
+		a.MiniWindow = nil
 	}
 
-	if a.ProxyService != nil {
-		a.ProxyService.UpdateXrayInstance(nil)
+	a.StopBackground()
+
+	// 退出时仍在连接的会话直接记入连接记录，不弹出报告
+	a.endSessionReport()
+
+	if a.MainWindow != nil {
+		a.MainWindow.Cleanup()
+		a.MainWindow = nil
 	}
 
-	if a.DiagnosticsService != nil {
-		a.DiagnosticsService.Stop()
+	if a.LogsPanel != nil {
+		a.LogsPanel.Stop()
+		a.LogsPanel = nil
 	}
+
+	a.Shutdown()
 }
 
 func (a *AppState) Run() {
@@ -450,11 +578,68 @@ func (a *AppState) Run() {
 		a.Window.Show()
 	}
 	if a.App != nil {
+		// 原生窗口在事件循环启动后才创建，置顶与悬浮窗需在此之后恢复
+		a.App.Lifecycle().SetOnStarted(a.restoreWindowPinning)
 		defer a.Cleanup()
 		a.App.Run()
 	}
 }
 
+// restoreWindowPinning 按配置恢复主窗口置顶与悬浮小窗。
+func (a *AppState) restoreWindowPinning() {
+	if a.ConfigService == nil {
+		return
+	}
+	if a.ConfigService.GetWindowAlwaysOnTop() && a.Window != nil {
+		setWindowAlwaysOnTop(a.Window, true)
+	}
+	if a.ConfigService.GetMiniWindowEnabled() {
+		// 启动时已锁定的，解锁后再显示悬浮窗
+		if a.appLock.locked {
+			a.appLock.miniWindow = true
+		} else {
+			a.SetMiniWindowVisible(true)
+		}
+	}
+}
+
+// SetWindowAlwaysOnTop 切换主窗口置顶并保存配置；当前平台不支持时返回错误。
+func (a *AppState) SetWindowAlwaysOnTop(onTop bool) error {
+	if a.Window != nil && !setWindowAlwaysOnTop(a.Window, onTop) && onTop {
+		return fmt.Errorf("应用状态: 当前平台不支持窗口置顶")
+	}
+	if a.ConfigService != nil {
+		if err := a.ConfigService.SetWindowAlwaysOnTop(onTop); err != nil {
+			return err
+		}
+	}
+	if a.TrayManager != nil {
+		a.TrayManager.RefreshMenu()
+	}
+	a.AppMenu.Refresh()
+	return nil
+}
+
+// SetMiniWindowVisible 显示或关闭悬浮状态小窗，并保存配置以便下次启动恢复。
+func (a *AppState) SetMiniWindowVisible(visible bool) {
+	if visible {
+		if a.MiniWindow == nil {
+			a.MiniWindow = NewMiniWindow(a)
+		}
+		a.MiniWindow.Show()
+	} else if a.MiniWindow != nil {
+		a.MiniWindow.Close()
+		a.MiniWindow = nil
+	}
+	if a.ConfigService != nil {
+		_ = a.ConfigService.SetMiniWindowEnabled(visible)
+	}
+	if a.TrayManager != nil {
+		a.TrayManager.RefreshMenu()
+	}
+	a.AppMenu.Refresh()
+}
+
 // GetTheme 获取主题配置。
 // 返回：主题变体（dark、light 或 system）
 func (a *AppState) GetTheme() string {
@@ -488,11 +673,10 @@ func (a *AppState) SetTheme(themeStr string) error {
 		default:
 			variant = theme.VariantDark
 		}
-		a.App.Settings().SetTheme(NewMonochromeTheme(variant))
+		a.App.Settings().SetTheme(NewMonochromeTheme(variant, largeControls(a)))
 	}
 
-	// 使主窗口与托盘图标跟随主题：清除缓存并重新生成
-	ClearIconCaches()
+	// 使主窗口与托盘图标跟随主题（图标按主题缓存在内存中，首次切换到该主题时生成）
 	if a.App != nil {
 		if icon := createAppIcon(a); icon != nil {
 			a.App.SetIcon(icon)
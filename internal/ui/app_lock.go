@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// appLockRetryDelay 输错密码后暂停输入的时间，放慢逐个尝试 PIN
+const appLockRetryDelay = 2 * time.Second
+
+// appLockIdleCheckInterval 检查是否达到自动锁定时间的间隔，锁定最多因此推迟这么久
+const appLockIdleCheckInterval = 15 * time.Second
+
+// appLockState 应用锁的运行状态，只在 UI 线程访问。
+type appLockState struct {
+	locked     bool
+	unlocked   bool              // 本次解锁后已输入过密码，进入设置页不再询问；锁定时清除
+	hidden     fyne.CanvasObject // 锁定期间被锁屏替换的窗口内容，期间的页面切换也写入这里
+	miniWindow bool              // 锁定时关闭了悬浮窗（或启动时因锁定未恢复），解锁后重新显示
+	lastInput  time.Time         // 主窗口最近一次键盘或鼠标输入的时间
+	idleTimer  *time.Timer       // 下一次自动锁定检查
+}
+
+// appLockEnabled 是否设置了应用锁密码。
+func (a *AppState) appLockEnabled() bool {
+	return a.ConfigService != nil && a.ConfigService.AppLockEnabled()
+}
+
+// lockOnStartup 设置了密码且开启「启动时需要密码」时锁定主窗口，并开始检查无操作后的自动锁定。
+func (a *AppState) lockOnStartup() {
+	a.noteAppLockInput()
+	if a.Window != nil {
+		if dc, ok := a.Window.Canvas().(desktop.Canvas); ok {
+			dc.SetOnKeyDown(func(*fyne.KeyEvent) { a.noteAppLockInput() })
+		}
+	}
+	a.scheduleAppLockIdleCheck()
+	if a.appLockEnabled() && a.ConfigService.GetAppLockOnStartup() {
+		a.LockApp()
+	}
+}
+
+// LockApp 用锁屏替换主窗口内容，关闭窗口上打开的对话框以及不经过锁屏的其他窗口；未设置密码或已锁定时忽略。
+// 锁定只遮挡界面，代理与后台任务照常运行。
+func (a *AppState) LockApp() {
+	if a.Window == nil || a.appLock.locked || !a.appLockEnabled() {
+		return
+	}
+	a.closeWindowsForLock()
+	c := a.Window.Canvas()
+	for _, o := range c.Overlays().List() {
+		c.Overlays().Remove(o)
+	}
+	a.appLock.hidden = a.Window.Content()
+	a.appLock.locked = true
+	a.appLock.unlocked = false
+	entry, content := a.buildLockScreen()
+	a.Window.SetContent(content)
+	c.Focus(entry)
+}
+
+// unlockApp 校验密码，正确时恢复锁定前的窗口内容。
+func (a *AppState) unlockApp(password string) bool {
+	if !a.appLock.locked {
+		return true
+	}
+	if a.ConfigService != nil && !a.ConfigService.CheckAppLockPassword(password) {
+		return false
+	}
+	a.appLock.locked = false
+	a.appLock.unlocked = true
+	a.noteAppLockInput()
+	if a.appLock.hidden != nil {
+		a.Window.SetContent(a.appLock.hidden)
+		a.appLock.hidden = nil
+	}
+	if a.appLock.miniWindow {
+		a.appLock.miniWindow = false
+		a.SetMiniWindowVisible(true)
+	}
+	return true
+}
+
+// closeWindowsForLock 关闭悬浮窗、弹出的日志窗口与配置查看窗口：这些窗口可以切换代理或查看配置，不能留在锁屏之外。
+// 悬浮窗不改动「悬浮窗」配置，解锁后恢复。
+func (a *AppState) closeWindowsForLock() {
+	if a.MiniWindow != nil {
+		a.MiniWindow.Close()
+		a.MiniWindow = nil
+		a.appLock.miniWindow = true
+		if a.TrayManager != nil {
+			a.TrayManager.RefreshMenu()
+		}
+		a.AppMenu.Refresh()
+	}
+	if a.LogsPanel != nil {
+		a.LogsPanel.Attach()
+	}
+	if a.configViewer != nil && a.configViewer.window != nil {
+		a.configViewer.window.Close()
+	}
+}
+
+// buildLockScreen 创建锁屏：密码输入框与解锁按钮，返回输入框（用于聚焦）与锁屏内容。
+func (a *AppState) buildLockScreen() (*widget.Entry, fyne.CanvasObject) {
+	entry := widget.NewPasswordEntry()
+	entry.SetPlaceHolder("密码或 PIN")
+	status := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{})
+	status.Importance = widget.DangerImportance
+	status.Hide()
+
+	var unlockBtn *widget.Button
+	tryUnlock := func() {
+		if entry.Disabled() || a.unlockApp(entry.Text) {
+			return
+		}
+		entry.SetText("")
+		status.SetText("密码错误")
+		status.Show()
+		entry.Disable()
+		unlockBtn.Disable()
+		time.AfterFunc(appLockRetryDelay, func() {
+			fyne.Do(func() {
+				entry.Enable()
+				unlockBtn.Enable()
+				if a.appLock.locked {
+					a.Window.Canvas().Focus(entry)
+				}
+			})
+		})
+	}
+	entry.OnSubmitted = func(string) { tryUnlock() }
+	unlockBtn = widget.NewButtonWithIcon("解锁", theme.LoginIcon(), tryUnlock)
+	unlockBtn.Importance = widget.HighImportance
+
+	title := widget.NewLabelWithStyle("myproxy 已锁定", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	hint := widget.NewLabelWithStyle("输入应用锁密码以继续，锁定期间代理保持运行。", fyne.TextAlignCenter, fyne.TextStyle{})
+	hint.Wrapping = fyne.TextWrapWord
+	hint.Importance = widget.LowImportance
+
+	box := container.NewVBox(widget.NewIcon(theme.AccountIcon()), title, hint, entry, unlockBtn, status)
+	form := container.NewGridWrap(fyne.NewSize(280, box.MinSize().Height), box)
+	return entry, wrapPageWithBackground(container.NewCenter(form), a.App)
+}
+
+// noteAppLockInput 记录一次主窗口输入，自动锁定从最后一次输入开始计时。
+func (a *AppState) noteAppLockInput() {
+	a.appLock.lastInput = time.Now()
+}
+
+// scheduleAppLockIdleCheck 安排下一次自动锁定检查。
+func (a *AppState) scheduleAppLockIdleCheck() {
+	a.appLock.idleTimer = time.AfterFunc(appLockIdleCheckInterval, func() { fyne.Do(a.checkAppLockIdle) })
+}
+
+// checkAppLockIdle 距最后一次输入超过设置的时间后锁定，与窗口是否在前台无关：
+// 窗口留在前台但无人操作、失去焦点或隐藏到托盘时都不会有输入。
+func (a *AppState) checkAppLockIdle() {
+	defer a.scheduleAppLockIdleCheck()
+	if a.appLock.locked || !a.appLockEnabled() {
+		return
+	}
+	if timeout := a.ConfigService.GetAppLockIdleTimeout(); timeout > 0 && time.Since(a.appLock.lastInput) >= timeout {
+		a.LockApp()
+	}
+}
+
+// stopAppLockIdleCheck 退出时停止自动锁定检查。
+func (a *AppState) stopAppLockIdleCheck() {
+	if a.appLock.idleTimer != nil {
+		a.appLock.idleTimer.Stop()
+		a.appLock.idleTimer = nil
+	}
+}
+
+// appLockInputTracker 包裹主窗口内容，记录鼠标在窗口内的移动与点击（未被子组件处理的部分）作为输入。
+// 鼠标事件只分发给最内层的组件，移动经过按钮、列表项之间的空白处即会计入；
+// 按键由画布的 OnKeyDown 记录（焦点在输入框内时按键交给输入框，不经过画布）。
+type appLockInputTracker struct {
+	widget.BaseWidget
+	appState *AppState
+	content  fyne.CanvasObject
+}
+
+func newAppLockInputTracker(appState *AppState, content fyne.CanvasObject) *appLockInputTracker {
+	t := &appLockInputTracker{appState: appState, content: content}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *appLockInputTracker) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.content)
+}
+
+func (t *appLockInputTracker) MouseIn(*desktop.MouseEvent)    { t.appState.noteAppLockInput() }
+func (t *appLockInputTracker) MouseMoved(*desktop.MouseEvent) { t.appState.noteAppLockInput() }
+func (t *appLockInputTracker) MouseOut()                      {}
+func (t *appLockInputTracker) MouseDown(*desktop.MouseEvent)  { t.appState.noteAppLockInput() }
+func (t *appLockInputTracker) MouseUp(*desktop.MouseEvent)    {}
+
+// settingsAccessAllowed 是否可以直接进入设置页：未开启设置页保护、本次解锁后已输入过密码，
+// 或主窗口已锁定（页面在锁屏后构建，解锁时即已验证密码）。
+func (a *AppState) settingsAccessAllowed() bool {
+	if !a.appLockEnabled() || !a.ConfigService.GetAppLockSettings() {
+		return true
+	}
+	return a.appLock.locked || a.appLock.unlocked
+}
+
+// promptAppLockPassword 弹出密码输入框，密码正确后调用 onOK。
+func (a *AppState) promptAppLockPassword(title string, onOK func()) {
+	if a.Window == nil {
+		return
+	}
+	entry := widget.NewPasswordEntry()
+	entry.SetPlaceHolder("密码或 PIN")
+	d := dialog.NewForm(title, "确定", "取消", []*widget.FormItem{
+		{Text: "应用锁密码", Widget: entry},
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		if a.ConfigService != nil && !a.ConfigService.CheckAppLockPassword(entry.Text) {
+			dialog.ShowError(fmt.Errorf("密码错误"), a.Window)
+			return
+		}
+		a.appLock.unlocked = true
+		onOK()
+	}, a.Window)
+	d.Resize(fyne.NewSize(320, 0))
+	d.Show()
+	a.Window.Canvas().Focus(entry)
+}
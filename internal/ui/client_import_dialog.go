@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/subscription"
+)
+
+// showClientImportDialog 选择其他客户端（Clash YAML、v2rayN guiNConfig.json、Shadowrocket 备份）的配置文件，
+// 预览可导入的内容后在后台导入并显示结果。
+func showClientImportDialog(appState *AppState, onDone func()) {
+	if appState == nil || appState.Window == nil || appState.ClientImportService == nil {
+		return
+	}
+	openDialog := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, appState.Window)
+			return
+		}
+		if r == nil {
+			return
+		}
+		data, err := io.ReadAll(r)
+		name := r.URI().Name()
+		_ = r.Close()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("读取文件失败: %w", err), appState.Window)
+			return
+		}
+		cfg, err := appState.ClientImportService.Parse(data)
+		if err != nil {
+			dialog.ShowError(err, appState.Window)
+			return
+		}
+		confirmClientImport(appState, name, cfg, onDone)
+	}, appState.Window)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".yaml", ".yml", ".json", ".conf", ".txt"}))
+	openDialog.Show()
+}
+
+// confirmClientImport 展示解析结果，确认后导入。
+func confirmClientImport(appState *AppState, fileName string, cfg *subscription.ClientConfig, onDone func()) {
+	lines := []string{
+		fmt.Sprintf("识别为%s（%s）", cfg.FormatName(), fileName),
+		fmt.Sprintf("节点: %s", cfg.Report.Summary()),
+	}
+	if cfg.Report.Hint() != "" {
+		lines = append(lines, cfg.Report.Hint())
+	}
+	routes := fmt.Sprintf("直连规则 %d 条（与本地列表合并）", len(cfg.DirectRoutes))
+	if cfg.SkippedRules > 0 {
+		routes += fmt.Sprintf("，%d 条无法转换", cfg.SkippedRules)
+	}
+	lines = append(lines, routes)
+	if len(cfg.Subscriptions) > 0 {
+		names := make([]string, 0, len(cfg.Subscriptions))
+		for _, sub := range cfg.Subscriptions {
+			name := sub.Label
+			if name == "" {
+				name = sub.URL
+			}
+			names = append(names, name)
+		}
+		lines = append(lines, fmt.Sprintf("订阅 %d 个（导入后立即更新）: %s", len(cfg.Subscriptions), strings.Join(names, "、")))
+	}
+	summary := widget.NewLabel(strings.Join(lines, "\n"))
+	summary.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("从其他客户端导入", "导入", "取消", summary, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			result, err := appState.ClientImportService.Import(cfg)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, appState.Window)
+					return
+				}
+				appState.AppendLog("INFO", "app", fmt.Sprintf("已从%s导入: %s", cfg.FormatName(), strings.ReplaceAll(result.Summary(), "\n", "；")))
+				if result.RoutesAdded > 0 && appState.MainWindow != nil {
+					appState.MainWindow.RestartXrayIfRunning("导入的直连规则")
+				}
+				if onDone != nil {
+					onDone()
+				}
+				dialog.ShowInformation("从其他客户端导入", result.Summary(), appState.Window)
+			})
+		}()
+	}, appState.Window)
+	d.Resize(fyne.NewSize(460, 0))
+	d.Show()
+}
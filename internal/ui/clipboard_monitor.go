@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/urlscheme"
+)
+
+const (
+	// clipboardPollInterval 剪贴板轮询间隔
+	clipboardPollInterval = 1500 * time.Millisecond
+	// clipboardLowPowerPollInterval 低功耗模式下的剪贴板轮询间隔
+	clipboardLowPowerPollInterval = 5 * time.Second
+	// clipboardPromptTimeout 导入提示自动消失的时间
+	clipboardPromptTimeout = 10 * time.Second
+)
+
+// ClipboardMonitor 剪贴板链接检测：定期读取剪贴板，发现节点分享链接或订阅地址时在窗口底部弹出导入提示。
+// 同一内容只提示一次；已存在的订阅地址不再提示。
+type ClipboardMonitor struct {
+	appState *AppState
+
+	mu       sync.Mutex
+	stopCh   chan struct{}
+	lastSeen string
+	popup    *widget.PopUp
+}
+
+// NewClipboardMonitor 创建剪贴板监听器。
+func NewClipboardMonitor(appState *AppState) *ClipboardMonitor {
+	return &ClipboardMonitor{appState: appState}
+}
+
+// ApplyConfig 按配置启动或停止监听。
+func (cm *ClipboardMonitor) ApplyConfig() {
+	if cm.appState == nil || cm.appState.ConfigService == nil {
+		return
+	}
+	if cm.appState.ConfigService.GetClipboardMonitorEnabled() {
+		cm.Start()
+	} else {
+		cm.Stop()
+	}
+}
+
+// Start 开始监听；已在运行时忽略。
+func (cm *ClipboardMonitor) Start() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.stopCh != nil {
+		return
+	}
+	stopCh := make(chan struct{})
+	cm.stopCh = stopCh
+	go func() {
+		interval := cm.pollInterval()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				fyne.Do(cm.check)
+				if next := cm.pollInterval(); next != interval {
+					interval = next
+					ticker.Reset(interval)
+				}
+			}
+		}
+	}()
+}
+
+// pollInterval 返回当前轮询间隔，低功耗模式下放缓。
+func (cm *ClipboardMonitor) pollInterval() time.Duration {
+	if cm.appState != nil && cm.appState.PowerService.LowPower() {
+		return clipboardLowPowerPollInterval
+	}
+	return clipboardPollInterval
+}
+
+// Stop 停止监听并关闭正在显示的提示。
+func (cm *ClipboardMonitor) Stop() {
+	cm.mu.Lock()
+	if cm.stopCh != nil {
+		close(cm.stopCh)
+		cm.stopCh = nil
+	}
+	cm.mu.Unlock()
+	fyne.Do(cm.hidePrompt)
+}
+
+// check 读取剪贴板并在识别到新链接时提示（须在主线程调用）。
+func (cm *ClipboardMonitor) check() {
+	if cm.appState == nil || cm.appState.Window == nil {
+		return
+	}
+	content := strings.TrimSpace(cm.appState.Window.Clipboard().Content())
+	cm.mu.Lock()
+	if content == "" || content == cm.lastSeen {
+		cm.mu.Unlock()
+		return
+	}
+	first := cm.lastSeen == ""
+	cm.lastSeen = content
+	cm.mu.Unlock()
+
+	// 启动前已在剪贴板中的内容不提示，避免每次打开应用都弹出
+	if first {
+		return
+	}
+	link := urlscheme.DetectClipboardLink(content)
+	if link == nil || cm.alreadyImported(link) {
+		return
+	}
+	if link.Profile != "" {
+		cm.appState.AppendLog("DEBUG", "app", "剪贴板检测到分享配置")
+	} else {
+		cm.appState.AppendLog("DEBUG", "app", "剪贴板检测到链接: "+logging.Redact(link.Target))
+	}
+	cm.showPrompt(link)
+}
+
+// IgnoreContent 应用自身写入剪贴板的内容（如复制的分享链接）不再提示导入。
+func (cm *ClipboardMonitor) IgnoreContent(content string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.lastSeen = strings.TrimSpace(content)
+}
+
+// alreadyImported 订阅地址已存在时不再提示。
+func (cm *ClipboardMonitor) alreadyImported(link *urlscheme.Link) bool {
+	if !link.IsSubscription() || cm.appState.Store == nil || cm.appState.Store.Subscriptions == nil {
+		return false
+	}
+	for _, sub := range cm.appState.Store.Subscriptions.GetAll() {
+		if sub.URL == link.Target {
+			return true
+		}
+	}
+	return false
+}
+
+// showPrompt 在窗口底部显示非模态提示，点击外部或超时后自动关闭。
+func (cm *ClipboardMonitor) showPrompt(link *urlscheme.Link) {
+	cm.hidePrompt()
+
+	message := "检测到节点链接，是否导入？"
+	if link.Profile != "" {
+		message = "检测到分享的配置，是否导入？"
+	} else if link.IsSubscription() {
+		message = "检测到订阅地址，是否导入？"
+	}
+	label := widget.NewLabel(message)
+
+	importBtn := widget.NewButtonWithIcon("导入", theme.DownloadIcon(), func() {
+		cm.hidePrompt()
+		cm.appState.openImportLink(link)
+	})
+	importBtn.Importance = widget.HighImportance
+	ignoreBtn := widget.NewButton("忽略", cm.hidePrompt)
+	ignoreBtn.Importance = widget.LowImportance
+
+	content := container.NewHBox(widget.NewIcon(theme.ContentPasteIcon()), label, layout.NewSpacer(), ignoreBtn, importBtn)
+	canvas := cm.appState.Window.Canvas()
+	popup := widget.NewPopUp(content, canvas)
+	size := popup.MinSize()
+	canvasSize := canvas.Size()
+	popup.ShowAtPosition(fyne.NewPos((canvasSize.Width-size.Width)/2, canvasSize.Height-size.Height-theme.Padding()*4))
+	cm.popup = popup
+
+	time.AfterFunc(clipboardPromptTimeout, func() {
+		fyne.Do(func() {
+			if cm.popup == popup {
+				cm.hidePrompt()
+			}
+		})
+	})
+}
+
+// hidePrompt 关闭当前提示（须在主线程调用）。
+func (cm *ClipboardMonitor) hidePrompt() {
+	if cm.popup != nil {
+		cm.popup.Hide()
+		cm.popup = nil
+	}
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// showNodeCompareDialog 选择两个节点，同时经各自的临时实例测量延迟与下载速度并并排展示。
+// preset 为默认填入左侧的节点。
+func showNodeCompareDialog(appState *AppState, preset *model.Node) {
+	if appState == nil || appState.Window == nil || appState.XrayControlService == nil || appState.Store == nil || appState.Store.Nodes == nil {
+		return
+	}
+	nodes := appState.Store.Nodes.GetAll()
+	if len(nodes) < 2 {
+		dialog.ShowInformation("节点对比", "至少需要两个节点才能对比", appState.Window)
+		return
+	}
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = fmt.Sprintf("%s (%s:%d)", n.Name, n.Addr, n.Port)
+	}
+	nodeAt := func(s *widget.Select) *model.Node {
+		if i := s.SelectedIndex(); i >= 0 && i < len(nodes) {
+			return nodes[i]
+		}
+		return nil
+	}
+
+	selectA := widget.NewSelect(names, nil)
+	selectB := widget.NewSelect(names, nil)
+	selectA.SetSelectedIndex(0)
+	if preset != nil {
+		for i, n := range nodes {
+			if n.ID == preset.ID {
+				selectA.SetSelectedIndex(i)
+				break
+			}
+		}
+	}
+	if selectA.SelectedIndex() == 0 {
+		selectB.SetSelectedIndex(1)
+	} else {
+		selectB.SetSelectedIndex(0)
+	}
+
+	resultA := widget.NewLabel("")
+	resultB := widget.NewLabel("")
+	resultA.Wrapping = fyne.TextWrapWord
+	resultB.Wrapping = fyne.TextWrapWord
+	statusLabel := widget.NewLabel("两个节点会同时运行约 10–20 秒，期间正在运行的代理日志暂停输出。")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	var cancel context.CancelFunc
+	var startBtn *widget.Button
+	startBtn = widget.NewButton("开始对比", func() {
+		a, b := nodeAt(selectA), nodeAt(selectB)
+		if a == nil || b == nil || a.ID == b.ID {
+			statusLabel.SetText("请选择两个不同的节点")
+			return
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		startBtn.Disable()
+		resultA.SetText("测量中…")
+		resultB.SetText("测量中…")
+		statusLabel.SetText("正在对比…")
+		go func() {
+			results, err := appState.XrayControlService.CompareNodes(ctx, a, b)
+			fyne.Do(func() {
+				startBtn.Enable()
+				if appState.MainWindow != nil {
+					appState.MainWindow.RestartXrayIfRunning("节点对比")
+				}
+				if err != nil {
+					statusLabel.SetText(err.Error())
+					return
+				}
+				resultA.SetText(formatCompareResult(&results[0]))
+				resultB.SetText(formatCompareResult(&results[1]))
+				statusLabel.SetText(compareVerdict(results))
+			})
+		}()
+	})
+	startBtn.Importance = widget.HighImportance
+
+	columns := container.NewGridWithColumns(2,
+		container.NewVBox(widget.NewLabel("节点 A"), selectA, resultA),
+		container.NewVBox(widget.NewLabel("节点 B"), selectB, resultB),
+	)
+	content := container.NewVBox(columns, statusLabel, startBtn)
+	d := dialog.NewCustom("节点对比", "关闭", content, appState.Window)
+	d.SetOnClosed(func() {
+		if cancel != nil {
+			cancel()
+		}
+	})
+	d.Resize(fyne.NewSize(560, 360))
+	d.Show()
+}
+
+// formatCompareResult 格式化单个节点的对比结果。
+func formatCompareResult(r *service.NodeCompareResult) string {
+	if len(r.Latencies) == 0 {
+		if r.Err != nil {
+			return "失败: " + r.Err.Error()
+		}
+		return "失败"
+	}
+	lines := []string{
+		fmt.Sprintf("延迟中位数: %d ms", r.MedianLatency().Milliseconds()),
+		fmt.Sprintf("成功: %d/%d", len(r.Latencies), len(r.Latencies)+r.Failures),
+	}
+	if r.Throughput > 0 {
+		lines = append(lines, "下载速度: "+formatSpeed(int64(r.Throughput)))
+	} else if r.Err != nil {
+		lines = append(lines, "下载速度: 失败（"+r.Err.Error()+"）")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compareVerdict 根据延迟与下载速度给出简短结论。
+func compareVerdict(results [2]service.NodeCompareResult) string {
+	a, b := &results[0], &results[1]
+	switch {
+	case len(a.Latencies) == 0 && len(b.Latencies) == 0:
+		return "两个节点均不可用"
+	case len(a.Latencies) == 0:
+		return "节点 A 不可用，建议使用节点 B"
+	case len(b.Latencies) == 0:
+		return "节点 B 不可用，建议使用节点 A"
+	}
+	latencyWinner, speedWinner := "A", "A"
+	if b.MedianLatency() < a.MedianLatency() {
+		latencyWinner = "B"
+	}
+	if b.Throughput > a.Throughput {
+		speedWinner = "B"
+	}
+	if a.Throughput == 0 && b.Throughput == 0 {
+		return fmt.Sprintf("节点 %s 延迟更低", latencyWinner)
+	}
+	if latencyWinner == speedWinner {
+		return fmt.Sprintf("节点 %s 延迟更低、下载更快", latencyWinner)
+	}
+	return fmt.Sprintf("节点 %s 延迟更低，节点 %s 下载更快", latencyWinner, speedWinner)
+}
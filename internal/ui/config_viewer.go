@@ -0,0 +1,340 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+// configViewerDefaultSize 配置查看窗口的默认大小
+var configViewerDefaultSize = fyne.NewSize(720, 640)
+
+// ConfigViewer 「查看当前配置」调试窗口：展示最近一次启动代理时生成的 xray 配置（语法高亮、可复制），
+// 并提供「校验配置」（不启动代理，用 xray 加载器检查按当前设置生成的配置）与「编辑并以此配置重启」的高级入口，后者仅本次运行生效。
+type ConfigViewer struct {
+	appState *AppState
+	window   fyne.Window
+
+	statusLabel *widget.Label
+	redactCheck *widget.Check
+	copyBtn     *widget.Button
+	checkBtn    *widget.Button
+	editBtn     *widget.Button
+	viewer      *widget.RichText
+	body        *fyne.Container
+}
+
+// ShowConfigViewer 打开配置查看窗口；已打开时切到前台并刷新。
+func (a *AppState) ShowConfigViewer() {
+	if a.configViewer != nil && a.configViewer.window != nil {
+		a.configViewer.refresh()
+		a.configViewer.window.Show()
+		a.configViewer.window.RequestFocus()
+		return
+	}
+	if a.App == nil {
+		return
+	}
+	cv := &ConfigViewer{appState: a}
+	cv.window = a.App.NewWindow("myproxy - 当前 xray 配置")
+	cv.window.SetContent(cv.build())
+	cv.window.Resize(configViewerDefaultSize)
+	cv.window.SetOnClosed(func() {
+		if a.configViewer == cv {
+			a.configViewer = nil
+		}
+	})
+	a.configViewer = cv
+	cv.refresh()
+	cv.window.Show()
+}
+
+func (cv *ConfigViewer) build() fyne.CanvasObject {
+	cv.statusLabel = widget.NewLabel("")
+	cv.statusLabel.Wrapping = fyne.TextWrapWord
+	cv.redactCheck = widget.NewCheck("隐藏凭据", func(bool) { cv.refresh() })
+	cv.redactCheck.SetChecked(true)
+	cv.copyBtn = widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), cv.copyConfig)
+	refreshBtn := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), cv.refresh)
+	cv.checkBtn = widget.NewButtonWithIcon("校验配置", theme.ConfirmIcon(), cv.validateGenerated)
+	cv.editBtn = widget.NewButtonWithIcon("编辑并以此配置重启…", theme.DocumentCreateIcon(), cv.confirmEdit)
+	cv.editBtn.Importance = widget.DangerImportance
+
+	cv.viewer = widget.NewRichText()
+	cv.viewer.Wrapping = fyne.TextWrapOff
+	cv.body = container.NewStack(container.NewScroll(cv.viewer))
+
+	toolbar := container.NewHBox(cv.redactCheck, layout.NewSpacer(), refreshBtn, cv.checkBtn, cv.copyBtn, cv.editBtn)
+	return container.NewBorder(container.NewVBox(toolbar, cv.statusLabel), nil, nil, nil, cv.body)
+}
+
+// currentConfig 读取最近一次启动使用的配置，redact 为 true 时隐藏凭据。
+func (cv *ConfigViewer) currentConfig(redact bool) (string, bool) {
+	if cv.appState == nil || cv.appState.XrayControlService == nil {
+		return "", false
+	}
+	data, custom := cv.appState.XrayControlService.CurrentConfig(redact)
+	return string(data), custom
+}
+
+// refresh 重新读取配置并高亮显示。
+func (cv *ConfigViewer) refresh() {
+	if cv.viewer == nil {
+		return
+	}
+	text, custom := cv.currentConfig(cv.redactCheck.Checked)
+	running := cv.appState.IsProxyRunning()
+	switch {
+	case text == "":
+		cv.statusLabel.SetText("本次运行尚未启动过代理，连接后即可查看生成的配置。")
+	case custom:
+		cv.statusLabel.SetText("当前使用的是手动编辑的配置；再次连接或切换节点后恢复自动生成。")
+	case running:
+		cv.statusLabel.SetText("以下为当前运行中的 xray 配置，由设置与选中节点自动生成。")
+	default:
+		cv.statusLabel.SetText("代理未运行，以下为最近一次启动时生成的配置。")
+	}
+	if text == "" {
+		cv.viewer.Segments = nil
+		cv.copyBtn.Disable()
+		cv.editBtn.Disable()
+	} else {
+		cv.viewer.Segments = jsonHighlightSegments(text)
+		cv.copyBtn.Enable()
+		if running {
+			cv.editBtn.Enable()
+		} else {
+			cv.editBtn.Disable()
+		}
+	}
+	cv.viewer.Refresh()
+}
+
+func (cv *ConfigViewer) copyConfig() {
+	text, _ := cv.currentConfig(cv.redactCheck.Checked)
+	if text == "" {
+		return
+	}
+	cv.window.Clipboard().SetContent(text)
+	if cv.redactCheck.Checked {
+		cv.statusLabel.SetText("已复制配置（凭据已隐藏）")
+	} else {
+		cv.statusLabel.SetText("已复制配置（包含凭据，请勿公开分享）")
+	}
+}
+
+// confirmEdit 警告后进入编辑模式：编辑的是包含凭据的完整配置。
+func (cv *ConfigViewer) confirmEdit() {
+	dialog.ShowConfirm("编辑 xray 配置",
+		"此功能仅用于调试：将跳过所有设置，直接以你编辑的配置重启 xray。\n"+
+			"配置有误可能导致无法上网、系统代理指向错误端口；再次连接或切换节点后恢复自动生成的配置。\n\n确定继续？",
+		func(ok bool) {
+			if ok {
+				cv.showEditor()
+			}
+		}, cv.window)
+}
+
+// showEditor 以可编辑文本框替换高亮视图。
+func (cv *ConfigViewer) showEditor() {
+	text, _ := cv.currentConfig(false)
+	if text == "" {
+		return
+	}
+	editor := widget.NewMultiLineEntry()
+	editor.TextStyle = fyne.TextStyle{Monospace: true}
+	editor.Wrapping = fyne.TextWrapOff
+	editor.SetText(text)
+
+	var restore func()
+	checkBtn := widget.NewButtonWithIcon("仅校验", theme.ConfirmIcon(), func() {
+		err := xray.ValidateConfigJSON([]byte(strings.TrimSpace(editor.Text)))
+		cv.showValidationResult(err, "编辑后的配置可以被 xray 加载。")
+	})
+	applyBtn := widget.NewButtonWithIcon("校验并重启", theme.ConfirmIcon(), func() {
+		cv.applyEdited(editor.Text, restore)
+	})
+	applyBtn.Importance = widget.DangerImportance
+	cancelBtn := widget.NewButtonWithIcon("取消", theme.CancelIcon(), func() { restore() })
+
+	viewerContent := cv.body.Objects
+	restore = func() {
+		cv.body.Objects = viewerContent
+		cv.body.Refresh()
+		cv.redactCheck.Enable()
+		cv.refresh()
+	}
+	cv.redactCheck.Disable()
+	cv.copyBtn.Disable()
+	cv.editBtn.Disable()
+	cv.statusLabel.SetText("编辑模式：配置包含凭据。校验通过后立即以此配置重启 xray。")
+	cv.body.Objects = []fyne.CanvasObject{container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), cancelBtn, checkBtn, applyBtn), nil, nil, editor)}
+	cv.body.Refresh()
+}
+
+// applyEdited 校验编辑后的配置，通过后以其重启 xray；失败时停留在编辑模式并提示错误。
+func (cv *ConfigViewer) applyEdited(text string, done func()) {
+	xcs := cv.appState.XrayControlService
+	mw := cv.appState.MainWindow
+	if xcs == nil || mw == nil {
+		return
+	}
+	if !cv.appState.IsProxyRunning() {
+		dialog.ShowInformation("编辑 xray 配置", "代理未运行，请先连接后再使用此功能。", cv.window)
+		return
+	}
+	if err := xcs.SetConfigOverride([]byte(strings.TrimSpace(text))); err != nil {
+		dialog.ShowError(fmt.Errorf("配置无效，未重启: %w", err), cv.window)
+		return
+	}
+	cv.appState.AppendLog("WARN", "app", "正在以手动编辑的 xray 配置重启代理")
+	mw.RestartXrayIfRunning("手动编辑的配置")
+	done()
+}
+
+// validateGenerated 在后台按当前设置与选中节点生成配置并交给 xray 加载器校验，不启动代理。
+func (cv *ConfigViewer) validateGenerated() {
+	xcs := cv.appState.XrayControlService
+	if xcs == nil {
+		return
+	}
+	status := cv.statusLabel.Text
+	cv.checkBtn.Disable()
+	cv.statusLabel.SetText("正在校验配置…")
+	go func() {
+		err := xcs.ValidateConfig()
+		fyne.Do(func() {
+			cv.checkBtn.Enable()
+			cv.statusLabel.SetText(status)
+			cv.showValidationResult(err, "按当前设置与选中节点生成的配置可以被 xray 加载，连接时不会因配置字段无效而失败。")
+		})
+	}()
+}
+
+// showValidationResult 展示校验结果；节点或配置校验失败时逐条列出问题。
+func (cv *ConfigViewer) showValidationResult(err error, okText string) {
+	if err == nil {
+		dialog.ShowInformation("校验配置", "校验通过："+okText, cv.window)
+		return
+	}
+	detail := widget.NewLabel(validationProblemsText(err))
+	detail.Wrapping = fyne.TextWrapWord
+	d := dialog.NewCustom("校验配置", "关闭", container.NewVScroll(detail), cv.window)
+	d.Resize(fyne.NewSize(560, 360))
+	d.Show()
+}
+
+// validationProblemsText 将校验错误整理为逐行列出的问题。
+func validationProblemsText(err error) string {
+	var nodeErr *model.NodeValidationError
+	var configErr *xray.ConfigValidationError
+	var header string
+	var problems []string
+	switch {
+	case errors.As(err, &nodeErr):
+		header = fmt.Sprintf("节点 %s（%s）的字段不满足协议要求：", nodeErr.NodeName, nodeErr.Protocol)
+		problems = nodeErr.Problems
+	case errors.As(err, &configErr):
+		header = fmt.Sprintf("xray %s配置失败，错误链（由外到内）：", configErr.Stage)
+		problems = configErr.Problems
+	default:
+		return "校验失败：" + err.Error()
+	}
+	var b strings.Builder
+	b.WriteString(header)
+	for _, p := range problems {
+		b.WriteString("\n• ")
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// JSON 语法高亮的配色
+const (
+	jsonColorKey    = theme.ColorNamePrimary
+	jsonColorString = theme.ColorNameSuccess
+	jsonColorNumber = theme.ColorNameWarning
+	jsonColorLit    = theme.ColorNameError
+	jsonColorPunct  = theme.ColorNameForeground
+)
+
+// jsonHighlightSegments 将缩进后的 JSON 文本切分为带颜色的等宽文本段：键、字符串、数字、true/false/null 分别着色。
+// 不做完整解析，仅按词法切分，遇到无法识别的字符原样输出。
+func jsonHighlightSegments(text string) []widget.RichTextSegment {
+	var segs []widget.RichTextSegment
+	add := func(s string, color fyne.ThemeColorName) {
+		if s == "" {
+			return
+		}
+		// 相邻同色片段合并，减少段数
+		if n := len(segs); n > 0 {
+			if last := segs[n-1].(*widget.TextSegment); last.Style.ColorName == color {
+				last.Text += s
+				return
+			}
+		}
+		segs = append(segs, &widget.TextSegment{Text: s, Style: widget.RichTextStyle{
+			Inline:    true,
+			ColorName: color,
+			TextStyle: fyne.TextStyle{Monospace: true},
+		}})
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(text) && text[j] != '"' {
+				if text[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(text) {
+				j++
+			}
+			// 字符串后（跳过空白）紧跟冒号的是键
+			k := j
+			for k < len(text) && (text[k] == ' ' || text[k] == '\t') {
+				k++
+			}
+			color := jsonColorString
+			if k < len(text) && text[k] == ':' {
+				color = jsonColorKey
+			}
+			add(text[i:min(j, len(text))], color)
+			i = j
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(text) && strings.IndexByte("0123456789.eE+-", text[j]) >= 0 {
+				j++
+			}
+			add(text[i:j], jsonColorNumber)
+			i = j
+		case strings.HasPrefix(text[i:], "true"), strings.HasPrefix(text[i:], "null"):
+			add(text[i:i+4], jsonColorLit)
+			i += 4
+		case strings.HasPrefix(text[i:], "false"):
+			add(text[i:i+5], jsonColorLit)
+			i += 5
+		default:
+			j := i + 1
+			for j < len(text) && strings.IndexByte("\"-0123456789tfn", text[j]) < 0 {
+				j++
+			}
+			add(text[i:j], jsonColorPunct)
+			i = j
+		}
+	}
+	return segs
+}
@@ -225,6 +225,12 @@ func (dp *DiagnosticsPage) Build() fyne.CanvasObject {
 		}),
 	)
 
+	viewConfigBtn := widget.NewButtonWithIcon("查看当前配置", theme.FileTextIcon(), func() {
+		if dp.appState != nil {
+			dp.appState.ShowConfigViewer()
+		}
+	})
+
 	configCard := container.NewVBox(
 		widget.NewLabelWithStyle("诊断配置", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		dp.pprofCheck,
@@ -253,6 +259,7 @@ func (dp *DiagnosticsPage) Build() fyne.CanvasObject {
 		buttonsRow1,
 		buttonsRow2,
 		buttonsRow3,
+		viewConfigBtn,
 	)
 
 	dp.content = newPaddedWithSize(content, spacing)
@@ -338,9 +345,9 @@ func (dp *DiagnosticsPage) currentSummary() model.DiagnosticSummary {
 				serverName = selected.Name
 			}
 		}
-		if dp.appState.XrayInstance != nil && dp.appState.XrayInstance.IsRunning() {
+		if dp.appState.IsProxyRunning() {
 			proxyRunning = true
-			proxyPort = dp.appState.XrayInstance.GetPort()
+			proxyPort = dp.appState.XrayInstance().GetPort()
 		}
 	}
 	return dp.appState.DiagnosticsService.GetSummary(proxyRunning, proxyPort, serverName)
@@ -445,13 +452,13 @@ func formatBytes(value uint64) string {
 	)
 	switch {
 	case value >= gb:
-		return fmt.Sprintf("%.2f GB", float64(value)/float64(gb))
+		return formatNumber(float64(value)/float64(gb), 2) + " GB"
 	case value >= mb:
-		return fmt.Sprintf("%.2f MB", float64(value)/float64(mb))
+		return formatNumber(float64(value)/float64(mb), 2) + " MB"
 	case value >= kb:
-		return fmt.Sprintf("%.2f KB", float64(value)/float64(kb))
+		return formatNumber(float64(value)/float64(kb), 2) + " KB"
 	default:
-		return fmt.Sprintf("%d B", value)
+		return formatNumber(float64(value), 0) + " B"
 	}
 }
 
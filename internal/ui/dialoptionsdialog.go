@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+// dialStrategyDefault 域名解析策略下拉框中表示「xray 默认」的选项
+const dialStrategyDefault = "默认（AsIs）"
+
+// showDialOptionsDialog 编辑节点的拨号选项（TCP Fast Open、保活、超时、域名解析策略、Happy Eyeballs，
+// SOCKS5 节点另有内置转发开关），保存后重启运行中的代理。
+func showDialOptionsDialog(appState *AppState, node *model.Node) {
+	if appState == nil || appState.Window == nil || appState.ServerService == nil || node == nil {
+		return
+	}
+	opts := node.DialOptions
+
+	formatInt := func(v int) string {
+		if v <= 0 {
+			return ""
+		}
+		return strconv.Itoa(v)
+	}
+	newIntEntry := func(v int, placeholder string) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetPlaceHolder(placeholder)
+		e.SetText(formatInt(v))
+		return e
+	}
+
+	tfoCheck := widget.NewCheck("启用 TCP Fast Open", nil)
+	tfoCheck.SetChecked(opts.TCPFastOpen)
+	keepAliveEntry := newIntEntry(opts.TCPKeepAliveInterval, "系统默认")
+	userTimeoutEntry := newIntEntry(opts.TCPUserTimeout, "系统默认")
+	happyEyeballsEntry := newIntEntry(opts.HappyEyeballsDelay, "不启用")
+	strategySelect := widget.NewSelect(append([]string{dialStrategyDefault}, xray.DomainStrategies...), nil)
+	strategySelect.SetSelected(dialStrategyDefault)
+	if opts.DomainStrategy != "" {
+		strategySelect.SetSelected(opts.DomainStrategy)
+	}
+
+	items := []*widget.FormItem{
+		{Text: "TCP Fast Open", Widget: tfoCheck, HintText: "减少建连往返，需系统与服务器支持"},
+		{Text: "保活间隔（秒）", Widget: keepAliveEntry},
+		{Text: "TCP 超时（毫秒）", Widget: userTimeoutEntry, HintText: "数据长时间未确认时断开，便于尽快发现失效连接"},
+		{Text: "域名解析策略", Widget: strategySelect, HintText: "服务器地址为域名时的解析方式，如 UseIPv4 仅用 IPv4"},
+		{Text: "Happy Eyeballs（毫秒）", Widget: happyEyeballsEntry, HintText: "多个 IP 依次尝试的间隔，需配合 UseIP 类策略"},
+	}
+	forwardCheck := widget.NewCheck("使用内置转发（不启动 xray）", nil)
+	forwardCheck.SetChecked(opts.BuiltinForward)
+	if node.ProtocolType == "socks5" {
+		items = append(items, &widget.FormItem{Text: "内置转发", Widget: forwardCheck, HintText: "开销更小；仅转发 TCP，直连规则、分流、负载均衡等不生效"})
+	}
+
+	parseInt := func(name, text string) (int, error) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return 0, nil
+		}
+		v, err := strconv.Atoi(text)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("%s需为非负整数", name)
+		}
+		return v, nil
+	}
+
+	d := dialog.NewForm("高级设置 - "+node.Name, "保存", "取消", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		updated := model.DialOptions{TCPFastOpen: tfoCheck.Checked, BuiltinForward: node.ProtocolType == "socks5" && forwardCheck.Checked}
+		var err error
+		if updated.TCPKeepAliveInterval, err = parseInt("保活间隔", keepAliveEntry.Text); err == nil {
+			if updated.TCPUserTimeout, err = parseInt("TCP 超时", userTimeoutEntry.Text); err == nil {
+				updated.HappyEyeballsDelay, err = parseInt("Happy Eyeballs", happyEyeballsEntry.Text)
+			}
+		}
+		if err == nil {
+			if strategySelect.Selected != dialStrategyDefault {
+				updated.DomainStrategy = strategySelect.Selected
+			}
+			err = appState.ServerService.UpdateDialOptions(node.ID, updated)
+		}
+		if err != nil {
+			dialog.ShowError(err, appState.Window)
+			return
+		}
+		if appState.MainWindow != nil {
+			appState.MainWindow.RestartXrayIfRunning("节点拨号选项")
+		}
+	}, appState.Window)
+	d.Resize(fyne.NewSize(460, 480))
+	d.Show()
+}
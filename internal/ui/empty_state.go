@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// skeletonRowCount 骨架屏占位行数
+const skeletonRowCount = 6
+
+// EmptyState 列表为空时的提示：图标、标题、说明与可选的操作按钮。
+type EmptyState struct {
+	content   fyne.CanvasObject
+	title     *widget.Label
+	hint      *widget.Label
+	actionBtn *widget.Button
+}
+
+// NewEmptyState 创建空状态提示；actionText 为空时不显示操作按钮。
+func NewEmptyState(icon fyne.Resource, title, hint, actionText string, action func()) *EmptyState {
+	es := &EmptyState{}
+	iconImg := widget.NewIcon(icon)
+	iconBox := container.NewCenter(container.NewGridWrap(fyne.NewSize(48, 48), iconImg))
+
+	es.title = widget.NewLabel(title)
+	es.title.Alignment = fyne.TextAlignCenter
+	es.title.TextStyle = fyne.TextStyle{Bold: true}
+
+	es.hint = widget.NewLabel(hint)
+	es.hint.Alignment = fyne.TextAlignCenter
+	es.hint.Wrapping = fyne.TextWrapWord
+	es.hint.Importance = widget.LowImportance
+
+	es.actionBtn = widget.NewButton(actionText, action)
+	es.actionBtn.Importance = widget.HighImportance
+	if actionText == "" {
+		es.actionBtn.Hide()
+	}
+
+	es.content = container.NewVBox(
+		layout.NewSpacer(),
+		iconBox,
+		es.title,
+		es.hint,
+		container.NewCenter(es.actionBtn),
+		layout.NewSpacer(),
+	)
+	return es
+}
+
+// Set 更新提示文本与操作；用于同一位置在「无数据」与「无匹配」之间切换。
+func (es *EmptyState) Set(title, hint, actionText string, action func()) {
+	es.title.SetText(title)
+	es.hint.SetText(hint)
+	es.actionBtn.SetText(actionText)
+	es.actionBtn.OnTapped = action
+	if actionText == "" {
+		es.actionBtn.Hide()
+	} else {
+		es.actionBtn.Show()
+	}
+}
+
+// newSkeletonList 构建加载中的骨架占位：若干行灰色圆角条，宽度交错以模拟列表内容。
+func newSkeletonList(appState *AppState) fyne.CanvasObject {
+	var app fyne.App
+	if appState != nil {
+		app = appState.App
+	}
+	fill := CurrentThemeColor(app, theme.ColorNameInputBackground)
+	rows := container.NewVBox()
+	for i := 0; i < skeletonRowCount; i++ {
+		bar := canvas.NewRectangle(fill)
+		bar.CornerRadius = 4
+		bar.SetMinSize(fyne.NewSize(0, 18))
+		short := canvas.NewRectangle(fill)
+		short.CornerRadius = 4
+		short.SetMinSize(fyne.NewSize(48, 18))
+		// 奇偶行的右侧短条交错，避免骨架看起来像一整块
+		if i%2 == 0 {
+			rows.Add(container.NewBorder(nil, nil, nil, short, bar))
+		} else {
+			rows.Add(container.NewBorder(nil, nil, short, nil, bar))
+		}
+	}
+	return rows
+}
+
+// ListStateView 列表容器的三态切换：加载中显示骨架、无数据显示空状态、否则显示列表。
+type ListStateView struct {
+	content  *fyne.Container
+	list     fyne.CanvasObject
+	empty    *EmptyState
+	skeleton fyne.CanvasObject
+	loading  bool
+}
+
+// NewListStateView 创建三态列表容器。
+func NewListStateView(appState *AppState, list fyne.CanvasObject, empty *EmptyState) *ListStateView {
+	v := &ListStateView{
+		list:     list,
+		empty:    empty,
+		skeleton: newSkeletonList(appState),
+	}
+	v.content = container.NewStack(list, empty.content, v.skeleton)
+	v.Sync(0)
+	return v
+}
+
+// Content 返回可放入布局的容器。
+func (v *ListStateView) Content() fyne.CanvasObject {
+	return v.content
+}
+
+// SetLoading 设置是否处于加载中；仅在列表为空时才显示骨架，已有数据时保留旧内容避免闪烁。
+func (v *ListStateView) SetLoading(loading bool, count int) {
+	v.loading = loading
+	v.Sync(count)
+}
+
+// Sync 按当前条目数与加载状态切换显示内容。
+func (v *ListStateView) Sync(count int) {
+	switch {
+	case count > 0:
+		v.skeleton.Hide()
+		v.empty.content.Hide()
+		v.list.Show()
+	case v.loading:
+		v.list.Hide()
+		v.empty.content.Hide()
+		v.skeleton.Show()
+	default:
+		v.list.Hide()
+		v.skeleton.Hide()
+		v.empty.content.Show()
+	}
+}
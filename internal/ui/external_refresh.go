@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+)
+
+// handleExternalRefresh 其他进程修改数据库后记录日志并刷新节点列表；节点与订阅数据已由 Store 重新加载。可在任意 goroutine 中调用。
+func (a *AppState) handleExternalRefresh(nodes, subscriptions bool) {
+	switch {
+	case nodes && subscriptions:
+		a.AppendLog("INFO", "app", "检测到其他程序修改了数据库，已刷新节点与订阅列表")
+	case nodes:
+		a.AppendLog("INFO", "app", "检测到其他程序修改了数据库，已刷新节点列表")
+	default:
+		a.AppendLog("INFO", "app", "检测到其他程序修改了数据库，已刷新订阅列表")
+	}
+	if !nodes {
+		return
+	}
+	fyne.Do(func() {
+		if a.MainWindow != nil && a.MainWindow.nodePageInstance != nil {
+			a.MainWindow.nodePageInstance.Refresh()
+		}
+	})
+}
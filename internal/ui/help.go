@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// helpDefaultLanguage 帮助说明缺少系统语言的译文时使用的语言
+const helpDefaultLanguage = "zh"
+
+// helpPopupWidth 帮助说明弹出层的宽度
+const helpPopupWidth float32 = 320
+
+// helpCatalog 不直观的设置项的简短说明，按主题与语言（ISO 639-1）索引；新增主题时至少提供中文。
+var helpCatalog = map[string]map[string]string{
+	"fragment": {
+		"zh": "把 TLS 握手的第一个包（ClientHello）拆成多段发送，使按 SNI 识别并重置连接的网络设备无法拼出完整的域名。只在握手被重置时开启，会略微增加建连耗时。",
+		"en": "Splits the first TLS packet (ClientHello) into several pieces so middleboxes that reset connections by SNI cannot read the full domain. Enable only when handshakes are being reset; it adds a little connection latency.",
+	},
+	"policy": {
+		"zh": "握手：建立连接后等待首个数据的时间。空闲：连接无数据多久后断开，调短可让失效连接更快释放。仅上行/仅下行：一端关闭后另一方向继续保持的时间。",
+		"en": "Handshake: time to wait for the first data after connecting. Idle: how long a silent connection is kept; shorten it to release stale connections sooner. Uplink/downlink only: how long one direction stays open after the other side closes.",
+	},
+	"balancer": {
+		"zh": "由 xray 在隧道内定时探测组内节点，按策略自动选用：leastPing 选延迟最低的，leastLoad 选延迟最稳定的。节点失效时自动切换，无需手动更换。",
+		"en": "xray probes the nodes in the group through the tunnel and picks one by strategy: leastPing chooses the lowest latency, leastLoad the most stable. Fails over automatically when a node stops working.",
+	},
+	"prewarm": {
+		"zh": "为最常访问的站点预先建立经节点的连接，打开这些站点时省去与节点握手的时间。预连接不发送数据，也不计入访问记录。",
+		"en": "Keeps a ready connection through the node to your most visited sites, saving the node handshake when you open them. Pre-connections send no data and are not counted in access records.",
+	},
+	"finalOutbound": {
+		"zh": "没有命中任何直连或分流规则的流量如何处理：代理（默认）、直连或阻止。选「阻止」时未列入规则的站点都无法访问。",
+		"en": "What happens to traffic that matches no direct or routing rule: proxy (default), direct, or block. With block, any site not covered by a rule is unreachable.",
+	},
+	"latencyMethod": {
+		"zh": "TCP 连接只测到服务器端口的连通耗时；加上 TLS 握手后，Trojan、TLS VMess 等节点的结果更接近实际打开网页时的等待。",
+		"en": "TCP connect measures only reaching the server port; adding the TLS handshake gives results closer to real page loads for Trojan and TLS VMess nodes.",
+	},
+	"bypassCN": {
+		"zh": "局域网地址与中国大陆的域名、IP 直连，不经节点。依赖 geoip.dat 与 geosite.dat，缺少文件时自动跳过该规则。",
+		"en": "LAN addresses and mainland China domains and IPs connect directly without the node. Requires geoip.dat and geosite.dat; the rule is skipped if they are missing.",
+	},
+}
+
+// helpText 返回主题的说明：优先使用系统语言，没有对应译文时使用中文。
+func helpText(topic string) string {
+	texts := helpCatalog[topic]
+	language, _, _ := strings.Cut(strings.ToLower(lang.SystemLocale().LanguageString()), "-")
+	if text, ok := texts[language]; ok {
+		return text
+	}
+	return texts[helpDefaultLanguage]
+}
+
+// withHelp 在设置控件后紧跟一个「?」帮助图标。
+func withHelp(obj fyne.CanvasObject, topic string) fyne.CanvasObject {
+	return container.NewHBox(obj, newHelpIcon(topic))
+}
+
+// helpIcon 设置项旁的「?」图标：鼠标悬停时在图标下方显示说明，点击时切换显示（便于触屏与键盘用户）。
+// Fyne 没有原生悬停提示，说明以弹出层显示。
+type helpIcon struct {
+	widget.BaseWidget
+	text  string
+	popup *widget.PopUp
+}
+
+func newHelpIcon(topic string) *helpIcon {
+	h := &helpIcon{text: helpText(topic)}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *helpIcon) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewCenter(widget.NewIcon(theme.QuestionIcon())))
+}
+
+func (h *helpIcon) MinSize() fyne.Size {
+	s := theme.IconInlineSize() + theme.Padding()
+	return fyne.NewSize(s, s)
+}
+
+func (h *helpIcon) MouseIn(_ *desktop.MouseEvent) {
+	h.show()
+}
+
+func (h *helpIcon) MouseMoved(_ *desktop.MouseEvent) {}
+
+func (h *helpIcon) MouseOut() {
+	h.hide()
+}
+
+func (h *helpIcon) Tapped(_ *fyne.PointEvent) {
+	if h.popup != nil && h.popup.Visible() {
+		h.hide()
+		return
+	}
+	h.show()
+}
+
+// show 在图标下方显示说明。
+func (h *helpIcon) show() {
+	if h.text == "" {
+		return
+	}
+	c := fyne.CurrentApp().Driver().CanvasForObject(h)
+	if c == nil {
+		return
+	}
+	if h.popup == nil {
+		label := widget.NewLabel(h.text)
+		label.Wrapping = fyne.TextWrapWord
+		// 换行标签的高度取决于宽度，先按弹出层内容宽度排版再量高度
+		label.Resize(fyne.NewSize(helpPopupWidth, label.MinSize().Height))
+		h.popup = widget.NewPopUp(label, c)
+		h.popup.Resize(fyne.NewSize(helpPopupWidth, label.MinSize().Height).AddWidthHeight(theme.InnerPadding(), theme.InnerPadding()))
+	}
+	// 弹出层超出窗口右边缘时会自动左移
+	h.popup.ShowAtPosition(fyne.CurrentApp().Driver().AbsolutePositionForObject(h).AddXY(0, h.Size().Height))
+}
+
+func (h *helpIcon) hide() {
+	if h.popup != nil {
+		h.popup.Hide()
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
 )
 
 // innerPadding 返回当前主题下的内边距（theme.SizeNameInnerPadding），供 newPaddedWithSize、compactVBoxLayout 使用。
@@ -14,6 +16,47 @@ func innerPadding(appState *AppState) float32 {
 	return theme.DefaultTheme().Size(theme.SizeNameInnerPadding)
 }
 
+// listDensity 返回节点与订阅列表的密度配置值。
+func listDensity(appState *AppState) string {
+	if appState != nil && appState.ConfigService != nil {
+		return appState.ConfigService.GetListDensity()
+	}
+	return service.ListDensityComfortable
+}
+
+// listRowMetrics 返回列表密度对应的行高与行内留白：宽松 52px、标准 40px、紧凑 32px；
+// 开启大号控件时行高按 largeControlsScale 放大。
+func listRowMetrics(appState *AppState) (rowHeight, padding float32) {
+	pad := innerPadding(appState)
+	switch listDensity(appState) {
+	case service.ListDensityStandard:
+		rowHeight, padding = 40, pad/2
+	case service.ListDensityCompact:
+		rowHeight, padding = 32, 0
+	default:
+		rowHeight, padding = 52, pad
+	}
+	if largeControls(appState) {
+		rowHeight *= largeControlsScale
+	}
+	return rowHeight, padding
+}
+
+// largeControls 是否开启辅助功能「大号控件」。
+func largeControls(appState *AppState) bool {
+	return appState != nil && appState.ConfigService != nil && appState.ConfigService.GetLargeControls()
+}
+
+// newIconButton 创建图标按钮。name 为按钮的可读名称（如「返回」「删除」）：开启大号控件时显示在图标旁，
+// 默认只显示图标。Fyne 目前没有读屏接口，名称以按钮文字的形式提供，不要再创建无名称的纯图标按钮。
+func newIconButton(appState *AppState, name string, icon fyne.Resource, tapped func()) *widget.Button {
+	label := ""
+	if largeControls(appState) {
+		label = name
+	}
+	return widget.NewButtonWithIcon(label, icon, tapped)
+}
+
 type uniformPadLayout struct {
 	padding float32
 }
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/store"
+)
+
+// logWindowDefaultSize 首次弹出日志窗口时的大小
+var logWindowDefaultSize = fyne.NewSize(720, 480)
+
+// BuildEmbedded 构建设置页「日志」内容区：面板未弹出时嵌入完整面板并提供「在新窗口中打开」，
+// 已弹出到独立窗口时显示提示与「收回」按钮。每次切换到日志菜单时调用。
+func (lp *LogsPanel) BuildEmbedded() fyne.CanvasObject {
+	lp.embedHost = container.NewStack()
+	lp.refreshEmbedded()
+	return lp.embedHost
+}
+
+// refreshEmbedded 按是否已弹出切换设置页内的日志内容。
+func (lp *LogsPanel) refreshEmbedded() {
+	if lp.embedHost == nil {
+		return
+	}
+	var content fyne.CanvasObject
+	if lp.window != nil {
+		hint := widget.NewLabel("日志已在独立窗口中显示")
+		hint.Alignment = fyne.TextAlignCenter
+		showBtn := widget.NewButtonWithIcon("显示窗口", theme.VisibilityIcon(), func() {
+			if lp.window != nil {
+				lp.window.Show()
+				lp.window.RequestFocus()
+			}
+		})
+		attachBtn := widget.NewButtonWithIcon("收回到设置页", theme.ContentUndoIcon(), lp.Attach)
+		content = container.NewCenter(container.NewVBox(hint, container.NewHBox(layout.NewSpacer(), showBtn, attachBtn, layout.NewSpacer())))
+	} else {
+		detachBtn := widget.NewButtonWithIcon("在新窗口中打开", theme.ViewFullScreenIcon(), lp.Detach)
+		detachBtn.Importance = widget.LowImportance
+		content = container.NewBorder(container.NewHBox(layout.NewSpacer(), detachBtn), nil, nil, nil, lp.Build())
+	}
+	lp.embedHost.Objects = []fyne.CanvasObject{content}
+	lp.embedHost.Refresh()
+}
+
+// Detach 将日志面板弹出到独立窗口，切换页面时仍可查看；窗口位置与大小保存在布局配置中。
+func (lp *LogsPanel) Detach() {
+	if lp.window != nil {
+		lp.window.Show()
+		lp.window.RequestFocus()
+		return
+	}
+	if lp.appState == nil || lp.appState.App == nil {
+		return
+	}
+	w := lp.appState.App.NewWindow("myproxy - 日志")
+	// 先把设置页内的面板换成提示，再把面板放进新窗口，避免同一组件同时出现在两个容器中
+	lp.window = w
+	lp.refreshEmbedded()
+	w.SetContent(lp.Build())
+	geometry := lp.savedWindowGeometry()
+	size := logWindowDefaultSize
+	if geometry != nil && geometry.Width > 0 && geometry.Height > 0 {
+		size = fyne.NewSize(geometry.Width, geometry.Height)
+	}
+	w.Resize(size)
+	w.SetCloseIntercept(lp.Attach)
+	w.Show()
+	if geometry != nil && geometry.HasPosition {
+		fyne.Do(func() {
+			if lp.window == w {
+				setWindowPosition(w, geometry.X, geometry.Y)
+			}
+		})
+	}
+	lp.refreshDisplay()
+}
+
+// Attach 关闭独立日志窗口（保存位置与大小），将面板放回设置页。
+func (lp *LogsPanel) Attach() {
+	if lp.window == nil {
+		return
+	}
+	w := lp.window
+	lp.saveWindowGeometry(w)
+	lp.window = nil
+	w.SetCloseIntercept(nil)
+	w.Close()
+	lp.refreshEmbedded()
+	lp.refreshDisplay()
+}
+
+func (lp *LogsPanel) layoutStore() *store.LayoutStore {
+	if lp.appState == nil || lp.appState.Store == nil {
+		return nil
+	}
+	return lp.appState.Store.Layout
+}
+
+func (lp *LogsPanel) savedWindowGeometry() *store.WindowGeometry {
+	if ls := lp.layoutStore(); ls != nil {
+		return ls.LogWindowGeometry()
+	}
+	return nil
+}
+
+// saveWindowGeometry 记录窗口大小，以及当前平台支持读取时的窗口位置。
+func (lp *LogsPanel) saveWindowGeometry(w fyne.Window) {
+	ls := lp.layoutStore()
+	if ls == nil {
+		return
+	}
+	size := w.Canvas().Size()
+	g := store.WindowGeometry{Width: size.Width, Height: size.Height}
+	g.X, g.Y, g.HasPosition = windowPosition(w)
+	if !g.HasPosition {
+		// 不支持读取位置时保留上次记录的位置
+		if old := ls.LogWindowGeometry(); old != nil {
+			g.X, g.Y, g.HasPosition = old.X, old.Y, old.HasPosition
+		}
+	}
+	if err := ls.SaveLogWindowGeometry(g); err != nil {
+		lp.appState.AppendLog("WARN", "app", "保存日志窗口位置失败: "+err.Error())
+	}
+}
@@ -52,6 +52,10 @@ type LogsPanel struct {
 	logScroll      *container.Scroll  // 日志滚动容器
 	panelContainer fyne.CanvasObject  // 面板容器
 
+	// 独立窗口（见 log_window.go）：弹出后面板显示在 window 中，设置页 embedHost 显示提示
+	window    fyne.Window
+	embedHost *fyne.Container
+
 	// 防抖刷新
 	refreshTimer  *time.Timer
 	refreshTimerMu sync.Mutex
@@ -249,6 +253,12 @@ func (lp *LogsPanel) AppendLogLine(logLine string) {
 	if lp.appState != nil && lp.appState.AccessRecordService != nil {
 		lp.appState.AccessRecordService.RecordAccessFromLogLine(logLine)
 	}
+	if lp.appState != nil && lp.appState.ProcessStatsService != nil {
+		lp.appState.ProcessStatsService.RecordFromLogLine(logLine)
+	}
+	if lp.appState != nil && lp.appState.ConnErrorStats != nil {
+		lp.appState.ConnErrorStats.RecordFromLogLine(logLine)
+	}
 
 	// 解析日志行
 	entry := lp.parseLogLine(logLine)
@@ -292,7 +302,8 @@ func (lp *LogsPanel) parseLogLine(line string) *LogEntry {
 	var message string
 	var timestamp time.Time
 
-	if typeStart != -1 && typeStart < 50 { // 第二个 [ 应该很快出现（应用日志格式）
+	// 第二个 [ 应该很快出现（应用日志格式）；xray 时间戳以 / 分隔，其后的 [数字] 是会话 ID 而不是类型
+	if typeStart != -1 && typeStart < 50 && !strings.Contains(timestampStr, "/") {
 		typeStart += levelEnd + 1
 		typeEnd := strings.Index(line[typeStart:], "]")
 		if typeEnd != -1 {
@@ -341,6 +352,8 @@ func (lp *LogsPanel) parseLogLine(line string) *LogEntry {
 	// 标准化级别名称（全大写），来源小写
 	level = strings.ToUpper(level)
 	switch level {
+	case "WARNING": // xray 的 [Warning]
+		level = "WARN"
 	case "DEBUG", "INFO", "WARN", "ERROR", "FATAL":
 	default:
 		level = "INFO"
@@ -583,4 +596,7 @@ func (lp *LogsPanel) Stop() {
 	if lp.fileWatcher != nil {
 		lp.fileWatcher.Close()
 	}
+	if lp.window != nil {
+		lp.saveWindowGeometry(lp.window)
+	}
 }
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// 主菜单快捷键：KeyModifierShortcutDefault 在 macOS 为 Cmd，其他平台为 Ctrl。
+var (
+	shortcutSettings    = &desktop.CustomShortcut{KeyName: fyne.KeyComma, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutAddSub      = &desktop.CustomShortcut{KeyName: fyne.KeyN, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutRefreshSubs = &desktop.CustomShortcut{KeyName: fyne.KeyR, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutToggleProxy = &desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutBack        = &desktop.CustomShortcut{KeyName: fyne.KeyLeftBracket, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutHomePage    = &desktop.CustomShortcut{KeyName: fyne.Key1, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutNodePage    = &desktop.CustomShortcut{KeyName: fyne.Key2, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutSubsPage    = &desktop.CustomShortcut{KeyName: fyne.Key3, Modifier: fyne.KeyModifierShortcutDefault}
+	shortcutLogWindow   = &desktop.CustomShortcut{KeyName: fyne.KeyL, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}
+)
+
+// AppMenu 应用主菜单（文件 / 视图 / 代理 / 帮助）及快捷键。
+// macOS 显示在系统菜单栏，其他平台显示在主窗口顶部；快捷键由 Fyne 按菜单项的 Shortcut 分发。
+type AppMenu struct {
+	appState *AppState
+	main     *fyne.MainMenu
+
+	toggleProxyItem *fyne.MenuItem // 连接 / 断开，文字随代理状态变化
+	proxyModeItems  [2]*fyne.MenuItem
+	pinItem         *fyne.MenuItem
+	miniItem        *fyne.MenuItem
+}
+
+// NewAppMenu 创建主菜单（未设置到窗口）。
+func NewAppMenu(appState *AppState) *AppMenu {
+	m := &AppMenu{appState: appState}
+	m.build()
+	return m
+}
+
+// newShortcutItem 创建带快捷键的菜单项。
+func newShortcutItem(label string, shortcut fyne.Shortcut, action func()) *fyne.MenuItem {
+	item := fyne.NewMenuItem(label, action)
+	item.Shortcut = shortcut
+	return item
+}
+
+func (m *AppMenu) build() {
+	a := m.appState
+
+	settingsItem := newShortcutItem("设置…", shortcutSettings, func() { m.withMainWindow((*MainWindow).ShowSettingsPage) })
+	quitItem := fyne.NewMenuItem("退出", func() {
+		if a.TrayManager != nil {
+			a.TrayManager.quit()
+		}
+	})
+	quitItem.IsQuit = true
+	fileMenu := fyne.NewMenu("文件",
+		newShortcutItem("新增订阅…", shortcutAddSub, func() {
+			m.withSubscriptionPage((*SubscriptionPage).showAddSubscriptionDialog)
+		}),
+		fyne.NewMenuItem("从其他客户端导入…", func() {
+			m.withSubscriptionPage(func(sp *SubscriptionPage) { showClientImportDialog(a, sp.Refresh) })
+		}),
+		fyne.NewMenuItem("分享配置…", func() { m.withMainWindow(func(*MainWindow) { showShareProfileDialog(a) }) }),
+		fyne.NewMenuItemSeparator(),
+		settingsItem,
+		fyne.NewMenuItemSeparator(),
+		quitItem,
+	)
+
+	m.pinItem = fyne.NewMenuItem("窗口置顶", func() {
+		if a.ConfigService == nil {
+			return
+		}
+		if err := a.SetWindowAlwaysOnTop(!a.ConfigService.GetWindowAlwaysOnTop()); err != nil {
+			a.AppendLog("WARN", "app", err.Error())
+		}
+	})
+	m.miniItem = fyne.NewMenuItem("悬浮窗", func() { a.SetMiniWindowVisible(a.MiniWindow == nil) })
+	viewMenu := fyne.NewMenu("视图",
+		newShortcutItem("主界面", shortcutHomePage, func() { m.withMainWindow((*MainWindow).ShowHomePage) }),
+		newShortcutItem("节点列表", shortcutNodePage, func() { m.withMainWindow((*MainWindow).ShowNodePage) }),
+		newShortcutItem("订阅管理", shortcutSubsPage, func() { m.withMainWindow((*MainWindow).ShowSubscriptionPage) }),
+		fyne.NewMenuItem("服务商概览", func() { m.withMainWindow((*MainWindow).ShowProvidersPage) }),
+		fyne.NewMenuItem("使用报告", func() { m.withMainWindow((*MainWindow).ShowUsagePage) }),
+		newShortcutItem("返回", shortcutBack, func() { m.withMainWindow((*MainWindow).Back) }),
+		fyne.NewMenuItem("锁定", func() {
+			if !a.appLockEnabled() {
+				m.showSettingsMenu(SettingsMenuAppearance)
+				return
+			}
+			a.LockApp()
+		}),
+		fyne.NewMenuItemSeparator(),
+		newShortcutItem("日志窗口", shortcutLogWindow, func() {
+			m.withMainWindow(func(*MainWindow) {
+				if a.LogsPanel != nil {
+					a.LogsPanel.Detach()
+				}
+			})
+		}),
+		m.miniItem,
+		m.pinItem,
+	)
+
+	m.toggleProxyItem = newShortcutItem("连接", shortcutToggleProxy, func() { m.withMainWindow((*MainWindow).onToggleProxy) })
+	m.proxyModeItems[0] = fyne.NewMenuItem(SystemProxyModeAuto.ShortString(), func() {
+		m.withMainWindow(func(mw *MainWindow) { _ = mw.SetSystemProxyMode(SystemProxyModeAuto) })
+	})
+	m.proxyModeItems[1] = fyne.NewMenuItem(SystemProxyModeClear.ShortString(), func() {
+		m.withMainWindow(func(mw *MainWindow) { _ = mw.SetSystemProxyMode(SystemProxyModeClear) })
+	})
+	proxyMenu := fyne.NewMenu("代理",
+		m.toggleProxyItem,
+		fyne.NewMenuItemSeparator(),
+		m.proxyModeItems[0],
+		m.proxyModeItems[1],
+		fyne.NewMenuItemSeparator(),
+		newShortcutItem("更新全部订阅", shortcutRefreshSubs, func() {
+			m.withSubscriptionPage((*SubscriptionPage).batchUpdateSubscriptions)
+		}),
+	)
+
+	helpMenu := fyne.NewMenu("帮助",
+		fyne.NewMenuItem("网络诊断", func() { m.showSettingsMenu(SettingsMenuNetwork) }),
+		fyne.NewMenuItem("关于 myproxy", func() { m.showSettingsMenu(SettingsMenuAbout) }),
+	)
+
+	m.main = fyne.NewMainMenu(fileMenu, viewMenu, proxyMenu, helpMenu)
+	m.updateItems()
+}
+
+// withMainWindow 主窗口已创建时执行 fn，并显示窗口（从托盘隐藏后用快捷键也能回到窗口）；
+// 应用锁定时只显示锁屏，不执行 fn。
+func (m *AppMenu) withMainWindow(fn func(mw *MainWindow)) {
+	if m.appState == nil || m.appState.MainWindow == nil {
+		return
+	}
+	if m.appState.Window != nil {
+		m.appState.Window.Show()
+	}
+	if m.appState.appLock.locked {
+		return
+	}
+	fn(m.appState.MainWindow)
+}
+
+// withSubscriptionPage 切换到订阅管理页后对页面执行 fn。
+func (m *AppMenu) withSubscriptionPage(fn func(sp *SubscriptionPage)) {
+	m.withMainWindow(func(mw *MainWindow) {
+		mw.ShowSubscriptionPage()
+		if mw.subscriptionPageInstance != nil {
+			fn(mw.subscriptionPageInstance)
+		}
+	})
+}
+
+// showSettingsMenu 打开设置页的指定菜单；已在设置页时切换菜单并记入导航历史。
+func (m *AppMenu) showSettingsMenu(menu SettingsMenu) {
+	m.withMainWindow(func(mw *MainWindow) {
+		if mw.currentPage == PageTypeSettings && mw.settingsPageInstance != nil {
+			mw.settingsPageInstance.openMenu(menu)
+			return
+		}
+		mw.ShowSettingsPage()
+		if mw.settingsPageInstance != nil {
+			mw.settingsPageInstance.showMenu(menu)
+		}
+	})
+}
+
+// Setup 将主菜单设置到主窗口，并在代理状态、系统代理模式变化时更新菜单项。
+func (m *AppMenu) Setup() {
+	a := m.appState
+	if a == nil || a.Window == nil {
+		return
+	}
+	a.Window.SetMainMenu(m.main)
+	if a.Store != nil && a.Store.ProxyStatus != nil {
+		listener := binding.NewDataListener(m.Refresh)
+		a.Store.ProxyStatus.ProxyStatusBinding.AddListener(listener)
+		a.Store.ProxyStatus.ModeBinding.AddListener(listener)
+	}
+}
+
+// updateItems 按当前状态更新菜单文字与勾选，返回是否有变化。
+func (m *AppMenu) updateItems() bool {
+	a := m.appState
+	changed := false
+	set := func(item *fyne.MenuItem, label string, checked bool) {
+		if item.Label != label || item.Checked != checked {
+			item.Label, item.Checked = label, checked
+			changed = true
+		}
+	}
+	toggleLabel := "连接"
+	if a.IsProxyRunning() {
+		toggleLabel = "断开"
+	}
+	set(m.toggleProxyItem, toggleLabel, false)
+	mode := getSystemProxyModeFromAppState(a)
+	if a.Store != nil && a.Store.ProxyStatus != nil {
+		if s, _ := a.Store.ProxyStatus.ModeBinding.Get(); s != "" {
+			mode = ParseSystemProxyMode(s)
+		}
+	}
+	set(m.proxyModeItems[0], m.proxyModeItems[0].Label, mode == SystemProxyModeAuto)
+	set(m.proxyModeItems[1], m.proxyModeItems[1].Label, mode == SystemProxyModeClear)
+	set(m.pinItem, m.pinItem.Label, a.ConfigService != nil && a.ConfigService.GetWindowAlwaysOnTop())
+	set(m.miniItem, m.miniItem.Label, a.MiniWindow != nil)
+	return changed
+}
+
+// Refresh 状态变化后更新菜单项（置顶、悬浮窗切换或代理状态变化时调用）。
+func (m *AppMenu) Refresh() {
+	if m == nil || m.main == nil {
+		return
+	}
+	if m.updateItems() {
+		m.main.Refresh()
+	}
+}
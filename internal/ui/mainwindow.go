@@ -7,13 +7,13 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/logging"
-	"myproxy.com/p/internal/service"
 	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/systemproxy"
 )
@@ -156,11 +156,39 @@ const (
 	PageTypeNode                         // 节点列表页面
 	PageTypeSettings                     // 设置页面
 	PageTypeSubscription                 // 订阅管理页面
+	PageTypeProviders                    // 服务商概览页面
+	PageTypeUsage                        // 使用报告页面
 )
 
+// pageTypeKeys 页面类型在布局配置中保存的名称
+var pageTypeKeys = map[PageType]string{
+	PageTypeHome:         store.LayoutPageHome,
+	PageTypeNode:         store.LayoutPageNode,
+	PageTypeSettings:     store.LayoutPageSettings,
+	PageTypeSubscription: store.LayoutPageSubscription,
+	PageTypeProviders:    store.LayoutPageProviders,
+	PageTypeUsage:        store.LayoutPageUsage,
+}
+
+// parsePageType 由布局配置中保存的名称解析页面类型。
+func parsePageType(key string) (PageType, bool) {
+	for p, k := range pageTypeKeys {
+		if k == key {
+			return p, true
+		}
+	}
+	return PageTypeHome, false
+}
+
+// NavEntry 导航历史中的一项：页面及其页内位置（设置页记录所在菜单），返回时据此还原。
+type NavEntry struct {
+	Page PageType
+	Menu SettingsMenu // 仅 Page 为 PageTypeSettings 时有效
+}
+
 // PageStack 路由栈结构，用于管理页面导航历史
 type PageStack struct {
-	stack    []PageType // 页面栈
+	stack    []NavEntry // 导航历史，栈顶为最近一项
 	maxDepth int        // 最大深度限制（0 表示无限制）
 }
 
@@ -172,42 +200,50 @@ const (
 // NewPageStack 创建新的路由栈
 func NewPageStack() *PageStack {
 	return &PageStack{
-		stack:    make([]PageType, 0),
+		stack:    make([]NavEntry, 0),
 		maxDepth: DefaultMaxStackDepth,
 	}
 }
 
-// Push 将页面压入栈中
+// Push 将导航项压入栈中，与栈顶相同时不重复压入
 // 如果栈已满（达到最大深度），会移除最旧的页面（FIFO）
-func (ps *PageStack) Push(pageType PageType) {
+func (ps *PageStack) Push(entry NavEntry) {
+	if n := len(ps.stack); n > 0 && ps.stack[n-1] == entry {
+		return
+	}
 	// 如果设置了最大深度限制，且栈已满，移除最旧的页面
 	if ps.maxDepth > 0 && len(ps.stack) >= ps.maxDepth {
 		ps.stack = ps.stack[1:]
 	}
-	ps.stack = append(ps.stack, pageType)
+	ps.stack = append(ps.stack, entry)
 }
 
-// Pop 从栈中弹出页面
-// 返回值：页面类型和是否成功弹出（栈为空时返回 false）
-func (ps *PageStack) Pop() (PageType, bool) {
+// Pop 从栈中弹出导航项
+// 返回值：导航项和是否成功弹出（栈为空时返回主界面和 false）
+func (ps *PageStack) Pop() (NavEntry, bool) {
 	if len(ps.stack) == 0 {
-		return PageTypeHome, false
+		return NavEntry{Page: PageTypeHome}, false
 	}
 	lastIndex := len(ps.stack) - 1
-	pageType := ps.stack[lastIndex]
+	entry := ps.stack[lastIndex]
 	ps.stack = ps.stack[:lastIndex]
-	return pageType, true
+	return entry, true
 }
 
-// Peek 查看栈顶页面但不弹出
-// 返回值：页面类型和是否存在（栈为空时返回 false）
-func (ps *PageStack) Peek() (PageType, bool) {
+// Peek 查看栈顶导航项但不弹出
+// 返回值：导航项和是否存在（栈为空时返回主界面和 false）
+func (ps *PageStack) Peek() (NavEntry, bool) {
 	if len(ps.stack) == 0 {
-		return PageTypeHome, false
+		return NavEntry{Page: PageTypeHome}, false
 	}
 	return ps.stack[len(ps.stack)-1], true
 }
 
+// History 返回导航历史的副本，从最旧到最近
+func (ps *PageStack) History() []NavEntry {
+	return append([]NavEntry(nil), ps.stack...)
+}
+
 // Size 返回栈中页面的数量
 func (ps *PageStack) Size() int {
 	return len(ps.stack)
@@ -232,17 +268,6 @@ func (ps *PageStack) SetMaxDepth(depth int) {
 	}
 }
 
-// LayoutConfig 存储窗口布局的配置信息，包括各区域的分割比例。
-// 这些配置会持久化到数据库中，以便在应用重启后恢复用户的布局偏好。
-// 注意：此类型已迁移到 store 包，这里保留作为类型别名以便兼容。
-type LayoutConfig = store.LayoutConfig
-
-// DefaultLayoutConfig 返回默认的布局配置。
-// 注意：此函数已迁移到 store 包，这里保留作为便捷函数。
-func DefaultLayoutConfig() *LayoutConfig {
-	return store.DefaultLayoutConfig()
-}
-
 // SystemProxyMode 系统代理模式类型
 type SystemProxyMode int
 
@@ -325,6 +350,9 @@ type MainWindow struct {
 	subscriptionPage         fyne.CanvasObject // 订阅管理页面
 	subscriptionPageInstance *SubscriptionPage // 订阅管理页面实例
 
+	providersPage fyne.CanvasObject // 服务商概览页面，每次进入时重建以汇总最新数据
+	usagePage     fyne.CanvasObject // 使用报告页面，每次进入时重建以汇总最新数据
+
 	homeLogoIcon *widget.Icon // 主页logo图标，用于主题变化时更新
 
 	// 主界面状态UI组件
@@ -333,6 +361,8 @@ type MainWindow struct {
 	proxyModeButtons [2]*widget.Button        // 系统代理模式按钮组（清除、系统）
 	systemProxy      *systemproxy.SystemProxy // 系统代理管理器
 	trafficChart     *TrafficChart            // 实时流量图组件
+	trafficTotals    fyne.CanvasObject        // 累计流量行，随流量图缓存，避免重复注册绑定监听
+	sessionInfo      fyne.CanvasObject        // 运行时长与出口 IP 行，同样缓存以免重复注册绑定监听
 
 	// 状态标志
 	systemProxyRestored bool // 标记系统代理状态是否已恢复（避免重复恢复）
@@ -398,15 +428,60 @@ func (mw *MainWindow) Refresh() {
 	}
 }
 
-// SaveLayoutConfig 保存当前的布局配置到 Store。
-// 该方法会在窗口关闭时自动调用，以保存用户的布局偏好。
+// SaveLayoutConfig 保存当前的布局状态到 LayoutStore。
+// 该方法会在退出应用时调用；列宽、展开的分组等在各页面变化时已即时保存。
 func (mw *MainWindow) SaveLayoutConfig() {
-	if mw.appState == nil || mw.appState.Store == nil || mw.appState.Store.Layout == nil {
+	mw.saveLastView()
+}
+
+// layoutStore 返回布局存储，未初始化时返回 nil。
+func (mw *MainWindow) layoutStore() *store.LayoutStore {
+	if mw.appState == nil || mw.appState.Store == nil {
+		return nil
+	}
+	return mw.appState.Store.Layout
+}
+
+// lastView 返回当前页面名称与节点列表滚动位置；节点列表尚未显示或待恢复时沿用已保存的位置。
+func (mw *MainWindow) lastView() (string, float32) {
+	var offset float32
+	if ls := mw.layoutStore(); ls != nil {
+		_, offset = ls.LastView()
+	}
+	if np := mw.nodePageInstance; np != nil && np.list != nil && np.pendingOffset == 0 && np.list.Size().Height > 0 {
+		offset = np.list.GetScrollOffset()
+	}
+	return pageTypeKeys[mw.currentPage], offset
+}
+
+// saveLastView 保存当前页面与节点列表滚动位置，下次启动时恢复。
+func (mw *MainWindow) saveLastView() {
+	ls := mw.layoutStore()
+	if ls == nil {
 		return
 	}
+	page, offset := mw.lastView()
+	if err := ls.SaveLastView(page, offset); err != nil {
+		mw.appState.AppendLog("WARN", "app", "保存当前页面失败: "+err.Error())
+	}
+}
 
-	config := mw.GetLayoutConfig()
-	_ = mw.appState.Store.Layout.Save(config)
+// RestoreLastPage 启动时回到上次退出时所在的页面（主界面保留在返回栈中）。
+func (mw *MainWindow) RestoreLastPage() {
+	ls := mw.layoutStore()
+	if ls == nil {
+		return
+	}
+	lastPage, _ := ls.LastView()
+	page, ok := parsePageType(lastPage)
+	if !ok || page == PageTypeHome {
+		return
+	}
+	// 设置页需要应用锁密码时停在主界面，不在启动时弹出密码框
+	if page == PageTypeSettings && !mw.appState.settingsAccessAllowed() {
+		return
+	}
+	mw.navigateToPage(page, true)
 }
 
 // Cleanup 清理资源（在窗口关闭时调用）
@@ -430,15 +505,6 @@ func (mw *MainWindow) Cleanup() {
 	}
 }
 
-// GetLayoutConfig 返回当前的布局配置。
-// 返回：布局配置实例，如果未初始化则返回默认配置
-func (mw *MainWindow) GetLayoutConfig() *LayoutConfig {
-	if mw.appState != nil && mw.appState.Store != nil && mw.appState.Store.Layout != nil {
-		return mw.appState.Store.Layout.Get()
-	}
-	return DefaultLayoutConfig()
-}
-
 // initPages 初始化单窗口的四个页面：home / node / settings / subscription
 func (mw *MainWindow) initPages() {
 	// 主界面（homePage）：极简状态 + 一键主开关
@@ -469,6 +535,9 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 		// 横向显示，超出可用宽度时截断并显示省略号
 		mw.serverNameLabel.Wrapping = fyne.TextTruncate
 		mw.serverNameLabel.Truncation = fyne.TextTruncateEllipsis
+		if status := mw.proxyStatus(); status != nil {
+			status.ServerNameBinding.AddListener(binding.NewDataListener(mw.updateHomeServerNameLabel))
+		}
 	}
 	mw.updateHomeServerNameLabel()
 	// 创建主开关按钮（圆形，带链接图标）
@@ -477,7 +546,7 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 		buttonSize := mw.calculateButtonSize()
 
 		// 创建圆形按钮（使用连接/断开图标，根据状态变化）
-		if mw.appState != nil && mw.appState.XrayInstance != nil && mw.appState.XrayInstance.IsRunning() {
+		if mw.appState != nil && mw.appState.IsProxyRunning() {
 			mw.mainToggleButton = NewCircularButton(theme.CancelIcon(), mw.onToggleProxy, buttonSize, mw.appState)
 		} else {
 			mw.mainToggleButton = NewCircularButton(theme.ConfirmIcon(), mw.onToggleProxy, buttonSize, mw.appState)
@@ -500,20 +569,25 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 			mw.proxyModeButtons[i].Importance = widget.LowImportance
 		}
 
-		// 从 Store 恢复系统代理模式选择
-		if mw.appState != nil && mw.appState.ConfigService != nil {
-			savedModeStr := mw.appState.ConfigService.GetSystemProxyMode()
-			if savedModeStr != "" {
-				savedMode := ParseSystemProxyMode(savedModeStr)
-				mw.updateProxyModeButtonsState(savedMode)
+		// 按钮选中状态跟随状态绑定中的模式；先从 Store 恢复保存的模式
+		if status := mw.proxyStatus(); status != nil {
+			if mw.appState.ConfigService != nil {
+				if savedModeStr := mw.appState.ConfigService.GetSystemProxyMode(); savedModeStr != "" {
+					status.SetMode(ParseSystemProxyMode(savedModeStr).String())
+				}
 			}
+			status.ModeBinding.AddListener(binding.NewDataListener(func() {
+				if mode, _ := status.ModeBinding.Get(); mode != "" {
+					mw.updateProxyModeButtonsState(ParseSystemProxyMode(mode))
+				}
+			}))
 		}
 	}
 
-	// 恢复系统代理状态（仅在首次创建时，避免重复应用）
+	// 恢复系统代理状态（仅在首次创建时，避免重复应用；安全模式下不改动系统代理）
 	// 注意：按钮状态已在创建按钮时恢复，这里只应用实际的系统代理设置
 	if !mw.systemProxyRestored {
-		if mw.appState != nil && mw.appState.ConfigService != nil {
+		if mw.appState != nil && mw.appState.ConfigService != nil && !mw.appState.SafeMode {
 			savedModeStr := mw.appState.ConfigService.GetSystemProxyMode()
 			if savedModeStr != "" {
 				// 终端代理仅为设置项：历史「环境变量代理」模式写入为「清除系统代理」
@@ -592,10 +666,18 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 	if mw.trafficChart == nil {
 		mw.trafficChart = NewTrafficChart(mw.appState)
 	}
-	trafficArea := newPaddedWithSize(mw.trafficChart, pad)
+	if mw.trafficTotals == nil {
+		mw.trafficTotals = mw.buildTrafficTotalsRow()
+	}
+	if mw.sessionInfo == nil {
+		mw.sessionInfo = mw.buildSessionInfoRow()
+	}
+	trafficArea := newPaddedWithSize(container.NewBorder(mw.sessionInfo, mw.trafficTotals, nil, nil, mw.trafficChart), pad)
 
 	// 整体垂直排版（减少顶部留白，整体往上移动）；此处保留 VBox 以便 Spacer 正确吃掉剩余高度。
+	// 离线时在主开关上方显示提示
 	content := container.NewVBox(
+		newOfflineBanner(mw.appState),
 		mainControlArea,
 		nodeAndMode,
 		layout.NewSpacer(),
@@ -630,6 +712,65 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 	)
 }
 
+// buildTrafficTotalsRow 构建流量图下方的累计流量行（绑定 TrafficService），附清零按钮。
+func (mw *MainWindow) buildTrafficTotalsRow() fyne.CanvasObject {
+	ts := mw.appState.TrafficService
+	if ts == nil {
+		return container.NewVBox()
+	}
+	totalsLabel := widget.NewLabel("")
+	totalsLabel.Importance = widget.LowImportance
+	update := func() {
+		up, _ := ts.TotalUpload.Get()
+		down, _ := ts.TotalDownload.Get()
+		totalsLabel.SetText(fmt.Sprintf("累计 上传 %s / 下载 %s", formatBytes(uint64(up)), formatBytes(uint64(down))))
+	}
+	listener := binding.NewDataListener(update)
+	ts.TotalUpload.AddListener(listener)
+	ts.TotalDownload.AddListener(listener)
+
+	resetBtn := newIconButton(mw.appState, "清零累计流量", theme.ContentClearIcon(), func() {
+		dialog.ShowConfirm("清零累计流量", "清零后重新开始统计累计上传与下载流量，确定吗？", func(ok bool) {
+			if ok {
+				ts.ResetTotals()
+			}
+		}, mw.appState.Window)
+	})
+	resetBtn.Importance = widget.LowImportance
+	return container.NewHBox(totalsLabel, layout.NewSpacer(), resetBtn)
+}
+
+// buildSessionInfoRow 构建流量图上方的运行时长与出口 IP 行（绑定代理状态）。
+func (mw *MainWindow) buildSessionInfoRow() fyne.CanvasObject {
+	status := mw.proxyStatus()
+	if status == nil {
+		return container.NewVBox()
+	}
+	infoLabel := widget.NewLabel("")
+	infoLabel.Importance = widget.LowImportance
+	update := func() {
+		uptime, _ := status.UptimeBinding.Get()
+		exitIP, _ := status.ExitIPBinding.Get()
+		if uptime == "" || uptime == "-" {
+			infoLabel.SetText("未连接")
+			return
+		}
+		infoLabel.SetText(fmt.Sprintf("已运行 %s · 出口 IP %s", uptime, exitIP))
+	}
+	listener := binding.NewDataListener(update)
+	status.UptimeBinding.AddListener(listener)
+	status.ExitIPBinding.AddListener(listener)
+	return infoLabel
+}
+
+// proxyStatus 返回代理状态绑定存储，未初始化时返回 nil。
+func (mw *MainWindow) proxyStatus() *store.ProxyStatusStore {
+	if mw.appState == nil || mw.appState.Store == nil {
+		return nil
+	}
+	return mw.appState.Store.ProxyStatus
+}
+
 // wrapPageWithBackground 为页面内容包裹主题背景色。
 func wrapPageWithBackground(content fyne.CanvasObject, app fyne.App) fyne.CanvasObject {
 	if content == nil {
@@ -642,7 +783,8 @@ func wrapPageWithBackground(content fyne.CanvasObject, app fyne.App) fyne.Canvas
 	return container.NewStack(bgRect, content)
 }
 
-// setWrappedWindowContent 切换窗口内容并保持当前用户调整后的窗口尺寸（各页面统一，不随内容最小尺寸回退到配置里的旧值）。
+// setWrappedWindowContent 切换窗口内容并保持当前用户调整后的窗口尺寸（各页面统一，不随内容最小尺寸回退到配置里的旧值）；
+// 当前尺寸小于新页面的最小尺寸时放大到最小尺寸。
 func (mw *MainWindow) setWrappedWindowContent(pageContent fyne.CanvasObject) {
 	if mw == nil || mw.appState == nil || mw.appState.Window == nil {
 		return
@@ -653,7 +795,15 @@ func (mw *MainWindow) setWrappedWindowContent(pageContent fyne.CanvasObject) {
 	if cur.Width < 200 || cur.Height < 200 {
 		cur = mw.appState.LoadWindowSize(defaultSize)
 	}
-	w.SetContent(mw.appState.wrapWithWindowSizePersistence(wrapPageWithBackground(pageContent, mw.appState.App)))
+	minSize := pageMinSize(mw.currentPage)
+	content := mw.appState.wrapWithWindowSizePersistence(wrapPageWithBackground(pageContent, mw.appState.App), minSize)
+	// 锁定期间只替换锁屏后的内容，解锁时显示
+	if mw.appState.appLock.locked {
+		mw.appState.appLock.hidden = content
+		return
+	}
+	cur = cur.Max(minSize)
+	w.SetContent(content)
 	w.Resize(cur)
 	mw.appState.SaveWindowSize(cur)
 }
@@ -664,33 +814,74 @@ func (mw *MainWindow) showPage(pageType PageType, pageContent fyne.CanvasObject,
 		return
 	}
 
+	// 离开节点列表前记下滚动位置
+	if mw.currentPage == PageTypeNode && pageType != PageTypeNode {
+		mw.saveLastView()
+	}
+
 	// 如果需要压入当前页面（通常从其他页面跳转时需要）
 	if pushCurrent && mw.currentPage != pageType {
-		mw.pageStack.Push(mw.currentPage)
+		mw.pageStack.Push(mw.currentEntry())
 	}
 
 	// 更新当前页面类型
 	mw.currentPage = pageType
 
 	mw.setWrappedWindowContent(pageContent)
+	mw.saveLastView()
 }
 
-// Back 返回到上一个页面（从路由栈中弹出）
+// currentEntry 返回当前页面对应的导航项（设置页附带当前菜单）。
+func (mw *MainWindow) currentEntry() NavEntry {
+	entry := NavEntry{Page: mw.currentPage}
+	if mw.currentPage == PageTypeSettings && mw.settingsPageInstance != nil {
+		entry.Menu = mw.settingsPageInstance.currentMenu
+	}
+	return entry
+}
+
+// pushSettingsMenu 设置页内切换菜单前记录切换前的菜单，使「返回」先回到该菜单。
+func (mw *MainWindow) pushSettingsMenu(menu SettingsMenu) {
+	if mw == nil || mw.currentPage != PageTypeSettings {
+		return
+	}
+	mw.pageStack.Push(NavEntry{Page: PageTypeSettings, Menu: menu})
+}
+
+// History 返回导航历史（从最旧到最近，不含当前页面）。
+func (mw *MainWindow) History() []NavEntry {
+	if mw == nil || mw.pageStack == nil {
+		return nil
+	}
+	return mw.pageStack.History()
+}
+
+// Back 返回到上一个导航项（从路由栈中弹出）：可能是另一个页面，也可能是设置页内之前的菜单，
+// 如 设置→日志→返回 先回到进入日志前的菜单，再返回才离开设置页。
 func (mw *MainWindow) Back() {
 	if mw == nil || mw.appState == nil || mw.appState.Window == nil {
 		return
 	}
 
-	// 从栈中弹出上一个页面
-	prevPageType, ok := mw.pageStack.Pop()
+	// 从栈中弹出上一个导航项
+	prev, ok := mw.pageStack.Pop()
 	if !ok {
 		// 如果栈为空，默认返回主界面（不压栈）
 		mw.navigateToPage(PageTypeHome, false)
 		return
 	}
 
-	// 切换到上一个页面（不压栈，因为这是返回操作）
-	mw.navigateToPage(prevPageType, false)
+	// 同在设置页内：只切回之前的菜单
+	if prev.Page == PageTypeSettings && mw.currentPage == PageTypeSettings && mw.settingsPageInstance != nil {
+		mw.settingsPageInstance.showMenu(prev.Menu)
+		return
+	}
+
+	// 切换到上一个页面（不压栈，因为这是返回操作），设置页还原离开时的菜单
+	mw.navigateToPage(prev.Page, false)
+	if prev.Page == PageTypeSettings && mw.settingsPageInstance != nil && mw.settingsPageInstance.currentMenu != prev.Menu {
+		mw.settingsPageInstance.showMenu(prev.Menu)
+	}
 }
 
 // navigateToPage 导航到指定页面（内部方法，不压栈）
@@ -707,7 +898,6 @@ func (mw *MainWindow) navigateToPage(pageType PageType, pushCurrent bool) {
 		if mw.appState != nil {
 			mw.appState.UpdateProxyStatus() // 更新绑定数据（serverNameLabel 会自动更新）
 		}
-		mw.updateHomeServerNameLabel()
 		pageContent = mw.homePage
 	case PageTypeNode:
 		if mw.nodePage == nil {
@@ -723,6 +913,10 @@ func (mw *MainWindow) navigateToPage(pageType PageType, pushCurrent bool) {
 		}
 		pageContent = mw.nodePage
 	case PageTypeSettings:
+		if !mw.appState.settingsAccessAllowed() {
+			mw.appState.promptAppLockPassword("进入设置", func() { mw.navigateToPage(PageTypeSettings, pushCurrent) })
+			return
+		}
 		if mw.settingsPage == nil {
 			mw.settingsPageInstance = NewSettingsPage(mw.appState)
 			mw.settingsPage = mw.settingsPageInstance.Build()
@@ -738,6 +932,12 @@ func (mw *MainWindow) navigateToPage(pageType PageType, pushCurrent bool) {
 			mw.subscriptionPageInstance.Refresh()
 		}
 		pageContent = mw.subscriptionPage
+	case PageTypeProviders:
+		mw.providersPage = NewProviderHealthPage(mw.appState).Build()
+		pageContent = mw.providersPage
+	case PageTypeUsage:
+		mw.usagePage = NewUsagePage(mw.appState).Build()
+		pageContent = mw.usagePage
 	default:
 		// 未知页面类型，返回主界面
 		if mw.homePage == nil {
@@ -770,6 +970,16 @@ func (mw *MainWindow) ShowSubscriptionPage() {
 	mw.navigateToPage(PageTypeSubscription, true)
 }
 
+// ShowProvidersPage 切换到服务商概览页面（providersPage）
+func (mw *MainWindow) ShowProvidersPage() {
+	mw.navigateToPage(PageTypeProviders, true)
+}
+
+// ShowUsagePage 切换到使用报告页面（usagePage）
+func (mw *MainWindow) ShowUsagePage() {
+	mw.navigateToPage(PageTypeUsage, true)
+}
+
 // RebuildCurrentPageForTheme 主题切换后重建当前页面，使侧栏/背景等缓存的主题色生效；
 // 同时使主页 logo 随主题更新（未在当前页时清空 homePage 缓存，下次进入主页时用 createHomeLogo 重新生成）。
 func (mw *MainWindow) RebuildCurrentPageForTheme() {
@@ -794,6 +1004,19 @@ func (mw *MainWindow) RebuildCurrentPageForTheme() {
 	}
 }
 
+// RebuildListPages 列表密度变化后丢弃节点页与订阅页的缓存内容，下次进入时按新的行高重建。
+func (mw *MainWindow) RebuildListPages() {
+	if mw == nil {
+		return
+	}
+	mw.nodePage = nil
+	if mw.subscriptionPageInstance != nil {
+		mw.subscriptionPageInstance.Cleanup()
+		mw.subscriptionPageInstance = nil
+	}
+	mw.subscriptionPage = nil
+}
+
 // onToggleProxy 主开关按钮回调：启动/停止代理
 func (mw *MainWindow) onToggleProxy() {
 	if mw.appState == nil {
@@ -802,15 +1025,18 @@ func (mw *MainWindow) onToggleProxy() {
 
 	// 检查代理是否正在运行
 	isRunning := false
-	if mw.appState.XrayInstance != nil {
-		isRunning = mw.appState.XrayInstance.IsRunning()
+	if mw.appState.XrayInstance() != nil {
+		isRunning = mw.appState.XrayInstance().IsRunning()
 	}
 
 	if isRunning {
 		// 停止代理
 		mw.stopProxy()
 	} else {
-		// 启动代理（使用当前选中的服务器）
+		// 启动代理（使用当前选中的服务器）；离线时只提示，断开不受影响
+		if guardOffline(mw.appState, "连接代理") {
+			return
+		}
 		mw.startProxy()
 	}
 
@@ -823,24 +1049,25 @@ func (mw *MainWindow) refreshHomePageStatus() {
 	if mw.appState != nil {
 		mw.appState.UpdateProxyStatus()
 	}
-	mw.updateHomeServerNameLabel()
+	// 节点名称由 ServerNameBinding 监听自动更新
 	// 注意：不再显示延迟，已从节点信息区域移除
 	if mw.mainToggleButton != nil {
 		mw.updateMainToggleButton()
 	}
 }
 
-// updateHomeServerNameLabel 更新主页节点名称显示，超长文本会被手动省略。
+// updateHomeServerNameLabel 按状态绑定中的节点名称更新主页显示，超长文本会被手动省略。
 func (mw *MainWindow) updateHomeServerNameLabel() {
 	if mw == nil || mw.serverNameLabel == nil {
 		return
 	}
 
-	name := "无"
-	if mw.appState != nil && mw.appState.Store != nil && mw.appState.Store.Nodes != nil {
-		if selected := mw.appState.Store.Nodes.GetSelected(); selected != nil {
-			name = selected.Name
-		}
+	name := ""
+	if status := mw.proxyStatus(); status != nil {
+		name, _ = status.ServerNameBinding.Get()
+	}
+	if name == "" {
+		name = "无"
 	}
 
 	mw.serverNameLabel.SetText(truncateDisplayText(name, 25))
@@ -871,47 +1098,15 @@ func (mw *MainWindow) startProxy() {
 		return
 	}
 
-	if mw.appState.XrayControlService == nil {
-		mw.logAndShowError("启动代理失败", fmt.Errorf("XrayControlService 未初始化"))
-		return
-	}
-
-	// 使用统一的日志文件路径（与应用日志使用同一个文件）
-	unifiedLogPath := ""
-	if mw.appState.Logger != nil {
-		unifiedLogPath = mw.appState.Logger.GetLogFilePath()
-	}
-
-	// 调用 service 启动代理
-	result := mw.appState.XrayControlService.StartProxy(mw.appState.XrayInstance, unifiedLogPath)
+	// 由应用核心启动代理并持有实例（同时更新 ProxyService、记录日志）
+	result := mw.appState.StartProxy()
 
 	if result.Error != nil {
 		mw.logAndShowError("启动代理失败", result.Error)
-		if mw.appState != nil {
-			mw.appState.UpdateProxyStatus()
-		}
+		mw.appState.UpdateProxyStatus()
 		return
 	}
 
-	// 启动成功，更新 AppState 中的 XrayInstance
-	mw.appState.XrayInstance = result.XrayInstance
-
-	// 更新 ProxyService 的 xray 实例引用
-	if mw.appState.ProxyService != nil {
-		mw.appState.ProxyService.UpdateXrayInstance(result.XrayInstance)
-	} else {
-		// 延迟初始化 ProxyService
-		mw.appState.ProxyService = service.NewProxyService(result.XrayInstance, mw.appState.ConfigService)
-	}
-
-	// 记录日志（统一日志记录）
-	if mw.appState.Logger != nil && result.XrayInstance != nil {
-		selectedNode := mw.appState.Store.Nodes.GetSelected()
-		if selectedNode != nil {
-			mw.appState.Logger.InfoWithType(logging.LogTypeProxy, "xray-core代理已启动: %s (端口: %d)", selectedNode.Name, result.XrayInstance.GetPort())
-		}
-	}
-
 	// 更新状态绑定（使用双向绑定，UI 会自动更新）
 	if mw.appState != nil {
 		mw.appState.UpdateProxyStatus()
@@ -957,27 +1152,14 @@ func (mw *MainWindow) stopProxy() {
 		return
 	}
 
-	if mw.appState.XrayControlService == nil {
-		mw.logAndShowError("停止代理失败", fmt.Errorf("XrayControlService 未初始化"))
-		return
-	}
-
-	// 调用 service 停止代理
-	result := mw.appState.XrayControlService.StopProxy(mw.appState.XrayInstance)
+	// 由应用核心停止代理并释放实例（生命周期 = 代理运行生命周期）
+	result := mw.appState.StopProxy()
 
 	if result.Error != nil {
 		mw.logAndShowError("停止代理失败", result.Error)
 		return
 	}
 
-	// 停止成功，销毁实例（生命周期 = 代理运行生命周期）
-	mw.appState.XrayInstance = nil
-
-	// 记录日志（统一日志记录）
-	if mw.appState.Logger != nil {
-		mw.appState.Logger.InfoWithType(logging.LogTypeProxy, "xray-core代理已停止")
-	}
-
 	// 更新状态绑定
 	if mw.appState != nil {
 		mw.appState.UpdateProxyStatus()
@@ -991,60 +1173,49 @@ func (mw *MainWindow) stopProxy() {
 		mw.nodePageInstance.Refresh()
 	}
 
-	// 显示成功对话框
-	if mw.appState.Window != nil {
-		if result.LogMessage == "代理未运行" {
+	// 显示成功对话框（开启连接报告时显示本次连接的报告）
+	if result.LogMessage == "代理未运行" {
+		if mw.appState.Window != nil {
 			dialog.ShowInformation("提示", "代理未运行", mw.appState.Window)
-		} else {
-			dialog.ShowInformation("代理停止成功", "代理已停止", mw.appState.Window)
 		}
+	} else {
+		showProxyStoppedDialog(mw.appState)
 	}
 }
 
 // RestartXrayIfRunningForInboundListenChange 在「允许 WSL/局域网入站」开关变更且代理已运行时重启 xray，使 listen 地址立即生效。
 func (mw *MainWindow) RestartXrayIfRunningForInboundListenChange() {
-	if mw == nil || mw.appState == nil || mw.appState.XrayControlService == nil {
-		return
-	}
-	if mw.appState.XrayInstance == nil || !mw.appState.XrayInstance.IsRunning() {
+	mw.RestartXrayIfRunning("入站监听范围")
+}
+
+// RestartXrayIfRunning 代理已运行时按当前配置重启 xray，使入站或路由相关设置立即生效；
+// what 为设置名称，用于日志与错误提示。
+func (mw *MainWindow) RestartXrayIfRunning(what string) {
+	if mw == nil || mw.appState == nil || !mw.appState.IsProxyRunning() {
 		return
 	}
 
-	stopRes := mw.appState.XrayControlService.StopProxy(mw.appState.XrayInstance)
+	stopRes := mw.appState.StopProxy()
 	if stopRes.Error != nil {
-		mw.logAndShowError("停止代理失败（无法套用入站监听设置）", stopRes.Error)
+		mw.logAndShowError(fmt.Sprintf("停止代理失败（无法套用%s）", what), stopRes.Error)
 		return
 	}
-	mw.appState.XrayInstance = nil
-	if mw.appState.ProxyService != nil {
-		mw.appState.ProxyService.UpdateXrayInstance(nil)
-	}
 	mw.appState.UpdateProxyStatus()
 	mw.updateMainToggleButton()
 	if mw.nodePageInstance != nil {
 		mw.nodePageInstance.Refresh()
 	}
 
-	unifiedLogPath := ""
-	if mw.appState.Logger != nil {
-		unifiedLogPath = mw.appState.Logger.GetLogFilePath()
-	}
-	startRes := mw.appState.XrayControlService.StartProxy(nil, unifiedLogPath)
+	startRes := mw.appState.StartProxy()
 	if startRes.Error != nil {
-		mw.logAndShowError("启动代理失败（入站监听设置可能未生效）", startRes.Error)
+		mw.logAndShowError(fmt.Sprintf("启动代理失败（%s可能未生效）", what), startRes.Error)
 		mw.appState.UpdateProxyStatus()
 		mw.updateMainToggleButton()
 		return
 	}
-	mw.appState.XrayInstance = startRes.XrayInstance
-	if mw.appState.ProxyService != nil {
-		mw.appState.ProxyService.UpdateXrayInstance(startRes.XrayInstance)
-	} else {
-		mw.appState.ProxyService = service.NewProxyService(startRes.XrayInstance, mw.appState.ConfigService)
-	}
 	if mw.appState.Logger != nil && startRes.XrayInstance != nil {
 		if n := mw.appState.Store.Nodes.GetSelected(); n != nil {
-			mw.appState.Logger.InfoWithType(logging.LogTypeProxy, "已重启 xray 以套用入站监听范围（节点: %s，端口: %d）", n.Name, startRes.XrayInstance.GetPort())
+			mw.appState.Logger.InfoWithType(logging.LogTypeProxy, "已重启 xray 以套用%s（节点: %s，端口: %d）", what, n.Name, startRes.XrayInstance.GetPort())
 		}
 	}
 	mw.appState.UpdateProxyStatus()
@@ -1071,8 +1242,7 @@ func (mw *MainWindow) logAndShowError(message string, err error) {
 		mw.appState.Logger.Error("%s: %v", message, err)
 	}
 	if mw.appState != nil && mw.appState.Window != nil {
-		errorMsg := fmt.Errorf("%s: %w", message, err)
-		dialog.ShowError(errorMsg, mw.appState.Window)
+		showProxyError(message, err, mw.appState.Window)
 	}
 	if mw.appState != nil {
 		mw.appState.AppendLog("ERROR", "app", fmt.Sprintf("%s: %v", message, err))
@@ -1120,8 +1290,8 @@ func (mw *MainWindow) updateMainToggleButton() {
 	}
 
 	isRunning := false
-	if mw.appState != nil && mw.appState.XrayInstance != nil {
-		isRunning = mw.appState.XrayInstance.IsRunning()
+	if mw.appState != nil && mw.appState.XrayInstance() != nil {
+		isRunning = mw.appState.XrayInstance().IsRunning()
 	}
 
 	// 更新按钮图标与配色：运行中 CancelIcon + Primary，未运行 ConfirmIcon + Separator
@@ -1155,13 +1325,18 @@ func (mw *MainWindow) applySystemProxyModeCore(mode SystemProxyMode, saveToStore
 	}
 	proxyPort := configPort
 	xrayOverrode := false
-	if mw.appState != nil && mw.appState.XrayInstance != nil && mw.appState.XrayInstance.IsRunning() {
-		if port := mw.appState.XrayInstance.GetPort(); port > 0 {
+	if mw.appState != nil && mw.appState.IsProxyRunning() {
+		if port := mw.appState.XrayInstance().GetPort(); port > 0 {
 			proxyPort = port
 			xrayOverrode = true
 		}
 	}
 
+	// 入站认证账号随终端/Git 代理地址一并写入
+	if mw.appState != nil && mw.appState.ConfigService != nil {
+		systemproxy.SetProxyCredentials(mw.appState.ConfigService.InboundAuth())
+	}
+
 	// 确保 SystemProxy 实例已创建
 	if mw.systemProxy == nil {
 		mw.systemProxy = systemproxy.NewSystemProxy(database.LocalMixedInboundListenHost, proxyPort)
@@ -1299,8 +1474,10 @@ func (mw *MainWindow) SetSystemProxyMode(mode SystemProxyMode) error {
 		return fmt.Errorf("appState 未初始化")
 	}
 
-	// 更新按钮选中状态（如果按钮已创建）
-	mw.updateProxyModeButtonsState(mode)
+	// 更新状态绑定中的模式，按钮与托盘随之刷新
+	if status := mw.proxyStatus(); status != nil {
+		status.SetMode(mode.String())
+	}
 
 	// 应用系统代理模式（保存到 Store）
 	err := mw.applySystemProxyModeCore(mode, true)
@@ -1362,8 +1539,8 @@ func (mw *MainWindow) updateSystemProxyPort() {
 	if mw.appState != nil && mw.appState.ConfigService != nil {
 		proxyPort = mw.appState.ConfigService.GetLocalInboundPort()
 	}
-	if mw.appState.XrayInstance != nil && mw.appState.XrayInstance.IsRunning() {
-		if port := mw.appState.XrayInstance.GetPort(); port > 0 {
+	if mw.appState.IsProxyRunning() {
+		if port := mw.appState.XrayInstance().GetPort(); port > 0 {
 			proxyPort = port
 		}
 	}
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/driver"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+// miniWindowSize 悬浮小窗的固定尺寸
+var miniWindowSize = fyne.NewSize(240, 56)
+
+// setWindowAlwaysOnTop 设置窗口置顶，返回当前平台是否支持。须在窗口显示后调用。
+func setWindowAlwaysOnTop(w fyne.Window, onTop bool) bool {
+	nw, ok := w.(driver.NativeWindow)
+	if !ok {
+		return false
+	}
+	applied := false
+	nw.RunNative(func(context any) {
+		applied = setNativeWindowTopmost(context, onTop)
+	})
+	return applied
+}
+
+// windowPosition 读取窗口在屏幕上的位置，返回当前平台是否支持。须在窗口显示后于主线程调用。
+func windowPosition(w fyne.Window) (x, y int, ok bool) {
+	nw, isNative := w.(driver.NativeWindow)
+	if !isNative {
+		return 0, 0, false
+	}
+	nw.RunNative(func(context any) {
+		x, y, ok = nativeWindowPosition(context)
+	})
+	return x, y, ok
+}
+
+// setWindowPosition 将窗口移动到指定位置，返回当前平台是否支持。须在窗口显示后调用。
+func setWindowPosition(w fyne.Window, x, y int) bool {
+	nw, ok := w.(driver.NativeWindow)
+	if !ok {
+		return false
+	}
+	applied := false
+	nw.RunNative(func(context any) {
+		applied = setNativeWindowPosition(context, x, y)
+	})
+	return applied
+}
+
+// MiniWindow 悬浮状态小窗：置顶显示连接状态与实时速度，单击切换代理，右键菜单可回到主窗口。
+// 适合主窗口隐藏到托盘时随时查看状态。
+type MiniWindow struct {
+	appState *AppState
+	window   fyne.Window
+
+	statusDot  *canvas.Circle
+	nameLabel  *widget.Label
+	speedLabel *widget.Label
+
+	trafficUnsub   func()
+	statusListener binding.DataListener
+}
+
+// NewMiniWindow 创建悬浮小窗（不显示）。
+func NewMiniWindow(appState *AppState) *MiniWindow {
+	return &MiniWindow{appState: appState}
+}
+
+// Show 显示小窗并置顶，开始订阅状态与流量。
+func (m *MiniWindow) Show() {
+	if m.window != nil {
+		m.window.Show()
+		return
+	}
+	if m.appState == nil || m.appState.App == nil {
+		return
+	}
+	w := m.appState.App.NewWindow("myproxy")
+	w.SetFixedSize(true)
+	w.SetPadded(false)
+	w.SetContent(m.build())
+	w.Resize(miniWindowSize)
+	w.SetCloseIntercept(func() {
+		m.appState.SetMiniWindowVisible(false)
+	})
+	m.window = w
+	m.subscribe()
+	w.Show()
+	fyne.Do(func() {
+		if m.window != nil && !setWindowAlwaysOnTop(m.window, true) {
+			m.appState.AppendLog("INFO", "app", "当前平台不支持窗口置顶，悬浮窗以普通窗口显示")
+		}
+	})
+}
+
+// Close 关闭小窗并取消订阅。
+func (m *MiniWindow) Close() {
+	m.unsubscribe()
+	if m.window != nil {
+		w := m.window
+		m.window = nil
+		w.SetCloseIntercept(nil)
+		w.Close()
+	}
+}
+
+func (m *MiniWindow) build() fyne.CanvasObject {
+	m.statusDot = canvas.NewCircle(CurrentThemeColor(m.appState.App, theme.ColorNameDisabled))
+	m.nameLabel = widget.NewLabel("")
+	m.nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+	m.nameLabel.Truncation = fyne.TextTruncateEllipsis
+	m.speedLabel = widget.NewLabel("↑- ↓-")
+	m.speedLabel.Alignment = fyne.TextAlignTrailing
+
+	dot := container.NewCenter(container.NewGridWrap(fyne.NewSize(10, 10), m.statusDot))
+	row := container.NewBorder(nil, nil, dot, m.speedLabel, m.nameLabel)
+	m.refreshStatus()
+	return newMiniWindowTapArea(m, container.NewPadded(row))
+}
+
+// subscribe 监听代理状态绑定与流量采样。
+func (m *MiniWindow) subscribe() {
+	if m.appState.Store != nil && m.appState.Store.ProxyStatus != nil {
+		m.statusListener = binding.NewDataListener(m.refreshStatus)
+		m.appState.Store.ProxyStatus.ProxyStatusBinding.AddListener(m.statusListener)
+		m.appState.Store.ProxyStatus.ServerNameBinding.AddListener(m.statusListener)
+	}
+	if m.appState.TrafficService != nil {
+		m.trafficUnsub = m.appState.TrafficService.Subscribe(func(sample service.TrafficSample) {
+			text := fmt.Sprintf("↑%s ↓%s", formatSpeed(sample.Upload), formatSpeed(sample.Download))
+			fyne.Do(func() {
+				if m.speedLabel != nil {
+					m.speedLabel.SetText(text)
+				}
+			})
+		})
+	}
+}
+
+func (m *MiniWindow) unsubscribe() {
+	if m.trafficUnsub != nil {
+		m.trafficUnsub()
+		m.trafficUnsub = nil
+	}
+	if m.statusListener != nil && m.appState.Store != nil && m.appState.Store.ProxyStatus != nil {
+		m.appState.Store.ProxyStatus.ProxyStatusBinding.RemoveListener(m.statusListener)
+		m.appState.Store.ProxyStatus.ServerNameBinding.RemoveListener(m.statusListener)
+		m.statusListener = nil
+	}
+}
+
+// refreshStatus 按代理状态更新指示点与节点名；未连接时速度显示为占位符。
+func (m *MiniWindow) refreshStatus() {
+	if m.nameLabel == nil {
+		return
+	}
+	connected := false
+	name := ""
+	if m.appState.Store != nil && m.appState.Store.ProxyStatus != nil {
+		status := m.appState.Store.ProxyStatus
+		connected = !status.SessionStartedAt().IsZero()
+		name, _ = status.ServerNameBinding.Get()
+	}
+	if connected {
+		m.statusDot.FillColor = CurrentThemeColor(m.appState.App, theme.ColorNameSuccess)
+		m.nameLabel.SetText(truncateDisplayText(name, 16))
+	} else {
+		m.statusDot.FillColor = CurrentThemeColor(m.appState.App, theme.ColorNameDisabled)
+		m.nameLabel.SetText("未连接")
+		m.speedLabel.SetText("↑- ↓-")
+	}
+	m.statusDot.Refresh()
+}
+
+// showMenu 右键菜单：显示主窗口、关闭悬浮窗。
+func (m *MiniWindow) showMenu(pos fyne.Position) {
+	if m.window == nil {
+		return
+	}
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("显示主窗口", func() {
+			if m.appState.Window != nil {
+				m.appState.Window.Show()
+				m.appState.Window.RequestFocus()
+			}
+		}),
+		fyne.NewMenuItem("关闭悬浮窗", func() {
+			m.appState.SetMiniWindowVisible(false)
+		}),
+	)
+	widget.ShowPopUpMenuAtPosition(menu, m.window.Canvas(), pos)
+}
+
+// miniWindowTapArea 小窗内容的点击区域：左键切换代理，右键弹出菜单。
+type miniWindowTapArea struct {
+	widget.BaseWidget
+	mini    *MiniWindow
+	content fyne.CanvasObject
+}
+
+func newMiniWindowTapArea(mini *MiniWindow, content fyne.CanvasObject) *miniWindowTapArea {
+	t := &miniWindowTapArea{mini: mini, content: content}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *miniWindowTapArea) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.content)
+}
+
+func (t *miniWindowTapArea) Tapped(_ *fyne.PointEvent) {
+	if t.mini.appState.MainWindow != nil {
+		t.mini.appState.MainWindow.onToggleProxy()
+	}
+}
+
+func (t *miniWindowTapArea) TappedSecondary(ev *fyne.PointEvent) {
+	t.mini.showMenu(ev.AbsolutePosition)
+}
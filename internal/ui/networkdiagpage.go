@@ -0,0 +1,364 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// NetworkDiagnosticsPage 网络诊断：检查默认网关、DNS（直连与经代理）以及到选中节点的连通性与逐跳延迟。
+type NetworkDiagnosticsPage struct {
+	appState *AppState
+	content  fyne.CanvasObject
+
+	runBtn      *widget.Button
+	statusLabel *widget.Label
+	checksList  *widget.List
+	hopsLabel   *widget.Label
+
+	mu      sync.Mutex
+	checks  []model.NetworkCheck
+	report  *model.NetworkReport
+	cancel  context.CancelFunc
+	running bool
+
+	// DNS 泄漏测试（见 buildDNSLeakSection）
+	leakBtn     *widget.Button
+	leakResult  *widget.Label
+	leakReport  *model.DNSLeakReport
+	leakCancel  context.CancelFunc
+	leakRunning bool
+}
+
+// NewNetworkDiagnosticsPage 创建网络诊断页。
+func NewNetworkDiagnosticsPage(appState *AppState) *NetworkDiagnosticsPage {
+	return &NetworkDiagnosticsPage{appState: appState}
+}
+
+// Build 构建网络诊断页；结果在切换菜单后保留。
+func (np *NetworkDiagnosticsPage) Build() fyne.CanvasObject {
+	if np.content != nil {
+		return np.content
+	}
+	spacing := innerPadding(np.appState)
+
+	np.statusLabel = widget.NewLabel("点击「开始诊断」检查本机网络、DNS 与到当前节点的线路。")
+	np.statusLabel.Wrapping = fyne.TextWrapWord
+	np.hopsLabel = widget.NewLabel("")
+	np.hopsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+
+	np.checksList = widget.NewList(
+		func() int {
+			np.mu.Lock()
+			defer np.mu.Unlock()
+			return len(np.checks)
+		},
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			resultLabel := widget.NewLabel("")
+			resultLabel.Alignment = fyne.TextAlignTrailing
+			return container.NewBorder(nil, nil, nil, resultLabel, nameLabel)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			np.mu.Lock()
+			if id < 0 || id >= len(np.checks) {
+				np.mu.Unlock()
+				return
+			}
+			c := np.checks[id]
+			np.mu.Unlock()
+			name := fmt.Sprintf("[%s] %s", service.NetworkCheckStatusText(c.Status), c.Name)
+			if c.Detail != "" {
+				name += "  " + c.Detail
+			}
+			result := ""
+			if c.Latency > 0 {
+				result = fmt.Sprintf("%dms", c.Latency.Milliseconds())
+			}
+			labels := collectLabelsFromObject(obj)
+			if len(labels) >= 2 {
+				labels[0].SetText(name)
+				labels[1].SetText(result)
+			}
+		},
+	)
+	listScroll := container.NewScroll(np.checksList)
+	listScroll.SetMinSize(fyne.NewSize(0, 220))
+
+	np.runBtn = widget.NewButtonWithIcon("开始诊断", theme.MediaPlayIcon(), np.toggleRun)
+	np.runBtn.Importance = widget.HighImportance
+	copyBtn := widget.NewButtonWithIcon("复制报告", theme.ContentCopyIcon(), func() {
+		report, ok := np.currentReport()
+		if !ok || np.appState == nil || np.appState.Window == nil {
+			return
+		}
+		np.appState.Window.Clipboard().SetContent(service.FormatNetworkReport(report))
+		np.statusLabel.SetText("诊断报告已复制到剪贴板")
+	})
+	saveBtn := widget.NewButtonWithIcon("保存报告", theme.DocumentSaveIcon(), func() {
+		report, ok := np.currentReport()
+		if !ok || np.appState == nil || np.appState.DiagnosticsService == nil {
+			return
+		}
+		path, err := np.appState.DiagnosticsService.ExportNetworkReport(report)
+		if err != nil {
+			np.showError(err)
+			return
+		}
+		np.statusLabel.SetText("诊断报告已保存: " + path)
+	})
+
+	np.content = newPaddedWithSize(newCompactVBox(spacing,
+		widget.NewLabelWithStyle("网络诊断", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		np.statusLabel,
+		container.NewGridWithColumns(3, np.runBtn, copyBtn, saveBtn),
+		widget.NewSeparator(),
+		listScroll,
+		widget.NewLabelWithStyle("逐跳延迟", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		np.hopsLabel,
+		widget.NewSeparator(),
+		np.buildDNSLeakSection(),
+	), spacing)
+	return np.content
+}
+
+// buildDNSLeakSection 构建 DNS 泄漏测试：比较隧道内与本机直连解析时测试服务看到的解析器，给出通过/未通过。
+func (np *NetworkDiagnosticsPage) buildDNSLeakSection() fyne.CanvasObject {
+	serviceEntry := widget.NewEntry()
+	serviceEntry.SetPlaceHolder(service.DefaultDNSLeakTestService)
+	if np.appState != nil && np.appState.ConfigService != nil {
+		serviceEntry.SetText(np.appState.ConfigService.GetDNSLeakTestService())
+	}
+	serviceEntry.OnSubmitted = func(s string) {
+		if np.appState == nil || np.appState.ConfigService == nil {
+			return
+		}
+		if err := np.appState.ConfigService.SetDNSLeakTestService(s); err != nil {
+			np.showError(err)
+			return
+		}
+		serviceEntry.SetText(np.appState.ConfigService.GetDNSLeakTestService())
+	}
+
+	np.leakResult = widget.NewLabel("只设置 SOCKS 代理时，应用可能仍在本机解析域名，使访问记录暴露给运营商的 DNS。测试需要代理运行。")
+	np.leakResult.Wrapping = fyne.TextWrapWord
+	np.leakBtn = widget.NewButtonWithIcon("开始测试", theme.SearchIcon(), func() {
+		// 先保存修改过的服务地址
+		if serviceEntry.OnSubmitted != nil {
+			serviceEntry.OnSubmitted(serviceEntry.Text)
+		}
+		np.toggleDNSLeakTest()
+	})
+	copyBtn := widget.NewButtonWithIcon("复制结果", theme.ContentCopyIcon(), func() {
+		np.mu.Lock()
+		report := np.leakReport
+		np.mu.Unlock()
+		if report == nil || np.appState == nil || np.appState.Window == nil {
+			return
+		}
+		np.appState.Window.Clipboard().SetContent(service.FormatDNSLeakReport(*report))
+	})
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("DNS 泄漏测试", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewBorder(nil, nil, widget.NewLabel("测试服务"), container.NewHBox(np.leakBtn, copyBtn), serviceEntry),
+		np.leakResult,
+	)
+}
+
+// toggleDNSLeakTest 开始 DNS 泄漏测试；运行中再次点击则取消。
+func (np *NetworkDiagnosticsPage) toggleDNSLeakTest() {
+	if np.appState == nil || np.appState.DiagnosticsService == nil {
+		return
+	}
+	if !np.appState.IsProxyRunning() {
+		np.leakResult.SetText("代理未运行，请先连接后再测试。")
+		return
+	}
+	np.mu.Lock()
+	if np.leakRunning {
+		if np.leakCancel != nil {
+			np.leakCancel()
+		}
+		np.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	np.leakCancel = cancel
+	np.leakRunning = true
+	np.leakReport = nil
+	np.mu.Unlock()
+
+	np.leakBtn.SetText("停止")
+	np.leakBtn.SetIcon(theme.MediaStopIcon())
+	np.leakResult.SetText("正在测试，约需 10 秒...")
+	proxyPort := np.appState.XrayInstance().GetPort()
+
+	go func() {
+		report, err := np.appState.DiagnosticsService.RunDNSLeakTest(ctx, proxyPort)
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		np.mu.Lock()
+		np.leakRunning = false
+		np.leakCancel = nil
+		np.leakReport = report
+		np.mu.Unlock()
+
+		fyne.Do(func() {
+			np.leakBtn.SetText("开始测试")
+			np.leakBtn.SetIcon(theme.SearchIcon())
+			switch {
+			case cancelled:
+				np.leakResult.SetText("测试已取消")
+			case err != nil:
+				np.leakResult.SetText(err.Error())
+			default:
+				np.leakResult.SetText(formatDNSLeakSummary(report))
+			}
+		})
+	}()
+}
+
+// formatDNSLeakSummary 页面上显示的测试结论与解析器列表。
+func formatDNSLeakSummary(report *model.DNSLeakReport) string {
+	ips := func(resolvers []model.DNSResolver) string {
+		parts := make([]string, len(resolvers))
+		for i, r := range resolvers {
+			parts[i] = r.IP
+			if r.Country != "" {
+				parts[i] += "（" + r.Country + "）"
+			}
+		}
+		if len(parts) == 0 {
+			return "-"
+		}
+		return strings.Join(parts, "、")
+	}
+	var b strings.Builder
+	if report.Leaked() {
+		fmt.Fprintf(&b, "✗ 未通过：经代理访问的域名仍由本机直连的解析器 %s 解析。", ips(report.LeakedResolvers))
+	} else {
+		b.WriteString("✓ 通过：经代理访问的域名未使用本机直连的解析器。")
+	}
+	fmt.Fprintf(&b, "\n经代理的解析器：%s", ips(report.TunnelResolvers))
+	fmt.Fprintf(&b, "\n本机直连的解析器：%s（只走 SOCKS 且在本机解析域名的应用会经这些解析器查询）", ips(report.DirectResolvers))
+	return b.String()
+}
+
+// toggleRun 开始诊断；运行中再次点击则取消。
+func (np *NetworkDiagnosticsPage) toggleRun() {
+	if np.appState == nil || np.appState.DiagnosticsService == nil {
+		return
+	}
+	np.mu.Lock()
+	if np.running {
+		if np.cancel != nil {
+			np.cancel()
+		}
+		np.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	np.cancel = cancel
+	np.running = true
+	np.checks = nil
+	np.report = nil
+	np.mu.Unlock()
+
+	np.runBtn.SetText("停止")
+	np.runBtn.SetIcon(theme.MediaStopIcon())
+	np.statusLabel.SetText("正在诊断，逐跳探测可能需要一分钟...")
+	np.hopsLabel.SetText("")
+	np.checksList.Refresh()
+
+	proxyRunning := false
+	proxyPort := 0
+	if np.appState.IsProxyRunning() {
+		proxyRunning = true
+		proxyPort = np.appState.XrayInstance().GetPort()
+	}
+
+	go func() {
+		report := np.appState.DiagnosticsService.RunNetworkDiagnostics(ctx, proxyRunning, proxyPort, func(c model.NetworkCheck) {
+			np.mu.Lock()
+			np.checks = append(np.checks, c)
+			np.mu.Unlock()
+			fyne.Do(np.checksList.Refresh)
+		})
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		np.mu.Lock()
+		np.running = false
+		np.cancel = nil
+		np.report = &report
+		np.checks = report.Checks
+		np.mu.Unlock()
+
+		failed := 0
+		for _, c := range report.Checks {
+			if c.Status == model.NetworkCheckFailed {
+				failed++
+			}
+		}
+		fyne.Do(func() {
+			np.runBtn.SetText("开始诊断")
+			np.runBtn.SetIcon(theme.MediaPlayIcon())
+			np.checksList.Refresh()
+			np.hopsLabel.SetText(strings.Join(report.Hops, "\n"))
+			switch {
+			case cancelled:
+				np.statusLabel.SetText("诊断已取消")
+			case failed > 0:
+				np.statusLabel.SetText(fmt.Sprintf("诊断完成，%d 项失败", failed))
+			default:
+				np.statusLabel.SetText("诊断完成，全部正常")
+			}
+		})
+	}()
+}
+
+// currentReport 返回最近一次完成的诊断报告。
+func (np *NetworkDiagnosticsPage) currentReport() (model.NetworkReport, bool) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if np.report == nil {
+		if np.statusLabel != nil {
+			np.statusLabel.SetText("请先完成一次诊断")
+		}
+		return model.NetworkReport{}, false
+	}
+	return *np.report, true
+}
+
+// Cleanup 取消正在进行的诊断。
+func (np *NetworkDiagnosticsPage) Cleanup() {
+	if np == nil {
+		return
+	}
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if np.cancel != nil {
+		np.cancel()
+	}
+	if np.leakCancel != nil {
+		np.leakCancel()
+	}
+}
+
+func (np *NetworkDiagnosticsPage) showError(err error) {
+	if np.appState != nil && np.appState.Window != nil {
+		dialog.ShowError(err, np.appState.Window)
+	}
+}
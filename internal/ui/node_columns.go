@@ -0,0 +1,360 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/store"
+)
+
+const (
+	// nodeColumnMinWidth 固定宽度列可拖到的最小宽度
+	nodeColumnMinWidth float32 = 40
+	// nodeColumnMaxWidth 固定宽度列可拖到的最大宽度
+	nodeColumnMaxWidth float32 = 400
+	// nodeNameColumnMinWidth 名称列至少保留的宽度
+	nodeNameColumnMinWidth float32 = 80
+	// nodeColumnDividerWidth 表头列分隔拖动手柄的宽度
+	nodeColumnDividerWidth float32 = 6
+	// nodePageNarrowWidth 节点页窄于该宽度时功能栏分两行并隐藏地区列（默认 420 宽的窗口即为紧凑布局）
+	nodePageNarrowWidth float32 = 440
+)
+
+// nodeColumnTitles 各列表头与列选择菜单中的名称
+var nodeColumnTitles = map[string]string{
+	store.NodeColumnRegion:   "地区",
+	store.NodeColumnName:     "节点名称",
+	store.NodeColumnProtocol: "协议",
+	store.NodeColumnPort:     "端口",
+	store.NodeColumnDelay:    "延迟",
+	store.NodeColumnSpeed:    "速度",
+	store.NodeColumnTraffic:  "流量",
+}
+
+// nodeColumnAlignment 返回列内容的对齐方式。
+func nodeColumnAlignment(id string) fyne.TextAlign {
+	switch id {
+	case store.NodeColumnName:
+		return fyne.TextAlignLeading
+	case store.NodeColumnRegion, store.NodeColumnProtocol:
+		return fyne.TextAlignCenter
+	default:
+		return fyne.TextAlignTrailing
+	}
+}
+
+// nodeColumnsLayout 按列配置排列单元格：对象与 NodePage.columns 一一对应，隐藏列不占位，
+// 固定宽度列按配置宽度，名称列占满剩余宽度。表头与列表项共用，保证对齐。
+type nodeColumnsLayout struct {
+	np *NodePage
+}
+
+func (l *nodeColumnsLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	cols := l.np.displayColumns()
+	pad := theme.Padding()
+	widths := nodeColumnWidths(cols, size.Width, pad)
+	x := float32(0)
+	for i, obj := range objects {
+		if i >= len(cols) || !cols[i].Visible {
+			obj.Hide()
+			continue
+		}
+		obj.Show()
+		obj.Move(fyne.NewPos(x, 0))
+		obj.Resize(fyne.NewSize(widths[i], size.Height))
+		x += widths[i] + pad
+	}
+}
+
+func (l *nodeColumnsLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	cols := l.np.displayColumns()
+	var w, h float32
+	visible := 0
+	for i, obj := range objects {
+		if i >= len(cols) || !cols[i].Visible {
+			continue
+		}
+		visible++
+		if cols[i].ID == store.NodeColumnName {
+			w += nodeNameColumnMinWidth
+		} else {
+			w += cols[i].Width
+		}
+		if mh := obj.MinSize().Height; mh > h {
+			h = mh
+		}
+	}
+	if visible > 1 {
+		w += theme.Padding() * float32(visible-1)
+	}
+	return fyne.NewSize(w, h)
+}
+
+// nodeColumnWidths 计算各列实际宽度：名称列取剩余宽度（不少于最小宽度）。
+func nodeColumnWidths(cols []store.NodeColumnConfig, total, pad float32) []float32 {
+	widths := make([]float32, len(cols))
+	used := float32(0)
+	visible := 0
+	nameIdx := -1
+	for i, c := range cols {
+		if !c.Visible {
+			continue
+		}
+		visible++
+		if c.ID == store.NodeColumnName {
+			nameIdx = i
+			continue
+		}
+		widths[i] = c.Width
+		used += c.Width
+	}
+	if visible > 1 {
+		used += pad * float32(visible-1)
+	}
+	if nameIdx >= 0 {
+		widths[nameIdx] = total - used
+		if widths[nameIdx] < nodeNameColumnMinWidth {
+			widths[nameIdx] = nodeNameColumnMinWidth
+		}
+	}
+	return widths
+}
+
+// nodeColumnDivider 表头列边界的拖动手柄，拖动调整相邻固定宽度列的宽度，松开后保存。
+type nodeColumnDivider struct {
+	widget.BaseWidget
+	np     *NodePage
+	column string
+	// sign 拖动方向：名称列左侧的列拖右边界（+1），右侧的列拖左边界（-1）
+	sign float32
+}
+
+func newNodeColumnDivider(np *NodePage, column string, sign float32) *nodeColumnDivider {
+	d := &nodeColumnDivider{np: np, column: column, sign: sign}
+	d.ExtendBaseWidget(d)
+	return d
+}
+
+func (d *nodeColumnDivider) CreateRenderer() fyne.WidgetRenderer {
+	line := canvas.NewRectangle(CurrentThemeColor(d.np.appState.App, theme.ColorNameSeparator))
+	line.SetMinSize(fyne.NewSize(1, 0))
+	return widget.NewSimpleRenderer(container.NewCenter(line))
+}
+
+func (d *nodeColumnDivider) MinSize() fyne.Size {
+	return fyne.NewSize(nodeColumnDividerWidth, 0)
+}
+
+// Cursor 鼠标悬停时显示左右调整光标。
+func (d *nodeColumnDivider) Cursor() desktop.Cursor {
+	return desktop.HResizeCursor
+}
+
+func (d *nodeColumnDivider) Dragged(ev *fyne.DragEvent) {
+	d.np.resizeColumn(d.column, d.sign*ev.Dragged.DX)
+}
+
+func (d *nodeColumnDivider) DragEnd() {
+	d.np.saveColumns()
+}
+
+// loadColumns 从布局配置读取列设置。
+func (np *NodePage) loadColumns() {
+	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Layout != nil {
+		np.columns = np.appState.Store.Layout.NodeColumns()
+		return
+	}
+	np.columns = store.DefaultNodeColumns()
+}
+
+// saveColumns 将当前列设置写入布局配置。
+func (np *NodePage) saveColumns() {
+	if np.appState == nil || np.appState.Store == nil || np.appState.Store.Layout == nil {
+		return
+	}
+	if err := np.appState.Store.Layout.SaveNodeColumns(np.columns); err != nil {
+		np.appState.AppendLog("WARN", "app", "保存节点列表列设置失败: "+err.Error())
+	}
+}
+
+// displayColumns 返回实际排列的列：窗口较窄时隐藏地区列，把宽度留给节点名（不改动保存的列设置）。
+func (np *NodePage) displayColumns() []store.NodeColumnConfig {
+	if !np.narrow {
+		return np.columns
+	}
+	cols := make([]store.NodeColumnConfig, len(np.columns))
+	copy(cols, np.columns)
+	for i := range cols {
+		if cols[i].ID == store.NodeColumnRegion {
+			cols[i].Visible = false
+		}
+	}
+	return cols
+}
+
+// setNarrow 节点页宽度跨过 nodePageNarrowWidth 时切换紧凑列布局。
+func (np *NodePage) setNarrow(narrow bool) {
+	if np.narrow == narrow {
+		return
+	}
+	np.narrow = narrow
+	np.relayoutColumns()
+}
+
+// columnVisible 判断列是否显示。
+func (np *NodePage) columnVisible(id string) bool {
+	for _, c := range np.columns {
+		if c.ID == id {
+			return c.Visible
+		}
+	}
+	return false
+}
+
+// resizeColumn 按拖动距离调整列宽并重新排列表头与列表。
+func (np *NodePage) resizeColumn(id string, delta float32) {
+	for i := range np.columns {
+		if np.columns[i].ID != id {
+			continue
+		}
+		w := np.columns[i].Width + delta
+		if w < nodeColumnMinWidth {
+			w = nodeColumnMinWidth
+		}
+		if w > nodeColumnMaxWidth {
+			w = nodeColumnMaxWidth
+		}
+		np.columns[i].Width = w
+	}
+	np.relayoutColumns()
+}
+
+// relayoutColumns 列显示或宽度变化后重新排列表头与列表项。
+func (np *NodePage) relayoutColumns() {
+	if np.tableHeader != nil {
+		np.tableHeader.Refresh()
+	}
+	if np.list != nil {
+		np.list.Refresh()
+	}
+	np.syncTrafficSubscription()
+}
+
+// buildTableHeader 构建表头：每列一个标题，固定宽度列在靠近名称列的一侧带拖动手柄。
+func (np *NodePage) buildTableHeader() *fyne.Container {
+	cells := make([]fyne.CanvasObject, len(np.columns))
+	passedName := false
+	for i, c := range np.columns {
+		title := widget.NewLabel(nodeColumnTitles[c.ID])
+		title.Alignment = nodeColumnAlignment(c.ID)
+		title.TextStyle = fyne.TextStyle{Bold: true}
+		title.Truncation = fyne.TextTruncateEllipsis
+		switch {
+		case c.ID == store.NodeColumnName:
+			passedName = true
+			cells[i] = title
+		case passedName:
+			cells[i] = container.NewBorder(nil, nil, newNodeColumnDivider(np, c.ID, -1), nil, title)
+		default:
+			cells[i] = container.NewBorder(nil, nil, nil, newNodeColumnDivider(np, c.ID, 1), title)
+		}
+	}
+	np.tableHeader = container.New(&nodeColumnsLayout{np: np}, cells...)
+	return np.tableHeader
+}
+
+// showColumnMenu 弹出列选择菜单：勾选要显示的列，或恢复默认列宽。
+func (np *NodePage) showColumnMenu(anchor fyne.CanvasObject) {
+	if np.appState == nil || np.appState.Window == nil {
+		return
+	}
+	var items []*fyne.MenuItem
+	for i, c := range np.columns {
+		if c.ID == store.NodeColumnName {
+			continue
+		}
+		idx := i
+		title := nodeColumnTitles[c.ID]
+		if np.narrow && c.ID == store.NodeColumnRegion {
+			title += "（窗口较窄时隐藏）"
+		}
+		item := fyne.NewMenuItem(title, func() {
+			np.columns[idx].Visible = !np.columns[idx].Visible
+			np.relayoutColumns()
+			np.saveColumns()
+		})
+		item.Checked = c.Visible
+		items = append(items, item)
+	}
+	items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("恢复默认列", func() {
+		np.columns = store.DefaultNodeColumns()
+		np.relayoutColumns()
+		if np.appState.Store != nil && np.appState.Store.Layout != nil {
+			_ = np.appState.Store.Layout.SaveNodeColumns(nil)
+		}
+	}))
+	canvasObj := np.appState.Window.Canvas()
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), canvasObj, pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// syncTrafficSubscription 显示速度或流量列时订阅流量采样，每秒刷新列表；两列都隐藏时取消订阅。
+func (np *NodePage) syncTrafficSubscription() {
+	need := np.columnVisible(store.NodeColumnSpeed) || np.columnVisible(store.NodeColumnTraffic)
+	if !need || np.appState == nil || np.appState.TrafficService == nil {
+		if np.trafficUnsub != nil {
+			np.trafficUnsub()
+			np.trafficUnsub = nil
+		}
+		return
+	}
+	if np.trafficUnsub != nil {
+		return
+	}
+	np.trafficUnsub = np.appState.TrafficService.Subscribe(func(_ service.TrafficSample) {
+		fyne.Do(func() {
+			if np.list != nil {
+				np.list.Refresh()
+			}
+		})
+	})
+}
+
+// nodeColumnText 返回节点在文本列中的显示内容；connected 表示该节点为当前连接的节点。
+func (np *NodePage) nodeColumnText(id string, node model.Node, connected bool) string {
+	switch id {
+	case store.NodeColumnRegion:
+		return nodeRegion(node.Name)
+	case store.NodeColumnProtocol:
+		if node.ProtocolType == "" {
+			return "-"
+		}
+		return node.ProtocolType
+	case store.NodeColumnPort:
+		return strconv.Itoa(node.Port)
+	case store.NodeColumnSpeed:
+		if !connected || np.appState == nil || np.appState.TrafficService == nil {
+			return "-"
+		}
+		s := np.appState.TrafficService.Latest()
+		return fmt.Sprintf("↑%s ↓%s", formatSpeed(s.Upload), formatSpeed(s.Download))
+	case store.NodeColumnTraffic:
+		if np.appState == nil || np.appState.TrafficService == nil {
+			return "-"
+		}
+		up, down := np.appState.TrafficService.NodeTraffic(node.ID)
+		if up == 0 && down == 0 {
+			return "-"
+		}
+		return formatBytes(uint64(up + down))
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// showNodeExportDialog 将节点导出为 Clash proxies YAML 或 v2ray outbounds JSON，可复制或保存为文件；
+// baseName 为默认文件名（不含扩展名）。
+func showNodeExportDialog(appState *AppState, title string, nodes []*model.Node, baseName string) {
+	if appState == nil || appState.Window == nil {
+		return
+	}
+	win := appState.Window
+	output := widget.NewMultiLineEntry()
+	output.TextStyle = fyne.TextStyle{Monospace: true}
+	output.Wrapping = fyne.TextWrapOff
+	hintLabel := widget.NewLabel("")
+	hintLabel.Wrapping = fyne.TextWrapWord
+
+	names := make([]string, len(service.NodeExportFormats))
+	for i, f := range service.NodeExportFormats {
+		names[i] = service.NodeExportFormatName(f)
+	}
+	format := service.NodeExportFormats[0]
+	generate := func() {
+		data, skipped, err := service.ExportNodes(nodes, format)
+		if err != nil {
+			output.SetText("")
+			hintLabel.SetText(err.Error())
+			return
+		}
+		output.SetText(string(data))
+		hint := fmt.Sprintf("共 %d 个节点", len(nodes)-skipped)
+		if skipped > 0 {
+			hint += fmt.Sprintf("，%d 个节点无法以该格式表示，已跳过", skipped)
+		}
+		hintLabel.SetText(hint + "。内容包含节点密码等凭据，请勿公开分享。")
+	}
+	formatSelect := widget.NewSelect(names, func(name string) {
+		for i, n := range names {
+			if n == name {
+				format = service.NodeExportFormats[i]
+			}
+		}
+		generate()
+	})
+
+	copyBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), func() {
+		if output.Text == "" {
+			return
+		}
+		if appState.ClipboardMonitor != nil {
+			appState.ClipboardMonitor.IgnoreContent(output.Text)
+		}
+		win.Clipboard().SetContent(output.Text)
+		appState.AppendLog("INFO", "app", fmt.Sprintf("已复制导出的节点配置（%s）", title))
+	})
+	saveBtn := widget.NewButtonWithIcon("保存为文件", theme.DocumentSaveIcon(), func() {
+		if output.Text == "" {
+			return
+		}
+		content := output.Text
+		saveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if w == nil {
+				return
+			}
+			defer w.Close()
+			if _, err := w.Write([]byte(content)); err != nil {
+				dialog.ShowError(fmt.Errorf("保存导出文件失败: %w", err), win)
+				return
+			}
+			appState.AppendLog("INFO", "app", fmt.Sprintf("已导出节点配置（%s）: %s", title, w.URI().Name()))
+		}, win)
+		saveDialog.SetFileName(service.NodeExportFileName(format, baseName))
+		saveDialog.Show()
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	formatSelect.SetSelectedIndex(0)
+	content := container.NewBorder(
+		container.NewVBox(formatSelect, hintLabel),
+		container.NewHBox(layout.NewSpacer(), copyBtn, saveBtn),
+		nil, nil,
+		output,
+	)
+	d := dialog.NewCustom("导出节点 - "+title, "关闭", content, win)
+	d.Resize(fyne.NewSize(560, 480))
+	d.Show()
+}
@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+)
+
+// nodeRegionOther 无法识别地区的节点在筛选中使用的代码
+const nodeRegionOther = "其他"
+
+// nodeRegionAliases 按节点名识别地区：中英文名称按子串匹配，字母缩写按完整单词匹配（避免 US 命中 RUSSIA）。
+var nodeRegionAliases = []struct {
+	code  string
+	names []string
+	abbrs []string
+}{
+	{"HK", []string{"香港", "hong kong", "hongkong"}, []string{"HK", "HKG"}},
+	{"TW", []string{"台湾", "台灣", "taiwan"}, []string{"TW", "TWN"}},
+	{"MO", []string{"澳门", "澳門", "macao", "macau"}, []string{"MO", "MAC"}},
+	{"JP", []string{"日本", "东京", "大阪", "japan", "tokyo", "osaka"}, []string{"JP", "JPN"}},
+	{"KR", []string{"韩国", "韓國", "首尔", "korea", "seoul"}, []string{"KR", "KOR"}},
+	{"SG", []string{"新加坡", "狮城", "singapore"}, []string{"SG", "SGP"}},
+	{"US", []string{"美国", "美國", "洛杉矶", "硅谷", "united states", "america", "los angeles"}, []string{"US", "USA"}},
+	{"CA", []string{"加拿大", "canada"}, []string{"CA", "CAN"}},
+	{"GB", []string{"英国", "英國", "伦敦", "united kingdom", "london"}, []string{"UK", "GB", "GBR"}},
+	{"DE", []string{"德国", "德國", "法兰克福", "germany", "frankfurt"}, []string{"DE", "DEU"}},
+	{"FR", []string{"法国", "法國", "巴黎", "france", "paris"}, []string{"FR", "FRA"}},
+	{"NL", []string{"荷兰", "荷蘭", "阿姆斯特丹", "netherlands", "amsterdam"}, []string{"NL", "NLD"}},
+	{"RU", []string{"俄罗斯", "俄羅斯", "莫斯科", "russia", "moscow"}, []string{"RU", "RUS"}},
+	{"TR", []string{"土耳其", "turkey", "türkiye"}, []string{"TR", "TUR"}},
+	{"IN", []string{"印度", "india"}, []string{"IND"}},
+	{"AU", []string{"澳大利亚", "澳洲", "悉尼", "australia", "sydney"}, []string{"AU", "AUS"}},
+	{"MY", []string{"马来西亚", "馬來西亞", "malaysia"}, []string{"MY", "MYS"}},
+	{"TH", []string{"泰国", "泰國", "thailand"}, []string{"TH", "THA"}},
+	{"VN", []string{"越南", "vietnam"}, []string{"VN", "VNM"}},
+	{"PH", []string{"菲律宾", "philippines"}, []string{"PH", "PHL"}},
+	{"ID", []string{"印尼", "印度尼西亚", "indonesia"}, []string{"IDN"}},
+	{"AR", []string{"阿根廷", "argentina"}, []string{"AR", "ARG"}},
+	{"BR", []string{"巴西", "brazil"}, []string{"BR", "BRA"}},
+}
+
+// nodeRegionCode 从节点名识别地区代码（ISO 3166 两位字母）：优先取名称中的国旗 emoji，其次按名称与缩写匹配；
+// 无法识别时返回 nodeRegionOther。
+func nodeRegionCode(name string) string {
+	runes := []rune(name)
+	for i := 0; i+1 < len(runes); i++ {
+		if isRegionalIndicator(runes[i]) && isRegionalIndicator(runes[i+1]) {
+			code := string([]rune{'A' + runes[i] - 0x1F1E6, 'A' + runes[i+1] - 0x1F1E6})
+			// 🇨🇳 常用于标注中转线路，紧随其后的地区才是实际出口
+			if code != "CN" {
+				return code
+			}
+			i++
+		}
+	}
+	lower := strings.ToLower(name)
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return r >= unicode.MaxASCII || !unicode.IsLetter(r)
+	})
+	for _, alias := range nodeRegionAliases {
+		for _, n := range alias.names {
+			if strings.Contains(lower, n) {
+				return alias.code
+			}
+		}
+		for _, w := range words {
+			for _, a := range alias.abbrs {
+				if w == a {
+					return alias.code
+				}
+			}
+		}
+	}
+	// 中国仅在没有其他地区时采用，如「中国 电信」
+	if strings.Contains(name, "🇨🇳") || strings.Contains(lower, "中国") || strings.Contains(lower, "china") {
+		return "CN"
+	}
+	return nodeRegionOther
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// regionFlag 返回地区代码对应的国旗 emoji；「其他」原样返回。
+func regionFlag(code string) string {
+	if len(code) != 2 {
+		return code
+	}
+	return string([]rune{0x1F1E6 + rune(code[0]-'A'), 0x1F1E6 + rune(code[1]-'A')})
+}
+
+// regionName 返回地区代码对应的中文名称，未知代码原样返回。
+func regionName(code string) string {
+	for _, alias := range nodeRegionAliases {
+		if alias.code == code {
+			return alias.names[0]
+		}
+	}
+	if code == "CN" {
+		return "中国"
+	}
+	return code
+}
+
+// nodeRegionCount 某地区的节点数
+type nodeRegionCount struct {
+	code  string
+	count int
+}
+
+// countNodeRegions 统计各地区节点数，按数量降序，「其他」排在最后。
+func countNodeRegions(nodes []*model.Node) []nodeRegionCount {
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		counts[nodeRegionCode(n.Name)]++
+	}
+	result := make([]nodeRegionCount, 0, len(counts))
+	for code, c := range counts {
+		result = append(result, nodeRegionCount{code: code, count: c})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if (a.code == nodeRegionOther) != (b.code == nodeRegionOther) {
+			return b.code == nodeRegionOther
+		}
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.code < b.code
+	})
+	return result
+}
+
+// regionFilterActive 是否选中了地区筛选。
+func (np *NodePage) regionFilterActive() bool {
+	return np.appState != nil && len(np.appState.nodeRegionFilter) > 0
+}
+
+// regionMatches 判断节点是否属于选中的地区（未选中任何地区时全部匹配）。
+func (np *NodePage) regionMatches(node *model.Node) bool {
+	if !np.regionFilterActive() {
+		return true
+	}
+	return np.appState.nodeRegionFilter[nodeRegionCode(node.Name)]
+}
+
+// toggleRegionFilter 选中或取消一个地区；可同时选中多个地区。
+func (np *NodePage) toggleRegionFilter(code string) {
+	if np.appState == nil {
+		return
+	}
+	if np.appState.nodeRegionFilter == nil {
+		np.appState.nodeRegionFilter = make(map[string]bool)
+	}
+	if np.appState.nodeRegionFilter[code] {
+		delete(np.appState.nodeRegionFilter, code)
+	} else {
+		np.appState.nodeRegionFilter[code] = true
+	}
+	np.regionFilterGen++
+	np.regionChipsKey = "" // 选中状态变化，强制重建筛选条
+	np.Refresh()
+	np.saveFilter()
+}
+
+// clearRegionFilter 取消全部地区筛选。
+func (np *NodePage) clearRegionFilter() {
+	if np.appState == nil || len(np.appState.nodeRegionFilter) == 0 {
+		return
+	}
+	np.appState.nodeRegionFilter = nil
+	np.regionFilterGen++
+	np.regionChipsKey = ""
+	np.Refresh()
+	np.saveFilter()
+}
+
+// buildRegionChips 构建搜索框下方的地区筛选条，内容由 refreshRegionChips 按节点生成。
+func (np *NodePage) buildRegionChips() fyne.CanvasObject {
+	np.regionChips = container.NewHBox()
+	np.regionChipsScroll = container.NewHScroll(np.regionChips)
+	np.regionChipsKey = ""
+	np.refreshRegionChips()
+	return np.regionChipsScroll
+}
+
+// refreshRegionChips 按当前节点重新生成地区筛选按钮（如「🇭🇰 12」）；地区与数量未变化时不重建，
+// 避免测速期间频繁刷新。只有一个地区时隐藏筛选条。
+func (np *NodePage) refreshRegionChips() {
+	if np.regionChips == nil || np.regionChipsScroll == nil {
+		return
+	}
+	var nodes []*model.Node
+	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
+		nodes = np.appState.Store.Nodes.GetAll()
+	}
+	regions := countNodeRegions(nodes)
+	var key strings.Builder
+	for _, r := range regions {
+		fmt.Fprintf(&key, "%s:%d;", r.code, r.count)
+	}
+	if key.String() == np.regionChipsKey {
+		return
+	}
+	np.regionChipsKey = key.String()
+
+	if len(regions) < 2 && !np.regionFilterActive() {
+		np.regionChips.Objects = nil
+		np.regionChipsScroll.Hide()
+		return
+	}
+	objects := make([]fyne.CanvasObject, 0, len(regions)+1)
+	allBtn := widget.NewButton(fmt.Sprintf("全部 %d", len(nodes)), np.clearRegionFilter)
+	allBtn.Importance = widget.LowImportance
+	if !np.regionFilterActive() {
+		allBtn.Importance = widget.HighImportance
+	}
+	objects = append(objects, allBtn)
+	for _, r := range regions {
+		code := r.code
+		label := regionFlag(code)
+		btn := widget.NewButton(fmt.Sprintf("%s %d", label, r.count), func() { np.toggleRegionFilter(code) })
+		btn.Importance = widget.LowImportance
+		if np.regionFilterActive() && np.appState.nodeRegionFilter[code] {
+			btn.Importance = widget.HighImportance
+		}
+		objects = append(objects, btn)
+	}
+	np.regionChips.Objects = objects
+	np.regionChips.Refresh()
+	np.regionChipsScroll.Show()
+}
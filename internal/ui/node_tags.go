@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"myproxy.com/p/internal/analytics"
+)
+
+// refreshNodeTags 在后台按测速历史重新推断节点标签，完成后刷新列表；进行中时忽略重复调用。
+func (np *NodePage) refreshNodeTags() {
+	if np.appState == nil || np.appState.ServerService == nil || np.tagsLoading {
+		return
+	}
+	np.tagsLoading = true
+	serverService := np.appState.ServerService
+	go func() {
+		tags, err := serverService.NodeTags()
+		fyne.Do(func() {
+			np.tagsLoading = false
+			if err != nil {
+				np.appState.AppendLog("WARN", "ping", fmt.Sprintf("推断节点标签失败: %v", err))
+				return
+			}
+			np.nodeTags = tags
+			if np.list != nil {
+				np.list.Refresh()
+			}
+		})
+	}()
+}
+
+// newNodeTagText 创建节点名后的标签文字：小号、弱化颜色，不抢节点名的视觉重心。
+func newNodeTagText(app fyne.App) *canvas.Text {
+	text := canvas.NewText("", CurrentThemeColor(app, theme.ColorNamePlaceHolder))
+	text.TextSize = theme.DefaultTheme().Size(theme.SizeNameCaptionText)
+	return text
+}
+
+// nodeTagLabel 把标签拼成「高丢包 · 晚间慢」，无标签时返回空串。
+func nodeTagLabel(tags []analytics.NodeTag) string {
+	labels := make([]string, len(tags))
+	for i, t := range tags {
+		labels[i] = t.Label
+	}
+	return strings.Join(labels, " · ")
+}
+
+// nodeTagMenuItems 右键菜单中说明各标签推断依据的只读条目。
+func nodeTagMenuItems(tags []analytics.NodeTag) []*fyne.MenuItem {
+	if len(tags) == 0 {
+		return nil
+	}
+	items := []*fyne.MenuItem{fyne.NewMenuItemSeparator()}
+	for _, t := range tags {
+		item := fyne.NewMenuItem(t.Label+"："+t.Detail, nil)
+		item.Disabled = true
+		items = append(items, item)
+	}
+	return items
+}
@@ -2,19 +2,22 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/analytics"
 	"myproxy.com/p/internal/database"
-	"myproxy.com/p/internal/logging"
 	"myproxy.com/p/internal/model"
-	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/store"
 )
 
 // NodePage 管理服务器列表的显示和操作。
@@ -23,32 +26,84 @@ type NodePage struct {
 	appState   *AppState
 	list       *widget.List      // 列表组件
 	scrollList *container.Scroll // 滚动容器
+	listState  *ListStateView    // 列表三态（加载骨架 / 空状态 / 列表）
+	emptyState *EmptyState       // 无节点或无匹配时的提示
 	content    fyne.CanvasObject // 内容容器
 	listener   binding.DataListener
 
 	// 搜索与过滤相关
-	searchEntry *widget.Entry // 节点搜索输入框
-	searchText  string        // 当前搜索关键字（小写）
+	searchEntry     *widget.Entry // 节点搜索输入框
+	searchText      string        // 当前搜索关键字（小写）
+	searchSaveTimer *time.Timer   // 停止输入后将搜索关键字写入布局配置
+
+	// 地区筛选条（见 node_regions.go），选中的地区保存在 AppState 并写入布局配置，页面重建或重启后恢复
+	regionChips       *fyne.Container
+	regionChipsScroll *container.Scroll
+	regionChipsKey    string // 上次生成筛选条时的地区与数量，未变化时不重建
+	regionFilterGen   int    // 地区筛选变化时递增，使过滤结果缓存失效
+
+	// 过滤结果缓存：列表每项更新都会读取，按节点数据版本、搜索关键字与地区筛选失效
+	filteredNodes    []*model.Node
+	filteredRevision uint64
+	filteredSearch   string
+	filteredRegion   int
+	filteredValid    bool
+
+	// 节点绑定更新的合并刷新：一键测速时每个结果都会触发更新，最多每 nodeListRefreshInterval 刷新一次
+	bindingRefresh *refreshThrottle
+	shownNodes     []*model.Node // 上次刷新列表时的过滤结果，用于只刷新变化的行
+
+	// pendingOffset 待恢复的上次退出时的列表滚动位置，列表首次显示且有数据后恢复，之后为 0
+	pendingOffset float32
 
 	// UI 组件
 	selectedServerLabel *widget.Label // 当前选中服务器名标签
+
+	// 选中节点快速探测：probeSeq 递增，仅最后一次选中的结果会显示
+	probeSeq    int
+	probeStatus string // 追加在选中服务器名后的探测结果，如「探测中…」「42 ms」
+
+	// 列设置：显示哪些列及列宽，保存在布局配置中（见 node_columns.go）
+	columns      []store.NodeColumnConfig
+	tableHeader  *fyne.Container
+	trafficUnsub func() // 显示速度/流量列时的流量采样订阅
+	narrow       bool   // 页面窄于 nodePageNarrowWidth：功能栏分两行、隐藏地区列
+
+	// 按测速历史推断的节点标签（见 node_tags.go），页面显示与测速完成后在后台刷新
+	nodeTags    map[string][]analytics.NodeTag
+	tagsLoading bool
 }
 
+// selectedNodeProbeTimeout 选中节点时快速探测的超时时间（比手动测速短，避免长时间等待）
+const selectedNodeProbeTimeout = 2 * time.Second
+
+// nodeListRefreshInterval 节点数据变化时列表刷新的最小间隔
+const nodeListRefreshInterval = 200 * time.Millisecond
+
+// nodeSearchSaveDebounce 搜索关键字停止输入后写入布局配置的延迟，避免每次按键都写库
+const nodeSearchSaveDebounce = 500 * time.Millisecond
+
 // NewNodePage 创建节点管理页面
 func NewNodePage(appState *AppState) *NodePage {
 	np := &NodePage{
 		appState: appState,
 	}
+	if appState != nil && appState.Store != nil && appState.Store.Layout != nil {
+		_, np.pendingOffset = appState.Store.Layout.LastView()
+		// 首次建页时恢复上次选中的地区，之后以 AppState 中的为准
+		if appState.nodeRegionFilter == nil {
+			_, regions := appState.Store.Layout.PageFilter(store.LayoutPageNode)
+			appState.nodeRegionFilter = make(map[string]bool, len(regions))
+			for _, code := range regions {
+				appState.nodeRegionFilter[code] = true
+			}
+		}
+	}
 
-	// 监听 Store 的节点绑定数据变化，自动刷新列表
+	// 监听 Store 的节点绑定数据变化，合并后刷新列表
 	if appState != nil && appState.Store != nil && appState.Store.Nodes != nil {
-		np.listener = binding.NewDataListener(func() {
-			if np.list != nil {
-				np.list.Refresh()
-				// 数据更新后，尝试滚动到选中位置
-				np.scrollToSelected()
-			}
-		})
+		np.bindingRefresh = newRefreshThrottle(nodeListRefreshInterval, np.onNodesChanged)
+		np.listener = binding.NewDataListener(np.bindingRefresh.Trigger)
 		appState.Store.Nodes.NodesBinding.AddListener(np.listener)
 	}
 
@@ -57,6 +112,18 @@ func NewNodePage(appState *AppState) *NodePage {
 
 // Cleanup 释放页面持有的监听器，避免重复建页时旧实例被 binding 持有。
 func (np *NodePage) Cleanup() {
+	if np != nil && np.searchSaveTimer != nil {
+		np.searchSaveTimer.Stop()
+		np.searchSaveTimer = nil
+		np.saveFilter()
+	}
+	if np != nil && np.trafficUnsub != nil {
+		np.trafficUnsub()
+		np.trafficUnsub = nil
+	}
+	if np != nil && np.bindingRefresh != nil {
+		np.bindingRefresh.Stop()
+	}
 	if np == nil || np.listener == nil || np.appState == nil || np.appState.Store == nil || np.appState.Store.Nodes == nil {
 		return
 	}
@@ -82,8 +149,9 @@ func (np *NodePage) loadNodes() {
 // 返回：包含返回按钮、操作按钮和服务器列表的容器组件
 func (np *NodePage) Build() fyne.CanvasObject {
 	pad := innerPadding(np.appState)
+	np.loadColumns()
 	// 1. 返回按钮
-	backBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+	backBtn := newIconButton(np.appState, "返回", theme.NavigateBackIcon(), func() {
 		if np.appState != nil && np.appState.MainWindow != nil {
 			np.appState.MainWindow.Back()
 		}
@@ -99,8 +167,9 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	np.updateSelectedServerLabel()                                // 初始化标签内容
 
 	// 3. 操作按钮组（参考 subscriptionpage 风格）
-	testAllBtn := widget.NewButtonWithIcon("测速", theme.ViewRefreshIcon(), np.onTestAll)
+	testAllBtn := widget.NewButtonWithIcon("测速", theme.ViewRefreshIcon(), func() { np.onTestAll(false) })
 	testAllBtn.Importance = widget.LowImportance
+	disableWhileOffline(np.appState, testAllBtn)
 
 	subscriptionBtn := widget.NewButtonWithIcon("订阅", theme.SettingsIcon(), func() {
 		if np.appState != nil && np.appState.MainWindow != nil {
@@ -109,16 +178,17 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	})
 	subscriptionBtn.Importance = widget.LowImportance
 
+	var columnsBtn *widget.Button
+	columnsBtn = widget.NewButtonWithIcon("列", theme.ListIcon(), func() {
+		np.showColumnMenu(columnsBtn)
+	})
+	columnsBtn.Importance = widget.LowImportance
+
 	// 4. 头部栏布局（返回按钮 + 选中服务器标签 + 操作按钮）
-	// 使用 Border 布局让 labelContainer 自动占满剩余空间
+	// labelContainer 占满中间剩余空间；窗口较窄时换到按钮下方单独一行，同时隐藏地区列
 	labelContainer := newPaddedWithSize(np.selectedServerLabel, pad)
-	rightButtons := container.NewHBox(testAllBtn, subscriptionBtn)
-	headerBar := container.NewBorder(
-		nil, nil, // 上下为空
-		backBtn,        // 左侧：返回按钮
-		rightButtons,   // 右侧：操作按钮组
-		labelContainer, // 中间：选中服务器标签（自动占满剩余空间）
-	)
+	rightButtons := container.NewHBox(testAllBtn, columnsBtn, subscriptionBtn)
+	headerBar := newHeaderRow(nodePageNarrowWidth, np.setNarrow, backBtn, labelContainer, rightButtons)
 
 	// 4. 组合头部区域（添加分隔线，移除 padding 降低高度）
 	separatorColor := CurrentThemeColor(np.appState.App, theme.ColorNameSeparator)
@@ -127,13 +197,19 @@ func (np *NodePage) Build() fyne.CanvasObject {
 		canvas.NewLine(separatorColor),
 	)
 
-	// 5. 搜索框（单独一行，在功能栏下方）
+	// 5. 搜索框（单独一行，在功能栏下方），恢复上次的关键字：主题切换等重建页面或重启后不丢失
 	np.searchEntry = widget.NewEntry()
 	np.searchEntry.SetPlaceHolder("搜索节点名称或地区...")
+	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Layout != nil {
+		search, _ := np.appState.Store.Layout.PageFilter(store.LayoutPageNode)
+		np.searchEntry.SetText(search)
+		np.searchText = strings.ToLower(strings.TrimSpace(search))
+	}
 	np.searchEntry.OnChanged = func(value string) {
 		// 记录小写关键字，便于不区分大小写匹配
 		np.searchText = strings.ToLower(strings.TrimSpace(value))
 		np.Refresh()
+		np.scheduleSaveSearch()
 	}
 	// 支持回车键搜索
 	np.searchEntry.OnSubmitted = func(value string) {
@@ -143,7 +219,7 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	}
 
 	// 搜索按钮（放大镜图标）
-	searchBtn := widget.NewButtonWithIcon("", theme.SearchIcon(), func() {
+	searchBtn := newIconButton(np.appState, "搜索", theme.SearchIcon(), func() {
 		// 触发搜索
 		value := np.searchEntry.Text
 		np.searchText = strings.ToLower(strings.TrimSpace(value))
@@ -158,29 +234,11 @@ func (np *NodePage) Build() fyne.CanvasObject {
 		np.searchEntry, // 移除 padding 降低搜索框高度
 	)
 
-	// 6. 表格头（与列表项对齐，使用最小高度）
-	regionHeader := widget.NewLabel("地区")
-	regionHeader.Alignment = fyne.TextAlignCenter
-	regionHeader.TextStyle = fyne.TextStyle{Bold: true}
-	regionHeader.Importance = widget.MediumImportance
-
-	nameHeader := widget.NewLabel("节点名称")
-	nameHeader.Alignment = fyne.TextAlignLeading
-	nameHeader.TextStyle = fyne.TextStyle{Bold: true}
-	nameHeader.Importance = widget.MediumImportance
-
-	delayHeader := widget.NewLabel("延迟")
-	delayHeader.Alignment = fyne.TextAlignTrailing
-	delayHeader.TextStyle = fyne.TextStyle{Bold: true}
-	delayHeader.Importance = widget.MediumImportance
-
-	// 表头使用与列表项相同的 GridWithColumns(3) 布局，确保对齐
-	// 使用最小 padding 减少高度
-	tableHeader := container.NewGridWithColumns(3,
-		regionHeader, // 地区列（移除 padding 减少高度）
-		nameHeader,   // 名称列
-		delayHeader,  // 延迟列
-	)
+	// 地区筛选条：与搜索关键字同时生效
+	regionChips := np.buildRegionChips()
+
+	// 6. 表格头：与列表项共用 nodeColumnsLayout，按列设置对齐，可拖动列边界调整宽度
+	tableHeader := newPaddedWithSize(np.buildTableHeader(), pad)
 
 	// 7. 节点列表（支持滚动，参考 subscriptionpage）
 	np.list = widget.NewList(
@@ -192,30 +250,116 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	// 包装在滚动容器中并设置最小尺寸确保布局占满
 	np.scrollList = container.NewScroll(np.list)
 
+	// 空状态与加载骨架：与列表叠放，按数据切换显示
+	np.emptyState = NewEmptyState(theme.ListIcon(), "", "", "", nil)
+	np.listState = NewListStateView(np.appState, np.scrollList, np.emptyState)
+
 	// 8. 组合布局：头部 + 搜索栏 + 表头 + 列表
 	// 移除所有不必要的 padding，降低高度
 	np.content = container.NewBorder(
 		container.NewVBox(
 			headerStack,
-			searchBar,   // 移除 padding
-			tableHeader, // 表头直接放置，不添加额外 padding
+			newOfflineBanner(np.appState), // 离线提示，有网络时隐藏
+			searchBar,                     // 移除 padding
+			regionChips,                   // 地区筛选条
+			tableHeader,                   // 表头直接放置，不添加额外 padding
 			canvas.NewLine(separatorColor),
 		),
 		nil, nil, nil,
-		newPaddedWithSize(np.scrollList, pad),
+		newPaddedWithSize(np.listState.Content(), pad),
 	)
 
+	np.loadNodesAsync()
+	np.syncTrafficSubscription()
+
 	return np.content
 }
 
+// scheduleSaveSearch 停止输入 nodeSearchSaveDebounce 后保存搜索关键字。
+func (np *NodePage) scheduleSaveSearch() {
+	if np.searchSaveTimer != nil {
+		np.searchSaveTimer.Stop()
+	}
+	np.searchSaveTimer = time.AfterFunc(nodeSearchSaveDebounce, func() {
+		fyne.Do(func() {
+			np.searchSaveTimer = nil
+			np.saveFilter()
+		})
+	})
+}
+
+// saveFilter 将搜索关键字与选中的地区写入布局配置。
+func (np *NodePage) saveFilter() {
+	if np.appState == nil || np.appState.Store == nil || np.appState.Store.Layout == nil {
+		return
+	}
+	search := ""
+	if np.searchEntry != nil {
+		search = np.searchEntry.Text
+	}
+	regions := make([]string, 0, len(np.appState.nodeRegionFilter))
+	for code := range np.appState.nodeRegionFilter {
+		regions = append(regions, code)
+	}
+	sort.Strings(regions)
+	if err := np.appState.Store.Layout.SavePageFilter(store.LayoutPageNode, search, regions); err != nil {
+		np.appState.AppendLog("WARN", "app", "保存节点筛选失败: "+err.Error())
+	}
+}
+
+// loadNodesAsync 在后台从数据库重新加载节点，加载期间列表为空时显示骨架占位。
+func (np *NodePage) loadNodesAsync() {
+	if np.listState == nil {
+		return
+	}
+	np.listState.SetLoading(true, np.getNodeCount())
+	go func() {
+		np.loadNodes()
+		fyne.Do(func() {
+			np.listState.SetLoading(false, np.getNodeCount())
+			np.syncListState()
+			np.refreshNodeTags()
+		})
+	}()
+}
+
+// syncListState 根据节点总数与搜索结果切换空状态文案与显示。
+func (np *NodePage) syncListState() {
+	if np.listState == nil || np.emptyState == nil {
+		return
+	}
+	total := 0
+	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
+		total = len(np.appState.Store.Nodes.GetAll())
+	}
+	if total == 0 {
+		np.emptyState.Set("还没有节点", "添加订阅或导入分享链接后，节点会显示在这里。", "添加订阅", func() {
+			if np.appState != nil && np.appState.MainWindow != nil {
+				np.appState.MainWindow.ShowSubscriptionPage()
+			}
+		})
+	} else {
+		np.emptyState.Set("没有匹配的节点", "换个关键字或地区试试，支持名称、地址与协议。", "清除筛选", func() {
+			np.clearRegionFilter()
+			if np.searchEntry != nil {
+				np.searchEntry.SetText("")
+			}
+		})
+	}
+	np.listState.Sync(np.getNodeCount())
+}
+
 // Refresh 刷新节点列表的显示，使 UI 反映最新的节点数据。
 func (np *NodePage) Refresh() {
 	np.loadNodes()
 	np.updateSelectedServerLabel() // 更新选中服务器标签
+	np.refreshRegionChips()
+	np.refreshNodeTags()
 	// 绑定数据更新后会自动触发列表刷新，无需手动调用
 	if np.list != nil {
 		np.list.Refresh()
 	}
+	np.syncListState()
 }
 
 // scrollToSelected 滚动到选中的节点位置
@@ -224,6 +368,15 @@ func (np *NodePage) scrollToSelected() {
 		return
 	}
 
+	// 首次显示时恢复上次的滚动位置，代替滚动到选中节点
+	if np.pendingOffset > 0 {
+		if np.list.Size().Height > 0 && np.getNodeCount() > 0 {
+			np.list.ScrollToOffset(np.pendingOffset)
+			np.pendingOffset = 0
+		}
+		return
+	}
+
 	// 获取选中的节点ID
 	selectedID := np.appState.Store.Nodes.GetSelectedID()
 	if selectedID == "" {
@@ -260,39 +413,168 @@ func (np *NodePage) updateSelectedServerLabel() {
 		return
 	}
 
-	// 显示服务器名称
-	np.selectedServerLabel.SetText(selectedNode.Name)
+	// 显示服务器名称（附带快速探测结果）
+	text := selectedNode.Name
+	if np.probeStatus != "" {
+		text = fmt.Sprintf("%s · %s", selectedNode.Name, np.probeStatus)
+	}
+	np.selectedServerLabel.SetText(text)
 	np.selectedServerLabel.Importance = widget.MediumImportance
 }
 
+// probeSelectedNode 在选中节点后、连接前快速探测其可达性，结果显示在选中服务器名后并写入延迟列。
+// 仅在「选中节点时自动测速」开启、未处于低功耗模式且有网络连接时执行；连续切换节点时只保留最后一次的结果。
+func (np *NodePage) probeSelectedNode(node model.Node) {
+	if np.appState == nil || np.appState.Ping == nil || np.appState.ConfigService == nil ||
+		!np.appState.ConfigService.GetAutoProbeSelectedNode() || np.appState.PowerService.LowPower() || isOffline(np.appState) {
+		np.probeStatus = ""
+		return
+	}
+
+	np.probeSeq++
+	seq := np.probeSeq
+	np.probeStatus = "探测中…"
+
+	go func() {
+		delay, err := np.appState.Ping.TestServerDelayWithTimeout(node, selectedNodeProbeTimeout)
+		if err != nil {
+			delay = -1
+			np.appState.AppendLog("WARN", "ping", fmt.Sprintf("选中节点 %s 探测失败: %v", node.Name, err))
+		}
+		np.recordTestResult(&node, delay, false)
+
+		fyne.Do(func() {
+			if seq != np.probeSeq {
+				return
+			}
+			if delay > 0 {
+				np.probeStatus = fmt.Sprintf("%d ms", delay)
+			} else {
+				np.probeStatus = "不可达"
+			}
+			np.updateSelectedServerLabel()
+		})
+	}()
+}
+
+// recordTestResult 通过 ServerService 记录测速结果（delay <= 0 为失败）并写日志。
+// 自动测速（manual 为 false）时返回是否因连续失败禁用了节点；手动测速时返回是否恢复了被自动禁用的节点。
+func (np *NodePage) recordTestResult(node *model.Node, delay int, manual bool) bool {
+	if np.appState == nil || np.appState.ServerService == nil {
+		return false
+	}
+	disabled, restored, err := np.appState.ServerService.RecordTestResult(node.ID, delay, manual)
+	if err != nil {
+		np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新服务器 %s 测速结果失败: %v", node.Name, err))
+		return false
+	}
+	if disabled {
+		np.appState.AppendLog("WARN", "ping", fmt.Sprintf("服务器 %s 连续测试失败，已自动禁用", node.Name))
+	}
+	if restored {
+		np.appState.AppendLog("INFO", "ping", fmt.Sprintf("服务器 %s 手动测速成功，已恢复启用", node.Name))
+	}
+	if manual {
+		return restored
+	}
+	return disabled
+}
+
 // getNodeCount 获取节点数量
 func (np *NodePage) getNodeCount() int {
 	return len(np.getFilteredNodes())
 }
 
-// getFilteredNodes 根据当前搜索关键字返回过滤后的节点列表。
-// 支持按名称、地址、协议类型进行不区分大小写的匹配。
+// onNodesChanged 节点数据变化后刷新列表：过滤结果的顺序未变时只刷新节点数据有变化的行
+// （Store 更新节点时替换为新指针），否则整体刷新。
+func (np *NodePage) onNodesChanged() {
+	if np.list == nil {
+		return
+	}
+	prev := np.shownNodes
+	nodes := np.getFilteredNodes()
+	np.shownNodes = nodes
+	if sameNodeOrder(prev, nodes) {
+		changed := make([]int, 0)
+		for i := range nodes {
+			if prev[i] != nodes[i] {
+				changed = append(changed, i)
+			}
+		}
+		if len(changed) <= len(nodes)/2 {
+			for _, i := range changed {
+				np.list.RefreshItem(i)
+			}
+		} else {
+			np.list.Refresh()
+		}
+	} else {
+		np.list.Refresh()
+	}
+	np.refreshRegionChips()
+	np.syncListState()
+	// 数据更新后，尝试滚动到选中位置
+	np.scrollToSelected()
+}
+
+// sameNodeOrder 判断两次过滤结果是否为相同节点的相同顺序。
+func sameNodeOrder(a, b []*model.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// getFilteredNodes 根据当前搜索关键字与地区筛选返回过滤后的节点列表（结果按节点数据版本缓存）。
+// 支持按名称（含改名前的原始名称）、地址、协议类型进行不区分大小写的匹配。
 func (np *NodePage) getFilteredNodes() []*model.Node {
 	// 从 Store 获取所有节点
 	var allNodes []*model.Node
+	var revision uint64
 	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
+		revision = np.appState.Store.Nodes.Revision()
+		if np.filteredValid && revision == np.filteredRevision &&
+			np.searchText == np.filteredSearch && np.regionFilterGen == np.filteredRegion {
+			return np.filteredNodes
+		}
 		allNodes = np.appState.Store.Nodes.GetAll()
 	} else {
 		allNodes = []*model.Node{}
 	}
+	filtered := np.filterNodes(allNodes)
+	np.filteredNodes, np.filteredRevision, np.filteredSearch, np.filteredRegion = filtered, revision, np.searchText, np.regionFilterGen
+	np.filteredValid = true
+	return filtered
+}
 
-	// 如果没有搜索关键字，直接返回完整列表
-	if np.searchText == "" {
+// filterNodes 按搜索关键字与地区筛选过滤节点。
+func (np *NodePage) filterNodes(allNodes []*model.Node) []*model.Node {
+	// 如果没有搜索关键字与地区筛选，直接返回完整列表
+	if np.searchText == "" && !np.regionFilterActive() {
 		return allNodes
 	}
 
 	filtered := make([]*model.Node, 0, len(allNodes))
 	for _, node := range allNodes {
+		if !np.regionMatches(node) {
+			continue
+		}
+		if np.searchText == "" {
+			filtered = append(filtered, node)
+			continue
+		}
 		name := strings.ToLower(node.Name)
+		originalName := strings.ToLower(node.OriginalName)
 		addr := strings.ToLower(node.Addr)
 		protocol := strings.ToLower(node.ProtocolType)
 
 		if strings.Contains(name, np.searchText) ||
+			strings.Contains(originalName, np.searchText) ||
 			strings.Contains(addr, np.searchText) ||
 			strings.Contains(protocol, np.searchText) {
 			filtered = append(filtered, node)
@@ -325,8 +607,8 @@ func (np *NodePage) updateNodeItem(id widget.ListItemID, obj fyne.CanvasObject)
 	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
 		selectedID = np.appState.Store.Nodes.GetSelectedID()
 	}
-	item.isConnected = (np.appState != nil && np.appState.XrayInstance != nil &&
-		np.appState.XrayInstance.IsRunning() && selectedID == node.ID)
+	item.isConnected = (np.appState != nil && np.appState.XrayInstance() != nil &&
+		np.appState.XrayInstance().IsRunning() && selectedID == node.ID)
 
 	// 使用新的Update方法更新多列信息
 	item.Update(*node)
@@ -351,7 +633,8 @@ func (np *NodePage) onNodeSelected(id widget.ListItemID) {
 		}
 	}
 
-	// 更新选中服务器标签
+	// 按配置快速探测选中节点，然后更新选中服务器标签
+	np.probeSelectedNode(*node)
 	np.updateSelectedServerLabel()
 
 	// 强制刷新列表显示（确保选中状态立即更新）
@@ -390,10 +673,24 @@ func (np *NodePage) onRightClick(id widget.ListItemID, ev *fyne.PointEvent) {
 			// 测速
 			np.onTestSpeed(id)
 		}),
+		fyne.NewMenuItem("与其他节点对比…", func() {
+			showNodeCompareDialog(np.appState, nodes[id])
+		}),
+		fyne.NewMenuItem("高级设置…", func() {
+			showDialOptionsDialog(np.appState, nodes[id])
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("导出此节点…", func() {
+			showNodeExportDialog(np.appState, nodes[id].Name, nodes[id:id+1], nodes[id].Name)
+		}),
+		fyne.NewMenuItem(fmt.Sprintf("导出列表中的 %d 个节点…", len(nodes)), func() {
+			showNodeExportDialog(np.appState, "当前列表", nodes, "")
+		}),
 	}
+	menuItems = append(menuItems, nodeTagMenuItems(np.nodeTags[nodes[id].ID])...)
 
 	// 如果代理正在运行，添加停止选项
-	if np.appState != nil && np.appState.XrayInstance != nil && np.appState.XrayInstance.IsRunning() {
+	if np.appState != nil && np.appState.IsProxyRunning() {
 		menuItems = append(menuItems, fyne.NewMenuItemSeparator())
 		menuItems = append(menuItems, fyne.NewMenuItem("停止代理", func() {
 			// 停止代理
@@ -418,6 +715,9 @@ func (np *NodePage) onTestSpeed(id widget.ListItemID) {
 	}
 
 	node := nodes[id]
+	if guardOffline(np.appState, "测速") {
+		return
+	}
 
 	// 在goroutine中执行测速
 	go func() {
@@ -432,7 +732,9 @@ func (np *NodePage) onTestSpeed(id widget.ListItemID) {
 			if np.appState != nil {
 				np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("服务器 %s 测速失败: %v", node.Name, err))
 			}
+			np.recordTestResult(node, -1, true)
 			fyne.Do(func() {
+				np.Refresh()
 				if np.appState != nil && np.appState.Window != nil {
 					dialog.ShowError(fmt.Errorf("测速失败: %w", err), np.appState.Window)
 				}
@@ -440,14 +742,8 @@ func (np *NodePage) onTestSpeed(id widget.ListItemID) {
 			return
 		}
 
-		// 通过 Store 更新服务器延迟（会自动更新数据库和绑定）
-		if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
-			if err := np.appState.Store.Nodes.UpdateDelay(node.ID, delay); err != nil {
-				if np.appState != nil {
-					np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新延迟失败: %v", err))
-				}
-			}
-		}
+		// 通过 ServerService 记录结果（更新延迟与健康状态；手动测速成功会恢复被自动禁用的节点）
+		restored := np.recordTestResult(node, delay, true)
 
 		// 记录成功日志
 		if np.appState != nil {
@@ -463,6 +759,9 @@ func (np *NodePage) onTestSpeed(id widget.ListItemID) {
 			}
 			if np.appState != nil && np.appState.Window != nil {
 				message := fmt.Sprintf("节点: %s\n延迟: %d ms", node.Name, delay)
+				if restored {
+					message += "\n节点已恢复启用"
+				}
 				dialog.ShowInformation("测速完成", message, np.appState.Window)
 			}
 		})
@@ -576,19 +875,12 @@ func (np *NodePage) StartProxyForSelected() {
 		return
 	}
 
-	if np.appState.XrayControlService == nil {
-		np.logAndShowError("启动代理失败", fmt.Errorf("XrayControlService 未初始化"))
+	if guardOffline(np.appState, "连接代理") {
 		return
 	}
 
-	// 使用统一的日志文件路径（与应用日志使用同一个文件）
-	unifiedLogPath := ""
-	if np.appState.Logger != nil {
-		unifiedLogPath = np.appState.Logger.GetLogFilePath()
-	}
-
-	// 调用 service 启动代理
-	result := np.appState.XrayControlService.StartProxy(np.appState.XrayInstance, unifiedLogPath)
+	// 由应用核心启动代理并持有实例（同时更新 ProxyService、记录日志）
+	result := np.appState.StartProxy()
 
 	if result.Error != nil {
 		np.logAndShowError("启动代理失败", result.Error)
@@ -596,25 +888,6 @@ func (np *NodePage) StartProxyForSelected() {
 		return
 	}
 
-	// 启动成功，更新 AppState 中的 XrayInstance
-	np.appState.XrayInstance = result.XrayInstance
-
-	// 更新 ProxyService 的 xray 实例引用
-	if np.appState.ProxyService != nil {
-		np.appState.ProxyService.UpdateXrayInstance(result.XrayInstance)
-	} else {
-		// 延迟初始化 ProxyService
-		np.appState.ProxyService = service.NewProxyService(result.XrayInstance, np.appState.ConfigService)
-	}
-
-	// 记录日志（统一日志记录）
-	if np.appState.Logger != nil && result.XrayInstance != nil {
-		selectedNode := np.appState.Store.Nodes.GetSelected()
-		if selectedNode != nil {
-			np.appState.Logger.InfoWithType(logging.LogTypeProxy, "xray-core代理已启动: %s (端口: %d)", selectedNode.Name, result.XrayInstance.GetPort())
-		}
-	}
-
 	np.Refresh()
 	// 更新状态绑定（使用双向绑定，UI 会自动更新）
 	np.appState.UpdateProxyStatus()
@@ -640,8 +913,7 @@ func (np *NodePage) logAndShowError(message string, err error) {
 		np.appState.Logger.Error("%s: %v", message, err)
 	}
 	if np.appState != nil && np.appState.Window != nil {
-		errorMsg := fmt.Errorf("%s: %w", message, err)
-		dialog.ShowError(errorMsg, np.appState.Window)
+		showProxyError(message, err, np.appState.Window)
 	}
 }
 
@@ -659,27 +931,14 @@ func (np *NodePage) onStopProxy() {
 		return
 	}
 
-	if np.appState.XrayControlService == nil {
-		np.logAndShowError("停止代理失败", fmt.Errorf("XrayControlService 未初始化"))
-		return
-	}
-
-	// 调用 service 停止代理
-	result := np.appState.XrayControlService.StopProxy(np.appState.XrayInstance)
+	// 由应用核心停止代理并释放实例（生命周期 = 代理运行生命周期）
+	result := np.appState.StopProxy()
 
 	if result.Error != nil {
 		np.logAndShowError("停止代理失败", result.Error)
 		return
 	}
 
-	// 停止成功，销毁实例（生命周期 = 代理运行生命周期）
-	np.appState.XrayInstance = nil
-
-	// 记录日志（统一日志记录）
-	if np.appState.Logger != nil {
-		np.appState.Logger.InfoWithType(logging.LogTypeProxy, "xray-core代理已停止")
-	}
-
 	// 更新状态绑定
 	np.appState.UpdateProxyStatus()
 
@@ -688,13 +947,13 @@ func (np *NodePage) onStopProxy() {
 		np.appState.MainWindow.RefreshMainToggleButton()
 	}
 
-	// 显示成功对话框
-	if np.appState.Window != nil {
-		if result.LogMessage == "代理未运行" {
+	// 显示成功对话框（开启连接报告时显示本次连接的报告）
+	if result.LogMessage == "代理未运行" {
+		if np.appState.Window != nil {
 			dialog.ShowInformation("提示", "代理未运行", np.appState.Window)
-		} else {
-			dialog.ShowInformation("代理停止成功", "代理已停止", np.appState.Window)
 		}
+	} else {
+		showProxyStoppedDialog(np.appState)
 	}
 }
 
@@ -704,32 +963,59 @@ func (np *NodePage) StopProxy() {
 	np.onStopProxy()
 }
 
-// onTestAll 一键测延迟 - 注释功能
-func (np *NodePage) onTestAll() {
+// onTestAll 一键测延迟。force 为 false 时跳过结果有效期内已测过的节点（见设置「一键测速跳过近期测过的节点」），
+// 全部节点都在有效期内时询问是否强制重测。
+func (np *NodePage) onTestAll(force bool) {
+	if guardOffline(np.appState, "测速") {
+		return
+	}
 	// 在goroutine中执行测速
 	go func() {
 		var servers []*database.Node
 		if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
 			servers = np.appState.Store.Nodes.GetAll()
 		}
-		enabledCount := 0
+		var freshWindow time.Duration
+		if !force && np.appState != nil && np.appState.ConfigService != nil {
+			freshWindow = np.appState.ConfigService.GetPingFreshWindow()
+		}
+
+		// 转换为 model.Node 列表，跳过有效期内测过的节点
+		serverList := make([]model.Node, 0, len(servers))
+		skippedCount := 0
 		for _, s := range servers {
-			if s != nil && s.Enabled {
-				enabledCount++
+			if s == nil || !s.Enabled {
+				continue
+			}
+			if freshWindow > 0 && !s.TestedAt.IsZero() && time.Since(s.TestedAt) < freshWindow {
+				skippedCount++
+				continue
 			}
+			serverList = append(serverList, *s)
 		}
 
-		// 记录开始测速日志
-		if np.appState != nil {
-			np.appState.AppendLog("INFO", "ping", fmt.Sprintf("开始一键测速，共 %d 个启用的服务器", enabledCount))
+		if len(serverList) == 0 && skippedCount > 0 {
+			fyne.Do(func() {
+				if np.appState == nil || np.appState.Window == nil {
+					return
+				}
+				message := fmt.Sprintf("%d 个启用的节点都在 %d 分钟内测过速，是否强制全部重新测速？", skippedCount, int(freshWindow.Minutes()))
+				dialog.ShowConfirm("一键测速", message, func(ok bool) {
+					if ok {
+						np.onTestAll(true)
+					}
+				}, np.appState.Window)
+			})
+			return
 		}
 
-		// 转换为 model.Node 列表
-		serverList := make([]model.Node, 0, len(servers))
-		for _, s := range servers {
-			if s != nil && s.Enabled {
-				serverList = append(serverList, *s)
+		// 记录开始测速日志
+		if np.appState != nil {
+			startMsg := fmt.Sprintf("开始一键测速，共 %d 个启用的服务器", len(serverList))
+			if skippedCount > 0 {
+				startMsg += fmt.Sprintf("，跳过 %d 分钟内已测过的 %d 个", int(freshWindow.Minutes()), skippedCount)
 			}
+			np.appState.AppendLog("INFO", "ping", startMsg)
 		}
 
 		// 测试所有服务器延迟
@@ -738,6 +1024,7 @@ func (np *NodePage) onTestAll() {
 		// 统计结果并记录每个服务器的详细日志，同时更新延迟
 		successCount := 0
 		failCount := 0
+		disabledCount := 0
 		for _, srv := range servers {
 			if srv == nil || !srv.Enabled {
 				continue
@@ -746,16 +1033,15 @@ func (np *NodePage) onTestAll() {
 			if !exists {
 				continue
 			}
+			if delay <= 0 {
+				delay = -1
+			}
+			// 通过 ServerService 记录结果（更新延迟与连续失败次数，达到阈值自动禁用）
+			if np.recordTestResult(srv, delay, false) {
+				disabledCount++
+			}
 			if delay > 0 {
 				successCount++
-				// 通过 Store 更新服务器延迟（会自动更新数据库和绑定）
-				if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
-					if err := np.appState.Store.Nodes.UpdateDelay(srv.ID, delay); err != nil {
-						if np.appState != nil {
-							np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新服务器 %s 延迟失败: %v", srv.Name, err))
-						}
-					}
-				}
 				if np.appState != nil {
 					np.appState.AppendLog("INFO", "ping", fmt.Sprintf("服务器 %s (%s:%d) 测速完成: %d ms", srv.Name, srv.Addr, srv.Port, delay))
 				}
@@ -777,6 +1063,12 @@ func (np *NodePage) onTestAll() {
 			np.Refresh()
 			if np.appState != nil && np.appState.Window != nil {
 				message := fmt.Sprintf("测速完成\n成功: %d 个\n失败: %d 个\n共测试: %d 个服务器", successCount, failCount, len(results))
+				if skippedCount > 0 {
+					message += fmt.Sprintf("\n跳过近期已测: %d 个", skippedCount)
+				}
+				if disabledCount > 0 {
+					message += fmt.Sprintf("\n因连续失败自动禁用: %d 个", disabledCount)
+				}
 				dialog.ShowInformation("批量测速完成", message, np.appState.Window)
 			}
 		})
@@ -784,6 +1076,66 @@ func (np *NodePage) onTestAll() {
 }
 
 // rightAlignLayout 将单个子对象右对齐、垂直居中放置（用于延迟列）。
+// delayCell 延迟列单元格：鼠标悬停时改为显示最近一次测速时间（Fyne 没有原生悬停提示，直接在单元格内切换文字）。
+type delayCell struct {
+	widget.BaseWidget
+	text     *canvas.Text
+	delay    string
+	testedAt time.Time
+	hovered  bool
+}
+
+func newDelayCell(text *canvas.Text) *delayCell {
+	c := &delayCell{text: text}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *delayCell) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.New(&rightAlignLayout{}, c.text))
+}
+
+// set 更新延迟文字与测速时间。
+func (c *delayCell) set(delay string, testedAt time.Time) {
+	c.delay, c.testedAt = delay, testedAt
+	c.showText()
+}
+
+func (c *delayCell) showText() {
+	if c.hovered && !c.testedAt.IsZero() {
+		c.text.Text = formatTestedAgo(c.testedAt)
+	} else {
+		c.text.Text = c.delay
+	}
+	c.text.Refresh()
+}
+
+func (c *delayCell) MouseIn(_ *desktop.MouseEvent) {
+	c.hovered = true
+	c.showText()
+}
+
+func (c *delayCell) MouseMoved(_ *desktop.MouseEvent) {}
+
+func (c *delayCell) MouseOut() {
+	c.hovered = false
+	c.showText()
+}
+
+// formatTestedAgo 将测速时间格式化为相对时间，如「3分钟前测」。
+func formatTestedAgo(t time.Time) string {
+	diff := time.Since(t)
+	switch {
+	case diff < time.Minute:
+		return "刚刚测"
+	case diff < time.Hour:
+		return fmt.Sprintf("%d分钟前测", int(diff.Minutes()))
+	case diff < 24*time.Hour:
+		return fmt.Sprintf("%d小时前测", int(diff.Hours()))
+	}
+	return t.Local().Format("01-02 15:04")
+}
+
 type rightAlignLayout struct {
 	minWidth float32
 }
@@ -817,17 +1169,32 @@ func (r rightAlignLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 	return fyne.NewSize(w, objects[0].MinSize().Height)
 }
 
+// nodeRegion 从节点名称中尝试提取地区前缀（例如 "US - LA" -> "US"），无法提取时为 "-"。
+func nodeRegion(name string) string {
+	name = strings.TrimSpace(name)
+	// 使用 "-" 或 空格 作为简单分隔符
+	if idx := strings.Index(name, "-"); idx > 0 {
+		return strings.TrimSpace(name[:idx])
+	} else if idx := strings.Index(name, " "); idx > 0 {
+		return strings.TrimSpace(name[:idx])
+	}
+	return "-"
+}
+
 // ServerListItem 自定义服务器列表项（支持右键菜单和多列显示）
 type ServerListItem struct {
 	widget.BaseWidget
 	id          widget.ListItemID
 	panel       *NodePage
 	appState    *AppState
-	renderObj   fyne.CanvasObject // 渲染对象
-	bgRect      *canvas.Rectangle // 背景矩形（用于动态改变颜色）
-	regionLabel *widget.Label
+	renderObj   fyne.CanvasObject        // 渲染对象
+	bgRect      *canvas.Rectangle        // 背景矩形（用于动态改变颜色）
+	content     *fyne.Container          // 按列设置排列的单元格
+	textCells   map[string]*widget.Label // 地区、协议、端口、速度、流量等文本列
 	nameLabel   *widget.Label
+	tagText     *canvas.Text   // 节点名后按测速历史推断的标签，如「高丢包 · 晚间慢」
 	delayText   *canvas.Text   // 延迟列（按 50/150ms 阈值着色）
+	delayCell   *delayCell     // 延迟列单元格，悬停显示测速时间
 	statusIcon  *widget.Icon   // 在线/离线状态图标
 	menuButton  *widget.Button // 右侧"..."菜单按钮
 	isSelected  bool           // 是否选中
@@ -847,13 +1214,10 @@ func NewServerListItem(panel *NodePage, appState *AppState) *ServerListItem {
 	}
 
 	// 创建标签组件
-	item.regionLabel = widget.NewLabel("")
-	item.regionLabel.Wrapping = fyne.TextTruncate
-	item.regionLabel.Alignment = fyne.TextAlignCenter
-
 	item.nameLabel = widget.NewLabel("")
 	item.nameLabel.Wrapping = fyne.TextTruncate
 	item.nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+	item.tagText = newNodeTagText(appState.App)
 
 	item.delayText = canvas.NewText("", CurrentThemeColor(appState.App, theme.ColorNameForeground))
 	item.delayText.Alignment = fyne.TextAlignTrailing
@@ -873,22 +1237,37 @@ func (s *ServerListItem) setupLayout() fyne.CanvasObject {
 	s.bgRect = canvas.NewRectangle(bgColor)
 	s.bgRect.CornerRadius = 4 // 较小的圆角，适合列表项
 
-	delayCell := container.New(&rightAlignLayout{minWidth: 70}, s.delayText)
-	content := container.NewGridWithColumns(3,
-		s.regionLabel,
-		s.nameLabel,
-		delayCell,
-	)
+	// 单元格与 NodePage.columns 一一对应，由 nodeColumnsLayout 按列设置排列（与表头共用）
+	s.textCells = make(map[string]*widget.Label)
+	cells := make([]fyne.CanvasObject, len(s.panel.columns))
+	for i, c := range s.panel.columns {
+		switch c.ID {
+		case store.NodeColumnName:
+			cells[i] = container.NewBorder(nil, nil, nil, s.tagText, s.nameLabel)
+		case store.NodeColumnDelay:
+			s.delayCell = newDelayCell(s.delayText)
+			cells[i] = s.delayCell
+		default:
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.Alignment = nodeColumnAlignment(c.ID)
+			s.textCells[c.ID] = label
+			cells[i] = label
+		}
+	}
+	s.content = container.New(&nodeColumnsLayout{np: s.panel}, cells...)
 
 	// 使用 Stack 布局：背景 + 内容
 	// 移除 padding，删除列表项之间的间距
 	// 使用 Padded 确保内容区域可点击
-	return container.NewStack(s.bgRect, newPaddedWithSize(content, innerPadding(s.appState)))
+	_, pad := listRowMetrics(s.appState)
+	return container.NewStack(s.bgRect, newPaddedWithSize(s.content, pad))
 }
 
-// MinSize 返回列表项的最小尺寸（设置行高为52px，符合UI改进建议：48-56px）
+// MinSize 返回列表项的最小尺寸，行高按列表密度取 52/40/32px（宽松档符合 48-56px 的建议）
 func (s *ServerListItem) MinSize() fyne.Size {
-	return fyne.NewSize(0, 52)
+	h, _ := listRowMetrics(s.appState)
+	return fyne.NewSize(0, h)
 }
 
 // CreateRenderer 创建渲染器（参考 SubscriptionCard）
@@ -924,8 +1303,8 @@ func (s *ServerListItem) Update(server model.Node) {
 			if s.panel.appState.Store != nil && s.panel.appState.Store.Nodes != nil {
 				selectedID = s.panel.appState.Store.Nodes.GetSelectedID()
 			}
-			s.isConnected = (s.panel.appState.XrayInstance != nil &&
-				s.panel.appState.XrayInstance.IsRunning() &&
+			s.isConnected = (s.panel.appState.XrayInstance() != nil &&
+				s.panel.appState.XrayInstance().IsRunning() &&
 				selectedID == server.ID)
 		}
 
@@ -943,18 +1322,10 @@ func (s *ServerListItem) Update(server model.Node) {
 			s.bgRect.Refresh()
 		}
 
-		// 地区：从名称中尝试提取前缀（例如 "US - LA" -> "US"）
-		region := "-"
-		if server.Name != "" {
-			nameLower := strings.TrimSpace(server.Name)
-			// 使用 "-" 或 空格 作为简单分隔符
-			if idx := strings.Index(nameLower, "-"); idx > 0 {
-				region = strings.TrimSpace(nameLower[:idx])
-			} else if idx := strings.Index(nameLower, " "); idx > 0 {
-				region = strings.TrimSpace(nameLower[:idx])
-			}
+		// 文本列：地区、协议、端口、速度、流量
+		for id, label := range s.textCells {
+			label.SetText(s.panel.nodeColumnText(id, server, s.isConnected))
 		}
-		s.regionLabel.SetText(region)
 
 		// 服务器名称（带选中标记和连接状态）
 		prefix := ""
@@ -967,13 +1338,22 @@ func (s *ServerListItem) Update(server model.Node) {
 		} else {
 			s.nameLabel.TextStyle = fyne.TextStyle{Bold: false}
 		}
-		if !server.Enabled {
+		if !server.Enabled && server.DisabledReason != "" {
+			// 因连续失败被自动禁用：手动测速成功后恢复
+			prefix += fmt.Sprintf("[%s 起禁用：%s] ", server.DisabledAt.Local().Format("01-02 15:04"), server.DisabledReason)
+			s.nameLabel.Importance = widget.LowImportance
+		} else if !server.Enabled {
 			prefix += "[禁用] "
 			s.nameLabel.Importance = widget.LowImportance
 		} else {
 			s.nameLabel.Importance = widget.MediumImportance
 		}
 		s.nameLabel.SetText(prefix + server.Name)
+		if s.panel != nil {
+			s.tagText.Text = nodeTagLabel(s.panel.nodeTags[server.ID])
+			s.tagText.Color = CurrentThemeColor(s.appState.App, theme.ColorNamePlaceHolder)
+			s.tagText.Refresh()
+		}
 
 		// 延迟 - 按 0-60ms 绿 / 60-150ms 黄 / >150ms 红 / 超时或未测速 灰 着色
 		delayDisplay := "未测速"
@@ -982,9 +1362,13 @@ func (s *ServerListItem) Update(server model.Node) {
 		} else if server.Delay < 0 {
 			delayDisplay = "测试失败"
 		}
-		s.delayText.Text = delayDisplay
 		s.delayText.Color = DelayColor(s.appState.App, server.Delay)
-		s.delayText.Refresh()
+		if s.delayCell != nil {
+			s.delayCell.set(delayDisplay, server.TestedAt)
+		} else {
+			s.delayText.Text = delayDisplay
+			s.delayText.Refresh()
+		}
 
 		// 更新在线/离线状态图标
 		if s.statusIcon != nil {
@@ -1000,6 +1384,11 @@ func (s *ServerListItem) Update(server model.Node) {
 			}
 		}
 
+		// 列显示或宽度可能已变化，重新排列单元格
+		if s.content != nil {
+			s.content.Refresh()
+		}
+
 		// 设置菜单按钮的点击事件（快速操作菜单）
 		if s.menuButton != nil && s.panel != nil {
 			s.menuButton.OnTapped = func() {
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"myproxy.com/p/internal/service"
+)
+
+// syncConnectionMonitor 代理状态变化后同步连接监控：新会话开始监控并报告连接，代理停止时结束监控。
+func (a *AppState) syncConnectionMonitor(newSession bool) {
+	if a.ConnectionMonitor == nil {
+		return
+	}
+	if !a.IsProxyRunning() {
+		a.ConnectionMonitor.Unwatch()
+		return
+	}
+	if !newSession {
+		return
+	}
+	nodeName := ""
+	if a.Store != nil && a.Store.Nodes != nil {
+		if n := a.Store.Nodes.GetSelected(); n != nil {
+			nodeName = n.Name
+		}
+	}
+	a.ConnectionMonitor.Watch(a.XrayInstance(), nodeName)
+}
+
+// handleConnectionEvent 记录连接事件，并按设置中对应的开关发送系统通知；可在任意 goroutine 中调用。
+func (a *AppState) handleConnectionEvent(event service.ConnectionEvent) {
+	var (
+		title, content, level string
+		enabled               bool
+	)
+	cs := a.ConfigService
+	switch event.Kind {
+	case service.ConnectionEventConnected:
+		title, level = "代理已连接", "INFO"
+		content = "节点: " + event.Node
+		if event.Restored {
+			title, content = "连接已恢复", "经代理访问外网已恢复正常"
+		}
+		enabled = cs != nil && cs.GetNotifyOnConnect()
+	case service.ConnectionEventDisconnected:
+		title, level = "连接中断", "WARN"
+		content = "代理仍在运行，但经代理无法访问外网: " + event.Detail
+		enabled = cs != nil && cs.GetNotifyOnDisconnect()
+	case service.ConnectionEventFailover:
+		title, level = "已自动切换节点", "WARN"
+		content = "负载均衡组已从 " + event.Detail + " 切换到 " + event.Node
+		enabled = cs != nil && cs.GetNotifyOnFailover()
+	default:
+		return
+	}
+
+	// 连接成功的提示已由启动流程记录，仅恢复时补记日志
+	if event.Kind != service.ConnectionEventConnected || event.Restored {
+		a.AppendLog(level, "app", title+": "+content)
+	}
+	if enabled && a.App != nil {
+		notification := fyne.NewNotification(title, content)
+		fyne.Do(func() { a.App.SendNotification(notification) })
+	}
+}
+
+// syncXraySupervisor 代理状态变化后同步 xray 守护：运行中的实例开始守护，代理停止时结束。
+// 手动编辑的配置可能不在本地入站端口监听，不做守护。
+func (a *AppState) syncXraySupervisor() {
+	if a.XraySupervisor == nil {
+		return
+	}
+	if !a.IsProxyRunning() {
+		a.XraySupervisor.Unwatch()
+		return
+	}
+	if a.XrayControlService != nil {
+		if _, custom := a.XrayControlService.CurrentConfig(false); custom {
+			a.XraySupervisor.Unwatch()
+			return
+		}
+	}
+	a.XraySupervisor.Watch(a.XrayInstance())
+}
+
+// handleSupervisorEvent 处理 xray 守护事件：实例停止时把界面切换为未连接，记录日志并按中断通知开关发送系统通知。
+// 在守护 goroutine 中调用。
+func (a *AppState) handleSupervisorEvent(event service.SupervisorEvent) {
+	var title, content, level string
+	switch event.Kind {
+	case service.SupervisorEventDied:
+		title, level = "代理已停止", "ERROR"
+		content = "xray 意外停止: " + event.Detail
+		if a.ConfigService != nil && a.ConfigService.GetXrayAutoRestart() {
+			content += "，正在尝试自动重启"
+		} else {
+			a.endSessionReport()
+		}
+		fyne.Do(func() {
+			// 实例已由守护停止；界面仍引用它时清除，使状态、托盘与主开关显示未连接
+			if a.ReleaseStoppedInstance() {
+				a.UpdateProxyStatus()
+				if a.MainWindow != nil {
+					a.MainWindow.RefreshMainToggleButton()
+				}
+			}
+		})
+	case service.SupervisorEventRestarted:
+		title, level = "代理已自动重启", "INFO"
+		content = fmt.Sprintf("xray 意外停止后第 %d 次重启成功", event.Attempt)
+	case service.SupervisorEventGaveUp:
+		title, level = "自动重启失败", "ERROR"
+		a.endSessionReport()
+		content = fmt.Sprintf("已尝试 %d 次仍无法启动 xray，请检查节点与设置后手动连接", event.Attempt)
+		if event.Detail != "" {
+			content += ": " + event.Detail
+		}
+	default:
+		return
+	}
+
+	a.AppendLog(level, "app", title+": "+content)
+	if a.App != nil && a.ConfigService != nil && a.ConfigService.GetNotifyOnDisconnect() {
+		notification := fyne.NewNotification(title, content)
+		fyne.Do(func() { a.App.SendNotification(notification) })
+	}
+}
+
+// restartCrashedProxy 供 xray 守护自动重启：在 UI 线程按当前选中节点启动代理并更新状态，不弹出错误对话框。
+// 用户已手动重新连接时直接返回成功。
+func (a *AppState) restartCrashedProxy() error {
+	var err error
+	fyne.DoAndWait(func() {
+		if a.IsProxyRunning() {
+			return
+		}
+		if result := a.StartProxy(); result.Error != nil {
+			err = result.Error
+			return
+		}
+		a.UpdateProxyStatus()
+		if a.MainWindow != nil {
+			a.MainWindow.RefreshMainToggleButton()
+		}
+	})
+	return err
+}
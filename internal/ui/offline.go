@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// offlineHint 离线提示横幅与对话框共用的说明
+const offlineHint = "网络未连接，连接与测速已暂停。请检查 Wi‑Fi 或网线，网络恢复后会自动继续。"
+
+// watchNetworkStatus 订阅网络连接状态：变化时更新离线绑定（在 UI 线程）并写日志，随后开始检测。
+func (a *AppState) watchNetworkStatus() {
+	if a.NetworkStatus == nil {
+		return
+	}
+	a.NetworkStatus.Subscribe(func(online bool) {
+		if online {
+			a.AppendLog("INFO", "app", "网络已恢复")
+		} else {
+			a.AppendLog("WARN", "app", "网络未连接：暂停连接与测速，恢复后自动继续")
+		}
+		fyne.Do(func() { _ = a.offlineBinding().Set(!online) })
+	})
+	a.NetworkStatus.Start()
+}
+
+// offlineBinding 离线状态绑定，供横幅显示与按钮停用；未检测时为 false。
+func (a *AppState) offlineBinding() binding.Bool {
+	if a.offline == nil {
+		a.offline = binding.NewBool()
+	}
+	return a.offline
+}
+
+// isOffline 当前是否没有网络连接。
+func isOffline(a *AppState) bool {
+	return a != nil && a.NetworkStatus != nil && !a.NetworkStatus.Online()
+}
+
+// newOfflineBanner 创建离线提示横幅：有网络时隐藏，离线时显示在页面顶部。
+func newOfflineBanner(a *AppState) fyne.CanvasObject {
+	label := widget.NewLabel(offlineHint)
+	label.Wrapping = fyne.TextWrapWord
+	label.Importance = widget.WarningImportance
+	banner := container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), nil, label)
+	banner.Hide()
+	if a == nil {
+		return banner
+	}
+	offline := a.offlineBinding()
+	offline.AddListener(binding.NewDataListener(func() {
+		if v, _ := offline.Get(); v {
+			banner.Show()
+		} else {
+			banner.Hide()
+		}
+	}))
+	return banner
+}
+
+// disableWhileOffline 离线时停用控件（如测速按钮），网络恢复后重新启用。
+func disableWhileOffline(a *AppState, w fyne.Disableable) {
+	if a == nil {
+		return
+	}
+	offline := a.offlineBinding()
+	offline.AddListener(binding.NewDataListener(func() {
+		if v, _ := offline.Get(); v {
+			w.Disable()
+		} else {
+			w.Enable()
+		}
+	}))
+}
+
+// guardOffline 离线时提示 action（如「连接代理」「测速」）需要网络并返回 true，调用方应直接返回。
+func guardOffline(a *AppState, action string) bool {
+	if !isOffline(a) {
+		return false
+	}
+	if a.Window != nil {
+		dialog.ShowInformation("网络未连接", action+"需要网络连接。\n"+offlineHint, a.Window)
+	}
+	return true
+}
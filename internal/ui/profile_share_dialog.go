@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+// showShareProfileDialog 生成分享配置链接（直连路由与订阅），可选择是否包含订阅凭据。
+func showShareProfileDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.ProfileShareService == nil {
+		return
+	}
+	linkEntry := widget.NewMultiLineEntry()
+	linkEntry.Wrapping = fyne.TextWrapBreak
+	linkEntry.SetMinRowsVisible(5)
+	hintLabel := widget.NewLabel("")
+	hintLabel.Wrapping = fyne.TextWrapWord
+
+	credentialsCheck := widget.NewCheck("包含订阅凭据（完整订阅地址）", nil)
+	generate := func() {
+		link, err := appState.ProfileShareService.Export(credentialsCheck.Checked)
+		if err != nil {
+			dialog.ShowError(err, appState.Window)
+			return
+		}
+		linkEntry.SetText(link)
+		if credentialsCheck.Checked {
+			hintLabel.SetText("链接包含完整订阅地址，拿到链接的人可以使用你的订阅，请只发给信任的同事。")
+		} else {
+			hintLabel.SetText("订阅地址只保留服务商主机，对方导入时需自行获取订阅链接；直连路由与过滤、改名规则照常导入。")
+		}
+	}
+	credentialsCheck.OnChanged = func(bool) { generate() }
+	generate()
+
+	copyBtn := widget.NewButtonWithIcon("复制链接", theme.ContentCopyIcon(), func() {
+		if appState.ClipboardMonitor != nil {
+			appState.ClipboardMonitor.IgnoreContent(linkEntry.Text)
+		}
+		appState.Window.Clipboard().SetContent(linkEntry.Text)
+		appState.AppendLog("INFO", "app", "已复制分享配置链接")
+	})
+	copyBtn.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		widget.NewLabel("把链接发给同事，对方在应用中打开或复制后即可导入路由规则与订阅。"),
+		credentialsCheck,
+		linkEntry,
+		hintLabel,
+		container.NewHBox(copyBtn),
+	)
+	d := dialog.NewCustom("分享配置", "关闭", content, appState.Window)
+	d.Resize(fyne.NewSize(480, 360))
+	d.Show()
+}
+
+// showImportProfileDialog 解码分享配置并展示内容，确认后在后台导入并显示结果。
+func showImportProfileDialog(appState *AppState, data string, onDone func()) {
+	if appState == nil || appState.Window == nil || appState.ProfileShareService == nil {
+		return
+	}
+	profile, err := service.DecodeShareProfile(data)
+	if err != nil {
+		dialog.ShowError(err, appState.Window)
+		return
+	}
+
+	lines := []string{fmt.Sprintf("直连路由 %d 条（与本地列表合并）", len(profile.DirectRoutes))}
+	if profile.DirectRoutesUseProxy {
+		lines = append(lines, "直连列表走代理：开启")
+	}
+	if profile.BypassLanAndCN {
+		lines = append(lines, "绕过局域网与中国大陆：开启")
+	} else {
+		lines = append(lines, "绕过局域网与中国大陆：关闭")
+	}
+	if len(profile.Subscriptions) > 0 {
+		var names []string
+		for _, sub := range profile.Subscriptions {
+			name := sub.Label
+			if name == "" {
+				name = sub.URL
+			}
+			if sub.Redacted {
+				name += "（不含凭据）"
+			}
+			names = append(names, name)
+		}
+		lines = append(lines, fmt.Sprintf("订阅 %d 个: %s", len(profile.Subscriptions), strings.Join(names, "、")))
+	}
+	summary := widget.NewLabel(strings.Join(lines, "\n"))
+	summary.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("导入分享配置", "导入", "取消", summary, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			result, err := appState.ProfileShareService.Import(profile)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, appState.Window)
+					return
+				}
+				appState.AppendLog("INFO", "app", "已导入分享配置: "+strings.ReplaceAll(result.Summary(), "\n", "；"))
+				if result.RoutesChanged && appState.MainWindow != nil {
+					appState.MainWindow.RestartXrayIfRunning("分享配置中的路由规则")
+				}
+				if onDone != nil {
+					onDone()
+				}
+				dialog.ShowInformation("导入分享配置", result.Summary(), appState.Window)
+			})
+		}()
+	}, appState.Window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
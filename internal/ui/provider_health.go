@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/analytics"
+	"myproxy.com/p/internal/service"
+)
+
+// 服务商概览的排序方式
+var providerSortOptions = []string{"按订阅顺序", "按可用率", "按平均延迟"}
+
+// ProviderHealthPage 服务商概览页：每个订阅的节点可用率、平均延迟、流量余量、更新状态与近期趋势，便于比较不同服务商。
+// 数据来自已保存的测速结果与订阅响应，进入页面或点「刷新」时重新汇总，不发起测速。
+type ProviderHealthPage struct {
+	appState   *AppState
+	list       *fyne.Container
+	statusText *widget.Label
+	sortSelect *widget.Select
+	health     []service.ProviderHealth
+}
+
+// NewProviderHealthPage 创建服务商概览页。
+func NewProviderHealthPage(appState *AppState) *ProviderHealthPage {
+	return &ProviderHealthPage{appState: appState}
+}
+
+// Build 构建页面并在后台加载数据。
+func (pp *ProviderHealthPage) Build() fyne.CanvasObject {
+	pad := innerPadding(pp.appState)
+	backBtn := newIconButton(pp.appState, "返回", theme.NavigateBackIcon(), func() {
+		if pp.appState != nil && pp.appState.MainWindow != nil {
+			pp.appState.MainWindow.Back()
+		}
+	})
+	backBtn.Importance = widget.LowImportance
+
+	title := widget.NewLabelWithStyle("服务商概览", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	pp.sortSelect = widget.NewSelect(providerSortOptions, func(string) { pp.render() })
+	pp.sortSelect.SetSelectedIndex(0)
+	refreshBtn := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), pp.load)
+	refreshBtn.Importance = widget.LowImportance
+
+	headerBar := container.NewHBox(backBtn, title, layout.NewSpacer(), pp.sortSelect, refreshBtn)
+	separatorColor := CurrentThemeColor(pp.appState.App, theme.ColorNameSeparator)
+	headerStack := container.NewVBox(
+		newPaddedWithSize(headerBar, pad),
+		canvas.NewLine(separatorColor),
+	)
+
+	pp.statusText = widget.NewLabel("加载中…")
+	pp.statusText.Wrapping = fyne.TextWrapWord
+	pp.statusText.Importance = widget.LowImportance
+	pp.list = container.NewVBox()
+
+	content := container.NewBorder(
+		headerStack,
+		nil, nil, nil,
+		container.NewVScroll(newPaddedWithSize(container.NewVBox(pp.statusText, pp.list), pad)),
+	)
+	pp.load()
+	return content
+}
+
+// load 在后台汇总各订阅的健康概况后刷新列表。
+func (pp *ProviderHealthPage) load() {
+	if pp.appState == nil || pp.appState.SubscriptionService == nil {
+		return
+	}
+	go func() {
+		health, err := pp.appState.SubscriptionService.ProviderHealth()
+		fyne.Do(func() {
+			if err != nil {
+				pp.health = nil
+				pp.statusText.SetText("读取服务商数据失败: " + err.Error())
+				pp.list.RemoveAll()
+				return
+			}
+			pp.health = health
+			pp.render()
+		})
+	}()
+}
+
+// render 按当前排序方式重建服务商卡片。
+func (pp *ProviderHealthPage) render() {
+	if pp.list == nil || pp.statusText == nil {
+		return
+	}
+	pp.list.RemoveAll()
+	if len(pp.health) == 0 {
+		pp.statusText.SetText("还没有订阅。添加订阅并测速后，这里会汇总各服务商的节点可用率与延迟。")
+		pp.list.Refresh()
+		return
+	}
+	pp.statusText.SetText(fmt.Sprintf("共 %d 个订阅。可用率与延迟取各节点最近一次测速结果，趋势为近 %d 天的测速历史。", len(pp.health), analytics.TrendDays))
+
+	sorted := append([]service.ProviderHealth(nil), pp.health...)
+	switch pp.sortSelect.Selected {
+	case providerSortOptions[1]:
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].AliveRate() > sorted[j].AliveRate() })
+	case providerSortOptions[2]:
+		// 没有可用节点的排在最后
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := sorted[i].AvgDelay, sorted[j].AvgDelay
+			return a > 0 && (b == 0 || a < b)
+		})
+	}
+	for _, h := range sorted {
+		pp.list.Add(pp.buildCard(h))
+	}
+	pp.list.Refresh()
+}
+
+// buildCard 创建一个服务商的概况卡片。
+func (pp *ProviderHealthPage) buildCard(h service.ProviderHealth) fyne.CanvasObject {
+	name := widget.NewLabelWithStyle(h.Subscription.Label, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	if h.Subscription.Label == "" {
+		name.SetText(truncateDisplayText(h.Subscription.URL, 40))
+	}
+
+	nodes := widget.NewLabel(providerNodesText(h))
+	nodes.Wrapping = fyne.TextWrapWord
+	quota := widget.NewLabel(providerQuotaText(h))
+	quota.Wrapping = fyne.TextWrapWord
+	status := widget.NewLabel(providerUpdateText(h))
+	status.Wrapping = fyne.TextWrapWord
+	if h.UpdateFailed() {
+		status.Importance = widget.DangerImportance
+	} else {
+		status.Importance = widget.LowImportance
+	}
+
+	info := container.NewVBox(name, nodes, quota, status)
+	chart := NewMetricChart(pp.appState, fmt.Sprintf("近 %d 天平均延迟", analytics.TrendDays), CurrentThemeColor(pp.appState.App, theme.ColorNamePrimary))
+	points, rate := providerTrend(h.Trend)
+	current := "暂无测速历史"
+	if rate >= 0 {
+		current = fmt.Sprintf("成功率 %.0f%%", rate*100)
+	}
+	chart.SetData(points, current)
+
+	bg := canvas.NewRectangle(CurrentThemeColor(pp.appState.App, theme.ColorNameInputBackground))
+	bg.CornerRadius = theme.InputRadiusSize()
+	body := container.NewBorder(nil, nil, nil, container.NewGridWrap(fyne.NewSize(260, 120), chart), info)
+	return container.NewStack(bg, newPaddedWithSize(body, innerPadding(pp.appState)))
+}
+
+// providerNodesText 节点数、可用率与平均延迟，如「24 个节点 · 可用 20/22（91%）· 平均 180 ms」。
+func providerNodesText(h service.ProviderHealth) string {
+	text := fmt.Sprintf("%d 个节点", h.Nodes)
+	if h.Tested == 0 {
+		return text + " · 尚未测速"
+	}
+	text += fmt.Sprintf(" · 可用 %d/%d（%.0f%%）", h.Alive, h.Tested, h.AliveRate()*100)
+	if h.Alive > 0 {
+		text += fmt.Sprintf(" · 平均 %d ms", h.AvgDelay)
+	}
+	if untested := h.Nodes - h.Tested; untested > 0 {
+		text += fmt.Sprintf(" · %d 个未测速", untested)
+	}
+	return text
+}
+
+// providerQuotaText 流量余量与到期时间；服务商未在响应头中提供时说明原因。
+func providerQuotaText(h service.ProviderHealth) string {
+	q := h.Quota
+	if q == nil {
+		return "流量：服务商未提供"
+	}
+	text := "流量：已用 " + formatBytes(uint64(q.Used()))
+	if remaining := q.Remaining(); remaining >= 0 {
+		text = fmt.Sprintf("流量：剩余 %s / 共 %s", formatBytes(uint64(remaining)), formatBytes(uint64(q.Total)))
+	}
+	if !q.Expire.IsZero() {
+		days := int(time.Until(q.Expire).Hours() / 24)
+		if q.Expire.Before(time.Now()) {
+			text += " · 已于 " + q.Expire.Format("2006-01-02") + " 到期"
+		} else {
+			text += fmt.Sprintf(" · %s 到期（剩 %d 天）", q.Expire.Format("2006-01-02"), days)
+		}
+	}
+	return text
+}
+
+// providerUpdateText 最近一次更新的结果。
+func providerUpdateText(h service.ProviderHealth) string {
+	sub := h.Subscription
+	if h.UpdateFailed() {
+		return fmt.Sprintf("更新失败（%s）：%s", sub.LastErrorAt.Format("01-02 15:04"), sub.LastError)
+	}
+	if sub.UpdatedAt.IsZero() {
+		return "从未更新"
+	}
+	return "更新成功 · " + sub.UpdatedAt.Format("2006-01-02 15:04")
+}
+
+// providerTrend 返回有测速的日期的平均延迟（供趋势图使用）与整段时间的测速成功率，没有测速时成功率为 -1。
+func providerTrend(trend []analytics.DayHealth) ([]float64, float64) {
+	var points []float64
+	samples, failed := 0, 0
+	for _, d := range trend {
+		samples += d.Samples
+		failed += d.Failed
+		if d.AvgDelay > 0 {
+			points = append(points, float64(d.AvgDelay))
+		}
+	}
+	if samples == 0 {
+		return points, -1
+	}
+	return points, float64(samples-failed) / float64(samples)
+}
@@ -7,45 +7,25 @@ import (
 	"image/color"
 	"image/png"
 	"math"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
 )
 
+// iconKey identifies a rendered icon: pixel size plus the variant it was drawn for.
+type iconKey struct {
+	size    int
+	variant fyne.ThemeVariant
+}
+
+// iconCache holds rendered icons in memory for the process lifetime. Entries are keyed by
+// variant, so a theme switch simply picks (or renders) the other entry; nothing is written to disk.
 var (
-	trayIconCache  fyne.Resource
-	appIconCache   fyne.Resource
+	iconCache      = make(map[iconKey]fyne.Resource)
 	iconCacheMutex sync.Mutex
 )
 
-// getIconDir returns the asset directory, compatible with both `go run` and `go build`.
-func getIconDir() string {
-	exec, err := os.Executable()
-	if err != nil {
-		wd, _ := os.Getwd()
-		return filepath.Join(wd, "assets")
-	}
-	dir := filepath.Dir(exec)
-	if strings.Contains(strings.ToLower(dir), "go-build") {
-		if wd, err := os.Getwd(); err == nil {
-			return filepath.Join(wd, "assets")
-		}
-	}
-	return filepath.Join(dir, "assets")
-}
-
-// ClearIconCaches clears cached icons; call this after a theme change.
-func ClearIconCaches() {
-	iconCacheMutex.Lock()
-	defer iconCacheMutex.Unlock()
-	trayIconCache = nil
-	appIconCache = nil
-}
-
 // resolveVariant converts the AppState theme setting to a fyne.ThemeVariant.
 func resolveVariant(appState *AppState) fyne.ThemeVariant {
 	if appState == nil {
@@ -80,60 +60,49 @@ func iconRasterVariant(appState *AppState) fyne.ThemeVariant {
 	return theme.VariantLight
 }
 
-// createAppIcon returns the 228×228 window icon (cached after first call).
+// createAppIcon returns the 228×228 window icon for the current theme.
 func createAppIcon(appState *AppState) fyne.Resource {
-	iconCacheMutex.Lock()
-	defer iconCacheMutex.Unlock()
-	if appIconCache == nil {
-		v := iconRasterVariant(appState)
-		name := fmt.Sprintf("app-icon-v3-%s.png", iconVariantSuffix(v))
-		appIconCache = buildIcon(228, name, v)
-	}
-	return appIconCache
+	return themedIcon(appState, 228)
 }
 
-// createTrayIconResource returns the 32×32 system tray icon (cached after first call).
+// createTrayIconResource returns the 32×32 system tray icon for the current theme.
 func createTrayIconResource(appState *AppState) fyne.Resource {
-	iconCacheMutex.Lock()
-	defer iconCacheMutex.Unlock()
-	if trayIconCache == nil {
-		v := iconRasterVariant(appState)
-		name := fmt.Sprintf("tray-icon-v3-%s.png", iconVariantSuffix(v))
-		trayIconCache = buildIcon(32, name, v)
-	}
-	return trayIconCache
+	return themedIcon(appState, 32)
 }
 
 // createHomeLogo returns the 32×32 home-page logo for the current theme.
+// It is the same pixmap as the tray icon, so both share one cache entry.
 func createHomeLogo(appState *AppState) fyne.Resource {
-	drawV := iconRasterVariant(appState)
-	varStr := "dark"
-	if drawV == theme.VariantLight {
-		varStr = "light"
+	return themedIcon(appState, 32)
+}
+
+// themedIcon returns the icon of the given size for the current theme, rendering it on first use.
+func themedIcon(appState *AppState, size int) fyne.Resource {
+	key := iconKey{size: size, variant: iconRasterVariant(appState)}
+	iconCacheMutex.Lock()
+	defer iconCacheMutex.Unlock()
+	if icon, ok := iconCache[key]; ok {
+		return icon
 	}
-	themeStr := "dark"
-	if appState != nil {
-		themeStr = string(appState.GetTheme())
+	icon := buildIcon(appState, key)
+	if icon != nil {
+		iconCache[key] = icon
 	}
-	name := fmt.Sprintf("home-logo-v3-%s-draw-%s.png", themeStr, varStr)
-	return buildIcon(32, name, drawV)
+	return icon
 }
 
-// buildIcon loads an icon from the disk cache, or renders and saves it.
-func buildIcon(size int, name string, variant fyne.ThemeVariant) fyne.Resource {
-	path := filepath.Join(getIconDir(), name)
-	if data, err := os.ReadFile(path); err == nil {
-		return fyne.NewStaticResource(name, data)
-	}
-	img := renderIcon(size, variant)
+// buildIcon renders and PNG-encodes an icon in memory.
+// Failures are reported through the app logger when one is available.
+func buildIcon(appState *AppState, key iconKey) fyne.Resource {
+	name := fmt.Sprintf("icon-%d-%s.png", key.size, iconVariantSuffix(key.variant))
+	img := renderIcon(key.size, key.variant)
 	var buf bytes.Buffer
 	if err := png.Encode(&buf, img); err != nil {
-		fmt.Printf("png encode failed (%s): %v\n", name, err)
+		if appState != nil && appState.SafeLogger != nil {
+			appState.SafeLogger.Error(fmt.Sprintf("图标编码失败 (%s): %v", name, err))
+		}
 		return nil
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
-		_ = os.WriteFile(path, buf.Bytes(), 0644)
-	}
 	return fyne.NewStaticResource(name, buf.Bytes())
 }
 
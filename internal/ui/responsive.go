@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+)
+
+// 各页面的最小窗口尺寸：窗口不能缩到比当前页面更小，避免控件被截断。
+// 节点页与设置页在窄于断点时改为紧凑布局（见 nodePageNarrowWidth、settingsNarrowWidth），最小宽度按紧凑布局取值。
+var pageMinSizes = map[PageType]fyne.Size{
+	PageTypeHome:         fyne.NewSize(320, 420),
+	PageTypeNode:         fyne.NewSize(320, 360),
+	PageTypeSettings:     fyne.NewSize(340, 400),
+	PageTypeSubscription: fyne.NewSize(320, 360),
+	PageTypeProviders:    fyne.NewSize(340, 360),
+	PageTypeUsage:        fyne.NewSize(340, 400),
+}
+
+// pageMinSize 返回页面的最小窗口尺寸，未配置的页面不限制。
+func pageMinSize(page PageType) fyne.Size {
+	return pageMinSizes[page]
+}
+
+// responsiveState 记录容器当前是否处于窄布局；宽度跨过断点时刷新所属容器，
+// 使其按新布局重新计算最小尺寸，并通知 onChange（在 UI 线程调用）。
+type responsiveState struct {
+	breakpoint float32
+	narrow     bool
+	owner      fyne.CanvasObject
+	onChange   func(narrow bool)
+}
+
+// update 按布局宽度更新窄布局状态，返回当前是否为窄布局。
+func (s *responsiveState) update(width float32) bool {
+	narrow := width > 0 && width < s.breakpoint
+	if narrow == s.narrow {
+		return narrow
+	}
+	s.narrow = narrow
+	// 布局过程中不直接刷新，避免在 Layout 内递归触发布局
+	fyne.Do(func() {
+		if s.owner != nil {
+			s.owner.Refresh()
+		}
+		if s.onChange != nil {
+			s.onChange(narrow)
+		}
+	})
+	return narrow
+}
+
+// headerRowLayout 页面功能栏：宽度足够时为「左 | 中（占满）| 右」一行；
+// 窄于断点时左右按钮保留在第一行，中间内容换到第二行占满宽度，不再挤压截断。
+type headerRowLayout struct {
+	responsiveState
+}
+
+// newHeaderRow 创建可换行的功能栏，leading、center、trailing 均不可为 nil；onChange 可为 nil。
+func newHeaderRow(breakpoint float32, onChange func(narrow bool), leading, center, trailing fyne.CanvasObject) *fyne.Container {
+	l := &headerRowLayout{responsiveState{breakpoint: breakpoint, onChange: onChange}}
+	c := container.New(l, leading, center, trailing)
+	l.owner = c
+	return c
+}
+
+func (l *headerRowLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) != 3 {
+		return
+	}
+	leading, center, trailing := objects[0], objects[1], objects[2]
+	pad := theme.Padding()
+	lm, cm, tm := leading.MinSize(), center.MinSize(), trailing.MinSize()
+	if l.update(size.Width) {
+		row := max(lm.Height, tm.Height)
+		leading.Move(fyne.NewPos(0, 0))
+		leading.Resize(fyne.NewSize(lm.Width, row))
+		trailing.Move(fyne.NewPos(size.Width-tm.Width, 0))
+		trailing.Resize(fyne.NewSize(tm.Width, row))
+		center.Move(fyne.NewPos(0, row+pad))
+		center.Resize(fyne.NewSize(size.Width, cm.Height))
+		return
+	}
+	leading.Move(fyne.NewPos(0, 0))
+	leading.Resize(fyne.NewSize(lm.Width, size.Height))
+	trailing.Move(fyne.NewPos(size.Width-tm.Width, 0))
+	trailing.Resize(fyne.NewSize(tm.Width, size.Height))
+	centerW := size.Width - lm.Width - tm.Width - 2*pad
+	if centerW < 0 {
+		centerW = 0
+	}
+	center.Move(fyne.NewPos(lm.Width+pad, 0))
+	center.Resize(fyne.NewSize(centerW, size.Height))
+}
+
+func (l *headerRowLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	if len(objects) != 3 {
+		return fyne.NewSize(0, 0)
+	}
+	lm, cm, tm := objects[0].MinSize(), objects[1].MinSize(), objects[2].MinSize()
+	pad := theme.Padding()
+	w := lm.Width + tm.Width + pad
+	if l.narrow {
+		return fyne.NewSize(max(w, cm.Width), max(lm.Height, tm.Height)+pad+cm.Height)
+	}
+	return fyne.NewSize(w+cm.Width+pad, max(lm.Height, cm.Height, tm.Height))
+}
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"myproxy.com/p/internal/service"
+)
+
+// handleLatencyReport 记录定时测速结果并刷新节点列表；webhookErr 为发送 Webhook 的错误。可在任意 goroutine 中调用。
+func (a *AppState) handleLatencyReport(report *service.LatencyReport, webhookErr error) {
+	if report == nil {
+		return
+	}
+	msg := fmt.Sprintf("定时测速完成: 成功 %d 个，失败 %d 个", report.Succeeded, report.Failed)
+	if report.AutoDisabled > 0 {
+		msg += fmt.Sprintf("，因连续失败自动禁用 %d 个", report.AutoDisabled)
+	}
+	a.AppendLog("INFO", "ping", msg)
+	if webhookErr != nil {
+		a.AppendLog("WARN", "ping", webhookErr.Error())
+	}
+	fyne.Do(func() {
+		if a.MainWindow != nil && a.MainWindow.nodePageInstance != nil {
+			a.MainWindow.nodePageInstance.Refresh()
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+// syncSessionReport 代理开始新会话时开始跟踪连接报告（已在跟踪时计为一次重连）。
+func (a *AppState) syncSessionReport(newSession bool) {
+	if a.SessionReport == nil || !newSession || !a.IsProxyRunning() {
+		return
+	}
+	nodeName := ""
+	if a.Store != nil && a.Store.Nodes != nil {
+		if n := a.Store.Nodes.GetSelected(); n != nil {
+			nodeName = n.Name
+		}
+	}
+	a.SessionReport.Begin(nodeName)
+}
+
+// endSessionReport 结束当前连接的跟踪并写入连接记录；未在跟踪时返回 nil。
+func (a *AppState) endSessionReport() *service.SessionReport {
+	if a.SessionReport == nil {
+		return nil
+	}
+	report, err := a.SessionReport.End()
+	if err != nil {
+		a.AppendLog("WARN", "app", err.Error())
+	}
+	return report
+}
+
+// showProxyStoppedDialog 用户停止代理后提示：开启连接报告时显示本次连接的报告，否则提示已停止。
+func showProxyStoppedDialog(appState *AppState) {
+	report := appState.endSessionReport()
+	if appState.Window == nil {
+		return
+	}
+	if report == nil {
+		dialog.ShowInformation("代理停止成功", "代理已停止", appState.Window)
+		return
+	}
+	showSessionReportDialog(appState, report)
+}
+
+// showSessionReportDialog 显示一次连接的报告，可复制或打开连接记录。
+func showSessionReportDialog(appState *AppState, report *service.SessionReport) {
+	win := appState.Window
+	text := formatSessionReport(report)
+	body := widget.NewLabel(text)
+	body.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	copyBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), func() {
+		if appState.ClipboardMonitor != nil {
+			appState.ClipboardMonitor.IgnoreContent(text)
+		}
+		win.Clipboard().SetContent(text)
+	})
+	historyBtn := widget.NewButtonWithIcon("连接记录", theme.HistoryIcon(), func() {
+		d.Hide()
+		showSessionHistoryDialog(appState)
+	})
+	content := container.NewBorder(nil,
+		container.NewHBox(layout.NewSpacer(), copyBtn, historyBtn),
+		nil, nil,
+		container.NewVScroll(body),
+	)
+	d = dialog.NewCustom("代理已停止 - 连接报告", "关闭", content, win)
+	d.Resize(fyne.NewSize(420, 420))
+	d.Show()
+}
+
+// showSessionHistoryDialog 列出连接记录（最近的在前），可导出为 JSON 或清空。
+func showSessionHistoryDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.SessionReport == nil {
+		return
+	}
+	win := appState.Window
+	reports, err := appState.SessionReport.History()
+	if err != nil {
+		dialog.ShowError(err, win)
+		return
+	}
+
+	detail := widget.NewLabel("")
+	detail.Wrapping = fyne.TextWrapWord
+	emptyText := "暂无连接记录。在设置中开启「断开连接时生成连接报告」后，每次断开都会记入这里。"
+	if len(reports) == 0 {
+		detail.SetText(emptyText)
+	}
+	list := widget.NewList(
+		func() int { return len(reports) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(sessionReportTitle(&reports[id]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		detail.SetText(formatSessionReport(&reports[id]))
+	}
+
+	exportBtn := widget.NewButtonWithIcon("导出", theme.DocumentSaveIcon(), func() {
+		if len(reports) == 0 {
+			return
+		}
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("导出连接记录失败: %w", err), win)
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if w == nil {
+				return
+			}
+			defer w.Close()
+			if _, err := w.Write(append(data, '\n')); err != nil {
+				dialog.ShowError(fmt.Errorf("导出连接记录失败: %w", err), win)
+				return
+			}
+			appState.AppendLog("INFO", "app", fmt.Sprintf("已导出 %d 条连接记录: %s", len(reports), w.URI().Name()))
+		}, win)
+		saveDialog.SetFileName("myproxy-sessions.json")
+		saveDialog.Show()
+	})
+	clearBtn := widget.NewButtonWithIcon("清空", theme.DeleteIcon(), func() {
+		if len(reports) == 0 {
+			return
+		}
+		dialog.ShowConfirm("清空连接记录", fmt.Sprintf("确定删除全部 %d 条连接记录？", len(reports)), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := appState.SessionReport.ClearHistory(); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			reports = nil
+			list.UnselectAll()
+			list.Refresh()
+			detail.SetText(emptyText)
+		}, win)
+	})
+	clearBtn.Importance = widget.DangerImportance
+
+	content := container.NewBorder(nil,
+		container.NewHBox(layout.NewSpacer(), exportBtn, clearBtn),
+		nil, nil,
+		container.NewVSplit(list, container.NewVScroll(detail)),
+	)
+	d := dialog.NewCustom("连接记录", "关闭", content, win)
+	d.Resize(fyne.NewSize(480, 540))
+	d.Show()
+}
+
+// sessionReportTitle 连接记录列表中的一行：开始时间、时长与节点。
+func sessionReportTitle(r *service.SessionReport) string {
+	title := r.StartedAt.Format("01-02 15:04") + " · " + formatSessionDuration(r.Duration())
+	if len(r.Nodes) > 0 {
+		title += " · " + r.Nodes[0]
+		if len(r.Nodes) > 1 {
+			title += fmt.Sprintf(" 等 %d 个节点", len(r.Nodes))
+		}
+	}
+	return title
+}
+
+// formatSessionReport 把连接报告格式化为多行文本，用于显示与复制。
+func formatSessionReport(r *service.SessionReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "时间: %s — %s\n", r.StartedAt.Format("2006-01-02 15:04:05"), r.EndedAt.Format("15:04:05"))
+	fmt.Fprintf(&b, "时长: %s\n", formatSessionDuration(r.Duration()))
+	if len(r.Nodes) > 0 {
+		fmt.Fprintf(&b, "节点: %s\n", strings.Join(r.Nodes, "、"))
+	}
+	fmt.Fprintf(&b, "流量: ↑ %s  ↓ %s\n", formatBytes(uint64(r.Upload)), formatBytes(uint64(r.Download)))
+	fmt.Fprintf(&b, "连接数: %d\n", r.Connections)
+	if r.AvgLatencyMs > 0 {
+		fmt.Fprintf(&b, "平均延迟: %d ms\n", r.AvgLatencyMs)
+	} else {
+		b.WriteString("平均延迟: -\n")
+	}
+	fmt.Fprintf(&b, "重连次数: %d\n", r.Reconnects)
+	if len(r.TopDestinations) > 0 {
+		b.WriteString("\n访问最多的站点:\n")
+		for i, d := range r.TopDestinations {
+			fmt.Fprintf(&b, "%2d. %s（%d 次）\n", i+1, d.Host, d.Count)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatSessionDuration 将连接时长格式化为「X 小时 Y 分」，不足一分钟时显示秒数。
+func formatSessionDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%d 秒", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%d 分", int(d/time.Minute))
+	default:
+		return fmt.Sprintf("%d 小时 %d 分", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+}
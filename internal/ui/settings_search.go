@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// settingsSearchItem 设置搜索索引中的一项：标题、所属菜单、可选的定位锚点与检索关键词。
+type settingsSearchItem struct {
+	title    string
+	menu     SettingsMenu
+	anchor   string // 为空时仅跳转到所在菜单
+	keywords []string
+}
+
+// settingsSearchIndex 设置项搜索索引；新增设置项时在此登记，并在构建控件时调用 registerAnchor。
+var settingsSearchIndex = []settingsSearchItem{
+	{title: "主题", menu: SettingsMenuAppearance, anchor: "theme", keywords: []string{"深色", "浅色", "跟随系统", "dark", "light", "外观"}},
+	{title: "列表密度", menu: SettingsMenuAppearance, anchor: "listDensity", keywords: []string{"行高", "紧凑", "宽松", "density", "compact"}},
+	{title: "低功耗模式", menu: SettingsMenuAppearance, anchor: "lowPower", keywords: []string{"电池", "省电", "功耗", "笔记本", "battery", "power"}},
+	{title: "系统通知", menu: SettingsMenuAppearance, anchor: "notifications", keywords: []string{"通知", "提醒", "断开", "中断", "切换", "notification", "failover"}},
+	{title: "连接报告与连接记录", menu: SettingsMenuAppearance, anchor: "sessionReport", keywords: []string{"报告", "连接记录", "历史", "时长", "流量", "session", "report", "history"}},
+	{title: "应用锁", menu: SettingsMenuAppearance, anchor: "appLock", keywords: []string{"密码", "PIN", "锁定", "自动锁定", "共用", "lock", "password"}},
+	{title: "速度单位", menu: SettingsMenuAppearance, anchor: "speedUnit", keywords: []string{"Mbps", "MB/s", "比特", "字节", "带宽", "bits", "bytes"}},
+	{title: "数字格式", menu: SettingsMenuAppearance, anchor: "numberFormat", keywords: []string{"千位分隔符", "小数点", "区域", "语言", "locale"}},
+	{title: "大号控件", menu: SettingsMenuAppearance, anchor: "largeControls", keywords: []string{"辅助功能", "无障碍", "放大", "字号", "按钮", "accessibility"}},
+	{title: "菜单栏显示实时速度", menu: SettingsMenuAppearance, anchor: "traySpeed", keywords: []string{"菜单栏", "托盘", "速度", "macos", "省电", "tray"}},
+	{title: "允许 WSL / 局域网访问本机入站", menu: SettingsMenuDirectRoute, anchor: "listenAll", keywords: []string{"wsl", "lan", "0.0.0.0", "监听", "局域网"}},
+	{title: "局域网入站", menu: SettingsMenuDirectRoute, anchor: "lanInbound", keywords: []string{"lan", "局域网", "7890", "http", "双栈", "多入站", "inbound"}},
+	{title: "入站认证", menu: SettingsMenuDirectRoute, anchor: "inboundAuth", keywords: []string{"认证", "密码", "账号", "auth", "socks", "局域网", "basic"}},
+	{title: "入站限速", menu: SettingsMenuDirectRoute, anchor: "rateLimit", keywords: []string{"限速", "带宽", "速度", "上传", "下载", "rate", "limit", "局域网"}},
+	{title: "选中节点时自动测速", menu: SettingsMenuDirectRoute, anchor: "autoProbe", keywords: []string{"延迟", "ping", "测速"}},
+	{title: "一键测速跳过近期测过的节点", menu: SettingsMenuDirectRoute, anchor: "pingFresh", keywords: []string{"测速", "缓存", "跳过", "ping", "延迟", "重测"}},
+	{title: "测速方式", menu: SettingsMenuDirectRoute, anchor: "latencyMethod", keywords: []string{"测速", "TLS", "握手", "TCP", "延迟", "ping", "handshake"}},
+	{title: "定时测速与 Webhook", menu: SettingsMenuDirectRoute, anchor: "scheduledPing", keywords: []string{"定时", "测速", "webhook", "告警", "延迟", "静默", "勿扰", "电源", "电池", "schedule", "ping", "quiet"}},
+	{title: "自动禁用失效节点", menu: SettingsMenuDirectRoute, anchor: "autoDisable", keywords: []string{"失败", "禁用", "失效", "节点", "测速"}},
+	{title: "检测剪贴板中的节点链接", menu: SettingsMenuDirectRoute, anchor: "clipboard", keywords: []string{"剪贴板", "clipboard", "复制", "导入"}},
+	{title: "保留不兼容的 SSR 节点", menu: SettingsMenuDirectRoute, anchor: "ssr", keywords: []string{"ssr", "shadowsocksr", "订阅", "混淆", "导入"}},
+	{title: "外部修改后自动刷新节点列表", menu: SettingsMenuDirectRoute, anchor: "externalRefresh", keywords: []string{"刷新", "同步", "数据库", "命令行", "cli", "api", "外部", "refresh"}},
+	{title: "自动选择节点（负载均衡组）", menu: SettingsMenuDirectRoute, anchor: "balancer", keywords: []string{"负载均衡", "balancer", "leastPing", "leastLoad", "observatory", "自动切换"}},
+	{title: "隧道内探测地址", menu: SettingsMenuDirectRoute, anchor: "probeURL", keywords: []string{"探测", "probe", "observatory", "generate_204", "间隔", "测速地址", "test url", "国内"}},
+	{title: "TLS 分片", menu: SettingsMenuDirectRoute, anchor: "fragment", keywords: []string{"fragment", "分片", "clienthello", "sni", "重置", "rst", "分片助手"}},
+	{title: "连接超时", menu: SettingsMenuDirectRoute, anchor: "policy", keywords: []string{"超时", "空闲", "握手", "timeout", "connIdle", "handshake", "policy", "挂起", "keep-alive"}},
+	{title: "为常用站点预热连接", menu: SettingsMenuDirectRoute, anchor: "prewarm", keywords: []string{"预热", "预连接", "首字节", "延迟", "常用站点", "prewarm", "pre-connect", "warm"}},
+	{title: "xray 意外停止时自动重启", menu: SettingsMenuDirectRoute, anchor: "autoRestart", keywords: []string{"崩溃", "重启", "守护", "退出", "crash", "restart", "supervisor"}},
+	{title: "浏览器导入接口", menu: SettingsMenuDirectRoute, anchor: "importApi", keywords: []string{"导入", "令牌", "token", "扩展", "import"}},
+	{title: "Prometheus 指标", menu: SettingsMenuDirectRoute, anchor: "metrics", keywords: []string{"指标", "监控", "metrics", "prometheus", "grafana"}},
+	{title: "状态文件（status.json）", menu: SettingsMenuDirectRoute, anchor: "statusFile", keywords: []string{"状态栏", "脚本", "polybar", "bitbar", "xbar", "status", "json"}},
+	{title: "WebDAV 同步", menu: SettingsMenuDirectRoute, anchor: "webdavSync", keywords: []string{"webdav", "同步", "云", "sync", "多台", "备份", "nextcloud", "坚果云"}},
+	{title: "注册导入链接", menu: SettingsMenuDirectRoute, anchor: "registerScheme", keywords: []string{"myproxy://", "sub://", "scheme", "协议"}},
+	{title: "终端代理", menu: SettingsMenuDirectRoute, anchor: "terminalProxy", keywords: []string{"环境变量", "http_proxy", "shell", "terminal"}},
+	{title: "Git 全局代理", menu: SettingsMenuDirectRoute, anchor: "gitProxy", keywords: []string{"git", "http.proxy"}},
+	{title: "开发工具代理", menu: SettingsMenuDirectRoute, anchor: "devTools", keywords: []string{"docker", "npm", "git", "开发", "一键"}},
+	{title: "代理类型", menu: SettingsMenuDirectRoute, anchor: "proxyType", keywords: []string{"socks5", "http", "https_tls"}},
+	{title: "不走直连", menu: SettingsMenuDirectRoute, anchor: "routeUseProxy", keywords: []string{"直连", "路由"}},
+	{title: "绕过局域网与中国大陆", menu: SettingsMenuDirectRoute, anchor: "bypassCN", keywords: []string{"geoip", "geosite", "cn", "大陆", "局域网", "分流", "直连"}},
+	{title: "最终流量走", menu: SettingsMenuDirectRoute, anchor: "finalOutbound", keywords: []string{"默认", "最终", "兜底", "MATCH", "FINAL", "阻止", "直连", "代理", "分流"}},
+	{title: "xray 资源目录", menu: SettingsMenuDirectRoute, anchor: "xrayAssetDir", keywords: []string{"geoip", "geosite", "XRAY_LOCATION_ASSET", "asset", "便携"}},
+	{title: "xray 内核", menu: SettingsMenuDirectRoute, anchor: "xrayCore", keywords: []string{"外部", "版本", "binary", "xray 可执行文件", "core"}},
+	{title: "xray gRPC API", menu: SettingsMenuDirectRoute, anchor: "xrayAPI", keywords: []string{"api", "grpc", "stats", "统计", "端口", "HandlerService"}},
+	{title: "路由规则测试", menu: SettingsMenuDirectRoute, anchor: "routeTester", keywords: []string{"路由", "规则", "测试", "分流", "走哪里", "直连", "代理"}},
+	{title: "直连路由列表", menu: SettingsMenuDirectRoute, anchor: "routeAdd", keywords: []string{"直连", "路由", "domain", "ip", "cidr", "重置"}},
+	{title: "日志", menu: SettingsMenuLog, keywords: []string{"log", "日志级别", "xray"}},
+	{title: "访问记录", menu: SettingsMenuAccessRecord, keywords: []string{"域名", "访问", "记录", "实时", "live", "tail"}},
+	{title: "访问记录方式", menu: SettingsMenuAccessRecord, anchor: "accessRecordMode", keywords: []string{"隐私", "不记录", "哈希", "privacy", "清除"}},
+	{title: "记录纯 IP 目标", menu: SettingsMenuAccessRecord, anchor: "accessRecordIPs", keywords: []string{"IP", "反向解析", "rDNS", "PTR", "GeoIP", "归属地"}},
+	{title: "统计使用代理的应用", menu: SettingsMenuAccessRecord, anchor: "processStats", keywords: []string{"应用维度", "进程", "process", "统计"}},
+	{title: "连接错误统计", menu: SettingsMenuAccessRecord, keywords: []string{"错误", "超时", "拒绝", "DNS", "节点坏了", "timeout", "refused"}},
+	{title: "启用本地 pprof", menu: SettingsMenuDiagnostics, anchor: "pprof", keywords: []string{"pprof", "性能", "调试", "debug"}},
+	{title: "诊断采样周期", menu: SettingsMenuDiagnostics, anchor: "sampling", keywords: []string{"采样", "内存", "goroutine"}},
+	{title: "导出诊断快照", menu: SettingsMenuDiagnostics, keywords: []string{"堆", "火焰图", "诊断", "导出"}},
+	{title: "查看当前配置", menu: SettingsMenuDiagnostics, keywords: []string{"xray", "配置", "json", "config", "调试", "编辑"}},
+	{title: "网络诊断", menu: SettingsMenuNetwork, keywords: []string{"网关", "dns", "traceroute", "ping", "延迟", "连通"}},
+	{title: "DNS 泄漏测试", menu: SettingsMenuNetwork, anchor: "dnsLeak", keywords: []string{"dns", "泄漏", "leak", "bash.ws", "socks5h"}},
+	{title: "关于", menu: SettingsMenuAbout, keywords: []string{"版本", "version", "about"}},
+}
+
+// matchSettings 按标题、菜单名与关键词（不区分大小写）筛选设置项。
+func matchSettings(query string) []settingsSearchItem {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+	var out []settingsSearchItem
+	for _, item := range settingsSearchIndex {
+		if strings.Contains(strings.ToLower(item.title), q) || strings.Contains(strings.ToLower(item.menu.String()), q) {
+			out = append(out, item)
+			continue
+		}
+		for _, kw := range item.keywords {
+			if strings.Contains(strings.ToLower(kw), q) {
+				out = append(out, item)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// registerAnchor 登记设置项控件，供搜索结果跳转定位。
+func (sp *SettingsPage) registerAnchor(name string, obj fyne.CanvasObject) {
+	if sp.anchors == nil {
+		sp.anchors = make(map[string]fyne.CanvasObject)
+	}
+	sp.anchors[name] = obj
+}
+
+// anchorObject 返回锚点对应的控件；诊断页、网络诊断页控件由各自页面持有。
+func (sp *SettingsPage) anchorObject(name string) fyne.CanvasObject {
+	if sp.diagnosticsPage != nil {
+		switch name {
+		case "pprof":
+			return sp.diagnosticsPage.pprofCheck
+		case "sampling":
+			return sp.diagnosticsPage.samplingSel
+		}
+	}
+	if name == "dnsLeak" && sp.networkDiagPage != nil && sp.networkDiagPage.leakBtn != nil {
+		return sp.networkDiagPage.leakBtn
+	}
+	return sp.anchors[name]
+}
+
+// onSearchChanged 搜索框内容变化：非空时在内容区展示匹配结果，清空时恢复当前菜单。
+func (sp *SettingsPage) onSearchChanged(query string) {
+	if sp.contentCard == nil {
+		return
+	}
+	if strings.TrimSpace(query) == "" {
+		sp.switchMenu(sp.currentMenu)
+		return
+	}
+	sp.contentCard.RemoveAll()
+	sp.contentCard.Add(sp.buildSearchResults(matchSettings(query)))
+	sp.contentCard.Refresh()
+	if sp.contentScroll != nil {
+		sp.contentScroll.ScrollToTop()
+	}
+}
+
+// buildSearchResults 构建搜索结果列表，点击后跳转到对应菜单并定位设置项。
+func (sp *SettingsPage) buildSearchResults(items []settingsSearchItem) fyne.CanvasObject {
+	if len(items) == 0 {
+		return container.NewVBox(widget.NewLabel("没有匹配的设置项"))
+	}
+	box := container.NewVBox()
+	for _, item := range items {
+		item := item
+		btn := widget.NewButtonWithIcon(item.menu.String()+" › "+item.title, theme.NavigateNextIcon(), func() {
+			sp.jumpTo(item)
+		})
+		btn.Alignment = widget.ButtonAlignLeading
+		btn.Importance = widget.LowImportance
+		box.Add(btn)
+	}
+	return box
+}
+
+// jumpTo 清空搜索、切换到设置项所在菜单，并在布局完成后滚动到控件并聚焦。
+func (sp *SettingsPage) jumpTo(item settingsSearchItem) {
+	sp.openMenu(item.menu)
+	if item.anchor == "" {
+		return
+	}
+	// 等待新内容完成布局后再计算位置
+	time.AfterFunc(50*time.Millisecond, func() {
+		fyne.Do(func() { sp.scrollToAnchor(item.anchor) })
+	})
+}
+
+// scrollToAnchor 将内容区滚动到锚点控件，并在可聚焦时设置焦点。
+func (sp *SettingsPage) scrollToAnchor(name string) {
+	obj := sp.anchorObject(name)
+	if obj == nil || sp.contentScroll == nil || sp.contentScroll.Content == nil || sp.appState == nil || sp.appState.App == nil {
+		return
+	}
+	driver := sp.appState.App.Driver()
+	target := driver.AbsolutePositionForObject(obj)
+	origin := driver.AbsolutePositionForObject(sp.contentScroll.Content)
+	sp.contentScroll.ScrollToOffset(fyne.NewPos(0, target.Y-origin.Y))
+	if focusable, ok := obj.(fyne.Focusable); ok && sp.appState.Window != nil {
+		sp.appState.Window.Canvas().Focus(focusable)
+	}
+}
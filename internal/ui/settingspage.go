@@ -1,8 +1,16 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -12,6 +20,12 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/systemproxy"
+	"myproxy.com/p/internal/urlscheme"
+	"myproxy.com/p/internal/utils"
+	"myproxy.com/p/internal/xray"
 )
 
 // SettingsMenu 设置菜单项
@@ -23,6 +37,7 @@ const (
 	SettingsMenuLog
 	SettingsMenuAccessRecord
 	SettingsMenuDiagnostics
+	SettingsMenuNetwork
 	SettingsMenuAbout
 )
 
@@ -54,6 +69,8 @@ func (m SettingsMenu) String() string {
 		return "访问记录"
 	case SettingsMenuDiagnostics:
 		return "诊断"
+	case SettingsMenuNetwork:
+		return "网络诊断"
 	case SettingsMenuAbout:
 		return "关于"
 	default:
@@ -61,17 +78,35 @@ func (m SettingsMenu) String() string {
 	}
 }
 
+// settingsNarrowWidth 设置页窄于该宽度时左侧菜单收起为内容区上方的下拉框
+const settingsNarrowWidth float32 = 440
+
 // fixedMenuContentLayout 固定左侧菜单宽度、右侧内容占满剩余空间的布局；分隔不随窗口拖拽变化。
+// 对象依次为左侧菜单、内容区与紧凑菜单（下拉框）：窄于 settingsNarrowWidth 时隐藏左侧菜单，
+// 下拉框置于内容区上方，内容区占满整宽。
 type fixedMenuContentLayout struct {
+	responsiveState
 	menuWidth float32
 }
 
-func (f fixedMenuContentLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
-	if len(objects) != 2 {
+// newFixedMenuContent 创建左侧菜单 + 内容区的分栏容器。
+func newFixedMenuContent(menuWidth float32, menu, content, compactMenu fyne.CanvasObject) *fyne.Container {
+	f := &fixedMenuContentLayout{responsiveState: responsiveState{breakpoint: settingsNarrowWidth}, menuWidth: menuWidth}
+	c := container.New(f, menu, content, compactMenu)
+	f.owner = c
+	return c
+}
+
+func (f *fixedMenuContentLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	if len(objects) != 3 {
 		return fyne.NewSize(0, 0)
 	}
 	menuMin := objects[0].MinSize()
 	contentMin := objects[1].MinSize()
+	if f.narrow {
+		compactMin := objects[2].MinSize()
+		return fyne.NewSize(max(compactMin.Width, contentMin.Width), compactMin.Height+theme.Padding()+contentMin.Height)
+	}
 	w := f.menuWidth
 	if w < menuMin.Width {
 		w = menuMin.Width
@@ -79,11 +114,25 @@ func (f fixedMenuContentLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 	return fyne.NewSize(w+contentMin.Width, max(menuMin.Height, contentMin.Height))
 }
 
-func (f fixedMenuContentLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
-	if len(objects) != 2 {
+func (f *fixedMenuContentLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) != 3 {
 		return
 	}
-	menuMin := objects[0].MinSize()
+	menu, content, compactMenu := objects[0], objects[1], objects[2]
+	if f.update(size.Width) {
+		menu.Hide()
+		compactMenu.Show()
+		h := compactMenu.MinSize().Height
+		compactMenu.Resize(fyne.NewSize(size.Width, h))
+		compactMenu.Move(fyne.NewPos(0, 0))
+		top := h + theme.Padding()
+		content.Resize(fyne.NewSize(size.Width, max(size.Height-top, 0)))
+		content.Move(fyne.NewPos(0, top))
+		return
+	}
+	compactMenu.Hide()
+	menu.Show()
+	menuMin := menu.MinSize()
 	w := f.menuWidth
 	if w < menuMin.Width {
 		w = menuMin.Width
@@ -92,10 +141,10 @@ func (f fixedMenuContentLayout) Layout(objects []fyne.CanvasObject, size fyne.Si
 	if contentW < 0 {
 		contentW = 0
 	}
-	objects[0].Resize(fyne.NewSize(w, size.Height))
-	objects[0].Move(fyne.NewPos(0, 0))
-	objects[1].Resize(fyne.NewSize(contentW, size.Height))
-	objects[1].Move(fyne.NewPos(w, 0))
+	menu.Resize(fyne.NewSize(w, size.Height))
+	menu.Move(fyne.NewPos(0, 0))
+	content.Resize(fyne.NewSize(contentW, size.Height))
+	content.Move(fyne.NewPos(w, 0))
 }
 
 // SettingsPage 管理应用设置的显示和操作。
@@ -103,10 +152,16 @@ func (f fixedMenuContentLayout) Layout(objects []fyne.CanvasObject, size fyne.Si
 type SettingsPage struct {
 	appState    *AppState
 	content     fyne.CanvasObject
-	menuButtons [6]*widget.Button
+	menuButtons [7]*widget.Button
+	menuSelect  *widget.Select // 窄窗口下代替左侧菜单的下拉框
 	contentCard *fyne.Container
 	currentMenu SettingsMenu
 
+	// 设置搜索：搜索框、内容区滚动容器与设置项定位锚点
+	searchEntry   *widget.Entry
+	contentScroll *container.Scroll
+	anchors       map[string]fyne.CanvasObject
+
 	// 直连路由相关
 	routesList    *widget.List
 	routesData    []string
@@ -118,13 +173,34 @@ type SettingsPage struct {
 
 	// 诊断页
 	diagnosticsPage *DiagnosticsPage
+	// 网络诊断页：缓存以便切换菜单后保留上次结果
+	networkDiagPage *NetworkDiagnosticsPage
 
 	// 代理配置面板（直连路由 + 终端/Git/类型）：构建较贵，缓存避免每次进入菜单重复创建
 	directRouteRoot fyne.CanvasObject
 
 	// 访问记录相关
-	accessRecordsList *widget.List
-	accessRecordsData []model.AccessRecord
+	accessRecordsTree   *widget.Tree
+	accessRecordsData   []model.AccessRecord
+	accessRecordGroups  []model.AccessRecordGroup
+	accessRecordGroupOf map[string]int // 组节点 ID（域名）-> accessRecordGroups 下标
+	accessRecordsState  *ListStateView
+	accessIPInfo        map[string]service.IPInfo // 纯 IP 目标的反向解析与归属地，后台查询后填入
+
+	// 应用维度统计
+	processRecordsList  *widget.List
+	processRecordsData  []model.ProcessRecord
+	processRecordsState *ListStateView
+	showProcessRecords  bool
+
+	// 连接错误统计
+	connErrorCells   map[string]*widget.Label // 键为「一侧/类型」，合计列的类型为 total
+	connErrorSummary *widget.Label
+	showConnErrors   bool
+
+	// 访问记录实时视图，仅在显示时订阅新连接
+	accessLive     *accessLiveView
+	showAccessLive bool
 }
 
 // NewSettingsPage 创建设置页面实例。
@@ -139,8 +215,9 @@ func NewSettingsPage(appState *AppState) *SettingsPage {
 // Build 构建设置页面 UI。
 func (sp *SettingsPage) Build() fyne.CanvasObject {
 	sp.directRouteRoot = nil
+	sp.anchors = nil
 	pad := innerPadding(sp.appState)
-	backBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+	backBtn := newIconButton(sp.appState, "返回", theme.NavigateBackIcon(), func() {
 		if sp.appState != nil && sp.appState.MainWindow != nil {
 			sp.appState.MainWindow.Back()
 		}
@@ -148,19 +225,27 @@ func (sp *SettingsPage) Build() fyne.CanvasObject {
 	backBtn.Importance = widget.LowImportance
 
 	titleLabel := NewTitleLabel("设置")
-	headerBar := newPaddedWithSize(container.NewHBox(
-		backBtn,
-		layout.NewSpacer(),
-		titleLabel,
-		layout.NewSpacer(),
+	sp.searchEntry = widget.NewEntry()
+	sp.searchEntry.SetPlaceHolder("搜索设置项…")
+	sp.searchEntry.OnChanged = sp.onSearchChanged
+
+	headerBar := newPaddedWithSize(container.NewVBox(
+		container.NewHBox(
+			backBtn,
+			layout.NewSpacer(),
+			titleLabel,
+			layout.NewSpacer(),
+		),
+		sp.searchEntry,
 	), pad)
 
-	sp.menuButtons[0] = widget.NewButton("外观", func() { sp.switchMenu(SettingsMenuAppearance) })
-	sp.menuButtons[1] = widget.NewButton("代理配置", func() { sp.switchMenu(SettingsMenuDirectRoute) })
-	sp.menuButtons[2] = widget.NewButton("日志", func() { sp.switchMenu(SettingsMenuLog) })
-	sp.menuButtons[3] = widget.NewButton("访问记录", func() { sp.switchMenu(SettingsMenuAccessRecord) })
-	sp.menuButtons[4] = widget.NewButton("诊断", func() { sp.switchMenu(SettingsMenuDiagnostics) })
-	sp.menuButtons[5] = widget.NewButton("关于", func() { sp.switchMenu(SettingsMenuAbout) })
+	sp.menuButtons[0] = widget.NewButton("外观", func() { sp.openMenu(SettingsMenuAppearance) })
+	sp.menuButtons[1] = widget.NewButton("代理配置", func() { sp.openMenu(SettingsMenuDirectRoute) })
+	sp.menuButtons[2] = widget.NewButton("日志", func() { sp.openMenu(SettingsMenuLog) })
+	sp.menuButtons[3] = widget.NewButton("访问记录", func() { sp.openMenu(SettingsMenuAccessRecord) })
+	sp.menuButtons[4] = widget.NewButton("诊断", func() { sp.openMenu(SettingsMenuDiagnostics) })
+	sp.menuButtons[5] = widget.NewButton("网络诊断", func() { sp.openMenu(SettingsMenuNetwork) })
+	sp.menuButtons[6] = widget.NewButton("关于", func() { sp.openMenu(SettingsMenuAbout) })
 
 	for i := range sp.menuButtons {
 		sp.menuButtons[i].Importance = widget.LowImportance
@@ -174,6 +259,7 @@ func (sp *SettingsPage) Build() fyne.CanvasObject {
 		sp.menuButtons[3],
 		sp.menuButtons[4],
 		sp.menuButtons[5],
+		sp.menuButtons[6],
 	)
 	menuBox := newPaddedWithSize(menuContent, pad)
 	// 极简柔光：浅色模式下侧边栏背景 #F1F5F9，增加物理隔离感
@@ -190,9 +276,24 @@ func (sp *SettingsPage) Build() fyne.CanvasObject {
 	sp.contentCard = container.NewMax()
 	sp.contentCard.Add(sp.buildAppearanceContent())
 	contentArea := container.NewScroll(newPaddedWithSize(sp.contentCard, pad))
+	sp.contentScroll = contentArea
+
+	// 窄窗口下的菜单下拉框，与左侧菜单共用 openMenu
+	menuNames := make([]string, len(sp.menuButtons))
+	for i := range menuNames {
+		menuNames[i] = SettingsMenu(i).String()
+	}
+	sp.menuSelect = widget.NewSelect(menuNames, func(name string) {
+		for i, n := range menuNames {
+			if n == name && SettingsMenu(i) != sp.currentMenu {
+				sp.openMenu(SettingsMenu(i))
+				return
+			}
+		}
+	})
 
 	// 左右分栏：菜单固定宽度，完整展示菜单项；内容区占剩余空间（分隔不随窗口拖拽变化）
-	mainContent := container.New(&fixedMenuContentLayout{menuWidth: 98}, leftColumn, contentArea)
+	mainContent := newFixedMenuContent(98, leftColumn, contentArea, sp.menuSelect)
 
 	sp.content = container.NewBorder(
 		headerBar,
@@ -204,9 +305,28 @@ func (sp *SettingsPage) Build() fyne.CanvasObject {
 	return sp.content
 }
 
+// openMenu 用户切换菜单：切换前的菜单记入导航历史，「返回」时先回到该菜单。
+func (sp *SettingsPage) openMenu(menu SettingsMenu) {
+	if menu != sp.currentMenu && sp.appState != nil && sp.appState.MainWindow != nil {
+		sp.appState.MainWindow.pushSettingsMenu(sp.currentMenu)
+	}
+	sp.showMenu(menu)
+}
+
+// showMenu 显示指定菜单；正在搜索时先清空搜索框（由 onSearchChanged 切换到 currentMenu）。
+func (sp *SettingsPage) showMenu(menu SettingsMenu) {
+	sp.currentMenu = menu
+	if sp.searchEntry != nil && sp.searchEntry.Text != "" {
+		sp.searchEntry.SetText("")
+		return
+	}
+	sp.switchMenu(menu)
+}
+
 // switchMenu 切换菜单并更新内容区。
 func (sp *SettingsPage) switchMenu(menu SettingsMenu) {
 	sp.currentMenu = menu
+	sp.stopAccessLive()
 	sp.contentCard.RemoveAll()
 	switch menu {
 	case SettingsMenuAppearance:
@@ -225,6 +345,8 @@ func (sp *SettingsPage) switchMenu(menu SettingsMenu) {
 		sp.contentCard.Add(sp.buildAccessRecordContent())
 	case SettingsMenuDiagnostics:
 		sp.contentCard.Add(sp.buildDiagnosticsContent())
+	case SettingsMenuNetwork:
+		sp.contentCard.Add(sp.buildNetworkDiagnosticsContent())
 	case SettingsMenuAbout:
 		sp.contentCard.Add(sp.buildAboutContent())
 	}
@@ -242,6 +364,9 @@ func (sp *SettingsPage) updateMenuState() {
 		}
 		sp.menuButtons[i].Refresh()
 	}
+	if sp.menuSelect != nil {
+		sp.menuSelect.SetSelectedIndex(int(sp.currentMenu))
+	}
 }
 
 // buildThemePreview 构建主题预览区域
@@ -303,16 +428,389 @@ func (sp *SettingsPage) buildAppearanceContent() fyne.CanvasObject {
 		}
 	}
 	themeSelect.SetSelected(currentThemeDisplay)
+	sp.registerAnchor("theme", themeSelect)
 
 	return container.NewVBox(
 		widget.NewLabel("主题"),
 		themeSelect,
+		widget.NewLabel("列表密度"),
+		sp.buildListDensitySelect(),
+		widget.NewLabel("速度单位与数字格式"),
+		sp.buildUnitFormatSelects(),
+		widget.NewLabel("辅助功能"),
+		sp.buildLargeControlsCheck(),
+		sp.buildTraySpeedCheck(),
+		widget.NewLabel("低功耗模式"),
+		sp.buildLowPowerSelect(),
+		widget.NewLabel("系统通知"),
+		sp.buildNotificationContent(),
+		widget.NewLabel("应用锁"),
+		sp.buildAppLockContent(),
 		// 添加主题预览区域
 		widget.NewSeparator(),
 		buildThemePreview(sp.appState),
 	)
 }
 
+// buildAppLockContent 构建应用锁设置：设置、修改或移除密码，启动与进入设置页时是否需要密码，无操作一段时间后自动锁定。
+// 修改或移除已有密码前须输入当前密码。
+func (sp *SettingsPage) buildAppLockContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return widget.NewLabel("")
+	}
+	cs := sp.appState.ConfigService
+
+	statusLabel := widget.NewLabel("")
+	startupCheck := widget.NewCheck("启动时需要密码", func(b bool) { _ = cs.SetAppLockOnStartup(b) })
+	startupCheck.Checked = cs.GetAppLockOnStartup()
+	settingsCheck := widget.NewCheck("进入设置页时需要密码", func(b bool) { _ = cs.SetAppLockSettings(b) })
+	settingsCheck.Checked = cs.GetAppLockSettings()
+
+	// 主窗口超过所选时间没有键盘或鼠标输入后锁定（窗口在前台时同样计时）
+	idleOptions := []string{"不自动锁定", "5 分钟", "15 分钟", "30 分钟", "60 分钟"}
+	idleValues := []int{0, 5, 15, 30, 60}
+	idleSelect := widget.NewSelect(idleOptions, nil)
+	current := int(cs.GetAppLockIdleTimeout().Minutes())
+	for i, v := range idleValues {
+		if v == current {
+			idleSelect.SetSelected(idleOptions[i])
+		}
+	}
+	if idleSelect.Selected == "" {
+		idleSelect.PlaceHolder = fmt.Sprintf("%d 分钟", current)
+	}
+	idleSelect.OnChanged = func(s string) {
+		for i, label := range idleOptions {
+			if label == s {
+				_ = cs.SetAppLockIdleMinutes(idleValues[i])
+			}
+		}
+	}
+	idleRow := container.NewBorder(nil, nil, widget.NewLabel("无操作后自动锁定"), nil, idleSelect)
+
+	var setBtn, removeBtn, lockBtn *widget.Button
+	refresh := func() {
+		enabled := cs.AppLockEnabled()
+		if enabled {
+			statusLabel.SetText("已设置密码")
+			setBtn.SetText("修改密码")
+		} else {
+			statusLabel.SetText("未设置密码，应用锁未开启")
+			setBtn.SetText("设置密码")
+		}
+		for _, w := range []fyne.Disableable{removeBtn, lockBtn, startupCheck, settingsCheck, idleSelect} {
+			if enabled {
+				w.Enable()
+			} else {
+				w.Disable()
+			}
+		}
+	}
+	// requirePassword 已有密码时先校验当前密码
+	requirePassword := func(title string, fn func()) {
+		if !cs.AppLockEnabled() {
+			fn()
+			return
+		}
+		sp.appState.promptAppLockPassword(title, fn)
+	}
+
+	setBtn = widget.NewButtonWithIcon("设置密码", theme.AccountIcon(), func() {
+		requirePassword("修改应用锁密码", func() {
+			sp.showAppLockPasswordDialog(refresh)
+		})
+	})
+	setBtn.Importance = widget.LowImportance
+	removeBtn = widget.NewButtonWithIcon("移除密码", theme.DeleteIcon(), func() {
+		requirePassword("移除应用锁密码", func() {
+			if err := cs.SetAppLockPassword(""); err != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			refresh()
+		})
+	})
+	removeBtn.Importance = widget.LowImportance
+	lockBtn = widget.NewButtonWithIcon("立即锁定", theme.VisibilityOffIcon(), sp.appState.LockApp)
+	lockBtn.Importance = widget.LowImportance
+	refresh()
+
+	hint := widget.NewLabel("适合多人共用的电脑：锁定后需输入密码才能使用主窗口，代理保持运行，托盘菜单与悬浮窗仍可连接或断开。密码只保存哈希，忘记密码时需删除数据库 app_config 表中的 appLockHash 项。")
+	hint.Wrapping = fyne.TextWrapWord
+	hint.Importance = widget.LowImportance
+
+	sp.registerAnchor("appLock", setBtn)
+	return container.NewVBox(
+		container.NewHBox(statusLabel, layout.NewSpacer(), setBtn, removeBtn, lockBtn),
+		startupCheck,
+		settingsCheck,
+		idleRow,
+		hint,
+	)
+}
+
+// showAppLockPasswordDialog 输入两次新密码后保存，保存成功后调用 onSaved。
+func (sp *SettingsPage) showAppLockPasswordDialog(onSaved func()) {
+	win := sp.appState.Window
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetPlaceHolder(fmt.Sprintf("至少 %d 位，可为数字 PIN", service.AppLockMinPasswordLen))
+	confirmEntry := widget.NewPasswordEntry()
+	d := dialog.NewForm("设置应用锁密码", "保存", "取消", []*widget.FormItem{
+		{Text: "新密码", Widget: passEntry},
+		{Text: "确认密码", Widget: confirmEntry},
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		if passEntry.Text != confirmEntry.Text {
+			dialog.ShowError(fmt.Errorf("两次输入的密码不一致"), win)
+			return
+		}
+		if passEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("密码不能为空"), win)
+			return
+		}
+		if err := sp.appState.ConfigService.SetAppLockPassword(passEntry.Text); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		// 刚设置的密码视为本次已验证，留在设置页不再询问
+		sp.appState.appLock.unlocked = true
+		onSaved()
+	}, win)
+	d.Resize(fyne.NewSize(360, 0))
+	d.Show()
+}
+
+// buildLargeControlsCheck 构建「大号控件」开关：放大间距、图标与文字，图标按钮旁显示名称；切换后重新应用主题并重建页面。
+func (sp *SettingsPage) buildLargeControlsCheck() fyne.CanvasObject {
+	check := widget.NewCheck("大号控件（更大的点击区域，图标按钮显示文字名称）", nil)
+	check.SetChecked(largeControls(sp.appState))
+	check.OnChanged = func(checked bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil || checked == largeControls(sp.appState) {
+			return
+		}
+		if err := sp.appState.ConfigService.SetLargeControls(checked); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		sp.appState.ApplyTheme()
+		if mw := sp.appState.MainWindow; mw != nil {
+			mw.RebuildListPages()
+			mw.RebuildCurrentPageForTheme()
+		}
+	}
+	sp.registerAnchor("largeControls", check)
+	return check
+}
+
+// buildTraySpeedCheck 构建「菜单栏显示实时速度」开关；仅 macOS 菜单栏支持文字，其它平台禁用。
+func (sp *SettingsPage) buildTraySpeedCheck() fyne.CanvasObject {
+	check := widget.NewCheck("菜单栏显示实时速度（仅 macOS，关闭可省电）", func(checked bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		if err := sp.appState.ConfigService.SetTrayShowSpeed(checked); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		if sp.appState.TrayManager != nil {
+			sp.appState.TrayManager.ApplySpeedTitle()
+		}
+	})
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		check.Checked = sp.appState.ConfigService.GetTrayShowSpeed()
+	}
+	if !trayTitleSupported {
+		check.Disable()
+	}
+	sp.registerAnchor("traySpeed", check)
+	return check
+}
+
+// buildNotificationContent 构建系统通知开关：连接成功、连接意外中断、负载均衡组自动切换节点各自独立。
+func (sp *SettingsPage) buildNotificationContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+	newCheck := func(label string, get func() bool, set func(bool) error) *widget.Check {
+		check := widget.NewCheck(label, nil)
+		check.SetChecked(get())
+		check.OnChanged = func(b bool) {
+			if err := set(b); err != nil && sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+		}
+		return check
+	}
+	connectCheck := newCheck("连接成功", cs.GetNotifyOnConnect, cs.SetNotifyOnConnect)
+	disconnectCheck := newCheck("连接意外中断（每 30 秒经代理探测一次）", cs.GetNotifyOnDisconnect, cs.SetNotifyOnDisconnect)
+	failoverCheck := newCheck("负载均衡组自动切换节点", cs.GetNotifyOnFailover, cs.SetNotifyOnFailover)
+	// 报告从下次连接开始跟踪；关闭时进行中的连接仍在断开时生成报告
+	reportCheck := newCheck("断开连接时显示连接报告（时长、流量、常访问站点、延迟）", cs.GetSessionReportEnabled, cs.SetSessionReportEnabled)
+	historyBtn := widget.NewButtonWithIcon("连接记录", theme.HistoryIcon(), func() {
+		showSessionHistoryDialog(sp.appState)
+	})
+
+	sp.registerAnchor("notifications", connectCheck)
+	sp.registerAnchor("sessionReport", reportCheck)
+	return container.NewVBox(connectCheck, disconnectCheck, failoverCheck,
+		container.NewBorder(nil, nil, nil, historyBtn, reportCheck))
+}
+
+// lowPowerOptions 低功耗模式下拉框选项与配置值的对应
+var lowPowerOptions = []struct{ label, mode string }{
+	{"使用电池时自动开启", service.LowPowerModeAuto},
+	{"始终开启", service.LowPowerModeOn},
+	{"关闭", service.LowPowerModeOff},
+}
+
+// buildLowPowerSelect 构建低功耗模式选择：开启时放缓流量与运行时采样、暂停选中节点时的自动测速。
+func (sp *SettingsPage) buildLowPowerSelect() fyne.CanvasObject {
+	labels := make([]string, len(lowPowerOptions))
+	for i, o := range lowPowerOptions {
+		labels[i] = o.label
+	}
+	powerSelect := widget.NewSelect(labels, nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current := sp.appState.ConfigService.GetLowPowerMode()
+		for _, o := range lowPowerOptions {
+			if o.mode == current {
+				powerSelect.SetSelected(o.label)
+			}
+		}
+	}
+	powerSelect.OnChanged = func(label string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		for _, o := range lowPowerOptions {
+			if o.label != label {
+				continue
+			}
+			if err := sp.appState.ConfigService.SetLowPowerMode(o.mode); err != nil {
+				if sp.appState.Window != nil {
+					dialog.ShowError(err, sp.appState.Window)
+				}
+				return
+			}
+			if sp.appState.PowerService != nil {
+				go sp.appState.PowerService.Refresh()
+			}
+		}
+	}
+	sp.registerAnchor("lowPower", powerSelect)
+	return powerSelect
+}
+
+// speedUnitOptions 速度单位下拉框选项与配置值的对应
+var speedUnitOptions = []struct{ label, value string }{
+	{"字节（KB/s、MB/s）", service.SpeedUnitBytes},
+	{"比特（Kbps、Mbps）", service.SpeedUnitBits},
+}
+
+// numberFormatOptions 数字格式下拉框选项与配置值的对应
+var numberFormatOptions = []struct{ label, value string }{
+	{"跟随系统语言", service.NumberFormatSystem},
+	{"1,234.5", service.NumberFormatCommaDot},
+	{"1.234,5", service.NumberFormatDotComma},
+	{"1 234,5", service.NumberFormatSpaceComma},
+	{"1234.5（不分组）", service.NumberFormatPlain},
+}
+
+// buildUnitFormatSelects 构建速度单位与数字格式选择，作用于流量图、状态栏、悬浮窗与各类统计，切换后立即生效。
+func (sp *SettingsPage) buildUnitFormatSelects() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+	newSelect := func(options []struct{ label, value string }, current string, set func(string) error) *widget.Select {
+		labels := make([]string, len(options))
+		for i, o := range options {
+			labels[i] = o.label
+		}
+		s := widget.NewSelect(labels, nil)
+		for _, o := range options {
+			if o.value == current {
+				s.SetSelected(o.label)
+			}
+		}
+		s.OnChanged = func(label string) {
+			for _, o := range options {
+				if o.label != label || o.value == current {
+					continue
+				}
+				if err := set(o.value); err != nil {
+					if sp.appState.Window != nil {
+						dialog.ShowError(err, sp.appState.Window)
+					}
+					return
+				}
+				current = o.value
+				applyUnitFormat(sp.appState)
+				if sp.appState.TrayManager != nil {
+					sp.appState.TrayManager.ApplySpeedTitle()
+				}
+			}
+		}
+		return s
+	}
+	unitSelect := newSelect(speedUnitOptions, cs.GetSpeedUnit(), cs.SetSpeedUnit)
+	formatSelect := newSelect(numberFormatOptions, cs.GetNumberFormat(), cs.SetNumberFormat)
+	sp.registerAnchor("speedUnit", unitSelect)
+	sp.registerAnchor("numberFormat", formatSelect)
+	return container.NewGridWithColumns(2, unitSelect, formatSelect)
+}
+
+// listDensityOptions 列表密度下拉框选项与配置值的对应
+var listDensityOptions = []struct{ label, density string }{
+	{"宽松（行高 52px）", service.ListDensityComfortable},
+	{"标准（行高 40px）", service.ListDensityStandard},
+	{"紧凑（行高 32px）", service.ListDensityCompact},
+}
+
+// buildListDensitySelect 构建节点与订阅列表的密度选择，切换后两页在下次进入时按新行高重建。
+func (sp *SettingsPage) buildListDensitySelect() fyne.CanvasObject {
+	labels := make([]string, len(listDensityOptions))
+	current := listDensity(sp.appState)
+	densitySelect := widget.NewSelect(nil, nil)
+	for i, o := range listDensityOptions {
+		labels[i] = o.label
+	}
+	densitySelect.Options = labels
+	for _, o := range listDensityOptions {
+		if o.density == current {
+			densitySelect.SetSelected(o.label)
+		}
+	}
+	densitySelect.OnChanged = func(label string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		for _, o := range listDensityOptions {
+			if o.label != label || o.density == current {
+				continue
+			}
+			if err := sp.appState.ConfigService.SetListDensity(o.density); err != nil {
+				if sp.appState.Window != nil {
+					dialog.ShowError(err, sp.appState.Window)
+				}
+				return
+			}
+			current = o.density
+			if sp.appState.MainWindow != nil {
+				sp.appState.MainWindow.RebuildListPages()
+			}
+		}
+	}
+	sp.registerAnchor("listDensity", densitySelect)
+	return densitySelect
+}
+
 // buildDirectRouteContent 构建设置「直连路由」内容区。
 func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 	sp.loadRoutes()
@@ -327,17 +825,64 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 		}
 	}
 
+	// 绕过局域网与中国大陆：独立于下方直连列表，切换后立即重启运行中的代理
+	bypassCNCheck := widget.NewCheck("绕过局域网与中国大陆", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		bypassCNCheck.SetChecked(sp.appState.ConfigService.GetBypassLanAndCN())
+	}
+	bypassCNCheck.OnChanged = func(b bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetBypassLanAndCN(b)
+		if b && sp.appState.Window != nil {
+			if missing := xray.MissingGeoAssets(); len(missing) > 0 {
+				dialog.ShowInformation("缺少规则数据", fmt.Sprintf("未找到 %s，该规则暂不生效。请将文件放到 %s，或在下方指定 xray 资源目录。", strings.Join(missing, "、"), sp.appState.ConfigService.GetXrayAssetDir()), sp.appState.Window)
+			}
+		}
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("分流规则")
+		}
+	}
+
+	// 最终流量走向：对应路由最后一条规则（Clash 的 MATCH），切换后立即重启运行中的代理
+	finalValues := []string{xray.FinalOutboundProxy, xray.FinalOutboundDirect, xray.FinalOutboundBlock}
+	finalOptions := []string{"代理", "直连", "阻止"}
+	finalSelect := widget.NewSelect(finalOptions, nil)
+	finalSelect.SetSelectedIndex(0)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current := sp.appState.ConfigService.GetFinalOutbound()
+		for i, v := range finalValues {
+			if v == current {
+				finalSelect.SetSelectedIndex(i)
+			}
+		}
+	}
+	finalSelect.OnChanged = func(string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetFinalOutbound(finalValues[finalSelect.SelectedIndex()])
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("分流规则")
+		}
+	}
+	finalHint := widget.NewLabel("未命中本地地址、直连列表与「绕过局域网与中国大陆」的流量。选择直连时可把直连列表设为「不走直连」，只让列表中的站点走代理。")
+	finalHint.Wrapping = fyne.TextWrapWord
+
 	sp.routesList = widget.NewList(
 		func() int { return len(sp.routesData) },
 		func() fyne.CanvasObject {
 			textBtn := widget.NewButton("", nil)
-			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
-			return container.NewHBox(textBtn, layout.NewSpacer(), delBtn)
+			checkBtn := newIconButton(sp.appState, "检查", theme.SearchIcon(), nil)
+			delBtn := newIconButton(sp.appState, "删除", theme.DeleteIcon(), nil)
+			return container.NewHBox(textBtn, layout.NewSpacer(), checkBtn, delBtn)
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
 			row := obj.(*fyne.Container)
 			textBtn := row.Objects[0].(*widget.Button)
-			delBtn := row.Objects[2].(*widget.Button)
+			checkBtn := row.Objects[2].(*widget.Button)
+			delBtn := row.Objects[3].(*widget.Button)
 
 			if id < 0 || id >= len(sp.routesData) {
 				return
@@ -345,6 +890,7 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 			route := sp.routesData[id]
 			textBtn.SetText(route)
 			textBtn.OnTapped = func() { sp.showEditRouteDialog(id) }
+			checkBtn.OnTapped = func() { sp.checkDirectRoute(route, checkBtn) }
 			delBtn.OnTapped = func() { sp.deleteRoute(id) }
 		},
 	)
@@ -393,6 +939,151 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 		sp.reapplyPersistedSystemProxyFromConfig()
 	}
 
+	autoProbeCheck := widget.NewCheck("选中节点时自动测速", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		autoProbeCheck.SetChecked(sp.appState.ConfigService.GetAutoProbeSelectedNode())
+	}
+	autoProbeCheck.OnChanged = func(b bool) {
+		if sp.appState != nil && sp.appState.ConfigService != nil {
+			_ = sp.appState.ConfigService.SetAutoProbeSelectedNode(b)
+		}
+	}
+
+	// 连续失败自动禁用：自动测速（选中探测、一键测速）或连接节点连续失败达到阈值后禁用节点，手动测速成功后恢复
+	autoDisableOptions := []string{"不自动禁用", "连续失败 2 次", "连续失败 3 次", "连续失败 5 次", "连续失败 10 次"}
+	autoDisableValues := []int{0, 2, 3, 5, 10}
+	autoDisableSelect := widget.NewSelect(autoDisableOptions, nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current := sp.appState.ConfigService.GetAutoDisableFailThreshold()
+		for i, v := range autoDisableValues {
+			if v == current {
+				autoDisableSelect.SetSelected(autoDisableOptions[i])
+			}
+		}
+		if autoDisableSelect.Selected == "" {
+			autoDisableSelect.PlaceHolder = fmt.Sprintf("连续失败 %d 次", current)
+		}
+	}
+	autoDisableSelect.OnChanged = func(s string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		for i, label := range autoDisableOptions {
+			if label == s {
+				_ = sp.appState.ConfigService.SetAutoDisableFailThreshold(autoDisableValues[i])
+			}
+		}
+	}
+	autoDisableRow := container.NewBorder(nil, nil, widget.NewLabel("自动禁用失效节点"), nil, autoDisableSelect)
+
+	// 一键测速结果有效期：该时间内测过的节点不再重测，全部在有效期内时可选择强制重测
+	pingFreshOptions := []string{"每次全部重测", "1 分钟内", "5 分钟内", "15 分钟内", "30 分钟内"}
+	pingFreshValues := []int{0, 1, 5, 15, 30}
+	pingFreshSelect := widget.NewSelect(pingFreshOptions, nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current := int(sp.appState.ConfigService.GetPingFreshWindow().Minutes())
+		for i, v := range pingFreshValues {
+			if v == current {
+				pingFreshSelect.SetSelected(pingFreshOptions[i])
+			}
+		}
+		if pingFreshSelect.Selected == "" {
+			pingFreshSelect.PlaceHolder = fmt.Sprintf("%d 分钟内", current)
+		}
+	}
+	pingFreshSelect.OnChanged = func(s string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		for i, label := range pingFreshOptions {
+			if label == s {
+				_ = sp.appState.ConfigService.SetPingFreshMinutes(pingFreshValues[i])
+			}
+		}
+	}
+	pingFreshRow := container.NewBorder(nil, nil, widget.NewLabel("一键测速跳过近期测过的节点"), nil, pingFreshSelect)
+
+	// 其他进程修改数据库后自动刷新列表：间隔越短越及时，关闭后需重启应用才能看到外部修改
+	externalRefreshOptions := []string{"关闭", "2 秒", "5 秒", "15 秒", "60 秒"}
+	externalRefreshValues := []int{0, 2, 5, 15, 60}
+	externalRefreshSelect := widget.NewSelect(externalRefreshOptions, nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current := int(sp.appState.ConfigService.GetExternalRefreshInterval().Seconds())
+		for i, v := range externalRefreshValues {
+			if v == current {
+				externalRefreshSelect.SetSelected(externalRefreshOptions[i])
+			}
+		}
+		if externalRefreshSelect.Selected == "" {
+			externalRefreshSelect.PlaceHolder = fmt.Sprintf("%d 秒", current)
+		}
+	}
+	externalRefreshSelect.OnChanged = func(s string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		for i, label := range externalRefreshOptions {
+			if label == s {
+				_ = sp.appState.ConfigService.SetExternalRefreshSeconds(externalRefreshValues[i])
+			}
+		}
+	}
+	externalRefreshRow := container.NewBorder(nil, nil, widget.NewLabel("外部修改后自动刷新节点列表"), nil, externalRefreshSelect)
+	externalRefreshHint := widget.NewLabel("其他程序（如命令行工具、接口服务）修改了节点或订阅时，按此间隔检查并刷新列表；低功耗模式下间隔加倍。")
+	externalRefreshHint.Wrapping = fyne.TextWrapWord
+
+	// 测速方式：TLS 握手计时对 Trojan、TLS VMess 节点更接近实际使用时的建连耗时
+	latencyMethodOptions := []string{"TCP 连接", "TCP 连接 + TLS 握手"}
+	latencyMethodValues := []string{utils.DelayMethodTCP, utils.DelayMethodTLS}
+	latencyMethodSelect := widget.NewSelect(latencyMethodOptions, nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current := sp.appState.ConfigService.GetLatencyMethod()
+		for i, v := range latencyMethodValues {
+			if v == current {
+				latencyMethodSelect.SetSelected(latencyMethodOptions[i])
+			}
+		}
+	}
+	latencyMethodSelect.OnChanged = func(s string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		for i, label := range latencyMethodOptions {
+			if label == s {
+				_ = sp.appState.ConfigService.SetLatencyMethod(latencyMethodValues[i])
+			}
+		}
+	}
+	latencyMethodRow := container.NewBorder(nil, nil, withHelp(widget.NewLabel("测速方式"), "latencyMethod"), nil, latencyMethodSelect)
+	latencyMethodHint := widget.NewLabel("TLS 握手计时只对 Trojan 与开启 TLS 的 VMess 节点生效，其余节点仍测 TCP 连接；两种方式的结果不宜直接比较。")
+	latencyMethodHint.Wrapping = fyne.TextWrapWord
+
+	clipboardCheck := widget.NewCheck("检测剪贴板中的节点链接", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		clipboardCheck.SetChecked(sp.appState.ConfigService.GetClipboardMonitorEnabled())
+	}
+	clipboardCheck.OnChanged = func(b bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetClipboardMonitorEnabled(b)
+		if sp.appState.ClipboardMonitor != nil {
+			sp.appState.ClipboardMonitor.ApplyConfig()
+		}
+	}
+
+	ssrCheck := widget.NewCheck("保留不兼容的 SSR 节点（禁用状态）", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		ssrCheck.SetChecked(sp.appState.ConfigService.GetKeepUnsupportedSSR())
+	}
+	ssrCheck.OnChanged = func(b bool) {
+		if sp.appState != nil && sp.appState.ConfigService != nil {
+			_ = sp.appState.ConfigService.SetKeepUnsupportedSSR(b)
+		}
+	}
+	ssrHint := widget.NewLabel("origin 协议 + plain 混淆的 SSR 节点会按 SS 导入；其余 SSR 节点 xray 无法使用，默认跳过并在解析结果中计数。下次更新订阅时生效。")
+	ssrHint.Wrapping = fyne.TextWrapWord
+
 	gitProxyCheck := widget.NewCheck("Git 全局代理", nil)
 	if sp.appState != nil && sp.appState.ConfigService != nil {
 		gitProxyCheck.SetChecked(sp.appState.ConfigService.GetGitProxyEnabled())
@@ -422,23 +1113,71 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 	proxyTypeHint := widget.NewLabel("http：CONNECT（含 HTTPS 站点）；https_tls：代理地址为 https://（需代理端 TLS）")
 	proxyTypeHint.Wrapping = fyne.TextWrapWord
 
+	sp.registerAnchor("listenAll", listenAllCheck)
+	sp.registerAnchor("autoProbe", autoProbeCheck)
+	sp.registerAnchor("autoDisable", autoDisableSelect)
+	sp.registerAnchor("pingFresh", pingFreshSelect)
+	sp.registerAnchor("latencyMethod", latencyMethodSelect)
+	sp.registerAnchor("clipboard", clipboardCheck)
+	sp.registerAnchor("ssr", ssrCheck)
+	sp.registerAnchor("externalRefresh", externalRefreshSelect)
+	sp.registerAnchor("terminalProxy", terminalProxyCheck)
+	sp.registerAnchor("gitProxy", gitProxyCheck)
+	sp.registerAnchor("proxyType", proxyTypeSelect)
+	sp.registerAnchor("routeUseProxy", sp.routeUseProxy)
+	sp.registerAnchor("bypassCN", bypassCNCheck)
+	sp.registerAnchor("finalOutbound", finalSelect)
+	sp.registerAnchor("routeAdd", sp.routeAddEntry)
+
 	// 代理配置区域：包含"终端代理"标题、"不走直连"、"重置"按钮
 	proxyConfigArea := container.NewVBox(
 		listenAllCheck,
 		listenAllHint,
+		sp.buildLANInboundContent(),
+		sp.buildInboundAuthContent(),
+		sp.buildRateLimitContent(),
+		autoProbeCheck,
+		autoDisableRow,
+		pingFreshRow,
+		latencyMethodRow,
+		latencyMethodHint,
+		sp.buildScheduledPingContent(),
+		clipboardCheck,
+		ssrCheck,
+		ssrHint,
+		externalRefreshRow,
+		externalRefreshHint,
+		widget.NewSeparator(),
+		sp.buildBalancerContent(),
+		widget.NewSeparator(),
+		sp.buildFragmentContent(),
+		sp.buildPolicyContent(),
+		sp.buildPrewarmContent(),
+		sp.buildAutoRestartContent(),
+		widget.NewSeparator(),
+		sp.buildImportAPIContent(),
+		widget.NewSeparator(),
+		sp.buildWebDAVSyncContent(),
 		widget.NewSeparator(),
 		terminalProxyCheck,
 		container.NewVBox(
 			gitProxyCheck,
 			gitProxyHint,
 		),
+		sp.buildDevToolsContent(),
 		container.NewVBox(
 			proxyTypeLabel,
 			proxyTypeSelect,
 			proxyTypeHint,
 		),
 		widget.NewSeparator(),
-		container.NewHBox(sp.routeUseProxy, resetBtn, layout.NewSpacer()),
+		container.NewHBox(sp.routeUseProxy, withHelp(bypassCNCheck, "bypassCN"), resetBtn, layout.NewSpacer()),
+		container.NewBorder(nil, nil, withHelp(widget.NewLabel("最终流量走"), "finalOutbound"), nil, finalSelect),
+		finalHint,
+		sp.buildXrayAssetDirContent(),
+		sp.buildXrayCoreContent(),
+		sp.buildXrayAPIContent(),
+		sp.buildRouteTesterContent(),
 	)
 
 	routesLabel := widget.NewLabel("路由列表")
@@ -452,31 +1191,1500 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 	)
 }
 
-// loadRoutes 从 ConfigService 加载直连路由到 routesData。
-func (sp *SettingsPage) loadRoutes() {
-	sp.routesData = nil
-	if sp.appState != nil && sp.appState.ConfigService != nil {
-		sp.routesData = sp.appState.ConfigService.GetDirectRoutes()
-	}
-	if sp.routesData == nil {
-		sp.routesData = []string{}
-	}
-}
-
-// resetToDefaultRoutes 重置直连路由：如果当前列表中没有默认路由则添加（使用map提高效率）
-func (sp *SettingsPage) resetToDefaultRoutes() {
+// buildRateLimitContent 构建入站限速设置：上传、下载上限（KB/s，留空或 0 为不限）与仅限局域网连接；保存后重启运行中的代理。
+func (sp *SettingsPage) buildRateLimitContent() fyne.CanvasObject {
 	if sp.appState == nil || sp.appState.ConfigService == nil {
-		return
+		return container.NewVBox()
 	}
+	cs := sp.appState.ConfigService
+	current := cs.GetInboundRateLimit()
 
-	// 从 ConfigService 获取默认路由
-	defaultRoutes := sp.appState.ConfigService.GetDefaultDirectRoutes()
-	if len(defaultRoutes) == 0 {
-		return
+	formatLimit := func(kbps int) string {
+		if kbps <= 0 {
+			return ""
+		}
+		return strconv.Itoa(kbps)
 	}
-
-	// 使用map提高查找效率
-	existingRoutes := make(map[string]bool)
+	uploadEntry := widget.NewEntry()
+	uploadEntry.SetPlaceHolder("不限")
+	uploadEntry.SetText(formatLimit(current.UploadKBps))
+	downloadEntry := widget.NewEntry()
+	downloadEntry.SetPlaceHolder("不限")
+	downloadEntry.SetText(formatLimit(current.DownloadKBps))
+	lanOnlyCheck := widget.NewCheck("仅限制局域网连接（本机不受限）", nil)
+	lanOnlyCheck.SetChecked(current.LANOnly)
+
+	parseLimit := func(name, text string) (int, error) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return 0, nil
+		}
+		v, err := strconv.Atoi(text)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("%s上限需为非负整数（KB/s）", name)
+		}
+		return v, nil
+	}
+	saveBtn := widget.NewButtonWithIcon("保存限速", theme.DocumentSaveIcon(), func() {
+		upload, err := parseLimit("上传", uploadEntry.Text)
+		if err == nil {
+			var download int
+			download, err = parseLimit("下载", downloadEntry.Text)
+			if err == nil {
+				err = cs.SetInboundRateLimit(xray.RateLimitOptions{UploadKBps: upload, DownloadKBps: download, LANOnly: lanOnlyCheck.Checked})
+			}
+		}
+		if err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("入站限速")
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("上限作用于混合入站与局域网入站（所有连接共享），留空或 0 为不限。开启后 xray 改为仅监听本机随机端口，由应用在原端口转发并限速。转发只支持 TCP，限速期间入站不接受 SOCKS5 UDP（依赖 UDP 的客户端会被拒绝）；日志中的来源地址显示为本机，应用统计仍按实际来源归类。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("rateLimit", uploadEntry)
+	return container.NewVBox(
+		widget.NewLabel("入站限速"),
+		container.NewGridWithColumns(2,
+			container.NewBorder(nil, nil, widget.NewLabel("上传 KB/s"), nil, uploadEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("下载 KB/s"), nil, downloadEntry),
+		),
+		container.NewHBox(lanOnlyCheck, layout.NewSpacer(), saveBtn),
+		hint,
+	)
+}
+
+// routeOutboundLabels 路由测试结果中出站标签的显示名称
+var routeOutboundLabels = map[string]string{"proxy": "代理", "direct": "直连", "block": "阻止"}
+
+// buildRouteTesterContent 构建路由规则测试：输入域名或 IP，显示当前直连列表与分流设置下命中的规则及走向。
+func (sp *SettingsPage) buildRouteTesterContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.XrayControlService == nil {
+		return container.NewVBox()
+	}
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder("输入域名或 IP，如 www.example.com")
+	resultLabel := widget.NewLabel("")
+	resultLabel.Wrapping = fyne.TextWrapWord
+
+	var testBtn *widget.Button
+	runTest := func() {
+		target := targetEntry.Text
+		testBtn.Disable()
+		resultLabel.SetText("正在匹配…")
+		go func() {
+			// geosite 规则编译需加载数据文件，放到后台执行
+			match, err := sp.appState.XrayControlService.TestRoute(target)
+			fyne.Do(func() {
+				testBtn.Enable()
+				if err != nil {
+					resultLabel.SetText(err.Error())
+					return
+				}
+				resultLabel.SetText(formatRouteMatch(match))
+			})
+		}()
+	}
+	testBtn = widget.NewButtonWithIcon("测试", theme.SearchIcon(), runTest)
+	testBtn.Importance = widget.LowImportance
+	targetEntry.OnSubmitted = func(string) { runTest() }
+
+	sp.registerAnchor("routeTester", targetEntry)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("这个地址会走哪里？"), testBtn, targetEntry),
+		resultLabel,
+	)
+}
+
+// checkDirectRoute 对直连列表中的一条规则做直连测试：直接连接规则对应的地址，代理运行时再确认实际是否走直连。
+func (sp *SettingsPage) checkDirectRoute(route string, btn *widget.Button) {
+	if sp.appState == nil || sp.appState.XrayControlService == nil || sp.appState.Window == nil {
+		return
+	}
+	btn.Disable()
+	instance := sp.appState.XrayInstance()
+	go func() {
+		result, err := sp.appState.XrayControlService.CheckDirectRoute(route, instance)
+		fyne.Do(func() {
+			btn.Enable()
+			if err != nil {
+				dialog.ShowError(err, sp.appState.Window)
+				return
+			}
+			dialog.ShowInformation("直连测试: "+route, result.Summary(), sp.appState.Window)
+		})
+	}()
+}
+
+// formatRouteMatch 将路由测试结果格式化为一行说明。
+func formatRouteMatch(m *xray.RouteMatch) string {
+	outbound := routeOutboundLabels[m.Outbound]
+	if outbound == "" {
+		outbound = m.Outbound
+	}
+	if m.Entry == "" {
+		text := fmt.Sprintf("%s → %s（未命中分流规则，使用第 %d 条最终规则）", m.Target, outbound, m.RuleIndex)
+		if !m.IsIP {
+			text += "；域名不会解析为 IP，IP/CIDR 规则只对直接访问 IP 的连接生效"
+		}
+		return text
+	}
+	return fmt.Sprintf("%s → %s（命中第 %d 条规则：%s）", m.Target, outbound, m.RuleIndex, m.Entry)
+}
+
+// buildXrayAssetDirContent 构建 xray 资源目录设置（geoip.dat、geosite.dat 所在），显示生效目录与缺失文件；保存后重启运行中的代理。
+func (sp *SettingsPage) buildXrayAssetDirContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+
+	dirEntry := widget.NewEntry()
+	dirEntry.SetPlaceHolder("留空使用数据目录下的 xray")
+	dirEntry.SetText(cs.GetXrayAssetDirSetting())
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	refreshStatus := func() {
+		text := "生效目录: " + cs.GetXrayAssetDir()
+		if missing := xray.MissingGeoAssets(); len(missing) > 0 {
+			text += "（缺少 " + strings.Join(missing, "、") + "）"
+		}
+		statusLabel.SetText(text)
+	}
+	saveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		if err := cs.SetXrayAssetDir(dirEntry.Text); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		refreshStatus()
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("xray 资源目录")
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+	refreshStatus()
+
+	sp.registerAnchor("xrayAssetDir", dirEntry)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("xray 资源目录"), saveBtn, dirEntry),
+		statusLabel,
+	)
+}
+
+// buildXrayCoreContent 构建 xray 内核设置：内置 xray-core 或外部 xray 程序（固定版本时使用），可检测外部程序版本；保存后重启运行中的代理。
+func (sp *SettingsPage) buildXrayCoreContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+
+	modeNames := map[string]string{
+		service.XrayCoreEmbedded: "内置 xray-core",
+		service.XrayCoreExternal: "外部 xray 程序",
+	}
+	modeSelect := widget.NewSelect([]string{modeNames[service.XrayCoreEmbedded], modeNames[service.XrayCoreExternal]}, nil)
+	modeSelect.SetSelected(modeNames[cs.GetXrayCoreMode()])
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder("xray 可执行文件路径")
+	pathEntry.SetText(cs.GetXrayBinaryPath())
+	versionLabel := widget.NewLabel("")
+	versionLabel.Wrapping = fyne.TextWrapWord
+
+	selectedMode := func() string {
+		if modeSelect.Selected == modeNames[service.XrayCoreExternal] {
+			return service.XrayCoreExternal
+		}
+		return service.XrayCoreEmbedded
+	}
+	checkBtn := widget.NewButton("检测版本", func() {
+		path := strings.TrimSpace(pathEntry.Text)
+		versionLabel.SetText("检测中…")
+		go func() {
+			version, err := xray.ExternalBinaryVersion(path)
+			fyne.Do(func() {
+				if err != nil {
+					versionLabel.SetText(err.Error())
+					return
+				}
+				versionLabel.SetText(version)
+			})
+		}()
+	})
+	checkBtn.Importance = widget.LowImportance
+	saveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		if err := cs.SetXrayCore(selectedMode(), pathEntry.Text); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("xray 内核")
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	sp.registerAnchor("xrayCore", modeSelect)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("xray 内核"), nil, modeSelect),
+		container.NewBorder(nil, nil, nil, container.NewHBox(checkBtn, saveBtn), pathEntry),
+		versionLabel,
+	)
+}
+
+// buildXrayAPIContent 构建 xray gRPC API 设置：开关与本机端口，供 xray api 命令或外部工具查询统计、管理出站；保存后重启运行中的代理。
+func (sp *SettingsPage) buildXrayAPIContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+
+	apiCheck := widget.NewCheck("开启 xray gRPC API", nil)
+	apiCheck.SetChecked(cs.GetXrayAPIEnabled())
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(cs.GetXrayAPIPort()))
+	saveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil {
+			err = fmt.Errorf("API 端口需为 1024-65535 之间的整数")
+		} else {
+			err = cs.SetXrayAPI(apiCheck.Checked, port)
+		}
+		if err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("xray gRPC API")
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("仅监听 127.0.0.1，提供 HandlerService、StatsService、RoutingService、LoggerService，" +
+		"可用 xray api statsquery --server=127.0.0.1:端口 查询；不校验身份，本机其他程序均可访问。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("xrayAPI", apiCheck)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, apiCheck, saveBtn, portEntry),
+		hint,
+	)
+}
+
+// buildLANInboundContent 构建局域网入站设置：在本地混合入站之外另开一个入站（如 192.168.x.x:7890 HTTP）供局域网设备使用，
+// 与本地入站各自开关、共用路由与入站认证；保存后重启运行中的代理。
+func (sp *SettingsPage) buildLANInboundContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+	current, enabled := cs.GetLANInbound()
+
+	lanCheck := widget.NewCheck("局域网入站（与本地入站同时运行）", nil)
+	lanCheck.SetChecked(enabled)
+	listenEntry := widget.NewSelectEntry(append([]string{"0.0.0.0"}, utils.LocalIPv4Addrs()...))
+	listenEntry.SetText(current.Listen)
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(current.Port))
+	protocolLabels := []string{"HTTP", "SOCKS5 + HTTP"}
+	protocolValues := []string{xray.ExtraInboundHTTP, xray.ExtraInboundMixed}
+	protocolSelect := widget.NewSelect(protocolLabels, nil)
+	for i, v := range protocolValues {
+		if v == current.Protocol {
+			protocolSelect.SetSelected(protocolLabels[i])
+		}
+	}
+
+	save := func() {
+		protocol := xray.ExtraInboundHTTP
+		for i, label := range protocolLabels {
+			if label == protocolSelect.Selected {
+				protocol = protocolValues[i]
+			}
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil {
+			err = fmt.Errorf("端口需为 1-65535 之间的整数")
+		} else {
+			err = cs.SetLANInbound(lanCheck.Checked, listenEntry.Text, port, protocol)
+		}
+		if err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("局域网入站")
+		}
+	}
+	saveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		// 未开启入站认证时局域网内任何设备都能使用代理，开启前明确提示
+		if _, pass := cs.InboundAuth(); lanCheck.Checked && pass == "" && sp.appState.Window != nil {
+			dialog.ShowConfirm("局域网入站", "尚未开启入站认证，同一网络中的任何设备都可以使用该代理并消耗节点流量。建议先在「入站认证」中设置账号密码。仍要开启吗？", func(ok bool) {
+				if ok {
+					save()
+				}
+			}, sp.appState.Window)
+			return
+		}
+		save()
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("本机仍使用本地混合入站；局域网设备填写所选地址与端口（0.0.0.0 时为本机局域网 IP）。xray 访问日志中该入站的 tag 为 lan-in。未开启入站认证时同一网络中的任何设备都可以使用该代理。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("lanInbound", lanCheck)
+	return container.NewVBox(
+		lanCheck,
+		container.NewGridWithColumns(3, listenEntry, portEntry, protocolSelect),
+		container.NewBorder(nil, nil, nil, saveBtn, hint),
+	)
+}
+
+// buildInboundAuthContent 构建本地入站认证设置：开关、账号密码与随机密码；修改后重启运行中的代理并重新写入终端/Git 代理。
+func (sp *SettingsPage) buildInboundAuthContent() fyne.CanvasObject {
+	var cs *service.ConfigService
+	if sp.appState != nil {
+		cs = sp.appState.ConfigService
+	}
+
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder("用户名")
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetPlaceHolder("密码")
+	if cs != nil {
+		user, pass := cs.GetInboundAuthCredentials()
+		userEntry.SetText(user)
+		passEntry.SetText(pass)
+	}
+
+	// applyAuthChange 入站账号变化后重启代理，并按新账号重写终端/Git 代理地址
+	applyAuthChange := func() {
+		if sp.appState != nil && sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("入站认证")
+		}
+		sp.reapplyPersistedSystemProxyFromConfig()
+	}
+
+	authCheck := widget.NewCheck("入站认证（SOCKS5 与 HTTP 需要账号密码）", nil)
+	if cs != nil {
+		authCheck.SetChecked(cs.GetInboundAuthEnabled())
+	}
+	authCheck.OnChanged = func(b bool) {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetInboundAuthEnabled(b); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		_, pass := cs.GetInboundAuthCredentials()
+		passEntry.SetText(pass)
+		applyAuthChange()
+	}
+
+	saveBtn := widget.NewButtonWithIcon("保存账号", theme.DocumentSaveIcon(), func() {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetInboundAuthCredentials(userEntry.Text, passEntry.Text); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		if cs.GetInboundAuthEnabled() {
+			applyAuthChange()
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	randomBtn := widget.NewButtonWithIcon("随机密码", theme.ViewRefreshIcon(), func() {
+		if cs == nil {
+			return
+		}
+		pass, err := cs.ResetInboundAuthPassword()
+		if err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		passEntry.SetText(pass)
+		if cs.GetInboundAuthEnabled() {
+			applyAuthChange()
+		}
+	})
+	randomBtn.Importance = widget.LowImportance
+
+	copyBtn := widget.NewButtonWithIcon("复制代理地址", theme.ContentCopyIcon(), func() {
+		if cs == nil || sp.appState.Window == nil {
+			return
+		}
+		user, pass := cs.GetInboundAuthCredentials()
+		proxyURL := fmt.Sprintf("socks5://%s@<本机IP>:%d", url.UserPassword(user, pass).String(), cs.GetLocalInboundPort())
+		sp.appState.Window.Clipboard().SetContent(proxyURL)
+	})
+	copyBtn.Importance = widget.LowImportance
+
+	snippetsBtn := widget.NewButtonWithIcon("导出配置片段", theme.DocumentIcon(), sp.showProxySnippetsDialog)
+	snippetsBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("建议在允许局域网访问时开启。终端与 Git 代理会自动带上账号；系统代理无法携带账号，浏览器等应用会弹出认证提示。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("inboundAuth", authCheck)
+
+	return container.NewVBox(
+		authCheck,
+		container.NewGridWithColumns(2, userEntry, passEntry),
+		container.NewHBox(saveBtn, randomBtn, copyBtn, snippetsBtn, layout.NewSpacer()),
+		hint,
+	)
+}
+
+// buildDevToolsContent 构建开发工具代理：为 git、npm、Docker 客户端一键写入或移除指向本地入站的代理，写入前预览变化。
+func (sp *SettingsPage) buildDevToolsContent() fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("开发工具代理", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	hint := widget.NewLabel("写入当前本地入站的 HTTP 代理地址；移除时恢复写入前的原值。Docker 客户端设置作用于新建的容器。")
+	hint.Wrapping = fyne.TextWrapWord
+	rows := container.NewVBox(title)
+	if sp.appState == nil || sp.appState.DevToolsService == nil {
+		return rows
+	}
+	ds := sp.appState.DevToolsService
+
+	for _, tool := range systemproxy.DevTools {
+		tool := tool
+		statusLabel := widget.NewLabel("")
+		removeBtn := widget.NewButton("移除", nil)
+		removeBtn.Importance = widget.LowImportance
+		refresh := func() {
+			if ds.IsApplied(tool) {
+				statusLabel.SetText(tool.DisplayName() + "（已写入）")
+				removeBtn.Enable()
+			} else {
+				statusLabel.SetText(tool.DisplayName())
+				removeBtn.Disable()
+			}
+		}
+		applyBtn := widget.NewButton("写入", func() {
+			sp.previewDevToolChanges(tool, "写入", ds.PlanApply, ds.Apply, refresh)
+		})
+		applyBtn.Importance = widget.LowImportance
+		removeBtn.OnTapped = func() {
+			sp.previewDevToolChanges(tool, "移除", ds.PlanRemove, func(changes []systemproxy.DevToolSetting) error {
+				return ds.Remove(tool, changes)
+			}, refresh)
+		}
+		refresh()
+		rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(applyBtn, removeBtn), statusLabel))
+	}
+	rows.Add(hint)
+	sp.registerAnchor("devTools", title)
+	return rows
+}
+
+// previewDevToolChanges 在后台计算变化后弹出预览，确认后执行并回调 done。
+func (sp *SettingsPage) previewDevToolChanges(tool systemproxy.DevTool, action string,
+	plan func(systemproxy.DevTool) ([]systemproxy.DevToolSetting, error),
+	apply func([]systemproxy.DevToolSetting) error, done func()) {
+	win := sp.appState.Window
+	go func() {
+		changes, err := plan(tool)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if len(changes) == 0 {
+				dialog.ShowInformation(action+"代理", tool.DisplayName()+" 的代理设置已是目标值，无需修改。", win)
+				done()
+				return
+			}
+			var b strings.Builder
+			for _, c := range changes {
+				old, next := c.Old, c.New
+				if old == "" {
+					old = "（未设置）"
+				}
+				if next == "" {
+					next = "（移除）"
+				}
+				fmt.Fprintf(&b, "%s\n  %s\n  → %s\n", c.Key, old, next)
+			}
+			preview := widget.NewLabel(strings.TrimRight(b.String(), "\n"))
+			preview.TextStyle = fyne.TextStyle{Monospace: true}
+			scroll := container.NewScroll(preview)
+			scroll.SetMinSize(fyne.NewSize(420, 180))
+			dialog.ShowCustomConfirm(fmt.Sprintf("%s %s 代理", action, tool.DisplayName()), action, "取消", scroll, func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := apply(changes); err != nil {
+					dialog.ShowError(err, win)
+				}
+				done()
+			}, win)
+		})
+	}()
+}
+
+// showProxySnippetsDialog 展示指向本地入站的 PAC、环境变量与 Docker/git/apt/npm 配置片段，可复制或保存为文件。
+func (sp *SettingsPage) showProxySnippetsDialog() {
+	if sp.appState == nil || sp.appState.ConfigService == nil || sp.appState.Window == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+	win := sp.appState.Window
+
+	hostEntry := widget.NewEntry()
+	hostEntry.SetPlaceHolder("入站地址，默认 127.0.0.1")
+	hintLabel := widget.NewLabel("")
+	hintLabel.Wrapping = fyne.TextWrapWord
+	contentLabel := widget.NewLabel("")
+	contentLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	contentScroll := container.NewScroll(contentLabel)
+	contentScroll.SetMinSize(fyne.NewSize(460, 240))
+
+	snippets := cs.ProxySnippets(hostEntry.Text)
+	names := make([]string, len(snippets))
+	for i, s := range snippets {
+		names[i] = s.Name
+	}
+	current := 0
+	show := func() {
+		snippets = cs.ProxySnippets(hostEntry.Text)
+		hintLabel.SetText(snippets[current].Hint)
+		contentLabel.SetText(snippets[current].Content)
+		contentScroll.ScrollToTop()
+	}
+	kindSelect := widget.NewSelect(names, func(name string) {
+		for i, n := range names {
+			if n == name {
+				current = i
+			}
+		}
+		show()
+	})
+	hostEntry.OnChanged = func(string) { show() }
+
+	copyBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), func() {
+		win.Clipboard().SetContent(snippets[current].Content)
+	})
+	saveBtn := widget.NewButtonWithIcon("保存为文件", theme.DocumentSaveIcon(), func() {
+		snippet := snippets[current]
+		saveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if w == nil {
+				return
+			}
+			defer w.Close()
+			if _, err := w.Write([]byte(snippet.Content)); err != nil {
+				dialog.ShowError(fmt.Errorf("保存配置片段失败: %w", err), win)
+			}
+		}, win)
+		saveDialog.SetFileName(snippet.FileName)
+		saveDialog.Show()
+	})
+
+	kindSelect.SetSelectedIndex(0)
+	hostHint := widget.NewLabel("局域网设备使用时填写本机局域网 IP（需开启允许局域网访问）。")
+	hostHint.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewGridWithColumns(2, kindSelect, hostEntry),
+			hostHint,
+			hintLabel,
+		),
+		container.NewHBox(layout.NewSpacer(), copyBtn, saveBtn),
+		nil, nil,
+		contentScroll,
+	)
+	dialog.ShowCustom("导出配置片段", "关闭", content, win)
+}
+
+// balancerStrategyOptions 负载均衡策略的显示名称与配置值。
+var balancerStrategyOptions = []struct{ label, value string }{
+	{"最低延迟（leastPing）", xray.BalancerStrategyLeastPing},
+	{"最稳定（leastLoad）", xray.BalancerStrategyLeastLoad},
+}
+
+// balancerIntervalOptions 隧道内探测间隔可选值。
+var balancerIntervalOptions = []time.Duration{30 * time.Second, time.Minute, 5 * time.Minute, 10 * time.Minute}
+
+// buildBalancerContent 构建负载均衡组开关、策略与隧道内探测设置；修改后重新启动代理生效。
+func (sp *SettingsPage) buildBalancerContent() fyne.CanvasObject {
+	var cs *service.ConfigService
+	if sp.appState != nil {
+		cs = sp.appState.ConfigService
+	}
+
+	balancerCheck := widget.NewCheck("自动选择节点（负载均衡组）", nil)
+	strategyLabels := make([]string, 0, len(balancerStrategyOptions))
+	for _, opt := range balancerStrategyOptions {
+		strategyLabels = append(strategyLabels, opt.label)
+	}
+	strategySelect := widget.NewSelect(strategyLabels, nil)
+
+	intervalLabels := make([]string, 0, len(balancerIntervalOptions))
+	for _, d := range balancerIntervalOptions {
+		intervalLabels = append(intervalLabels, "每 "+formatProbeInterval(d))
+	}
+	intervalSelect := widget.NewSelect(intervalLabels, nil)
+
+	probeURLEntry := widget.NewSelectEntry(probeURLPresetOptions())
+	probeURLEntry.SetPlaceHolder(xray.DefaultObservatoryProbeURL)
+
+	if cs != nil {
+		balancerCheck.SetChecked(cs.GetBalancerEnabled())
+		for _, opt := range balancerStrategyOptions {
+			if opt.value == cs.GetBalancerStrategy() {
+				strategySelect.SetSelected(opt.label)
+			}
+		}
+		current := cs.GetObservatoryProbeInterval()
+		for i, d := range balancerIntervalOptions {
+			if d == current {
+				intervalSelect.SetSelected(intervalLabels[i])
+			}
+		}
+		if intervalSelect.Selected == "" {
+			intervalSelect.PlaceHolder = "每 " + formatProbeInterval(current)
+		}
+		probeURLEntry.SetText(cs.GetObservatoryProbeURL())
+	}
+
+	balancerCheck.OnChanged = func(b bool) {
+		if cs != nil {
+			_ = cs.SetBalancerEnabled(b)
+		}
+	}
+	strategySelect.OnChanged = func(s string) {
+		for _, opt := range balancerStrategyOptions {
+			if opt.label == s && cs != nil {
+				_ = cs.SetBalancerStrategy(opt.value)
+			}
+		}
+	}
+	intervalSelect.OnChanged = func(s string) {
+		for i, label := range intervalLabels {
+			if label == s && cs != nil {
+				_ = cs.SetObservatoryProbeInterval(balancerIntervalOptions[i])
+			}
+		}
+	}
+	probeURLEntry.OnSubmitted = func(s string) {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetObservatoryProbeURL(s); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		probeURLEntry.SetText(cs.GetObservatoryProbeURL())
+	}
+
+	hint := widget.NewLabel("开启后当前订阅（未选择订阅时为全部）的已启用节点组成均衡组，由 xray 在隧道内定期探测并自动选择；选中节点作为探测失败时的回退。探测地址按回车保存，修改后重新启动代理生效；也用于连接监控、节点对比与分片助手，订阅可在编辑中单独指定测速地址。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("balancer", balancerCheck)
+	sp.registerAnchor("probeURL", probeURLEntry)
+
+	return container.NewVBox(
+		withHelp(balancerCheck, "balancer"),
+		container.NewGridWithColumns(2, strategySelect, intervalSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("探测地址"), nil, probeURLEntry),
+		hint,
+	)
+}
+
+// probeURLPresetOptions 返回探测地址输入框的下拉选项（常用地址，含国内地址）。
+func probeURLPresetOptions() []string {
+	options := make([]string, len(service.ProbeURLPresets))
+	for i, p := range service.ProbeURLPresets {
+		options[i] = p.URL
+	}
+	return options
+}
+
+// formatProbeInterval 将探测间隔格式化为「30 秒」「5 分钟」。
+func formatProbeInterval(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		return fmt.Sprintf("%d 分钟", int(d/time.Minute))
+	}
+	return fmt.Sprintf("%d 秒", int(d/time.Second))
+}
+
+// buildFragmentContent 构建 TLS 分片开关与分片助手入口。
+func (sp *SettingsPage) buildFragmentContent() fyne.CanvasObject {
+	var cs *service.ConfigService
+	if sp.appState != nil {
+		cs = sp.appState.ConfigService
+	}
+
+	fragmentCheck := widget.NewCheck("TLS 分片（拆分 ClientHello）", nil)
+	paramsLabel := widget.NewLabel("")
+	refreshParams := func() {
+		if cs == nil {
+			return
+		}
+		opts := cs.GetFragmentOptions()
+		paramsLabel.SetText("当前参数: " + opts.String())
+	}
+	if cs != nil {
+		fragmentCheck.SetChecked(cs.GetFragmentEnabled())
+	}
+	refreshParams()
+	fragmentCheck.OnChanged = func(b bool) {
+		if cs == nil {
+			return
+		}
+		_ = cs.SetFragmentEnabled(b)
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("TLS 分片")
+		}
+	}
+
+	assistantBtn := widget.NewButtonWithIcon("分片助手", theme.SearchIcon(), func() {
+		sp.showFragmentAssistant(func() {
+			if cs != nil {
+				fragmentCheck.SetChecked(cs.GetFragmentEnabled())
+			}
+			refreshParams()
+		})
+	})
+	assistantBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("TLS 握手被重置（连接一建立就断开）时开启。分片助手会用选中节点逐一尝试多组分片参数，推荐延迟最低的可用组合。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("fragment", fragmentCheck)
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, assistantBtn, withHelp(fragmentCheck, "fragment")),
+		paramsLabel,
+		hint,
+	)
+}
+
+// buildPolicyContent 构建连接超时设置（秒）：握手、空闲、仅上行、仅下行；保存后重启运行中的代理。
+func (sp *SettingsPage) buildPolicyContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+
+	newSecondsEntry := func() *widget.Entry {
+		e := widget.NewEntry()
+		e.SetPlaceHolder("秒")
+		return e
+	}
+	handshakeEntry, idleEntry, uplinkEntry, downlinkEntry := newSecondsEntry(), newSecondsEntry(), newSecondsEntry(), newSecondsEntry()
+	fill := func(p xray.PolicyOptions) {
+		handshakeEntry.SetText(strconv.Itoa(int(p.Handshake / time.Second)))
+		idleEntry.SetText(strconv.Itoa(int(p.ConnIdle / time.Second)))
+		uplinkEntry.SetText(strconv.Itoa(int(p.UplinkOnly / time.Second)))
+		downlinkEntry.SetText(strconv.Itoa(int(p.DownlinkOnly / time.Second)))
+	}
+	fill(cs.GetPolicyOptions())
+
+	parseSeconds := func(name, text string) (time.Duration, error) {
+		v, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("%s超时需为非负整数（秒）", name)
+		}
+		return time.Duration(v) * time.Second, nil
+	}
+	save := func(opts xray.PolicyOptions) {
+		if err := cs.SetPolicyOptions(opts); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		fill(opts)
+		if sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.RestartXrayIfRunning("连接超时")
+		}
+	}
+	saveBtn := widget.NewButtonWithIcon("保存超时", theme.DocumentSaveIcon(), func() {
+		var opts xray.PolicyOptions
+		var err error
+		for _, f := range []struct {
+			name  string
+			entry *widget.Entry
+			dst   *time.Duration
+		}{
+			{"握手", handshakeEntry, &opts.Handshake},
+			{"空闲", idleEntry, &opts.ConnIdle},
+			{"仅上行", uplinkEntry, &opts.UplinkOnly},
+			{"仅下行", downlinkEntry, &opts.DownlinkOnly},
+		} {
+			if *f.dst, err = parseSeconds(f.name, f.entry.Text); err != nil {
+				if sp.appState.Window != nil {
+					dialog.ShowError(err, sp.appState.Window)
+				}
+				return
+			}
+		}
+		save(opts)
+	})
+	saveBtn.Importance = widget.LowImportance
+	defaultBtn := widget.NewButtonWithIcon("恢复默认", theme.ContentUndoIcon(), func() {
+		save(xray.DefaultPolicyOptions())
+	})
+	defaultBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("xray 默认空闲 300 秒才断开无数据的连接，切换网络或节点失效后连接会长时间挂起，可调短空闲超时（如 60-120 秒）。" +
+		"仅上行/仅下行为一端关闭后另一方向继续保持的时间。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("policy", idleEntry)
+	return container.NewVBox(
+		withHelp(widget.NewLabel("连接超时（秒）"), "policy"),
+		container.NewGridWithColumns(4,
+			container.NewBorder(nil, nil, widget.NewLabel("握手"), nil, handshakeEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("空闲"), nil, idleEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("仅上行"), nil, uplinkEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("仅下行"), nil, downlinkEntry),
+		),
+		container.NewHBox(saveBtn, defaultBtn, layout.NewSpacer()),
+		hint,
+	)
+}
+
+// buildPrewarmContent 构建连接预热设置：开关与预热的目标数。
+func (sp *SettingsPage) buildPrewarmContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+
+	topOptions := []string{"前 3 个站点", "前 5 个站点", "前 10 个站点"}
+	topValues := []int{3, 5, 10}
+	topSelect := widget.NewSelect(topOptions, nil)
+	current := cs.GetPrewarmTopN()
+	for i, v := range topValues {
+		if v == current {
+			topSelect.SetSelected(topOptions[i])
+		}
+	}
+	if topSelect.Selected == "" {
+		topSelect.PlaceHolder = fmt.Sprintf("前 %d 个站点", current)
+	}
+	topSelect.OnChanged = func(s string) {
+		for i, label := range topOptions {
+			if label == s {
+				_ = cs.SetPrewarmTopN(topValues[i])
+			}
+		}
+	}
+
+	prewarmCheck := widget.NewCheck("为常用站点预热连接", nil)
+	prewarmCheck.SetChecked(cs.GetPrewarmEnabled())
+	if !prewarmCheck.Checked {
+		topSelect.Disable()
+	}
+	prewarmCheck.OnChanged = func(checked bool) {
+		_ = cs.SetPrewarmEnabled(checked)
+		if checked {
+			topSelect.Enable()
+		} else {
+			topSelect.Disable()
+		}
+	}
+
+	hint := widget.NewLabel("代理运行时，按访问记录为最近 7 天经代理访问最多的站点各保持一条经节点的连接，" +
+		"让节点线路与 DNS 保持就绪，减少打开这些站点时的首次等待。预连接不计入访问记录，低功耗模式下暂停；匿名方式记录的站点无法预热。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("prewarm", prewarmCheck)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, withHelp(prewarmCheck, "prewarm"), nil, topSelect),
+		hint,
+	)
+}
+
+// buildAutoRestartContent 构建 xray 意外停止后的自动重启设置：开关与最大尝试次数。
+func (sp *SettingsPage) buildAutoRestartContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewVBox()
+	}
+	cs := sp.appState.ConfigService
+
+	retryOptions := []string{"最多 3 次", "最多 5 次", "最多 10 次", "最多 20 次"}
+	retryValues := []int{3, 5, 10, 20}
+	retrySelect := widget.NewSelect(retryOptions, nil)
+	current := cs.GetXrayAutoRestartMaxRetries()
+	for i, v := range retryValues {
+		if v == current {
+			retrySelect.SetSelected(retryOptions[i])
+		}
+	}
+	if retrySelect.Selected == "" {
+		retrySelect.PlaceHolder = fmt.Sprintf("最多 %d 次", current)
+	}
+	retrySelect.OnChanged = func(s string) {
+		for i, label := range retryOptions {
+			if label == s {
+				_ = cs.SetXrayAutoRestartMaxRetries(retryValues[i])
+			}
+		}
+	}
+
+	autoRestartCheck := widget.NewCheck("xray 意外停止时自动重启", nil)
+	autoRestartCheck.SetChecked(cs.GetXrayAutoRestart())
+	if !autoRestartCheck.Checked {
+		retrySelect.Disable()
+	}
+	autoRestartCheck.OnChanged = func(checked bool) {
+		_ = cs.SetXrayAutoRestart(checked)
+		if checked {
+			retrySelect.Enable()
+		} else {
+			retrySelect.Disable()
+		}
+	}
+
+	hint := widget.NewLabel("每 5 秒检查本地入站能否连接，连续失败视为 xray 已停止，界面随即显示未连接；" +
+		"开启自动重启时按 2 秒起逐次翻倍的间隔重试。使用手动编辑的配置时不检查。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("autoRestart", autoRestartCheck)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, autoRestartCheck, nil, retrySelect),
+		hint,
+	)
+}
+
+// showFragmentAssistant 打开分片助手：逐项展示试验结果，结束后询问是否套用推荐组合。
+// 试验使用临时 xray 实例，结束后重启正在运行的代理以恢复其日志；onApplied 在配置变更后调用。
+func (sp *SettingsPage) showFragmentAssistant(onApplied func()) {
+	if sp.appState == nil || sp.appState.XrayControlService == nil || sp.appState.ConfigService == nil || sp.appState.Window == nil {
+		return
+	}
+	var (
+		mu     sync.Mutex
+		trials []service.FragmentTrial
+	)
+	statusLabel := widget.NewLabel("正在测试不分片与各分片组合，预计需要一分钟...")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	trialList := widget.NewList(
+		func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(trials)
+		},
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			resultLabel := widget.NewLabel("")
+			resultLabel.Alignment = fyne.TextAlignTrailing
+			return container.NewBorder(nil, nil, nil, resultLabel, nameLabel)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			mu.Lock()
+			if id < 0 || id >= len(trials) {
+				mu.Unlock()
+				return
+			}
+			t := trials[id]
+			mu.Unlock()
+			result := fmt.Sprintf("%dms", t.Latency.Milliseconds())
+			if t.Err != nil {
+				result = "失败"
+			}
+			labels := collectLabelsFromObject(obj)
+			if len(labels) >= 2 {
+				labels[0].SetText(t.Options.String())
+				labels[1].SetText(result)
+			}
+		},
+	)
+	listScroll := container.NewScroll(trialList)
+	listScroll.SetMinSize(fyne.NewSize(0, 260))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := dialog.NewCustom("分片助手", "关闭", container.NewBorder(statusLabel, nil, nil, nil, listScroll), sp.appState.Window)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(420, 400))
+	d.Show()
+
+	go func() {
+		best, err := sp.appState.XrayControlService.TuneFragment(ctx, func(t service.FragmentTrial) {
+			mu.Lock()
+			trials = append(trials, t)
+			mu.Unlock()
+			fyne.Do(trialList.Refresh)
+		})
+		cancelled := ctx.Err() != nil
+		fyne.Do(func() {
+			restart := func() {
+				if sp.appState.MainWindow != nil {
+					sp.appState.MainWindow.RestartXrayIfRunning("TLS 分片")
+				}
+			}
+			switch {
+			case err != nil:
+				statusLabel.SetText(err.Error())
+				return
+			case cancelled:
+				statusLabel.SetText("已取消")
+				restart()
+				return
+			case best == nil:
+				statusLabel.SetText("所有组合均失败：节点可能不可用，或阻断与 TLS 握手无关。")
+				restart()
+				return
+			}
+			statusLabel.SetText(fmt.Sprintf("推荐: %s（%dms）", best.Options.String(), best.Latency.Milliseconds()))
+			message := fmt.Sprintf("推荐组合: %s，延迟 %dms。是否套用？", best.Options.String(), best.Latency.Milliseconds())
+			if best.Options == nil {
+				message = fmt.Sprintf("不分片即可正常连接（%dms），是否关闭 TLS 分片？", best.Latency.Milliseconds())
+			}
+			dialog.ShowConfirm("分片助手", message, func(ok bool) {
+				if ok {
+					cs := sp.appState.ConfigService
+					if best.Options == nil {
+						_ = cs.SetFragmentEnabled(false)
+					} else if err := cs.SetFragmentOptions(*best.Options); err != nil {
+						dialog.ShowError(err, sp.appState.Window)
+					} else {
+						_ = cs.SetFragmentEnabled(true)
+					}
+					if onApplied != nil {
+						onApplied()
+					}
+				}
+				restart()
+			}, sp.appState.Window)
+		})
+	}()
+}
+
+// buildImportAPIContent 构建浏览器导入接口开关与令牌操作区。
+func (sp *SettingsPage) buildImportAPIContent() fyne.CanvasObject {
+	importAPICheck := widget.NewCheck("浏览器导入接口（仅本机）", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		importAPICheck.SetChecked(sp.appState.ConfigService.GetImportAPIEnabled())
+	}
+	importAPICheck.OnChanged = func(b bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil || sp.appState.ImportAPIService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetImportAPIEnabled(b)
+		if err := sp.appState.ImportAPIService.ApplyConfig(); err != nil && sp.appState.Window != nil {
+			dialog.ShowError(err, sp.appState.Window)
+		}
+	}
+	importAPIHint := widget.NewLabel("开启后浏览器扩展或「一键导入」链接可通过 /import?url=... 添加订阅或节点，请求需携带令牌。" +
+		"「注册导入链接」让系统把 myproxy://、sub:// 等链接交给本应用打开（Windows、Linux），不需要开启导入接口。")
+	importAPIHint.Wrapping = fyne.TextWrapWord
+
+	copyURLBtn := widget.NewButtonWithIcon("复制导入地址", theme.ContentCopyIcon(), func() {
+		if sp.appState == nil || sp.appState.ImportAPIService == nil || sp.appState.Window == nil {
+			return
+		}
+		sp.appState.Window.Clipboard().SetContent(sp.appState.ImportAPIService.ImportURL())
+	})
+	copyURLBtn.Importance = widget.LowImportance
+
+	resetTokenBtn := widget.NewButtonWithIcon("重置令牌", theme.ViewRefreshIcon(), func() {
+		if sp.appState == nil || sp.appState.ImportAPIService == nil || sp.appState.Window == nil {
+			return
+		}
+		dialog.ShowConfirm("重置令牌", "重置后旧令牌立即失效，已连接的日志推送会断开，需要在浏览器扩展中更新。确定继续吗？", func(ok bool) {
+			if !ok {
+				return
+			}
+			if _, err := sp.appState.ImportAPIService.ResetToken(); err != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+		}, sp.appState.Window)
+	})
+	resetTokenBtn.Importance = widget.LowImportance
+
+	registerSchemeBtn := widget.NewButtonWithIcon("注册导入链接", theme.LoginIcon(), func() {
+		if sp.appState == nil || sp.appState.Window == nil {
+			return
+		}
+		exePath, err := os.Executable()
+		if err == nil {
+			err = urlscheme.Register(exePath)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("注册导入链接失败: %w", err), sp.appState.Window)
+			return
+		}
+		dialog.ShowInformation("注册导入链接", fmt.Sprintf("已注册 %s 链接，点击后将由本应用打开。", strings.Join(urlscheme.Schemes, "://、")+"://"), sp.appState.Window)
+	})
+	registerSchemeBtn.Importance = widget.LowImportance
+	// macOS 需要应用包声明协议，暂不支持
+	if !urlscheme.RegisterSupported() {
+		registerSchemeBtn.Disable()
+	}
+
+	metricsCheck := widget.NewCheck("Prometheus 指标（/metrics）", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		metricsCheck.SetChecked(sp.appState.ConfigService.GetMetricsEnabled())
+	}
+	metricsCheck.OnChanged = func(b bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetMetricsEnabled(b)
+	}
+	copyMetricsBtn := widget.NewButtonWithIcon("复制指标地址", theme.ContentCopyIcon(), func() {
+		if sp.appState == nil || sp.appState.ImportAPIService == nil || sp.appState.Window == nil {
+			return
+		}
+		sp.appState.Window.Clipboard().SetContent(sp.appState.ImportAPIService.MetricsURL())
+	})
+	copyMetricsBtn.Importance = widget.LowImportance
+	metricsHint := widget.NewLabel("导出连接数、流量、节点延迟与订阅更新时间，供 Prometheus/Grafana 抓取；需同时开启导入接口。")
+	metricsHint.Wrapping = fyne.TextWrapWord
+
+	statusFileCheck := widget.NewCheck("写入状态文件（status.json）", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		statusFileCheck.SetChecked(sp.appState.ConfigService.GetStatusFileEnabled())
+	}
+	statusFileCheck.OnChanged = func(b bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil || sp.appState.StatusFileService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetStatusFileEnabled(b)
+		if err := sp.appState.StatusFileService.ApplyConfig(); err != nil && sp.appState.Window != nil {
+			dialog.ShowError(err, sp.appState.Window)
+		}
+	}
+	copyStatusPathBtn := widget.NewButtonWithIcon("复制文件路径", theme.ContentCopyIcon(), func() {
+		if sp.appState == nil || sp.appState.Window == nil {
+			return
+		}
+		path := service.StatusFilePath()
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		sp.appState.Window.Clipboard().SetContent(path)
+	})
+	copyStatusPathBtn.Importance = widget.LowImportance
+	statusFileHint := widget.NewLabel("连接、断开或切换节点时立即更新，连接期间每 5 秒刷新速度与流量；包含节点名称、端口、模式与流量，" +
+		"不含服务器地址，供 polybar、BitBar 等状态栏脚本读取，无需开启导入接口。")
+	statusFileHint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("importApi", importAPICheck)
+	sp.registerAnchor("registerScheme", registerSchemeBtn)
+	sp.registerAnchor("metrics", metricsCheck)
+	sp.registerAnchor("statusFile", statusFileCheck)
+
+	return container.NewVBox(
+		importAPICheck,
+		importAPIHint,
+		container.NewHBox(copyURLBtn, resetTokenBtn, registerSchemeBtn, layout.NewSpacer()),
+		container.NewHBox(metricsCheck, copyMetricsBtn, layout.NewSpacer()),
+		metricsHint,
+		container.NewHBox(statusFileCheck, copyStatusPathBtn, layout.NewSpacer()),
+		statusFileHint,
+	)
+}
+
+// buildScheduledPingContent 构建定时测速设置：测速间隔、静默时段与仅接通电源时运行、结果 Webhook 地址与立即运行。
+func (sp *SettingsPage) buildScheduledPingContent() fyne.CanvasObject {
+	var cs *service.ConfigService
+	if sp.appState != nil {
+		cs = sp.appState.ConfigService
+	}
+
+	intervalOptions := []string{"关闭", "每 15 分钟", "每 30 分钟", "每小时", "每 3 小时", "每 6 小时", "每 12 小时", "每天"}
+	intervalValues := []time.Duration{0, 15 * time.Minute, 30 * time.Minute, time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour}
+	intervalSelect := widget.NewSelect(intervalOptions, nil)
+	if cs != nil {
+		current := cs.GetScheduledPingInterval()
+		for i, v := range intervalValues {
+			if v == current {
+				intervalSelect.SetSelected(intervalOptions[i])
+			}
+		}
+		if intervalSelect.Selected == "" {
+			intervalSelect.PlaceHolder = "每 " + current.String()
+		}
+	}
+	intervalSelect.OnChanged = func(s string) {
+		if cs == nil {
+			return
+		}
+		for i, label := range intervalOptions {
+			if label == s {
+				_ = cs.SetScheduledPingInterval(intervalValues[i])
+			}
+		}
+	}
+
+	quietEntry := widget.NewEntry()
+	quietEntry.SetPlaceHolder("静默时段（可选），如 23:00-07:00")
+	if cs != nil {
+		quietEntry.SetText(cs.GetScheduledPingQuietHours().String())
+	}
+	quietEntry.Validator = func(s string) error {
+		_, err := service.ParseQuietHours(s)
+		return err
+	}
+	quietEntry.OnSubmitted = func(s string) {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetScheduledPingQuietHours(s); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		quietEntry.SetText(cs.GetScheduledPingQuietHours().String())
+	}
+	quietSaveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() { quietEntry.OnSubmitted(quietEntry.Text) })
+	quietSaveBtn.Importance = widget.LowImportance
+
+	acOnlyCheck := widget.NewCheck("仅在接通电源时运行", nil)
+	if cs != nil {
+		acOnlyCheck.SetChecked(cs.GetScheduledPingACOnly())
+	}
+	acOnlyCheck.OnChanged = func(checked bool) {
+		if cs != nil {
+			_ = cs.SetScheduledPingACOnly(checked)
+		}
+	}
+
+	webhookEntry := widget.NewEntry()
+	webhookEntry.SetPlaceHolder("Webhook 地址（可选），每轮测速后 POST JSON 摘要")
+	if cs != nil {
+		webhookEntry.SetText(cs.GetPingWebhookURL())
+	}
+	saveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetPingWebhookURL(webhookEntry.Text); err != nil && sp.appState.Window != nil {
+			dialog.ShowError(err, sp.appState.Window)
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	var runBtn *widget.Button
+	runBtn = widget.NewButtonWithIcon("立即运行", theme.MediaPlayIcon(), func() {
+		if cs == nil || sp.appState.ScheduledPing == nil {
+			return
+		}
+		if err := cs.SetPingWebhookURL(webhookEntry.Text); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		runBtn.Disable()
+		go func() {
+			report, err := sp.appState.ScheduledPing.RunNow()
+			sp.appState.handleLatencyReport(report, err)
+			fyne.Do(func() {
+				runBtn.Enable()
+				if sp.appState.Window == nil {
+					return
+				}
+				if report == nil {
+					if err != nil {
+						dialog.ShowError(err, sp.appState.Window)
+					}
+					return
+				}
+				message := fmt.Sprintf("成功: %d 个\n失败: %d 个", report.Succeeded, report.Failed)
+				switch {
+				case err != nil:
+					message += "\n" + err.Error()
+				case cs.GetPingWebhookURL() != "":
+					message += "\n已发送到 Webhook"
+				}
+				dialog.ShowInformation("定时测速", message, sp.appState.Window)
+			})
+		}()
+	})
+	runBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("定时测速全部启用节点，结果按一键测速记录（连续失败会自动禁用）；" +
+		"低功耗模式下、静默时段内暂停，勾选仅接通电源时使用电池期间也暂停，恢复后补测错过的一轮。" +
+		"Webhook 内容包含各订阅的失败数与平均延迟、各节点延迟，不含服务器地址。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("scheduledPing", intervalSelect)
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("定时测速"), nil, intervalSelect),
+		container.NewBorder(nil, nil, nil, quietSaveBtn, quietEntry),
+		acOnlyCheck,
+		container.NewBorder(nil, nil, nil, container.NewHBox(saveBtn, runBtn), webhookEntry),
+		hint,
+	)
+}
+
+// buildWebDAVSyncContent 构建 WebDAV 同步设置：地址与账号、是否同步完整订阅地址、立即同步。
+func (sp *SettingsPage) buildWebDAVSyncContent() fyne.CanvasObject {
+	var cs *service.ConfigService
+	if sp.appState != nil {
+		cs = sp.appState.ConfigService
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://dav.example.com/remote.php/dav/files/me/myproxy/")
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder("用户名")
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetPlaceHolder("密码或应用专用密码")
+	if cs != nil {
+		endpoint, user, pass := cs.GetWebDAVEndpoint()
+		urlEntry.SetText(endpoint)
+		userEntry.SetText(user)
+		passEntry.SetText(pass)
+	}
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	showLastSync := func() {
+		if sp.appState == nil || sp.appState.WebDAVSyncService == nil {
+			return
+		}
+		if at := sp.appState.WebDAVSyncService.LastSyncAt(); !at.IsZero() {
+			statusLabel.SetText("上次同步: " + at.Format("2006-01-02 15:04"))
+		}
+	}
+	showLastSync()
+
+	syncCheck := widget.NewCheck("WebDAV 同步（设置、直连路由与订阅列表）", nil)
+	if cs != nil {
+		syncCheck.SetChecked(cs.GetWebDAVSyncEnabled())
+	}
+	syncCheck.OnChanged = func(b bool) {
+		if cs == nil {
+			return
+		}
+		_ = cs.SetWebDAVSyncEnabled(b)
+	}
+
+	credentialsCheck := widget.NewCheck("同步完整订阅地址（含令牌）", nil)
+	if cs != nil {
+		credentialsCheck.SetChecked(cs.GetWebDAVSyncCredentials())
+	}
+	credentialsCheck.OnChanged = func(b bool) {
+		if cs == nil {
+			return
+		}
+		_ = cs.SetWebDAVSyncCredentials(b)
+	}
+
+	saveBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetWebDAVEndpoint(urlEntry.Text, userEntry.Text, passEntry.Text); err != nil && sp.appState.Window != nil {
+			dialog.ShowError(err, sp.appState.Window)
+		}
+	})
+	saveBtn.Importance = widget.LowImportance
+
+	var syncBtn *widget.Button
+	syncBtn = widget.NewButtonWithIcon("立即同步", theme.ViewRefreshIcon(), func() {
+		if cs == nil || sp.appState.WebDAVSyncService == nil {
+			return
+		}
+		if err := cs.SetWebDAVEndpoint(urlEntry.Text, userEntry.Text, passEntry.Text); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		syncBtn.Disable()
+		statusLabel.SetText("正在同步…")
+		go func() {
+			result, err := sp.appState.WebDAVSyncService.Sync()
+			sp.appState.handleWebDAVSyncResult(result, err)
+			fyne.Do(func() {
+				syncBtn.Enable()
+				if err != nil {
+					statusLabel.SetText(err.Error())
+					return
+				}
+				showLastSync()
+				sp.loadRoutes()
+				if sp.routesList != nil {
+					sp.routesList.Refresh()
+				}
+				if sp.appState.Window != nil {
+					dialog.ShowInformation("WebDAV 同步", result.Summary(), sp.appState.Window)
+				}
+			})
+		}()
+	})
+	syncBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("开启后每 30 分钟自动同步。端口、入站认证等本机设置不同步；两台电脑都修改过的设置保留本机的值。" +
+		"未开启「同步完整订阅地址」时只同步订阅名称、服务商主机与规则，其他电脑需自行添加订阅。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	sp.registerAnchor("webdavSync", syncCheck)
+
+	return container.NewVBox(
+		syncCheck,
+		urlEntry,
+		container.NewGridWithColumns(2, userEntry, passEntry),
+		container.NewHBox(credentialsCheck, saveBtn, syncBtn, layout.NewSpacer()),
+		statusLabel,
+		hint,
+	)
+}
+
+// loadRoutes 从 ConfigService 加载直连路由到 routesData。
+func (sp *SettingsPage) loadRoutes() {
+	sp.routesData = nil
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		sp.routesData = sp.appState.ConfigService.GetDirectRoutes()
+	}
+	if sp.routesData == nil {
+		sp.routesData = []string{}
+	}
+}
+
+// resetToDefaultRoutes 重置直连路由：如果当前列表中没有默认路由则添加（使用map提高效率）
+func (sp *SettingsPage) resetToDefaultRoutes() {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return
+	}
+
+	// 从 ConfigService 获取默认路由
+	defaultRoutes := sp.appState.ConfigService.GetDefaultDirectRoutes()
+	if len(defaultRoutes) == 0 {
+		return
+	}
+
+	// 使用map提高查找效率
+	existingRoutes := make(map[string]bool)
 	for _, route := range sp.routesData {
 		existingRoutes[route] = true
 	}
@@ -618,12 +2826,12 @@ func isLikelyIPOrCIDR(s string) bool {
 // buildLogContent 构建设置「日志」内容区，嵌入完整日志面板用于查看日志。
 func (sp *SettingsPage) buildLogContent() fyne.CanvasObject {
 	if sp.appState != nil && sp.appState.LogsPanel != nil {
-		return sp.appState.LogsPanel.Build()
+		return sp.appState.LogsPanel.BuildEmbedded()
 	}
 	if sp.logsPanel == nil {
 		sp.logsPanel = NewLogsPanel(sp.appState)
 	}
-	return sp.logsPanel.Build()
+	return sp.logsPanel.BuildEmbedded()
 }
 
 func (sp *SettingsPage) buildDiagnosticsContent() fyne.CanvasObject {
@@ -633,15 +2841,34 @@ func (sp *SettingsPage) buildDiagnosticsContent() fyne.CanvasObject {
 	return sp.diagnosticsPage.Build()
 }
 
+func (sp *SettingsPage) buildNetworkDiagnosticsContent() fyne.CanvasObject {
+	if sp.networkDiagPage == nil {
+		sp.networkDiagPage = NewNetworkDiagnosticsPage(sp.appState)
+	}
+	return sp.networkDiagPage.Build()
+}
+
 // Cleanup 释放设置页资源。
 func (sp *SettingsPage) Cleanup() {
 	if sp.diagnosticsPage != nil {
 		sp.diagnosticsPage.Cleanup()
 		sp.diagnosticsPage = nil
 	}
+	if sp.networkDiagPage != nil {
+		sp.networkDiagPage.Cleanup()
+		sp.networkDiagPage = nil
+	}
+	sp.stopAccessLive()
 	sp.directRouteRoot = nil
 }
 
+// stopAccessLive 停止访问记录实时视图的订阅。
+func (sp *SettingsPage) stopAccessLive() {
+	if sp.accessLive != nil {
+		sp.accessLive.Stop()
+	}
+}
+
 // reloadDirectRouteListFromStore 在已缓存的代理配置面板存在时，仅重新拉取路由数据并刷新列表。
 func (sp *SettingsPage) reloadDirectRouteListFromStore() {
 	sp.loadRoutes()
@@ -658,11 +2885,38 @@ func (sp *SettingsPage) reapplyPersistedSystemProxyFromConfig() {
 
 // buildAccessRecordContent 构建设置「访问记录」内容区，展示访问的网站及累计访问次数。
 func (sp *SettingsPage) buildAccessRecordContent() fyne.CanvasObject {
-	sp.loadAccessRecords()
-
-	sp.accessRecordsList = widget.NewList(
-		func() int { return len(sp.accessRecordsData) },
-		func() fyne.CanvasObject {
+	sp.accessRecordsData = nil
+	sp.setAccessRecordGroups(nil)
+
+	// 树形展示：顶层为可注册域名（foo.com），展开后为其下各地址（api.foo.com:443、cdn.foo.com:443）；
+	// 只有一个地址的组直接显示为叶子节点。子节点 ID 为「组 ID + \x00 + 组内下标」。
+	sp.accessRecordsTree = widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			if uid == "" {
+				ids := make([]widget.TreeNodeID, len(sp.accessRecordGroups))
+				for i, g := range sp.accessRecordGroups {
+					ids[i] = g.Domain
+				}
+				return ids
+			}
+			g, ok := sp.accessRecordGroup(uid)
+			if !ok || len(g.Records) < 2 {
+				return nil
+			}
+			ids := make([]widget.TreeNodeID, len(g.Records))
+			for i := range g.Records {
+				ids[i] = uid + "\x00" + strconv.Itoa(i)
+			}
+			return ids
+		},
+		func(uid widget.TreeNodeID) bool {
+			if uid == "" {
+				return true
+			}
+			g, ok := sp.accessRecordGroup(uid)
+			return ok && len(g.Records) > 1
+		},
+		func(branch bool) fyne.CanvasObject {
 			addrLabel := widget.NewLabel("")
 			addrLabel.Wrapping = fyne.TextWrapOff
 			addrLabel.Truncation = fyne.TextTruncateEllipsis
@@ -674,81 +2928,566 @@ func (sp *SettingsPage) buildAccessRecordContent() fyne.CanvasObject {
 				addrLabel,
 			)
 		},
-		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			if id < 0 || id >= len(sp.accessRecordsData) {
-				return
-			}
-			r := sp.accessRecordsData[id]
-			displayAddr := r.Address
-			if displayAddr == "" {
-				displayAddr = r.Domain
+		func(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+			var text, countText string
+			if groupID, idx, isChild := strings.Cut(uid, "\x00"); isChild {
+				g, ok := sp.accessRecordGroup(groupID)
+				i, err := strconv.Atoi(idx)
+				if !ok || err != nil || i < 0 || i >= len(g.Records) {
+					return
+				}
+				r := g.Records[i]
+				text, countText = sp.accessRecordText(r), accessCountText(r.AccessCount, r.ProxiedCount, r.DirectCount)
+			} else {
+				g, ok := sp.accessRecordGroup(uid)
+				if !ok || len(g.Records) == 0 {
+					return
+				}
+				if len(g.Records) == 1 {
+					text, countText = sp.accessRecordText(g.Records[0]), accessCountText(g.AccessCount, g.ProxiedCount, g.DirectCount)
+				} else {
+					text, countText = sp.accessHostText(g.Domain), fmt.Sprintf("%d 个地址 · %s", len(g.Records), accessCountText(g.AccessCount, g.ProxiedCount, g.DirectCount))
+				}
 			}
-			countText := fmt.Sprintf("访问 %d 次", r.AccessCount)
 			labels := collectLabelsFromObject(obj)
 			if len(labels) >= 2 {
-				labels[0].SetText(displayAddr)
+				labels[0].SetText(text)
 				labels[1].SetText(countText)
 			}
 		},
 	)
+	// 展开的域名保存在布局配置中，重新加载记录或再次进入时恢复
+	sp.accessRecordsTree.OnBranchOpened = func(uid widget.TreeNodeID) { sp.saveAccessGroupExpanded(uid, true) }
+	sp.accessRecordsTree.OnBranchClosed = func(uid widget.TreeNodeID) { sp.saveAccessGroupExpanded(uid, false) }
 
 	clearBtn := widget.NewButtonWithIcon("清空记录", theme.DeleteIcon(), func() {
 		if sp.appState == nil || sp.appState.Window == nil {
 			return
 		}
-		dialog.ShowConfirm("清空访问记录", "确定要清空所有访问记录吗？此操作不可恢复。", func(ok bool) {
-			if !ok {
-				return
+		if sp.showAccessLive {
+			sp.accessLive.Clear()
+			return
+		}
+		if sp.showConnErrors {
+			if sp.appState.ConnErrorStats != nil {
+				sp.appState.ConnErrorStats.Reset()
 			}
-			if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.AccessRecords != nil {
-				_ = sp.appState.Store.AccessRecords.ClearAll()
-				_ = sp.appState.Store.AccessRecords.Load()
-				sp.loadAccessRecords()
-				if sp.accessRecordsList != nil {
-					sp.accessRecordsList.Refresh()
+			sp.refreshConnErrors()
+			return
+		}
+		if sp.showProcessRecords {
+			dialog.ShowConfirm("清空应用统计", "确定要清空所有应用统计吗？此操作不可恢复。", func(ok bool) {
+				if !ok || sp.appState.Store == nil || sp.appState.Store.ProcessRecords == nil {
+					return
 				}
+				_ = sp.appState.Store.ProcessRecords.ClearAll()
+				sp.reloadProcessRecordsAsync()
+			}, sp.appState.Window)
+			return
+		}
+		dialog.ShowConfirm("清空访问记录", "确定要清空所有访问记录吗？此操作不可恢复。", func(ok bool) {
+			if ok {
+				sp.purgeAccessRecords()
 			}
 		}, sp.appState.Window)
 	})
 	clearBtn.Importance = widget.LowImportance
 
 	refreshBtn := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), func() {
-		sp.loadAccessRecords()
-		if sp.accessRecordsList != nil {
-			sp.accessRecordsList.Refresh()
+		if sp.showAccessLive {
+			return
+		}
+		if sp.showConnErrors {
+			sp.refreshConnErrors()
+		} else if sp.showProcessRecords {
+			sp.reloadProcessRecordsAsync()
+		} else {
+			sp.reloadAccessRecordsAsync()
 		}
 	})
 	refreshBtn.Importance = widget.LowImportance
 
+	titleLabel := widget.NewLabel("访问的站点（按域名归并，按最近访问时间排序）")
+
+	listScroll := container.NewScroll(sp.accessRecordsTree)
+	listScroll.SetMinSize(fyne.NewSize(0, 200))
+
+	emptyState := NewEmptyState(theme.HistoryIcon(), "暂无访问记录", "启动代理后，经由本地入站访问的网站会显示在这里。", "", nil)
+	sp.accessRecordsState = NewListStateView(sp.appState, listScroll, emptyState)
+	sp.reloadAccessRecordsAsync()
+
+	processContent := sp.buildProcessRecordContent()
+	processContent.Hide()
+	domainContent := container.NewBorder(sp.buildAccessRecordModeContent(), nil, nil, nil, sp.accessRecordsState.Content())
+	connErrorContent := sp.buildConnErrorContent()
+	connErrorContent.Hide()
+	sp.accessLive = newAccessLiveView(sp.appState)
+	liveContent := sp.accessLive.Content()
+	liveContent.Hide()
+
+	viewRadio := widget.NewRadioGroup([]string{"域名维度", "应用维度", "连接错误", "实时"}, func(s string) {
+		sp.showProcessRecords = s == "应用维度"
+		sp.showConnErrors = s == "连接错误"
+		sp.showAccessLive = s == "实时"
+		domainContent.Hide()
+		processContent.Hide()
+		connErrorContent.Hide()
+		liveContent.Hide()
+		sp.accessLive.Stop()
+		refreshBtn.Show()
+		switch {
+		case sp.showAccessLive:
+			titleLabel.SetText("新建立的连接（目标地址与匹配的出站）")
+			refreshBtn.Hide()
+			liveContent.Show()
+			sp.accessLive.Start()
+		case sp.showConnErrors:
+			titleLabel.SetText("本次运行的连接错误（按出错位置与原因分类）")
+			connErrorContent.Show()
+			sp.refreshConnErrors()
+		case sp.showProcessRecords:
+			titleLabel.SetText("使用代理的应用（按连接次数排序）")
+			processContent.Show()
+			sp.reloadProcessRecordsAsync()
+		default:
+			titleLabel.SetText("访问的站点（按域名归并，按最近访问时间排序）")
+			domainContent.Show()
+		}
+	})
+	viewRadio.Horizontal = true
+	viewRadio.Required = true
+	viewRadio.SetSelected("域名维度")
+
 	topBar := container.NewHBox(
-		widget.NewLabel("访问的地址（host:port，按最近访问时间排序）"),
+		viewRadio,
+		titleLabel,
 		layout.NewSpacer(),
 		refreshBtn,
 		clearBtn,
 	)
 
-	listScroll := container.NewScroll(sp.accessRecordsList)
-	listScroll.SetMinSize(fyne.NewSize(0, 200))
-
 	return container.NewBorder(
 		container.NewVBox(topBar, NewSeparator()),
 		nil, nil, nil,
-		listScroll,
+		container.NewStack(domainContent, processContent, connErrorContent, liveContent),
 	)
 }
 
-// loadAccessRecords 从数据库刷新访问记录缓存并载入列表数据。
-func (sp *SettingsPage) loadAccessRecords() {
-	sp.accessRecordsData = nil
+// connErrorSideLabels 与 connErrorKindLabels 为连接错误表格的行、列标题
+var (
+	connErrorSideLabels = map[string]string{
+		service.ConnErrorSideNode:    "连接节点",
+		service.ConnErrorSideTarget:  "目标网站（直连）",
+		service.ConnErrorSideInbound: "本地入站",
+	}
+	connErrorKindLabels = map[string]string{
+		service.ConnErrorRefused: "拒绝",
+		service.ConnErrorTimeout: "超时",
+		service.ConnErrorDNS:     "DNS",
+		service.ConnErrorAuth:    "认证",
+		service.ConnErrorReset:   "重置",
+		service.ConnErrorOther:   "其他",
+	}
+)
+
+// buildConnErrorContent 构建「连接错误」视图：按出错位置（节点 / 目标网站 / 本地入站）与原因统计 xray 日志中的连接错误。
+func (sp *SettingsPage) buildConnErrorContent() fyne.CanvasObject {
+	sp.connErrorCells = make(map[string]*widget.Label)
+	cells := []fyne.CanvasObject{widget.NewLabel("")}
+	for _, kind := range service.ConnErrorKinds {
+		cells = append(cells, newConnErrorHeader(connErrorKindLabels[kind]))
+	}
+	cells = append(cells, newConnErrorHeader("合计"))
+	for _, side := range service.ConnErrorSides {
+		cells = append(cells, widget.NewLabelWithStyle(connErrorSideLabels[side], fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for _, kind := range append(append([]string(nil), service.ConnErrorKinds...), "total") {
+			cell := widget.NewLabel("0")
+			cell.Alignment = fyne.TextAlignTrailing
+			sp.connErrorCells[side+"/"+kind] = cell
+			cells = append(cells, cell)
+		}
+	}
+	table := container.NewGridWithColumns(len(service.ConnErrorKinds)+2, cells...)
+
+	sp.connErrorSummary = widget.NewLabel("")
+	sp.connErrorSummary.Wrapping = fyne.TextWrapWord
+	hint := widget.NewLabel("「连接节点」错误多说明节点不可用，可换节点或重新测速；「目标网站」错误多说明网站本身不可达或域名有误；" +
+		"「本地入站」为本机客户端认证失败或请求无效。经代理访问的网站不可达时，错误发生在节点一侧，本机通常看不到具体原因。")
+	hint.Wrapping = fyne.TextWrapWord
+	hint.Importance = widget.LowImportance
+
+	sp.refreshConnErrors()
+	return container.NewVScroll(container.NewVBox(sp.connErrorSummary, table, NewSeparator(), hint))
+}
+
+func newConnErrorHeader(text string) *widget.Label {
+	return widget.NewLabelWithStyle(text, fyne.TextAlignTrailing, fyne.TextStyle{Bold: true})
+}
+
+// refreshConnErrors 用最新计数刷新「连接错误」视图。
+func (sp *SettingsPage) refreshConnErrors() {
+	if sp.connErrorSummary == nil || sp.appState == nil || sp.appState.ConnErrorStats == nil {
+		return
+	}
+	snap := sp.appState.ConnErrorStats.Snapshot()
+	var failed int64
+	for _, side := range service.ConnErrorSides {
+		for _, kind := range service.ConnErrorKinds {
+			if cell := sp.connErrorCells[side+"/"+kind]; cell != nil {
+				cell.SetText(strconv.FormatInt(snap.Counts[side][kind], 10))
+			}
+		}
+		total := snap.Total(side)
+		failed += total
+		if cell := sp.connErrorCells[side+"/total"]; cell != nil {
+			cell.SetText(strconv.FormatInt(total, 10))
+		}
+	}
+	summary := fmt.Sprintf("自 %s 起共 %d 次连接错误", snap.Since.Format("01-02 15:04"), failed)
+	if sp.appState.AccessRecordService != nil {
+		summary = fmt.Sprintf("本次运行经本地入站连接 %d 次；%s", sp.appState.AccessRecordService.ConnectionCount(), summary)
+	}
+	sp.connErrorSummary.SetText(summary)
+}
+
+// accessRecordModeOptions 访问记录方式下拉框选项与配置值的对应
+var accessRecordModeOptions = []struct{ label, mode string }{
+	{"记录完整地址", service.AccessRecordModeFull},
+	{"仅记录次数（隐藏域名）", service.AccessRecordModeHashed},
+	{"不记录", service.AccessRecordModeOff},
+}
+
+// buildAccessRecordModeContent 构建访问记录方式（隐私）设置：切换为更严格的方式时询问是否清除已有记录。
+func (sp *SettingsPage) buildAccessRecordModeContent() fyne.CanvasObject {
+	labels := make([]string, len(accessRecordModeOptions))
+	for i, o := range accessRecordModeOptions {
+		labels[i] = o.label
+	}
+	current := service.AccessRecordModeFull
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		current = sp.appState.ConfigService.GetAccessRecordMode()
+	}
+	modeSelect := widget.NewSelect(labels, nil)
+	for _, o := range accessRecordModeOptions {
+		if o.mode == current {
+			modeSelect.SetSelected(o.label)
+		}
+	}
+	modeSelect.OnChanged = func(label string) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		mode := service.AccessRecordModeFull
+		for _, o := range accessRecordModeOptions {
+			if o.label == label {
+				mode = o.mode
+			}
+		}
+		if mode == current {
+			return
+		}
+		if err := sp.appState.ConfigService.SetAccessRecordMode(mode); err != nil {
+			if sp.appState.Window != nil {
+				dialog.ShowError(err, sp.appState.Window)
+			}
+			return
+		}
+		current = mode
+		if mode == service.AccessRecordModeFull || sp.appState.Window == nil {
+			return
+		}
+		dialog.ShowConfirm("清除已有记录", "新的记录方式只影响之后的访问，是否同时清除已保存的完整访问记录？", func(ok bool) {
+			if ok {
+				sp.purgeAccessRecords()
+			}
+		}, sp.appState.Window)
+	}
+	sp.registerAnchor("accessRecordMode", modeSelect)
+
+	ipCheck := widget.NewCheck("记录纯 IP 目标（显示反向解析主机名与归属地）", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		ipCheck.SetChecked(sp.appState.ConfigService.GetAccessRecordIPs())
+	}
+	ipCheck.OnChanged = func(checked bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		if err := sp.appState.ConfigService.SetAccessRecordIPs(checked); err != nil && sp.appState.Window != nil {
+			dialog.ShowError(err, sp.appState.Window)
+		}
+	}
+	sp.registerAnchor("accessRecordIPs", ipCheck)
+
+	hint := widget.NewLabel("「仅记录次数」以本机密钥对域名做哈希后保存；日志文件中的 xray 访问日志不受此设置影响。" +
+		"纯 IP 目标多为直接按 IP 连接的应用，归属地来自 xray 资源目录中的 geoip.dat，反向解析经系统 DNS 进行。")
+	hint.Wrapping = fyne.TextWrapWord
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("记录方式"), nil, modeSelect),
+		ipCheck,
+		hint,
+	)
+}
+
+// purgeAccessRecords 丢弃待写入的访问记录并清空数据库中的记录。
+func (sp *SettingsPage) purgeAccessRecords() {
+	if sp.appState == nil {
+		return
+	}
+	if sp.appState.AccessRecordService != nil {
+		sp.appState.AccessRecordService.Discard()
+	}
+	if sp.appState.Store != nil && sp.appState.Store.AccessRecords != nil {
+		_ = sp.appState.Store.AccessRecords.ClearAll()
+	}
+	sp.reloadAccessRecordsAsync()
+}
+
+// buildProcessRecordContent 构建「应用维度」视图：统计开关与按连接次数排序的应用列表。
+func (sp *SettingsPage) buildProcessRecordContent() fyne.CanvasObject {
+	sp.processRecordsData = nil
+	sp.processRecordsList = widget.NewList(
+		func() int { return len(sp.processRecordsData) },
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			countLabel := widget.NewLabel("")
+			countLabel.Alignment = fyne.TextAlignTrailing
+			return container.NewBorder(nil, nil, nil, countLabel, nameLabel)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(sp.processRecordsData) {
+				return
+			}
+			r := sp.processRecordsData[id]
+			labels := collectLabelsFromObject(obj)
+			if len(labels) >= 2 {
+				labels[0].SetText(r.Name)
+				labels[1].SetText(fmt.Sprintf("连接 %d 次 · 最近 %s", r.ConnectionCount, r.LastSeen.Format("01-02 15:04")))
+			}
+		},
+	)
+
+	statsCheck := widget.NewCheck("统计使用代理的应用（按连接来源端口查找本机进程）", nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		statsCheck.SetChecked(sp.appState.ConfigService.GetProcessStatsEnabled())
+	}
+	statsCheck.OnChanged = func(b bool) {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		_ = sp.appState.ConfigService.SetProcessStatsEnabled(b)
+		if sp.appState.ProcessStatsService != nil {
+			sp.appState.ProcessStatsService.ApplyConfig()
+		}
+	}
+	sp.registerAnchor("processStats", statsCheck)
+
+	listScroll := container.NewScroll(sp.processRecordsList)
+	listScroll.SetMinSize(fyne.NewSize(0, 200))
+	emptyState := NewEmptyState(theme.ComputerIcon(), "暂无应用统计", "开启统计并启动代理后，通过本地入站连接的应用会显示在这里；局域网设备按来源 IP 显示。", "", nil)
+	sp.processRecordsState = NewListStateView(sp.appState, listScroll, emptyState)
+
+	return container.NewBorder(statsCheck, nil, nil, nil, sp.processRecordsState.Content())
+}
+
+// reloadProcessRecordsAsync 先将内存中的统计落盘，再在后台加载应用维度统计。
+func (sp *SettingsPage) reloadProcessRecordsAsync() {
+	if sp.processRecordsState != nil {
+		sp.processRecordsState.SetLoading(true, len(sp.processRecordsData))
+	}
+	go func() {
+		var records []model.ProcessRecord
+		if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.ProcessRecords != nil {
+			if sp.appState.ProcessStatsService != nil {
+				_ = sp.appState.ProcessStatsService.Flush()
+			}
+			if err := sp.appState.Store.ProcessRecords.Load(); err != nil && sp.appState.Logger != nil {
+				sp.appState.Logger.Error("加载应用统计失败: %v", err)
+			}
+			records = sp.appState.Store.ProcessRecords.GetAll()
+		}
+		fyne.Do(func() {
+			sp.processRecordsData = records
+			if sp.processRecordsList != nil {
+				sp.processRecordsList.Refresh()
+			}
+			if sp.processRecordsState != nil {
+				sp.processRecordsState.SetLoading(false, len(sp.processRecordsData))
+			}
+		})
+	}()
+}
+
+// reloadAccessRecordsAsync 在后台从数据库加载访问记录，加载期间列表为空时显示骨架占位。
+func (sp *SettingsPage) reloadAccessRecordsAsync() {
+	if sp.accessRecordsState != nil {
+		sp.accessRecordsState.SetLoading(true, len(sp.accessRecordsData))
+	}
+	go func() {
+		records := sp.loadAccessRecords()
+		fyne.Do(func() {
+			sp.accessRecordsData = records
+			sp.setAccessRecordGroups(service.GroupAccessRecords(records))
+			if sp.accessRecordsTree != nil {
+				sp.accessRecordsTree.Refresh()
+				sp.restoreExpandedAccessGroups()
+			}
+			if sp.accessRecordsState != nil {
+				sp.accessRecordsState.SetLoading(false, len(sp.accessRecordsData))
+			}
+			sp.lookupAccessIPInfoAsync(records)
+		})
+	}()
+}
+
+// setAccessRecordGroups 更新访问记录分组及组 ID 索引。
+func (sp *SettingsPage) setAccessRecordGroups(groups []model.AccessRecordGroup) {
+	sp.accessRecordGroups = groups
+	sp.accessRecordGroupOf = make(map[string]int, len(groups))
+	for i, g := range groups {
+		sp.accessRecordGroupOf[g.Domain] = i
+	}
+}
+
+// saveAccessGroupExpanded 记录访问记录域名分组的展开状态（子节点不记录）。
+func (sp *SettingsPage) saveAccessGroupExpanded(uid widget.TreeNodeID, expanded bool) {
+	if strings.Contains(uid, "\x00") || sp.appState == nil || sp.appState.Store == nil || sp.appState.Store.Layout == nil {
+		return
+	}
+	if err := sp.appState.Store.Layout.SetGroupExpanded(store.LayoutPageSettings, uid, expanded); err != nil {
+		sp.appState.AppendLog("WARN", "app", "保存访问记录展开状态失败: "+err.Error())
+	}
+}
+
+// restoreExpandedAccessGroups 展开上次展开且仍有多个地址的域名分组。
+func (sp *SettingsPage) restoreExpandedAccessGroups() {
+	if sp.accessRecordsTree == nil || sp.appState == nil || sp.appState.Store == nil || sp.appState.Store.Layout == nil {
+		return
+	}
+	for _, domain := range sp.appState.Store.Layout.ExpandedGroups(store.LayoutPageSettings) {
+		if g, ok := sp.accessRecordGroup(domain); ok && len(g.Records) > 1 && !sp.accessRecordsTree.IsBranchOpen(domain) {
+			sp.accessRecordsTree.OpenBranch(domain)
+		}
+	}
+}
+
+// accessRecordGroup 按组节点 ID 查找分组。
+func (sp *SettingsPage) accessRecordGroup(id string) (model.AccessRecordGroup, bool) {
+	i, ok := sp.accessRecordGroupOf[id]
+	if !ok || i >= len(sp.accessRecordGroups) {
+		return model.AccessRecordGroup{}, false
+	}
+	return sp.accessRecordGroups[i], true
+}
+
+// accessRecordAddress 返回访问记录的展示地址（host:port，旧数据为域名）。
+func accessRecordAddress(r model.AccessRecord) string {
+	if r.Address != "" {
+		return r.Address
+	}
+	return r.Domain
+}
+
+// maxAccessIPLookups 每次加载访问记录后最多查询的 IP 数
+const maxAccessIPLookups = 200
+
+// accessRecordText 返回访问记录的展示文字：地址，纯 IP 目标附上反向解析主机名与归属地。
+func (sp *SettingsPage) accessRecordText(r model.AccessRecord) string {
+	address := accessRecordAddress(r)
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	if extra := sp.accessIPInfoText(host); extra != "" {
+		return address + " · " + extra
+	}
+	return address
+}
+
+// accessHostText 返回分组主机名的展示文字，IP 分组附上反向解析主机名与归属地。
+func (sp *SettingsPage) accessHostText(host string) string {
+	if extra := sp.accessIPInfoText(host); extra != "" {
+		return host + " · " + extra
+	}
+	return host
+}
+
+// accessIPInfoText 返回 IP 的「主机名 · 归属地」，尚未查询或不是 IP 时为空。
+func (sp *SettingsPage) accessIPInfoText(host string) string {
+	info, ok := sp.accessIPInfo[host]
+	if !ok {
+		return ""
+	}
+	var parts []string
+	if info.Hostname != "" {
+		parts = append(parts, info.Hostname)
+	}
+	switch info.Country {
+	case "":
+	case "PRIVATE":
+		parts = append(parts, "局域网")
+	default:
+		parts = append(parts, info.Country)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// lookupAccessIPInfoAsync 在后台查询访问记录中纯 IP 目标的反向解析与归属地，完成后刷新列表。
+func (sp *SettingsPage) lookupAccessIPInfoAsync(records []model.AccessRecord) {
+	if sp.appState == nil || sp.appState.AccessRecordService == nil {
+		return
+	}
+	var ips []string
+	seen := make(map[string]bool)
+	for _, r := range records {
+		host, _, err := net.SplitHostPort(accessRecordAddress(r))
+		if err != nil || seen[host] || !service.IsIPHost(host) {
+			continue
+		}
+		seen[host] = true
+		if ips = append(ips, host); len(ips) >= maxAccessIPLookups {
+			break
+		}
+	}
+	if len(ips) == 0 {
+		return
+	}
+	go func() {
+		info := sp.appState.AccessRecordService.LookupIPInfo(context.Background(), ips)
+		fyne.Do(func() {
+			sp.accessIPInfo = info
+			if sp.accessRecordsTree != nil {
+				sp.accessRecordsTree.Refresh()
+			}
+		})
+	}()
+}
+
+// accessCountText 返回访问次数说明；有出站信息时附上经代理、直连的次数（旧记录没有出站信息）。
+func accessCountText(total, proxied, direct int64) string {
+	if proxied+direct == 0 {
+		return fmt.Sprintf("访问 %d 次", total)
+	}
+	return fmt.Sprintf("访问 %d 次（代理 %d · 直连 %d）", total, proxied, direct)
+}
+
+// loadAccessRecords 从数据库刷新访问记录缓存并返回列表数据（可在后台协程调用）。
+func (sp *SettingsPage) loadAccessRecords() []model.AccessRecord {
+	var records []model.AccessRecord
 	if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.AccessRecords != nil {
+		// 先落盘写缓冲，列表包含刚发生的访问
+		if sp.appState.AccessRecordService != nil {
+			_ = sp.appState.AccessRecordService.Flush()
+		}
 		if err := sp.appState.Store.AccessRecords.Load(); err != nil && sp.appState.Logger != nil {
 			sp.appState.Logger.Error("加载访问记录失败: %v", err)
 		}
-		sp.accessRecordsData = sp.appState.Store.AccessRecords.GetAll()
+		records = sp.appState.Store.AccessRecords.GetAll()
 	}
-	if sp.accessRecordsData == nil {
-		sp.accessRecordsData = []model.AccessRecord{}
+	if records == nil {
+		records = []model.AccessRecord{}
 	}
+	return records
 }
 
 // collectLabelsFromObject 递归收集 CanvasObject 树中的 *widget.Label，保持遍历顺序。
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -13,14 +14,19 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/subscription"
+	"myproxy.com/p/internal/urlscheme"
 )
 
 // SubscriptionPage 订阅管理页面
 type SubscriptionPage struct {
-	appState *AppState
-	list     *widget.List
-	content  fyne.CanvasObject
-	listener binding.DataListener
+	appState  *AppState
+	list      *widget.List
+	listState *ListStateView // 列表三态（加载骨架 / 空状态 / 列表）
+	content   fyne.CanvasObject
+	listener  binding.DataListener
 }
 
 // NewSubscriptionPage 创建订阅管理页面
@@ -37,6 +43,9 @@ func NewSubscriptionPage(appState *AppState) *SubscriptionPage {
 				if sp.list != nil {
 					sp.list.Refresh()
 				}
+				if sp.listState != nil {
+					sp.listState.Sync(sp.getSubscriptionCount())
+				}
 			})
 		})
 		appState.Store.Subscriptions.SubscriptionsBinding.AddListener(sp.listener)
@@ -58,7 +67,7 @@ func (sp *SubscriptionPage) Cleanup() {
 func (sp *SubscriptionPage) Build() fyne.CanvasObject {
 	pad := innerPadding(sp.appState)
 	// 1. 返回按钮
-	backBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+	backBtn := newIconButton(sp.appState, "返回", theme.NavigateBackIcon(), func() {
 		if sp.appState != nil && sp.appState.MainWindow != nil {
 			sp.appState.MainWindow.Back()
 		}
@@ -72,12 +81,32 @@ func (sp *SubscriptionPage) Build() fyne.CanvasObject {
 	batchUpdateBtn := widget.NewButtonWithIcon("全部更新", theme.ViewRefreshIcon(), sp.batchUpdateSubscriptions)
 	batchUpdateBtn.Importance = widget.LowImportance
 
+	shareBtn := widget.NewButtonWithIcon("分享配置", theme.MailForwardIcon(), func() { showShareProfileDialog(sp.appState) })
+	shareBtn.Importance = widget.LowImportance
+
+	importBtn := widget.NewButtonWithIcon("从其他客户端导入", theme.FolderOpenIcon(), func() { showClientImportDialog(sp.appState, sp.Refresh) })
+	importBtn.Importance = widget.LowImportance
+
+	providersBtn := newIconButton(sp.appState, "服务商概览", theme.InfoIcon(), func() {
+		if sp.appState != nil && sp.appState.MainWindow != nil {
+			sp.appState.MainWindow.ShowProvidersPage()
+		}
+	})
+	providersBtn.Importance = widget.LowImportance
+
+	trashBtn := newIconButton(sp.appState, "回收站", theme.DeleteIcon(), func() { showTrashDialog(sp.appState) })
+	trashBtn.Importance = widget.LowImportance
+
 	// 合并返回按钮和操作工具栏到一行
 	headerBar := container.NewHBox(
 		backBtn,
 		layout.NewSpacer(),
 		addBtn,
 		batchUpdateBtn,
+		importBtn,
+		shareBtn,
+		providersBtn,
+		trashBtn,
 	)
 
 	// 组合头部区域
@@ -97,15 +126,31 @@ func (sp *SubscriptionPage) Build() fyne.CanvasObject {
 	// 包装在滚动容器中并设置最小尺寸确保布局占满
 	scrollList := container.NewScroll(sp.list)
 
+	emptyState := NewEmptyState(theme.StorageIcon(), "还没有订阅", "添加订阅链接后会自动拉取节点。", "添加订阅", sp.showAddSubscriptionDialog)
+	sp.listState = NewListStateView(sp.appState, scrollList, emptyState)
+
 	sp.content = container.NewBorder(
 		headerStack,
 		nil, nil, nil,
-		newPaddedWithSize(scrollList, pad),
+		newPaddedWithSize(sp.listState.Content(), pad),
 	)
 
+	sp.loadSubscriptionsAsync()
+
 	return sp.content
 }
 
+// loadSubscriptionsAsync 在后台从数据库重新加载订阅，加载期间列表为空时显示骨架占位。
+func (sp *SubscriptionPage) loadSubscriptionsAsync() {
+	sp.listState.SetLoading(true, sp.getSubscriptionCount())
+	go func() {
+		sp.loadSubscriptions()
+		fyne.Do(func() {
+			sp.listState.SetLoading(false, sp.getSubscriptionCount())
+		})
+	}()
+}
+
 // loadSubscriptions 从 Store 加载订阅（Store 已经维护了绑定，这里只是确保数据最新）
 func (sp *SubscriptionPage) loadSubscriptions() {
 	if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
@@ -140,10 +185,61 @@ func (sp *SubscriptionPage) Refresh() {
 
 // showAddSubscriptionDialog 修复逻辑：支持添加重复URL作为新订阅
 func (sp *SubscriptionPage) showAddSubscriptionDialog() {
+	sp.showAddSubscriptionDialogWithValues("", "")
+}
+
+// ShowImportDialog 根据导入链接弹出预填的导入对话框：订阅地址走「添加新订阅」，分享链接走「导入节点」，
+// 分享配置走「导入分享配置」。
+func (sp *SubscriptionPage) ShowImportDialog(link *urlscheme.Link) {
+	if link == nil {
+		return
+	}
+	if link.Profile != "" {
+		showImportProfileDialog(sp.appState, link.Profile, sp.Refresh)
+		return
+	}
+	if link.IsSubscription() {
+		sp.showAddSubscriptionDialogWithValues(link.Target, link.Label)
+		return
+	}
+	sp.showImportNodeDialog(link.Target)
+}
+
+// showImportNodeDialog 弹出导入单个节点的对话框，确认后解析分享链接并保存为独立节点。
+func (sp *SubscriptionPage) showImportNodeDialog(link string) {
+	linkEntry := widget.NewMultiLineEntry()
+	linkEntry.SetText(link)
+	linkEntry.Wrapping = fyne.TextWrapBreak
+
+	items := []*widget.FormItem{
+		{Text: "分享链接", Widget: linkEntry},
+	}
+
+	d := dialog.NewForm("导入节点", "确定导入", "取消", items, func(ok bool) {
+		if !ok || linkEntry.Text == "" || sp.appState == nil || sp.appState.SubscriptionService == nil {
+			return
+		}
+		node, err := sp.appState.SubscriptionService.ImportShareLink(linkEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			return
+		}
+		sp.appState.AppendLog("INFO", "app", fmt.Sprintf("已导入节点: %s", node.Name))
+		dialog.ShowInformation("导入节点", fmt.Sprintf("已导入节点: %s", node.Name), sp.appState.Window)
+	}, sp.appState.Window)
+
+	d.Resize(fyne.NewSize(420, 240))
+	d.Show()
+}
+
+// showAddSubscriptionDialogWithValues 弹出添加订阅对话框，url/label 非空时预填（用于导入链接）。
+func (sp *SubscriptionPage) showAddSubscriptionDialogWithValues(url, label string) {
 	urlEntry := widget.NewEntry()
 	urlEntry.SetPlaceHolder("https://...")
+	urlEntry.SetText(url)
 	labelEntry := widget.NewEntry()
 	labelEntry.SetPlaceHolder("订阅名称")
+	labelEntry.SetText(label)
 
 	items := []*widget.FormItem{
 		{Text: "名称", Widget: labelEntry},
@@ -165,7 +261,9 @@ func (sp *SubscriptionPage) showAddSubscriptionDialog() {
 				}
 
 				// 立即执行一次抓取（通过 Store）
-				if err := sp.appState.Store.Subscriptions.Fetch(urlEntry.Text, labelEntry.Text); err != nil {
+				report, err := sp.appState.Store.Subscriptions.Fetch(urlEntry.Text, labelEntry.Text)
+				sp.reportParseResult(labelEntry.Text, report)
+				if err != nil {
 					fyne.Do(func() { dialog.ShowError(err, sp.appState.Window) })
 					return
 				}
@@ -206,20 +304,64 @@ func (sp *SubscriptionPage) batchUpdateSubscriptions() {
 			if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
 				subs = sp.appState.Store.Subscriptions.GetAll()
 			}
+			total := &subscription.ParseReport{}
 			for _, sub := range subs {
 				if sp.appState != nil && sp.appState.SubscriptionService != nil {
-					if err := sp.appState.SubscriptionService.UpdateByID(sub.ID); err != nil {
+					report, err := sp.appState.SubscriptionService.UpdateByID(sub.ID)
+					sp.logParseReport(sub.Label, report)
+					total.Merge(report)
+					if err != nil {
 						fyne.Do(func() {
 							dialog.ShowError(fmt.Errorf("更新订阅失败: %w", err), sp.appState.Window)
 						})
 					}
 				}
 			}
+			sp.showParseReport("全部订阅", total)
 			fyne.Do(func() { sp.Refresh() })
 		}()
 	}, sp.appState.Window)
 }
 
+// reportParseResult 将单个订阅的解析报告写入日志，并在有跳过项时弹出摘要对话框。
+func (sp *SubscriptionPage) reportParseResult(label string, report *subscription.ParseReport) {
+	sp.logParseReport(label, report)
+	sp.showParseReport(label, report)
+}
+
+// logParseReport 将解析摘要与逐行错误写入应用日志。
+func (sp *SubscriptionPage) logParseReport(label string, report *subscription.ParseReport) {
+	if report == nil || sp.appState == nil {
+		return
+	}
+	level := "INFO"
+	if report.Skipped() > 0 {
+		level = "WARN"
+	}
+	sp.appState.AppendLog(level, "app", fmt.Sprintf("订阅 [%s] 解析结果: %s", label, report.Summary()))
+	if !report.Diff.IsEmpty() {
+		sp.appState.AppendLog("INFO", "app", fmt.Sprintf("订阅 [%s] %s", label, report.Diff.Summary()))
+	}
+	for _, line := range report.Details() {
+		sp.appState.AppendLog("WARN", "app", fmt.Sprintf("订阅 [%s] %s", label, line))
+	}
+}
+
+// showParseReport 有跳过项时弹出解析摘要对话框；全部导入成功时不打扰用户。
+func (sp *SubscriptionPage) showParseReport(label string, report *subscription.ParseReport) {
+	if report == nil || report.Skipped() == 0 || sp.appState == nil || sp.appState.Window == nil {
+		return
+	}
+	msg := report.Summary()
+	if hint := report.Hint(); hint != "" {
+		msg += "\n\n" + hint
+	}
+	msg += "\n\n逐行错误详见日志。"
+	fyne.Do(func() {
+		dialog.ShowInformation(fmt.Sprintf("订阅解析结果 - %s", label), msg, sp.appState.Window)
+	})
+}
+
 // --- SubscriptionCard 内部组件 ---
 
 type SubscriptionCard struct {
@@ -234,8 +376,11 @@ type SubscriptionCard struct {
 	urlLabel  *widget.Label
 	statusBar *canvas.Rectangle
 	bgRect    *canvas.Rectangle // 背景矩形，用于主题切换时重绘
+	diffBtn   *widget.Button    // 最近一次更新的节点变化摘要，点击查看明细
 
 	updateBtn *widget.Button
+	exportBtn *widget.Button
+	lockBtn   *widget.Button // 锁定后编辑、删除按钮置灰，解锁需确认
 	editBtn   *widget.Button
 	deleteBtn *widget.Button
 }
@@ -248,6 +393,9 @@ func NewSubscriptionCard(page *SubscriptionPage, appState *AppState) *Subscripti
 	card.urlLabel.Truncation = fyne.TextTruncateEllipsis
 
 	card.infoLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{})
+	card.diffBtn = widget.NewButton("", card.showDiffDialog)
+	card.diffBtn.Importance = widget.LowImportance
+	card.diffBtn.Hide()
 
 	primaryColor := CurrentThemeColor(appState.App, theme.ColorNamePrimary)
 	card.statusBar = canvas.NewRectangle(primaryColor)
@@ -255,13 +403,19 @@ func NewSubscriptionCard(page *SubscriptionPage, appState *AppState) *Subscripti
 	card.statusBar.CornerRadius = 2 // 极简柔光：左侧绿条圆角 2px
 
 	// 微型化图标按钮
-	card.updateBtn = widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), nil)
+	card.updateBtn = newIconButton(appState, "更新", theme.ViewRefreshIcon(), nil)
 	card.updateBtn.Importance = widget.LowImportance
 
-	card.editBtn = widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil)
+	card.exportBtn = newIconButton(appState, "导出", theme.DocumentSaveIcon(), nil)
+	card.exportBtn.Importance = widget.LowImportance
+
+	card.lockBtn = widget.NewButton("", nil)
+	card.lockBtn.Importance = widget.LowImportance
+
+	card.editBtn = newIconButton(appState, "编辑", theme.DocumentCreateIcon(), nil)
 	card.editBtn.Importance = widget.LowImportance
 
-	card.deleteBtn = widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+	card.deleteBtn = newIconButton(appState, "删除", theme.DeleteIcon(), nil)
 	card.deleteBtn.Importance = widget.DangerImportance // 红色警告背景，白色前景
 
 	card.renderObj = card.setupLayout()
@@ -275,17 +429,25 @@ func (card *SubscriptionCard) setupLayout() fyne.CanvasObject {
 	card.bgRect.CornerRadius = 10
 	bg := card.bgRect
 
-	// 文字信息排版
-	textInfo := container.NewVBox(
-		card.nameLabel,
-		card.urlLabel,
-		container.NewHBox(widget.NewIcon(theme.InfoIcon()), card.infoLabel),
-	)
+	// 文字信息排版：按列表密度减少行数，宽松显示名称、地址、统计三行，标准省略地址，紧凑合为一行
+	infoRow := container.NewHBox(widget.NewIcon(theme.InfoIcon()), card.infoLabel, card.diffBtn)
+	var textInfo fyne.CanvasObject
+	switch listDensity(card.appState) {
+	case service.ListDensityCompact:
+		textInfo = container.NewBorder(nil, nil, card.nameLabel, nil, infoRow)
+	case service.ListDensityStandard:
+		textInfo = container.NewVBox(card.nameLabel, infoRow)
+	default:
+		textInfo = container.NewVBox(card.nameLabel, card.urlLabel, infoRow)
+	}
+	_, pad := listRowMetrics(card.appState)
 
 	// 右侧按钮组，水平排列，使用 Center 垂直居中避免占据整个容器高度
 	btnBox := container.NewCenter(
 		container.NewHBox(
 			card.updateBtn,
+			card.exportBtn,
+			card.lockBtn,
 			card.editBtn,
 			card.deleteBtn,
 		),
@@ -295,7 +457,7 @@ func (card *SubscriptionCard) setupLayout() fyne.CanvasObject {
 		nil, nil,
 		card.statusBar,
 		btnBox,
-		newPaddedWithSize(textInfo, innerPadding(card.appState)),
+		newPaddedWithSize(textInfo, pad),
 	)
 
 	return container.NewStack(bg, content)
@@ -332,14 +494,26 @@ func (card *SubscriptionCard) Update(sub *database.Subscription) {
 	if !sub.UpdatedAt.IsZero() {
 		lastUpdate = card.formatTime(sub.UpdatedAt)
 	}
-	card.infoLabel.SetText(fmt.Sprintf("%d 节点 · 更新于 %s", nodeCount, lastUpdate))
+	info := fmt.Sprintf("%d 节点 · 更新于 %s", nodeCount, lastUpdate)
+	if sub.Locked {
+		info += " · 已锁定"
+	}
+	card.infoLabel.SetText(info)
+	if sub.LastDiff.IsEmpty() {
+		card.diffBtn.Hide()
+	} else {
+		card.diffBtn.SetText(sub.LastDiff.Summary())
+		card.diffBtn.Show()
+	}
 
 	// 绑定事件 (基于 ID 操作)
 	card.updateBtn.OnTapped = func() {
 		card.updateBtn.Disable()
 		go func() {
 			if card.page != nil && card.page.appState != nil && card.page.appState.SubscriptionService != nil {
-				if err := card.page.appState.SubscriptionService.UpdateByID(sub.ID); err != nil {
+				report, err := card.page.appState.SubscriptionService.UpdateByID(sub.ID)
+				card.page.reportParseResult(sub.Label, report)
+				if err != nil {
 					fyne.Do(func() {
 						card.updateBtn.Enable()
 						dialog.ShowError(fmt.Errorf("更新订阅失败: %w", err), card.page.appState.Window)
@@ -355,10 +529,54 @@ func (card *SubscriptionCard) Update(sub *database.Subscription) {
 		}()
 	}
 
+	card.exportBtn.OnTapped = func() {
+		if card.appState == nil || card.appState.Store == nil || card.appState.Store.Nodes == nil {
+			return
+		}
+		nodes, err := card.appState.Store.Nodes.GetBySubscriptionID(sub.ID)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("读取订阅节点失败: %w", err), card.appState.Window)
+			return
+		}
+		showNodeExportDialog(card.appState, sub.Label, nodes, sub.Label)
+	}
+
 	card.editBtn.OnTapped = card.showEditDialog
 
+	// 锁定：防止误编辑、误删除（如公司统一下发的订阅），解锁需确认
+	if sub.Locked {
+		card.lockBtn.SetText("解锁")
+		card.editBtn.Disable()
+		card.deleteBtn.Disable()
+	} else {
+		card.lockBtn.SetText("锁定")
+		card.editBtn.Enable()
+		card.deleteBtn.Enable()
+	}
+	card.lockBtn.OnTapped = func() {
+		if card.appState == nil || card.appState.Store == nil || card.appState.Store.Subscriptions == nil {
+			return
+		}
+		setLocked := func(locked bool) {
+			if err := card.appState.Store.Subscriptions.SetLocked(sub.ID, locked); err != nil {
+				dialog.ShowError(err, card.appState.Window)
+				return
+			}
+			card.page.Refresh()
+		}
+		if !sub.Locked {
+			setLocked(true)
+			return
+		}
+		dialog.ShowConfirm("解锁订阅", fmt.Sprintf("解锁后可以编辑或删除订阅 '%s'，确定解锁吗？", sub.Label), func(ok bool) {
+			if ok {
+				setLocked(false)
+			}
+		}, card.appState.Window)
+	}
+
 	card.deleteBtn.OnTapped = func() {
-		msg := fmt.Sprintf("确定删除订阅 '%s' 吗？\n下属的 %d 个节点将被移除。", sub.Label, nodeCount)
+		msg := fmt.Sprintf("确定删除订阅 '%s' 吗？\n订阅及下属的 %d 个节点将移入回收站，%d 天内可恢复。", sub.Label, nodeCount, int(model.TrashRetention.Hours()/24))
 		dialog.ShowConfirm("删除确认", msg, func(ok bool) {
 			if ok {
 				// 通过 Store 删除订阅（会自动更新数据库和绑定）
@@ -380,6 +598,37 @@ func (card *SubscriptionCard) Update(sub *database.Subscription) {
 	}
 }
 
+// showDiffDialog 展示最近一次更新新增、移除与变更的节点名称。
+func (card *SubscriptionCard) showDiffDialog() {
+	if card.sub == nil || card.sub.LastDiff.IsEmpty() || card.appState == nil || card.appState.Window == nil {
+		return
+	}
+	diff := card.sub.LastDiff
+	var b strings.Builder
+	section := func(title string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s（%d）\n", title, len(names))
+		for _, name := range names {
+			b.WriteString("  " + name + "\n")
+		}
+	}
+	section("新增", diff.Added)
+	section("移除", diff.Removed)
+	section("变更（地址/端口/协议）", diff.Changed)
+
+	text := widget.NewLabel(strings.TrimRight(b.String(), "\n"))
+	text.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(text)
+	scroll.SetMinSize(fyne.NewSize(360, 280))
+	title := fmt.Sprintf("%s · %s", card.sub.Label, card.formatTime(diff.At))
+	dialog.ShowCustom(title, "关闭", scroll, card.appState.Window)
+}
+
 func (card *SubscriptionCard) showEditDialog() {
 	urlEntry := widget.NewEntry()
 	urlEntry.SetText(card.sub.URL)
@@ -388,9 +637,42 @@ func (card *SubscriptionCard) showEditDialog() {
 	labelEntry.SetText(card.sub.Label)
 	labelEntry.SetPlaceHolder("订阅名称")
 
+	// 节点过滤规则（正则），非法时表单无法提交
+	includeEntry := widget.NewEntry()
+	includeEntry.SetText(card.sub.IncludeFilter)
+	includeEntry.SetPlaceHolder("如 香港|HK，留空不限制")
+	includeEntry.Validator = subscription.ValidateFilterPattern
+	excludeEntry := widget.NewEntry()
+	excludeEntry.SetText(card.sub.ExcludeFilter)
+	excludeEntry.SetPlaceHolder("如 到期|官网|流量")
+	excludeEntry.Validator = subscription.ValidateFilterPattern
+
+	// 节点改名规则：原始名称会保留，节点搜索仍可按原名匹配
+	stripEmojiCheck := widget.NewCheck("去除 emoji", nil)
+	stripEmojiCheck.SetChecked(card.sub.RenameRules.StripEmoji)
+	removeEntry := widget.NewEntry()
+	removeEntry.SetText(card.sub.RenameRules.RemovePattern)
+	removeEntry.SetPlaceHolder("如 ^【.*?】")
+	removeEntry.Validator = subscription.ValidateFilterPattern
+	templateEntry := widget.NewEntry()
+	templateEntry.SetText(card.sub.RenameRules.Template)
+	templateEntry.SetPlaceHolder("如 {label} {index} {name}")
+
+	// 测速地址：覆盖全局探测地址，如国内中转线路访问 gstatic 不稳定时改用国内地址
+	testURLEntry := widget.NewSelectEntry(probeURLPresetOptions())
+	testURLEntry.SetText(card.sub.TestURL)
+	testURLEntry.SetPlaceHolder("留空使用全局探测地址")
+	testURLEntry.Validator = subscription.ValidateTestURL
+
 	items := []*widget.FormItem{
 		{Text: "名称", Widget: labelEntry},
 		{Text: "链接", Widget: urlEntry},
+		{Text: "仅包含", Widget: includeEntry, HintText: "按节点名称匹配的正则，更新订阅后生效"},
+		{Text: "排除", Widget: excludeEntry},
+		{Text: "改名", Widget: stripEmojiCheck},
+		{Text: "移除片段", Widget: removeEntry, HintText: "正则，匹配部分从名称中删除"},
+		{Text: "名称模板", Widget: templateEntry, HintText: "支持 {name}、{label}、{index}，留空保持名称"},
+		{Text: "测速地址", Widget: testURLEntry, HintText: "连接监控、均衡组探测与节点对比使用，留空使用设置中的探测地址"},
 	}
 
 	d := dialog.NewForm("编辑订阅", "确认", "取消", items, func(ok bool) {
@@ -404,6 +686,23 @@ func (card *SubscriptionCard) showEditDialog() {
 				dialog.ShowError(err, card.page.appState.Window)
 				return
 			}
+			if err := card.page.appState.Store.Subscriptions.UpdateFilters(card.sub.ID, includeEntry.Text, excludeEntry.Text); err != nil {
+				dialog.ShowError(err, card.page.appState.Window)
+				return
+			}
+			rules := model.RenameRules{
+				StripEmoji:    stripEmojiCheck.Checked,
+				RemovePattern: removeEntry.Text,
+				Template:      templateEntry.Text,
+			}
+			if err := card.page.appState.Store.Subscriptions.UpdateRenameRules(card.sub.ID, rules); err != nil {
+				dialog.ShowError(err, card.page.appState.Window)
+				return
+			}
+			if err := card.page.appState.Store.Subscriptions.UpdateTestURL(card.sub.ID, testURLEntry.Text); err != nil {
+				dialog.ShowError(err, card.page.appState.Window)
+				return
+			}
 		} else {
 			// 降级方案：通过Store更新订阅
 			if card.page.appState != nil && card.page.appState.Store != nil && card.page.appState.Store.Subscriptions != nil {
@@ -414,7 +713,7 @@ func (card *SubscriptionCard) showEditDialog() {
 		card.page.Refresh()
 	}, card.page.appState.Window)
 
-	d.Resize(fyne.NewSize(420, 240))
+	d.Resize(fyne.NewSize(420, 540))
 	d.Show()
 }
 
@@ -430,6 +729,15 @@ func (card *SubscriptionCard) formatTime(t time.Time) string {
 	return t.Format("2006-01-02")
 }
 
+// MinSize 行高不低于列表密度对应的高度（52/40/32px），内容更高时以内容为准
+func (card *SubscriptionCard) MinSize() fyne.Size {
+	size := card.BaseWidget.MinSize()
+	if h, _ := listRowMetrics(card.appState); size.Height < h {
+		size.Height = h
+	}
+	return size
+}
+
 func (card *SubscriptionCard) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(card.renderObj)
 }
@@ -11,9 +11,13 @@ import (
 // MonochromeTheme 实现 Fyne 主题接口。
 // 极简黑白灰 + 状态强调色：交互控件黑白灰，仅状态反馈用绿/红/橙。
 type MonochromeTheme struct {
-	variant fyne.ThemeVariant
+	variant       fyne.ThemeVariant
+	largeControls bool // 辅助功能「大号控件」：按 largeControlsScale 放大间距、图标与文字
 }
 
+// largeControlsScale 大号控件的放大比例
+const largeControlsScale = 1.25
+
 // 浅色模式 - 极简黑白灰（背景偏白）
 const (
 	LightBackground   = "#FFFFFF" // 页面最底层
@@ -53,9 +57,9 @@ const (
 	DelayNone  = "#9E9E9E" // 未测速/超时 占位灰
 )
 
-// NewMonochromeTheme 创建主题实例。
-func NewMonochromeTheme(variant fyne.ThemeVariant) fyne.Theme {
-	return &MonochromeTheme{variant: variant}
+// NewMonochromeTheme 创建主题实例；largeControls 为 true 时放大控件尺寸。
+func NewMonochromeTheme(variant fyne.ThemeVariant, largeControls bool) fyne.Theme {
+	return &MonochromeTheme{variant: variant, largeControls: largeControls}
 }
 
 // CurrentThemeColor 从当前应用主题取色。
@@ -218,7 +222,15 @@ func (t *MonochromeTheme) Font(style fyne.TextStyle) fyne.Resource {
 	return theme.DefaultTheme().Font(style)
 }
 
-// Size 使用默认尺寸
+// Size 使用默认尺寸；开启大号控件时放大间距、图标、文字与滚动条，使按钮等点击区域随之变大
 func (t *MonochromeTheme) Size(name fyne.ThemeSizeName) float32 {
-	return theme.DefaultTheme().Size(name)
+	size := theme.DefaultTheme().Size(name)
+	if t.largeControls {
+		switch name {
+		case theme.SizeNamePadding, theme.SizeNameInnerPadding, theme.SizeNameInlineIcon,
+			theme.SizeNameText, theme.SizeNameLineSpacing, theme.SizeNameScrollBar:
+			return size * largeControlsScale
+		}
+	}
+	return size
 }
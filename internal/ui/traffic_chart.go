@@ -10,6 +10,7 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
 )
 
 // TrafficData 流量数据点
@@ -33,117 +34,69 @@ type TrafficChart struct {
 	currentUpload   int64
 	currentDownload int64
 
-	// 上一次的流量统计（用于计算实时流量）
-	lastUpload   int64
-	lastDownload int64
-	lastTime     time.Time
-
 	// 锁保护
 	mu sync.RWMutex
 
-	// 更新定时器
-	updateTicker *time.Ticker
-	stopChan     chan struct{}
-	stopOnce     sync.Once
+	// 取消订阅 TrafficService 的采样
+	unsubscribe func()
+	stopOnce    sync.Once
 }
 
-// NewTrafficChart 创建新的流量图组件
+// NewTrafficChart 创建新的流量图组件，数据来自 TrafficService 的集中采样（每秒一次）
 func NewTrafficChart(appState *AppState) *TrafficChart {
 	tc := &TrafficChart{
 		appState:   appState,
 		dataPoints: make([]TrafficData, 0),
 		maxPoints:  60, // 保留最近60个数据点（约1分钟，假设每秒更新）
-		lastTime:   time.Now(),
-		stopChan:   make(chan struct{}),
 	}
 	tc.ExtendBaseWidget(tc)
 
-	// 启动更新定时器（每秒更新一次）
-	tc.updateTicker = time.NewTicker(1 * time.Second)
-	go tc.updateLoop()
-
-	return tc
-}
-
-// updateLoop 更新循环
-func (tc *TrafficChart) updateLoop() {
-	for {
-		select {
-		case <-tc.updateTicker.C:
-			tc.updateData()
+	if appState != nil && appState.TrafficService != nil {
+		for _, s := range appState.TrafficService.History() {
+			tc.addSample(s)
+		}
+		tc.unsubscribe = appState.TrafficService.Subscribe(func(s service.TrafficSample) {
+			tc.addSample(s)
 			// 使用 fyne.Do 确保 UI 更新在主线程中执行
 			fyne.Do(func() {
 				tc.Refresh()
 			})
-		case <-tc.stopChan:
-			return
-		}
+		})
 	}
+
+	return tc
 }
 
-// updateData 更新流量数据
-func (tc *TrafficChart) updateData() {
+// addSample 追加一个速度采样点
+func (tc *TrafficChart) addSample(s service.TrafficSample) {
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
 
-	var totalUpload, totalDownload int64
-	if tc.appState != nil && tc.appState.XrayControlService != nil && tc.appState.XrayInstance != nil && tc.appState.XrayInstance.IsRunning() {
-		totalUpload, totalDownload = tc.appState.XrayControlService.GetTrafficStats(tc.appState.XrayInstance)
-	}
-
-	// 计算实时流量（与上一次的差值）
-	now := time.Now()
-	timeDiff := now.Sub(tc.lastTime).Seconds()
-	if timeDiff == 0 {
-		timeDiff = 1 // 避免除以零
-	}
-
-	// 计算实时流量（字节/秒）
-	upload := int64(float64(totalUpload-tc.lastUpload) / timeDiff)
-	download := int64(float64(totalDownload-tc.lastDownload) / timeDiff)
-
-	// 确保流量不为负数
-	if upload < 0 {
-		upload = 0
-	}
-	if download < 0 {
-		download = 0
-	}
-
-	// 更新上一次的流量数据和时间
-	tc.lastUpload = totalUpload
-	tc.lastDownload = totalDownload
-	tc.lastTime = now
-
-	// 添加新数据点
-	newPoint := TrafficData{
-		Upload:   upload,
-		Download: download,
-		Time:     now,
-	}
-
-	tc.dataPoints = append(tc.dataPoints, newPoint)
+	tc.dataPoints = append(tc.dataPoints, TrafficData{
+		Upload:   s.Upload,
+		Download: s.Download,
+		Time:     s.Time,
+	})
 
 	// 限制数据点数量
 	if len(tc.dataPoints) > tc.maxPoints {
 		tc.dataPoints = tc.dataPoints[len(tc.dataPoints)-tc.maxPoints:]
 	}
 
-	tc.currentUpload = upload
-	tc.currentDownload = download
+	tc.currentUpload = s.Upload
+	tc.currentDownload = s.Download
 }
 
-// Stop 停止更新（可重复调用；仅首次会停 ticker 并关闭 stopChan，避免 panic）。
+// Stop 停止更新（可重复调用；仅首次会取消订阅）。
 func (tc *TrafficChart) Stop() {
 	if tc == nil {
 		return
 	}
 	tc.stopOnce.Do(func() {
-		if tc.updateTicker != nil {
-			tc.updateTicker.Stop()
-			tc.updateTicker = nil
+		if tc.unsubscribe != nil {
+			tc.unsubscribe()
+			tc.unsubscribe = nil
 		}
-		close(tc.stopChan)
 	})
 }
 
@@ -329,37 +282,15 @@ func toRGBA(c color.Color) color.RGBA {
 	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
 }
 
-// formatSpeed 格式化速度显示
+// formatSpeed 格式化速度显示，单位（MB/s 或 Mbps）与数字格式取自设置
 func formatSpeed(bytes int64) string {
-	const (
-		KB = 1024
-		MB = KB * 1024
-		GB = MB * 1024
-	)
-
-	var value float64
-	var unit string
-
-	switch {
-	case bytes >= GB:
-		value = float64(bytes) / GB
-		unit = "GB/s"
-	case bytes >= MB:
-		value = float64(bytes) / MB
-		unit = "MB/s"
-	case bytes >= KB:
-		value = float64(bytes) / KB
-		unit = "KB/s"
-	default:
-		value = float64(bytes)
-		unit = "B/s"
-	}
+	value, unit := scaleRate(bytes)
 
 	if value < 10 {
-		return fmt.Sprintf("%.2f %s", value, unit)
+		return formatNumber(value, 2) + " " + unit
 	} else if value < 100 {
-		return fmt.Sprintf("%.1f %s", value, unit)
+		return formatNumber(value, 1) + " " + unit
 	} else {
-		return fmt.Sprintf("%.0f %s", value, unit)
+		return formatNumber(value, 0) + " " + unit
 	}
 }
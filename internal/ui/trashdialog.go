@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+)
+
+// showTrashDialog 展示回收站：逐条恢复或永久删除，或清空全部。
+func showTrashDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.TrashService == nil {
+		return
+	}
+	var items []model.TrashItem
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	var list *widget.List
+	reload := func() {
+		loaded, err := appState.TrashService.List()
+		if err != nil {
+			dialog.ShowError(err, appState.Window)
+			return
+		}
+		items = loaded
+		if len(items) == 0 {
+			statusLabel.SetText("回收站是空的")
+		} else {
+			statusLabel.SetText(fmt.Sprintf("共 %d 项，删除 %d 天后自动永久删除", len(items), int(model.TrashRetention.Hours()/24)))
+		}
+		list.Refresh()
+	}
+
+	list = widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			detailLabel := widget.NewLabel("")
+			detailLabel.Importance = widget.LowImportance
+			restoreBtn := newIconButton(appState, "恢复", theme.ContentUndoIcon(), nil)
+			restoreBtn.Importance = widget.LowImportance
+			purgeBtn := newIconButton(appState, "永久删除", theme.DeleteIcon(), nil)
+			purgeBtn.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, container.NewHBox(restoreBtn, purgeBtn),
+				container.NewVBox(nameLabel, detailLabel))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(items) {
+				return
+			}
+			item := items[id]
+			name := "[节点] " + item.Name
+			if item.Kind == model.TrashKindSubscription {
+				name = fmt.Sprintf("[订阅] %s（%d 个节点）", item.Name, item.NodeCount)
+			}
+			detail := fmt.Sprintf("删除于 %s，%s 后永久删除",
+				item.DeletedAt.Local().Format("01-02 15:04"), item.ExpiresAt().Local().Format("01-02"))
+			labels := collectLabelsFromObject(obj)
+			if len(labels) >= 2 {
+				labels[0].SetText(name)
+				labels[1].SetText(detail)
+			}
+			border := obj.(*fyne.Container)
+			for _, o := range border.Objects {
+				box, ok := o.(*fyne.Container)
+				if !ok || len(box.Objects) != 2 {
+					continue
+				}
+				restoreBtn, ok1 := box.Objects[0].(*widget.Button)
+				purgeBtn, ok2 := box.Objects[1].(*widget.Button)
+				if !ok1 || !ok2 {
+					continue
+				}
+				restoreBtn.OnTapped = func() {
+					if err := appState.TrashService.Restore(item); err != nil {
+						dialog.ShowError(err, appState.Window)
+						return
+					}
+					reload()
+				}
+				purgeBtn.OnTapped = func() {
+					dialog.ShowConfirm("永久删除", fmt.Sprintf("永久删除 '%s'？此操作无法撤销。", item.Name), func(ok bool) {
+						if !ok {
+							return
+						}
+						if err := appState.TrashService.Purge(item); err != nil {
+							dialog.ShowError(err, appState.Window)
+							return
+						}
+						reload()
+					}, appState.Window)
+				}
+			}
+		},
+	)
+
+	emptyBtn := widget.NewButtonWithIcon("清空回收站", theme.DeleteIcon(), func() {
+		if len(items) == 0 {
+			return
+		}
+		dialog.ShowConfirm("清空回收站", "永久删除回收站中的全部订阅与节点？此操作无法撤销。", func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := appState.TrashService.Empty(); err != nil {
+				dialog.ShowError(err, appState.Window)
+				return
+			}
+			reload()
+		}, appState.Window)
+	})
+	emptyBtn.Importance = widget.DangerImportance
+
+	scroll := container.NewScroll(list)
+	scroll.SetMinSize(fyne.NewSize(380, 300))
+	content := container.NewBorder(statusLabel, emptyBtn, nil, nil, scroll)
+	reload()
+	dialog.ShowCustom("回收站", "关闭", content, appState.Window)
+}
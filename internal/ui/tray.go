@@ -1,8 +1,12 @@
 package ui
 
 import (
+	"fmt"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/driver/desktop"
+	"myproxy.com/p/internal/service"
 )
 
 // TrayManager 管理系统托盘
@@ -11,6 +15,9 @@ type TrayManager struct {
 	app                fyne.App
 	window             fyne.Window
 	proxyModeMenuItems [2]*fyne.MenuItem // 系统代理模式菜单项（清除、系统）
+	statusMenuItem     *fyne.MenuItem    // 连接摘要（节点、出口 IP），只读
+	speedUnsub         func()            // 菜单栏速度的流量订阅，未显示时为 nil
+	speedTitle         string            // 菜单栏当前显示的速度文字
 }
 
 // NewTrayManager 创建系统托盘管理器
@@ -44,6 +51,8 @@ func (tm *TrayManager) SetupTray() {
 		}
 		desk.SetSystemTrayIcon(icon)
 		tm.createTrayMenu(desk)
+		tm.bindProxyStatus(desk)
+		tm.ApplySpeedTitle()
 	} else {
 		tm.appState.SafeLogger.Warn("应用不支持桌面扩展，无法显示系统托盘")
 	}
@@ -62,29 +71,132 @@ func (tm *TrayManager) RefreshTrayIcon() {
 	}
 }
 
+// ApplySpeedTitle 按配置开始或停止在菜单栏图标旁显示实时速度（仅 macOS），每秒随流量采样更新。
+func (tm *TrayManager) ApplySpeedTitle() {
+	enabled := trayTitleSupported && tm.appState != nil && tm.appState.TrafficService != nil &&
+		tm.appState.ConfigService != nil && tm.appState.ConfigService.GetTrayShowSpeed()
+	if !enabled {
+		if tm.speedUnsub != nil {
+			tm.speedUnsub()
+			tm.speedUnsub = nil
+		}
+		tm.setSpeedTitle("")
+		return
+	}
+	if tm.speedUnsub != nil {
+		return
+	}
+	tm.speedUnsub = tm.appState.TrafficService.Subscribe(func(sample service.TrafficSample) {
+		title := ""
+		if tm.appState.Store != nil && tm.appState.Store.ProxyStatus != nil && !tm.appState.Store.ProxyStatus.SessionStartedAt().IsZero() {
+			title = fmt.Sprintf("↑%s ↓%s", formatTraySpeed(sample.Upload), formatTraySpeed(sample.Download))
+		}
+		fyne.Do(func() {
+			if tm.speedUnsub != nil {
+				tm.setSpeedTitle(title)
+			}
+		})
+	})
+}
+
+// setSpeedTitle 仅在文字变化时更新菜单栏，避免每秒重复刷新。
+func (tm *TrayManager) setSpeedTitle(title string) {
+	if title == tm.speedTitle {
+		return
+	}
+	tm.speedTitle = title
+	setTrayTitle(title)
+}
+
+// traySpeedUnits 菜单栏速度的简写单位：字节用单字母，比特保留 b
+var traySpeedUnits = map[string]string{
+	"B/s": "B", "KB/s": "K", "MB/s": "M", "GB/s": "G",
+	"bps": "b", "Kbps": "Kb", "Mbps": "Mb", "Gbps": "Gb",
+}
+
+// formatTraySpeed 菜单栏空间有限，速度取整并使用简写单位，如 "820B"、"12K"、"1.5M"（按比特显示时为 "12Kb"、"1.5Mb"）。
+func formatTraySpeed(bytes int64) string {
+	value, unit := scaleRate(bytes)
+	short := traySpeedUnits[unit]
+	decimals := 0
+	switch short {
+	case "G", "Gb":
+		decimals = 1
+	case "M", "Mb":
+		if value < 10 {
+			decimals = 1
+		}
+	}
+	return formatNumber(value, decimals) + short
+}
+
+// bindProxyStatus 监听代理状态绑定：模式变化时刷新选中状态，连接摘要变化时刷新状态菜单项。
+func (tm *TrayManager) bindProxyStatus(desk desktop.App) {
+	if tm.appState == nil || tm.appState.Store == nil || tm.appState.Store.ProxyStatus == nil {
+		return
+	}
+	status := tm.appState.Store.ProxyStatus
+	status.ModeBinding.AddListener(binding.NewDataListener(tm.refreshProxyModeMenu))
+	summaryListener := binding.NewDataListener(func() {
+		if tm.statusMenuItem != nil && tm.statusMenuItem.Label != tm.statusSummary() {
+			tm.createTrayMenu(desk)
+		}
+	})
+	status.ProxyStatusBinding.AddListener(summaryListener)
+	status.ServerNameBinding.AddListener(summaryListener)
+	status.ExitIPBinding.AddListener(summaryListener)
+}
+
+// statusSummary 由代理状态绑定生成托盘中的连接摘要。
+func (tm *TrayManager) statusSummary() string {
+	if tm.appState == nil || tm.appState.Store == nil || tm.appState.Store.ProxyStatus == nil {
+		return "⚪ 未连接"
+	}
+	status := tm.appState.Store.ProxyStatus
+	if status.SessionStartedAt().IsZero() {
+		return "⚪ 未连接"
+	}
+	name, _ := status.ServerNameBinding.Get()
+	exitIP, _ := status.ExitIPBinding.Get()
+	return fmt.Sprintf("🟢 %s · 出口 %s", truncateDisplayText(name, 20), exitIP)
+}
+
+// currentMode 返回状态绑定中的系统代理模式，绑定尚未写入时回退到配置。
+func (tm *TrayManager) currentMode() SystemProxyMode {
+	if tm.appState != nil && tm.appState.Store != nil && tm.appState.Store.ProxyStatus != nil {
+		if mode, _ := tm.appState.Store.ProxyStatus.ModeBinding.Get(); mode != "" {
+			return ParseSystemProxyMode(mode)
+		}
+	}
+	return getSystemProxyModeFromAppState(tm.appState)
+}
+
 // createTrayMenu 创建托盘菜单
 func (tm *TrayManager) createTrayMenu(desk desktop.App) {
 	// 创建系统代理模式菜单项（如果尚未创建）
 	if tm.proxyModeMenuItems[0] == nil {
-		tm.proxyModeMenuItems[0] = fyne.NewMenuItem(SystemProxyModeClear.ShortString(), func() {
+		tm.proxyModeMenuItems[0] = fyne.NewMenuItem(SystemProxyModeClear.ShortString(), tm.whenUnlocked(func() {
 			if tm.appState != nil && tm.appState.MainWindow != nil {
 				_ = tm.appState.MainWindow.SetSystemProxyMode(SystemProxyModeClear)
 				// SetSystemProxyMode 内部会调用 RefreshProxyModeMenu，这里不需要再次调用
 			}
-		})
-		tm.proxyModeMenuItems[1] = fyne.NewMenuItem(SystemProxyModeAuto.ShortString(), func() {
+		}))
+		tm.proxyModeMenuItems[1] = fyne.NewMenuItem(SystemProxyModeAuto.ShortString(), tm.whenUnlocked(func() {
 			if tm.appState != nil && tm.appState.MainWindow != nil {
 				_ = tm.appState.MainWindow.SetSystemProxyMode(SystemProxyModeAuto)
 				// SetSystemProxyMode 内部会调用 RefreshProxyModeMenu，这里不需要再次调用
 			}
-		})
+		}))
 	}
 
 	// 更新菜单项的选中状态
 	tm.updateProxyModeMenuCheckedState()
 
+	tm.statusMenuItem = fyne.NewMenuItem(tm.statusSummary(), nil)
+	tm.statusMenuItem.Disabled = true
+
 	// 创建关闭代理菜单项
-	closeProxyMenuItem := fyne.NewMenuItem("关闭代理", func() {
+	closeProxyMenuItem := fyne.NewMenuItem("关闭代理", tm.whenUnlocked(func() {
 		if tm.appState != nil && tm.appState.MainWindow != nil {
 			// 停止Xray实例
 			tm.appState.MainWindow.StopProxy()
@@ -93,14 +205,42 @@ func (tm *TrayManager) createTrayMenu(desk desktop.App) {
 				_ = tm.appState.MainWindow.SetSystemProxyMode(SystemProxyModeClear)
 			}
 		}
-	})
+	}))
+
+	// 测试当前节点：代理运行中才可用，结果以系统通知显示
+	pingMenuItem := fyne.NewMenuItem("测试当前节点延迟", tm.whenUnlocked(tm.pingConnectedNode))
+	pingMenuItem.Disabled = tm.appState == nil || !tm.appState.IsProxyRunning()
+
+	// 窗口置顶与悬浮窗：勾选状态取自配置
+	pinMenuItem := fyne.NewMenuItem("窗口置顶", tm.whenUnlocked(func() {
+		if tm.appState == nil || tm.appState.ConfigService == nil {
+			return
+		}
+		if err := tm.appState.SetWindowAlwaysOnTop(!tm.appState.ConfigService.GetWindowAlwaysOnTop()); err != nil {
+			tm.appState.AppendLog("WARN", "app", err.Error())
+		}
+	}))
+	miniMenuItem := fyne.NewMenuItem("悬浮窗", tm.whenUnlocked(func() {
+		if tm.appState != nil {
+			tm.appState.SetMiniWindowVisible(tm.appState.MiniWindow == nil)
+		}
+	}))
+	if tm.appState != nil {
+		pinMenuItem.Checked = tm.appState.ConfigService != nil && tm.appState.ConfigService.GetWindowAlwaysOnTop()
+		miniMenuItem.Checked = tm.appState.MiniWindow != nil
+	}
 
 	// 创建托盘菜单
 	menu := fyne.NewMenu("SOCKS5 代理客户端",
+		tm.statusMenuItem,
+		pingMenuItem,
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("显示窗口", func() {
 			tm.window.Show()
 			tm.window.RequestFocus()
 		}),
+		pinMenuItem,
+		miniMenuItem,
 		fyne.NewMenuItemSeparator(),
 		closeProxyMenuItem, // 关闭代理（停止Xray）
 		fyne.NewMenuItemSeparator(),
@@ -116,17 +256,78 @@ func (tm *TrayManager) createTrayMenu(desk desktop.App) {
 	desk.SetSystemTrayMenu(menu)
 }
 
+// whenUnlocked 包装托盘菜单项的操作：应用锁定时只显示锁屏，不执行 fn（托盘不经过锁屏，否则锁定后仍可切换代理）。
+func (tm *TrayManager) whenUnlocked(fn func()) func() {
+	return func() {
+		if tm.appState != nil && tm.appState.appLock.locked {
+			tm.window.Show()
+			tm.window.RequestFocus()
+			return
+		}
+		fn()
+	}
+}
+
+// pingConnectedNode 重新测试当前连接的节点：结果记入节点延迟（与手动测速相同），并以系统通知显示，不需要打开主窗口。
+func (tm *TrayManager) pingConnectedNode() {
+	a := tm.appState
+	if a == nil || a.Ping == nil || a.Store == nil || a.Store.Nodes == nil || tm.app == nil {
+		return
+	}
+	node := a.Store.Nodes.GetSelected()
+	if node == nil || !a.IsProxyRunning() {
+		tm.app.SendNotification(fyne.NewNotification("测试当前节点", "代理未连接"))
+		return
+	}
+	if isOffline(a) {
+		tm.app.SendNotification(fyne.NewNotification("测试当前节点", offlineHint))
+		return
+	}
+
+	go func() {
+		title := "节点正常"
+		delay, err := a.Ping.TestServerDelay(*node)
+		content := fmt.Sprintf("%s · %d ms", node.Name, delay)
+		if err != nil {
+			delay = -1
+			title, content = "节点不可达", fmt.Sprintf("%s · %v", node.Name, err)
+			a.AppendLog("WARN", "ping", fmt.Sprintf("托盘测试当前节点 %s 失败: %v", node.Name, err))
+		} else {
+			a.AppendLog("INFO", "ping", fmt.Sprintf("托盘测试当前节点 %s: %d ms", node.Name, delay))
+		}
+		if a.ServerService != nil {
+			if _, _, err := a.ServerService.RecordTestResult(node.ID, delay, true); err != nil {
+				a.AppendLog("ERROR", "ping", fmt.Sprintf("更新服务器 %s 测速结果失败: %v", node.Name, err))
+			}
+		}
+		notification := fyne.NewNotification(title, content)
+		fyne.Do(func() {
+			tm.app.SendNotification(notification)
+			if a.MainWindow != nil && a.MainWindow.nodePageInstance != nil {
+				a.MainWindow.nodePageInstance.Refresh()
+			}
+		})
+	}()
+}
+
+// RefreshMenu 重建托盘菜单（置顶、悬浮窗等勾选状态变化后调用）。
+func (tm *TrayManager) RefreshMenu() {
+	if desk, ok := tm.app.(desktop.App); ok {
+		tm.createTrayMenu(desk)
+	}
+}
+
 // RefreshProxyModeMenu 刷新系统代理模式菜单的选中状态（公共方法）
 func (tm *TrayManager) RefreshProxyModeMenu() {
 	tm.refreshProxyModeMenu()
 }
 
-// updateProxyModeMenuCheckedState 从 AppState（ConfigService）读取系统代理模式，更新菜单选中状态。
+// updateProxyModeMenuCheckedState 按状态绑定中的系统代理模式更新菜单选中状态。
 func (tm *TrayManager) updateProxyModeMenuCheckedState() {
-	if tm.appState == nil || tm.appState.ConfigService == nil {
+	if tm.appState == nil {
 		return
 	}
-	currentMode := getSystemProxyModeFromAppState(tm.appState)
+	currentMode := tm.currentMode()
 
 	// 更新菜单项的选中状态
 	for i, item := range tm.proxyModeMenuItems {
@@ -142,12 +343,12 @@ func (tm *TrayManager) updateProxyModeMenuCheckedState() {
 	}
 }
 
-// refreshProxyModeMenu 根据 AppState 当前状态刷新托盘代理模式菜单。
+// refreshProxyModeMenu 根据状态绑定中的模式刷新托盘代理模式菜单。
 func (tm *TrayManager) refreshProxyModeMenu() {
-	if tm.appState == nil || tm.appState.ConfigService == nil {
+	if tm.appState == nil {
 		return
 	}
-	currentMode := getSystemProxyModeFromAppState(tm.appState)
+	currentMode := tm.currentMode()
 
 	// 检查是否有状态变化
 	needRefresh := false
@@ -0,0 +1,13 @@
+//go:build darwin
+
+package ui
+
+import "fyne.io/systray"
+
+// trayTitleSupported macOS 菜单栏图标旁可显示文字
+const trayTitleSupported = true
+
+// setTrayTitle 设置菜单栏图标旁的文字，空字符串时仅显示图标。
+func setTrayTitle(title string) {
+	systray.SetTitle(title)
+}
@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package ui
+
+// trayTitleSupported Windows 托盘不支持文字；Linux 的标题由 Fyne 固定为应用名，不做覆盖
+const trayTitleSupported = false
+
+func setTrayTitle(title string) {
+	_ = title
+}
@@ -1,8 +1,16 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
 )
 
 func NewTitleLabel(text string) *widget.Label {
@@ -14,3 +22,73 @@ func NewTitleLabel(text string) *widget.Label {
 func NewSeparator() *widget.Separator {
 	return widget.NewSeparator()
 }
+
+// refreshThrottle 合并高频刷新：空闲时的首次触发立即执行，之后 interval 内的触发合并为间隔结束时的一次，
+// 即最多每 interval 执行一次且最后一次变化一定会被执行。fn 在主线程（fyne.Do）中调用，Trigger 可在任意 goroutine 调用。
+type refreshThrottle struct {
+	interval time.Duration
+	fn       func()
+
+	mu      sync.Mutex
+	last    time.Time
+	timer   *time.Timer
+	stopped bool
+}
+
+func newRefreshThrottle(interval time.Duration, fn func()) *refreshThrottle {
+	return &refreshThrottle{interval: interval, fn: fn}
+}
+
+// Trigger 请求一次刷新。
+func (t *refreshThrottle) Trigger() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped || t.timer != nil {
+		return
+	}
+	wait := t.interval - time.Since(t.last)
+	if wait <= 0 {
+		t.last = time.Now()
+		fyne.Do(t.fn)
+		return
+	}
+	t.timer = time.AfterFunc(wait, func() {
+		t.mu.Lock()
+		t.timer = nil
+		stopped := t.stopped
+		t.last = time.Now()
+		t.mu.Unlock()
+		if !stopped {
+			fyne.Do(t.fn)
+		}
+	})
+}
+
+// Stop 取消待执行的刷新，之后的 Trigger 不再生效。
+func (t *refreshThrottle) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// showProxyError 显示启动代理等操作的错误；节点字段校验失败时逐条列出问题，便于用户定位具体字段。
+func showProxyError(message string, err error, window fyne.Window) {
+	if window == nil || err == nil {
+		return
+	}
+	var validationErr *model.NodeValidationError
+	if errors.As(err, &validationErr) {
+		lines := make([]string, 0, len(validationErr.Problems)+1)
+		lines = append(lines, fmt.Sprintf("节点: %s（%s）", validationErr.NodeName, validationErr.Protocol))
+		for _, p := range validationErr.Problems {
+			lines = append(lines, "• "+p)
+		}
+		dialog.ShowInformation("节点配置无效", strings.Join(lines, "\n"), window)
+		return
+	}
+	dialog.ShowError(fmt.Errorf("%s: %w", message, err), window)
+}
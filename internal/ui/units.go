@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2/lang"
+	"myproxy.com/p/internal/service"
+)
+
+// unitFormat 速度单位与数字格式，由 applyUnitFormat 按配置更新；formatSpeed、formatBytes 等在任意 goroutine 读取。
+type unitFormat struct {
+	bits    bool   // 速度以比特每秒显示
+	decimal string // 小数点
+	group   string // 千位分隔符，空表示不分组
+}
+
+var currentUnitFormat atomic.Pointer[unitFormat]
+
+// numberFormatSeparators 各数字格式的小数点与千位分隔符
+var numberFormatSeparators = map[string][2]string{
+	service.NumberFormatCommaDot:   {".", ","},
+	service.NumberFormatDotComma:   {",", "."},
+	service.NumberFormatSpaceComma: {",", "\u202f"}, // 窄不换行空格，避免数字在空格处折行
+	service.NumberFormatPlain:      {".", ""},
+}
+
+// localeNumberFormats 使用「1.234,5」或「1 234,5」写法的语言，其余语言按「1,234.5」
+var localeNumberFormats = map[string]string{
+	"de": service.NumberFormatDotComma, "es": service.NumberFormatDotComma, "it": service.NumberFormatDotComma,
+	"nl": service.NumberFormatDotComma, "pt": service.NumberFormatDotComma, "id": service.NumberFormatDotComma,
+	"tr": service.NumberFormatDotComma, "da": service.NumberFormatDotComma,
+	"fr": service.NumberFormatSpaceComma, "ru": service.NumberFormatSpaceComma, "pl": service.NumberFormatSpaceComma,
+	"cs": service.NumberFormatSpaceComma, "sv": service.NumberFormatSpaceComma, "fi": service.NumberFormatSpaceComma,
+	"nb": service.NumberFormatSpaceComma, "uk": service.NumberFormatSpaceComma,
+}
+
+// systemNumberFormat 按系统语言选择数字格式。
+func systemNumberFormat() string {
+	language := strings.ToLower(lang.SystemLocale().LanguageString())
+	if f, ok := localeNumberFormats[language]; ok {
+		return f
+	}
+	return service.NumberFormatCommaDot
+}
+
+// applyUnitFormat 从配置加载速度单位与数字格式（启动时与设置变更后调用）。
+func applyUnitFormat(a *AppState) {
+	unit, format := service.SpeedUnitBytes, service.NumberFormatSystem
+	if a != nil && a.ConfigService != nil {
+		unit, format = a.ConfigService.GetSpeedUnit(), a.ConfigService.GetNumberFormat()
+	}
+	if format == service.NumberFormatSystem || format == "" {
+		format = systemNumberFormat()
+	}
+	sep, ok := numberFormatSeparators[format]
+	if !ok {
+		sep = numberFormatSeparators[service.NumberFormatCommaDot]
+	}
+	currentUnitFormat.Store(&unitFormat{bits: unit == service.SpeedUnitBits, decimal: sep[0], group: sep[1]})
+}
+
+// unitFormatting 返回当前格式，尚未加载配置时为字节单位与「1,234.5」。
+func unitFormatting() unitFormat {
+	if f := currentUnitFormat.Load(); f != nil {
+		return *f
+	}
+	return unitFormat{decimal: ".", group: ","}
+}
+
+// formatNumber 按当前数字格式输出保留 decimals 位小数的数字。
+func formatNumber(value float64, decimals int) string {
+	f := unitFormatting()
+	s := strconv.FormatFloat(value, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac, _ := strings.Cut(s, ".")
+	if f.group != "" && len(intPart) > 3 {
+		var b strings.Builder
+		for i, c := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				b.WriteString(f.group)
+			}
+			b.WriteRune(c)
+		}
+		intPart = b.String()
+	}
+	if frac == "" {
+		return sign + intPart
+	}
+	return sign + intPart + f.decimal + frac
+}
+
+// scaleRate 将字节每秒换算为显示数值与单位：字节按 1024 进制，比特按 1000 进制。
+func scaleRate(bytesPerSec int64) (float64, string) {
+	if unitFormatting().bits {
+		bits := float64(bytesPerSec) * 8
+		switch {
+		case bits >= 1e9:
+			return bits / 1e9, "Gbps"
+		case bits >= 1e6:
+			return bits / 1e6, "Mbps"
+		case bits >= 1e3:
+			return bits / 1e3, "Kbps"
+		default:
+			return bits, "bps"
+		}
+	}
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	value := float64(bytesPerSec)
+	switch {
+	case bytesPerSec >= GB:
+		return value / GB, "GB/s"
+	case bytesPerSec >= MB:
+		return value / MB, "MB/s"
+	case bytesPerSec >= KB:
+		return value / KB, "KB/s"
+	default:
+		return value, "B/s"
+	}
+}
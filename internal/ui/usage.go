@@ -0,0 +1,306 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/analytics"
+)
+
+// usageTopN 使用报告中列出的常用地区与节点数
+const usageTopN = 5
+
+// syncUsageStats 代理开始新会话时记入使用统计，停止后结束累计连接时长。
+func (a *AppState) syncUsageStats(newSession bool) {
+	if a.UsageStats == nil {
+		return
+	}
+	if !a.IsProxyRunning() {
+		a.UsageStats.End()
+		return
+	}
+	if !newSession {
+		return
+	}
+	nodeName := ""
+	if a.Store != nil && a.Store.Nodes != nil {
+		if n := a.Store.Nodes.GetSelected(); n != nil {
+			nodeName = n.Name
+		}
+	}
+	a.UsageStats.Begin(nodeName)
+}
+
+// UsagePage 使用报告页：按年汇总本机记录的连接次数、连接时长、常用地区与节点，以「年度报告」的形式展示。
+// 数据只来自本机数据库，页面不发起任何网络请求。
+type UsagePage struct {
+	appState   *AppState
+	yearSelect *widget.Select
+	body       *fyne.Container
+	report     *analytics.UsageReport
+}
+
+// NewUsagePage 创建使用报告页。
+func NewUsagePage(appState *AppState) *UsagePage {
+	return &UsagePage{appState: appState}
+}
+
+// Build 构建页面并在后台加载当年的报告。
+func (up *UsagePage) Build() fyne.CanvasObject {
+	pad := innerPadding(up.appState)
+	backBtn := newIconButton(up.appState, "返回", theme.NavigateBackIcon(), func() {
+		if up.appState != nil && up.appState.MainWindow != nil {
+			up.appState.MainWindow.Back()
+		}
+	})
+	backBtn.Importance = widget.LowImportance
+
+	title := widget.NewLabelWithStyle("使用报告", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	up.yearSelect = widget.NewSelect(up.years(), func(string) { up.load() })
+	refreshBtn := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), up.load)
+	refreshBtn.Importance = widget.LowImportance
+
+	headerBar := container.NewHBox(backBtn, title, layout.NewSpacer(), up.yearSelect, refreshBtn)
+	separatorColor := CurrentThemeColor(up.appState.App, theme.ColorNameSeparator)
+	headerStack := container.NewVBox(
+		newPaddedWithSize(headerBar, pad),
+		canvas.NewLine(separatorColor),
+	)
+
+	privacy := widget.NewLabel("统计只保存在本机数据库，不参与同步，也不会上传到任何地方。")
+	privacy.Wrapping = fyne.TextWrapWord
+	privacy.Importance = widget.LowImportance
+	up.body = container.NewVBox()
+
+	content := container.NewBorder(
+		headerStack,
+		nil, nil, nil,
+		container.NewVScroll(newPaddedWithSize(container.NewVBox(up.body, widget.NewSeparator(), privacy, up.buildControls()), pad)),
+	)
+	// 设置选中项会触发 load
+	up.yearSelect.SetSelected(strconv.Itoa(time.Now().Year()))
+	return content
+}
+
+// years 返回可选的年份：有统计的年份与今年，按降序。
+func (up *UsagePage) years() []string {
+	current := time.Now().Year()
+	years := []int{current}
+	if up.appState != nil && up.appState.UsageStats != nil {
+		if recorded, err := up.appState.UsageStats.Years(); err == nil {
+			for _, y := range recorded {
+				if y != current {
+					years = append(years, y)
+				}
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	options := make([]string, len(years))
+	for i, y := range years {
+		options[i] = strconv.Itoa(y)
+	}
+	return options
+}
+
+// buildControls 记录开关与清空按钮。
+func (up *UsagePage) buildControls() fyne.CanvasObject {
+	cs := up.appState.ConfigService
+	enabledCheck := widget.NewCheck("记录使用统计", nil)
+	if cs != nil {
+		enabledCheck.SetChecked(cs.GetUsageStatsEnabled())
+	}
+	enabledCheck.OnChanged = func(checked bool) {
+		if cs == nil {
+			return
+		}
+		if err := cs.SetUsageStatsEnabled(checked); err != nil {
+			dialog.ShowError(err, up.appState.Window)
+			return
+		}
+		// 开启时当前连接从现在起计入
+		if checked {
+			up.appState.syncUsageStats(true)
+		}
+	}
+	clearBtn := widget.NewButtonWithIcon("清空统计", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("清空使用统计", "将删除本机记录的全部使用统计，确定吗？", func(ok bool) {
+			if !ok || up.appState.UsageStats == nil {
+				return
+			}
+			if err := up.appState.UsageStats.Clear(); err != nil {
+				dialog.ShowError(err, up.appState.Window)
+				return
+			}
+			up.yearSelect.SetOptions(up.years())
+			up.load()
+		}, up.appState.Window)
+	})
+	clearBtn.Importance = widget.LowImportance
+	return container.NewHBox(enabledCheck, layout.NewSpacer(), clearBtn)
+}
+
+// load 在后台读取所选年份的报告后刷新页面。
+func (up *UsagePage) load() {
+	if up.appState == nil || up.appState.UsageStats == nil || up.body == nil {
+		return
+	}
+	year, err := strconv.Atoi(up.yearSelect.Selected)
+	if err != nil {
+		return
+	}
+	go func() {
+		report, err := up.appState.UsageStats.Report(year)
+		fyne.Do(func() {
+			up.body.RemoveAll()
+			if err != nil {
+				up.body.Add(widget.NewLabel("读取使用统计失败: " + err.Error()))
+				up.body.Refresh()
+				return
+			}
+			up.report = report
+			up.render()
+		})
+	}()
+}
+
+// render 按报告重建页面内容。
+func (up *UsagePage) render() {
+	r := up.report
+	if r.Connects == 0 && r.Duration == 0 {
+		empty := widget.NewLabel(fmt.Sprintf("%d 年还没有使用记录。连接代理后，这里会汇总你的连接次数、时长与常用地区。", r.Year))
+		empty.Wrapping = fyne.TextWrapWord
+		up.body.Add(empty)
+		up.body.Refresh()
+		return
+	}
+
+	headline := widget.NewLabelWithStyle(fmt.Sprintf("%d 年度报告", r.Year), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	up.body.Add(headline)
+	up.body.Add(container.NewGridWithColumns(2,
+		up.statCard(strconv.Itoa(r.Connects), "次连接"),
+		up.statCard(fmt.Sprintf("%.1f", r.Duration.Hours()), "小时在线"),
+		up.statCard(strconv.Itoa(r.ActiveDays), "天有连接"),
+		up.statCard(strconv.Itoa(r.LongestStreak), "天最长连续使用"),
+	))
+
+	story := widget.NewLabel(usageStoryText(r))
+	story.Wrapping = fyne.TextWrapWord
+	up.body.Add(story)
+
+	if regions := usageRegions(r.Nodes); len(regions) > 0 {
+		up.body.Add(widget.NewLabelWithStyle("常去的地区", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for i, region := range regions {
+			if i == usageTopN {
+				break
+			}
+			up.body.Add(up.shareRow(regionFlag(region.code)+" "+regionName(region.code), region.duration, r.Duration))
+		}
+	}
+
+	up.body.Add(widget.NewLabelWithStyle("常用节点", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for i, n := range r.Nodes {
+		if i == usageTopN {
+			break
+		}
+		name := n.Name
+		if name == "" {
+			name = "未知节点"
+		}
+		up.body.Add(up.shareRow(truncateDisplayText(name, 32), n.Duration, r.Duration))
+	}
+
+	chart := NewMetricChart(up.appState, "各月在线时长（小时）", CurrentThemeColor(up.appState.App, theme.ColorNamePrimary))
+	points := make([]float64, len(r.Months))
+	for i, d := range r.Months {
+		points[i] = d.Hours()
+	}
+	chart.SetData(points, "1 月 - 12 月")
+	up.body.Add(container.NewGridWrap(fyne.NewSize(300, 140), chart))
+	up.body.Refresh()
+}
+
+// statCard 大号数字加说明的统计卡片。
+func (up *UsagePage) statCard(value, caption string) fyne.CanvasObject {
+	number := canvas.NewText(value, CurrentThemeColor(up.appState.App, theme.ColorNamePrimary))
+	number.TextSize = theme.TextHeadingSize() * 1.5
+	number.TextStyle = fyne.TextStyle{Bold: true}
+	number.Alignment = fyne.TextAlignCenter
+	label := widget.NewLabelWithStyle(caption, fyne.TextAlignCenter, fyne.TextStyle{})
+	label.Importance = widget.LowImportance
+
+	bg := canvas.NewRectangle(CurrentThemeColor(up.appState.App, theme.ColorNameInputBackground))
+	bg.CornerRadius = theme.InputRadiusSize()
+	return container.NewStack(bg, newPaddedWithSize(container.NewVBox(number, label), innerPadding(up.appState)))
+}
+
+// shareRow 名称、时长占比条与时长。
+func (up *UsagePage) shareRow(name string, d, total time.Duration) fyne.CanvasObject {
+	bar := widget.NewProgressBar()
+	bar.TextFormatter = func() string { return formatSessionDuration(d) }
+	if total > 0 {
+		bar.SetValue(float64(d) / float64(total))
+	}
+	return container.NewGridWithColumns(2, widget.NewLabel(name), bar)
+}
+
+// usageStoryText 报告中的几句总结，如「最忙的一天是 3 月 14 日，在线 9 小时 12 分」。
+func usageStoryText(r *analytics.UsageReport) string {
+	text := fmt.Sprintf("%d 年，你连接了 %d 次，累计在线 %s。", r.Year, r.Connects, formatSessionDuration(r.Duration))
+	if day, err := time.ParseInLocation(time.DateOnly, r.BusiestDay, time.Local); err == nil && r.BusiestTime > 0 {
+		text += fmt.Sprintf("\n最忙的一天是 %d 月 %d 日，在线 %s。", day.Month(), day.Day(), formatSessionDuration(r.BusiestTime))
+	}
+	busiestMonth := 0
+	for i, d := range r.Months {
+		if d > r.Months[busiestMonth] {
+			busiestMonth = i
+		}
+	}
+	if r.Months[busiestMonth] > 0 {
+		text += fmt.Sprintf("\n%d 月是你最常在线的月份。", busiestMonth+1)
+	}
+	if regions := usageRegions(r.Nodes); len(regions) > 0 && regions[0].code != nodeRegionOther {
+		text += fmt.Sprintf("\n你最常「去」的地方是 %s %s。", regionFlag(regions[0].code), regionName(regions[0].code))
+	}
+	return text
+}
+
+// usageRegion 某地区节点的累计连接时长
+type usageRegion struct {
+	code     string
+	duration time.Duration
+}
+
+// usageRegions 按节点名识别地区并汇总连接时长，按时长降序，「其他」排在最后。
+func usageRegions(nodes []analytics.UsageNode) []usageRegion {
+	totals := make(map[string]time.Duration)
+	for _, n := range nodes {
+		totals[nodeRegionCode(n.Name)] += n.Duration
+	}
+	regions := make([]usageRegion, 0, len(totals))
+	for code, d := range totals {
+		if d > 0 {
+			regions = append(regions, usageRegion{code: code, duration: d})
+		}
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		a, b := regions[i], regions[j]
+		if (a.code == nodeRegionOther) != (b.code == nodeRegionOther) {
+			return b.code == nodeRegionOther
+		}
+		if a.duration != b.duration {
+			return a.duration > b.duration
+		}
+		return a.code < b.code
+	})
+	return regions
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"myproxy.com/p/internal/service"
+)
+
+// handleWebDAVSyncResult 记录同步结果并使从远端应用的设置生效：主题立即切换，路由等设置重启运行中的代理；
+// 可在任意 goroutine 中调用。
+func (a *AppState) handleWebDAVSyncResult(result *service.WebDAVSyncResult, err error) {
+	if err != nil {
+		a.AppendLog("WARN", "app", "WebDAV 同步失败: "+err.Error())
+		return
+	}
+	if result == nil {
+		return
+	}
+	if len(result.Pulled) == 0 && !result.SubscriptionChanged && !result.Pushed && len(result.Skipped) == 0 {
+		return
+	}
+	a.AppendLog("INFO", "app", "WebDAV 同步: "+result.Summary())
+	if len(result.Pulled) == 0 {
+		return
+	}
+	fyne.Do(func() {
+		for _, key := range result.Pulled {
+			switch key {
+			case "theme":
+				a.ApplyTheme()
+			case "speedUnit", "numberFormat":
+				applyUnitFormat(a)
+			}
+		}
+		if result.ProxyConfigChanged && a.MainWindow != nil {
+			a.MainWindow.RestartXrayIfRunning("同步的路由设置")
+		}
+	})
+}
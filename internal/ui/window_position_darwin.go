@@ -0,0 +1,47 @@
+//go:build darwin
+
+package ui
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#import <Cocoa/Cocoa.h>
+
+static void getWindowOrigin(uintptr_t handle, double *x, double *y) {
+	NSWindow *window = (NSWindow *)handle;
+	NSRect frame = [window frame];
+	*x = frame.origin.x;
+	*y = frame.origin.y;
+}
+
+static void setWindowOrigin(uintptr_t handle, double x, double y) {
+	NSWindow *window = (NSWindow *)handle;
+	dispatch_async(dispatch_get_main_queue(), ^{
+		[window setFrameOrigin:NSMakePoint(x, y)];
+	});
+}
+*/
+import "C"
+
+import "fyne.io/fyne/v2/driver"
+
+// nativeWindowPosition 读取 NSWindow 左下角在屏幕坐标系中的位置（Cocoa 坐标，原样保存与恢复）。
+func nativeWindowPosition(context any) (x, y int, ok bool) {
+	ctx, isMac := context.(driver.MacWindowContext)
+	if !isMac || ctx.NSWindow == 0 {
+		return 0, 0, false
+	}
+	var cx, cy C.double
+	C.getWindowOrigin(C.uintptr_t(ctx.NSWindow), &cx, &cy)
+	return int(cx), int(cy), true
+}
+
+// setNativeWindowPosition 移动 NSWindow 到保存的位置。
+func setNativeWindowPosition(context any, x, y int) bool {
+	ctx, ok := context.(driver.MacWindowContext)
+	if !ok || ctx.NSWindow == 0 {
+		return false
+	}
+	C.setWindowOrigin(C.uintptr_t(ctx.NSWindow), C.double(x), C.double(y))
+	return true
+}
@@ -0,0 +1,15 @@
+//go:build !windows && !darwin
+
+package ui
+
+// nativeWindowPosition Linux 等平台的窗口位置由窗口管理器决定，不读取。
+func nativeWindowPosition(context any) (x, y int, ok bool) {
+	_ = context
+	return 0, 0, false
+}
+
+// setNativeWindowPosition Linux 等平台不支持移动窗口。
+func setNativeWindowPosition(context any, x, y int) bool {
+	_, _, _ = context, x, y
+	return false
+}
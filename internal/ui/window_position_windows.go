@@ -0,0 +1,39 @@
+//go:build windows
+// +build windows
+
+package ui
+
+import (
+	"unsafe"
+
+	"fyne.io/fyne/v2/driver"
+	"golang.org/x/sys/windows"
+)
+
+var procGetWindowRect = windows.NewLazySystemDLL("user32.dll").NewProc("GetWindowRect")
+
+const swpNoZOrder = 0x0004
+
+// nativeWindowPosition 通过 GetWindowRect 读取窗口左上角的屏幕坐标。
+func nativeWindowPosition(context any) (x, y int, ok bool) {
+	ctx, isWin := context.(driver.WindowsWindowContext)
+	if !isWin || ctx.HWND == 0 {
+		return 0, 0, false
+	}
+	var rect struct{ Left, Top, Right, Bottom int32 }
+	ret, _, _ := procGetWindowRect.Call(ctx.HWND, uintptr(unsafe.Pointer(&rect)))
+	if ret == 0 {
+		return 0, 0, false
+	}
+	return int(rect.Left), int(rect.Top), true
+}
+
+// setNativeWindowPosition 通过 SetWindowPos 移动窗口，不改变大小与层级。
+func setNativeWindowPosition(context any, x, y int) bool {
+	ctx, ok := context.(driver.WindowsWindowContext)
+	if !ok || ctx.HWND == 0 {
+		return false
+	}
+	ret, _, _ := procSetWindowPos.Call(ctx.HWND, 0, uintptr(x), uintptr(y), 0, 0, swpNoSize|swpNoZOrder|swpNoActivate)
+	return ret != 0
+}
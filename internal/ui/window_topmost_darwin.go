@@ -0,0 +1,41 @@
+//go:build darwin
+
+package ui
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#import <Cocoa/Cocoa.h>
+
+static void setWindowFloating(uintptr_t handle, int floating) {
+	NSWindow *window = (NSWindow *)handle;
+	dispatch_async(dispatch_get_main_queue(), ^{
+		[window setLevel:(floating ? NSFloatingWindowLevel : NSNormalWindowLevel)];
+		// 置顶时在所有桌面（Space）显示，切换桌面后仍可见
+		NSWindowCollectionBehavior behavior = [window collectionBehavior];
+		if (floating) {
+			behavior |= NSWindowCollectionBehaviorCanJoinAllSpaces;
+		} else {
+			behavior &= ~NSWindowCollectionBehaviorCanJoinAllSpaces;
+		}
+		[window setCollectionBehavior:behavior];
+	});
+}
+*/
+import "C"
+
+import "fyne.io/fyne/v2/driver"
+
+// setNativeWindowTopmost 将 NSWindow 的层级切换为浮动（NSFloatingWindowLevel）或普通层级。
+func setNativeWindowTopmost(context any, onTop bool) bool {
+	ctx, ok := context.(driver.MacWindowContext)
+	if !ok || ctx.NSWindow == 0 {
+		return false
+	}
+	floating := C.int(0)
+	if onTop {
+		floating = 1
+	}
+	C.setWindowFloating(C.uintptr_t(ctx.NSWindow), floating)
+	return true
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package ui
+
+// setNativeWindowTopmost Linux 等平台的窗口管理器各不相同，暂不支持置顶，由窗口管理器自行设置。
+func setNativeWindowTopmost(context any, onTop bool) bool {
+	_, _ = context, onTop
+	return false
+}
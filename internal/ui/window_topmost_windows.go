@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+package ui
+
+import (
+	"fyne.io/fyne/v2/driver"
+	"golang.org/x/sys/windows"
+)
+
+var procSetWindowPos = windows.NewLazySystemDLL("user32.dll").NewProc("SetWindowPos")
+
+const (
+	swpNoSize     = 0x0001
+	swpNoMove     = 0x0002
+	swpNoActivate = 0x0010
+)
+
+// setNativeWindowTopmost 通过 SetWindowPos 切换 HWND_TOPMOST（-1）/ HWND_NOTOPMOST（-2）。
+func setNativeWindowTopmost(context any, onTop bool) bool {
+	ctx, ok := context.(driver.WindowsWindowContext)
+	if !ok || ctx.HWND == 0 {
+		return false
+	}
+	insertAfter := ^uintptr(1) // HWND_NOTOPMOST
+	if onTop {
+		insertAfter = ^uintptr(0) // HWND_TOPMOST
+	}
+	ret, _, _ := procSetWindowPos.Call(ctx.HWND, insertAfter, 0, 0, 0, 0, swpNoMove|swpNoSize|swpNoActivate)
+	return ret != 0
+}
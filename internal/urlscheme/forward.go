@@ -0,0 +1,143 @@
+package urlscheme
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// instanceFileName 数据目录下记录已运行实例链接通道地址与令牌的文件
+const instanceFileName = "instance.json"
+
+// forwardTimeout 转发链接时连接与读写的超时
+const forwardTimeout = 2 * time.Second
+
+// instanceInfo 链接通道的地址与令牌，令牌防止本机其他程序冒充转发
+type instanceInfo struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token"`
+}
+
+// Listener 已运行实例接收转发链接的本机通道：再次通过链接启动应用时，新进程把链接交给它后直接退出。
+// 通道只监听 127.0.0.1 的随机端口，与本机导入接口是否开启无关。
+type Listener struct {
+	listener net.Listener
+	path     string
+	info     instanceInfo
+	onLink   func(link string)
+	wg       sync.WaitGroup
+}
+
+// Listen 开始接收转发的链接，并把地址与令牌写入 dir 下的 instance.json（仅当前用户可读）。
+// onLink 在通道的协程中调用。
+func Listen(dir string, onLink func(link string)) (*Listener, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("链接通道: 生成令牌失败: %w", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("链接通道: 监听失败: %w", err)
+	}
+	l := &Listener{
+		listener: ln,
+		path:     filepath.Join(dir, instanceFileName),
+		info:     instanceInfo{Addr: ln.Addr().String(), Token: hex.EncodeToString(token)},
+		onLink:   onLink,
+	}
+	data, _ := json.Marshal(l.info)
+	if err := os.WriteFile(l.path, data, 0600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("链接通道: 写入 %s 失败: %w", l.path, err)
+	}
+	l.wg.Add(1)
+	go l.serve()
+	return l, nil
+}
+
+// Close 停止接收，并在 instance.json 仍指向本实例时删除该文件。
+func (l *Listener) Close() error {
+	if l == nil {
+		return nil
+	}
+	err := l.listener.Close()
+	l.wg.Wait()
+	if info, readErr := readInstanceInfo(l.path); readErr == nil && info.Token == l.info.Token {
+		_ = os.Remove(l.path)
+	}
+	return err
+}
+
+func (l *Listener) serve() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		l.handle(conn)
+	}
+}
+
+// handle 读取一次转发：第一行为令牌，第二行为链接；令牌正确时回复 ok。
+func (l *Listener) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(forwardTimeout))
+	r := bufio.NewReader(conn)
+	token, err := r.ReadString('\n')
+	if err != nil || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(l.info.Token)) != 1 {
+		return
+	}
+	link, err := r.ReadString('\n')
+	link = strings.TrimSpace(link)
+	if err != nil || !IsSupported(link) {
+		return
+	}
+	if l.onLink != nil {
+		l.onLink(link)
+	}
+	_, _ = conn.Write([]byte("ok\n"))
+}
+
+// Forward 把链接交给 dir 下 instance.json 记录的已运行实例；没有实例在运行（文件不存在或已失效）时返回错误。
+func Forward(dir, link string) error {
+	info, err := readInstanceInfo(filepath.Join(dir, instanceFileName))
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", info.Addr, forwardTimeout)
+	if err != nil {
+		return fmt.Errorf("链接通道: 没有正在运行的实例: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(forwardTimeout))
+	if _, err := fmt.Fprintf(conn, "%s\n%s\n", info.Token, strings.TrimSpace(link)); err != nil {
+		return fmt.Errorf("链接通道: 转发失败: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || strings.TrimSpace(reply) != "ok" {
+		return fmt.Errorf("链接通道: 已运行的实例未接收链接")
+	}
+	return nil
+}
+
+func readInstanceInfo(path string) (instanceInfo, error) {
+	var info instanceInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, fmt.Errorf("链接通道: 没有正在运行的实例: %w", err)
+	}
+	if err := json.Unmarshal(data, &info); err != nil || info.Addr == "" || info.Token == "" {
+		return info, fmt.Errorf("链接通道: %s 内容无效", path)
+	}
+	return info, nil
+}
@@ -0,0 +1,51 @@
+package urlscheme
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// desktopFileName 写入 ~/.local/share/applications 的桌面文件名
+const desktopFileName = "myproxy-url-handler.desktop"
+
+// registerLinux 写入 freedesktop 桌面文件并通过 xdg-mime 设为各协议的默认处理程序。
+func registerLinux(exePath string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("获取用户目录失败: %w", err)
+	}
+	appDir := filepath.Join(home, ".local", "share", "applications")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		return fmt.Errorf("创建应用目录失败: %w", err)
+	}
+
+	mimeTypes := make([]string, 0, len(Schemes))
+	for _, scheme := range Schemes {
+		mimeTypes = append(mimeTypes, "x-scheme-handler/"+scheme)
+	}
+	content := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=MyProxy
+Exec="%s" %%u
+Terminal=false
+NoDisplay=true
+MimeType=%s;
+`, exePath, strings.Join(mimeTypes, ";"))
+	if err := os.WriteFile(filepath.Join(appDir, desktopFileName), []byte(content), 0644); err != nil {
+		return fmt.Errorf("写入桌面文件失败: %w", err)
+	}
+
+	xdgMime, err := exec.LookPath("xdg-mime")
+	if err != nil {
+		return fmt.Errorf("未找到 xdg-mime，已写入桌面文件但未设为默认处理程序")
+	}
+	for _, mimeType := range mimeTypes {
+		if out, err := exec.Command(xdgMime, "default", desktopFileName, mimeType).CombinedOutput(); err != nil {
+			return fmt.Errorf("设置 %s 默认处理程序失败: %v, 输出: %s", mimeType, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
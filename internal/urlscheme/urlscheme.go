@@ -0,0 +1,182 @@
+package urlscheme
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// AppScheme 应用自有的链接协议，格式：myproxy://import?url=<订阅地址或分享链接>&label=<名称>，
+// 或分享配置 myproxy://profile?data=<编码后的配置>
+const AppScheme = "myproxy"
+
+// Schemes 需要在系统中注册由本应用打开的链接协议
+var Schemes = []string{AppScheme, "sub", "vmess", "ss"}
+
+// Link 解析后的导入链接
+type Link struct {
+	Target  string // 订阅地址（http/https）或单节点分享链接
+	Label   string // 名称（可选）
+	Profile string // 分享配置数据（myproxy://profile），非空时 Target 为空
+}
+
+// ProfileURL 返回分享配置的链接。
+func ProfileURL(data string) string {
+	return AppScheme + "://profile?data=" + url.QueryEscape(data)
+}
+
+// IsSubscription 判断导入目标是否为订阅地址
+func (l *Link) IsSubscription() bool {
+	lower := strings.ToLower(l.Target)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// IsSupported 判断字符串是否为已注册协议的链接
+func IsSupported(raw string) bool {
+	lower := strings.ToLower(strings.TrimSpace(raw))
+	for _, scheme := range Schemes {
+		if strings.HasPrefix(lower, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// FindInArgs 从命令行参数中找出第一个已注册协议的链接（系统通过参数把被点击的链接传给应用）
+func FindInArgs(args []string) string {
+	for _, arg := range args {
+		if IsSupported(arg) {
+			return strings.TrimSpace(arg)
+		}
+	}
+	return ""
+}
+
+// Parse 解析导入链接：
+//   - myproxy://import?url=...&label=...
+//   - myproxy://profile?data=...（分享配置）
+//   - sub://<Base64(订阅地址)>#名称（也兼容未编码的地址）
+//   - vmess://、ss:// 等分享链接原样作为导入目标
+func Parse(raw string) (*Link, error) {
+	raw = strings.TrimSpace(raw)
+	scheme, rest, found := strings.Cut(raw, "://")
+	if !found {
+		return nil, fmt.Errorf("无效的链接: 缺少协议")
+	}
+
+	switch strings.ToLower(scheme) {
+	case AppScheme:
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("无效的链接: %w", err)
+		}
+		if strings.EqualFold(u.Host, "profile") {
+			data := strings.TrimSpace(u.Query().Get("data"))
+			if data == "" {
+				return nil, fmt.Errorf("无效的链接: 缺少 data 参数")
+			}
+			return &Link{Profile: data}, nil
+		}
+		target := strings.TrimSpace(u.Query().Get("url"))
+		if target == "" {
+			return nil, fmt.Errorf("无效的链接: 缺少 url 参数")
+		}
+		return &Link{Target: target, Label: strings.TrimSpace(u.Query().Get("label"))}, nil
+	case "sub":
+		encoded, label, _ := strings.Cut(rest, "#")
+		if decodedLabel, err := url.QueryUnescape(label); err == nil {
+			label = decodedLabel
+		}
+		target := decodeSubTarget(encoded)
+		if target == "" {
+			return nil, fmt.Errorf("无效的订阅链接")
+		}
+		return &Link{Target: target, Label: label}, nil
+	default:
+		if !IsSupported(raw) {
+			return nil, fmt.Errorf("不支持的链接协议: %s", scheme)
+		}
+		return &Link{Target: raw}, nil
+	}
+}
+
+// decodeSubTarget 解码 sub:// 后的订阅地址：优先按 Base64 解码，失败时视为明文地址
+func decodeSubTarget(encoded string) string {
+	encoded = strings.TrimSpace(encoded)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(encoded); err == nil {
+			s := strings.TrimSpace(string(decoded))
+			if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+				return s
+			}
+		}
+	}
+	if unescaped, err := url.QueryUnescape(encoded); err == nil {
+		encoded = unescaped
+	}
+	if strings.HasPrefix(encoded, "http://") || strings.HasPrefix(encoded, "https://") {
+		return encoded
+	}
+	return ""
+}
+
+// RegisterSupported 当前系统能否注册链接协议。macOS 需在应用包的 Info.plist 中声明协议并处理 Apple Event 才能收到链接，
+// 目前构建的是独立可执行文件，暂不支持。
+func RegisterSupported() bool {
+	return runtime.GOOS == "windows" || runtime.GOOS == "linux"
+}
+
+// Register 在当前系统中把 Schemes 注册为由 exePath 打开。
+func Register(exePath string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return registerWindows(exePath)
+	case "linux":
+		return registerLinux(exePath)
+	default:
+		return fmt.Errorf("不支持在 %s 上注册导入链接", runtime.GOOS)
+	}
+}
+
+// shareLinkSchemes 可作为单节点导入的分享链接协议（剪贴板检测使用，未必在系统中注册）
+var shareLinkSchemes = []string{"vmess", "ss", "trojan", "socks5"}
+
+// subscriptionURLHints 订阅地址中常见的路径或参数片段，用于从普通网址中识别订阅链接
+var subscriptionURLHints = []string{"sub", "subscribe", "token=", "clash", "/link/", "v2ray", "flag="}
+
+// DetectClipboardLink 识别剪贴板文本是否为可导入的链接：已注册协议、节点分享链接或疑似订阅地址。
+// 仅处理单个不含空白的链接，无法识别时返回 nil。
+func DetectClipboardLink(text string) *Link {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text, " \t\r\n") {
+		return nil
+	}
+	if IsSupported(text) {
+		link, err := Parse(text)
+		if err != nil {
+			return nil
+		}
+		return link
+	}
+	lower := strings.ToLower(text)
+	for _, scheme := range shareLinkSchemes {
+		if strings.HasPrefix(lower, scheme+"://") && len(text) > len(scheme)+3 {
+			return &Link{Target: text}
+		}
+	}
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		u, err := url.Parse(text)
+		if err != nil || u.Host == "" {
+			return nil
+		}
+		pathAndQuery := strings.ToLower(u.Path + "?" + u.RawQuery)
+		for _, hint := range subscriptionURLHints {
+			if strings.Contains(pathAndQuery, hint) {
+				return &Link{Target: text}
+			}
+		}
+	}
+	return nil
+}
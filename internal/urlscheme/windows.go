@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package urlscheme
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// registerWindows 在 HKEY_CURRENT_USER\Software\Classes 下为每个协议写入 URL Protocol 及打开命令（无需管理员权限）。
+func registerWindows(exePath string) error {
+	for _, scheme := range Schemes {
+		base := `Software\Classes\` + scheme
+		key, _, err := registry.CreateKey(registry.CURRENT_USER, base, registry.SET_VALUE)
+		if err != nil {
+			return fmt.Errorf("注册 %s:// 失败: %w", scheme, err)
+		}
+		_ = key.SetStringValue("", "URL:"+scheme+" Protocol")
+		err = key.SetStringValue("URL Protocol", "")
+		key.Close()
+		if err != nil {
+			return fmt.Errorf("注册 %s:// 失败: %w", scheme, err)
+		}
+
+		cmdKey, _, err := registry.CreateKey(registry.CURRENT_USER, base+`\shell\open\command`, registry.SET_VALUE)
+		if err != nil {
+			return fmt.Errorf("注册 %s:// 打开命令失败: %w", scheme, err)
+		}
+		err = cmdKey.SetStringValue("", fmt.Sprintf(`"%s" "%%1"`, exePath))
+		cmdKey.Close()
+		if err != nil {
+			return fmt.Errorf("注册 %s:// 打开命令失败: %w", scheme, err)
+		}
+	}
+	return nil
+}
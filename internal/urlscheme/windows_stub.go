@@ -0,0 +1,11 @@
+//go:build !windows
+
+package urlscheme
+
+import "fmt"
+
+// registerWindows 仅在 Windows 构建中由 windows.go 提供真实实现；其它 GOOS 需占位以满足 urlscheme.go 的编译期引用。
+func registerWindows(exePath string) error {
+	_ = exePath
+	return fmt.Errorf("不支持的操作系统: windows")
+}
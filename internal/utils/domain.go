@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"net"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// RegistrableDomain 返回主机名的可注册域名（公共后缀 + 一级），用于按站点归并访问记录。
+// 按公共后缀列表计算：api.foo.com -> foo.com，a.b.example.co.uk -> example.co.uk。
+// IP 地址、单标签主机名或本身就是公共后缀（如 github.io）时原样返回（小写）。
+func RegistrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(strings.TrimSpace(host), "[]")), ".")
+	if host == "" || net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
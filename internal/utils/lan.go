@@ -0,0 +1,29 @@
+package utils
+
+import "net"
+
+// LocalIPv4Addrs 返回本机已启用网卡上的 IPv4 地址（不含回环），用于选择监听的局域网地址。
+func LocalIPv4Addrs() []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var addrs []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range ifAddrs {
+			if ipNet, ok := a.(*net.IPNet); ok {
+				if ip4 := ipNet.IP.To4(); ip4 != nil && !ip4.IsLinkLocalUnicast() {
+					addrs = append(addrs, ip4.String())
+				}
+			}
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "net"
+
+// networkProbeAddrs 检测默认路由时使用的公网地址（IPv4、IPv6 各一），只用于选路，不会发出数据包
+var networkProbeAddrs = []string{"1.1.1.1:53", "[2606:4700:4700::1111]:53"}
+
+// HasDefaultRoute 本机是否有通往公网的路由：对公网地址建立 UDP「连接」只查询路由表、不发送数据，
+// 网线拔出、Wi‑Fi 断开或飞行模式时返回 false。有路由不代表一定能上网（如需要网页认证的热点）。
+func HasDefaultRoute() bool {
+	for _, addr := range networkProbeAddrs {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		return true
+	}
+	return false
+}
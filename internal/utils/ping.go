@@ -1,17 +1,28 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"myproxy.com/p/internal/model"
 )
 
+// 测速方式（latencyMethod 取值）
+const (
+	DelayMethodTCP = "tcp" // TCP 连接耗时
+	DelayMethodTLS = "tls" // TCP 连接 + TLS 握手耗时，更接近实际使用；非 TLS 节点仍按 TCP 连接
+)
+
 // Ping 延迟测试工具。
 // 负责测试服务器延迟，不涉及数据更新操作。
 type Ping struct {
+	method func() string // 返回当前测速方式，未设置时按 TCP 连接
 }
 
 // NewPing 创建新的延迟测试工具实例。
@@ -20,28 +31,86 @@ func NewPing() *Ping {
 	return &Ping{}
 }
 
+// SetMethodSource 设置测速方式的来源（如 ConfigService.GetLatencyMethod），每次测速时读取。
+func (p *Ping) SetMethodSource(method func() string) {
+	p.method = method
+}
+
+// Method 返回当前测速方式。
+func (p *Ping) Method() string {
+	if p.method == nil {
+		return DelayMethodTCP
+	}
+	return p.method()
+}
+
+// DefaultDelayTimeout 测速默认超时时间
+const DefaultDelayTimeout = 5 * time.Second
+
 // TestServerDelay 测试单个服务器延迟。
 // 参数：
 //   - server: 服务器节点
 //
 // 返回：延迟值（毫秒）和错误（如果有）
 func (p *Ping) TestServerDelay(server model.Node) (int, error) {
+	return p.TestServerDelayWithTimeout(server, DefaultDelayTimeout)
+}
+
+// TestServerDelayWithTimeout 以指定超时测试单个服务器延迟，用于选中节点时的快速探测。
+// 测速方式为 TLS 且节点使用 TLS 时，延迟包含 TLS 握手，timeout 为连接与握手的总时长。
+// 参数：
+//   - server: 服务器节点
+//   - timeout: 连接超时时间
+//
+// 返回：延迟值（毫秒）和错误（如果有）
+func (p *Ping) TestServerDelayWithTimeout(server model.Node, timeout time.Duration) (int, error) {
 	// 使用TCP连接测试延迟
-	addr := fmt.Sprintf("%s:%d", server.Addr, server.Port)
+	addr := net.JoinHostPort(server.Addr, strconv.Itoa(server.Port))
 	start := time.Now()
 
 	// 尝试建立TCP连接
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	conn, err := net.DialTimeout("tcp", addr, timeout)
 	if err != nil {
 		return -1, fmt.Errorf("连接服务器失败: %w", err)
 	}
 	defer conn.Close()
 
+	if config, ok := nodeTLSConfig(server); ok && p.Method() == DelayMethodTLS {
+		ctx, cancel := context.WithDeadline(context.Background(), start.Add(timeout))
+		defer cancel()
+		if err := tls.Client(conn, config).HandshakeContext(ctx); err != nil {
+			return -1, fmt.Errorf("TLS 握手失败: %w", err)
+		}
+	}
+
 	// 计算延迟
 	delay := int(time.Since(start).Milliseconds())
 	return delay, nil
 }
 
+// nodeTLSConfig 返回 TLS 节点（Trojan、开启 TLS 的 VMess）握手用的配置，其他节点返回 false。
+// 只用于计时、握手后不传输数据，因此不校验证书（自签名证书的节点也能测速）。
+func nodeTLSConfig(server model.Node) (*tls.Config, bool) {
+	config := &tls.Config{InsecureSkipVerify: true}
+	switch {
+	case server.ProtocolType == "trojan":
+		config.ServerName = server.TrojanSNI
+		for _, alpn := range strings.Split(server.TrojanAlpn, ",") {
+			if alpn = strings.TrimSpace(alpn); alpn != "" {
+				config.NextProtos = append(config.NextProtos, alpn)
+			}
+		}
+	case server.ProtocolType == "vmess" && server.VMessTLS == "tls":
+		config.ServerName = server.VMessHost
+	default:
+		return nil, false
+	}
+	if config.ServerName == "" {
+		config.ServerName = server.Addr
+	}
+	return config, true
+}
+
 // TestAllServersDelay 测试多个服务器延迟。
 // 参数：
 //   - servers: 服务器节点列表
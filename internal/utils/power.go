@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// OnBatteryPower 判断本机当前是否由电池供电。台式机等无电池设备返回 false；
+// 无法检测（命令不存在、权限不足等）时返回错误，调用方应按接通电源处理。
+func OnBatteryPower() (bool, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return onBatteryPowerDarwin()
+	case "linux":
+		return onBatteryPowerLinux()
+	case "windows":
+		return onBatteryPowerWindows()
+	default:
+		return false, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+	}
+}
+
+// onBatteryPowerDarwin 解析 pmset 输出首行：Now drawing from 'Battery Power' / 'AC Power'。
+func onBatteryPowerDarwin() (bool, error) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, fmt.Errorf("读取电源状态失败: %w", err)
+	}
+	return strings.Contains(string(out), "'Battery Power'"), nil
+}
+
+// onBatteryPowerLinux 读取 /sys/class/power_supply：有外接电源在线时为接通电源，
+// 否则只要存在电池即视为电池供电。
+func onBatteryPowerLinux() (bool, error) {
+	dirs, err := filepath.Glob("/sys/class/power_supply/*")
+	if err != nil {
+		return false, fmt.Errorf("读取电源状态失败: %w", err)
+	}
+	hasBattery := false
+	for _, dir := range dirs {
+		kind := readSysfsValue(filepath.Join(dir, "type"))
+		switch kind {
+		case "Mains", "USB":
+			if readSysfsValue(filepath.Join(dir, "online")) == "1" {
+				return false, nil
+			}
+		case "Battery":
+			// 外设（鼠标、手柄等）的电池 scope 为 Device，不代表本机供电
+			if readSysfsValue(filepath.Join(dir, "scope")) != "Device" {
+				hasBattery = true
+			}
+		}
+	}
+	return hasBattery, nil
+}
+
+func readSysfsValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
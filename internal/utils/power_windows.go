@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGetSystemPowerStatus = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus 对应 Win32 SYSTEM_POWER_STATUS。
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// onBatteryPowerWindows 通过 GetSystemPowerStatus 读取交流电源状态（0 为未接通）。
+func onBatteryPowerWindows() (bool, error) {
+	var status systemPowerStatus
+	ret, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return false, fmt.Errorf("读取电源状态失败: %w", err)
+	}
+	return status.ACLineStatus == 0, nil
+}
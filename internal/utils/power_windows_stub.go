@@ -0,0 +1,10 @@
+//go:build !windows
+
+package utils
+
+import "fmt"
+
+// onBatteryPowerWindows 仅在 Windows 构建中由 power_windows.go 提供真实实现；其它 GOOS 需占位以满足 power.go 的编译期引用。
+func onBatteryPowerWindows() (bool, error) {
+	return false, fmt.Errorf("不支持的操作系统: windows")
+}
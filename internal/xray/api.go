@@ -0,0 +1,88 @@
+package xray
+
+import (
+	"net"
+	"strconv"
+)
+
+const (
+	// APITag 生成配置中 gRPC API 入站、出站与路由规则使用的 tag
+	APITag = "api"
+	// apiListenHost API 入站只监听本机，不对局域网开放
+	apiListenHost = "127.0.0.1"
+)
+
+// apiServices 开启 API 时注册的服务：HandlerService 用于增删出站（热切换节点），StatsService 用于流量统计，
+// RoutingService 用于均衡器状态与连接路由查询，LoggerService 用于重启日志。
+var apiServices = []string{"HandlerService", "StatsService", "RoutingService", "LoggerService"}
+
+// APIOptions xray gRPC API 选项
+type APIOptions struct {
+	Port int // 本机监听端口
+}
+
+// applyAPI 在配置中加入 API 入站（dokodemo-door，兼容不支持 api.listen 的旧版本）、
+// 把该入站路由到 API 的规则（须排在所有规则之前）与统计开关。
+func applyAPI(config map[string]interface{}, port int, services []string) {
+	config["api"] = map[string]interface{}{
+		"tag":      APITag,
+		"services": services,
+	}
+	if _, ok := config["stats"]; !ok {
+		config["stats"] = map[string]interface{}{}
+	}
+	inbounds, _ := config["inbounds"].([]interface{})
+	config["inbounds"] = append(inbounds, map[string]interface{}{
+		"tag":      APITag,
+		"listen":   apiListenHost,
+		"port":     port,
+		"protocol": "dokodemo-door",
+		"settings": map[string]interface{}{"address": apiListenHost},
+	})
+	routing, _ := config["routing"].(map[string]interface{})
+	if routing == nil {
+		routing = map[string]interface{}{}
+		config["routing"] = routing
+	}
+	rules, _ := routing["rules"].([]interface{})
+	apiRule := map[string]interface{}{
+		"type":        "field",
+		"inboundTag":  []string{APITag},
+		"outboundTag": APITag,
+	}
+	routing["rules"] = append([]interface{}{apiRule}, rules...)
+}
+
+// apiInboundPort 返回配置中 API 入站（tag 为 APITag）的端口，没有时返回 0。
+func apiInboundPort(config map[string]interface{}) int {
+	inbounds, _ := config["inbounds"].([]interface{})
+	for _, ib := range inbounds {
+		m, ok := ib.(map[string]interface{})
+		if !ok || m["tag"] != APITag {
+			continue
+		}
+		switch port := m["port"].(type) {
+		case float64:
+			return int(port)
+		case int:
+			return port
+		}
+	}
+	return 0
+}
+
+// apiAddress 返回本机 API 地址（host:port），port 为 0 时返回空串。
+func apiAddress(port int) string {
+	if port <= 0 {
+		return ""
+	}
+	return net.JoinHostPort(apiListenHost, strconv.Itoa(port))
+}
+
+// APIAddr 返回实例的 gRPC API 地址（如 127.0.0.1:10085），未开启 API 时返回空串。
+func (xi *XrayInstance) APIAddr() string {
+	if xi.external != nil {
+		return xi.external.apiAddr
+	}
+	return xi.apiAddr
+}
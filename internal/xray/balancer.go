@@ -0,0 +1,150 @@
+package xray
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/features/routing"
+	"myproxy.com/p/internal/model"
+)
+
+const (
+	// BalancerStrategyLeastPing 按 observatory 测得的最低延迟选择出站
+	BalancerStrategyLeastPing = "leastPing"
+	// BalancerStrategyLeastLoad 按 burstObservatory 的多次采样（延迟与稳定性）选择出站
+	BalancerStrategyLeastLoad = "leastLoad"
+
+	// DefaultObservatoryProbeURL 隧道内探测默认使用的地址
+	DefaultObservatoryProbeURL = "https://www.gstatic.com/generate_204"
+	// DefaultObservatoryProbeInterval 默认探测间隔
+	DefaultObservatoryProbeInterval = time.Minute
+
+	// balancerTag 负载均衡器 tag；路由规则中原本指向 "proxy" 的流量改为指向该均衡器
+	balancerTag = "proxy-balancer"
+	// balancerOutboundPrefix 均衡组内出站 tag 前缀，observatory 与 balancer 均按此前缀选择出站
+	balancerOutboundPrefix = "proxy-"
+)
+
+// BalancerOptions 负载均衡组配置：多个节点按策略自动选择，由 xray 在隧道内实测延迟。
+type BalancerOptions struct {
+	Nodes         []*model.Node // 组内节点，第一个作为探测结果不可用时的回退出站
+	Strategy      string        // leastPing 或 leastLoad，空则为 leastPing
+	ProbeURL      string        // 隧道内探测地址，空则使用 DefaultObservatoryProbeURL
+	ProbeInterval time.Duration // 探测间隔，<= 0 则使用 DefaultObservatoryProbeInterval
+}
+
+// balancerOutboundTag 返回均衡组内第 i 个出站的 tag。
+func balancerOutboundTag(i int) string {
+	return fmt.Sprintf("%s%d", balancerOutboundPrefix, i)
+}
+
+// BalancerNodeIndex 由均衡组出站 tag（proxy-N）得到 BalancerOptions.Nodes 中的下标，无法识别时返回 -1。
+func BalancerNodeIndex(tag string) int {
+	if !strings.HasPrefix(tag, balancerOutboundPrefix) {
+		return -1
+	}
+	i, err := strconv.Atoi(strings.TrimPrefix(tag, balancerOutboundPrefix))
+	if err != nil || i < 0 {
+		return -1
+	}
+	return i
+}
+
+// BalancerTarget 返回负载均衡器当前选中的出站 tag（如 proxy-1）；未启用均衡组或尚无探测结果时返回空。
+func (xi *XrayInstance) BalancerTarget() string {
+	// 内置转发不启动 xray，没有均衡组
+	if !xi.IsRunning() || xi.forwarder != nil {
+		return ""
+	}
+	if xi.external != nil {
+		return xi.external.balancerTarget(balancerTag)
+	}
+	pt, ok := xi.instance.GetFeature(routing.RouterType()).(routing.BalancerPrincipleTarget)
+	if !ok {
+		return ""
+	}
+	targets, err := pt.GetPrincipleTarget(balancerTag)
+	if err != nil || len(targets) == 0 {
+		return ""
+	}
+	return targets[0]
+}
+
+// isProxyOutboundTag 判断出站 tag 是否为代理出站（单节点的 "proxy" 或均衡组内的 "proxy-N"）。
+func isProxyOutboundTag(tag string) bool {
+	return tag == "proxy" || (strings.HasPrefix(tag, balancerOutboundPrefix) && tag != balancerTag)
+}
+
+// buildBalancerOutbounds 为均衡组内每个节点创建出站，tag 依次为 proxy-0、proxy-1…
+func buildBalancerOutbounds(opts *BalancerOptions) ([]interface{}, error) {
+	outbounds := make([]interface{}, 0, len(opts.Nodes))
+	for i, node := range opts.Nodes {
+		outbound, err := CreateOutboundFromServer(node)
+		if err != nil {
+			return nil, fmt.Errorf("节点 %s: %w", node.Name, err)
+		}
+		outbound["tag"] = balancerOutboundTag(i)
+		outbounds = append(outbounds, outbound)
+	}
+	return outbounds, nil
+}
+
+// applyBalancer 向配置写入 balancer 与对应的 observatory/burstObservatory，并把路由规则中的 "proxy" 出站改为均衡器。
+func applyBalancer(config map[string]interface{}, rules []interface{}, opts *BalancerOptions) {
+	probeURL := strings.TrimSpace(opts.ProbeURL)
+	if probeURL == "" {
+		probeURL = DefaultObservatoryProbeURL
+	}
+	interval := opts.ProbeInterval
+	if interval <= 0 {
+		interval = DefaultObservatoryProbeInterval
+	}
+	strategy := opts.Strategy
+	if strategy != BalancerStrategyLeastLoad {
+		strategy = BalancerStrategyLeastPing
+	}
+
+	selector := []string{balancerOutboundPrefix}
+	if strategy == BalancerStrategyLeastLoad {
+		// leastLoad 依赖 burstObservatory 的多次采样结果
+		config["burstObservatory"] = map[string]interface{}{
+			"subjectSelector": selector,
+			"pingConfig": map[string]interface{}{
+				"destination": probeURL,
+				"interval":    interval.String(),
+				"sampling":    3,
+				"timeout":     "5s",
+			},
+		}
+	} else {
+		config["observatory"] = map[string]interface{}{
+			"subjectSelector":   selector,
+			"probeURL":          probeURL,
+			"probeInterval":     interval.String(),
+			"enableConcurrency": true,
+		}
+	}
+
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok || rule["outboundTag"] != "proxy" {
+			continue
+		}
+		delete(rule, "outboundTag")
+		rule["balancerTag"] = balancerTag
+	}
+
+	routing := config["routing"].(map[string]interface{})
+	routing["balancers"] = []interface{}{
+		map[string]interface{}{
+			"tag":         balancerTag,
+			"selector":    selector,
+			"fallbackTag": balancerOutboundTag(0),
+			"strategy": map[string]interface{}{
+				"type": strategy,
+			},
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package xray
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// xray-core 通过以下环境变量（或其大写下划线形式）定位资源、证书与配置文件，缺省为可执行文件所在目录
+const (
+	envAssetLocation   = "xray.location.asset"
+	envCertLocation    = "xray.location.cert"
+	envConfigLocation  = "xray.location.config"
+	envConfdirLocation = "xray.location.confdir"
+)
+
+var (
+	launchAssetDirOnce sync.Once
+	launchAssetDir     string
+)
+
+// LaunchAssetDir 返回应用启动时环境变量 XRAY_LOCATION_ASSET（或 xray.location.asset）指定的资源目录，未指定时为空。
+// 首次调用时读取，之后 ConfigureEnvironment 的改写不影响其结果。
+func LaunchAssetDir() string {
+	launchAssetDirOnce.Do(func() {
+		launchAssetDir = lookupEnvLocation(envAssetLocation)
+	})
+	return launchAssetDir
+}
+
+// ConfigureEnvironment 将 xray-core 的资源目录设为 assetDir，证书与配置目录限定在 runtimeDir 下，
+// 避免便携版与多个实例共用可执行文件目录或全局环境中的文件。须在创建 xray 实例前调用。
+func ConfigureEnvironment(assetDir, runtimeDir string) error {
+	LaunchAssetDir()
+	for _, dir := range []string{assetDir, runtimeDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("Xray: 创建目录 %s 失败: %w", dir, err)
+		}
+	}
+	setEnvLocation(envAssetLocation, assetDir)
+	setEnvLocation(envCertLocation, runtimeDir)
+	setEnvLocation(envConfigLocation, runtimeDir)
+	setEnvLocation(envConfdirLocation, "")
+	return nil
+}
+
+// envAltName 与 xray-core 的 NormalizeEnvName 一致：xray.location.asset -> XRAY_LOCATION_ASSET。
+func envAltName(name string) string {
+	return strings.ReplaceAll(strings.ToUpper(name), ".", "_")
+}
+
+func lookupEnvLocation(name string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return os.Getenv(envAltName(name))
+}
+
+// setEnvLocation 同时写入点分与大写两种形式，xray-core 优先读取点分形式。
+func setEnvLocation(name, value string) {
+	_ = os.Setenv(name, value)
+	_ = os.Setenv(envAltName(name), value)
+}
@@ -0,0 +1,337 @@
+package xray
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	routercmd "github.com/xtls/xray-core/app/router/command"
+	statscmd "github.com/xtls/xray-core/app/stats/command"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// externalStartTimeout 等待外部进程的 API 端口可连接的最长时间
+	externalStartTimeout = 5 * time.Second
+	// externalStopTimeout 请求外部进程退出后等待的时间，超时则强制结束
+	externalStopTimeout = 3 * time.Second
+	// externalAPITimeout 单次 gRPC 查询的超时
+	externalAPITimeout = time.Second
+	// externalStderrTail 启动失败时附带的最后几行输出
+	externalStderrTail = 5
+)
+
+// externalProcess 以外部 xray 可执行文件运行的实例：配置经标准输入传入（不落盘），
+// 日志读取自进程输出，流量统计与均衡器状态经注入的 gRPC API 查询。
+type externalProcess struct {
+	binary      string
+	configJSON  []byte
+	apiAddr     string
+	logCallback LogCallback
+
+	cmd    *exec.Cmd
+	conn   *grpc.ClientConn
+	stats  statscmd.StatsServiceClient
+	router routercmd.RoutingServiceClient
+	done   chan struct{} // 进程退出后关闭
+
+	mu       sync.Mutex
+	stopping bool
+	tail     []string // 最近的输出行，启动失败时用于说明原因
+	exitErr  error
+}
+
+// NewXrayInstanceFromBinary 创建以外部 xray 可执行文件运行的实例，用于固定使用某个 xray 版本。
+// 配置未开启 API 时注入仅监听本机的 gRPC API 入站（StatsService、RoutingService），用于读取流量与均衡器状态。
+func NewXrayInstanceFromBinary(binary string, configJSON []byte, logCallback LogCallback) (*XrayInstance, error) {
+	if err := CheckExternalBinary(binary); err != nil {
+		return nil, err
+	}
+	logCallback = dedupLogCallback(logCallback)
+	var config map[string]interface{}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, fmt.Errorf("Xray: 解析配置失败: %w", err)
+	}
+	var proxyTags []string
+	if outbounds, ok := config["outbounds"].([]interface{}); ok {
+		for _, ob := range outbounds {
+			if m, ok := ob.(map[string]interface{}); ok {
+				if tag, _ := m["tag"].(string); isProxyOutboundTag(tag) {
+					proxyTags = append(proxyTags, tag)
+				}
+			}
+		}
+	}
+
+	// 配置已开启 API（设置中启用了 gRPC API）时直接使用，否则在随机端口上注入
+	apiPort := apiInboundPort(config)
+	if apiPort == 0 {
+		port, err := freeLocalPort()
+		if err != nil {
+			return nil, fmt.Errorf("Xray: 分配 API 端口失败: %w", err)
+		}
+		apiPort = port
+		applyAPI(config, apiPort, []string{"StatsService", "RoutingService"})
+	}
+	injected, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("Xray: 生成配置失败: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &XrayInstance{
+		ctx:         ctx,
+		cancel:      cancel,
+		logWriter:   NewLogWriter(logCallback),
+		logCallback: logCallback,
+		proxyTags:   proxyTags,
+		external: &externalProcess{
+			binary:      binary,
+			configJSON:  injected,
+			apiAddr:     apiAddress(apiPort),
+			logCallback: logCallback,
+		},
+	}, nil
+}
+
+// CheckExternalBinary 检查外部 xray 可执行文件是否存在且不是目录。
+func CheckExternalBinary(binary string) error {
+	if strings.TrimSpace(binary) == "" {
+		return fmt.Errorf("Xray: 未设置 xray 可执行文件路径")
+	}
+	info, err := os.Stat(binary)
+	if err != nil {
+		return fmt.Errorf("Xray: 找不到 xray 可执行文件: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("Xray: %s 是目录，请选择 xray 可执行文件", binary)
+	}
+	return nil
+}
+
+// ExternalBinaryVersion 运行 `xray version` 并返回首行（如 "Xray 1.8.24 (Xray, Penetrates Everything.) ..."）。
+func ExternalBinaryVersion(binary string) (string, error) {
+	if err := CheckExternalBinary(binary); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalStartTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, binary, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("Xray: 读取版本失败: %w", err)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// freeLocalPort 返回本机回环地址上一个当前空闲的端口。
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// start 启动进程并等待 API 端口可连接；进程提前退出时返回其最后几行输出。
+func (ep *externalProcess) start() error {
+	cmd := exec.Command(ep.binary, "run", "-config", "stdin:", "-format", "json")
+	cmd.Stdin = bytes.NewReader(ep.configJSON)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Xray: 启动外部进程失败: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("Xray: 启动外部进程失败: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Xray: 启动外部进程失败: %w", err)
+	}
+	ep.cmd = cmd
+	ep.done = make(chan struct{})
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go ep.readOutput(stdout, &readers)
+	go ep.readOutput(stderr, &readers)
+	go func() {
+		// 先读完输出再 Wait，避免丢失退出前的最后几行
+		readers.Wait()
+		err := cmd.Wait()
+		ep.mu.Lock()
+		ep.exitErr = err
+		stopping := ep.stopping
+		ep.mu.Unlock()
+		if !stopping && ep.logCallback != nil {
+			ep.logCallback("ERROR", fmt.Sprintf("xray 外部进程意外退出: %v", err))
+		}
+		close(ep.done)
+	}()
+
+	deadline := time.Now().Add(externalStartTimeout)
+	for {
+		select {
+		case <-ep.done:
+			return fmt.Errorf("Xray: 外部进程启动后退出: %s", ep.failureDetail())
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", ep.apiAddr, readyDialTimeout)
+		if err == nil {
+			_ = conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			ep.stop()
+			return fmt.Errorf("Xray: 外部进程在 %s 内未就绪", externalStartTimeout)
+		}
+		time.Sleep(readyRetryInterval)
+	}
+
+	conn, err := grpc.NewClient(ep.apiAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		ep.stop()
+		return fmt.Errorf("Xray: 连接 API 失败: %w", err)
+	}
+	ep.conn = conn
+	ep.stats = statscmd.NewStatsServiceClient(conn)
+	ep.router = routercmd.NewRoutingServiceClient(conn)
+	return nil
+}
+
+// readOutput 逐行转发进程输出到日志回调（保持原始格式，便于访问记录解析），并保留最后几行。
+func (ep *externalProcess) readOutput(r io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ep.mu.Lock()
+		ep.tail = append(ep.tail, line)
+		if len(ep.tail) > externalStderrTail {
+			ep.tail = ep.tail[len(ep.tail)-externalStderrTail:]
+		}
+		ep.mu.Unlock()
+		if ep.logCallback != nil {
+			ep.logCallback(detectLogLevel(line), line)
+		}
+	}
+}
+
+// failureDetail 返回退出原因与最后几行输出。
+func (ep *externalProcess) failureDetail() string {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	detail := fmt.Sprint(ep.exitErr)
+	if len(ep.tail) > 0 {
+		detail += "\n" + strings.Join(ep.tail, "\n")
+	}
+	return detail
+}
+
+// stop 请求进程退出，超时后强制结束，并关闭 API 连接。
+func (ep *externalProcess) stop() {
+	ep.mu.Lock()
+	ep.stopping = true
+	ep.mu.Unlock()
+	if ep.conn != nil {
+		_ = ep.conn.Close()
+		ep.conn = nil
+	}
+	if ep.cmd == nil || ep.cmd.Process == nil {
+		return
+	}
+	select {
+	case <-ep.done:
+		return
+	default:
+	}
+	// Windows 不支持向进程发送中断信号，直接结束
+	if runtime.GOOS == "windows" {
+		_ = ep.cmd.Process.Kill()
+	} else {
+		_ = ep.cmd.Process.Signal(os.Interrupt)
+	}
+	select {
+	case <-ep.done:
+	case <-time.After(externalStopTimeout):
+		_ = ep.cmd.Process.Kill()
+		<-ep.done
+	}
+}
+
+// exited 进程是否已退出。
+func (ep *externalProcess) exited() bool {
+	if ep.done == nil {
+		return false
+	}
+	select {
+	case <-ep.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// queryTraffic 经 StatsService 查询出站流量计数，返回 tag 到上传、下载字节数的映射。
+func (ep *externalProcess) queryTraffic() map[string][2]int64 {
+	if ep.stats == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalAPITimeout)
+	defer cancel()
+	resp, err := ep.stats.QueryStats(ctx, &statscmd.QueryStatsRequest{Pattern: "outbound>>>"})
+	if err != nil {
+		return nil
+	}
+	traffic := make(map[string][2]int64)
+	for _, stat := range resp.GetStat() {
+		// 名称格式：outbound>>>{tag}>>>traffic>>>uplink|downlink
+		parts := strings.Split(stat.GetName(), ">>>")
+		if len(parts) != 4 || parts[2] != "traffic" {
+			continue
+		}
+		v := traffic[parts[1]]
+		switch parts[3] {
+		case "uplink":
+			v[0] += stat.GetValue()
+		case "downlink":
+			v[1] += stat.GetValue()
+		}
+		traffic[parts[1]] = v
+	}
+	return traffic
+}
+
+// balancerTarget 经 RoutingService 查询均衡器当前选中的出站 tag。
+func (ep *externalProcess) balancerTarget(tag string) string {
+	if ep.router == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalAPITimeout)
+	defer cancel()
+	resp, err := ep.router.GetBalancerInfo(ctx, &routercmd.GetBalancerInfoRequest{Tag: tag})
+	if err != nil {
+		return ""
+	}
+	targets := resp.GetBalancer().GetPrincipleTarget().GetTag()
+	if len(targets) == 0 {
+		return ""
+	}
+	return targets[0]
+}
@@ -0,0 +1,418 @@
+package xray
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"myproxy.com/p/internal/model"
+)
+
+const (
+	// forwardDialTimeout 连接上游 SOCKS5 服务器的超时
+	forwardDialTimeout = 10 * time.Second
+	// forwardHandshakeTimeout 本地客户端完成 SOCKS5 / HTTP 握手的最长时间
+	forwardHandshakeTimeout = 10 * time.Second
+	// forwardLogTime 访问日志的时间格式，与 xray 访问日志一致，便于访问记录解析
+	forwardLogTime = "2006/01/02 15:04:05.000000"
+)
+
+// SupportsBuiltinForward 节点是否可用内置转发代替 xray：SOCKS5 节点且在高级设置中开启了内置转发。
+func SupportsBuiltinForward(node *model.Node) bool {
+	return node != nil && node.BuiltinForward && node.ProtocolType == "socks5"
+}
+
+// socksForwarder 内置的轻量转发：在本地入站地址上接收 SOCKS5 与 HTTP 代理请求（与 xray 混合入站相同），
+// 经上游 SOCKS5 服务器（可带用户名/密码）转发 TCP 连接，不启动 xray。不支持 UDP、分流规则等 xray 功能。
+type socksForwarder struct {
+	listenAddr  string
+	upstream    proxy.ContextDialer
+	inbound     *InboundAuth
+	logCallback LogCallback
+
+	listener net.Listener
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	upload   atomic.Int64 // 客户端 -> 上游累计字节数
+	download atomic.Int64 // 上游 -> 客户端累计字节数
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// NewForwarderInstance 创建以内置 SOCKS5 转发运行的实例，在 listenAddr 上接收连接并转发到 node 的 SOCKS5 服务器。
+// auth 非 nil 时本地入站需认证（SOCKS5 用户名/密码与 HTTP Basic）。
+func NewForwarderInstance(node *model.Node, listenAddr string, auth *InboundAuth, logCallback LogCallback) (*XrayInstance, error) {
+	if !SupportsBuiltinForward(node) {
+		return nil, fmt.Errorf("Xray: 内置转发仅支持 SOCKS5 节点")
+	}
+	var upstreamAuth *proxy.Auth
+	if node.Username != "" || node.Password != "" {
+		upstreamAuth = &proxy.Auth{User: node.Username, Password: node.Password}
+	}
+	upstreamAddr := net.JoinHostPort(node.Addr, strconv.Itoa(node.Port))
+	dialer, err := proxy.SOCKS5("tcp", upstreamAddr, upstreamAuth, &net.Dialer{Timeout: forwardDialTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("Xray: 创建内置转发失败: %w", err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("Xray: 创建内置转发失败: 上游拨号器不支持 context")
+	}
+
+	logCallback = dedupLogCallback(logCallback)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &XrayInstance{
+		ctx:         ctx,
+		cancel:      cancel,
+		logWriter:   NewLogWriter(logCallback),
+		logCallback: logCallback,
+		forwarder: &socksForwarder{
+			listenAddr:  listenAddr,
+			upstream:    contextDialer,
+			inbound:     auth,
+			logCallback: logCallback,
+			conns:       make(map[net.Conn]struct{}),
+		},
+	}, nil
+}
+
+// start 开始监听本地入站。
+func (f *socksForwarder) start() error {
+	ln, err := net.Listen("tcp", f.listenAddr)
+	if err != nil {
+		return fmt.Errorf("Xray: 内置转发监听 %s 失败: %w", f.listenAddr, err)
+	}
+	f.listener = ln
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+	f.wg.Add(1)
+	go f.acceptLoop()
+	return nil
+}
+
+// stop 停止监听并断开所有转发中的连接。
+func (f *socksForwarder) stop() {
+	if f.listener == nil {
+		return
+	}
+	f.mu.Lock()
+	f.cancel()
+	for c := range f.conns {
+		_ = c.Close()
+	}
+	f.mu.Unlock()
+	_ = f.listener.Close()
+	f.wg.Wait()
+}
+
+// traffic 返回累计的上传、下载字节数。
+func (f *socksForwarder) traffic() (upload, download int64) {
+	return f.upload.Load(), f.download.Load()
+}
+
+func (f *socksForwarder) acceptLoop() {
+	defer f.wg.Done()
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.serve(conn)
+		}()
+	}
+}
+
+// serve 按首字节区分 SOCKS5（0x05）与 HTTP 代理请求，握手完成后经上游转发。
+func (f *socksForwarder) serve(client net.Conn) {
+	if !f.track(client) {
+		return
+	}
+	defer f.untrack(client)
+
+	_ = client.SetDeadline(time.Now().Add(forwardHandshakeTimeout))
+	reader := bufio.NewReader(client)
+	first, err := reader.Peek(1)
+	if err != nil {
+		return
+	}
+	var target string
+	var pending *http.Request // HTTP 普通请求：连接成功后改写发往目标
+	var reply func(ok bool)
+	if first[0] == 0x05 {
+		target, err = f.socksHandshake(client, reader)
+		reply = func(ok bool) { writeSocksReply(client, ok) }
+	} else {
+		target, pending, reply, err = f.httpHandshake(client, reader)
+	}
+	if err != nil {
+		return
+	}
+
+	remote, err := f.upstream.DialContext(f.ctx, "tcp", target)
+	if err != nil {
+		reply(false)
+		f.log("WARN", fmt.Sprintf("内置转发: 经上游连接 %s 失败: %v", target, err))
+		return
+	}
+	if !f.track(remote) {
+		return
+	}
+	defer f.untrack(remote)
+	reply(true)
+	_ = client.SetDeadline(time.Time{})
+	f.log("INFO", fmt.Sprintf("%s from tcp:%s accepted tcp:%s [mixed-in -> proxy]", time.Now().Format(forwardLogTime), client.RemoteAddr(), target))
+
+	// 边转发边计数，长连接的流量也能及时计入速率统计
+	upload := &countingWriter{w: remote, n: &f.upload}
+	download := &countingWriter{w: client, n: &f.download}
+	if pending != nil {
+		if err := pending.Write(upload); err != nil {
+			return
+		}
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		// reader 中可能已缓冲了握手之后的数据，需从 reader 而非 client 读取
+		_, _ = io.Copy(upload, reader)
+		closeWrite(remote)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(download, remote)
+		closeWrite(client)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// socksHandshake 完成 SOCKS5 服务端握手（仅支持 CONNECT），返回目标地址 host:port。
+func (f *socksForwarder) socksHandshake(client net.Conn, r *bufio.Reader) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return "", err
+	}
+	want := byte(0x00) // 无需认证
+	if f.inbound != nil {
+		want = 0x02 // 用户名/密码
+	}
+	if !containsByte(methods, want) {
+		_, _ = client.Write([]byte{0x05, 0xff})
+		return "", fmt.Errorf("没有可接受的认证方式")
+	}
+	if _, err := client.Write([]byte{0x05, want}); err != nil {
+		return "", err
+	}
+	if f.inbound != nil {
+		user, pass, err := readSocksCredentials(r)
+		if err != nil {
+			return "", err
+		}
+		if user != f.inbound.Username || pass != f.inbound.Password {
+			_, _ = client.Write([]byte{0x01, 0x01})
+			return "", fmt.Errorf("认证失败")
+		}
+		if _, err := client.Write([]byte{0x01, 0x00}); err != nil {
+			return "", err
+		}
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return "", err
+	}
+	if request[1] != 0x01 {
+		// 仅支持 CONNECT，UDP ASSOCIATE 等需要 xray
+		_, _ = client.Write([]byte{0x05, 0x07, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return "", fmt.Errorf("不支持的 SOCKS5 命令: %d", request[1])
+	}
+	var host string
+	switch request[3] {
+	case 0x01, 0x04:
+		ip := make([]byte, net.IPv4len)
+		if request[3] == 0x04 {
+			ip = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case 0x03:
+		length, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		name := make([]byte, length)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", fmt.Errorf("不支持的地址类型: %d", request[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// readSocksCredentials 读取 SOCKS5 用户名/密码子协商（RFC 1929）。
+func readSocksCredentials(r *bufio.Reader) (user, pass string, err error) {
+	readField := func() (string, error) {
+		length, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, length)
+		_, err = io.ReadFull(r, b)
+		return string(b), err
+	}
+	if _, err = r.ReadByte(); err != nil { // 子协商版本
+		return "", "", err
+	}
+	if user, err = readField(); err != nil {
+		return "", "", err
+	}
+	pass, err = readField()
+	return user, pass, err
+}
+
+// writeSocksReply 回复 CONNECT 结果，绑定地址填 0.0.0.0:0。
+func writeSocksReply(client net.Conn, ok bool) {
+	status := byte(0x00)
+	if !ok {
+		status = 0x05 // 连接被拒绝
+	}
+	_, _ = client.Write([]byte{0x05, status, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+}
+
+// httpHandshake 读取 HTTP 代理请求，返回目标地址与回复函数。
+// CONNECT 请求在连接成功后回复 200；普通请求去掉代理相关头并限定每个连接只转发一个请求，由 pending 带回。
+func (f *socksForwarder) httpHandshake(client net.Conn, r *bufio.Reader) (target string, pending *http.Request, reply func(ok bool), err error) {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if f.inbound != nil && !checkProxyAuth(req.Header.Get("Proxy-Authorization"), f.inbound) {
+		_, _ = io.WriteString(client, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\nContent-Length: 0\r\n\r\n")
+		return "", nil, nil, fmt.Errorf("HTTP 代理认证失败")
+	}
+	fail := func() {
+		_, _ = io.WriteString(client, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+	}
+
+	if req.Method == http.MethodConnect {
+		reply = func(ok bool) {
+			if ok {
+				_, _ = io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+			} else {
+				fail()
+			}
+		}
+		return withDefaultPort(req.Host, "443"), nil, reply, nil
+	}
+
+	if req.URL.Host == "" {
+		_, _ = io.WriteString(client, "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n")
+		return "", nil, nil, fmt.Errorf("不是代理请求: %s", req.URL)
+	}
+	target = withDefaultPort(req.URL.Host, "80")
+	req.Header.Del("Proxy-Authorization")
+	req.Header.Del("Proxy-Connection")
+	req.Close = true
+	reply = func(ok bool) {
+		if !ok {
+			fail()
+		}
+	}
+	return target, req, reply, nil
+}
+
+// countingWriter 将写入的字节数累加到 n。
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// checkProxyAuth 校验 HTTP Basic 形式的 Proxy-Authorization。
+func checkProxyAuth(header string, auth *InboundAuth) bool {
+	encoded, ok := strings.CutPrefix(header, "Basic ")
+	if !ok {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return false
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	return ok && user == auth.Username && pass == auth.Password
+}
+
+// withDefaultPort 为不带端口的 host 补上默认端口。
+func withDefaultPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
+
+func containsByte(b []byte, v byte) bool {
+	for _, c := range b {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *socksForwarder) log(level, message string) {
+	if f.logCallback != nil {
+		f.logCallback(level, message)
+	}
+}
+
+// track 登记连接以便 stop 时断开；已停止时直接关闭连接并返回 false。
+func (f *socksForwarder) track(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ctx.Err() != nil {
+		_ = conn.Close()
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+func (f *socksForwarder) untrack(conn net.Conn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = conn.Close()
+	delete(f.conns, conn)
+}
@@ -0,0 +1,87 @@
+package xray
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// fragmentOutboundTag 分片 freedom 出站的 tag；代理出站通过 sockopt.dialerProxy 经由该出站拨号
+const fragmentOutboundTag = "fragment"
+
+// fragmentRangePattern 分片参数的取值格式：单个数字或「最小-最大」
+var fragmentRangePattern = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// FragmentOptions TLS 分片：由 freedom 出站把 TLS ClientHello 拆成多个 TCP 包发送，
+// 用于 ClientHello 被按 SNI 识别并重置（RST）的网络。
+type FragmentOptions struct {
+	Packets  string // 分片范围："tlshello" 仅拆分 ClientHello，或包序号范围如 "1-3"
+	Length   string // 每片长度（字节）范围，如 "100-200"
+	Interval string // 片间隔（毫秒）范围，如 "10-20"
+}
+
+// String 返回「tlshello / 100-200 字节 / 10-20 毫秒」形式的描述。
+func (f *FragmentOptions) String() string {
+	if f == nil {
+		return "不分片"
+	}
+	return fmt.Sprintf("%s / %s 字节 / %s 毫秒", f.Packets, f.Length, f.Interval)
+}
+
+// Validate 检查分片参数格式。
+func (f *FragmentOptions) Validate() error {
+	if f.Packets != "tlshello" && !fragmentRangePattern.MatchString(f.Packets) {
+		return fmt.Errorf("分片范围无效: %q（应为 tlshello 或如 1-3）", f.Packets)
+	}
+	if !fragmentRangePattern.MatchString(f.Length) {
+		return fmt.Errorf("分片长度无效: %q（应如 100-200）", f.Length)
+	}
+	if !fragmentRangePattern.MatchString(f.Interval) {
+		return fmt.Errorf("分片间隔无效: %q（应如 10-20）", f.Interval)
+	}
+	return nil
+}
+
+// FragmentCandidates 返回分片助手依次尝试的参数组合：从对连接影响较小的组合开始。
+func FragmentCandidates() []FragmentOptions {
+	var out []FragmentOptions
+	for _, packets := range []string{"tlshello", "1-3"} {
+		for _, length := range []string{"100-200", "50-100", "10-20"} {
+			for _, interval := range []string{"10-20", "1-5"} {
+				out = append(out, FragmentOptions{Packets: packets, Length: length, Interval: interval})
+			}
+		}
+	}
+	return out
+}
+
+// applyFragment 令所有代理出站经分片出站拨号，并追加该 freedom 出站。
+func applyFragment(proxyOutbounds []interface{}, opts *FragmentOptions) []interface{} {
+	for _, ob := range proxyOutbounds {
+		outbound, ok := ob.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		streamSettings, ok := outbound["streamSettings"].(map[string]interface{})
+		if !ok {
+			streamSettings = map[string]interface{}{}
+			outbound["streamSettings"] = streamSettings
+		}
+		sockopt, ok := streamSettings["sockopt"].(map[string]interface{})
+		if !ok {
+			sockopt = map[string]interface{}{}
+			streamSettings["sockopt"] = sockopt
+		}
+		sockopt["dialerProxy"] = fragmentOutboundTag
+	}
+	return append(proxyOutbounds, map[string]interface{}{
+		"tag":      fragmentOutboundTag,
+		"protocol": "freedom",
+		"settings": map[string]interface{}{
+			"fragment": map[string]interface{}{
+				"packets":  opts.Packets,
+				"length":   opts.Length,
+				"interval": opts.Interval,
+			},
+		},
+	})
+}
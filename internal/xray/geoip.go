@@ -0,0 +1,64 @@
+package xray
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/platform"
+	"google.golang.org/protobuf/proto"
+)
+
+// LookupGeoIP 用 xray 资源目录中的 geoip.dat 查询 IP 的归属地，返回 IP -> 国家或地区代码（如 US、CN，
+// 局域网等保留地址为 PRIVATE）。查不到的 IP 不在结果中。
+// 每次调用读取并解析整个数据文件，适合对一批 IP 集中查询，结果应由调用方缓存。
+func LookupGeoIP(ips []string) (map[string]string, error) {
+	addrs := make(map[string]netip.Addr, len(ips))
+	for _, s := range ips {
+		if addr, err := netip.ParseAddr(strings.Trim(s, "[]")); err == nil {
+			addrs[s] = addr.Unmap()
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	data, err := os.ReadFile(platform.GetAssetLocation("geoip.dat"))
+	if err != nil {
+		return nil, fmt.Errorf("Xray: 读取 geoip.dat 失败: %w", err)
+	}
+	var list router.GeoIPList
+	if err := proto.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("Xray: 解析 geoip.dat 失败: %w", err)
+	}
+
+	result := make(map[string]string, len(addrs))
+	for _, entry := range list.Entry {
+		code := strings.ToUpper(entry.CountryCode)
+		// 只取国家或地区代码与 PRIVATE，跳过 CLOUDFLARE、TELEGRAM 等按服务划分的列表
+		if len(code) != 2 && code != "PRIVATE" {
+			continue
+		}
+		for _, cidr := range entry.Cidr {
+			ip, ok := netip.AddrFromSlice(cidr.Ip)
+			if !ok {
+				continue
+			}
+			prefix := netip.PrefixFrom(ip.Unmap(), int(cidr.Prefix))
+			if ip.Is4In6() {
+				prefix = netip.PrefixFrom(ip.Unmap(), int(cidr.Prefix)-96)
+			}
+			for s, addr := range addrs {
+				if prefix.Contains(addr) {
+					result[s] = code
+					delete(addrs, s)
+				}
+			}
+		}
+		if len(addrs) == 0 {
+			break
+		}
+	}
+	return result, nil
+}
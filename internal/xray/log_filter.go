@@ -0,0 +1,173 @@
+package xray
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// xrayLogLevels xray 日志行首的级别标记（[Debug]、[Info]、[Warning]、[Error]）对应的应用日志级别。
+var xrayLogLevels = map[string]string{
+	"debug":   "DEBUG",
+	"info":    "INFO",
+	"warning": "WARN",
+	"error":   "ERROR",
+}
+
+// xrayLevelNames 应用日志级别对应的 xray 级别标记，用于生成与 xray 同格式的汇总行。
+var xrayLevelNames = map[string]string{
+	"DEBUG": "Debug",
+	"INFO":  "Info",
+	"WARN":  "Warning",
+	"ERROR": "Error",
+}
+
+var (
+	// xrayTimestampPattern xray 日志时间戳，如 2026/02/12 10:43:05.123456
+	xrayTimestampPattern = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?\s*`)
+	// xraySessionPattern xray 连接会话 ID，如 [1234567890]，每条连接不同
+	xraySessionPattern = regexp.MustCompile(`\[\d+\]\s*`)
+	// logDedupSummaryPattern 合并重复日志的汇总行，捕获被省略的次数（格式见 logDeduper.flush）
+	logDedupSummaryPattern = regexp.MustCompile(`app/log: 相同日志 \d+ 秒内又出现 (\d+) 次，已省略: `)
+)
+
+// detectLogLevel 返回 xray 日志行的应用日志级别（DEBUG、INFO、WARN、ERROR）。
+// 带时间戳的行按行首级别标记判断，无标记的是访问日志，记为 INFO；
+// 其他输出（如外部 xray 启动失败信息）按级别关键字判断。
+func detectLogLevel(s string) string {
+	line := strings.TrimSpace(s)
+	if ts := xrayTimestampPattern.FindString(line); ts != "" {
+		rest := line[len(ts):]
+		if strings.HasPrefix(rest, "[") {
+			if end := strings.Index(rest, "]"); end > 0 {
+				if level, ok := xrayLogLevels[strings.ToLower(rest[1:end])]; ok {
+					return level
+				}
+			}
+		}
+		return "INFO"
+	}
+	upper := strings.ToUpper(line)
+	switch {
+	case strings.Contains(upper, "ERROR"):
+		return "ERROR"
+	case strings.Contains(upper, "WARN"):
+		return "WARN"
+	case strings.Contains(upper, "DEBUG"):
+		return "DEBUG"
+	}
+	return "INFO"
+}
+
+const (
+	// logDedupWindow 相同警告、错误日志的合并窗口：窗口内重复的行只保留第一条，结束时输出一条汇总。
+	logDedupWindow = 10 * time.Second
+	// logDedupSummaryMax 汇总行中原日志内容的最大长度（字符）
+	logDedupSummaryMax = 160
+)
+
+// logDedupEntry 合并窗口内的一条日志
+type logDedupEntry struct {
+	level      string
+	message    string
+	first      time.Time
+	suppressed int
+	timer      *time.Timer
+}
+
+// logDeduper 合并重复的 xray 警告、错误日志：节点频繁断连时同一错误可能每秒出现上百次，
+// 窗口内重复的行不再转发给回调（落盘与面板展示），窗口结束时输出「重复 N 次已省略」。
+// 比较时忽略时间戳与会话 ID；DEBUG、INFO（含访问日志）原样转发。
+type logDeduper struct {
+	mu       sync.Mutex
+	window   time.Duration
+	callback LogCallback
+	entries  map[string]*logDedupEntry
+}
+
+// dedupLogCallback 返回合并重复日志后再调用 callback 的回调；callback 为 nil 时返回 nil。
+func dedupLogCallback(callback LogCallback) LogCallback {
+	if callback == nil {
+		return nil
+	}
+	d := &logDeduper{window: logDedupWindow, callback: callback, entries: make(map[string]*logDedupEntry)}
+	return d.write
+}
+
+// SuppressedLogCount 返回合并重复日志的汇总行所代表的被省略行数；不是汇总行时返回 0。
+// 按日志计数的统计（如连接错误）据此补上被合并掉的次数。
+func SuppressedLogCount(line string) int {
+	m := logDedupSummaryPattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// normalizeLogLine 去掉时间戳、级别标记与会话 ID，得到用于比较的日志内容。
+func normalizeLogLine(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, xrayTimestampPattern.FindString(line))
+	if end := strings.Index(line, "]"); strings.HasPrefix(line, "[") && end > 0 {
+		if _, ok := xrayLogLevels[strings.ToLower(line[1:end])]; ok {
+			line = line[end+1:]
+		}
+	}
+	return strings.TrimSpace(xraySessionPattern.ReplaceAllString(line, ""))
+}
+
+func (d *logDeduper) write(level, line string) {
+	if level != "WARN" && level != "ERROR" {
+		d.callback(level, line)
+		return
+	}
+	message := normalizeLogLine(line)
+	key := level + " " + message
+	now := time.Now()
+
+	d.mu.Lock()
+	for k, e := range d.entries {
+		// 有重复的记录由定时器移除，其余过期记录在这里清理
+		if e.timer == nil && now.Sub(e.first) >= d.window {
+			delete(d.entries, k)
+		}
+	}
+	if e, ok := d.entries[key]; ok && now.Sub(e.first) < d.window {
+		e.suppressed++
+		if e.timer == nil {
+			e.timer = time.AfterFunc(d.window-now.Sub(e.first), func() { d.flush(key, e) })
+		}
+		d.mu.Unlock()
+		return
+	}
+	d.entries[key] = &logDedupEntry{level: level, message: message, first: now}
+	d.mu.Unlock()
+	d.callback(level, line)
+}
+
+// flush 窗口结束：移除记录，有被省略的重复日志时输出汇总行。
+func (d *logDeduper) flush(key string, e *logDedupEntry) {
+	d.mu.Lock()
+	if d.entries[key] == e {
+		delete(d.entries, key)
+	}
+	suppressed := e.suppressed
+	d.mu.Unlock()
+	if suppressed == 0 {
+		return
+	}
+	message := []rune(e.message)
+	if len(message) > logDedupSummaryMax {
+		message = append(message[:logDedupSummaryMax], '…')
+	}
+	d.callback(e.level, fmt.Sprintf("%s [%s] app/log: 相同日志 %d 秒内又出现 %d 次，已省略: %s",
+		time.Now().Format("2006/01/02 15:04:05.000000"), xrayLevelNames[e.level],
+		int(d.window/time.Second), suppressed, string(message)))
+}
@@ -0,0 +1,67 @@
+package xray
+
+import (
+	"fmt"
+	"time"
+)
+
+// PolicyOptions 连接超时策略，写入 xray policy.levels["0"]，作用于本地混合入站的全部连接（入站用户均为 level 0）。
+// xray 默认的 5 分钟空闲超时会让已失效的连接（如切换网络后）长时间挂起，可在此调短。
+type PolicyOptions struct {
+	Handshake    time.Duration // 建立连接时握手（读取首个请求）的超时
+	ConnIdle     time.Duration // 连接空闲超时：超过该时间上下行都没有数据则断开
+	UplinkOnly   time.Duration // 下行关闭后，上行继续保持的时间
+	DownlinkOnly time.Duration // 上行关闭后，下行继续保持的时间
+}
+
+// DefaultPolicyOptions 返回 xray 内置的默认超时。
+func DefaultPolicyOptions() PolicyOptions {
+	return PolicyOptions{
+		Handshake:    4 * time.Second,
+		ConnIdle:     300 * time.Second,
+		UplinkOnly:   2 * time.Second,
+		DownlinkOnly: 5 * time.Second,
+	}
+}
+
+// IsDefault 是否与 xray 默认值一致（一致时不写入配置）。
+func (p PolicyOptions) IsDefault() bool {
+	return p == DefaultPolicyOptions()
+}
+
+// String 返回「握手 4s / 空闲 5m0s / 仅上行 2s / 仅下行 5s」形式的描述。
+func (p PolicyOptions) String() string {
+	return fmt.Sprintf("握手 %s / 空闲 %s / 仅上行 %s / 仅下行 %s", p.Handshake, p.ConnIdle, p.UplinkOnly, p.DownlinkOnly)
+}
+
+// Validate 检查取值范围：握手 1-60 秒，空闲 10 秒-1 小时，仅上行/仅下行 0-60 秒，均须为整秒。
+func (p PolicyOptions) Validate() error {
+	check := func(name string, d, min, max time.Duration) error {
+		if d%time.Second != 0 || d < min || d > max {
+			return fmt.Errorf("%s超时须为 %d-%d 秒的整数", name, int(min/time.Second), int(max/time.Second))
+		}
+		return nil
+	}
+	if err := check("握手", p.Handshake, time.Second, time.Minute); err != nil {
+		return err
+	}
+	if err := check("空闲", p.ConnIdle, 10*time.Second, time.Hour); err != nil {
+		return err
+	}
+	if err := check("仅上行", p.UplinkOnly, 0, time.Minute); err != nil {
+		return err
+	}
+	return check("仅下行", p.DownlinkOnly, 0, time.Minute)
+}
+
+// applyPolicy 将超时写入 policy.levels["0"]（单位为秒）。
+func applyPolicy(policyConfig map[string]interface{}, p *PolicyOptions) {
+	policyConfig["levels"] = map[string]interface{}{
+		"0": map[string]interface{}{
+			"handshake":    int(p.Handshake / time.Second),
+			"connIdle":     int(p.ConnIdle / time.Second),
+			"uplinkOnly":   int(p.UplinkOnly / time.Second),
+			"downlinkOnly": int(p.DownlinkOnly / time.Second),
+		},
+	}
+}
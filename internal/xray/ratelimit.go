@@ -0,0 +1,252 @@
+package xray
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitBurst 令牌桶容量下限，需不小于单次读写的缓冲区大小
+const rateLimitBurst = 64 * 1024
+
+// relaySourceTTL 转发连接关闭后仍保留来源对应关系的时间，访问日志的处理可能晚于连接关闭
+const relaySourceTTL = 30 * time.Second
+
+// relaySources 经限速转发的连接在 xray 看来的来源地址（转发连接的本地地址）-> 客户端的实际地址
+var relaySources sync.Map
+
+// RelayClientAddr 将 xray 访问日志中的来源地址还原为客户端的实际地址（host:port）。
+// 开启入站限速时所有连接都由本机转发到 xray，日志中的来源均为 127.0.0.1；不是转发连接时返回 false。
+func RelayClientAddr(source string) (string, bool) {
+	v, ok := relaySources.Load(source)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// RateLimitOptions 入站带宽上限（KB/s，0 为不限）。
+type RateLimitOptions struct {
+	UploadKBps   int  // 客户端 -> 代理
+	DownloadKBps int  // 代理 -> 客户端
+	LANOnly      bool // 仅限制非本机来源的连接（向局域网共享时使用）
+}
+
+// Enabled 是否设置了任一方向的上限。
+func (o RateLimitOptions) Enabled() bool {
+	return o.UploadKBps > 0 || o.DownloadKBps > 0
+}
+
+// String 返回便于日志展示的描述。
+func (o RateLimitOptions) String() string {
+	format := func(kbps int) string {
+		if kbps <= 0 {
+			return "不限"
+		}
+		return fmt.Sprintf("%d KB/s", kbps)
+	}
+	s := fmt.Sprintf("上传 %s，下载 %s", format(o.UploadKBps), format(o.DownloadKBps))
+	if o.LANOnly {
+		s += "（仅局域网连接）"
+	}
+	return s
+}
+
+// newLimiter 按 KB/s 创建令牌桶，kbps <= 0 时返回 nil（不限速）。
+func newLimiter(kbps int) *rate.Limiter {
+	if kbps <= 0 {
+		return nil
+	}
+	bytesPerSec := kbps * 1024
+	burst := bytesPerSec
+	if burst < rateLimitBurst {
+		burst = rateLimitBurst
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+}
+
+// rateLimitRelay 在入站对外地址上监听，将连接转发到仅监听本机的 xray 入站，
+// 两个方向分别经共享令牌桶限速，即上限作用于所有经转发的入站（本地入站与局域网入站）而非单个连接。
+type rateLimitRelay struct {
+	opts     RateLimitOptions
+	upload   *rate.Limiter
+	download *rate.Limiter
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	conns     map[net.Conn]struct{}
+}
+
+// startRateLimitRelay 监听 listenAddr 并开始转发到 backendAddr。
+func startRateLimitRelay(listenAddr, backendAddr string, opts RateLimitOptions) (*rateLimitRelay, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &rateLimitRelay{
+		opts:     opts,
+		upload:   newLimiter(opts.UploadKBps),
+		download: newLimiter(opts.DownloadKBps),
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	if err := r.listen(listenAddr, backendAddr); err != nil {
+		cancel()
+		return nil, err
+	}
+	return r, nil
+}
+
+// listen 再监听一个入站地址并转发到 backendAddr，与已有入站共用令牌桶。
+func (r *rateLimitRelay) listen(listenAddr, backendAddr string) error {
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("Xray: 限速入站监听 %s 失败: %w", listenAddr, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx.Err() != nil {
+		_ = ln.Close()
+		return fmt.Errorf("Xray: 限速入站已关闭")
+	}
+	r.listeners = append(r.listeners, ln)
+	r.wg.Add(1)
+	go r.acceptLoop(ln, backendAddr)
+	return nil
+}
+
+func (r *rateLimitRelay) acceptLoop(ln net.Listener, backendAddr string) {
+	defer r.wg.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.serve(conn, backendAddr)
+		}()
+	}
+}
+
+func (r *rateLimitRelay) serve(client net.Conn, backendAddr string) {
+	var dialer net.Dialer
+	backend, err := dialer.DialContext(r.ctx, "tcp", backendAddr)
+	if err != nil {
+		_ = client.Close()
+		return
+	}
+	if !r.track(client, backend) {
+		return
+	}
+	defer r.untrack(client, backend)
+	source, clientAddr := backend.LocalAddr().String(), client.RemoteAddr().String()
+	relaySources.Store(source, clientAddr)
+	defer time.AfterFunc(relaySourceTTL, func() { relaySources.CompareAndDelete(source, clientAddr) })
+
+	upload, download := r.upload, r.download
+	if r.opts.LANOnly && isLoopbackConn(client) {
+		upload, download = nil, nil
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		r.copy(backend, client, upload)
+		closeWrite(backend)
+		done <- struct{}{}
+	}()
+	go func() {
+		r.copy(client, backend, download)
+		closeWrite(client)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// copy 从 src 读取写入 dst；limiter 非 nil 时每次写入前等待相应令牌。
+func (r *rateLimitRelay) copy(dst io.Writer, src io.Reader, limiter *rate.Limiter) {
+	if limiter == nil {
+		_, _ = io.Copy(dst, src)
+		return
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if werr := limiter.WaitN(r.ctx, n); werr != nil {
+				return
+			}
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// track 登记连接以便 Close 时断开；relay 已关闭时直接关闭连接并返回 false。
+func (r *rateLimitRelay) track(conns ...net.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx.Err() != nil {
+		for _, c := range conns {
+			_ = c.Close()
+		}
+		return false
+	}
+	for _, c := range conns {
+		r.conns[c] = struct{}{}
+	}
+	return true
+}
+
+func (r *rateLimitRelay) untrack(conns ...net.Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range conns {
+		_ = c.Close()
+		delete(r.conns, c)
+	}
+}
+
+// Close 停止监听并断开所有转发中的连接。
+func (r *rateLimitRelay) Close() error {
+	r.mu.Lock()
+	r.cancel()
+	for c := range r.conns {
+		_ = c.Close()
+	}
+	listeners := r.listeners
+	r.mu.Unlock()
+	var err error
+	for _, ln := range listeners {
+		if cerr := ln.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	r.wg.Wait()
+	return err
+}
+
+func isLoopbackConn(conn net.Conn) bool {
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	return ok && addr.IP.IsLoopback()
+}
+
+// closeWrite 半关闭写方向，让对端读到 EOF，同时保留另一方向的数据传输。
+func closeWrite(conn net.Conn) {
+	if tc, ok := conn.(*net.TCPConn); ok {
+		_ = tc.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,41 @@
+package xray
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// readyDialTimeout 就绪检测中单次连接的超时
+	readyDialTimeout = 300 * time.Millisecond
+	// readyRetryInterval 就绪检测的重试间隔
+	readyRetryInterval = 50 * time.Millisecond
+)
+
+// WaitInboundReady 反复连接入站地址，直到可以建立 TCP 连接或超过 timeout。
+// core.Instance.Start 返回时监听可能尚未就绪，首批请求会失败；启动后应先等待入站可连接再报告已连接。
+// 监听所有地址（0.0.0.0、::）时改为连接本机回环地址。
+func WaitInboundReady(addr string, timeout time.Duration) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("Xray: 入站地址无效: %w", err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	addr = net.JoinHostPort(host, port)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, readyDialTimeout)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		if time.Now().Add(readyRetryInterval).After(deadline) {
+			return fmt.Errorf("Xray: 入站 %s 在 %s 内未就绪: %w", addr, timeout, err)
+		}
+		time.Sleep(readyRetryInterval)
+	}
+}
@@ -0,0 +1,142 @@
+package xray
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	xnet "github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// RouteMatch 路由规则测试结果：目标命中的规则及其出站。
+type RouteMatch struct {
+	Target    string // 规范化后的测试目标（域名或 IP）
+	IsIP      bool   // 目标是否为 IP
+	RuleIndex int    // 命中规则的序号（从 1 开始，与生成的 xray 配置中 routing.rules 顺序一致）
+	Entry     string // 命中的具体条目，如 domain:example.com、geoip:cn；默认规则为空
+	Outbound  string // 出站标签：proxy、direct 或 block
+}
+
+// MatchRoute 用 buildRoutingRules 生成、经 xray-core 编译的同一套路由规则判断目标走向。
+// target 可为域名、IP 或带协议头/端口/路径的地址。路由 domainStrategy 为 AsIs，
+// 与实际运行一致：域名目标不解析为 IP，只匹配域名类规则。
+// geosite:/geoip: 规则从 xray 资源目录加载数据文件，缺失时返回错误。
+func MatchRoute(routing *RoutingOptions, target string) (*RouteMatch, error) {
+	host, err := routeTestHost(target)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &routeTestContext{}
+	match := &RouteMatch{Target: host}
+	if ip := net.ParseIP(host); ip != nil {
+		ctx.ip = ip
+		match.IsIP = true
+	} else {
+		ctx.domain = strings.ToLower(host)
+		match.Target = ctx.domain
+	}
+
+	for i, raw := range buildRoutingRules(routing) {
+		rule, _ := raw.(map[string]interface{})
+		ok, err := routeRuleMatches(rule, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Xray: 编译第 %d 条路由规则失败: %w", i+1, err)
+		}
+		if !ok {
+			continue
+		}
+		match.RuleIndex = i + 1
+		match.Outbound, _ = rule["outboundTag"].(string)
+		match.Entry = matchedRouteEntry(rule, ctx)
+		return match, nil
+	}
+	// 最后一条最终规则匹配所有 TCP/UDP 流量，正常不会走到这里；xray 未命中任何规则时使用第一个出站
+	match.Outbound = "proxy"
+	return match, nil
+}
+
+// routeTestHost 从用户输入中取出主机部分：去除协议头、路径、端口与 IPv6 方括号。
+func routeTestHost(target string) (string, error) {
+	s := strings.TrimSpace(target)
+	if s == "" {
+		return "", fmt.Errorf("Xray: 请输入域名或 IP")
+	}
+	if strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil && u.Hostname() != "" {
+			return u.Hostname(), nil
+		}
+	}
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		s = s[:i]
+	}
+	if h, _, err := net.SplitHostPort(s); err == nil {
+		s = h
+	}
+	s = strings.TrimSuffix(strings.Trim(s, "[]"), ".")
+	if s == "" {
+		return "", fmt.Errorf("Xray: %q 不是有效的域名或 IP", target)
+	}
+	return s, nil
+}
+
+// routeRuleMatches 将规则交给 xray-core 解析并编译为路由条件后判断是否命中。
+func routeRuleMatches(rule map[string]interface{}, ctx routing.Context) (bool, error) {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return false, err
+	}
+	parsed, err := conf.ParseRule(data)
+	if err != nil {
+		return false, err
+	}
+	cond, err := parsed.BuildCondition()
+	if err != nil {
+		return false, err
+	}
+	return cond.Apply(ctx), nil
+}
+
+// matchedRouteEntry 在命中的规则中逐条测试 domain/ip 条目，返回第一个命中的条目。
+func matchedRouteEntry(rule map[string]interface{}, ctx routing.Context) string {
+	for _, field := range []string{"domain", "ip"} {
+		entries, _ := rule[field].([]string)
+		for _, entry := range entries {
+			single := map[string]interface{}{"type": "field", field: []string{entry}, "outboundTag": rule["outboundTag"]}
+			if ok, err := routeRuleMatches(single, ctx); err == nil && ok {
+				return entry
+			}
+		}
+	}
+	return ""
+}
+
+// routeTestContext 规则测试用的路由上下文：TCP 连接，目标为单个域名或 IP。
+type routeTestContext struct {
+	domain string
+	ip     net.IP
+}
+
+func (c *routeTestContext) GetInboundTag() string            { return "" }
+func (c *routeTestContext) GetSourceIPs() []xnet.IP          { return nil }
+func (c *routeTestContext) GetSourcePort() xnet.Port         { return 0 }
+func (c *routeTestContext) GetLocalIPs() []xnet.IP           { return nil }
+func (c *routeTestContext) GetLocalPort() xnet.Port          { return 0 }
+func (c *routeTestContext) GetTargetDomain() string          { return c.domain }
+func (c *routeTestContext) GetNetwork() xnet.Network         { return xnet.Network_TCP }
+func (c *routeTestContext) GetProtocol() string              { return "" }
+func (c *routeTestContext) GetUser() string                  { return "" }
+func (c *routeTestContext) GetVlessRoute() xnet.Port         { return 0 }
+func (c *routeTestContext) GetAttributes() map[string]string { return nil }
+func (c *routeTestContext) GetSkipDNSResolve() bool          { return true }
+func (c *routeTestContext) GetTargetPort() xnet.Port         { return 443 }
+
+func (c *routeTestContext) GetTargetIPs() []xnet.IP {
+	if c.ip == nil {
+		return nil
+	}
+	return []xnet.IP{c.ip}
+}
@@ -0,0 +1,78 @@
+package xray
+
+import (
+	"fmt"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+)
+
+// DomainStrategies sockopt.domainStrategy 的可选值，空为 xray 默认（AsIs，交由系统解析）。
+var DomainStrategies = []string{
+	"AsIs", "UseIP", "UseIPv4", "UseIPv6", "UseIPv4v6", "UseIPv6v4",
+	"ForceIP", "ForceIPv4", "ForceIPv6", "ForceIPv4v6", "ForceIPv6v4",
+}
+
+// ValidateDialOptions 校验拨号选项的取值范围。
+func ValidateDialOptions(opts model.DialOptions) error {
+	var problems []string
+	if opts.TCPKeepAliveInterval < 0 || opts.TCPKeepAliveInterval > 3600 {
+		problems = append(problems, "TCP 保活间隔需在 0–3600 秒之间")
+	}
+	if opts.TCPUserTimeout < 0 || opts.TCPUserTimeout > 600000 {
+		problems = append(problems, "TCP 超时需在 0–600000 毫秒之间")
+	}
+	if opts.HappyEyeballsDelay < 0 || opts.HappyEyeballsDelay > 10000 {
+		problems = append(problems, "Happy Eyeballs 等待需在 0–10000 毫秒之间")
+	}
+	if opts.DomainStrategy != "" && !isDomainStrategy(opts.DomainStrategy) {
+		problems = append(problems, fmt.Sprintf("不支持的域名解析策略: %s", opts.DomainStrategy))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("拨号选项无效: %s", strings.Join(problems, "；"))
+	}
+	return nil
+}
+
+func isDomainStrategy(s string) bool {
+	for _, v := range DomainStrategies {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDialOptions 将节点的拨号选项写入出站 streamSettings.sockopt，未设置时不改动出站。
+func applyDialOptions(outbound map[string]interface{}, opts model.DialOptions) {
+	if opts.SockoptZero() {
+		return
+	}
+	streamSettings, ok := outbound["streamSettings"].(map[string]interface{})
+	if !ok {
+		streamSettings = map[string]interface{}{}
+		outbound["streamSettings"] = streamSettings
+	}
+	sockopt, ok := streamSettings["sockopt"].(map[string]interface{})
+	if !ok {
+		sockopt = map[string]interface{}{}
+		streamSettings["sockopt"] = sockopt
+	}
+	if opts.TCPFastOpen {
+		sockopt["tcpFastOpen"] = true
+	}
+	if opts.TCPKeepAliveInterval > 0 {
+		sockopt["tcpKeepAliveInterval"] = opts.TCPKeepAliveInterval
+	}
+	if opts.TCPUserTimeout > 0 {
+		sockopt["tcpUserTimeout"] = opts.TCPUserTimeout
+	}
+	if opts.DomainStrategy != "" {
+		sockopt["domainStrategy"] = opts.DomainStrategy
+	}
+	if opts.HappyEyeballsDelay > 0 {
+		sockopt["happyEyeballs"] = map[string]interface{}{
+			"tryDelayMs": opts.HappyEyeballsDelay,
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package xray
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xtls/xray-core/infra/conf"
+	"myproxy.com/p/internal/model"
+)
+
+// vmessNetworks xray 支持的 VMess 传输协议
+var vmessNetworks = map[string]bool{
+	"": true, "tcp": true, "kcp": true, "ws": true, "websocket": true,
+	"h2": true, "http": true, "quic": true, "grpc": true, "httpupgrade": true,
+}
+
+// ssMethods xray 支持的 Shadowsocks 加密方式
+var ssMethods = map[string]bool{
+	"aes-128-gcm": true, "aes-256-gcm": true,
+	"chacha20-poly1305": true, "chacha20-ietf-poly1305": true,
+	"xchacha20-poly1305": true, "xchacha20-ietf-poly1305": true,
+	"2022-blake3-aes-128-gcm": true, "2022-blake3-aes-256-gcm": true, "2022-blake3-chacha20-poly1305": true,
+	"none": true, "plain": true,
+}
+
+// ValidateNode 在 model.NodeFieldProblems 的基础上按协议能力矩阵校验节点，在生成 xray 配置前调用，返回精确到字段的错误信息。
+// 参数：
+//   - server: 服务器配置
+//
+// 返回：校验失败时返回 *model.NodeValidationError，通过返回 nil
+func ValidateNode(server *model.Node) error {
+	if server == nil {
+		return fmt.Errorf("Xray: 节点为空")
+	}
+
+	problems := model.NodeFieldProblems(server)
+	switch server.ProtocolType {
+	case "socks5", "trojan":
+		// 必需字段已由 model.NodeFieldProblems 检查
+
+	case "vmess":
+		if !vmessNetworks[server.VMessNetwork] {
+			problems = append(problems, fmt.Sprintf("不支持的传输协议: %s", server.VMessNetwork))
+		}
+		switch server.VMessNetwork {
+		case "ws", "websocket", "httpupgrade":
+			if server.VMessPath == "" {
+				problems = append(problems, fmt.Sprintf("%s 传输缺少 path", server.VMessNetwork))
+			}
+		case "grpc":
+			if server.VMessPath == "" {
+				problems = append(problems, "grpc 传输缺少 serviceName（path）")
+			}
+		case "h2", "http":
+			if server.VMessTLS != "tls" {
+				problems = append(problems, "h2 传输需要启用 TLS")
+			}
+		}
+		if server.VMessTLS != "" && server.VMessTLS != "tls" && server.VMessTLS != "none" {
+			problems = append(problems, fmt.Sprintf("不支持的 TLS 设置: %s", server.VMessTLS))
+		}
+
+	case "ss":
+		if server.SSMethod != "" && !ssMethods[strings.ToLower(server.SSMethod)] {
+			problems = append(problems, fmt.Sprintf("xray 不支持加密方式 %s", server.SSMethod))
+		}
+		if server.SSPlugin != "" {
+			problems = append(problems, fmt.Sprintf("xray 不支持 Shadowsocks 插件 %s", server.SSPlugin))
+		}
+
+	default:
+		problems = append(problems, fmt.Sprintf("不支持的协议类型: %s", server.ProtocolType))
+	}
+
+	if err := ValidateDialOptions(server.DialOptions); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &model.NodeValidationError{
+		NodeName: server.Name,
+		Protocol: server.ProtocolType,
+		Problems: problems,
+	}
+}
+
+// ConfigValidationError xray 配置未通过加载器校验时返回。Stage 为失败的阶段（解析或构建），
+// Problems 为 xray 错误链逐层拆开后的说明，最外层在前、最具体的原因在后。
+type ConfigValidationError struct {
+	Stage    string
+	Problems []string
+	Err      error
+}
+
+func (e *ConfigValidationError) Error() string {
+	return fmt.Sprintf("Xray: %s配置失败: %v", e.Stage, e.Err)
+}
+
+func (e *ConfigValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateConfigJSON 用 xray 的配置加载器解析并构建完整的配置 JSON（不创建实例、不监听入站），
+// 用于在启动或重启前发现无效的字段。校验失败时返回 *ConfigValidationError。
+func ValidateConfigJSON(configJSON []byte) error {
+	var config conf.Config
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return newConfigValidationError("解析", err)
+	}
+	if _, err := config.Build(); err != nil {
+		return newConfigValidationError("构建", err)
+	}
+	return nil
+}
+
+// newConfigValidationError 按 xray 错误链的分隔符「 > 」拆出每一层的说明。
+func newConfigValidationError(stage string, err error) *ConfigValidationError {
+	var problems []string
+	for _, part := range strings.Split(err.Error(), " > ") {
+		if part = strings.TrimSpace(part); part != "" {
+			problems = append(problems, part)
+		}
+	}
+	return &ConfigValidationError{Stage: stage, Problems: problems, Err: err}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -11,6 +13,7 @@ import (
 	_ "github.com/xtls/xray-core/main/distro/all"
 
 	"github.com/xtls/xray-core/app/log"
+	"github.com/xtls/xray-core/common/platform"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/infra/conf"
@@ -96,24 +99,8 @@ func (lw *logWriter) processLogLine(line string) {
 		return
 	}
 
-	// 解析日志级别（xray-core 的日志格式通常包含级别信息）
-	level := "INFO"
-	message := line
-
-	// 尝试解析常见的日志格式
-	upperLine := strings.ToUpper(line)
-	if strings.Contains(upperLine, "[ERROR]") || strings.Contains(upperLine, " ERROR ") {
-		level = "ERROR"
-	} else if strings.Contains(upperLine, "[WARN]") || strings.Contains(upperLine, " WARN ") {
-		level = "WARN"
-	} else if strings.Contains(upperLine, "[DEBUG]") || strings.Contains(upperLine, " DEBUG ") {
-		level = "DEBUG"
-	} else if strings.Contains(upperLine, "[INFO]") || strings.Contains(upperLine, " INFO ") {
-		level = "INFO"
-	}
-
-	// 调用回调函数
-	lw.callback(level, message)
+	// 解析日志级别并调用回调函数
+	lw.callback(detectLogLevel(line), line)
 }
 
 // shouldFilterLog 判断是否应该过滤掉这条日志
@@ -145,16 +132,7 @@ type xrayInterceptorWriter struct {
 
 func (w *xrayInterceptorWriter) Write(s string) error {
 	if w.callback != nil && strings.TrimSpace(s) != "" {
-		level := "INFO"
-		upper := strings.ToUpper(s)
-		if strings.Contains(upper, "ERROR") {
-			level = "ERROR"
-		} else if strings.Contains(upper, "WARN") {
-			level = "WARN"
-		} else if strings.Contains(upper, "DEBUG") {
-			level = "DEBUG"
-		}
-		w.callback(level, s)
+		w.callback(detectLogLevel(s), s)
 	}
 	return nil
 }
@@ -193,10 +171,15 @@ type XrayInstance struct {
 	instance    *core.Instance
 	ctx         context.Context
 	cancel      context.CancelFunc
-	isRunning   bool        // 运行状态
-	port        int         // 监听端口
-	logWriter   *logWriter  // 日志写入器
-	logCallback LogCallback // 日志回调函数
+	isRunning   bool            // 运行状态
+	port        int             // 监听端口
+	logWriter   *logWriter      // 日志写入器
+	logCallback LogCallback     // 日志回调函数
+	proxyTags   []string         // 代理出站 tag（单节点为 "proxy"，均衡组为 proxy-0…），用于汇总流量
+	relay       *rateLimitRelay  // 入站限速转发，未限速时为 nil
+	external    *externalProcess // 以外部 xray 可执行文件运行时非 nil，此时 instance 为 nil
+	forwarder   *socksForwarder  // 以内置 SOCKS5 转发运行时非 nil，此时 instance 为 nil
+	apiAddr     string           // gRPC API 地址，配置未开启 API 时为空
 }
 
 // NewXrayInstanceFromJSON 从 JSON 配置创建 xray-core 实例
@@ -205,8 +188,9 @@ func NewXrayInstanceFromJSON(configJSON []byte) (*XrayInstance, error) {
 }
 
 // NewXrayInstanceFromJSONWithCallback 从 JSON 配置创建 xray-core 实例，并设置日志回调。
-// 日志通过 registerInterceptorHandler 劫持，由 callback 落盘、展示、解析访问记录。
+// 日志通过 registerInterceptorHandler 劫持，重复的警告、错误合并后由 callback 落盘、展示、解析访问记录。
 func NewXrayInstanceFromJSONWithCallback(configJSON []byte, logCallback LogCallback) (*XrayInstance, error) {
+	logCallback = dedupLogCallback(logCallback)
 	registerInterceptorHandler(logCallback)
 
 	var config conf.Config
@@ -214,6 +198,19 @@ func NewXrayInstanceFromJSONWithCallback(configJSON []byte, logCallback LogCallb
 		return nil, fmt.Errorf("Xray: 解析配置失败: %w", err)
 	}
 
+	var proxyTags []string
+	for _, ob := range config.OutboundConfigs {
+		if isProxyOutboundTag(ob.Tag) {
+			proxyTags = append(proxyTags, ob.Tag)
+		}
+	}
+	var apiPort int
+	for _, ib := range config.InboundConfigs {
+		if ib.Tag == APITag && ib.PortList != nil && len(ib.PortList.Range) > 0 {
+			apiPort = int(ib.PortList.Range[0].From)
+		}
+	}
+
 	pbConfig, err := config.Build()
 	if err != nil {
 		return nil, fmt.Errorf("Xray: 构建配置失败: %w", err)
@@ -237,6 +234,8 @@ func NewXrayInstanceFromJSONWithCallback(configJSON []byte, logCallback LogCallb
 		port:        0,
 		logWriter:   logWriter,
 		logCallback: logCallback,
+		proxyTags:   proxyTags,
+		apiAddr:     apiAddress(apiPort),
 	}
 
 	return xi, nil
@@ -255,6 +254,20 @@ func (xi *XrayInstance) Start() error {
 	if xi.isRunning {
 		return fmt.Errorf("Xray: xray实例已经在运行")
 	}
+	if xi.external != nil {
+		if err := xi.external.start(); err != nil {
+			return err
+		}
+		xi.isRunning = true
+		return nil
+	}
+	if xi.forwarder != nil {
+		if err := xi.forwarder.start(); err != nil {
+			return err
+		}
+		xi.isRunning = true
+		return nil
+	}
 	if err := xi.instance.Start(); err != nil {
 		return fmt.Errorf("Xray: 启动失败: %w", err)
 	}
@@ -269,15 +282,60 @@ func (xi *XrayInstance) Stop() error {
 	}
 	xi.isRunning = false
 	xi.cancel()
+	if xi.relay != nil {
+		_ = xi.relay.Close()
+		xi.relay = nil
+	}
 	if xi.instance != nil {
 		xi.instance.Close()
 	}
+	if xi.external != nil {
+		xi.external.stop()
+	}
+	if xi.forwarder != nil {
+		xi.forwarder.stop()
+	}
+	return nil
+}
+
+// StartRateLimitedInbound 在 listenAddr 上启动限速转发，连接转发到 xray 入站实际监听的 backendAddr，
+// 随实例停止而关闭。须在 Start 之后调用。
+func (xi *XrayInstance) StartRateLimitedInbound(listenAddr, backendAddr string, opts RateLimitOptions) error {
+	if !xi.isRunning {
+		return fmt.Errorf("Xray: xray实例未运行")
+	}
+	if xi.relay != nil {
+		return fmt.Errorf("Xray: 限速入站已启动")
+	}
+	relay, err := startRateLimitRelay(listenAddr, backendAddr, opts)
+	if err != nil {
+		return err
+	}
+	xi.relay = relay
 	return nil
 }
 
+// AddRateLimitedInbound 在已启动的限速转发上再监听一个入站（如局域网入站），与其共用带宽上限。
+func (xi *XrayInstance) AddRateLimitedInbound(listenAddr, backendAddr string) error {
+	if xi.relay == nil {
+		return fmt.Errorf("Xray: 限速入站未启动")
+	}
+	return xi.relay.listen(listenAddr, backendAddr)
+}
+
 // IsRunning 检查 xray 实例是否在运行
 func (xi *XrayInstance) IsRunning() bool {
-	return xi.isRunning && xi.instance != nil
+	return xi.isRunning && (xi.instance != nil || xi.external != nil || xi.forwarder != nil)
+}
+
+// IsExternal 是否以外部 xray 可执行文件运行。
+func (xi *XrayInstance) IsExternal() bool {
+	return xi.external != nil
+}
+
+// IsBuiltinForward 是否以内置 SOCKS5 转发运行（未启动 xray）。
+func (xi *XrayInstance) IsBuiltinForward() bool {
+	return xi.forwarder != nil
 }
 
 // SetPort 设置监听端口
@@ -290,33 +348,70 @@ func (xi *XrayInstance) GetPort() int {
 	return xi.port
 }
 
-// GetInstance 获取底层 xray-core 实例（用于高级操作）
+// GetInstance 获取底层 xray-core 实例（用于高级操作）；以外部可执行文件或内置转发运行时为 nil
 func (xi *XrayInstance) GetInstance() *core.Instance {
 	return xi.instance
 }
 
 // TrafficStats 返回当前出站代理的流量统计（上传、下载字节数）。
-// 需在配置中启用 "stats": {"enabled": true}，且出站 tag 为 "proxy"。
+// 需在配置中启用 "stats": {"enabled": true}，且出站 tag 为 "proxy"（均衡组为 proxy-0、proxy-1…）。
 func (xi *XrayInstance) TrafficStats() (upload, download int64) {
-	if !xi.IsRunning() || xi.instance == nil {
+	if !xi.IsRunning() {
 		return 0, 0
 	}
+	if xi.forwarder != nil {
+		return xi.forwarder.traffic()
+	}
+	tags := xi.proxyTags
+	if len(tags) == 0 {
+		tags = []string{"proxy"}
+	}
+	if xi.external != nil {
+		traffic := xi.external.queryTraffic()
+		for _, tag := range tags {
+			upload += traffic[tag][0]
+			download += traffic[tag][1]
+		}
+		return upload, download
+	}
 	mgr, ok := xi.instance.GetFeature(stats.ManagerType()).(stats.Manager)
 	if !ok || mgr == nil {
 		return 0, 0
 	}
-	// 出站 tag 与 CreateOutboundFromServer 中一致，路径格式见 xray 文档
-	const uplinkName = "outbound>>>proxy>>>traffic>>>uplink"
-	const downlinkName = "outbound>>>proxy>>>traffic>>>downlink"
-	if c := mgr.GetCounter(uplinkName); c != nil {
-		upload = c.Value()
-	}
-	if c := mgr.GetCounter(downlinkName); c != nil {
-		download = c.Value()
+	// 出站 tag 与 CreateXrayConfig 中一致，路径格式见 xray 文档；均衡组时汇总组内所有出站
+	for _, tag := range tags {
+		if c := mgr.GetCounter("outbound>>>" + tag + ">>>traffic>>>uplink"); c != nil {
+			upload += c.Value()
+		}
+		if c := mgr.GetCounter("outbound>>>" + tag + ">>>traffic>>>downlink"); c != nil {
+			download += c.Value()
+		}
 	}
 	return upload, download
 }
 
+// OutboundTraffic 返回指定出站（如 "direct"）累计的上传与下载字节数之和；代理未运行时为 0。
+func (xi *XrayInstance) OutboundTraffic(tag string) int64 {
+	if !xi.IsRunning() || xi.forwarder != nil {
+		return 0
+	}
+	if xi.external != nil {
+		v := xi.external.queryTraffic()[tag]
+		return v[0] + v[1]
+	}
+	mgr, ok := xi.instance.GetFeature(stats.ManagerType()).(stats.Manager)
+	if !ok || mgr == nil {
+		return 0
+	}
+	var total int64
+	for _, dir := range []string{"uplink", "downlink"} {
+		if c := mgr.GetCounter("outbound>>>" + tag + ">>>traffic>>>" + dir); c != nil {
+			total += c.Value()
+		}
+	}
+	return total
+}
+
 // CreateOutboundFromServer 根据服务器配置创建 xray 出站配置
 func CreateOutboundFromServer(server *model.Node) (map[string]interface{}, error) {
 	var outbound map[string]interface{}
@@ -462,6 +557,8 @@ func CreateOutboundFromServer(server *model.Node) (map[string]interface{}, error
 		return nil, fmt.Errorf("Xray: 不支持的协议类型: %s", server.ProtocolType)
 	}
 
+	applyDialOptions(outbound, server.DialOptions)
+
 	return outbound, nil
 }
 
@@ -554,8 +651,77 @@ func buildSSStreamSettings(server *model.Node) map[string]interface{} {
 
 // RoutingOptions 路由相关配置（直连列表、直连列表是否走代理等）。
 type RoutingOptions struct {
-	DirectRoutes         []string // 用户配置的直连列表（domain:xxx 或 ip/cidr）
-	DirectRoutesUseProxy bool     // true：直连列表走代理；false：走直连
+	DirectRoutes         []string         // 用户配置的直连列表（domain:xxx 或 ip/cidr）
+	DirectRoutesUseProxy bool             // true：直连列表走代理；false：走直连
+	Balancer             *BalancerOptions // 非 nil 且节点数 ≥ 2 时以负载均衡组代替单个代理出站
+	BypassLanAndCN       bool             // true：局域网与中国大陆（geoip:private、geoip:cn、geosite:cn）直连
+	Fragment             *FragmentOptions // 非 nil 时代理出站经分片出站拨号，拆分 TLS ClientHello
+	Policy               *PolicyOptions   // 非 nil 时覆盖 xray 默认的连接超时
+	API                  *APIOptions      // 非 nil 时开启仅监听本机的 gRPC API 入站
+	ExtraInbounds        []ExtraInbound   // 本地混合入站之外的入站（如供局域网设备使用的 HTTP 入站）
+	FinalOutbound        string           // 未命中其他规则的流量走向：FinalOutboundProxy（默认）、FinalOutboundDirect 或 FinalOutboundBlock
+	DisableMixedUDP      bool             // true：本地混合入站与额外入站不接受 SOCKS5 UDP（入站限速只转发 TCP，UDP 中继无法经过转发）
+}
+
+// 最终规则（相当于 Clash 的 MATCH / FINAL）的出站
+const (
+	FinalOutboundProxy  = "proxy"  // 走代理
+	FinalOutboundDirect = "direct" // 直连
+	FinalOutboundBlock  = "block"  // 阻止（blackhole 出站）
+)
+
+// finalOutbound 返回最终规则的出站标签，未设置或无效时走代理。
+func finalOutbound(routing *RoutingOptions) string {
+	if routing != nil && (routing.FinalOutbound == FinalOutboundDirect || routing.FinalOutbound == FinalOutboundBlock) {
+		return routing.FinalOutbound
+	}
+	return FinalOutboundProxy
+}
+
+// 额外入站的协议
+const (
+	ExtraInboundHTTP  = "http"  // 仅 HTTP 代理
+	ExtraInboundMixed = "mixed" // SOCKS5 与 HTTP 同端口，与本地混合入站相同
+)
+
+// ExtraInbound 额外入站：与本地混合入站使用同一份路由与入站认证，tag 用于在访问记录中区分来源。
+type ExtraInbound struct {
+	Tag      string
+	Listen   string
+	Port     int
+	Protocol string // ExtraInboundHTTP 或 ExtraInboundMixed
+}
+
+// geoAssetFiles 「绕过局域网与中国大陆」规则依赖的 xray 资源文件
+var geoAssetFiles = []string{"geoip.dat", "geosite.dat"}
+
+// MissingGeoAssets 返回 xray 资源目录（ConfigureEnvironment 设置的目录，未设置时为可执行文件所在目录）中缺失的 geo 数据文件。
+// 缺少时 geoip:/geosite: 规则会导致 xray 启动失败。
+func MissingGeoAssets() []string {
+	var missing []string
+	for _, file := range geoAssetFiles {
+		if _, err := os.Stat(platform.GetAssetLocation(file)); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
+
+// HasGeoAssets 判断目录中是否齐全 geo 数据文件。
+func HasGeoAssets(dir string) bool {
+	for _, file := range geoAssetFiles {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// InboundAuth 本地混合入站的认证凭据；xray 的 socks 入站开启 password 认证后，
+// 同端口的 HTTP 代理请求也要求相同账号（Basic 认证）。
+type InboundAuth struct {
+	Username string
+	Password string
 }
 
 // CreateXrayConfig 创建完整的 xray 配置。
@@ -564,8 +730,9 @@ type RoutingOptions struct {
 //   - listenHost: 入站 bind 地址，如 database.LocalMixedInboundListenHost 或 "0.0.0.0"（空则回退为 127.0.0.1）
 //   - server: 服务器配置，用于创建出站配置
 //   - logFilePath: 日志文件路径（可选，为空则不设置）
-//   - routing: 路由选项（可选，nil 则仅使用内置规则）
-func CreateXrayConfig(localPort int, listenHost string, server *model.Node, logFilePath string, routing *RoutingOptions) ([]byte, error) {
+//   - routing: 路由选项（可选，nil 则仅使用内置规则）；routing.Balancer 有效时忽略 server，改用均衡组
+//   - auth: 入站认证（可选，nil 或密码为空则不认证）
+func CreateXrayConfig(localPort int, listenHost string, server *model.Node, logFilePath string, routing *RoutingOptions, auth *InboundAuth) ([]byte, error) {
 	if localPort == 0 {
 		localPort = database.DefaultMixedInboundPort
 	}
@@ -574,21 +741,55 @@ func CreateXrayConfig(localPort int, listenHost string, server *model.Node, logF
 	}
 
 	// 创建入站配置：Xray Socks 入站同时接受 SOCKS5 与 HTTP（同一端口）
+	inboundSettings := map[string]interface{}{
+		"auth": "noauth",
+		"udp":  routing == nil || !routing.DisableMixedUDP,
+	}
+	if auth != nil && auth.Username != "" && auth.Password != "" {
+		inboundSettings["auth"] = "password"
+		inboundSettings["accounts"] = []map[string]string{
+			{
+				"user": auth.Username,
+				"pass": auth.Password,
+			},
+		}
+	}
 	inbound := map[string]interface{}{
 		"tag":      "mixed-in",
 		"listen":   listenHost,
 		"port":     localPort,
 		"protocol": "socks",
-		"settings": map[string]interface{}{
-			"auth": "noauth",
-			"udp":  true,
-		},
+		"settings": inboundSettings,
 	}
 
-	// 创建出站配置
-	outbound, err := CreateOutboundFromServer(server)
-	if err != nil {
-		return nil, fmt.Errorf("Xray: 创建出站配置失败: %w", err)
+	inbounds := []interface{}{inbound}
+	if routing != nil {
+		for _, extra := range routing.ExtraInbounds {
+			inbounds = append(inbounds, buildExtraInbound(extra, auth, !routing.DisableMixedUDP))
+		}
+	}
+
+	// 创建出站配置：均衡组为每个节点各建一个出站，否则只有单个 "proxy" 出站
+	var balancer *BalancerOptions
+	if routing != nil && routing.Balancer != nil && len(routing.Balancer.Nodes) >= 2 {
+		balancer = routing.Balancer
+	}
+	var proxyOutbounds []interface{}
+	if balancer != nil {
+		outbounds, err := buildBalancerOutbounds(balancer)
+		if err != nil {
+			return nil, fmt.Errorf("Xray: 创建均衡组出站配置失败: %w", err)
+		}
+		proxyOutbounds = outbounds
+	} else {
+		outbound, err := CreateOutboundFromServer(server)
+		if err != nil {
+			return nil, fmt.Errorf("Xray: 创建出站配置失败: %w", err)
+		}
+		proxyOutbounds = []interface{}{outbound}
+	}
+	if routing != nil && routing.Fragment != nil {
+		proxyOutbounds = applyFragment(proxyOutbounds, routing.Fragment)
 	}
 
 	// 创建直连出站配置
@@ -614,25 +815,73 @@ func CreateXrayConfig(localPort int, listenHost string, server *model.Node, logF
 			"statsOutboundDownlink": true,
 		},
 	}
+	if routing != nil && routing.Policy != nil {
+		applyPolicy(policyConfig, routing.Policy)
+	}
+
+	outbounds := append(proxyOutbounds, directOutbound)
+	if finalOutbound(routing) == FinalOutboundBlock {
+		outbounds = append(outbounds, map[string]interface{}{
+			"tag":      FinalOutboundBlock,
+			"protocol": "blackhole",
+			"settings": map[string]interface{}{},
+		})
+	}
 
 	// 构建完整配置
 	config := map[string]interface{}{
 		"log":       logConfig,
 		"stats":    map[string]interface{}{},
 		"policy":   policyConfig,
-		"inbounds":  []interface{}{inbound},
-		"outbounds": []interface{}{outbound, directOutbound},
+		"inbounds":  inbounds,
+		"outbounds": outbounds,
 		"routing": map[string]interface{}{
 			"rules":          rules,
 			"domainStrategy": "AsIs",
 		},
 	}
+	if balancer != nil {
+		applyBalancer(config, rules, balancer)
+	}
+	if routing != nil && routing.API != nil {
+		applyAPI(config, routing.API.Port, apiServices)
+	}
 
 	return json.MarshalIndent(config, "", "  ")
 }
 
+// buildExtraInbound 构建额外入站；入站认证与本地混合入站一致，udp 为 false 时 SOCKS5 不接受 UDP。
+func buildExtraInbound(extra ExtraInbound, auth *InboundAuth, udp bool) map[string]interface{} {
+	hasAuth := auth != nil && auth.Username != "" && auth.Password != ""
+	if extra.Protocol == ExtraInboundHTTP {
+		settings := map[string]interface{}{}
+		if hasAuth {
+			settings["accounts"] = []map[string]string{{"user": auth.Username, "pass": auth.Password}}
+		}
+		return map[string]interface{}{
+			"tag":      extra.Tag,
+			"listen":   extra.Listen,
+			"port":     extra.Port,
+			"protocol": "http",
+			"settings": settings,
+		}
+	}
+	settings := map[string]interface{}{"auth": "noauth", "udp": udp}
+	if hasAuth {
+		settings["auth"] = "password"
+		settings["accounts"] = []map[string]string{{"user": auth.Username, "pass": auth.Password}}
+	}
+	return map[string]interface{}{
+		"tag":      extra.Tag,
+		"listen":   extra.Listen,
+		"port":     extra.Port,
+		"protocol": "socks",
+		"settings": settings,
+	}
+}
+
 // buildRoutingRules 构建路由规则。
-// 顺序：本地直连 -> 用户直连列表（根据 directRoutesUseProxy 走直连或代理）-> 默认代理。
+// 顺序：本地直连 -> 用户直连列表（根据 directRoutesUseProxy 走直连或代理）-> 绕过局域网与中国大陆（可选）-> 最终规则（默认代理）。
 func buildRoutingRules(routing *RoutingOptions) []interface{} {
 	rules := []interface{}{}
 
@@ -654,28 +903,40 @@ func buildRoutingRules(routing *RoutingOptions) []interface{} {
 	// 2. 用户直连列表：走直连或走代理（直连列表中的地址也可以走代理）
 	if routing != nil && len(routing.DirectRoutes) > 0 {
 		domains, ips := splitDirectRoutes(routing.DirectRoutes)
-		if len(domains) > 0 || len(ips) > 0 {
-			r := map[string]interface{}{"type": "field"}
-			if len(domains) > 0 {
-				r["domain"] = domains
-			}
-			if len(ips) > 0 {
-				r["ip"] = ips
-			}
-			if routing.DirectRoutesUseProxy {
-				r["outboundTag"] = "proxy"
-			} else {
-				r["outboundTag"] = "direct"
-			}
-			rules = append(rules, r)
+		outboundTag := "direct"
+		if routing.DirectRoutesUseProxy {
+			outboundTag = "proxy"
 		}
+		// 同一条规则内 domain 与 ip 条件须同时满足，因此拆成两条规则
+		if len(domains) > 0 {
+			rules = append(rules, map[string]interface{}{"type": "field", "domain": domains, "outboundTag": outboundTag})
+		}
+		if len(ips) > 0 {
+			rules = append(rules, map[string]interface{}{"type": "field", "ip": ips, "outboundTag": outboundTag})
+		}
+	}
+
+	// 3. 绕过局域网与中国大陆：放在用户列表之后，用户可通过直连列表「走代理」覆盖个别站点
+	if routing != nil && routing.BypassLanAndCN {
+		rules = append(rules,
+			map[string]interface{}{
+				"type":        "field",
+				"domain":      []string{"geosite:cn"},
+				"outboundTag": "direct",
+			},
+			map[string]interface{}{
+				"type":        "field",
+				"ip":          []string{"geoip:private", "geoip:cn"},
+				"outboundTag": "direct",
+			},
+		)
 	}
 
-	// 3. 默认代理（所有其他流量）
+	// 4. 最终规则（所有其他流量）：默认走代理，可设置为直连或阻止
 	rules = append(rules, map[string]interface{}{
 		"type":        "field",
 		"network":     []string{"tcp", "udp"},
-		"outboundTag": "proxy",
+		"outboundTag": finalOutbound(routing),
 	})
 
 	return rules